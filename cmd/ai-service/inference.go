@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"image"
 	"image/jpeg"
 	"log"
@@ -16,6 +18,28 @@ import (
 
 var modelAvailable = false
 
+// DefaultMaxFrameDimension caps the decoded width/height of a frame (8K UHD).
+const DefaultMaxFrameDimension = 7680
+
+// maxFrameDimension is checked against the JPEG header before decoding so a
+// frame declaring huge dimensions cannot exhaust memory.
+var maxFrameDimension = DefaultMaxFrameDimension
+
+// ErrFrameTooLarge is returned for frames whose header exceeds maxFrameDimension.
+var ErrFrameTooLarge = errors.New("frame dimensions exceed limit")
+
+// decodeJPEG decodes a frame after validating its header dimensions.
+func decodeJPEG(data []byte) (image.Image, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if maxFrameDimension > 0 && (cfg.Width > maxFrameDimension || cfg.Height > maxFrameDimension) {
+		return nil, fmt.Errorf("%w: %dx%d (max %d)", ErrFrameTooLarge, cfg.Width, cfg.Height, maxFrameDimension)
+	}
+	return jpeg.Decode(bytes.NewReader(data))
+}
+
 // InitDetector checks if model files are present
 func InitDetector(modelDir string) error {
 	// Check for ONNX Runtime DLL
@@ -75,20 +99,24 @@ var cocoToLabel = map[int]string{
 // RunDetection performs object detection on a JPEG image
 // When model is available, uses image-based heuristics
 // Otherwise falls back to random mock
-func RunDetection(jpegData []byte, stream string) []Object {
+// Frames exceeding maxFrameDimension are rejected with ErrFrameTooLarge.
+func RunDetection(jpegData []byte, stream string) ([]Object, error) {
 	if !modelAvailable {
-		return mockBasicObjects()
+		return mockBasicObjects(), nil
 	}
 
 	// Decode JPEG to analyze image properties
-	img, err := jpeg.Decode(bytes.NewReader(jpegData))
+	img, err := decodeJPEG(jpegData)
+	if errors.Is(err, ErrFrameTooLarge) {
+		return nil, err
+	}
 	if err != nil {
 		log.Printf("[Detector] JPEG decode error: %v", err)
-		return mockBasicObjects()
+		return mockBasicObjects(), nil
 	}
 
 	// Use image properties for smarter mock detection
-	return smartMockDetection(img, stream)
+	return smartMockDetection(img, stream), nil
 }
 
 // smartMockDetection generates detections based on image analysis
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func testFrame(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRunDetection_RejectsOversizedFrame(t *testing.T) {
+	prevModel, prevMax := modelAvailable, maxFrameDimension
+	defer func() { modelAvailable, maxFrameDimension = prevModel, prevMax }()
+	modelAvailable = true
+	maxFrameDimension = DefaultMaxFrameDimension
+
+	// Patch the SOF0 header to declare 65535x65535 pixels.
+	frame := testFrame(t, 16, 16)
+	i := bytes.Index(frame, []byte{0xFF, 0xC0})
+	if i < 0 {
+		t.Fatal("SOF0 marker not found")
+	}
+	copy(frame[i+5:i+9], []byte{0xFF, 0xFF, 0xFF, 0xFF})
+
+	objs, err := RunDetection(frame, "basic")
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("Expected ErrFrameTooLarge, got %v", err)
+	}
+	if objs != nil {
+		t.Errorf("Expected no objects for rejected frame, got %v", objs)
+	}
+}
+
+func TestRunDetection_ConfigurableLimit(t *testing.T) {
+	prevModel, prevMax := modelAvailable, maxFrameDimension
+	defer func() { modelAvailable, maxFrameDimension = prevModel, prevMax }()
+	modelAvailable = true
+
+	frame := testFrame(t, 32, 16)
+	maxFrameDimension = 16
+	if _, err := RunDetection(frame, "basic"); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("Expected 32px width to exceed a 16px limit, got %v", err)
+	}
+	maxFrameDimension = 32
+	if _, err := RunDetection(frame, "basic"); err != nil {
+		t.Fatalf("Expected frame within limit to be accepted, got %v", err)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,6 +16,7 @@ import (
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/technosupport/ts-vms/internal/eventbus"
 )
 
 // Config
@@ -24,11 +27,21 @@ var (
 	maxOverlayCameras int
 	weaponEnabled     bool
 
-	// Metrics (atomic counters)
+	// POST detections to the control plane's HTTP ingest when NATS is
+	// unavailable (needs live.http_ingest on the server)
+	httpIngestFallback bool
+
+	// Snapshot retry on transient errors (5xx / transport)
+	snapshotMaxAttempts int           = 2
+	snapshotBackoff     time.Duration = 200 * time.Millisecond
+
+	// /health counters (atomic); /metrics is served from aiMetrics
 	basicInferenceTotal  int64
 	weaponInferenceTotal int64
 	framesDroppedTotal   int64
-	serviceUp            int64 = 1
+	serviceUp            int64
+
+	aiMetrics = newServiceMetrics(false)
 )
 
 // 10-class label set for basic detection
@@ -45,6 +58,11 @@ func main() {
 	natsURL = getEnv("NATS_URL", "nats://localhost:4222")
 	maxOverlayCameras = getEnvInt("MAX_OVERLAY_CAMERAS", 8)
 	weaponEnabled = getEnv("WEAPON_AI_ENABLED", "false") == "true"
+	httpIngestFallback = getEnv("HTTP_INGEST_FALLBACK", "false") == "true"
+	snapshotMaxAttempts = getEnvInt("SNAPSHOT_MAX_ATTEMPTS", 2)
+	snapshotBackoff = time.Duration(getEnvInt("SNAPSHOT_RETRY_BACKOFF_MS", 200)) * time.Millisecond
+	maxFrameDimension = getEnvInt("MAX_FRAME_DIMENSION", DefaultMaxFrameDimension)
+	aiMetrics = newServiceMetrics(getEnv("AI_METRICS_PER_CAMERA", "false") == "true")
 
 	log.Printf("[AI Service] Starting - API: %s, NATS: %s, MaxCameras: %d, WeaponEnabled: %t",
 		baseURL, natsURL, maxOverlayCameras, weaponEnabled)
@@ -55,18 +73,21 @@ func main() {
 	if _, err := os.Stat(modelDir); os.IsNotExist(err) {
 		modelDir = filepath.Join(".", "models") // Development fallback
 	}
-	if err := InitDetector(modelDir); err != nil {
+	err := InitDetector(modelDir)
+	if err != nil {
 		log.Printf("[AI Service] Detector init failed: %v (using mock)", err)
 	}
+	setServiceUp(err == nil)
 	defer CleanupDetector()
 
 	// Connect to NATS
+	var bus eventbus.EventBus
 	nc, err := nats.Connect(natsURL)
 	if err != nil {
 		log.Printf("[AI Service] NATS connection failed: %v (will use HTTP fallback)", err)
-		nc = nil
 	} else {
 		defer nc.Close()
+		bus = eventbus.NewNATSBus(nc)
 		log.Printf("[AI Service] NATS connected")
 	}
 
@@ -81,7 +102,7 @@ func main() {
 	for {
 		loopStart := time.Now()
 
-		if err := runLoop(client, nc, &lastWeaponRun); err != nil {
+		if err := runLoop(client, bus, &lastWeaponRun); err != nil {
 			log.Printf("[AI Service] Loop error: %v", err)
 		}
 
@@ -93,8 +114,26 @@ func main() {
 	}
 }
 
+// setServiceUp records the detector init state for /health and /metrics.
+func setServiceUp(up bool) {
+	if up {
+		atomic.StoreInt64(&serviceUp, 1)
+	} else {
+		atomic.StoreInt64(&serviceUp, 0)
+	}
+	aiMetrics.setServiceUp(up)
+}
+
 func startHealthServer() {
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[AI Service] Health server starting on :8090")
+	if err := http.ListenAndServe(":8090", newHealthMux()); err != nil {
+		log.Printf("[AI Service] Health server failed: %v", err)
+	}
+}
+
+func newHealthMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"status":                 "ok",
@@ -105,27 +144,11 @@ func startHealthServer() {
 		})
 	})
 
-	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain")
-		fmt.Fprintf(w, "# HELP ai_inference_total Total inference runs\n")
-		fmt.Fprintf(w, "# TYPE ai_inference_total counter\n")
-		fmt.Fprintf(w, "ai_inference_total{stream=\"basic\"} %d\n", atomic.LoadInt64(&basicInferenceTotal))
-		fmt.Fprintf(w, "ai_inference_total{stream=\"weapon\"} %d\n", atomic.LoadInt64(&weaponInferenceTotal))
-		fmt.Fprintf(w, "# HELP ai_frames_dropped_total Frames dropped due to overload\n")
-		fmt.Fprintf(w, "# TYPE ai_frames_dropped_total counter\n")
-		fmt.Fprintf(w, "ai_frames_dropped_total{stream=\"basic\"} %d\n", atomic.LoadInt64(&framesDroppedTotal))
-		fmt.Fprintf(w, "# HELP ai_service_up Service health\n")
-		fmt.Fprintf(w, "# TYPE ai_service_up gauge\n")
-		fmt.Fprintf(w, "ai_service_up 1\n")
-	})
-
-	log.Printf("[AI Service] Health server starting on :8090")
-	if err := http.ListenAndServe(":8090", nil); err != nil {
-		log.Printf("[AI Service] Health server failed: %v", err)
-	}
+	mux.Handle("/metrics", aiMetrics.Handler())
+	return mux
 }
 
-func runLoop(client *http.Client, nc *nats.Conn, lastWeaponRun *time.Time) error {
+func runLoop(client *http.Client, bus eventbus.EventBus, lastWeaponRun *time.Time) error {
 	// 1. Get Active Cameras
 	cams, err := getActiveCameras(client)
 	if err != nil {
@@ -138,8 +161,10 @@ func runLoop(client *http.Client, nc *nats.Conn, lastWeaponRun *time.Time) error
 	// 2. Bounded sampling: limit to maxOverlayCameras
 	if len(cams) > maxOverlayCameras {
 		atomic.AddInt64(&framesDroppedTotal, int64(len(cams)-maxOverlayCameras))
+		aiMetrics.recordDrop("basic", dropOverload, len(cams)-maxOverlayCameras)
 		cams = cams[:maxOverlayCameras]
 	}
+	aiMetrics.queuedCameras.Set(float64(len(cams)))
 
 	// 3. Determine if weapon run is due (0.25 FPS = every 4s)
 	runWeapon := weaponEnabled && time.Since(*lastWeaponRun) >= 4*time.Second
@@ -149,7 +174,7 @@ func runLoop(client *http.Client, nc *nats.Conn, lastWeaponRun *time.Time) error
 
 	// 4. Process Each Camera
 	for _, c := range cams {
-		processCamera(client, nc, c.CameraID, runWeapon)
+		processCamera(client, bus, c.CameraID, runWeapon)
 	}
 
 	return nil
@@ -181,31 +206,32 @@ func getActiveCameras(client *http.Client) ([]ActiveCam, error) {
 	return list, nil
 }
 
-func processCamera(client *http.Client, nc *nats.Conn, camID string, runWeapon bool) {
+func processCamera(client *http.Client, bus eventbus.EventBus, camID string, runWeapon bool) {
 	// A. Fetch Snapshot
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/internal/cameras/%s/snapshot", baseURL, camID), nil)
-	req.Header.Set("Authorization", "Bearer "+serviceToken)
-
-	resp, err := client.Do(req)
+	jpegData, err := fetchSnapshot(client, camID)
 	if err != nil {
-		log.Printf("[%s] Snapshot fetch failed: %v", camID, err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
+		var statusErr *snapshotStatusError
+		if errors.As(err, &statusErr) && statusErr.Code < 500 {
+			log.Printf("[%s] Snapshot refused: %v", camID, err)
+			aiMetrics.recordDrop("basic", dropSnapshot4xx, 1)
+			return
+		}
+		log.Printf("[%s] Snapshot dropped: %v", camID, err)
+		atomic.AddInt64(&framesDroppedTotal, 1)
+		aiMetrics.recordDrop("basic", dropSnapshotError, 1)
 		return
 	}
 
-	// Read snapshot JPEG data for real detection
-	jpegData, err := io.ReadAll(resp.Body)
+	// B. Run Basic Detection (real or mock fallback)
+	start := time.Now()
+	basicObjects, err := RunDetection(jpegData, "basic")
 	if err != nil {
-		log.Printf("[%s] Snapshot read failed: %v", camID, err)
+		log.Printf("[%s] Frame rejected: %v", camID, err)
+		atomic.AddInt64(&framesDroppedTotal, 1)
+		aiMetrics.recordDrop("basic", dropFrameRejected, 1)
 		return
 	}
-
-	// B. Run Basic Detection (real or mock fallback)
-	basicObjects := RunDetection(jpegData, "basic")
+	aiMetrics.recordInference(camID, "basic", time.Since(start))
 	if basicObjects == nil {
 		basicObjects = []Object{} // Empty detection is valid
 	}
@@ -216,25 +242,89 @@ func processCamera(client *http.Client, nc *nats.Conn, camID string, runWeapon b
 		Stream:   "basic",
 		Objects:  basicObjects,
 	}
-	publishDetection(nc, "detections.basic."+camID, basicPayload)
+	publishDetection(client, bus, "detections.basic."+camID, basicPayload)
 	atomic.AddInt64(&basicInferenceTotal, 1)
 
 	// C. Run Weapon Detection (if enabled and due)
 	if runWeapon {
-		weaponObjects := RunDetection(jpegData, "weapon")
-		if weaponObjects != nil && len(weaponObjects) > 0 {
+		start := time.Now()
+		weaponObjects, err := RunDetection(jpegData, "weapon")
+		if err != nil {
+			log.Printf("[%s] Weapon frame rejected: %v", camID, err)
+			aiMetrics.recordDrop("weapon", dropFrameRejected, 1)
+			return
+		}
+		aiMetrics.recordInference(camID, "weapon", time.Since(start))
+		if len(weaponObjects) > 0 {
 			weaponPayload := DetectionPayload{
 				CameraID: camID,
 				TSUnixMS: time.Now().UnixMilli(),
 				Stream:   "weapon",
 				Objects:  weaponObjects,
 			}
-			publishDetection(nc, "detections.weapon."+camID, weaponPayload)
+			publishDetection(client, bus, "detections.weapon."+camID, weaponPayload)
 		}
 		atomic.AddInt64(&weaponInferenceTotal, 1)
 	}
 }
 
+// fetchSnapshot reads the camera snapshot, retrying transient failures
+// (transport errors, 5xx) up to snapshotMaxAttempts with linear backoff.
+func fetchSnapshot(client *http.Client, camID string) ([]byte, error) {
+	attempts := snapshotMaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(i) * snapshotBackoff)
+		}
+
+		data, retry, err := fetchSnapshotOnce(client, camID)
+		if err == nil {
+			return data, nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// snapshotStatusError is a non-200 answer from the snapshot endpoint.
+type snapshotStatusError struct {
+	Code int
+}
+
+func (e *snapshotStatusError) Error() string {
+	return fmt.Sprintf("snapshot status %d", e.Code)
+}
+
+func fetchSnapshotOnce(client *http.Client, camID string) ([]byte, bool, error) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/internal/cameras/%s/snapshot", baseURL, camID), nil)
+	req.Header.Set("Authorization", "Bearer "+serviceToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, resp.StatusCode >= 500, &snapshotStatusError{Code: resp.StatusCode}
+	}
+
+	// Read snapshot JPEG data for real detection
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	return data, false, nil
+}
+
 type DetectionPayload struct {
 	CameraID string   `json:"camera_id"`
 	TSUnixMS int64    `json:"ts_unix_ms"`
@@ -304,23 +394,55 @@ func mockWeaponObjects() []Object {
 	}
 }
 
-func publishDetection(nc *nats.Conn, subject string, payload DetectionPayload) {
+// publishDetection publishes to NATS; when NATS is down or the publish
+// fails it falls back to HTTP ingest if enabled, otherwise logs the payload.
+func publishDetection(client *http.Client, bus eventbus.EventBus, subject string, payload DetectionPayload) {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("Marshal error: %v", err)
 		return
 	}
 
-	if nc != nil {
-		if err := nc.Publish(subject, data); err != nil {
+	if bus != nil {
+		// A reconnecting NATS connection buffers publishes without error;
+		// send those detections over HTTP instead of holding them.
+		if c, ok := bus.(interface{ IsConnected() bool }); ok && !c.IsConnected() {
+			log.Printf("NATS disconnected, skipping publish on %s", subject)
+		} else if err := bus.Publish(subject, data); err == nil {
+			return
+		} else {
 			log.Printf("NATS publish failed: %v", err)
 		}
-	} else {
-		// Fallback: log only (HTTP ingest is dev-only and disabled by default)
+	}
+
+	if httpIngestFallback {
+		if err := postDetection(client, data); err != nil {
+			log.Printf("[%s] HTTP ingest failed: %v", payload.CameraID, err)
+		}
+	} else if bus == nil {
 		log.Printf("[NATS-MOCK] %s: %s", subject, string(data))
 	}
 }
 
+// postDetection sends one detection to POST /api/v1/internal/detections.
+func postDetection(client *http.Client, data []byte) error {
+	req, _ := http.NewRequest("POST", baseURL+"/api/v1/internal/detections", bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+serviceToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ingest error: %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/technosupport/ts-vms/internal/eventbus"
+)
+
+func TestFetchSnapshot_RetriesTransientError(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable) // keyframe wait
+			return
+		}
+		w.Write([]byte("jpeg-bytes"))
+	}))
+	defer srv.Close()
+
+	baseURL = srv.URL
+	snapshotMaxAttempts = 2
+	snapshotBackoff = time.Millisecond
+
+	data, err := fetchSnapshot(srv.Client(), "cam-1")
+	if err != nil {
+		t.Fatalf("Expected retry to recover the frame, got %v", err)
+	}
+	if string(data) != "jpeg-bytes" {
+		t.Errorf("Unexpected snapshot data %q", data)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Expected 2 attempts, got %d", got)
+	}
+}
+
+func TestFetchSnapshot_NoRetryOnClientError(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	baseURL = srv.URL
+	snapshotMaxAttempts = 3
+	snapshotBackoff = time.Millisecond
+
+	if _, err := fetchSnapshot(srv.Client(), "cam-1"); err == nil {
+		t.Fatal("Expected error for 404")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected a single attempt for 4xx, got %d", got)
+	}
+}
+
+func TestProcessCamera_SnapshotDropReasons(t *testing.T) {
+	aiMetrics = newServiceMetrics(false)
+	t.Cleanup(func() { aiMetrics = newServiceMetrics(false) })
+
+	status := http.StatusNotFound
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	baseURL = srv.URL
+	snapshotMaxAttempts = 1
+
+	before := atomic.LoadInt64(&framesDroppedTotal)
+	processCamera(srv.Client(), nil, "cam-1", false)
+	if got := atomic.LoadInt64(&framesDroppedTotal); got != before {
+		t.Errorf("A 4xx snapshot must not count as a dropped frame, got %d more", got-before)
+	}
+	status = http.StatusServiceUnavailable
+	processCamera(srv.Client(), nil, "cam-1", false)
+	if got := atomic.LoadInt64(&framesDroppedTotal); got != before+1 {
+		t.Errorf("Expected a 5xx snapshot to count as one dropped frame, got %d", got-before)
+	}
+
+	rr := httptest.NewRecorder()
+	aiMetrics.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		`ai_frames_dropped_total{reason="snapshot_4xx",stream="basic"} 1`,
+		`ai_frames_dropped_total{reason="snapshot_error",stream="basic"} 1`,
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("Expected %q in /metrics output", want)
+		}
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	aiMetrics = newServiceMetrics(true)
+	t.Cleanup(func() { aiMetrics = newServiceMetrics(false) })
+
+	aiMetrics.recordInference("cam-1", "basic", 42*time.Millisecond)
+	aiMetrics.recordDrop("basic", dropOverload, 2)
+
+	scrape := func() string {
+		rr := httptest.NewRecorder()
+		newHealthMux().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rr.Code)
+		}
+		return rr.Body.String()
+	}
+
+	setServiceUp(true)
+	body := scrape()
+	for _, want := range []string{
+		"# TYPE ai_inference_latency_seconds histogram",
+		`ai_inference_latency_seconds_bucket{stream="basic",le="0.05"} 1`,
+		`ai_inference_total{stream="basic"} 1`,
+		`ai_camera_inference_total{camera_id="cam-1",stream="basic"} 1`,
+		`ai_frames_dropped_total{reason="overload",stream="basic"} 2`,
+		"ai_service_up 1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in /metrics output", want)
+		}
+	}
+
+	// A failed detector init reports the service down
+	setServiceUp(false)
+	if body := scrape(); !strings.Contains(body, "ai_service_up 0") {
+		t.Errorf("Expected ai_service_up 0 after detector failure")
+	}
+}
+
+type failingBus struct{}
+
+func (failingBus) Publish(subject string, data []byte) error {
+	return errors.New("nats: connection closed")
+}
+func (failingBus) Subscribe(pattern string, h eventbus.Handler) (eventbus.Subscription, error) {
+	return nil, errors.New("nats: connection closed")
+}
+
+// reconnectingBus mimics a reconnecting *nats.Conn: Publish buffers and
+// succeeds while IsConnected is false.
+type reconnectingBus struct{ published int }
+
+func (b *reconnectingBus) Publish(subject string, data []byte) error {
+	b.published++
+	return nil
+}
+func (b *reconnectingBus) Subscribe(pattern string, h eventbus.Handler) (eventbus.Subscription, error) {
+	return nil, nil
+}
+func (b *reconnectingBus) IsConnected() bool { return false }
+
+func TestPublishDetection_HTTPFallback(t *testing.T) {
+	var posts []DetectionPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v1/internal/detections" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer svc-token" {
+			t.Errorf("Expected service token, got %q", got)
+		}
+		var p DetectionPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("Invalid body: %v", err)
+		}
+		posts = append(posts, p)
+	}))
+	defer srv.Close()
+
+	baseURL, serviceToken = srv.URL, "svc-token"
+	payload := DetectionPayload{CameraID: "cam-1", TSUnixMS: 1, Stream: "basic", Objects: []Object{}}
+
+	httpIngestFallback = false
+	publishDetection(srv.Client(), nil, "detections.basic.cam-1", payload)
+	if len(posts) != 0 {
+		t.Fatalf("Expected no HTTP ingest when the fallback is disabled, got %d", len(posts))
+	}
+
+	httpIngestFallback = true
+	t.Cleanup(func() { httpIngestFallback = false })
+	publishDetection(srv.Client(), nil, "detections.basic.cam-1", payload)
+	publishDetection(srv.Client(), failingBus{}, "detections.basic.cam-1", payload)
+	reconnecting := &reconnectingBus{}
+	publishDetection(srv.Client(), reconnecting, "detections.basic.cam-1", payload)
+	if len(posts) != 3 {
+		t.Fatalf("Expected NATS-less, failed and disconnected publishes to use HTTP ingest, got %d", len(posts))
+	}
+	if reconnecting.published != 0 {
+		t.Errorf("Expected no NATS publish while disconnected, got %d", reconnecting.published)
+	}
+	if posts[0].CameraID != "cam-1" || posts[0].Stream != "basic" {
+		t.Errorf("Unexpected ingested payload %+v", posts[0])
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Drop reasons for ai_frames_dropped_total
+const (
+	dropOverload      = "overload"       // Beyond MAX_OVERLAY_CAMERAS in a round
+	dropSnapshotError = "snapshot_error" // Snapshot fetch failed after retries (transport error or 5xx)
+	dropSnapshot4xx   = "snapshot_4xx"   // Snapshot refused (4xx, e.g. camera gone); not a lost frame
+	dropFrameRejected = "frame_rejected" // Detector refused the frame (e.g. too large)
+)
+
+// serviceMetrics is the Prometheus view of the AI service, kept on its own
+// registry so /metrics only exposes this process's series.
+type serviceMetrics struct {
+	registry  *prometheus.Registry
+	perCamera bool
+
+	up               prometheus.Gauge
+	inferenceTotal   *prometheus.CounterVec
+	inferenceLatency *prometheus.HistogramVec
+	framesDropped    *prometheus.CounterVec
+	queuedCameras    prometheus.Gauge
+	cameraInference  *prometheus.CounterVec // Only with perCamera; one series per camera
+}
+
+// newServiceMetrics registers the AI service metrics. perCamera adds
+// camera_id-labelled inference counts (AI_METRICS_PER_CAMERA), which grow
+// with the number of overlay cameras.
+func newServiceMetrics(perCamera bool) *serviceMetrics {
+	reg := prometheus.NewRegistry()
+	m := &serviceMetrics{
+		registry:  reg,
+		perCamera: perCamera,
+		up: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ai_service_up",
+			Help: "AI service health status (1=detector initialised, 0=detector failed)",
+		}),
+		inferenceTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ai_inference_total",
+			Help: "Total inference runs by stream type",
+		}, []string{"stream"}),
+		inferenceLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ai_inference_latency_seconds",
+			Help:    "Inference latency in seconds",
+			Buckets: []float64{.005, .01, .025, .05, .1, .2, .5, 1, 2, 5},
+		}, []string{"stream"}),
+		framesDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ai_frames_dropped_total",
+			Help: "Total frames dropped by stream type and reason",
+		}, []string{"stream", "reason"}),
+		queuedCameras: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ai_queued_cameras",
+			Help: "Cameras queued for inference in the current round",
+		}),
+	}
+	reg.MustRegister(m.up, m.inferenceTotal, m.inferenceLatency, m.framesDropped, m.queuedCameras,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	if perCamera {
+		m.cameraInference = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ai_camera_inference_total",
+			Help: "Inference runs per camera and stream",
+		}, []string{"camera_id", "stream"})
+		reg.MustRegister(m.cameraInference)
+	}
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *serviceMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// setServiceUp reflects the detector init state.
+func (m *serviceMetrics) setServiceUp(up bool) {
+	if up {
+		m.up.Set(1)
+	} else {
+		m.up.Set(0)
+	}
+}
+
+func (m *serviceMetrics) recordInference(camID, stream string, latency time.Duration) {
+	m.inferenceTotal.WithLabelValues(stream).Inc()
+	m.inferenceLatency.WithLabelValues(stream).Observe(latency.Seconds())
+	if m.perCamera {
+		m.cameraInference.WithLabelValues(camID, stream).Inc()
+	}
+}
+
+func (m *serviceMetrics) recordDrop(stream, reason string, n int) {
+	m.framesDropped.WithLabelValues(stream, reason).Add(float64(n))
+}
@@ -85,6 +85,21 @@ func main() {
 
 	// 3. Components
 	tokenMgr := tokens.NewManager(jwtKey)
+	// Only tokens minted for hlsd are honoured (POST /api/v1/auth/hls-token);
+	// control-plane tokens carry another aud
+	tokenMgr.Issuer, tokenMgr.Audience = tokens.DefaultIssuer, tokens.AudienceHLS
+	if v := os.Getenv("JWT_ISSUER"); v != "" {
+		tokenMgr.Issuer = v
+	}
+	if v := os.Getenv("HLSD_JWT_AUDIENCE"); v != "" {
+		tokenMgr.Audience = v
+	}
+	// Tokens minted before iss/aud existed keep working for the grace period
+	legacyGrace := tokens.DefaultLegacyClaimsGrace
+	if d, err := time.ParseDuration(os.Getenv("JWT_LEGACY_CLAIMS_GRACE")); err == nil && d >= 0 {
+		legacyGrace = d
+	}
+	tokenMgr.LegacyClaimsUntil = time.Now().Add(legacyGrace)
 	blacklist := auth.NewRedisBlacklist(rdb)
 	camRepo := data.CameraModel{DB: db}
 	permModel := data.PermissionModel{DB: db}
@@ -97,12 +112,16 @@ func main() {
 	}
 	rlMiddleware := middleware.NewRateLimitMiddleware(limiter, tokenMgr, rlCfg, nil)
 
+	keyProvider := &hlsd.MapKeyProvider{Keys: hmacKeys}
 	hlsHandler := hlsd.NewHandler(hlsd.Config{
 		HlsRoot:        hlsRoot,
 		AllowedOrigins: allowedOrigs,
-		Keys:           &hlsd.MapKeyProvider{Keys: hmacKeys},
+		Keys:           keyProvider,
 	}, permsMiddleware)
 
+	// Runtime key rotation (disabled unless HLSD_ADMIN_TOKEN is set)
+	keyAdmin := hlsd.NewKeyAdminHandler(keyProvider, os.Getenv("HLSD_ADMIN_TOKEN"))
+
 	// 4. Routing
 	r := chi.NewRouter()
 	r.Use(chimiddleware.RequestID)
@@ -144,6 +163,9 @@ func main() {
 	// HLS Delivery w/ Custom Auth logic (HMAC Token + RBAC) - Must be outside standard JWT middleware
 	hlsHandler.Register(r)
 
+	// Internal admin (HMAC key rotation)
+	keyAdmin.Register(r)
+
 	r.Group(func(r chi.Router) {
 		r.Use(jwtAuth.Middleware)
 		// API endpoints can go here
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// confirmDestroyEnv confirms a full rollback for automation that cannot pass
+// -confirm-destroy; it must be set to "1".
+const confirmDestroyEnv = "MIGRATOR_CONFIRM_DESTROY"
+
+var errDownNotConfirmed = errors.New("full rollback refused: pass -confirm-destroy (or set " + confirmDestroyEnv + "=1); use -steps -1 to roll back one migration")
+
+// checkDownConfirmed gates -down, which drops every table.
+func checkDownConfirmed(confirmFlag bool, getenv func(string) string) error {
+	if confirmFlag || getenv(confirmDestroyEnv) == "1" {
+		return nil
+	}
+	return errDownNotConfirmed
+}
+
+// droppedObject is one down migration and the tables it drops.
+type droppedObject struct {
+	Version uint
+	File    string
+	Tables  []string
+}
+
+var dropTableRe = regexp.MustCompile(`(?i)DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?([\w."]+(?:\s*,\s*[\w."]+)*)`)
+
+// downPlan lists, newest first, the down migrations a full rollback from
+// version runs in dir and the tables each drops.
+func downPlan(dir string, version uint) ([]droppedObject, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.down.sql"))
+	if err != nil {
+		return nil, err
+	}
+	var plan []droppedObject
+	for _, f := range files {
+		name := filepath.Base(f)
+		prefix, _, _ := strings.Cut(name, "_")
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil || uint(v) > version {
+			continue
+		}
+		sqlText, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		obj := droppedObject{Version: uint(v), File: name}
+		for _, m := range dropTableRe.FindAllStringSubmatch(string(sqlText), -1) {
+			for _, t := range strings.Split(m[1], ",") {
+				obj.Tables = append(obj.Tables, strings.TrimSpace(t))
+			}
+		}
+		plan = append(plan, obj)
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Version > plan[j].Version })
+	return plan, nil
+}
+
+// printDownWarning describes what a full rollback will destroy.
+func printDownWarning(dbname string, plan []droppedObject) {
+	fmt.Fprintf(os.Stderr, "WARNING: -down rolls back ALL %d migrations of database %q and destroys their data.\n", len(plan), dbname)
+	for _, p := range plan {
+		if len(p.Tables) == 0 {
+			fmt.Fprintf(os.Stderr, "  %s\n", p.File)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  %s: drops %s\n", p.File, strings.Join(p.Tables, ", "))
+	}
+}
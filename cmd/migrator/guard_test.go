@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckDownConfirmed(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(k string) string { return env[k] }
+
+	if err := checkDownConfirmed(false, getenv); !errors.Is(err, errDownNotConfirmed) {
+		t.Errorf("Full rollback without confirmation must be refused, got %v", err)
+	}
+	env[confirmDestroyEnv] = "true"
+	if err := checkDownConfirmed(false, getenv); err == nil {
+		t.Errorf("Only %s=1 confirms", confirmDestroyEnv)
+	}
+	if err := checkDownConfirmed(true, func(string) string { return "" }); err != nil {
+		t.Errorf("-confirm-destroy must allow the rollback, got %v", err)
+	}
+	env[confirmDestroyEnv] = "1"
+	if err := checkDownConfirmed(false, getenv); err != nil {
+		t.Errorf("Env confirmation must allow the rollback, got %v", err)
+	}
+}
+
+func TestDownPlan(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"000001_initial.down.sql": "DROP TABLE IF EXISTS sites;\nDROP TABLE tenants;",
+		"000002_nvr.down.sql":     "drop table if exists nvr_credentials, nvrs;",
+		"000003_column.down.sql":  "ALTER TABLE nvrs DROP COLUMN IF EXISTS x;",
+		"000004_future.down.sql":  "DROP TABLE future;",
+		"000001_initial.up.sql":   "CREATE TABLE tenants ();",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plan, err := downPlan(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range plan {
+		got = append(got, p.File+":"+strings.Join(p.Tables, "|"))
+	}
+	want := "000003_column.down.sql:,000002_nvr.down.sql:nvr_credentials|nvrs,000001_initial.down.sql:sites|tenants"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Unexpected plan:\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+}
@@ -16,7 +16,8 @@ import (
 
 func main() {
 	upCmd := flag.Bool("up", false, "Run all up migrations")
-	downCmd := flag.Bool("down", false, "Rollback all migrations")
+	downCmd := flag.Bool("down", false, "Rollback all migrations (requires -confirm-destroy)")
+	confirmDestroy := flag.Bool("confirm-destroy", false, "Confirm that -down may drop every table")
 	stepsCmd := flag.Int("steps", 0, "Run +/- steps")
 	flag.Parse()
 
@@ -74,6 +75,18 @@ func main() {
 		}
 		log.Println("Migration UP completed.")
 	} else if *downCmd {
+		version, _, err := m.Version()
+		if err != nil && err != migrate.ErrNilVersion {
+			log.Fatalf("Failed to read schema version: %v", err)
+		}
+		plan, err := downPlan("db/migrations", version)
+		if err != nil {
+			log.Fatalf("Failed to read down migrations: %v", err)
+		}
+		printDownWarning(dbname, plan)
+		if err := checkDownConfirmed(*confirmDestroy, os.Getenv); err != nil {
+			log.Fatal(err)
+		}
 		log.Println("Running DOWN migrations...")
 		if err := m.Down(); err != nil && err != migrate.ErrNoChange {
 			log.Fatalf("Migration DOWN failed: %v", err)
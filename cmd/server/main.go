@@ -9,8 +9,10 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/nats-io/nats.go"
 	"github.com/redis/go-redis/v9"
@@ -23,13 +25,16 @@ import (
 	"github.com/technosupport/ts-vms/internal/crypto"
 	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/discovery"
+	"github.com/technosupport/ts-vms/internal/eventbus"
 	"github.com/technosupport/ts-vms/internal/health"
 	"github.com/technosupport/ts-vms/internal/license"
 	"github.com/technosupport/ts-vms/internal/live"
+	"github.com/technosupport/ts-vms/internal/maintenance"
 	"github.com/technosupport/ts-vms/internal/media"
 	"github.com/technosupport/ts-vms/internal/metrics"
 	"github.com/technosupport/ts-vms/internal/middleware"
 	"github.com/technosupport/ts-vms/internal/nvr"
+	"github.com/technosupport/ts-vms/internal/nvr/adapters"
 	"github.com/technosupport/ts-vms/internal/platform/paths"
 	"github.com/technosupport/ts-vms/internal/platform/windows"
 	"github.com/technosupport/ts-vms/internal/ratelimit"
@@ -37,6 +42,7 @@ import (
 	"github.com/technosupport/ts-vms/internal/sfu"
 	"github.com/technosupport/ts-vms/internal/tokens"
 	"github.com/technosupport/ts-vms/internal/users"
+	"github.com/technosupport/ts-vms/internal/webhook"
 
 	_ "github.com/technosupport/ts-vms/internal/nvr/adapters/dahua"
 	_ "github.com/technosupport/ts-vms/internal/nvr/adapters/hikvision"
@@ -116,6 +122,23 @@ func main() {
 		log.Fatalf("DB ping error: %v", err)
 	}
 
+	// Readiness gate: refuse to serve against an unmigrated or dirty schema
+	var dbCfg struct {
+		Database struct {
+			AutoMigrate    bool   `yaml:"auto_migrate"`
+			MigrationsPath string `yaml:"migrations_path"`
+		} `yaml:"database"`
+	}
+	dbCfgData, _ := os.ReadFile("config/default.yaml")
+	_ = yaml.Unmarshal(dbCfgData, &dbCfg)
+	if dbCfg.Database.MigrationsPath == "" {
+		dbCfg.Database.MigrationsPath = "db/migrations"
+	}
+	if err := ensureSchema(context.Background(), db, dbCfg.Database.AutoMigrate, dbCfg.Database.MigrationsPath); err != nil {
+		elog.Error(eventIDError, fmt.Sprintf("Database schema not ready: %v", err))
+		log.Fatalf("Database schema not ready: %v. Run `migrator -up` (or set database.auto_migrate) before starting the server.", err)
+	}
+
 	// 3. Components
 	// Shared Redis Client
 	rdb := redis.NewClient(&redis.Options{Addr: redisAddr})
@@ -123,9 +146,80 @@ func main() {
 	// Managers
 	sessionMgr := session.NewManager(redisAddr, "") // TODO: Update SessionMgr to use shared client in future refactor
 	tokenMgr := tokens.NewManager(jwtKey)
+	var jwtCfg struct {
+		Auth struct {
+			JWT struct {
+				Issuer            string `yaml:"issuer"`
+				Audience          string `yaml:"audience"`
+				HLSAudience       string `yaml:"hls_audience"`
+				LegacyClaimsGrace string `yaml:"legacy_claims_grace"`
+			} `yaml:"jwt"`
+		} `yaml:"auth"`
+	}
+	jwtCfgData, _ := os.ReadFile("config/default.yaml")
+	_ = yaml.Unmarshal(jwtCfgData, &jwtCfg)
+	tokenMgr.Issuer, tokenMgr.Audience = tokens.DefaultIssuer, tokens.AudienceControlPlane
+	if v := jwtCfg.Auth.JWT.Issuer; v != "" {
+		tokenMgr.Issuer = v
+	}
+	if v := jwtCfg.Auth.JWT.Audience; v != "" {
+		tokenMgr.Audience = v
+	}
+	// Tokens minted before iss/aud existed keep working for the grace period
+	legacyGrace := tokens.DefaultLegacyClaimsGrace
+	if d, err := time.ParseDuration(jwtCfg.Auth.JWT.LegacyClaimsGrace); err == nil && d >= 0 {
+		legacyGrace = d
+	}
+	tokenMgr.LegacyClaimsUntil = time.Now().Add(legacyGrace)
 
 	// Audit Service (Phase 1.5)
 	auditService := audit.NewService(db)
+	type exportLimitsCfg struct {
+		MaxRows      int    `yaml:"max_rows"`
+		MaxRange     string `yaml:"max_range"`
+		AsyncMaxRows int    `yaml:"async_max_rows"`
+	}
+	var auditCfg struct {
+		Audit struct {
+			HashChain     bool   `yaml:"hash_chain"`
+			SystemActorID string `yaml:"system_actor_id"`
+			Export        struct {
+				Limits         exportLimitsCfg            `yaml:"limits"`
+				Tenants        map[string]exportLimitsCfg `yaml:"tenants"`
+				Dir            string                     `yaml:"dir"`
+				MaxRunningJobs int                        `yaml:"max_running_jobs"`
+				JobTTL         string                     `yaml:"job_ttl"`
+			} `yaml:"export"`
+		} `yaml:"audit"`
+	}
+	auditCfgData, _ := os.ReadFile("config/default.yaml")
+	_ = yaml.Unmarshal(auditCfgData, &auditCfg)
+	auditService.HashChain = auditCfg.Audit.HashChain
+	if auditCfg.Audit.SystemActorID != "" {
+		id, err := uuid.Parse(auditCfg.Audit.SystemActorID)
+		if err != nil {
+			log.Fatalf("Invalid audit.system_actor_id %q: %v", auditCfg.Audit.SystemActorID, err)
+		}
+		audit.SetSystemActorID(id)
+	}
+
+	// Audit export limits; zero fields keep the defaults
+	toExportLimits := func(c exportLimitsCfg) audit.ExportLimits {
+		d, _ := time.ParseDuration(c.MaxRange)
+		return audit.ExportLimits{MaxRows: c.MaxRows, MaxRange: d, AsyncMaxRows: c.AsyncMaxRows}
+	}
+	auditService.ExportLimits = audit.DefaultExportLimits().Merge(toExportLimits(auditCfg.Audit.Export.Limits))
+	for tid, c := range auditCfg.Audit.Export.Tenants {
+		id, err := uuid.Parse(tid)
+		if err != nil {
+			log.Printf("Warning: audit.export.tenants: invalid tenant id %q", tid)
+			continue
+		}
+		if auditService.TenantExportLimits == nil {
+			auditService.TenantExportLimits = make(map[uuid.UUID]audit.ExportLimits)
+		}
+		auditService.TenantExportLimits[id] = toExportLimits(c)
+	}
 
 	// Config Spooler (Using default from task or env helper later)
 	// For now using hardcoded default from prompt requirements via ConfigureFailover
@@ -136,9 +230,62 @@ func main() {
 	// Config Loading - Quick inline for Phase 1.6
 	var licCfg struct {
 		License struct {
-			Path          string `yaml:"path"`
-			PublicKeyPath string `yaml:"public_key_path"`
+			Path            string `yaml:"path"`
+			PublicKeyPath   string `yaml:"public_key_path"`
+			WatchDebounce   string `yaml:"watch_debounce"`
+			ParseRetryDelay string `yaml:"parse_retry_delay"`
+			ParseRetries    *int   `yaml:"parse_retries"`
+			FailurePolicy   string `yaml:"failure_policy"`
+			MaxStaleness    string `yaml:"max_staleness"`
 		} `yaml:"license"`
+		Cameras struct {
+			UniqueIPPerSite *bool                 `yaml:"unique_ip_per_site"`
+			SnapshotMaxDim  int                   `yaml:"snapshot_max_dimension"`
+			SnapshotTTL     string                `yaml:"snapshot_cache_ttl"`
+			SnapshotViaNVR  *bool                 `yaml:"snapshot_via_nvr"`
+			MaxTags         int                   `yaml:"max_tags_per_camera"`
+			DefaultPort     int                   `yaml:"default_port"`
+			MaxAICameras    int                   `yaml:"max_ai_active_cameras"`
+			SnapshotLimit   ratelimit.LimitConfig `yaml:"snapshot_rate_limit"`
+			AIServiceScope  struct {
+				Tenants   []string `yaml:"tenants"`
+				LogAccess bool     `yaml:"log_access"`
+			} `yaml:"ai_service_scope"`
+			RTSPHostAllowlist []string              `yaml:"rtsp_host_allowlist"`
+			MediaValidation   media.ValidatorConfig `yaml:"media_validation"`
+			DeletedRetention  string                `yaml:"deleted_retention"`
+		} `yaml:"cameras"`
+		Discovery struct {
+			MaxConcurrentRuns *int `yaml:"max_concurrent_runs"`
+		} `yaml:"discovery"`
+		Credentials struct {
+			RevealLimit  *int   `yaml:"reveal_limit"`
+			RevealWindow string `yaml:"reveal_window"`
+		} `yaml:"credentials"`
+		Health struct {
+			RecheckCooldown string `yaml:"recheck_cooldown"`
+			OfflineAlert    struct {
+				AfterFailures    int            `yaml:"after_failures"`
+				Tenants          map[string]int `yaml:"tenants"`
+				Notifier         string         `yaml:"notifier"`
+				WebhookURL       string         `yaml:"webhook_url"`
+				WebhookSecretEnv string         `yaml:"webhook_secret_env"`
+			} `yaml:"offline_alert"`
+		} `yaml:"health"`
+		Maintenance struct {
+			Global bool `yaml:"global"`
+		} `yaml:"maintenance"`
+		Webhooks struct {
+			CameraLifecycle struct {
+				Enabled        bool   `yaml:"enabled"`
+				URL            string `yaml:"url"`
+				SecretEnv      string `yaml:"secret_env"`
+				Timeout        string `yaml:"timeout"`
+				MaxAttempts    int    `yaml:"max_attempts"`
+				InitialBackoff string `yaml:"initial_backoff"`
+				QueueSize      int    `yaml:"queue_size"`
+			} `yaml:"camera_lifecycle"`
+		} `yaml:"webhooks"`
 	}
 	// Re-read config (inefficient but safe for this phase wiring)
 	licCfgData, _ := os.ReadFile("config/default.yaml")
@@ -153,6 +300,25 @@ func main() {
 	// 2. Create Manager
 	usageStub := &license.StubUsageProvider{}
 	licenseManager := license.NewManager(licCfg.License.Path, licenseParser, usageStub, auditService)
+	if d, err := time.ParseDuration(licCfg.License.WatchDebounce); err == nil && d > 0 {
+		licenseManager.WatchDebounce = d
+	}
+	if d, err := time.ParseDuration(licCfg.License.ParseRetryDelay); err == nil && d > 0 {
+		licenseManager.ParseRetryDelay = d
+	}
+	if licCfg.License.ParseRetries != nil && *licCfg.License.ParseRetries >= 0 {
+		licenseManager.ParseRetries = *licCfg.License.ParseRetries
+	}
+	failurePolicy, err := license.ParseFailurePolicy(licCfg.License.FailurePolicy)
+	if err != nil {
+		log.Fatalf("license.failure_policy: %v", err)
+	}
+	licenseManager.FailurePolicy = failurePolicy
+	if d, err := time.ParseDuration(licCfg.License.MaxStaleness); err == nil && d > 0 {
+		licenseManager.MaxStaleness = d
+	} else if licCfg.License.MaxStaleness != "" {
+		log.Printf("Warning: license.max_staleness %q invalid, using %s", licCfg.License.MaxStaleness, license.DefaultMaxStaleness)
+	}
 
 	// 3. Start Watcher & Scheduler
 	licenseManager.StartWatcher(context.Background())
@@ -162,25 +328,103 @@ func main() {
 	// 3.1 Camera Components (Phase 2.1)
 	camRepo := data.CameraModel{DB: db}
 	camService := cameras.NewService(camRepo, licenseManager, auditService)
+	if licCfg.Cameras.UniqueIPPerSite != nil {
+		camService.SetUniqueIPPerSite(*licCfg.Cameras.UniqueIPPerSite)
+	}
+	camService.SetMaxTagsPerCamera(licCfg.Cameras.MaxTags)
+	if licCfg.Cameras.DefaultPort != 0 {
+		if !cameras.ValidPort(licCfg.Cameras.DefaultPort) {
+			log.Printf("Warning: cameras.default_port %d out of range, using %d", licCfg.Cameras.DefaultPort, cameras.DefaultCameraPort)
+		}
+		camService.SetDefaultPort(licCfg.Cameras.DefaultPort)
+	}
+	// Bring tenants back within quota if the license was downgraded while
+	// offline, and restore cameras it disabled once the license allows.
+	// Skipped unless the license is valid: a missing or unreadable license
+	// says nothing about the real quota.
+	if st := licenseManager.EffectiveState(); st.Status != license.StatusValid {
+		log.Printf("License quota reconciliation skipped: license status %s", st.Status)
+	} else if disabled, restored, err := camService.ReconcileLicenseQuota(context.Background()); err != nil {
+		log.Printf("Warning: License quota reconciliation failed: %v", err)
+	} else if disabled > 0 || restored > 0 {
+		log.Printf("License quota reconciliation disabled %d and restored %d camera(s)", disabled, restored)
+	}
+	deletedRetention := cameras.DefaultDeletedRetention
+	if d, err := time.ParseDuration(licCfg.Cameras.DeletedRetention); err == nil && d > 0 {
+		deletedRetention = d
+	} else if licCfg.Cameras.DeletedRetention != "" {
+		log.Printf("Warning: cameras.deleted_retention %q invalid, using %s", licCfg.Cameras.DeletedRetention, deletedRetention)
+	}
+
+	// Camera lifecycle webhooks for external asset sync
+	var camWebhooks *webhook.Dispatcher
+	if wh := licCfg.Webhooks.CameraLifecycle; wh.Enabled {
+		if wh.URL == "" {
+			log.Fatalf("webhooks.camera_lifecycle.url is required when enabled")
+		}
+		whCfg := webhook.Config{URL: wh.URL, MaxAttempts: wh.MaxAttempts, QueueSize: wh.QueueSize}
+		if wh.SecretEnv != "" {
+			whCfg.Secret = os.Getenv(wh.SecretEnv)
+		}
+		if whCfg.Secret == "" {
+			log.Printf("Warning: webhooks.camera_lifecycle has no secret (secret_env %q unset), deliveries are unsigned", wh.SecretEnv)
+		}
+		if d, err := time.ParseDuration(wh.Timeout); err == nil {
+			whCfg.Timeout = d
+		} else if wh.Timeout != "" {
+			log.Printf("Warning: webhooks.camera_lifecycle.timeout %q invalid, using %s", wh.Timeout, webhook.DefaultTimeout)
+		}
+		if d, err := time.ParseDuration(wh.InitialBackoff); err == nil {
+			whCfg.InitialBackoff = d
+		} else if wh.InitialBackoff != "" {
+			log.Printf("Warning: webhooks.camera_lifecycle.initial_backoff %q invalid, using %s", wh.InitialBackoff, webhook.DefaultInitialBackoff)
+		}
+		camWebhooks = webhook.NewDispatcher(whCfg)
+		camWebhooks.Start()
+		camService.SetWebhooks(camWebhooks)
+	}
 	camHandler := api.NewCameraHandler(camService)
 
 	// Crypto Components (Phase 2.2)
+	var keyCfg struct {
+		MasterKeys crypto.KeySourceConfig `yaml:"master_keys"`
+	}
+	_ = yaml.Unmarshal(licCfgData, &keyCfg)
+	keySources, err := keyCfg.MasterKeys.Build(nil) // No KMS client integrated yet
+	if err != nil {
+		log.Fatalf("Failed to initialize Keyring: %v", err)
+	}
 	keyring := crypto.NewKeyring()
-	if err := keyring.LoadFromEnv(); err != nil {
+	if err := keyring.LoadFrom(context.Background(), keySources...); err != nil {
 		log.Fatalf("Failed to initialize Keyring: %v", err)
 	}
+	log.Printf("Keyring: master keys loaded from %s (active kid %s)", keyring.Source(), keyring.ActiveKID())
 	credRepo := data.CredentialModel{DB: db}
 	credService := cameras.NewCredentialService(credRepo, keyring, auditService)
+	if licCfg.Credentials.RevealLimit != nil {
+		window, _ := time.ParseDuration(licCfg.Credentials.RevealWindow)
+		credService.SetRevealLimit(*licCfg.Credentials.RevealLimit, window)
+	}
 
 	// Discovery Components (Phase 2.3)
 	discRepo := &data.DiscoveryModel{DB: db}
 	discService := discovery.NewService(discRepo, keyring, auditService)
+	if licCfg.Discovery.MaxConcurrentRuns != nil {
+		discService.MaxConcurrentRuns = *licCfg.Discovery.MaxConcurrentRuns
+	}
 
 	// Media Components (Phase 2.4)
 	mediaRepo := &data.MediaModel{DB: db}
 	// Note: CredService and OnvifClient used internally
-	mediaService := cameras.NewMediaService(mediaRepo, &camRepo, credService, auditService)
+	mediaService := cameras.NewMediaService(mediaRepo, &camRepo, credService, auditService, licCfg.Cameras.MediaValidation)
+	camService.SetCloneSources(credService, mediaRepo)
+	rtspHosts, err := cameras.NewRTSPHostPolicy(licCfg.Cameras.RTSPHostAllowlist)
+	if err != nil {
+		log.Fatalf("cameras.rtsp_host_allowlist: %v", err)
+	}
+	mediaService.RTSPHosts = rtspHosts
 	mediaHandler := api.NewMediaHandler(mediaService)
+	imagingHandler := api.NewImagingHandler(cameras.NewImagingService(&camRepo, credService, auditService))
 
 	// SFU Components (Phase 3.4)
 	sfuClient := sfu.NewClient(sfuURL, sfuSecret)
@@ -189,6 +433,7 @@ func main() {
 		log.Printf("Warning: Failed to connect to Media Plane: %v", err)
 	}
 	sfuService := cameras.NewSfuService(sfuClient, mediaClient, &camRepo, mediaRepo)
+	sfuService.RTSPHosts = rtspHosts
 	sfuHandler := api.NewSfuHandler(sfuService)
 
 	// NVR Components (Phase 2.6)
@@ -196,10 +441,62 @@ func main() {
 	nvrService := nvr.NewService(&nvrRepo, keyring, auditService, camService)
 	nvrHandler := api.NewNVRHandler(nvrService)
 
+	// Maintenance mode pauses the background pollers below
+	maintenanceSwitch := maintenance.New()
+	if licCfg.Maintenance.Global {
+		maintenanceSwitch.SetGlobal(true)
+		log.Printf("Maintenance mode active at startup (maintenance.global); pollers paused until cleared")
+	}
+	// Tenant maintenance is persisted, so a restart keeps pollers paused
+	maintenanceSwitch.Store = data.MaintenanceModel{DB: db}
+	if err := maintenanceSwitch.Load(context.Background()); err != nil {
+		log.Printf("Warning: loading tenant maintenance failed: %v", err)
+	}
+	maintenanceSwitch.StartSync(context.Background(), maintenance.DefaultSyncInterval)
+
 	// Health Components (Phase 2.5)
 	healthRepo := &data.HealthModel{DB: db}
 	healthProber := health.NewRTSPProber(credService)
 	healthService := health.NewService(healthRepo, &nvrRepo, healthProber)
+	if d, err := time.ParseDuration(licCfg.Health.RecheckCooldown); err == nil && d >= 0 {
+		healthService.RecheckCooldown = d
+	} else if licCfg.Health.RecheckCooldown != "" {
+		log.Printf("Warning: health.recheck_cooldown %q invalid, using %s", licCfg.Health.RecheckCooldown, health.DefaultRecheckCooldown)
+	}
+	healthService.Maintenance = maintenanceSwitch
+
+	// Offline notifications: once per incident after N consecutive failed checks
+	var healthWebhooks *webhook.Dispatcher
+	if oa := licCfg.Health.OfflineAlert; oa.Notifier != "" && oa.Notifier != "none" {
+		healthService.Alerts.OfflineThreshold.Default = oa.AfterFailures
+		if len(oa.Tenants) > 0 {
+			healthService.Alerts.OfflineThreshold.Tenants = make(map[uuid.UUID]int, len(oa.Tenants))
+			for tid, n := range oa.Tenants {
+				id, err := uuid.Parse(tid)
+				if err != nil {
+					log.Fatalf("health.offline_alert.tenants: invalid tenant id %q", tid)
+				}
+				healthService.Alerts.OfflineThreshold.Tenants[id] = n
+			}
+		}
+		switch oa.Notifier {
+		case "log":
+			healthService.Alerts.Notifier = health.LogNotifier{}
+		case "webhook":
+			if oa.WebhookURL == "" {
+				log.Fatalf("health.offline_alert.webhook_url is required for the webhook notifier")
+			}
+			whCfg := webhook.Config{URL: oa.WebhookURL}
+			if oa.WebhookSecretEnv != "" {
+				whCfg.Secret = os.Getenv(oa.WebhookSecretEnv)
+			}
+			healthWebhooks = webhook.NewDispatcher(whCfg)
+			healthWebhooks.Start()
+			healthService.Alerts.Notifier = health.WebhookNotifier{Sender: healthWebhooks}
+		default:
+			log.Fatalf("health.offline_alert.notifier: unknown notifier %q (log | webhook | none)", oa.Notifier)
+		}
+	}
 	healthHandler := api.NewHealthHandler(healthService)
 
 	healthScheduler := health.NewScheduler(health.SchedulerConfig{}, healthService)
@@ -215,6 +512,9 @@ func main() {
 		Events    struct {
 			Nvr nvr.PollerConfig `yaml:"nvr"`
 		} `yaml:"events"`
+		RBAC struct {
+			DenyDetails bool `yaml:"deny_details"`
+		} `yaml:"rbac"`
 	}
 	cfgData, _ := os.ReadFile("config/default.yaml")
 	_ = yaml.Unmarshal(cfgData, &rootCfg) // Error handling ignored for brevity in main
@@ -223,13 +523,120 @@ func main() {
 
 	// Use Real Camera Resolver (camRepo implements it)
 	permsMiddleware := middleware.NewPermissionMiddleware(permModel, camRepo)
+	permsMiddleware.DenyDetails = rootCfg.RBAC.DenyDetails
 
 	// --- Phase 3.6 WebRTC-HLS Fallback ---
 	// Live Service & Handler (Needed for NATS AI Sub)
 	liveService := live.NewService(rdb, camService, "http://localhost:8080", live.HLSParams{
 		BaseURL: "http://localhost:8081",
 	})
+	var liveCfg struct {
+		Live struct {
+			FallbackDowngradeThreshold *int           `yaml:"fallback_downgrade_threshold"`
+			MaxObjectsBasic            int            `yaml:"max_objects_basic"`
+			MaxObjectsWeapon           int            `yaml:"max_objects_weapon"`
+			DetectionStore             string         `yaml:"detection_store"`
+			DetectionWorkers           int            `yaml:"detection_workers"`
+			DetectionQueueSize         int            `yaml:"detection_queue_size"`
+			HTTPIngest                 bool           `yaml:"http_ingest"`
+			TenantCacheTTL             *time.Duration `yaml:"tenant_cache_ttl"`
+			TenantCacheSize            int            `yaml:"tenant_cache_size"`
+			OverlayDemandSource        string         `yaml:"overlay_demand_source"`
+			SfuMaxRoomsPerTenant       int            `yaml:"sfu_max_rooms_per_tenant"`
+			DegradedSessions           struct {
+				Enabled bool          `yaml:"enabled"`
+				TTL     time.Duration `yaml:"ttl"`
+			} `yaml:"degraded_sessions"`
+			RecentDetections struct {
+				Window time.Duration `yaml:"window"`
+				Depth  int           `yaml:"depth"`
+			} `yaml:"recent_detections"`
+			HLS struct {
+				SegmentDurationMs int            `yaml:"segment_duration_ms"`
+				TargetLatencyMs   int            `yaml:"target_latency_ms"`
+				Tenants           map[string]int `yaml:"tenants"`
+			} `yaml:"hls"`
+			AuditViews struct {
+				All     bool            `yaml:"all"`
+				Tenants map[string]bool `yaml:"tenants"`
+				Cameras map[string]bool `yaml:"cameras"`
+			} `yaml:"audit_views"`
+		} `yaml:"live"`
+	}
+	_ = yaml.Unmarshal(cfgData, &liveCfg)
+	if liveCfg.Live.FallbackDowngradeThreshold != nil {
+		liveService.FallbackDowngradeThreshold = *liveCfg.Live.FallbackDowngradeThreshold
+	}
+	liveService.HLSParams.SegmentDurationMs = liveCfg.Live.HLS.SegmentDurationMs
+	liveService.HLSParams.TargetLatencyMs = liveCfg.Live.HLS.TargetLatencyMs
+	for tid, ms := range liveCfg.Live.HLS.Tenants {
+		id, err := uuid.Parse(tid)
+		if err != nil {
+			log.Printf("Warning: live.hls.tenants: invalid tenant id %q", tid)
+			continue
+		}
+		if liveService.HLSParams.TenantTargetLatencyMs == nil {
+			liveService.HLSParams.TenantTargetLatencyMs = make(map[uuid.UUID]int)
+		}
+		liveService.HLSParams.TenantTargetLatencyMs[id] = ms
+	}
+	if liveCfg.Live.TenantCacheTTL != nil {
+		liveService.TenantCacheTTL = *liveCfg.Live.TenantCacheTTL
+	}
+	liveService.TenantCacheSize = liveCfg.Live.TenantCacheSize
+	sfuService.MaxRoomsPerTenant = liveCfg.Live.SfuMaxRoomsPerTenant
+	sfuService.Licenses = licenseManager
+	camService.OnCamerasChanged(liveService.InvalidateCameraTenants)
+	// Started once the change hooks are registered, so purges reach them.
+	camService.StartDeletedCameraPurger(context.Background(), deletedRetention, time.Hour)
+	liveService.Auditor = auditService
+	liveService.ViewAudit = live.ViewAuditPolicy{
+		All:     liveCfg.Live.AuditViews.All,
+		Tenants: make(map[uuid.UUID]bool),
+		Cameras: make(map[uuid.UUID]bool),
+	}
+	for tid, on := range liveCfg.Live.AuditViews.Tenants {
+		id, err := uuid.Parse(tid)
+		if err != nil {
+			log.Printf("Warning: live.audit_views.tenants: invalid tenant id %q", tid)
+			continue
+		}
+		liveService.ViewAudit.Tenants[id] = on
+	}
+	for cid, on := range liveCfg.Live.AuditViews.Cameras {
+		id, err := uuid.Parse(cid)
+		if err != nil {
+			log.Printf("Warning: live.audit_views.cameras: invalid camera id %q", cid)
+			continue
+		}
+		liveService.ViewAudit.Cameras[id] = on
+	}
+	if liveCfg.Live.MaxObjectsBasic > 0 {
+		liveService.ObjectLimits.Basic = liveCfg.Live.MaxObjectsBasic
+	}
+	if liveCfg.Live.MaxObjectsWeapon > 0 {
+		liveService.ObjectLimits.Weapon = liveCfg.Live.MaxObjectsWeapon
+	}
+	switch liveCfg.Live.DetectionStore {
+	case "", live.DetectionStoreRedis:
+	case live.DetectionStoreMemory:
+		liveService.Detections = live.NewMemoryDetectionStore()
+		log.Println("Live: detections stored in process memory (single-node only)")
+	default:
+		log.Printf("Warning: unknown live.detection_store %q, using redis", liveCfg.Live.DetectionStore)
+	}
+	if w := liveCfg.Live.RecentDetections.Window; w > 0 {
+		liveService.RecentDetections = live.NewRecentDetections(rdb, w, liveCfg.Live.RecentDetections.Depth)
+	}
+	if liveCfg.Live.DegradedSessions.Enabled {
+		liveService.DegradedSessionKey = live.DegradedSessionKeyFrom(jwtKey)
+		liveService.DegradedSessionTTL = liveCfg.Live.DegradedSessions.TTL
+	}
+	if src := liveCfg.Live.OverlayDemandSource; src != "" && src != "timestamp" {
+		log.Printf("Warning: live.overlay_demand_source %q is no longer supported, using timestamp", src)
+	}
 	telemetryService := live.NewTelemetryService(rdb)
+	telemetryService.Rooms = sfuService
 	liveHandler := api.NewLiveHandler(liveService, telemetryService)
 
 	// --- NATS Connection (Phase 3.8 AI & Phase 2.10 NVR) ---
@@ -237,25 +644,68 @@ func main() {
 	if natsURL == "" {
 		natsURL = nats.DefaultURL
 	}
-	nc, err := nats.Connect(natsURL, nats.Name(serviceName))
+	var natsCfg struct {
+		Nats struct {
+			MaxReconnects   int `yaml:"max_reconnects"`
+			ReconnectWaitMs int `yaml:"reconnect_wait_ms"`
+		} `yaml:"nats"`
+	}
+	natsCfg.Nats.MaxReconnects = nats.DefaultMaxReconnect
+	natsCfg.Nats.ReconnectWaitMs = int(nats.DefaultReconnectWait / time.Millisecond)
+	_ = yaml.Unmarshal(cfgData, &natsCfg)
+
+	// Components that hold work during an outage register here to resume it
+	// from the single reconnect handler.
+	var reconnectHooks struct {
+		sync.Mutex
+		fns []func()
+	}
+	onNATSReconnect := func(fn func()) {
+		reconnectHooks.Lock()
+		defer reconnectHooks.Unlock()
+		reconnectHooks.fns = append(reconnectHooks.fns, fn)
+	}
+
+	nc, err := nats.Connect(natsURL,
+		nats.Name(serviceName),
+		nats.MaxReconnects(natsCfg.Nats.MaxReconnects), // -1 = retry forever
+		nats.ReconnectWait(time.Duration(natsCfg.Nats.ReconnectWaitMs)*time.Millisecond),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			metrics.NATSConnected.Set(0)
+			metrics.NATSDisconnectsTotal.Inc()
+			log.Printf("Warning: NATS disconnected: %v", err)
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			metrics.NATSConnected.Set(1)
+			metrics.NATSReconnectsTotal.Inc()
+			log.Printf("NATS reconnected to %s", c.ConnectedUrl())
+			reconnectHooks.Lock()
+			defer reconnectHooks.Unlock()
+			for _, fn := range reconnectHooks.fns {
+				fn()
+			}
+		}),
+		nats.ClosedHandler(func(_ *nats.Conn) {
+			metrics.NATSConnected.Set(0)
+		}),
+	)
 	if err != nil {
 		log.Printf("Warning: NATS Connect Failed: %v. AI/Events disabled.", err)
 	} else {
 		log.Println("Connected to NATS")
+		metrics.NATSConnected.Set(1)
 		// --- Phase 3.8 AI Detection Subscription ---
-		_, err = nc.Subscribe("detections.>", func(m *nats.Msg) {
-			if err := liveService.SaveDetectionFromNATS(context.Background(), m.Data); err != nil {
-				log.Printf("Error saving AI detection from NATS: %v", err)
-			}
-		})
-		if err == nil {
-			log.Println("AI Detection Subscriber Active on subject: detections.>")
+		detectionIngester := live.NewDetectionIngester(liveService.SaveDetectionFromNATS, liveCfg.Live.DetectionWorkers, liveCfg.Live.DetectionQueueSize)
+		detectionIngester.Start(context.Background())
+		if _, err := detectionIngester.Subscribe(eventbus.NewNATSBus(nc)); err == nil {
+			log.Printf("AI Detection Subscriber Active on subject: %s", live.DetectionSubject)
 		}
 		defer nc.Close()
 	}
 
 	// --- Phase 2.10 NVR Events ---
 	var nvrPoller *nvr.NVRPoller
+	var onvifBridge *nvr.ONVIFEventBridge
 	if rootCfg.Events.Nvr.Enabled && nc != nil {
 		// Re-define a local config struct that matches yaml
 		type NvrEventConfig struct {
@@ -266,21 +716,35 @@ func main() {
 			TimeBudgetMs     int    `yaml:"time_budget_ms"`
 			BackoffMs        int    `yaml:"backoff_ms"`
 			PublishRetryMax  int    `yaml:"publish_retry_max"`
+			PublishBufferMax int    `yaml:"publish_buffer_size"`
 			DedupTTLSeconds  int    `yaml:"dedup_ttl_seconds"`
 			DedupMaxKeys     int    `yaml:"dedup_max_keys"`
 			NatsSubject      string `yaml:"nats_subject"`
 			SnapshotMode     string `yaml:"snapshot_mode"`
 		}
+		type OnvifEventConfig struct {
+			Enabled           bool `yaml:"enabled"`
+			PullIntervalMs    int  `yaml:"pull_interval_ms"`
+			PullTimeoutMs     int  `yaml:"pull_timeout_ms"`
+			MaxMessages       int  `yaml:"max_messages"`
+			SubscriptionTTLMs int  `yaml:"subscription_ttl_ms"`
+			MaxInflight       int  `yaml:"max_inflight_cameras"`
+		}
 		var rawEvtCfg struct {
 			Events struct {
-				Nvr NvrEventConfig `yaml:"nvr"`
+				Nvr   NvrEventConfig   `yaml:"nvr"`
+				Onvif OnvifEventConfig `yaml:"onvif"`
 			} `yaml:"events"`
 		}
 		_ = yaml.Unmarshal(cfgData, &rawEvtCfg) // Re-parse for safety config match
 		c := rawEvtCfg.Events.Nvr
 
 		// Components
-		pub := nvr.NewNATSPublisher(nc, c.NatsSubject, c.PublishRetryMax)
+		pub := nvr.NewNATSPublisher(nc, c.NatsSubject, c.PublishRetryMax, c.PublishBufferMax)
+		// Drain events buffered during the outage once NATS is back
+		onNATSReconnect(func() {
+			log.Printf("NATS: flushed %d buffered NVR events", pub.Flush())
+		})
 		enricher := nvr.NewEventEnricher(&nvrRepo)
 		dedup := nvr.NewEventDedup(c.DedupMaxKeys, c.DedupTTLSeconds)
 
@@ -298,8 +762,27 @@ func main() {
 		}
 
 		nvrPoller = nvr.NewNVRPoller(nvrService, pub, enricher, dedup, pCfg)
+		nvrPoller.Maintenance = maintenanceSwitch
 		nvrPoller.Start()
 		elog.Info(eventIDStart, "NVR Event Poller Started")
+
+		// ONVIF events from directly connected cameras share the publisher and dedup
+		oc := rawEvtCfg.Events.Onvif
+		onvifBridge = nvr.NewONVIFEventBridge(&camRepo, func(ctx context.Context, tenantID, cameraID uuid.UUID) (string, string, error) {
+			out, found, err := credService.GetCredentials(ctx, tenantID, cameraID, true)
+			if err != nil || !found {
+				return "", "", err
+			}
+			return out.Data.Username, out.Data.Password, nil
+		}, pub, dedup, nvr.ONVIFBridgeConfig{
+			Enabled:         oc.Enabled,
+			PullInterval:    time.Duration(oc.PullIntervalMs) * time.Millisecond,
+			PullTimeout:     time.Duration(oc.PullTimeoutMs) * time.Millisecond,
+			MaxMessages:     oc.MaxMessages,
+			SubscriptionTTL: time.Duration(oc.SubscriptionTTLMs) * time.Millisecond,
+			MaxInflight:     oc.MaxInflight,
+		})
+		onvifBridge.Start()
 	}
 
 	// Credential Handler (Phase 2.2)
@@ -322,12 +805,30 @@ func main() {
 		Tokens:  tokenMgr,
 		Session: sessionMgr,
 		Hasher:  auth.DefaultParams,
+
+		HLSAudience: jwtCfg.Auth.JWT.HLSAudience,
 	}
 
 	// Audit API Handler
+	auditExportDir := auditCfg.Audit.Export.Dir
+	if auditExportDir == "" {
+		auditExportDir = "C:\\ProgramData\\TechnoSupport\\VMS\\audit_exports"
+	}
+	auditExports := audit.NewExportJobs(auditService, auditExportDir)
+	if n := auditCfg.Audit.Export.MaxRunningJobs; n > 0 {
+		auditExports.MaxRunning = n
+	}
+	if d, err := time.ParseDuration(auditCfg.Audit.Export.JobTTL); err == nil && d > 0 {
+		auditExports.TTL = d
+	} else if auditCfg.Audit.Export.JobTTL != "" {
+		log.Printf("Warning: audit.export.job_ttl %q invalid, using %s", auditCfg.Audit.Export.JobTTL, auditExports.TTL)
+	}
+	auditExports.Prune() // Files left by a previous run
+	auditExports.StartPruning(context.Background(), min(auditExports.TTL, time.Hour))
 	auditHandler := &api.AuditHandler{
 		Service: auditService,
 		Perms:   permsMiddleware,
+		Exports: auditExports,
 	}
 
 	// User Service (Phase 1.7)
@@ -359,11 +860,10 @@ func main() {
 
 	// --- Phase 2.1 Camera Routes ---
 	// CRUD
-	// POST /cameras -> cameras.create (Site Scope needed in Body? Or Tenant Wide?)
-	// Let's require "cameras.create" (tenant scope or site scope if supported later).
-	// For now "tenant".
-	protectedMux.Handle("POST /api/v1/cameras",
-		permsMiddleware.RequirePermission("cameras.create", "tenant")(http.HandlerFunc(camHandler.Create)))
+	// POST /cameras -> cameras.create, checked by the handler against the
+	// site in the body (tenant-wide grants or a grant on that site).
+	camHandler.Perms = permsMiddleware
+	protectedMux.Handle("POST /api/v1/cameras", http.HandlerFunc(camHandler.Create))
 
 	protectedMux.Handle("GET /api/v1/cameras",
 		permsMiddleware.RequirePermission("cameras.list", "tenant")(http.HandlerFunc(camHandler.List)))
@@ -379,6 +879,9 @@ func main() {
 
 	// --- Existing Routes ---
 
+	// hlsd access token (aud hls_audience); hlsd enforces camera RBAC itself
+	protectedMux.Handle("POST /api/v1/auth/hls-token", http.HandlerFunc(authHandler.HLSToken))
+
 	// Debug
 	debugHandler := permsMiddleware.RequirePermission("debug.view", "tenant")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ac, _ := middleware.GetAuthContext(r.Context())
@@ -406,6 +909,8 @@ func main() {
 		permsMiddleware.RequirePermission("license.manage", "tenant")(http.HandlerFunc(licenseHandler.Reload)))
 
 	// Users
+	protectedMux.Handle("GET /api/v1/users",
+		permsMiddleware.RequirePermission("user.read", "tenant")(http.HandlerFunc(userHandler.ListUsers)))
 	protectedMux.Handle("GET /api/v1/users/{id}",
 		permsMiddleware.RequirePermission("user.read", "tenant")(http.HandlerFunc(userHandler.GetUser)))
 	protectedMux.Handle("POST /api/v1/users",
@@ -449,16 +954,28 @@ func main() {
 	// Let's use a helper for cleaner Main.
 	Protect := func(h http.Handler) http.Handler { return jwtMiddleware.Middleware(h) }
 
-	mux.Handle("POST /api/v1/cameras", Protect(permsMiddleware.RequirePermission("cameras.create", "tenant")(http.HandlerFunc(camHandler.Create))))
+	mux.Handle("POST /api/v1/cameras", Protect(http.HandlerFunc(camHandler.Create))) // Site-scoped cameras.create check in the handler
 	mux.Handle("GET /api/v1/cameras", Protect(permsMiddleware.RequirePermission("cameras.list", "tenant")(http.HandlerFunc(camHandler.List))))
+	mux.Handle("GET /api/v1/cameras/tags", Protect(permsMiddleware.RequirePermission("cameras.list", "tenant")(http.HandlerFunc(camHandler.ListTags))))
 	mux.Handle("POST /api/v1/cameras/bulk", Protect(permsMiddleware.RequirePermission("cameras.manage", "tenant")(http.HandlerFunc(camHandler.Bulk))))
+	mux.Handle("PUT /api/v1/cameras/{id}", Protect(permsMiddleware.RequirePermission("cameras.manage", "tenant")(http.HandlerFunc(camHandler.Update))))
 	mux.Handle("POST /api/v1/cameras/{id}/enable", Protect(permsMiddleware.RequirePermission("cameras.manage", "tenant")(http.HandlerFunc(camHandler.Enable))))
 	mux.Handle("POST /api/v1/cameras/{id}/disable", Protect(permsMiddleware.RequirePermission("cameras.manage", "tenant")(http.HandlerFunc(camHandler.Disable))))
+	mux.Handle("POST /api/v1/cameras/{id}/clone", Protect(permsMiddleware.RequirePermission("cameras.create", "tenant")(http.HandlerFunc(camHandler.Clone))))
+	mux.Handle("GET /api/v1/tenant/camera-settings", Protect(permsMiddleware.RequirePermission("cameras.list", "tenant")(http.HandlerFunc(camHandler.GetSettings))))
+	mux.Handle("PUT /api/v1/tenant/camera-settings", Protect(permsMiddleware.RequirePermission("cameras.manage", "tenant")(http.HandlerFunc(camHandler.UpdateSettings))))
+	mux.Handle("PUT /api/v1/cameras/{id}/onvif-events", Protect(permsMiddleware.RequirePermission("cameras.manage", "tenant")(http.HandlerFunc(camHandler.SetONVIFEvents))))
+	mux.Handle("POST /api/v1/cameras/{id}/favorite", Protect(permsMiddleware.RequirePermission("cameras.list", "tenant")(http.HandlerFunc(camHandler.AddFavorite))))
+	mux.Handle("DELETE /api/v1/cameras/{id}/favorite", Protect(permsMiddleware.RequirePermission("cameras.list", "tenant")(http.HandlerFunc(camHandler.RemoveFavorite))))
 
 	// Credentials (Phase 2.2)
 	mux.Handle("PUT /api/v1/cameras/{id}/credentials", Protect(http.HandlerFunc(credHandler.Update)))
 	mux.Handle("GET /api/v1/cameras/{id}/credentials", Protect(http.HandlerFunc(credHandler.Get)))
+	mux.Handle("POST /api/v1/cameras/{id}/credentials/reveal", Protect(http.HandlerFunc(credHandler.Reveal)))
+	mux.Handle("POST /api/v1/cameras/{id}/credentials/rekey", Protect(http.HandlerFunc(credHandler.Rekey)))
+	mux.Handle("GET /api/v1/cameras/credentials/inventory", Protect(permsMiddleware.RequirePermission("audit.read", "tenant")(http.HandlerFunc(credHandler.Inventory))))
 	mux.Handle("DELETE /api/v1/cameras/{id}/credentials", Protect(http.HandlerFunc(credHandler.Delete)))
+	mux.Handle("DELETE /api/v1/sites/{id}/credentials", Protect(http.HandlerFunc(credHandler.DeleteSite)))
 
 	// Discovery (Phase 2.3)
 	mux.Handle("POST /api/v1/onvif/credentials", Protect(http.HandlerFunc(discHandler.CreateCredential)))
@@ -472,6 +989,9 @@ func main() {
 	mux.Handle("POST /api/v1/cameras/{id}/select-media-profiles", Protect(permsMiddleware.RequirePermission("camera.media.select", "tenant")(http.HandlerFunc(mediaHandler.SelectProfiles))))
 	mux.Handle("GET /api/v1/cameras/{id}/media-selection", Protect(permsMiddleware.RequirePermission("camera.media.read", "tenant")(http.HandlerFunc(mediaHandler.GetSelection))))
 	mux.Handle("POST /api/v1/cameras/{id}/validate-rtsp", Protect(permsMiddleware.RequirePermission("camera.media.validate", "tenant")(http.HandlerFunc(mediaHandler.ValidateRTSP))))
+	mux.Handle("GET /api/v1/cameras/{id}/validation-history", Protect(permsMiddleware.RequirePermission("camera.media.read", "tenant")(http.HandlerFunc(mediaHandler.ValidationHistory))))
+	mux.Handle("GET /api/v1/cameras/{id}/imaging", Protect(permsMiddleware.RequirePermission("camera.imaging.read", "tenant")(http.HandlerFunc(imagingHandler.Get))))
+	mux.Handle("PUT /api/v1/cameras/{id}/imaging", Protect(permsMiddleware.RequirePermission("camera.imaging.write", "tenant")(http.HandlerFunc(imagingHandler.Update))))
 
 	// Health (Phase 2.5)
 	// Permissions:
@@ -481,10 +1001,20 @@ func main() {
 
 	mux.Handle("GET /api/v1/cameras/health", Protect(permsMiddleware.RequirePermission("camera.health.read", "tenant")(http.HandlerFunc(healthHandler.GetHealth))))
 	mux.Handle("GET /api/v1/cameras/{id}/health", Protect(permsMiddleware.RequirePermission("camera.health.read", "tenant")(http.HandlerFunc(healthHandler.GetCameraHealth))))
+	camStatusHandler := &api.CameraStatusHandler{Cameras: camService, Health: healthService, Links: &nvrRepo, Validations: mediaService}
+	if mediaClient != nil {
+		camStatusHandler.Ingest = mediaClient
+	}
+	mux.Handle("GET /api/v1/cameras/{id}/status", Protect(permsMiddleware.RequirePermission("camera.health.read", "tenant")(http.HandlerFunc(camStatusHandler.GetStatus))))
 	mux.Handle("GET /api/v1/cameras/{id}/health/history", Protect(permsMiddleware.RequirePermission("camera.health.read", "tenant")(http.HandlerFunc(healthHandler.GetHistory))))
 	mux.Handle("GET /api/v1/alerts/cameras", Protect(permsMiddleware.RequirePermission("alerts.read", "tenant")(http.HandlerFunc(healthHandler.ListAlerts))))
 	mux.Handle("POST /api/v1/cameras/{id}/health-recheck", Protect(permsMiddleware.RequirePermission("camera.health.recheck", "tenant")(http.HandlerFunc(healthHandler.ManualRecheck))))
 
+	// Maintenance mode
+	maintenanceHandler := &api.MaintenanceHandler{Switch: maintenanceSwitch, Audit: auditService}
+	mux.Handle("GET /api/v1/admin/maintenance", Protect(permsMiddleware.RequirePermission("admin.maintenance.read", "tenant")(http.HandlerFunc(maintenanceHandler.Get))))
+	mux.Handle("PUT /api/v1/admin/maintenance", Protect(permsMiddleware.RequirePermission("admin.maintenance.write", "tenant")(http.HandlerFunc(maintenanceHandler.SetTenant))))
+
 	// WS Signaling (Phase 3.4 Fix 4)
 	wsHandler := api.NewSfuWsHandler(tokenMgr)
 	mux.HandleFunc("/api/v1/sfu/ws", wsHandler.ServeWS)
@@ -506,6 +1036,8 @@ func main() {
 	mux.Handle("GET /api/v1/nvrs/{id}", Protect(permsMiddleware.RequirePermission("nvr.read", "tenant")(http.HandlerFunc(nvrHandler.Get))))
 	mux.Handle("PUT /api/v1/nvrs/{id}", Protect(permsMiddleware.RequirePermission("nvr.write", "tenant")(http.HandlerFunc(nvrHandler.Update))))
 	mux.Handle("DELETE /api/v1/nvrs/{id}", Protect(permsMiddleware.RequirePermission("nvr.delete", "tenant")(http.HandlerFunc(nvrHandler.Delete))))
+	mux.Handle("GET /api/v1/nvrs/default-recording-mode", Protect(permsMiddleware.RequirePermission("nvr.read", "tenant")(http.HandlerFunc(nvrHandler.GetDefaultRecordingMode))))
+	mux.Handle("PUT /api/v1/nvrs/default-recording-mode", Protect(permsMiddleware.RequirePermission("nvr.write", "tenant")(http.HandlerFunc(nvrHandler.SetDefaultRecordingMode))))
 
 	// Linking
 	mux.Handle("PUT /api/v1/nvrs/{id}/cameras", Protect(permsMiddleware.RequirePermission("nvr.link.write", "tenant")(http.HandlerFunc(nvrHandler.UpsertLinks))))
@@ -515,6 +1047,8 @@ func main() {
 	// NVR Credentials
 	mux.Handle("PUT /api/v1/nvrs/{id}/credentials", Protect(permsMiddleware.RequirePermission("nvr.credential.write", "tenant")(http.HandlerFunc(nvrHandler.SetCredentials))))
 	mux.Handle("GET /api/v1/nvrs/{id}/credentials", Protect(permsMiddleware.RequirePermission("nvr.credential.read", "tenant")(http.HandlerFunc(nvrHandler.GetCredentials))))
+	mux.Handle("GET /api/v1/nvrs/credentials/health", Protect(permsMiddleware.RequirePermission("admin.nvr.credential.health", "tenant")(http.HandlerFunc(nvrHandler.CredentialHealth))))
+	mux.Handle("POST /api/v1/nvrs/credentials/migrate-aad", Protect(permsMiddleware.RequirePermission("nvr.credential.write", "tenant")(http.HandlerFunc(nvrHandler.MigrateCredentialAAD))))
 	mux.Handle("DELETE /api/v1/nvrs/{id}/credentials", Protect(permsMiddleware.RequirePermission("nvr.credential.delete", "tenant")(http.HandlerFunc(nvrHandler.DeleteCredentials))))
 
 	// NVR Adapter Routes (Phase 2.7)
@@ -533,9 +1067,68 @@ func main() {
 
 	// Start NVR Scheduler
 	nvrService.StartDailySync(context.Background())
+	nvrService.StartOrphanChannelReconciler(context.Background(), 10*time.Minute)
 
 	// NVR Monitor (Phase 2.9)
 	nvrMonitor := nvr.NewMonitor(nvrService, &nvrRepo)
+	nvrMonitor.Maintenance = maintenanceSwitch
+	var monCfg struct {
+		NVR struct {
+			MaxChannelsPerNVR    int    `yaml:"max_channels_per_nvr"`
+			VendorDetectTimeout  string `yaml:"vendor_detect_timeout"`
+			CredentialAADVersion int    `yaml:"credential_aad_version"`
+			HealthSummaryTTL     string `yaml:"health_summary_cache_ttl"`
+			AdapterClientIdleTTL string `yaml:"adapter_client_idle_ttl"`
+			DescribeProbe        bool   `yaml:"describe_probe"`
+		} `yaml:"nvr"`
+		NVRMonitor struct {
+			ChannelOfflineAfterFailures int `yaml:"channel_offline_after_failures"`
+		} `yaml:"nvr_monitor"`
+	}
+	_ = yaml.Unmarshal(cfgData, &monCfg)
+	if monCfg.NVR.MaxChannelsPerNVR > 0 {
+		nvrService.MaxChannelsPerNVR = monCfg.NVR.MaxChannelsPerNVR
+	}
+	nvrService.DescribeProbe = monCfg.NVR.DescribeProbe
+	if monCfg.NVR.VendorDetectTimeout != "" {
+		if d, err := time.ParseDuration(monCfg.NVR.VendorDetectTimeout); err == nil && d > 0 {
+			nvrService.VendorDetectTimeout = d
+		} else {
+			log.Printf("Warning: nvr.vendor_detect_timeout %q invalid, using %s", monCfg.NVR.VendorDetectTimeout, nvr.DefaultVendorDetectTimeout)
+		}
+	}
+	summaryTTL := nvr.DefaultHealthSummaryTTL
+	if monCfg.NVR.HealthSummaryTTL != "" {
+		if d, err := time.ParseDuration(monCfg.NVR.HealthSummaryTTL); err == nil && d >= 0 {
+			summaryTTL = d
+		} else {
+			log.Printf("Warning: nvr.health_summary_cache_ttl %q invalid, using %s", monCfg.NVR.HealthSummaryTTL, nvr.DefaultHealthSummaryTTL)
+		}
+	}
+	if summaryTTL > 0 {
+		nvrService.HealthSummaryCache = nvr.NewHealthSummaryCache(rdb, summaryTTL)
+	}
+	clientIdleTTL := adapters.DefaultClientIdleTTL
+	if monCfg.NVR.AdapterClientIdleTTL != "" {
+		if d, err := time.ParseDuration(monCfg.NVR.AdapterClientIdleTTL); err == nil && d >= 0 {
+			clientIdleTTL = d
+		} else {
+			log.Printf("Warning: nvr.adapter_client_idle_ttl %q invalid, using %s", monCfg.NVR.AdapterClientIdleTTL, adapters.DefaultClientIdleTTL)
+		}
+	}
+	if clientIdleTTL > 0 {
+		nvrService.AdapterClients = adapters.NewClientPool(clientIdleTTL)
+	}
+	switch v := monCfg.NVR.CredentialAADVersion; v {
+	case 0:
+	case nvr.CredentialAADv1, nvr.CredentialAADv2:
+		nvrService.CredentialAADVersion = v
+	default:
+		log.Printf("Warning: nvr.credential_aad_version %d unknown, using %d", v, nvr.DefaultCredentialAADVersion)
+	}
+	if monCfg.NVRMonitor.ChannelOfflineAfterFailures > 0 {
+		nvrMonitor.ChannelOfflineAfterFailures = monCfg.NVRMonitor.ChannelOfflineAfterFailures
+	}
 	nvrMonitor.Start(context.Background())
 
 	// NVR Health API
@@ -553,10 +1146,14 @@ func main() {
 
 	mux.Handle("GET /api/v1/audit/events", Protect(permsMiddleware.RequirePermission("audit.read", "tenant")(http.HandlerFunc(auditHandler.GetEvents))))
 	mux.Handle("POST /api/v1/audit/exports", Protect(permsMiddleware.RequirePermission("audit.export", "tenant")(http.HandlerFunc(auditHandler.ExportEvents))))
+	mux.Handle("GET /api/v1/audit/exports/{id}", Protect(permsMiddleware.RequirePermission("audit.export", "tenant")(http.HandlerFunc(auditHandler.GetExportJob))))
+	mux.Handle("GET /api/v1/audit/exports/{id}/download", Protect(permsMiddleware.RequirePermission("audit.export", "tenant")(http.HandlerFunc(auditHandler.DownloadExport))))
+	mux.Handle("GET /api/v1/audit/verify", Protect(permsMiddleware.RequirePermission("audit.read", "tenant")(http.HandlerFunc(auditHandler.VerifyChain))))
 
 	mux.Handle("GET /api/v1/license/status", Protect(permsMiddleware.RequirePermission("license.read", "tenant")(http.HandlerFunc(licenseHandler.GetStatus))))
 	mux.Handle("POST /api/v1/license/reload", Protect(permsMiddleware.RequirePermission("license.manage", "tenant")(http.HandlerFunc(licenseHandler.Reload))))
 
+	mux.Handle("GET /api/v1/users", Protect(permsMiddleware.RequirePermission("user.read", "tenant")(http.HandlerFunc(userHandler.ListUsers))))
 	mux.Handle("GET /api/v1/users/{id}", Protect(permsMiddleware.RequirePermission("user.read", "tenant")(http.HandlerFunc(userHandler.GetUser))))
 	mux.Handle("POST /api/v1/users", Protect(permsMiddleware.RequirePermission("user.create", "tenant")(http.HandlerFunc(userHandler.CreateUser))))
 	mux.Handle("POST /api/v1/users/{id}/disable", Protect(permsMiddleware.RequirePermission("user.disable", "tenant")(http.HandlerFunc(userHandler.DisableUser))))
@@ -575,14 +1172,46 @@ func main() {
 
 	// Metrics (Phase 3.5)
 	internalHandler := api.NewInternalHandler(liveService)
+	internalHandler.RTSP = mediaService
+	internalHandler.Thumbnails = camService
+	internalHandler.MaxSnapshotDimension = licCfg.Cameras.SnapshotMaxDim
+	snapshotTTL := live.DefaultSnapshotCacheTTL
+	if d, err := time.ParseDuration(licCfg.Cameras.SnapshotTTL); err == nil {
+		snapshotTTL = d
+	}
+	internalHandler.Snapshots = live.NewSnapshotFlight(snapshotTTL)
+	if v := licCfg.Cameras.SnapshotViaNVR; v == nil || *v {
+		internalHandler.NVRSnapshots = nvrService
+	}
+	internalHandler.MaxActiveCameras = licCfg.Cameras.MaxAICameras
+	internalHandler.SnapshotLimiter = limiter
+	internalHandler.SnapshotLimit = licCfg.Cameras.SnapshotLimit
+	// ENABLE_HTTP_INGEST=true predates live.http_ingest and still enables it.
+	internalHandler.HTTPIngest = liveCfg.Live.HTTPIngest || os.Getenv("ENABLE_HTTP_INGEST") == "true"
+	internalHandler.Scope.LogAccess = licCfg.Cameras.AIServiceScope.LogAccess
+	if tenants := licCfg.Cameras.AIServiceScope.Tenants; len(tenants) > 0 {
+		internalHandler.Scope.Tenants = make(map[uuid.UUID]bool, len(tenants))
+		for _, tid := range tenants {
+			id, err := uuid.Parse(tid)
+			if err != nil {
+				// Skipping could leave the scope empty, i.e. global.
+				log.Fatalf("cameras.ai_service_scope.tenants: invalid tenant id %q", tid)
+			}
+			internalHandler.Scope.Tenants[id] = true
+		}
+		log.Printf("Internal camera endpoints scoped to %d tenant(s)", len(tenants))
+	}
 	// Routes
 	mux.Handle("POST /api/v1/cameras/{id}/live/start", Protect(http.HandlerFunc(liveHandler.StartSession)))
+	mux.Handle("POST /api/v1/live/{session_id}/stop", Protect(http.HandlerFunc(liveHandler.StopSession)))
 	mux.Handle("POST /api/v1/live/events", Protect(http.HandlerFunc(liveHandler.RecordEvent)))
 
 	// Phase 3.8: Overlay & Polling
 	mux.Handle("POST /api/v1/live/{session_id}/overlay/enable", Protect(http.HandlerFunc(liveHandler.EnableOverlay)))
 	mux.Handle("POST /api/v1/live/{session_id}/overlay/disable", Protect(http.HandlerFunc(liveHandler.DisableOverlay)))
 	mux.Handle("GET /api/v1/cameras/{id}/detections/latest", Protect(http.HandlerFunc(liveHandler.GetLatestDetection)))
+	mux.Handle("POST /api/v1/live/detections/batch", Protect(http.HandlerFunc(liveHandler.GetLatestDetectionsBatch)))
+	mux.Handle("GET /api/v1/live/{id}/detections/recent", Protect(http.HandlerFunc(liveHandler.GetRecentDetections)))
 	mux.Handle("GET /api/v1/cameras/{id}/snapshot", Protect(http.HandlerFunc(liveHandler.GetSnapshot)))
 
 	// Phase 3.8: Internal AI Service
@@ -590,6 +1219,9 @@ func main() {
 	mux.Handle("GET /api/v1/internal/cameras/active", internalHandler.ServiceAuthMiddleware(http.HandlerFunc(internalHandler.GetActiveCameras)))
 
 	mux.Handle("GET /api/v1/internal/cameras/{id}/snapshot", internalHandler.ServiceAuthMiddleware(http.HandlerFunc(internalHandler.GetInternalSnapshot)))
+	mux.Handle("GET /api/v1/internal/cameras/thumbnails/due", internalHandler.ServiceAuthMiddleware(http.HandlerFunc(internalHandler.ListThumbnailsDue)))
+	mux.Handle("POST /api/v1/internal/cameras/{id}/thumbnail", internalHandler.ServiceAuthMiddleware(http.HandlerFunc(internalHandler.MarkThumbnailCaptured)))
+	mux.Handle("GET /api/v1/internal/cameras/{id}/rtsp", internalHandler.ServiceAuthMiddleware(http.HandlerFunc(internalHandler.GetCameraRTSP)))
 
 	// Metrics (Phase 3.5)
 	metricsCfg := metrics.Config{
@@ -612,12 +1244,38 @@ func main() {
 
 	// Wrap TOP Level Mux with Global Rate Limiter -> Audit Logger -> RequestLogger
 
-	// CORS -> RequestLogger -> RateLimit -> Audit -> Mux
-	auditWrappedMux := auditMiddleware.LogRequest(mux)
+	// CORS (configurable, answers preflight before auth) -> RequestLogger -> RateLimit -> Audit -> Timeouts -> Concurrency -> Mux
+	timeoutCfg := struct {
+		Timeouts *middleware.TimeoutConfig `yaml:"http_timeouts"`
+	}{}
+	_ = yaml.Unmarshal(cfgData, &timeoutCfg)
+	timeoutPolicy := middleware.DefaultTimeoutConfig()
+	if timeoutCfg.Timeouts != nil {
+		timeoutPolicy = *timeoutCfg.Timeouts
+	}
+	concurrencyCfg := struct {
+		Concurrency *middleware.ConcurrencyConfig `yaml:"http_concurrency"`
+	}{}
+	_ = yaml.Unmarshal(cfgData, &concurrencyCfg)
+	concurrencyPolicy := middleware.DefaultConcurrencyConfig()
+	if concurrencyCfg.Concurrency != nil {
+		concurrencyPolicy = *concurrencyCfg.Concurrency
+	}
+	// Concurrency slots sit inside the timeouts so they are held until the handler returns
+	timedMux := middleware.NewRequestTimeouts(timeoutPolicy)(middleware.NewConcurrencyLimiter(concurrencyPolicy)(mux))
+	auditWrappedMux := auditMiddleware.LogRequest(timedMux)
 	rlWrappedMux := rlMiddleware.GlobalLimiter(auditWrappedMux)
 	// A1: Add Request Logger
 	loggingWrappedMux := middleware.RequestLogger(rlWrappedMux)
-	finalHandler := middleware.CORS(loggingWrappedMux)
+	corsCfg := struct {
+		CORS *middleware.CORSConfig `yaml:"cors"`
+	}{}
+	_ = yaml.Unmarshal(cfgData, &corsCfg)
+	corsPolicy := middleware.DefaultCORSConfig()
+	if corsCfg.CORS != nil {
+		corsPolicy = *corsCfg.CORS
+	}
+	finalHandler := middleware.NewCORS(corsPolicy)(loggingWrappedMux)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -660,6 +1318,15 @@ func main() {
 	defer cancel()
 
 	healthScheduler.Stop()
+	if camWebhooks != nil {
+		camWebhooks.Stop()
+	}
+	if healthWebhooks != nil {
+		healthWebhooks.Stop()
+	}
+	if onvifBridge != nil {
+		onvifBridge.Stop()
+	}
 	if nvrPoller != nil {
 		nvrPoller.Stop()
 	}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"github.com/technosupport/ts-vms/internal/data"
+)
+
+// ensureSchema blocks startup until the database is at data.RequiredSchemaVersion.
+// With autoMigrate set, a missing or behind schema is migrated up in place; a
+// dirty schema always needs an operator (migrator -force) and is refused.
+func ensureSchema(ctx context.Context, db *sql.DB, autoMigrate bool, migrationsPath string) error {
+	st, err := data.ReadSchemaStatus(ctx, db)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	err = st.Check(data.RequiredSchemaVersion)
+	if err == nil {
+		log.Printf("Schema version %d (required >= %d)", st.Version, data.RequiredSchemaVersion)
+		return nil
+	}
+	if errors.Is(err, data.ErrSchemaDirty) || !autoMigrate {
+		return err
+	}
+
+	log.Printf("Schema not ready (%v); running migrations from %s", err, migrationsPath)
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return fmt.Errorf("create migrate driver: %w", err)
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+migrationsPath, "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("init migrate: %w", err)
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+
+	st, err = data.ReadSchemaStatus(ctx, db)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if err := st.Check(data.RequiredSchemaVersion); err != nil {
+		return err
+	}
+	log.Printf("Migrated schema to version %d", st.Version)
+	return nil
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/tokens"
 )
 
 type Claims struct {
@@ -17,6 +19,10 @@ type Claims struct {
 }
 
 func main() {
+	issuer := flag.String("iss", tokens.DefaultIssuer, "issuer claim")
+	audience := flag.String("aud", tokens.AudienceControlPlane, "audience claim ("+tokens.AudienceControlPlane+" or "+tokens.AudienceHLS+")")
+	flag.Parse()
+
 	key := []byte("dev-secret-do-not-use-in-prod")
 
 	userID := "00000000-0000-0000-0000-000000000002"
@@ -33,6 +39,8 @@ func main() {
 			NotBefore: jwt.NewNumericDate(now),
 			ID:        uuid.New().String(),
 			Subject:   userID,
+			Issuer:    *issuer,
+			Audience:  jwt.ClaimStrings{*audience},
 		},
 	}
 
@@ -44,7 +52,7 @@ func main() {
 		panic(err)
 	}
 
-	fmt.Println("Generated 24h Token:")
+	fmt.Printf("Generated 24h Token (aud=%s):\n", *audience)
 	fmt.Println(tokenString)
 	os.WriteFile("token.txt", []byte(tokenString), 0644)
 }
@@ -0,0 +1,2 @@
+ALTER TABLE onvif_discovery_runs DROP COLUMN IF EXISTS truncated;
+ALTER TABLE onvif_discovery_runs DROP COLUMN IF EXISTS max_devices;
@@ -0,0 +1,3 @@
+-- Per-run device cap and truncation flag for ONVIF discovery
+ALTER TABLE onvif_discovery_runs ADD COLUMN IF NOT EXISTS max_devices INT NOT NULL DEFAULT 4096;
+ALTER TABLE onvif_discovery_runs ADD COLUMN IF NOT EXISTS truncated BOOLEAN NOT NULL DEFAULT FALSE;
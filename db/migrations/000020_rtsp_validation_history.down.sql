@@ -0,0 +1 @@
+DROP TABLE IF EXISTS rtsp_validation_history;
@@ -0,0 +1,23 @@
+-- Append-only RTSP validation timeline (rtsp_validation_results keeps the latest row only)
+CREATE TABLE IF NOT EXISTS rtsp_validation_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    camera_id UUID NOT NULL,
+    variant TEXT NOT NULL,
+
+    status TEXT NOT NULL,
+    last_error_code TEXT,
+    rtt_ms INT,
+
+    validated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_history_variant CHECK (variant IN ('main', 'sub'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_rtsp_validation_history_camera
+    ON rtsp_validation_history (camera_id, variant, validated_at DESC);
+
+ALTER TABLE rtsp_validation_history ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY validation_history_isolation ON rtsp_validation_history
+    USING (tenant_id = current_setting('app.current_tenant')::uuid);
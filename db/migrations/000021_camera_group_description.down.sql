@@ -0,0 +1 @@
+ALTER TABLE camera_groups DROP COLUMN IF EXISTS description;
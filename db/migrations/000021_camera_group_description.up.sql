@@ -0,0 +1,2 @@
+-- Optional group description (capped at 500 chars, validated in the service layer)
+ALTER TABLE camera_groups ADD COLUMN IF NOT EXISTS description VARCHAR(500);
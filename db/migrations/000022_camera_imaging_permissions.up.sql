@@ -0,0 +1,20 @@
+-- ONVIF imaging (brightness/contrast/focus) permissions
+INSERT INTO permissions (name, description) VALUES
+('camera.imaging.read', 'View Camera Imaging Settings'),
+('camera.imaging.write', 'Adjust Camera Imaging Settings')
+ON CONFLICT (name) DO NOTHING;
+
+DO $$
+DECLARE
+    admin_role_id UUID;
+    perm RECORD;
+BEGIN
+    SELECT id INTO admin_role_id FROM roles WHERE name = 'Admin';
+    IF admin_role_id IS NOT NULL THEN
+        FOR perm IN SELECT id FROM permissions WHERE name LIKE 'camera.imaging.%' LOOP
+            INSERT INTO role_permissions (role_id, permission_id)
+            VALUES (admin_role_id, perm.id)
+            ON CONFLICT DO NOTHING;
+        END LOOP;
+    END IF;
+END $$;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_audit_chain_seq;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS chain_seq;
@@ -0,0 +1,7 @@
+-- Per-tenant hash chain for tamper evidence (populated when audit.hash_chain is enabled)
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS chain_seq BIGINT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hash TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_chain_seq
+    ON audit_logs (tenant_id, chain_seq) WHERE chain_seq IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Per-user camera favorites (pins) for quick access in the camera list
+CREATE TABLE IF NOT EXISTS camera_favorites (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    camera_id UUID NOT NULL REFERENCES cameras(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, camera_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_camera_favorites_tenant_user
+    ON camera_favorites (tenant_id, user_id);
+
+ALTER TABLE camera_favorites ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY camera_favorites_isolation ON camera_favorites
+    USING (tenant_id = current_setting('app.current_tenant')::uuid);
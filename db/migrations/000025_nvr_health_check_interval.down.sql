@@ -0,0 +1 @@
+ALTER TABLE nvrs DROP COLUMN IF EXISTS health_check_interval_seconds;
@@ -0,0 +1,5 @@
+-- Per-NVR health check cadence. The monitor only enqueues an NVR once its
+-- interval has elapsed since the last check.
+ALTER TABLE nvrs
+    ADD COLUMN health_check_interval_seconds INT NOT NULL DEFAULT 60
+    CHECK (health_check_interval_seconds BETWEEN 10 AND 86400);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS camera_nvr_links_channel_key;
@@ -0,0 +1,15 @@
+-- Drop duplicate channel links left by earlier versions, keeping the oldest.
+DELETE FROM camera_nvr_links a
+WHERE a.nvr_channel_ref IS NOT NULL
+  AND EXISTS (
+    SELECT 1 FROM camera_nvr_links b
+    WHERE b.tenant_id = a.tenant_id AND b.nvr_id = a.nvr_id
+      AND b.nvr_channel_ref = a.nvr_channel_ref
+      AND (b.created_at, b.id) < (a.created_at, a.id)
+  );
+
+-- A channel feeds exactly one camera. Partial so links without a channel
+-- reference (manual links) are unaffected.
+CREATE UNIQUE INDEX IF NOT EXISTS camera_nvr_links_channel_key
+    ON camera_nvr_links (tenant_id, nvr_id, nvr_channel_ref)
+    WHERE nvr_channel_ref IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Latest thumbnail capture per camera; drives the thumbnail refresh job
+CREATE TABLE IF NOT EXISTS camera_thumbnails (
+    camera_id UUID PRIMARY KEY REFERENCES cameras(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    captured_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_camera_thumbnails_captured_at
+    ON camera_thumbnails (captured_at);
+
+ALTER TABLE camera_thumbnails ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY camera_thumbnails_isolation ON camera_thumbnails
+    USING (tenant_id = current_setting('app.current_tenant')::uuid);
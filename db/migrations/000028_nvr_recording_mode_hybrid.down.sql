@@ -0,0 +1,6 @@
+ALTER TABLE tenants DROP COLUMN IF EXISTS default_recording_mode;
+
+UPDATE camera_nvr_links SET recording_mode = 'vms' WHERE recording_mode = 'hybrid';
+ALTER TABLE camera_nvr_links DROP CONSTRAINT IF EXISTS camera_nvr_links_recording_mode_check;
+ALTER TABLE camera_nvr_links ADD CONSTRAINT camera_nvr_links_recording_mode_check
+    CHECK (recording_mode IN ('vms', 'nvr'));
@@ -0,0 +1,9 @@
+-- 'hybrid' records on both the VMS and the NVR.
+ALTER TABLE camera_nvr_links DROP CONSTRAINT IF EXISTS camera_nvr_links_recording_mode_check;
+ALTER TABLE camera_nvr_links ADD CONSTRAINT camera_nvr_links_recording_mode_check
+    CHECK (recording_mode IN ('vms', 'nvr', 'hybrid'));
+
+-- Recording mode applied to links created by NVR channel provisioning when
+-- the request does not name one.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS default_recording_mode TEXT NOT NULL DEFAULT 'vms'
+    CHECK (default_recording_mode IN ('vms', 'nvr', 'hybrid'));
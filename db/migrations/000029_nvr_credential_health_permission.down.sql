@@ -0,0 +1,2 @@
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE name = 'admin.nvr.credential.health');
+DELETE FROM permissions WHERE name = 'admin.nvr.credential.health';
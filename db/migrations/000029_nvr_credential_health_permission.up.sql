@@ -0,0 +1,16 @@
+-- Fleet check of NVR credential decryptability (admin only)
+INSERT INTO permissions (name, description) VALUES
+('admin.nvr.credential.health', 'Check NVR Credential Health')
+ON CONFLICT (name) DO NOTHING;
+
+DO $$
+DECLARE
+    admin_role_id UUID;
+BEGIN
+    SELECT id INTO admin_role_id FROM roles WHERE name = 'Admin';
+    IF admin_role_id IS NOT NULL THEN
+        INSERT INTO role_permissions (role_id, permission_id)
+        SELECT admin_role_id, id FROM permissions WHERE name = 'admin.nvr.credential.health'
+        ON CONFLICT DO NOTHING;
+    END IF;
+END $$;
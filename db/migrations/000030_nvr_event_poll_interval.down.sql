@@ -0,0 +1 @@
+ALTER TABLE nvrs DROP COLUMN IF EXISTS event_poll_interval_ms;
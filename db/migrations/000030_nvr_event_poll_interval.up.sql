@@ -0,0 +1,5 @@
+-- Per-NVR event poll cadence. NULL polls at the global
+-- events.nvr.poll_interval_ms.
+ALTER TABLE nvrs
+    ADD COLUMN event_poll_interval_ms INT
+    CHECK (event_poll_interval_ms BETWEEN 500 AND 3600000);
@@ -0,0 +1,6 @@
+-- How the monitor probes channel liveness: an RTSP OPTIONS handshake, or a
+-- still image from the NVR/ONVIF snapshot endpoint for devices that rate
+-- limit or reject RTSP probes.
+ALTER TABLE nvrs
+    ADD COLUMN health_probe_mode TEXT NOT NULL DEFAULT 'rtsp'
+    CHECK (health_probe_mode IN ('rtsp', 'snapshot'));
@@ -0,0 +1,3 @@
+ALTER TABLE cameras
+    DROP COLUMN IF EXISTS last_changed_at,
+    DROP COLUMN IF EXISTS last_changed_by;
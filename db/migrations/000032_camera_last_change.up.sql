@@ -0,0 +1,5 @@
+-- Who last changed a camera's configuration, and when. NULL until the first
+-- update after this migration; last_changed_by is NULL for system changes.
+ALTER TABLE cameras
+    ADD COLUMN last_changed_at TIMESTAMPTZ,
+    ADD COLUMN last_changed_by UUID;
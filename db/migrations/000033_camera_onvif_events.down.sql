@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_cameras_onvif_events;
+ALTER TABLE cameras
+    DROP COLUMN IF EXISTS onvif_events_enabled;
@@ -0,0 +1,7 @@
+-- Opt-in ONVIF PullPoint event subscription for cameras connected directly
+-- (no NVR, no AI service).
+ALTER TABLE cameras
+    ADD COLUMN onvif_events_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_cameras_onvif_events ON cameras(tenant_id)
+    WHERE onvif_events_enabled = TRUE AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- Site used by camera creation when the request omits site_id. NULL: no
+-- default, site_id is required.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS default_site_id UUID REFERENCES sites(id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+-- NOT VALID: rows already carrying the system actor (or a deleted user) are
+-- kept as they are; the hash chain covers actor_user_id.
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_actor_user_id_fkey
+    FOREIGN KEY (actor_user_id) REFERENCES users(id) ON DELETE SET NULL NOT VALID;
@@ -0,0 +1,4 @@
+-- Background jobs record a system actor (audit.system_actor_id) that is not a
+-- users row, so actor_user_id can no longer reference users. Audit rows also
+-- keep their actor once the user is deleted.
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_actor_user_id_fkey;
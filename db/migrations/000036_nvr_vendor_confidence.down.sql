@@ -0,0 +1 @@
+ALTER TABLE nvrs DROP COLUMN IF EXISTS vendor_confidence;
@@ -0,0 +1,5 @@
+-- Confidence (0-1) of the vendor picked by auto-detection (vendor "auto" on
+-- create/update). NULL: the vendor was chosen by the operator.
+ALTER TABLE nvrs
+    ADD COLUMN vendor_confidence REAL
+    CHECK (vendor_confidence IS NULL OR (vendor_confidence >= 0 AND vendor_confidence <= 1));
@@ -0,0 +1 @@
+ALTER TABLE nvr_credentials DROP COLUMN IF EXISTS aad_version;
@@ -0,0 +1,5 @@
+-- AAD format the credential is bound to (1: "{tenant}:{nvr}:nvr_credential_v1",
+-- 2: "ts-vms/nvr_credential/v2/{tenant}/{nvr}"). Existing rows are v1.
+ALTER TABLE nvr_credentials
+    ADD COLUMN aad_version SMALLINT NOT NULL DEFAULT 1
+    CHECK (aad_version IN (1, 2));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_camera_alerts_open;
+ALTER TABLE camera_alerts DROP COLUMN IF EXISTS last_seen_at;
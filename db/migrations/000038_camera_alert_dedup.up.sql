@@ -0,0 +1,19 @@
+-- At most one open alert per (camera, type). Repeated detections refresh
+-- last_seen_at on the open alert instead of inserting duplicates.
+ALTER TABLE camera_alerts
+    ADD COLUMN last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+UPDATE camera_alerts SET last_seen_at = COALESCE(ended_at, started_at);
+
+-- Close duplicates left by earlier versions, keeping the oldest open alert.
+UPDATE camera_alerts a
+SET state = 'closed', ended_at = NOW()
+WHERE a.state = 'open'
+  AND EXISTS (
+    SELECT 1 FROM camera_alerts b
+    WHERE b.camera_id = a.camera_id AND b.type = a.type AND b.state = 'open'
+      AND (b.started_at, b.id) < (a.started_at, a.id)
+  );
+
+CREATE UNIQUE INDEX uq_camera_alerts_open
+    ON camera_alerts (camera_id, type) WHERE state = 'open';
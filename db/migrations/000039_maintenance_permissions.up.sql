@@ -0,0 +1,18 @@
+-- Maintenance mode (pauses background pollers). Tenant-scoped read/write go
+-- to Admin; system-wide maintenance is set in config only.
+INSERT INTO permissions (name, description) VALUES
+('admin.maintenance.read', 'View Maintenance Mode'),
+('admin.maintenance.write', 'Set Tenant Maintenance Mode')
+ON CONFLICT (name) DO NOTHING;
+
+DO $$
+DECLARE
+    admin_role_id UUID;
+BEGIN
+    SELECT id INTO admin_role_id FROM roles WHERE name = 'Admin';
+    IF admin_role_id IS NOT NULL THEN
+        INSERT INTO role_permissions (role_id, permission_id)
+        SELECT admin_role_id, id FROM permissions WHERE name IN ('admin.maintenance.read', 'admin.maintenance.write')
+        ON CONFLICT DO NOTHING;
+    END IF;
+END $$;
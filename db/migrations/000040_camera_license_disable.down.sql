@@ -0,0 +1,3 @@
+ALTER TABLE tenants DROP COLUMN IF EXISTS camera_default_enabled;
+DROP INDEX IF EXISTS idx_cameras_license_disabled;
+ALTER TABLE cameras DROP COLUMN IF EXISTS license_disabled_at;
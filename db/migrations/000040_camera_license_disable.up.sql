@@ -0,0 +1,11 @@
+-- Cameras disabled by startup license reconciliation are marked so they can
+-- be re-enabled once the license allows it again. Any manual enable/disable
+-- clears the mark.
+ALTER TABLE cameras ADD COLUMN IF NOT EXISTS license_disabled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_cameras_license_disabled
+    ON cameras (tenant_id, created_at)
+    WHERE license_disabled_at IS NOT NULL AND deleted_at IS NULL;
+
+-- Enabled state applied to cameras created without is_enabled.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS camera_default_enabled BOOLEAN NOT NULL DEFAULT TRUE;
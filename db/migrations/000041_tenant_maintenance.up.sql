@@ -0,0 +1,3 @@
+-- Tenant maintenance mode (pollers paused) survives restarts and is shared
+-- by every control-plane instance.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS maintenance_since TIMESTAMPTZ;
@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,6 +17,7 @@ import (
 type AuditHandler struct {
 	Service *audit.Service
 	Perms   *middleware.PermissionMiddleware
+	Exports *audit.ExportJobs // Async exports; nil disables async=true
 }
 
 func (h *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
@@ -31,14 +34,8 @@ func (h *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		Cursor: q.Get("cursor"),
 	}
 
-	if limitStr := q.Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			filter.Limit = l
-		}
-	}
-	if filter.Limit == 0 || filter.Limit > 100 {
-		filter.Limit = 50
-	}
+	// Cursor-based: offset is not used
+	filter.Limit, _ = ParsePagination(r, 50, 100)
 
 	// Tenant Isolation
 	ac, ok := middleware.GetAuthContext(r.Context())
@@ -63,7 +60,7 @@ func (h *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 
 	events, nextCursor, err := h.Service.QueryEvents(r.Context(), filter)
 	if err != nil {
-		http.Error(w, "Query Failed", http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -74,6 +71,10 @@ func (h *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// POST /api/v1/audit/exports?from=&to=[&async=true]
+// Sync exports stream JSONL within the tenant's row/range limits; a request
+// past them gets 400 naming the limit. async=true starts a job instead
+// (202 + job_id) whose file is fetched from /exports/{id}/download.
 func (h *AuditHandler) ExportEvents(w http.ResponseWriter, r *http.Request) {
 	// RBAC: audit.export
 	// Tenant Isolation
@@ -82,30 +83,175 @@ func (h *AuditHandler) ExportEvents(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	// Filters (Similar to GetEvents but likely larger range)
-	// For export, we require time range usually.
-
 	filter := audit.AuditFilter{
 		TenantID: uuid.MustParse(ac.TenantID),
 	}
-	// ... (Parsing logic similar to GetEvents)
+	q := r.URL.Query()
+	async := q.Get("async") == "true"
+	limits := h.Service.ExportLimitsFor(filter.TenantID)
+
+	to := time.Now().UTC()
+	if toStr := q.Get("to"); toStr != "" {
+		t, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			http.Error(w, "Invalid 'to' (RFC3339)", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	filter.DateTo = &to
+	if fromStr := q.Get("from"); fromStr != "" {
+		t, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			http.Error(w, "Invalid 'from' (RFC3339)", http.StatusBadRequest)
+			return
+		}
+		filter.DateFrom = &t
+	} else if !async {
+		// Default sync window: the widest range allowed
+		from := to.Add(-limits.MaxRange)
+		filter.DateFrom = &from
+	}
+	if filter.DateFrom != nil && !filter.DateFrom.Before(to) {
+		http.Error(w, "'from' must be before 'to'", http.StatusBadRequest)
+		return
+	}
+
+	if async {
+		if h.Exports == nil {
+			http.Error(w, "Async export unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		job, err := h.Exports.Start(filter)
+		if err != nil {
+			respondMappedError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]any{
+			"job_id":     job.ID,
+			"status":     job.Status,
+			"status_url": "/api/v1/audit/exports/" + job.ID.String(),
+		})
+		return
+	}
+
+	if err := h.Service.CheckExportRange(filter); err != nil {
+		http.Error(w, fmt.Sprintf("Time range too wide (max %s); narrow the range or use async=true", limits.MaxRange), http.StatusBadRequest)
+		return
+	}
+	events, err := h.Service.CollectExport(r.Context(), filter, limits.MaxRows)
+	if errors.Is(err, audit.ErrExportTooLarge) {
+		http.Error(w, fmt.Sprintf("Export exceeds %d events; narrow the range or use async=true", limits.MaxRows), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
 
 	// Streaming Response
 	w.Header().Set("Content-Type", "application/x-jsonl")
 	w.Header().Set("Content-Disposition", "attachment; filename=\"audit_export.jsonl\"")
 
-	// Flush headers
-	if flusher, ok := w.(http.Flusher); ok {
-		flusher.Flush()
+	enc := json.NewEncoder(w)
+	for _, evt := range events {
+		if err := enc.Encode(evt); err != nil {
+			// Headers already sent; log it.
+			fmt.Printf("Export stream error: %v\n", err)
+			return
+		}
+	}
+}
+
+// GET /api/v1/audit/exports/{id}
+func (h *AuditHandler) GetExportJob(w http.ResponseWriter, r *http.Request) {
+	tid, jobID, ok := h.exportJobRequest(w, r)
+	if !ok {
+		return
+	}
+	job, err := h.Exports.Get(tid, jobID)
+	if err != nil {
+		http.Error(w, "Export job not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// GET /api/v1/audit/exports/{id}/download
+func (h *AuditHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	tid, jobID, ok := h.exportJobRequest(w, r)
+	if !ok {
+		return
+	}
+	f, err := h.Exports.Open(tid, jobID)
+	if errors.Is(err, audit.ErrExportNotReady) {
+		http.Error(w, "Export not completed", http.StatusConflict)
+		return
 	}
+	if err != nil {
+		http.Error(w, "Export job not found", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
 
-	// Stream
-	// We need Service to support Callback or Iterator?
-	// `ExportEvents` in service needs to write to IO writer.
-	err := h.Service.ExportEvents(r.Context(), filter, w)
+	w.Header().Set("Content-Type", "application/x-jsonl")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"audit_export_%s.jsonl\"", jobID))
+	io.Copy(w, f)
+}
+
+func (h *AuditHandler) exportJobRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if h.Exports == nil {
+		http.Error(w, "Async export unavailable", http.StatusServiceUnavailable)
+		return uuid.Nil, uuid.Nil, false
+	}
+	jobID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		// If headers already sent, we can't send JSON error easily.
-		// Log it.
-		fmt.Printf("Export stream error: %v\n", err)
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
 	}
+	return uuid.MustParse(ac.TenantID), jobID, true
+}
+
+// GET /api/v1/audit/verify?after_seq=&limit=
+// Walks one batch of the tenant's audit hash chain and reports the first
+// broken link; next_after_seq in the response resumes with the next batch.
+func (h *AuditHandler) VerifyChain(w http.ResponseWriter, r *http.Request) {
+	// RBAC: audit.read
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	tid, err := uuid.Parse(ac.TenantID)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var afterSeq int64
+	if v := r.URL.Query().Get("after_seq"); v != "" {
+		afterSeq, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || afterSeq < 0 {
+			http.Error(w, "Invalid after_seq", http.StatusBadRequest)
+			return
+		}
+	}
+	limit, _ := ParsePagination(r, audit.MaxVerifyBatch, audit.MaxVerifyBatch)
+
+	res, err := h.Service.VerifyChain(r.Context(), tid, afterSeq, limit)
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
 }
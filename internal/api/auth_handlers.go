@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/auth"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/middleware"
 	"github.com/technosupport/ts-vms/internal/session"
 	"github.com/technosupport/ts-vms/internal/tokens"
 )
@@ -19,6 +20,9 @@ type AuthHandler struct {
 	Tokens  *tokens.Manager
 	Session *session.Manager
 	Hasher  *auth.Params
+
+	// HLSAudience is the aud hlsd expects; empty means tokens.AudienceHLS.
+	HLSAudience string
 }
 
 type LoginRequest struct {
@@ -235,6 +239,27 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HLSToken mints a short-lived access token for hlsd for the caller.
+// POST /api/v1/auth/hls-token
+func (h *AuthHandler) HLSToken(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		h.genericError(w)
+		return
+	}
+	aud := h.HLSAudience
+	if aud == "" {
+		aud = tokens.AudienceHLS
+	}
+	token, err := h.Tokens.GenerateAccessTokenFor(ac.UserID, ac.TenantID, aud)
+	if err != nil {
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TokenResponse{AccessToken: token, ExpiresIn: 900})
+}
+
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	// ... Logic ...
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"strconv"
@@ -13,8 +14,19 @@ import (
 	"github.com/technosupport/ts-vms/internal/middleware"
 )
 
+// PermissionAuthorizer checks a permission for a scope known only after
+// reading the request, writing the 403 ERR_RBAC_DENIED body on denial.
+type PermissionAuthorizer interface {
+	Authorize(w http.ResponseWriter, r *http.Request, permSlug, scopeType, scopeID string) bool
+}
+
 type CameraHandler struct {
 	Service *cameras.Service
+
+	// Perms scopes Create to the caller's cameras.create grants: site-scoped
+	// users may only create cameras in their permitted sites. Create is
+	// refused while it is unset.
+	Perms PermissionAuthorizer
 }
 
 func NewCameraHandler(svc *cameras.Service) *CameraHandler {
@@ -32,6 +44,47 @@ func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{"error": message})
 }
 
+func respondDuplicateIP(w http.ResponseWriter) {
+	respondJSON(w, http.StatusConflict, map[string]string{
+		"code":  cameras.ErrDuplicateIP.Error(),
+		"error": "Another camera in this site already uses this IP address",
+	})
+}
+
+func respondTooManyTags(w http.ResponseWriter, max int) {
+	respondJSON(w, http.StatusBadRequest, map[string]string{
+		"code":  cameras.ErrTooManyTags.Error(),
+		"error": fmt.Sprintf("A camera can have at most %d tags", max),
+	})
+}
+
+// respondCreateError maps camera create and site resolution errors.
+func respondCreateError(w http.ResponseWriter, r *http.Request, svc *cameras.Service, err error) {
+	if errors.Is(err, cameras.ErrLicenseLimitExceeded) {
+		respondError(w, http.StatusPaymentRequired, "License limit would be exceeded") // 402 or 403 or 400? Prompt says reason_code.
+		// Let's use 403 Forbidden with custom body? Or 400 Bad Request.
+		// Standard is 403 for policy/quota.
+		return
+	}
+	if errors.Is(err, cameras.ErrDuplicateIP) {
+		respondDuplicateIP(w)
+		return
+	}
+	if errors.Is(err, cameras.ErrTooManyTags) {
+		respondTooManyTags(w, svc.MaxTagsPerCamera())
+		return
+	}
+	if errors.Is(err, cameras.ErrSiteRequired) {
+		respondError(w, http.StatusBadRequest, "site_id is required: no default site is configured for this tenant")
+		return
+	}
+	if errors.Is(err, cameras.ErrSiteScopeMismatch) {
+		respondError(w, http.StatusBadRequest, "Site does not belong to tenant")
+		return
+	}
+	respondMappedError(w, r, err)
+}
+
 // POST /api/v1/cameras
 func (h *CameraHandler) Create(w http.ResponseWriter, r *http.Request) {
 	ac, ok := middleware.GetAuthContext(r.Context())
@@ -39,13 +92,17 @@ func (h *CameraHandler) Create(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusForbidden, "Forbidden")
 		return
 	}
+	if h.Perms == nil {
+		respondError(w, http.StatusInternalServerError, "Permission checker not configured")
+		return
+	}
 
 	var req struct {
 		SiteID    string   `json:"site_id"`
 		Name      string   `json:"name"`
 		IPAddress string   `json:"ip_address"`
-		Port      int      `json:"port"`
-		IsEnabled bool     `json:"is_enabled,omitempty"` // Default true
+		Port      *int     `json:"port,omitempty"`       // Omitted: cameras.default_port
+		IsEnabled *bool    `json:"is_enabled,omitempty"` // Omitted: tenant default
 		Tags      []string `json:"tags"`
 		// Metadata... omitted for brevity but should map
 	}
@@ -55,57 +112,67 @@ func (h *CameraHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Basic Validation
-	siteID, err := uuid.Parse(req.SiteID)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid Site ID")
-		return
+	// Basic Validation. An omitted site_id falls back to the tenant's default site.
+	var siteID uuid.UUID
+	if req.SiteID != "" {
+		id, err := uuid.Parse(req.SiteID)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid Site ID")
+			return
+		}
+		siteID = id
 	}
 	ip := net.ParseIP(req.IPAddress)
 	if ip == nil {
 		respondError(w, http.StatusBadRequest, "Invalid IP")
 		return
 	}
+	port := h.Service.DefaultPort()
+	if req.Port != nil {
+		if !cameras.ValidPort(*req.Port) {
+			respondMappedError(w, r, cameras.ErrInvalidPort)
+			return
+		}
+		port = *req.Port
+	}
 
-	// Check Scope: Does user have access to this site?
-	// Middleware usually checks "cameras.create" on "site" scope?
-	// But endpoint is generic POST /cameras.
-	// RBAC Logic: If user has 'create' on Site A, they can create for Site A.
-	// We must verify `req.SiteID` is permitted.
-	// TODO: Integrate with Permissions check.
-	// For Phase 2.1, we assume Middleware checked broad permission, but we check specific site.
-	// Wait, if Middleware is `RequirePermission("cameras.create", "tenant")` it allows all.
-	// If `RequirePermission("cameras.create", "site")`, we need to know the site BEFORE handler.
-	// But Site ID is in Body. Middleware can't see body easily.
-	// So Handler must verify Site ID is in User's Permitted Sites.
-	// This requires fetching permissions again or Context having them.
-	// For now, let's assume Tenant Admin (Tenant Wide).
-	// Strictly: We should check if `ac` has access to `siteID`.
+	// Check Scope: the site must be within the caller's cameras.create grant
+	// (checked before any lookup when given) and belong to the tenant; an
+	// omitted site_id resolves to the default, which is checked once known.
+	if siteID != uuid.Nil && !h.Perms.Authorize(w, r, "cameras.create", "site", siteID.String()) {
+		return
+	}
+	resolved, err := h.Service.ResolveSite(r.Context(), uuid.MustParse(ac.TenantID), siteID)
+	if err != nil {
+		respondCreateError(w, r, h.Service, err)
+		return
+	}
+	if siteID == uuid.Nil && !h.Perms.Authorize(w, r, "cameras.create", "site", resolved.String()) {
+		return
+	}
+	siteID = resolved
 
 	c := &data.Camera{
 		TenantID:  uuid.MustParse(ac.TenantID),
 		SiteID:    siteID,
 		Name:      req.Name,
 		IPAddress: ip,
-		Port:      req.Port,
-		IsEnabled: true, // Default enabled? Or based on req?
+		Port:      port,
 		Tags:      req.Tags,
 	}
-	// Explicit enabled override
-	if !req.IsEnabled {
-		c.IsEnabled = false
+	if req.IsEnabled != nil {
+		c.IsEnabled = *req.IsEnabled
 	} else {
-		c.IsEnabled = true // Default true
+		enabled, err := h.Service.DefaultEnabled(r.Context(), c.TenantID)
+		if err != nil {
+			respondMappedError(w, r, err)
+			return
+		}
+		c.IsEnabled = enabled
 	}
 
 	if err := h.Service.CreateCamera(r.Context(), c); err != nil {
-		if errors.Is(err, cameras.ErrLicenseLimitExceeded) {
-			respondError(w, http.StatusPaymentRequired, "License limit would be exceeded") // 402 or 403 or 400? Prompt says reason_code.
-			// Let's use 403 Forbidden with custom body? Or 400 Bad Request.
-			// Standard is 403 for policy/quota.
-			return
-		}
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondCreateError(w, r, h.Service, err)
 		return
 	}
 
@@ -120,24 +187,8 @@ func (h *CameraHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Query Params
-	limit := 50 // Strict default
-	// Prompt: "Default page size = 50... Maximum limit=50 even if client asks for 500"
-	// So we ignore client limit if > 50? Or error? Prompt: "Maximum limit=50".
-	// Let's cap it.
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if v, err := strconv.Atoi(l); err == nil {
-			if v > 0 && v < 50 {
-				limit = v
-			}
-		}
-	}
-	offset := 0
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
-			offset = v
-		}
-	}
+	// Default page size = 50, maximum limit = 50 even if client asks for 500
+	limit, offset := ParsePagination(r, 50, 50)
 
 	filter := data.CameraFilter{}
 	if siteStr := r.URL.Query().Get("site_id"); siteStr != "" {
@@ -148,12 +199,33 @@ func (h *CameraHandler) List(w http.ResponseWriter, r *http.Request) {
 	if q := r.URL.Query().Get("q"); q != "" {
 		filter.Query = q
 	}
+	if r.URL.Query().Get("favorites_only") == "true" {
+		uid, err := uuid.Parse(ac.UserID)
+		if err != nil {
+			respondError(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+		filter.FavoritesOf = &uid
+	}
 
 	tenantID := uuid.MustParse(ac.TenantID)
-	// Use Service.List (which wraps repo)
-	list, total, err := h.Service.List(r.Context(), tenantID, filter, limit, offset)
+
+	// view=summary returns the reduced CameraSummary projection for large
+	// camera trees; the default stays the full camera record.
+	var list any
+	var total int
+	var err error
+	switch r.URL.Query().Get("view") {
+	case "", "full":
+		list, total, err = h.Service.List(r.Context(), tenantID, filter, limit, offset)
+	case "summary":
+		list, total, err = h.Service.ListSummary(r.Context(), tenantID, filter, limit, offset)
+	default:
+		respondError(w, http.StatusBadRequest, "view must be one of: full, summary")
+		return
+	}
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -177,9 +249,10 @@ func (h *CameraHandler) Bulk(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Action    string      `json:"action"` // enable, disable, tag_add, tag_remove
+		Action    string      `json:"action"` // enable, disable, tag_add, tag_remove, tag_set, move_site
 		CameraIDs []uuid.UUID `json:"camera_ids"`
 		Tags      []string    `json:"tags"`
+		SiteID    string      `json:"site_id"` // move_site only
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid JSON")
@@ -198,23 +271,74 @@ func (h *CameraHandler) Bulk(w http.ResponseWriter, r *http.Request) {
 		err = h.Service.BulkAddTags(r.Context(), tid, req.CameraIDs, req.Tags)
 	case "tag_remove":
 		err = h.Service.BulkRemoveTags(r.Context(), tid, req.CameraIDs, req.Tags)
+	case "tag_set":
+		err = h.Service.BulkSetTags(r.Context(), tid, req.CameraIDs, req.Tags)
+	case "move_site":
+		h.bulkMoveSite(w, r, tid, req.CameraIDs, req.SiteID)
+		return
 	default:
 		respondError(w, http.StatusBadRequest, "Invalid Action")
 		return
 	}
 
 	if err != nil {
+		var qe *cameras.QuotaError
+		if errors.As(err, &qe) {
+			respondJSON(w, http.StatusPaymentRequired, map[string]any{
+				"error":        "License limit exceeded",
+				"limit":        qe.Limit,
+				"enabled":      qe.Enabled,
+				"rejected_ids": qe.Rejected,
+			})
+			return
+		}
 		if errors.Is(err, cameras.ErrLicenseLimitExceeded) {
 			respondError(w, http.StatusPaymentRequired, "License limit exceeded")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, err.Error())
+		if errors.Is(err, cameras.ErrTooManyTags) {
+			respondTooManyTags(w, h.Service.MaxTagsPerCamera())
+			return
+		}
+		respondMappedError(w, r, err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"status": "success"})
 }
 
+func (h *CameraHandler) bulkMoveSite(w http.ResponseWriter, r *http.Request, tid uuid.UUID, ids []uuid.UUID, siteStr string) {
+	siteID, err := uuid.Parse(siteStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid Site ID")
+		return
+	}
+	if len(ids) == 0 {
+		respondError(w, http.StatusBadRequest, "camera_ids required")
+		return
+	}
+
+	res, err := h.Service.BulkMoveSite(r.Context(), tid, ids, siteID)
+	if err != nil {
+		if errors.Is(err, cameras.ErrSiteScopeMismatch) {
+			respondError(w, http.StatusBadRequest, "Site does not belong to tenant")
+			return
+		}
+		if errors.Is(err, cameras.ErrDuplicateIP) {
+			respondDuplicateIP(w)
+			return
+		}
+		respondMappedError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"status":  "success",
+		"moved":   res.Moved,
+		"flagged": res.Flagged,
+	})
+}
+
 // POST /api/v1/cameras/{id}:enable
 func (h *CameraHandler) Enable(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Path // Need proper mux in Main to extract ID. assuming standard ServeMux
@@ -242,7 +366,7 @@ func (h *CameraHandler) Enable(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusPaymentRequired, "License limit exceeded")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondMappedError(w, r, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]string{"status": "enabled"})
@@ -259,12 +383,294 @@ func (h *CameraHandler) Disable(w http.ResponseWriter, r *http.Request) {
 	ac, _ := middleware.GetAuthContext(r.Context())
 	err = h.Service.DisableCamera(r.Context(), id, uuid.MustParse(ac.TenantID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondMappedError(w, r, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]string{"status": "disabled"})
 }
 
+// PUT /api/v1/cameras/{id}
+// Takes the Create fields except site_id and is_enabled; omitted fields keep
+// their stored values.
+func (h *CameraHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req struct {
+		Name         *string   `json:"name"`
+		IPAddress    *string   `json:"ip_address"`
+		Port         *int      `json:"port"`
+		Manufacturer *string   `json:"manufacturer"`
+		Model        *string   `json:"model"`
+		SerialNumber *string   `json:"serial_number"`
+		MacAddress   *string   `json:"mac_address"`
+		Tags         *[]string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	tenantID := uuid.MustParse(ac.TenantID)
+	existing, err := h.Service.GetByID(r.Context(), id, tenantID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			respondError(w, http.StatusNotFound, "Camera not found")
+			return
+		}
+		respondMappedError(w, r, err)
+		return
+	}
+	if existing.TenantID != tenantID || existing.DeletedAt != nil {
+		respondError(w, http.StatusNotFound, "Camera not found")
+		return
+	}
+
+	// Edit a copy so UpdateCamera can diff against the stored camera.
+	c := *existing
+	if req.Name != nil {
+		if len(*req.Name) == 0 || len(*req.Name) > 120 {
+			respondMappedError(w, r, cameras.ErrNameTooLong)
+			return
+		}
+		c.Name = *req.Name
+	}
+	if req.IPAddress != nil {
+		ip := net.ParseIP(*req.IPAddress)
+		if ip == nil {
+			respondError(w, http.StatusBadRequest, "Invalid IP")
+			return
+		}
+		c.IPAddress = ip
+	}
+	if req.Port != nil {
+		// UpdateCamera reads 0 as "keep", so reject it here.
+		if !cameras.ValidPort(*req.Port) {
+			respondMappedError(w, r, cameras.ErrInvalidPort)
+			return
+		}
+		c.Port = *req.Port
+	}
+	if req.Manufacturer != nil {
+		c.Manufacturer = *req.Manufacturer
+	}
+	if req.Model != nil {
+		c.Model = *req.Model
+	}
+	if req.SerialNumber != nil {
+		c.SerialNumber = *req.SerialNumber
+	}
+	if req.MacAddress != nil {
+		c.MacAddress = *req.MacAddress
+	}
+	if req.Tags != nil {
+		c.Tags = *req.Tags
+	}
+
+	if err := h.Service.UpdateCamera(r.Context(), &c); err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			respondError(w, http.StatusNotFound, "Camera not found")
+		case errors.Is(err, cameras.ErrDuplicateIP):
+			respondDuplicateIP(w)
+		case errors.Is(err, cameras.ErrTooManyTags):
+			respondTooManyTags(w, h.Service.MaxTagsPerCamera())
+		default:
+			respondMappedError(w, r, err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, &c)
+}
+
+// POST /api/v1/cameras/{id}/clone
+func (h *CameraHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+	sourceID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req struct {
+		Name      string `json:"name"`
+		IPAddress string `json:"ip_address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	ip := net.ParseIP(req.IPAddress)
+	if ip == nil {
+		respondError(w, http.StatusBadRequest, "Invalid IP")
+		return
+	}
+
+	c, err := h.Service.Clone(r.Context(), sourceID, uuid.MustParse(ac.TenantID), ip, req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			respondError(w, http.StatusNotFound, "Camera not found")
+		case errors.Is(err, cameras.ErrLicenseLimitExceeded):
+			respondError(w, http.StatusPaymentRequired, "License limit would be exceeded")
+		case errors.Is(err, cameras.ErrNameTooLong):
+			respondError(w, http.StatusBadRequest, "Invalid Name")
+		case errors.Is(err, cameras.ErrDuplicateIP):
+			respondDuplicateIP(w)
+		case errors.Is(err, cameras.ErrTooManyTags):
+			respondTooManyTags(w, h.Service.MaxTagsPerCamera())
+		default:
+			respondMappedError(w, r, err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, c)
+}
+
+// PUT /api/v1/cameras/{id}/onvif-events
+// Body: {"enabled": true}. Subscribes the camera to the ONVIF event bridge.
+func (h *CameraHandler) SetONVIFEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var input struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Enabled == nil {
+		respondError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	ac, _ := middleware.GetAuthContext(r.Context())
+	if err := h.Service.SetONVIFEvents(r.Context(), uuid.MustParse(ac.TenantID), id, *input.Enabled); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			respondError(w, http.StatusNotFound, "Camera not found")
+			return
+		}
+		respondMappedError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"camera_id": id, "onvif_events_enabled": *input.Enabled})
+}
+
+// GET /api/v1/tenant/camera-settings
+func (h *CameraHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	ac, _ := middleware.GetAuthContext(r.Context())
+	h.respondSettings(w, r, uuid.MustParse(ac.TenantID))
+}
+
+// PUT /api/v1/tenant/camera-settings
+// default_site_id is a site of the tenant, or null to clear it.
+func (h *CameraHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		DefaultEnabled *bool           `json:"default_enabled"`
+		DefaultSiteID  json.RawMessage `json:"default_site_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || (input.DefaultEnabled == nil && input.DefaultSiteID == nil) {
+		respondError(w, http.StatusBadRequest, "default_enabled or default_site_id is required")
+		return
+	}
+	var siteID *uuid.UUID
+	if input.DefaultSiteID != nil {
+		if err := json.Unmarshal(input.DefaultSiteID, &siteID); err != nil {
+			respondError(w, http.StatusBadRequest, "default_site_id must be a UUID or null")
+			return
+		}
+	}
+
+	ac, _ := middleware.GetAuthContext(r.Context())
+	tenantID := uuid.MustParse(ac.TenantID)
+	if input.DefaultSiteID != nil {
+		if err := h.Service.SetDefaultSite(r.Context(), tenantID, siteID); err != nil {
+			respondMappedError(w, r, err)
+			return
+		}
+	}
+	if input.DefaultEnabled != nil {
+		if err := h.Service.SetDefaultEnabled(r.Context(), tenantID, *input.DefaultEnabled); err != nil {
+			respondMappedError(w, r, err)
+			return
+		}
+	}
+	h.respondSettings(w, r, tenantID)
+}
+
+func (h *CameraHandler) respondSettings(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID) {
+	enabled, err := h.Service.DefaultEnabled(r.Context(), tenantID)
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+	siteID, err := h.Service.DefaultSite(r.Context(), tenantID)
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"default_enabled": enabled, "default_site_id": siteID})
+}
+
+// POST /api/v1/cameras/{id}/favorite
+func (h *CameraHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	h.setFavorite(w, r, true)
+}
+
+// DELETE /api/v1/cameras/{id}/favorite
+func (h *CameraHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	h.setFavorite(w, r, false)
+}
+
+func (h *CameraHandler) setFavorite(w http.ResponseWriter, r *http.Request, pinned bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+	userID, err := uuid.Parse(ac.UserID)
+	if err != nil {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+	tenantID := uuid.MustParse(ac.TenantID)
+
+	if pinned {
+		err = h.Service.AddFavorite(r.Context(), tenantID, userID, id)
+	} else {
+		err = h.Service.RemoveFavorite(r.Context(), tenantID, userID, id)
+	}
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			respondError(w, http.StatusNotFound, "Camera not found")
+			return
+		}
+		respondMappedError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"camera_id": id, "favorite": pinned})
+}
+
 // --- Group Handlers ---
 
 // POST /api/v1/camera-groups
@@ -288,20 +694,59 @@ func (h *CameraHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.Service.CreateGroup(r.Context(), g); err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		var vErr *cameras.ValidationError
+		if errors.As(err, &vErr) {
+			respondJSON(w, http.StatusBadRequest, map[string]any{
+				"error":  "validation_failed",
+				"fields": vErr.Fields,
+			})
+			return
+		}
+		if errors.Is(err, cameras.ErrDuplicateGroupName) {
+			respondError(w, http.StatusConflict, "A camera group with this name already exists")
+			return
+		}
+		respondMappedError(w, r, err)
 		return
 	}
 
 	respondJSON(w, http.StatusCreated, g)
 }
 
+// GET /api/v1/cameras/tags?prefix=&limit=
+func (h *CameraHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	tags, err := h.Service.ListTags(r.Context(), uuid.MustParse(ac.TenantID), r.URL.Query().Get("prefix"), limit)
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"data": tags})
+}
+
 // GET /api/v1/camera-groups
 func (h *CameraHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
 	ac, _ := middleware.GetAuthContext(r.Context())
 
 	groups, err := h.Service.ListGroups(r.Context(), uuid.MustParse(ac.TenantID))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -337,7 +782,7 @@ func (h *CameraHandler) SetGroupMembers(w http.ResponseWriter, r *http.Request)
 
 	ac, _ := middleware.GetAuthContext(r.Context())
 	if err := h.Service.SetGroupMembers(r.Context(), groupID, uuid.MustParse(ac.TenantID), cams); err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -355,7 +800,7 @@ func (h *CameraHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 
 	ac, _ := middleware.GetAuthContext(r.Context())
 	if err := h.Service.DeleteGroup(r.Context(), groupID, uuid.MustParse(ac.TenantID)); err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondMappedError(w, r, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
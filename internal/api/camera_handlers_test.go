@@ -3,9 +3,14 @@ package api_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/api"
@@ -29,29 +34,109 @@ func (m *MockLicense) GetLimits(tenantID uuid.UUID) license.LicenseLimits {
 
 // Mock Repo
 type HMockRepo struct {
+	groupNames      map[string]bool
+	cams            map[uuid.UUID]*data.Camera
+	favorites       map[uuid.UUID]map[uuid.UUID]bool // user -> camera
+	defaultSite     *uuid.UUID
+	defaultDisabled bool      // tenant camera_default_enabled = false
+	foreignSite     uuid.UUID // a site of another tenant
 }
 
 func (m *HMockRepo) Create(ctx context.Context, c *data.Camera) error { c.ID = uuid.New(); return nil }
 func (m *HMockRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+	if c, ok := m.cams[id]; ok {
+		return c, nil
+	}
 	return &data.Camera{ID: id, Name: "Handler Cam", IsEnabled: true}, nil
 }
+func (m *HMockRepo) ListByIDs(ctx context.Context, t uuid.UUID, ids []uuid.UUID) ([]*data.Camera, error) {
+	var out []*data.Camera
+	for _, id := range ids {
+		if c, ok := m.cams[id]; ok {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
 func (m *HMockRepo) Update(ctx context.Context, c *data.Camera) error             { return nil }
 func (m *HMockRepo) SetStatus(ctx context.Context, id, t uuid.UUID, e bool) error { return nil }
 func (m *HMockRepo) SoftDelete(ctx context.Context, id, t uuid.UUID) error        { return nil }
 func (m *HMockRepo) CountAll(ctx context.Context, t uuid.UUID) (int, error)       { return 0, nil }
-func (m *HMockRepo) BulkUpdateStatus(ctx context.Context, t uuid.UUID, ids []uuid.UUID, e bool) error {
+func (m *HMockRepo) CountEnabled(ctx context.Context, t uuid.UUID) (int, error)   { return 0, nil }
+func (m *HMockRepo) ListDisabledIDs(ctx context.Context, t uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return ids, nil
+}
+func (m *HMockRepo) SetONVIFEvents(ctx context.Context, tenantID, id uuid.UUID, enabled bool) error {
 	return nil
 }
-func (m *HMockRepo) BulkAddTags(ctx context.Context, t uuid.UUID, ids []uuid.UUID, tags []string) error {
+func (m *HMockRepo) BulkUpdateStatus(ctx context.Context, t uuid.UUID, ids []uuid.UUID, e bool) error {
 	return nil
 }
+func (m *HMockRepo) BulkAddTags(ctx context.Context, t uuid.UUID, ids []uuid.UUID, tags []string, maxTags int) ([]uuid.UUID, error) {
+	return nil, nil
+}
 func (m *HMockRepo) BulkRemoveTags(ctx context.Context, t uuid.UUID, ids []uuid.UUID, tags []string) error {
 	return nil
 }
+func (m *HMockRepo) BulkSetTags(ctx context.Context, t uuid.UUID, ids []uuid.UUID, tags []string) error {
+	for _, id := range ids {
+		if c, ok := m.cams[id]; ok && c.TenantID == t && c.DeletedAt == nil {
+			c.Tags = append([]string(nil), tags...)
+		}
+	}
+	return nil
+}
+func (m *HMockRepo) ListTags(ctx context.Context, t uuid.UUID, prefix string, limit int) ([]data.TagCount, error) {
+	counts := map[string]int{}
+	for _, c := range m.cams {
+		if c.TenantID != t || c.DeletedAt != nil {
+			continue
+		}
+		for _, tag := range c.Tags {
+			if strings.HasPrefix(strings.ToLower(tag), strings.ToLower(prefix)) {
+				counts[tag]++
+			}
+		}
+	}
+	out := []data.TagCount{}
+	for tag, n := range counts {
+		out = append(out, data.TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
 func (m *HMockRepo) List(ctx context.Context, t uuid.UUID, f data.CameraFilter, l, o int) ([]*data.Camera, int, error) {
+	if f.FavoritesOf != nil {
+		var out []*data.Camera
+		for id := range m.favorites[*f.FavoritesOf] {
+			if c, ok := m.cams[id]; ok && c.TenantID == t {
+				out = append(out, c)
+			}
+		}
+		return out, len(out), nil
+	}
 	return []*data.Camera{{Name: "Listed Cam"}}, 1, nil
 }
+func (m *HMockRepo) ListSummary(ctx context.Context, t uuid.UUID, f data.CameraFilter, l, o int) ([]*data.CameraSummary, int, error) {
+	return []*data.CameraSummary{{ID: uuid.New(), Name: "Listed Cam", IsEnabled: true, HealthStatus: "ONLINE"}}, 1, nil
+}
 func (m *HMockRepo) CreateGroup(ctx context.Context, g *data.CameraGroup) error {
+	key := g.TenantID.String() + "/" + g.Name
+	if m.groupNames[key] {
+		return data.ErrDuplicateGroupName
+	}
+	if m.groupNames == nil {
+		m.groupNames = map[string]bool{}
+	}
+	m.groupNames[key] = true
 	g.ID = uuid.New()
 	return nil
 }
@@ -62,6 +147,84 @@ func (m *HMockRepo) DeleteGroup(ctx context.Context, id, t uuid.UUID) error { re
 func (m *HMockRepo) SetGroupMembers(ctx context.Context, gid, t uuid.UUID, cids []uuid.UUID) error {
 	return nil
 }
+func (m *HMockRepo) AddFavorite(ctx context.Context, t, userID, cameraID uuid.UUID) error {
+	if m.favorites == nil {
+		m.favorites = map[uuid.UUID]map[uuid.UUID]bool{}
+	}
+	if m.favorites[userID] == nil {
+		m.favorites[userID] = map[uuid.UUID]bool{}
+	}
+	m.favorites[userID][cameraID] = true
+	return nil
+}
+func (m *HMockRepo) RemoveFavorite(ctx context.Context, t, userID, cameraID uuid.UUID) error {
+	delete(m.favorites[userID], cameraID)
+	return nil
+}
+func (m *HMockRepo) SiteBelongsToTenant(ctx context.Context, siteID, tenantID uuid.UUID) (bool, error) {
+	return siteID != m.foreignSite, nil
+}
+func (m *HMockRepo) GetDefaultSiteID(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error) {
+	return m.defaultSite, nil
+}
+func (m *HMockRepo) SetDefaultSiteID(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID) error {
+	m.defaultSite = siteID
+	return nil
+}
+func (m *HMockRepo) ListNVRSiteConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]data.CameraSiteConflict, error) {
+	return nil, nil
+}
+func (m *HMockRepo) FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error) {
+	return uuid.Nil, data.ErrRecordNotFound
+}
+func (m *HMockRepo) CameraIPInSite(ctx context.Context, tenantID, siteID uuid.UUID, ip net.IP, excludeID uuid.UUID) (bool, error) {
+	for _, c := range m.cams {
+		if c.TenantID == tenantID && c.SiteID == siteID && c.IPAddress.Equal(ip) && c.ID != excludeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (m *HMockRepo) ListSiteIPConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *HMockRepo) BulkMoveSite(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) (int, error) {
+	return len(ids), nil
+}
+func (m *HMockRepo) ListTenantsWithEnabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *HMockRepo) ListDueForThumbnail(ctx context.Context, cutoff time.Time, limit int) ([]data.ThumbnailDue, error) {
+	return nil, nil
+}
+func (m *HMockRepo) MarkThumbnailCaptured(ctx context.Context, tenantID, cameraID uuid.UUID, capturedAt time.Time) error {
+	return nil
+}
+func (m *HMockRepo) ListPurgeable(ctx context.Context, cutoff time.Time, limit int) ([]data.PurgeCandidate, error) {
+	return nil, nil
+}
+func (m *HMockRepo) HardDelete(ctx context.Context, id, tenantID uuid.UUID, cutoff time.Time) error {
+	return nil
+}
+func (m *HMockRepo) ListEnabledIDsByPriority(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *HMockRepo) DisableForLicense(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error {
+	return nil
+}
+func (m *HMockRepo) ListTenantsWithLicenseDisabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *HMockRepo) ListLicenseDisabledIDs(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *HMockRepo) GetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	return !m.defaultDisabled, nil
+}
+func (m *HMockRepo) SetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID, enabled bool) error {
+	m.defaultDisabled = !enabled
+	return nil
+}
 
 func withAuth(req *http.Request) *http.Request {
 	ac := &middleware.AuthContext{
@@ -75,6 +238,7 @@ func withAuth(req *http.Request) *http.Request {
 func TestHandler_CreateCamera(t *testing.T) {
 	svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
 	h := api.NewCameraHandler(svc)
+	h.Perms = allowAll{}
 
 	body := `{"name":"test-cam", "ip_address":"1.2.3.4", "port":554, "site_id":"` + uuid.New().String() + `"}`
 	req := httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body))
@@ -88,9 +252,287 @@ func TestHandler_CreateCamera(t *testing.T) {
 	}
 }
 
+func TestHandler_CreateCamera_DefaultEnabled(t *testing.T) {
+	cases := []struct {
+		name           string
+		defaultEnabled bool
+		isEnabled      string
+		want           bool
+	}{
+		{"omitted uses default on", true, "", true},
+		{"omitted uses default off", false, "", false},
+		{"explicit false overrides default", true, `, "is_enabled": false`, false},
+		{"explicit true overrides default", false, `, "is_enabled": true`, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := cameras.NewService(&HMockRepo{defaultDisabled: !tc.defaultEnabled}, &MockLicense{}, &MockAuditor{})
+			h := api.NewCameraHandler(svc)
+			h.Perms = allowAll{}
+
+			body := `{"name":"test-cam", "ip_address":"1.2.3.4", "port":554, "site_id":"` + uuid.New().String() + `"` + tc.isEnabled + `}`
+			req := withAuth(httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body)))
+			rr := httptest.NewRecorder()
+			h.Create(rr, req)
+
+			if rr.Code != http.StatusCreated {
+				t.Fatalf("Expected 201, got %d. Body: %s", rr.Code, rr.Body.String())
+			}
+			var cam data.Camera
+			if err := json.NewDecoder(rr.Body).Decode(&cam); err != nil {
+				t.Fatal(err)
+			}
+			if cam.IsEnabled != tc.want {
+				t.Errorf("Expected is_enabled=%v, got %v", tc.want, cam.IsEnabled)
+			}
+		})
+	}
+}
+
+func TestHandler_CameraSettings_DefaultEnabled(t *testing.T) {
+	h := api.NewCameraHandler(cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{}))
+	h.Perms = allowAll{}
+
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, withAuth(httptest.NewRequest("PUT", "/api/v1/tenant/camera-settings", bytes.NewBufferString(`{}`))))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without default_enabled, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.UpdateSettings(rr, withAuth(httptest.NewRequest("PUT", "/api/v1/tenant/camera-settings", bytes.NewBufferString(`{"default_enabled":false}`))))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.GetSettings(rr, withAuth(httptest.NewRequest("GET", "/api/v1/tenant/camera-settings", nil)))
+	var got struct {
+		DefaultEnabled bool `json:"default_enabled"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusOK || got.DefaultEnabled {
+		t.Errorf("Expected default_enabled=false, got %d %+v", rr.Code, got)
+	}
+
+	// Cameras created without is_enabled now start disabled
+	body := `{"name":"test-cam", "ip_address":"1.2.3.4", "port":554, "site_id":"` + uuid.New().String() + `"}`
+	rr = httptest.NewRecorder()
+	h.Create(rr, withAuth(httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body))))
+	var cam data.Camera
+	if err := json.NewDecoder(rr.Body).Decode(&cam); err != nil {
+		t.Fatal(err)
+	}
+	if cam.IsEnabled {
+		t.Error("Expected the tenant default (disabled) to apply")
+	}
+}
+
+func TestHandler_CreateCamera_DuplicateIP(t *testing.T) {
+	tenantID, siteA := uuid.New(), uuid.New()
+	existing := &data.Camera{ID: uuid.New(), TenantID: tenantID, SiteID: siteA, IPAddress: net.ParseIP("1.2.3.4")}
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{existing.ID: existing}}
+	h := api.NewCameraHandler(cameras.NewService(repo, &MockLicense{}, &MockAuditor{}))
+	h.Perms = allowAll{}
+
+	create := func(siteID uuid.UUID) *httptest.ResponseRecorder {
+		body := `{"name":"dup-cam", "ip_address":"1.2.3.4", "port":554, "site_id":"` + siteID.String() + `"}`
+		req := httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body))
+		req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{TenantID: tenantID.String(), UserID: uuid.New().String()}))
+		rr := httptest.NewRecorder()
+		h.Create(rr, req)
+		return rr
+	}
+
+	rr := create(siteA)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("Expected 409 for duplicate IP in site, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var body map[string]string
+	json.NewDecoder(rr.Body).Decode(&body)
+	if body["code"] != "ERR_DUPLICATE_IP" {
+		t.Errorf("Expected code ERR_DUPLICATE_IP, got %q", body["code"])
+	}
+
+	if rr := create(uuid.New()); rr.Code != http.StatusCreated {
+		t.Errorf("Expected 201 for same IP in another site, got %d", rr.Code)
+	}
+}
+
+func TestHandler_CreateCamera_DefaultSite(t *testing.T) {
+	siteID := uuid.New()
+	h := api.NewCameraHandler(cameras.NewService(&HMockRepo{defaultSite: &siteID}, &MockLicense{}, &MockAuditor{}))
+	h.Perms = allowAll{}
+
+	body := `{"name":"test-cam", "ip_address":"1.2.3.4", "port":554}`
+	req := withAuth(httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body)))
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var cam data.Camera
+	if err := json.NewDecoder(rr.Body).Decode(&cam); err != nil {
+		t.Fatal(err)
+	}
+	if cam.SiteID != siteID {
+		t.Errorf("Expected default site %s, got %s", siteID, cam.SiteID)
+	}
+}
+
+func TestHandler_CameraSettings_DefaultSite(t *testing.T) {
+	siteID := uuid.New()
+	repo := &HMockRepo{foreignSite: uuid.New()}
+	h := api.NewCameraHandler(cameras.NewService(repo, &MockLicense{}, &MockAuditor{}))
+	h.Perms = allowAll{}
+	put := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.UpdateSettings(rr, withAuth(httptest.NewRequest("PUT", "/api/v1/tenant/camera-settings", bytes.NewBufferString(body))))
+		return rr
+	}
+
+	if rr := put(`{"default_site_id":"` + repo.foreignSite.String() + `"}`); rr.Code != http.StatusBadRequest || repo.defaultSite != nil {
+		t.Fatalf("Expected 400 for another tenant's site, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if rr := put(`{"default_site_id":"not-a-uuid"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed site id, got %d", rr.Code)
+	}
+	if rr := put(`{"default_site_id":"` + siteID.String() + `"}`); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), siteID.String()) {
+		t.Fatalf("Expected 200 with the new default site, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+
+	// Creates without site_id now land in it
+	rr := httptest.NewRecorder()
+	h.Create(rr, withAuth(httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(`{"name":"test-cam", "ip_address":"1.2.3.4", "port":554}`))))
+	var cam data.Camera
+	if err := json.NewDecoder(rr.Body).Decode(&cam); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusCreated || cam.SiteID != siteID {
+		t.Errorf("Expected 201 in the default site, got %d site %s", rr.Code, cam.SiteID)
+	}
+
+	if rr := put(`{"default_site_id":null}`); rr.Code != http.StatusOK || repo.defaultSite != nil {
+		t.Errorf("Expected null to clear the default site, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandler_CreateCamera_Port(t *testing.T) {
+	siteID := uuid.New()
+	svc := cameras.NewService(&HMockRepo{defaultSite: &siteID}, &MockLicense{}, &MockAuditor{})
+	h := api.NewCameraHandler(svc)
+	h.Perms = allowAll{}
+	create := func(port string) *httptest.ResponseRecorder {
+		body := `{"name":"test-cam", "ip_address":"1.2.3.4"` + port + `}`
+		rr := httptest.NewRecorder()
+		h.Create(rr, withAuth(httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body))))
+		return rr
+	}
+
+	for _, port := range []string{`, "port":0`, `, "port":70000`, `, "port":-1`} {
+		rr := create(port)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "ERR_INVALID_PORT") {
+			t.Errorf("%s: expected 400 ERR_INVALID_PORT, got %d %s", port, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := create("")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 without a port, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var cam data.Camera
+	json.NewDecoder(rr.Body).Decode(&cam)
+	if cam.Port != cameras.DefaultCameraPort {
+		t.Errorf("Expected default port %d, got %d", cameras.DefaultCameraPort, cam.Port)
+	}
+
+	svc.SetDefaultPort(80)
+	json.NewDecoder(create("").Body).Decode(&cam)
+	if cam.Port != 80 {
+		t.Errorf("Expected configured default port 80, got %d", cam.Port)
+	}
+}
+
+func TestHandler_CreateCamera_NoSiteNoDefault(t *testing.T) {
+	h := api.NewCameraHandler(cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{}))
+	h.Perms = allowAll{}
+
+	body := `{"name":"test-cam", "ip_address":"1.2.3.4", "port":554}`
+	req := withAuth(httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body)))
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "site_id is required") {
+		t.Errorf("Expected site_id error, got %s", rr.Body.String())
+	}
+}
+
+// allowAll authorizes every request
+type allowAll struct{}
+
+func (allowAll) Authorize(http.ResponseWriter, *http.Request, string, string, string) bool {
+	return true
+}
+
+// sitePerms grants every permission on the listed sites only
+type sitePerms struct{ sites []uuid.UUID }
+
+func (p sitePerms) GetPermissionsForUser(ctx context.Context, tenantID, userID string) (map[string]data.PermissionGrant, error) {
+	grant := data.PermissionGrant{SiteIDs: map[string]struct{}{}}
+	for _, id := range p.sites {
+		grant.SiteIDs[id.String()] = struct{}{}
+	}
+	return map[string]data.PermissionGrant{"cameras.create": grant}, nil
+}
+
+func TestHandler_CreateCamera_SiteScopedGrant(t *testing.T) {
+	siteA, siteB := uuid.New(), uuid.New()
+	h := api.NewCameraHandler(cameras.NewService(&HMockRepo{defaultSite: &siteB}, &MockLicense{}, &MockAuditor{}))
+	h.Perms = middleware.NewPermissionMiddleware(sitePerms{sites: []uuid.UUID{siteA}}, middleware.StubCameraResolver{})
+
+	create := func(site string) *httptest.ResponseRecorder {
+		body := `{"name":"scoped-cam", "ip_address":"1.2.3.4", "port":554` + site + `}`
+		rr := httptest.NewRecorder()
+		h.Create(rr, withAuth(httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body))))
+		return rr
+	}
+
+	if rr := create(`, "site_id":"` + siteA.String() + `"`); rr.Code != http.StatusCreated {
+		t.Errorf("Expected 201 in the permitted site, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	rr := create(`, "site_id":"` + siteB.String() + `"`)
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "ERR_RBAC_DENIED") {
+		t.Errorf("Expected 403 in another site of the tenant, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	// Omitting site_id resolves to the default site, which is not permitted either
+	if rr := create(""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for the unpermitted default site, got %d", rr.Code)
+	}
+}
+
+func TestHandler_CreateCamera_NoPermsDenies(t *testing.T) {
+	siteID := uuid.New()
+	repo := &HMockRepo{}
+	h := api.NewCameraHandler(cameras.NewService(repo, &MockLicense{}, &MockAuditor{}))
+
+	body := `{"site_id":"` + siteID.String() + `", "name":"cam", "ip_address":"1.2.3.4", "port":554}`
+	rr := httptest.NewRecorder()
+	h.Create(rr, withAuth(httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(body))))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected create to be refused without a permission checker, got %d", rr.Code)
+	}
+}
+
 func TestHandler_CreateCamera_BadJSON(t *testing.T) {
 	svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
 	h := api.NewCameraHandler(svc)
+	h.Perms = allowAll{}
 	req := httptest.NewRequest("POST", "/api/v1/cameras", bytes.NewBufferString(`{invalid`))
 	req = withAuth(req)
 	rr := httptest.NewRecorder()
@@ -112,6 +554,54 @@ func TestHandler_ListCameras(t *testing.T) {
 	}
 }
 
+func TestHandler_ListCameras_SummaryView(t *testing.T) {
+	h := api.NewCameraHandler(cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{}))
+	list := func(query string) (int, []map[string]any) {
+		rr := httptest.NewRecorder()
+		h.List(rr, withAuth(httptest.NewRequest("GET", "/api/v1/cameras"+query, nil)))
+		var resp struct {
+			Data []map[string]any `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return rr.Code, resp.Data
+	}
+
+	code, rows := list("?view=summary")
+	if code != http.StatusOK || len(rows) != 1 {
+		t.Fatalf("Expected 200 with one row, got %d (%d rows)", code, len(rows))
+	}
+	want := []string{"health_status", "id", "is_enabled", "name", "site_id"}
+	var got []string
+	for k := range rows[0] {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected summary fields %v, got %v", want, got)
+	}
+	if rows[0]["health_status"] != "ONLINE" {
+		t.Errorf("Expected health_status ONLINE, got %v", rows[0]["health_status"])
+	}
+
+	// Default remains the full record.
+	code, rows = list("")
+	if code != http.StatusOK || len(rows) != 1 {
+		t.Fatalf("Expected 200 with one row, got %d (%d rows)", code, len(rows))
+	}
+	for _, k := range []string{"tenant_id", "ip_address", "port", "tags", "created_at"} {
+		if _, ok := rows[0][k]; !ok {
+			t.Errorf("Expected full view to include %q", k)
+		}
+	}
+	if _, ok := rows[0]["health_status"]; ok {
+		t.Error("Expected full view without health_status")
+	}
+
+	if code, _ := list("?view=compact"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown view, got %d", code)
+	}
+}
+
 func TestHandler_EnableCamera(t *testing.T) {
 	svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
 	h := api.NewCameraHandler(svc)
@@ -164,6 +654,31 @@ func TestHandler_BulkDisable(t *testing.T) {
 	}
 }
 
+func TestHandler_BulkTagSet_OverwritesAndDedups(t *testing.T) {
+	tid := uuid.New()
+	camA, camB := uuid.New(), uuid.New()
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{
+		camA: {ID: camA, TenantID: tid, Tags: []string{"old", "lobby"}},
+		camB: {ID: camB, TenantID: tid, Tags: []string{"stale"}},
+	}}
+	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
+	h := api.NewCameraHandler(svc)
+
+	body := `{"action":"tag_set", "camera_ids":["` + camA.String() + `","` + camB.String() + `"], "tags":["lobby","entrance","lobby","entrance"]}`
+	req := httptest.NewRequest("POST", "/api/v1/cameras/bulk", bytes.NewBufferString(body))
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{TenantID: tid.String(), UserID: uuid.New().String()}))
+	rr := httptest.NewRecorder()
+	h.Bulk(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	for _, id := range []uuid.UUID{camA, camB} {
+		if got := strings.Join(repo.cams[id].Tags, ","); got != "lobby,entrance" {
+			t.Errorf("Camera %s: expected tags lobby,entrance, got %s", id, got)
+		}
+	}
+}
+
 func TestHandler_CreateGroup(t *testing.T) {
 	svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
 	h := api.NewCameraHandler(svc)
@@ -229,3 +744,267 @@ func TestHandler_GroupMembers_BadID(t *testing.T) {
 		t.Errorf("Expected 400, got %d", rr.Code)
 	}
 }
+
+func TestHandler_BulkMoveSite(t *testing.T) {
+	svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
+	h := api.NewCameraHandler(svc)
+	body := `{"action":"move_site", "site_id":"` + uuid.New().String() + `", "camera_ids":["` + uuid.New().String() + `"]}`
+	req := httptest.NewRequest("POST", "/api/v1/cameras/bulk", bytes.NewBufferString(body))
+	req = withAuth(req)
+	rr := httptest.NewRecorder()
+	h.Bulk(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandler_BulkMoveSite_InvalidSite(t *testing.T) {
+	svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
+	h := api.NewCameraHandler(svc)
+	body := `{"action":"move_site", "site_id":"not-a-uuid", "camera_ids":["` + uuid.New().String() + `"]}`
+	req := httptest.NewRequest("POST", "/api/v1/cameras/bulk", bytes.NewBufferString(body))
+	req = withAuth(req)
+	rr := httptest.NewRecorder()
+	h.Bulk(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rr.Code)
+	}
+}
+
+func TestHandler_CreateGroup_Validation(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"empty name", `{"name":"  "}`, "name"},
+		{"oversized name", `{"name":"` + strings.Repeat("n", cameras.MaxGroupNameLen+1) + `"}`, "name"},
+		{"oversized description", `{"name":"Lobby","description":"` + strings.Repeat("d", cameras.MaxGroupDescriptionLen+1) + `"}`, "description"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
+			h := api.NewCameraHandler(svc)
+
+			req := withAuth(httptest.NewRequest("POST", "/api/v1/camera-groups", bytes.NewBufferString(tc.body)))
+			rr := httptest.NewRecorder()
+			h.CreateGroup(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected 400, got %d. Body: %s", rr.Code, rr.Body.String())
+			}
+			var resp struct {
+				Fields map[string]string `json:"fields"`
+			}
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.Fields[tc.field] == "" {
+				t.Errorf("Expected field error for %q, got %v", tc.field, resp.Fields)
+			}
+		})
+	}
+}
+
+func TestHandler_CreateGroup_DuplicateName(t *testing.T) {
+	svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
+	h := api.NewCameraHandler(svc)
+
+	ac := &middleware.AuthContext{TenantID: uuid.New().String(), UserID: uuid.New().String()}
+	create := func() int {
+		req := httptest.NewRequest("POST", "/api/v1/camera-groups", bytes.NewBufferString(`{"name":"Lobby"}`))
+		req = req.WithContext(middleware.WithAuthContext(req.Context(), ac))
+		rr := httptest.NewRecorder()
+		h.CreateGroup(rr, req)
+		return rr.Code
+	}
+
+	if code := create(); code != http.StatusCreated {
+		t.Fatalf("Expected 201 on first create, got %d", code)
+	}
+	if code := create(); code != http.StatusConflict {
+		t.Errorf("Expected 409 on duplicate name, got %d", code)
+	}
+}
+
+func TestHandler_Favorites_PinUnpinAndFilter(t *testing.T) {
+	tenantID, userID, otherUser := uuid.New(), uuid.New(), uuid.New()
+	pinned := &data.Camera{ID: uuid.New(), TenantID: tenantID, Name: "Gate"}
+	other := &data.Camera{ID: uuid.New(), TenantID: tenantID, Name: "Dock"}
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{pinned.ID: pinned, other.ID: other}}
+	h := api.NewCameraHandler(cameras.NewService(repo, &MockLicense{}, &MockAuditor{}))
+
+	as := func(req *http.Request, uid uuid.UUID) *http.Request {
+		ac := &middleware.AuthContext{TenantID: tenantID.String(), UserID: uid.String()}
+		return req.WithContext(middleware.WithAuthContext(req.Context(), ac))
+	}
+	favorite := func(method string, id, uid uuid.UUID) int {
+		req := httptest.NewRequest(method, "/api/v1/cameras/"+id.String()+"/favorite", nil)
+		req.SetPathValue("id", id.String())
+		rr := httptest.NewRecorder()
+		if method == http.MethodPost {
+			h.AddFavorite(rr, as(req, uid))
+		} else {
+			h.RemoveFavorite(rr, as(req, uid))
+		}
+		return rr.Code
+	}
+	listFavorites := func(uid uuid.UUID) []data.Camera {
+		rr := httptest.NewRecorder()
+		h.List(rr, as(httptest.NewRequest("GET", "/api/v1/cameras?favorites_only=true", nil), uid))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200 from list, got %d", rr.Code)
+		}
+		var resp struct {
+			Data []data.Camera `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	if code := favorite(http.MethodPost, pinned.ID, userID); code != http.StatusOK {
+		t.Fatalf("Expected 200 on favorite, got %d", code)
+	}
+	if code := favorite(http.MethodPost, other.ID, otherUser); code != http.StatusOK {
+		t.Fatalf("Expected 200 on favorite by other user, got %d", code)
+	}
+
+	got := listFavorites(userID)
+	if len(got) != 1 || got[0].ID != pinned.ID {
+		t.Fatalf("Expected only the user's pin, got %+v", got)
+	}
+
+	if code := favorite(http.MethodDelete, pinned.ID, userID); code != http.StatusOK {
+		t.Fatalf("Expected 200 on unfavorite, got %d", code)
+	}
+	if got := listFavorites(userID); len(got) != 0 {
+		t.Errorf("Expected no favorites after unpin, got %+v", got)
+	}
+	if got := listFavorites(otherUser); len(got) != 1 || got[0].ID != other.ID {
+		t.Errorf("Other user's pins should be untouched, got %+v", got)
+	}
+}
+
+func TestHandler_Favorite_ForeignCamera(t *testing.T) {
+	foreign := &data.Camera{ID: uuid.New(), TenantID: uuid.New()}
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{foreign.ID: foreign}}
+	h := api.NewCameraHandler(cameras.NewService(repo, &MockLicense{}, &MockAuditor{}))
+
+	req := httptest.NewRequest("POST", "/api/v1/cameras/"+foreign.ID.String()+"/favorite", nil)
+	req.SetPathValue("id", foreign.ID.String())
+	rr := httptest.NewRecorder()
+	h.AddFavorite(rr, withAuth(req))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for camera in another tenant, got %d", rr.Code)
+	}
+	if len(repo.favorites) != 0 {
+		t.Errorf("Foreign camera must not be pinned, got %v", repo.favorites)
+	}
+}
+
+func TestHandler_ListTags_DistinctWithCounts(t *testing.T) {
+	tenantID := uuid.New()
+	cam := func(tenant uuid.UUID, tags ...string) *data.Camera {
+		return &data.Camera{ID: uuid.New(), TenantID: tenant, Tags: tags}
+	}
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{}}
+	for _, c := range []*data.Camera{
+		cam(tenantID, "entrance", "outdoor"),
+		cam(tenantID, "entrance", "Entry-B"),
+		cam(tenantID, "lobby"),
+		cam(uuid.New(), "entrance", "entrance-foreign"),
+	} {
+		repo.cams[c.ID] = c
+	}
+	h := api.NewCameraHandler(cameras.NewService(repo, &MockLicense{}, &MockAuditor{}))
+
+	req := httptest.NewRequest("GET", "/api/v1/cameras/tags?prefix=ent", nil)
+	ac := &middleware.AuthContext{TenantID: tenantID.String(), UserID: uuid.New().String()}
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), ac))
+	rr := httptest.NewRecorder()
+	h.ListTags(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rr.Code)
+	}
+	var resp struct {
+		Data []data.TagCount `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+
+	want := []data.TagCount{{Tag: "entrance", Count: 2}, {Tag: "Entry-B", Count: 1}}
+	if len(resp.Data) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, resp.Data)
+	}
+	for i := range want {
+		if resp.Data[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, resp.Data)
+		}
+	}
+}
+
+func TestHandler_ListTags_InvalidLimit(t *testing.T) {
+	h := api.NewCameraHandler(cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{}))
+	rr := httptest.NewRecorder()
+	h.ListTags(rr, withAuth(httptest.NewRequest("GET", "/api/v1/cameras/tags?limit=abc", nil)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rr.Code)
+	}
+}
+
+func TestHandler_UpdateCamera(t *testing.T) {
+	tenantID := uuid.New()
+	own := &data.Camera{ID: uuid.New(), TenantID: tenantID, SiteID: uuid.New(), Name: "Lobby", IPAddress: net.ParseIP("10.0.0.1"), Port: 554, Tags: []string{"old"}}
+	foreign := &data.Camera{ID: uuid.New(), TenantID: uuid.New(), Name: "Other", IPAddress: net.ParseIP("10.0.0.2"), Port: 554}
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{own.ID: own, foreign.ID: foreign}}
+	h := api.NewCameraHandler(cameras.NewService(repo, &MockLicense{}, &MockAuditor{}))
+	update := func(id uuid.UUID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/v1/cameras/"+id.String(), bytes.NewBufferString(body))
+		req.SetPathValue("id", id.String())
+		req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{TenantID: tenantID.String(), UserID: uuid.New().String()}))
+		rr := httptest.NewRecorder()
+		h.Update(rr, req)
+		return rr
+	}
+
+	rr := update(own.ID, `{"name":"Lobby East","ip_address":"10.0.0.9","port":8554,"manufacturer":"Axis","model":"P3245","serial_number":"SN1","mac_address":"00:11:22:33:44:55","tags":["entry"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var got data.Camera
+	json.NewDecoder(rr.Body).Decode(&got)
+	if got.Name != "Lobby East" || !got.IPAddress.Equal(net.ParseIP("10.0.0.9")) || got.Port != 8554 ||
+		got.Manufacturer != "Axis" || got.Model != "P3245" || got.SerialNumber != "SN1" ||
+		got.MacAddress != "00:11:22:33:44:55" || len(got.Tags) != 1 || got.Tags[0] != "entry" {
+		t.Errorf("Changes not applied: %+v", got)
+	}
+	if got.SiteID != own.SiteID || got.TenantID != tenantID {
+		t.Errorf("Site and tenant must be kept, got %+v", got)
+	}
+
+	// Omitted fields keep their stored values.
+	rr = update(own.ID, `{"name":"Lobby West"}`)
+	json.NewDecoder(rr.Body).Decode(&got)
+	if rr.Code != http.StatusOK || got.Name != "Lobby West" || got.Port != 554 || !got.IPAddress.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("Expected a name-only update, got %d %+v", rr.Code, got)
+	}
+
+	for name, tc := range map[string]struct {
+		id   uuid.UUID
+		body string
+		want int
+	}{
+		"foreign":    {foreign.ID, `{"name":"x"}`, http.StatusNotFound},
+		"unknown":    {uuid.New(), `{"name":"x"}`, http.StatusNotFound},
+		"invalid_ip": {own.ID, `{"ip_address":"not-an-ip"}`, http.StatusBadRequest},
+		"bad_port":   {own.ID, `{"port":0}`, http.StatusBadRequest},
+		"empty_name": {own.ID, `{"name":""}`, http.StatusBadRequest},
+		"bad_json":   {own.ID, `{"name":`, http.StatusBadRequest},
+	} {
+		if rr := update(tc.id, tc.body); rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d. Body: %s", name, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+	if foreign.Name != "Other" {
+		t.Errorf("Foreign camera must not change, got %q", foreign.Name)
+	}
+}
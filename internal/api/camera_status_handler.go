@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	mediav1 "github.com/technosupport/ts-vms/gen/go/media/v1"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/middleware"
+)
+
+// statusSubCallTimeout bounds each sub-call so one slow dependency (e.g. the
+// media plane) cannot stall the whole response.
+const statusSubCallTimeout = 3 * time.Second
+
+// CameraStatusHandler aggregates health, stream, NVR link and the last RTSP
+// validation into one response. Any source may be nil; it is then reported
+// as unavailable.
+type CameraStatusHandler struct {
+	Cameras interface {
+		GetCamera(ctx context.Context, tenantID uuid.UUID, id string) (*data.Camera, error)
+	}
+	Health interface {
+		GetStatus(ctx context.Context, cameraID uuid.UUID) (*data.CameraHealthCurrent, error)
+	}
+	Ingest interface {
+		GetIngestStatus(ctx context.Context, cameraID string) (*mediav1.GetIngestStatusResponse, error)
+	}
+	Links interface {
+		GetLinkByCameraID(ctx context.Context, cameraID uuid.UUID) (*data.NVRLink, error)
+	}
+	Validations interface {
+		GetValidationHistory(ctx context.Context, tenantID, cameraID uuid.UUID, limit int) ([]*data.RTSPValidationResult, error)
+	}
+}
+
+// StreamStatus is the media plane's view of the camera's ingest.
+type StreamStatus struct {
+	Streaming      bool   `json:"streaming"`
+	State          string `json:"state,omitempty"`
+	FPS            int32  `json:"fps"`
+	LastFrameAgeMS int64  `json:"last_frame_age_ms"`
+	HLSState       string `json:"hls_state,omitempty"`
+}
+
+// CameraStatus is the GET /api/v1/cameras/{id}/status body. A nil section
+// with an entry in Errors means that sub-call failed; a nil section without
+// one means there is nothing to report (no health record, not linked, ...).
+type CameraStatus struct {
+	CameraID       uuid.UUID                  `json:"camera_id"`
+	Health         *data.CameraHealthCurrent  `json:"health"`
+	Stream         *StreamStatus              `json:"stream"`
+	NVRLink        *data.NVRLink              `json:"nvr_link"`
+	LastValidation *data.RTSPValidationResult `json:"last_validation"`
+	Partial        bool                       `json:"partial"`
+	Errors         map[string]string          `json:"errors,omitempty"`
+}
+
+// GET /api/v1/cameras/{id}/status
+func (h *CameraStatusHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	tenantID, err := uuid.Parse(ac.TenantID)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	cameraID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid Camera ID", http.StatusBadRequest)
+		return
+	}
+
+	cam, err := h.Cameras.GetCamera(r.Context(), tenantID, cameraID.String())
+	if err == nil && (cam == nil || cam.DeletedAt != nil) {
+		err = data.ErrRecordNotFound
+	}
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+
+	resp := CameraStatus{CameraID: cameraID}
+	var mu sync.Mutex
+	errUnavailable := errors.New("unavailable")
+	// fail records the section's mapped message, never the raw error text
+	fail := func(section string, err error) {
+		msg := errUnavailable.Error()
+		if !errors.Is(err, errUnavailable) {
+			status, body := MapError(err)
+			if status == http.StatusInternalServerError {
+				log.Printf("[ERROR] %s %s: %s: %v", r.Method, r.URL.Path, section, err)
+			}
+			msg, _ = body["error"].(string)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if resp.Errors == nil {
+			resp.Errors = map[string]string{}
+		}
+		resp.Errors[section] = msg
+	}
+
+	var wg sync.WaitGroup
+	run := func(fn func(ctx context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), statusSubCallTimeout)
+			defer cancel()
+			fn(ctx)
+		}()
+	}
+
+	run(func(ctx context.Context) {
+		if h.Health == nil {
+			fail("health", errUnavailable)
+			return
+		}
+		st, err := h.Health.GetStatus(ctx, cameraID)
+		if err != nil {
+			fail("health", err)
+			return
+		}
+		resp.Health = st
+	})
+
+	run(func(ctx context.Context) {
+		if h.Ingest == nil {
+			fail("stream", errUnavailable)
+			return
+		}
+		ing, err := h.Ingest.GetIngestStatus(ctx, cameraID.String())
+		if err != nil {
+			fail("stream", err)
+			return
+		}
+		resp.Stream = &StreamStatus{
+			Streaming:      ing.Running,
+			State:          ing.State,
+			FPS:            ing.Fps,
+			LastFrameAgeMS: ing.LastFrameAgeMs,
+			HLSState:       ing.HlsState,
+		}
+	})
+
+	run(func(ctx context.Context) {
+		if h.Links == nil {
+			fail("nvr_link", errUnavailable)
+			return
+		}
+		link, err := h.Links.GetLinkByCameraID(ctx, cameraID)
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return // Not linked
+		}
+		if err != nil {
+			fail("nvr_link", err)
+			return
+		}
+		if link != nil && link.TenantID == tenantID {
+			resp.NVRLink = link
+		}
+	})
+
+	run(func(ctx context.Context) {
+		if h.Validations == nil {
+			fail("last_validation", errUnavailable)
+			return
+		}
+		hist, err := h.Validations.GetValidationHistory(ctx, tenantID, cameraID, 1)
+		if err != nil {
+			fail("last_validation", err)
+			return
+		}
+		if len(hist) > 0 {
+			resp.LastValidation = hist[0]
+		}
+	})
+
+	wg.Wait()
+	resp.Partial = len(resp.Errors) > 0
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
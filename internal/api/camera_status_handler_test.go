@@ -0,0 +1,147 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	mediav1 "github.com/technosupport/ts-vms/gen/go/media/v1"
+	"github.com/technosupport/ts-vms/internal/api"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/middleware"
+)
+
+type statusCameras struct{ cam *data.Camera }
+
+func (s statusCameras) GetCamera(ctx context.Context, tenantID uuid.UUID, id string) (*data.Camera, error) {
+	if s.cam == nil || s.cam.ID.String() != id || s.cam.TenantID != tenantID {
+		return nil, data.ErrRecordNotFound
+	}
+	return s.cam, nil
+}
+
+type statusHealth struct{ err error }
+
+func (s statusHealth) GetStatus(ctx context.Context, cameraID uuid.UUID) (*data.CameraHealthCurrent, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &data.CameraHealthCurrent{CameraID: cameraID, Status: data.HealthStatusOnline}, nil
+}
+
+type statusIngest struct{ err error }
+
+func (s statusIngest) GetIngestStatus(ctx context.Context, cameraID string) (*mediav1.GetIngestStatusResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &mediav1.GetIngestStatusResponse{Running: true, State: "RUNNING", Fps: 25}, nil
+}
+
+type statusLinks struct{ link *data.NVRLink }
+
+func (s statusLinks) GetLinkByCameraID(ctx context.Context, cameraID uuid.UUID) (*data.NVRLink, error) {
+	if s.link == nil {
+		return nil, data.ErrRecordNotFound
+	}
+	return s.link, nil
+}
+
+type statusValidations struct{}
+
+func (statusValidations) GetValidationHistory(ctx context.Context, tenantID, cameraID uuid.UUID, limit int) ([]*data.RTSPValidationResult, error) {
+	return []*data.RTSPValidationResult{{CameraID: cameraID, Status: "ok", ValidatedAt: time.Now()}}, nil
+}
+
+func getCameraStatus(t *testing.T, h *api.CameraStatusHandler, tenantID, cameraID uuid.UUID) (*httptest.ResponseRecorder, api.CameraStatus) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/v1/cameras/"+cameraID.String()+"/status", nil)
+	req.SetPathValue("id", cameraID.String())
+	ac := &middleware.AuthContext{TenantID: tenantID.String(), UserID: uuid.New().String()}
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), ac))
+	rr := httptest.NewRecorder()
+	h.GetStatus(rr, req)
+
+	var body api.CameraStatus
+	json.NewDecoder(rr.Body).Decode(&body)
+	return rr, body
+}
+
+func TestCameraStatus_AllSections(t *testing.T) {
+	cam := &data.Camera{ID: uuid.New(), TenantID: uuid.New()}
+	link := &data.NVRLink{TenantID: cam.TenantID, CameraID: cam.ID, NVRID: uuid.New(), RecordingMode: "nvr"}
+	h := &api.CameraStatusHandler{
+		Cameras:     statusCameras{cam},
+		Health:      statusHealth{},
+		Ingest:      statusIngest{},
+		Links:       statusLinks{link},
+		Validations: statusValidations{},
+	}
+
+	rr, body := getCameraStatus(t, h, cam.TenantID, cam.ID)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rr.Code)
+	}
+	if body.Partial || len(body.Errors) != 0 {
+		t.Errorf("Expected complete status, got errors %v", body.Errors)
+	}
+	if body.Health == nil || body.Health.Status != data.HealthStatusOnline {
+		t.Errorf("Health not populated: %+v", body.Health)
+	}
+	if body.Stream == nil || !body.Stream.Streaming || body.Stream.FPS != 25 {
+		t.Errorf("Stream not populated: %+v", body.Stream)
+	}
+	if body.NVRLink == nil || body.NVRLink.NVRID != link.NVRID {
+		t.Errorf("NVR link not populated: %+v", body.NVRLink)
+	}
+	if body.LastValidation == nil || body.LastValidation.Status != "ok" {
+		t.Errorf("Last validation not populated: %+v", body.LastValidation)
+	}
+}
+
+func TestCameraStatus_PartialOnSubCallFailure(t *testing.T) {
+	cam := &data.Camera{ID: uuid.New(), TenantID: uuid.New()}
+	h := &api.CameraStatusHandler{
+		Cameras:     statusCameras{cam},
+		Health:      statusHealth{},
+		Ingest:      statusIngest{err: errors.New("media plane unreachable")},
+		Links:       statusLinks{},
+		Validations: statusValidations{},
+	}
+
+	rr, body := getCameraStatus(t, h, cam.TenantID, cam.ID)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 with partial result, got %d", rr.Code)
+	}
+	if !body.Partial || body.Errors["stream"] == "" {
+		t.Errorf("Expected stream failure flagged, got partial=%v errors=%v", body.Partial, body.Errors)
+	}
+	if strings.Contains(body.Errors["stream"], "unreachable") {
+		t.Errorf("Raw sub-call error leaked: %q", body.Errors["stream"])
+	}
+	if body.Stream != nil {
+		t.Errorf("Stream should be null on failure, got %+v", body.Stream)
+	}
+	if body.Health == nil || body.LastValidation == nil {
+		t.Error("Healthy sub-calls should still be populated")
+	}
+	if body.NVRLink != nil || body.Errors["nvr_link"] != "" {
+		t.Errorf("Unlinked camera should report null link without error, got %+v / %q", body.NVRLink, body.Errors["nvr_link"])
+	}
+}
+
+func TestCameraStatus_ForeignCamera(t *testing.T) {
+	cam := &data.Camera{ID: uuid.New(), TenantID: uuid.New()}
+	h := &api.CameraStatusHandler{Cameras: statusCameras{cam}}
+
+	rr, _ := getCameraStatus(t, h, uuid.New(), cam.ID)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's camera, got %d", rr.Code)
+	}
+}
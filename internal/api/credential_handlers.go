@@ -106,25 +106,31 @@ func (h *CredentialHandler) Update(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "Payload too large")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "Internal Error")
+		respondMappedError(w, r, err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
+// GET /api/v1/cameras/{id}/credentials
+// Always redacted; plaintext goes through POST .../credentials/reveal.
 func (h *CredentialHandler) Get(w http.ResponseWriter, r *http.Request) {
 	tenantID, cameraID, ok := h.checkAccess(w, r, "camera.credential.read")
 	if !ok {
 		return
 	}
 
-	reveal := r.URL.Query().Get("reveal") == "true"
+	if r.URL.Query().Get("reveal") == "true" {
+		// Justifications do not belong in URLs (access logs, proxies)
+		respondError(w, http.StatusBadRequest, "Use POST /credentials/reveal with a justification")
+		return
+	}
 
-	out, found, err := h.CredService.GetCredentials(r.Context(), tenantID, cameraID, reveal)
+	out, found, err := h.CredService.GetCredentials(r.Context(), tenantID, cameraID, false)
 	if err != nil {
 		// Crypto error or internal
-		respondError(w, http.StatusInternalServerError, "Internal Check Failed")
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -137,6 +143,78 @@ func (h *CredentialHandler) Get(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, out)
 }
 
+// POST /api/v1/cameras/{id}/credentials/reveal {"justification": "..."}
+// User-initiated reveal: justified, rate-limited, audited as credential.reveal
+func (h *CredentialHandler) Reveal(w http.ResponseWriter, r *http.Request) {
+	tenantID, cameraID, ok := h.checkAccess(w, r, "camera.credential.read")
+	if !ok {
+		return
+	}
+
+	ac, _ := middleware.GetAuthContext(r.Context())
+	userID, err := uuid.Parse(ac.UserID)
+	if err != nil {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	var req struct {
+		Justification string `json:"justification"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 8192)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	out, found, err := h.CredService.RevealCredentials(r.Context(), tenantID, cameraID, userID, req.Justification)
+	switch {
+	case errors.Is(err, cameras.ErrRevealJustificationRequired):
+		respondError(w, http.StatusBadRequest, "Justification required to reveal credentials")
+		return
+	case errors.Is(err, cameras.ErrRevealRateLimited):
+		respondError(w, http.StatusTooManyRequests, "Reveal rate limit exceeded")
+		return
+	case err != nil:
+		respondMappedError(w, r, err)
+		return
+	}
+
+	if !found {
+		respondError(w, http.StatusNotFound, "Credentials not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, out)
+}
+
+// POST /api/v1/cameras/{id}/credentials/rekey
+// Re-wraps the stored credentials under the active master key; answers
+// status "already_current" without changes when they already are.
+func (h *CredentialHandler) Rekey(w http.ResponseWriter, r *http.Request) {
+	tenantID, cameraID, ok := h.checkAccess(w, r, "camera.credential.write")
+	if !ok {
+		return
+	}
+
+	res, err := h.CredService.RekeyCredentials(r.Context(), tenantID, cameraID)
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+
+	status := "rekeyed"
+	if !res.Rekeyed {
+		status = "already_current"
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"status":       status,
+		"rekeyed":      res.Rekeyed,
+		"previous_kid": res.PreviousKID,
+		"master_kid":   res.MasterKID,
+	})
+}
+
 func (h *CredentialHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	tenantID, cameraID, ok := h.checkAccess(w, r, "camera.credential.delete")
 	if !ok {
@@ -144,9 +222,68 @@ func (h *CredentialHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.CredService.DeleteCredentials(r.Context(), tenantID, cameraID); err != nil {
-		respondError(w, http.StatusInternalServerError, "Delete Failed")
+		respondMappedError(w, r, err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
+
+// DELETE /api/v1/sites/{id}/credentials
+// Purges credentials for all cameras in the site. Requires camera.credential.delete
+// at site scope; like the per-camera routes, a denied check answers 404.
+func (h *CredentialHandler) DeleteSite(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+	siteID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid Site ID")
+		return
+	}
+
+	allowed, err := h.Perms.CheckPermission(r.Context(), "camera.credential.delete", "site", siteID.String())
+	if err != nil || !allowed {
+		respondError(w, http.StatusNotFound, "Site not found")
+		return
+	}
+
+	n, err := h.CredService.DeleteSiteCredentials(r.Context(), uuid.MustParse(ac.TenantID), siteID)
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"status": "deleted", "count": n})
+}
+
+// GET /api/v1/cameras/credentials/inventory
+// Route guarded by audit.read; returns key metadata only, never secrets.
+func (h *CredentialHandler) Inventory(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	items, err := h.CredService.Inventory(r.Context(), uuid.MustParse(ac.TenantID))
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+
+	deprecated := 0
+	for _, it := range items {
+		if it.DeprecatedKey {
+			deprecated++
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"data":                 items,
+		"total":                len(items),
+		"deprecated_key_count": deprecated,
+	})
+}
@@ -7,7 +7,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/audit"
@@ -56,10 +58,30 @@ func (m *MockCredUpdater) Get(ctx context.Context, id uuid.UUID) (*data.CameraCr
 	}
 	return nil, data.ErrCredentialNotFound
 }
+func (m *MockCredUpdater) RewrapDEK(ctx context.Context, c *data.CameraCredential, previousKID string, previousDEK []byte) error {
+	cur, ok := m.Store[c.CameraID.String()]
+	if !ok || cur.MasterKID != previousKID || string(cur.DEKCiphertext) != string(previousDEK) {
+		return data.ErrOptimisticLock
+	}
+	m.Store[c.CameraID.String()] = c
+	return nil
+}
 func (m *MockCredUpdater) Delete(ctx context.Context, id uuid.UUID) error {
 	delete(m.Store, id.String())
 	return nil
 }
+func (m *MockCredUpdater) DeleteBySite(ctx context.Context, tenantID, siteID uuid.UUID) (int, error) {
+	return 0, nil
+}
+func (m *MockCredUpdater) ListInventory(ctx context.Context, tenantID uuid.UUID) ([]data.CredentialInventoryEntry, error) {
+	var out []data.CredentialInventoryEntry
+	for _, c := range m.Store {
+		if c.TenantID == tenantID {
+			out = append(out, data.CredentialInventoryEntry{CameraID: c.CameraID, HasCredentials: true, MasterKID: c.MasterKID})
+		}
+	}
+	return out, nil
+}
 
 // Local Definition of MockAuditor for Handler pkg
 type MockAuditor struct{}
@@ -112,20 +134,20 @@ func TestCredentialHandler(t *testing.T) {
 		t.Errorf("PUT Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
 	}
 
-	// 2. Test GET (Reveal)
-	req2 := httptest.NewRequest("GET", "/api/v1/cameras/"+camID.String()+"/credentials?reveal=true", nil)
+	// 2. Test POST (Reveal)
+	req2 := httptest.NewRequest("POST", "/api/v1/cameras/"+camID.String()+"/credentials/reveal", strings.NewReader(`{"justification":"field maintenance"}`))
 	req2.SetPathValue("id", camID.String())
 	req2 = req2.WithContext(ctx)
 	rr2 := httptest.NewRecorder()
 
-	h.Get(rr2, req2)
+	h.Reveal(rr2, req2)
 	if rr2.Code != http.StatusOK {
-		t.Errorf("GET Expected 200, got %d", rr2.Code)
+		t.Errorf("Reveal Expected 200, got %d", rr2.Code)
 	}
 	var out cameras.CredentialOutput
 	json.NewDecoder(rr2.Body).Decode(&out)
 	if out.Data == nil || out.Data.Username != "admin" {
-		t.Error("GET Reveal failed")
+		t.Error("Reveal failed")
 	}
 
 	// 3. Test Unauthorized (Site Scope Fail) -> 404
@@ -157,3 +179,155 @@ func TestCredentialHandler(t *testing.T) {
 		t.Error("Failed to delete from repo")
 	}
 }
+
+func TestCredentialHandler_Inventory(t *testing.T) {
+	repo := &MockCredUpdater{Store: make(map[string]*data.CameraCredential)}
+	key, _ := crypto.GenerateDEK()
+	t.Setenv("MASTER_KEYS", `[{"kid":"test","material":"`+base64.StdEncoding.EncodeToString(key)+`"}]`)
+	t.Setenv("ACTIVE_MASTER_KID", "test")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+	credSvc := cameras.NewCredentialService(repo, kr, &MockAuditor{})
+
+	tenantID := uuid.New()
+	credSvc.SetCredentials(context.Background(), tenantID, uuid.New(), cameras.CredentialInput{Username: "admin", Password: "topSecret"})
+
+	h := NewCredentialHandler(credSvc, &MockCamProvider{}, &MockPermChecker{Result: true})
+	req := httptest.NewRequest("GET", "/api/v1/cameras/credentials/inventory", nil)
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{TenantID: tenantID.String()}))
+	rr := httptest.NewRecorder()
+	h.Inventory(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rr.Code)
+	}
+	if bytes.Contains(rr.Body.Bytes(), []byte("topSecret")) || bytes.Contains(rr.Body.Bytes(), []byte("admin")) {
+		t.Error("Inventory response leaked credential material")
+	}
+	var resp struct {
+		Total int                               `json:"total"`
+		Data  []cameras.CredentialInventoryItem `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Total != 1 || !resp.Data[0].HasCredentials || resp.Data[0].MasterKID != "test" {
+		t.Errorf("Unexpected inventory response: %+v", resp)
+	}
+}
+
+func TestCredentialHandler_DeleteSite_RequiresSitePermission(t *testing.T) {
+	credSvc := cameras.NewCredentialService(&MockCredUpdater{Store: make(map[string]*data.CameraCredential)}, crypto.NewKeyring(), &MockAuditor{})
+	siteID := uuid.New()
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{TenantID: uuid.New().String()})
+
+	for _, tc := range []struct {
+		allowed bool
+		want    int
+	}{{true, http.StatusOK}, {false, http.StatusNotFound}} {
+		h := NewCredentialHandler(credSvc, &MockCamProvider{}, &MockPermChecker{Result: tc.allowed})
+		req := httptest.NewRequest("DELETE", "/api/v1/sites/"+siteID.String()+"/credentials", nil).WithContext(ctx)
+		req.SetPathValue("id", siteID.String())
+		rr := httptest.NewRecorder()
+		h.DeleteSite(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("allowed=%v: expected %d, got %d", tc.allowed, tc.want, rr.Code)
+		}
+	}
+}
+
+func TestCredentialHandler_RevealRequiresJustification(t *testing.T) {
+	repo := &MockCredUpdater{Store: make(map[string]*data.CameraCredential)}
+	key, _ := crypto.GenerateDEK()
+	t.Setenv("MASTER_KEYS", `[{"kid":"test","material":"`+base64.StdEncoding.EncodeToString(key)+`"}]`)
+	t.Setenv("ACTIVE_MASTER_KID", "test")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+	credSvc := cameras.NewCredentialService(repo, kr, &MockAuditor{})
+	credSvc.SetRevealLimit(1, time.Hour)
+
+	tenantID, camID := uuid.New(), uuid.New()
+	credSvc.SetCredentials(context.Background(), tenantID, camID, cameras.CredentialInput{Username: "admin", Password: "topSecret"})
+	h := NewCredentialHandler(credSvc, &MockCamProvider{Camera: &data.Camera{ID: camID, TenantID: tenantID}}, &MockPermChecker{Result: true})
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{TenantID: tenantID.String(), UserID: uuid.New().String()})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/cameras/"+camID.String()+"/credentials"+query, nil).WithContext(ctx)
+		req.SetPathValue("id", camID.String())
+		rr := httptest.NewRecorder()
+		h.Get(rr, req)
+		return rr
+	}
+	reveal := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/cameras/"+camID.String()+"/credentials/reveal", strings.NewReader(body)).WithContext(ctx)
+		req.SetPathValue("id", camID.String())
+		rr := httptest.NewRecorder()
+		h.Reveal(rr, req)
+		return rr
+	}
+
+	for name, body := range map[string]string{"missing": `{}`, "blank": `{"justification":"  "}`, "malformed": `justification=x`} {
+		rr := reveal(body)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s justification: expected 400, got %d", name, rr.Code)
+		}
+		if bytes.Contains(rr.Body.Bytes(), []byte("topSecret")) {
+			t.Errorf("%s justification: response leaked the password", name)
+		}
+	}
+
+	// The query-string reveal is gone: justifications must not land in URLs
+	if rr := get("?reveal=true&justification=ticket-42"); rr.Code != http.StatusBadRequest || bytes.Contains(rr.Body.Bytes(), []byte("topSecret")) {
+		t.Errorf("GET reveal: expected 400 without the password, got %d", rr.Code)
+	}
+
+	if rr := reveal(`{"justification":"ticket-42"}`); rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte("topSecret")) {
+		t.Fatalf("Justified reveal: expected 200 with the password, got %d", rr.Code)
+	}
+	if rr := reveal(`{"justification":"ticket-42"}`); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Second reveal over the limit: expected 429, got %d", rr.Code)
+	}
+	// Redacted reads are not rate-limited
+	if rr := get(""); rr.Code != http.StatusOK {
+		t.Errorf("Redacted read: expected 200, got %d", rr.Code)
+	}
+}
+
+func TestCredentialHandler_RekeyAlreadyCurrent(t *testing.T) {
+	repo := &MockCredUpdater{Store: make(map[string]*data.CameraCredential)}
+	key, _ := crypto.GenerateDEK()
+	t.Setenv("MASTER_KEYS", `[{"kid":"test","material":"`+base64.StdEncoding.EncodeToString(key)+`"}]`)
+	t.Setenv("ACTIVE_MASTER_KID", "test")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+	credSvc := cameras.NewCredentialService(repo, kr, &MockAuditor{})
+
+	tenantID, camID := uuid.New(), uuid.New()
+	credSvc.SetCredentials(context.Background(), tenantID, camID, cameras.CredentialInput{Username: "admin", Password: "pw"})
+	h := NewCredentialHandler(credSvc, &MockCamProvider{Camera: &data.Camera{ID: camID, TenantID: tenantID}}, &MockPermChecker{Result: true})
+
+	req := httptest.NewRequest("POST", "/api/v1/cameras/"+camID.String()+"/credentials/rekey", nil)
+	req.SetPathValue("id", camID.String())
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{TenantID: tenantID.String(), UserID: uuid.New().String()}))
+	rr := httptest.NewRecorder()
+	h.Rekey(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]any
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp["status"] != "already_current" || resp["rekeyed"] != false || resp["master_kid"] != "test" {
+		t.Errorf("Expected already_current no-op, got %v", resp)
+	}
+
+	// No stored credentials
+	other := uuid.New()
+	h.CameraService = &MockCamProvider{Camera: &data.Camera{ID: other, TenantID: tenantID}}
+	req = httptest.NewRequest("POST", "/api/v1/cameras/"+other.String()+"/credentials/rekey", nil)
+	req.SetPathValue("id", other.String())
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{TenantID: tenantID.String(), UserID: uuid.New().String()}))
+	rr = httptest.NewRecorder()
+	h.Rekey(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without credentials, got %d", rr.Code)
+	}
+}
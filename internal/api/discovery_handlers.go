@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -44,7 +45,7 @@ func (h *DiscoveryHandler) CreateCredential(w http.ResponseWriter, r *http.Reque
 
 	id, err := h.Service.CreateBootstrapCredential(r.Context(), uuid.MustParse(ac.TenantID), req.Username, req.Password)
 	if err != nil {
-		http.Error(w, "Failed to create credential", http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -60,10 +61,16 @@ func (h *DiscoveryHandler) StartRun(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		SiteID string `json:"site_id"`
+		SiteID     string `json:"site_id"`
+		MaxDevices int    `json:"max_devices"` // Optional, capped at discovery.MaxDevicesPerRun
 	}
 	json.NewDecoder(r.Body).Decode(&req) // Optional
 
+	if req.MaxDevices < 0 {
+		http.Error(w, "max_devices must not be negative", http.StatusBadRequest)
+		return
+	}
+
 	// Check Perms
 	if req.SiteID != "" {
 		if allowed, _ := h.Perms.CheckPermission(r.Context(), "onvif.discovery.run", "site", req.SiteID); !allowed {
@@ -83,9 +90,13 @@ func (h *DiscoveryHandler) StartRun(w http.ResponseWriter, r *http.Request) {
 		siteUUID = &id
 	}
 
-	id, err := h.Service.StartDiscovery(r.Context(), uuid.MustParse(ac.TenantID), siteUUID)
+	id, err := h.Service.StartDiscovery(r.Context(), uuid.MustParse(ac.TenantID), siteUUID, discovery.RunOptions{MaxDevices: req.MaxDevices})
+	if errors.Is(err, discovery.ErrDiscoveryInProgress) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -159,7 +170,7 @@ func (h *DiscoveryHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
 
 	list, err := h.Service.ListDevices(r.Context(), runID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	json.NewEncoder(w).Encode(list)
@@ -224,7 +235,7 @@ func (h *DiscoveryHandler) ProbeDevice(w http.ResponseWriter, r *http.Request) {
 
 	err = h.Service.ProbeDevice(r.Context(), devID, credID, uuid.MustParse(ac.TenantID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
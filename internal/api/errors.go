@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/technosupport/ts-vms/internal/audit"
+	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/discovery"
+	"github.com/technosupport/ts-vms/internal/health"
+	"github.com/technosupport/ts-vms/internal/live"
+	"github.com/technosupport/ts-vms/internal/nvr"
+	"github.com/technosupport/ts-vms/internal/ratelimit"
+)
+
+// Error codes returned in the "code" field of mapped error responses.
+// Sentinels whose text is already an ERR_* code keep it.
+const (
+	CodeNotFound       = "ERR_NOT_FOUND"
+	CodeConflict       = "ERR_CONFLICT"
+	CodeLicenseLimit   = "ERR_LICENSE_LIMIT"
+	CodeValidation     = "ERR_VALIDATION"
+	CodeRateLimited    = "ERR_RATE_LIMITED"
+	CodeGone           = "ERR_GONE"
+	CodeForbidden      = "ERR_FORBIDDEN"
+	CodeNotImplemented = "ERR_NOT_SUPPORTED"
+	CodeInternal       = "ERR_INTERNAL"
+)
+
+type errorMapping struct {
+	err     error
+	status  int
+	code    string
+	message string
+}
+
+// errorMappings is checked in order with errors.Is; the first match wins.
+var errorMappings = []errorMapping{
+	// Not found
+	{data.ErrRecordNotFound, http.StatusNotFound, CodeNotFound, "Not found"},
+	{data.ErrUserNotFound, http.StatusNotFound, CodeNotFound, "User not found"},
+	{data.ErrTokenNotFound, http.StatusNotFound, CodeNotFound, "Token not found"},
+	{data.ErrCredentialNotFound, http.StatusNotFound, CodeNotFound, "Credentials not found"},
+	{data.ErrRunNotFound, http.StatusNotFound, CodeNotFound, "Discovery run not found"},
+	{data.ErrDeviceNotFound, http.StatusNotFound, CodeNotFound, "Discovered device not found"},
+	{nvr.ErrNVRNotFound, http.StatusNotFound, CodeNotFound, "NVR not found"},
+	{audit.ErrExportJobNotFound, http.StatusNotFound, CodeNotFound, "Export job not found"},
+	{live.ErrSessionNotFound, http.StatusNotFound, CodeNotFound, "Session not found"},
+
+	// Conflicts
+	{cameras.ErrDuplicateIP, http.StatusConflict, cameras.ErrDuplicateIP.Error(), "Another camera in this site already uses this IP address"},
+	{data.ErrDuplicateGroupName, http.StatusConflict, CodeConflict, "A camera group with this name already exists"},
+	{data.ErrChannelLinked, http.StatusConflict, CodeConflict, "NVR channel is already linked to another camera"},
+	{data.ErrEmailDuplicate, http.StatusConflict, CodeConflict, "Email already exists"},
+	{data.ErrOptimisticLock, http.StatusConflict, CodeConflict, "Resource was modified concurrently; retry"},
+	{discovery.ErrDiscoveryInProgress, http.StatusConflict, discovery.ErrDiscoveryInProgress.Error(), "A discovery run is already in progress"},
+	{audit.ErrExportNotReady, http.StatusConflict, CodeConflict, "Export not completed"},
+
+	// Expired or consumed tokens
+	{data.ErrTokenExpired, http.StatusGone, CodeGone, "Token expired"},
+	{data.ErrTokenUsed, http.StatusGone, CodeGone, "Token already used"},
+
+	// License
+	{cameras.ErrLicenseLimitExceeded, http.StatusPaymentRequired, CodeLicenseLimit, "License limit exceeded"},
+
+	// Validation
+	{cameras.ErrSiteRequired, http.StatusBadRequest, CodeValidation, "site_id is required: no default site is configured for this tenant"},
+	{cameras.ErrSiteScopeMismatch, http.StatusBadRequest, CodeValidation, "Site does not belong to tenant"},
+	{cameras.ErrInvalidIP, http.StatusBadRequest, CodeValidation, "Invalid IP"},
+	{cameras.ErrNameTooLong, http.StatusBadRequest, CodeValidation, "Invalid Name"},
+	{cameras.ErrTooManyTags, http.StatusBadRequest, cameras.ErrTooManyTags.Error(), "Too many tags"},
+	{cameras.ErrInvalidPort, http.StatusBadRequest, cameras.ErrInvalidPort.Error(), "Port must be between 1 and 65535"},
+	{cameras.ErrUnknownStreamVariant, http.StatusBadRequest, CodeValidation, "Invalid variant"},
+	{cameras.ErrCredentialTooLarge, http.StatusBadRequest, CodeValidation, "Payload too large"},
+	{cameras.ErrCredentialInvalid, http.StatusBadRequest, CodeValidation, "Invalid credential format"},
+	{cameras.ErrRevealJustificationRequired, http.StatusBadRequest, CodeValidation, "Justification required to reveal credentials"},
+	{nvr.ErrInvalidOp, http.StatusBadRequest, CodeValidation, "Invalid operation"},
+	{nvr.ErrInvalidRecordingMode, http.StatusBadRequest, CodeValidation, "Invalid recording_mode (vms, nvr or hybrid)"},
+	{nvr.ErrInvalidChannelName, http.StatusBadRequest, CodeValidation, "Invalid channel name"},
+	{nvr.ErrInvalidNVRName, http.StatusBadRequest, CodeValidation, "Invalid name (1-120 characters)"},
+	{nvr.ErrInvalidNVRIP, http.StatusBadRequest, CodeValidation, "Invalid ip_address"},
+	{nvr.ErrInvalidVendor, http.StatusBadRequest, CodeValidation, "Invalid vendor"},
+	{nvr.ErrInvalidHealthCheckInterval, http.StatusBadRequest, CodeValidation, "Invalid health_check_interval_seconds (10-86400)"},
+	{nvr.ErrInvalidEventPollInterval, http.StatusBadRequest, CodeValidation, "Invalid event_poll_interval_ms (500-3600000)"},
+	{nvr.ErrInvalidHealthProbeMode, http.StatusBadRequest, CodeValidation, "Invalid health_probe_mode (rtsp or snapshot)"},
+	{data.ErrInvalidLinkOrder, http.StatusBadRequest, CodeValidation, "Invalid order (created_at, camera_name or channel_ref)"},
+	{audit.ErrExportRangeTooWide, http.StatusBadRequest, CodeValidation, "Export time range exceeds limit"},
+	{audit.ErrExportTooLarge, http.StatusBadRequest, CodeValidation, "Export row count exceeds limit"},
+	{audit.ErrChainSeqNotFound, http.StatusBadRequest, CodeValidation, "after_seq is not in the audit chain"},
+	{cameras.ErrRTSPHostMismatch, http.StatusUnprocessableEntity, cameras.ErrRTSPHostMismatch.Error(), "Camera stream URIs point at another host"},
+
+	// Access and rate limiting
+	{live.ErrCameraAccessDenied, http.StatusForbidden, CodeForbidden, "Camera access denied"},
+	{cameras.ErrRevealRateLimited, http.StatusTooManyRequests, CodeRateLimited, "Reveal rate limit exceeded"},
+	{ratelimit.ErrRateLimitExceeded, http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded"},
+	{audit.ErrExportTooManyJobs, http.StatusTooManyRequests, CodeRateLimited, "Too many running export jobs"},
+	{health.ErrRecheckTooSoon, http.StatusTooManyRequests, health.ErrRecheckTooSoon.Error(), "Recheck requested too soon"},
+
+	// Unsupported device features
+	{discovery.ErrImagingNotSupported, http.StatusNotImplemented, CodeNotImplemented, "Camera does not support ONVIF imaging"},
+	{discovery.ErrEventsNotSupported, http.StatusNotImplemented, CodeNotImplemented, "Camera does not support ONVIF events"},
+}
+
+// MapError maps a data-layer or service error to an HTTP status and a JSON
+// body of the form {"code": ..., "error": ...}. A *cameras.ValidationError
+// also carries its "fields". Unrecognized errors map to 500 with a generic
+// message so internal details are not leaked to clients.
+func MapError(err error) (int, map[string]any) {
+	var vErr *cameras.ValidationError
+	if errors.As(err, &vErr) {
+		return http.StatusBadRequest, map[string]any{
+			"code":   CodeValidation,
+			"error":  "validation_failed",
+			"fields": vErr.Fields,
+		}
+	}
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.status, map[string]any{"code": m.code, "error": m.message}
+		}
+	}
+	return http.StatusInternalServerError, map[string]any{"code": CodeInternal, "error": "Internal Error"}
+}
+
+// respondMappedError writes err as mapped by MapError; unmapped errors are
+// logged since the client only sees a generic message.
+func respondMappedError(w http.ResponseWriter, r *http.Request, err error) {
+	status, body := MapError(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("[ERROR] %s %s: %v", r.Method, r.URL.Path, err)
+	}
+	respondJSON(w, status, body)
+}
@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/technosupport/ts-vms/internal/api"
+	"github.com/technosupport/ts-vms/internal/audit"
+	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/discovery"
+	"github.com/technosupport/ts-vms/internal/health"
+	"github.com/technosupport/ts-vms/internal/live"
+	"github.com/technosupport/ts-vms/internal/nvr"
+	"github.com/technosupport/ts-vms/internal/ratelimit"
+)
+
+func TestMapError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{data.ErrRecordNotFound, http.StatusNotFound},
+		{data.ErrUserNotFound, http.StatusNotFound},
+		{data.ErrTokenNotFound, http.StatusNotFound},
+		{data.ErrCredentialNotFound, http.StatusNotFound},
+		{data.ErrRunNotFound, http.StatusNotFound},
+		{data.ErrDeviceNotFound, http.StatusNotFound},
+		{nvr.ErrNVRNotFound, http.StatusNotFound},
+		{audit.ErrExportJobNotFound, http.StatusNotFound},
+		{live.ErrSessionNotFound, http.StatusNotFound},
+
+		{cameras.ErrDuplicateIP, http.StatusConflict},
+		{data.ErrDuplicateGroupName, http.StatusConflict},
+		{data.ErrChannelLinked, http.StatusConflict},
+		{data.ErrEmailDuplicate, http.StatusConflict},
+		{data.ErrOptimisticLock, http.StatusConflict},
+		{discovery.ErrDiscoveryInProgress, http.StatusConflict},
+		{audit.ErrExportNotReady, http.StatusConflict},
+
+		{data.ErrTokenExpired, http.StatusGone},
+		{data.ErrTokenUsed, http.StatusGone},
+
+		{cameras.ErrLicenseLimitExceeded, http.StatusPaymentRequired},
+		{&cameras.QuotaError{Limit: 1, Enabled: 1, Requested: 1}, http.StatusPaymentRequired},
+
+		{cameras.ErrSiteRequired, http.StatusBadRequest},
+		{cameras.ErrSiteScopeMismatch, http.StatusBadRequest},
+		{cameras.ErrInvalidIP, http.StatusBadRequest},
+		{cameras.ErrNameTooLong, http.StatusBadRequest},
+		{cameras.ErrTooManyTags, http.StatusBadRequest},
+		{cameras.ErrUnknownStreamVariant, http.StatusBadRequest},
+		{cameras.ErrCredentialTooLarge, http.StatusBadRequest},
+		{cameras.ErrCredentialInvalid, http.StatusBadRequest},
+		{cameras.ErrRevealJustificationRequired, http.StatusBadRequest},
+		{nvr.ErrInvalidOp, http.StatusBadRequest},
+		{nvr.ErrInvalidRecordingMode, http.StatusBadRequest},
+		{nvr.ErrInvalidChannelName, http.StatusBadRequest},
+		{nvr.ErrInvalidNVRName, http.StatusBadRequest},
+		{nvr.ErrInvalidNVRIP, http.StatusBadRequest},
+		{nvr.ErrInvalidVendor, http.StatusBadRequest},
+		{nvr.ErrInvalidHealthCheckInterval, http.StatusBadRequest},
+		{nvr.ErrInvalidEventPollInterval, http.StatusBadRequest},
+		{nvr.ErrInvalidHealthProbeMode, http.StatusBadRequest},
+		{data.ErrInvalidLinkOrder, http.StatusBadRequest},
+		{audit.ErrExportRangeTooWide, http.StatusBadRequest},
+		{audit.ErrExportTooLarge, http.StatusBadRequest},
+		{&cameras.ValidationError{Fields: map[string]string{"brightness": "out of range"}}, http.StatusBadRequest},
+		{cameras.ErrRTSPHostMismatch, http.StatusUnprocessableEntity},
+
+		{live.ErrCameraAccessDenied, http.StatusForbidden},
+		{cameras.ErrRevealRateLimited, http.StatusTooManyRequests},
+		{ratelimit.ErrRateLimitExceeded, http.StatusTooManyRequests},
+		{&health.RecheckTooSoonError{Remaining: time.Second}, http.StatusTooManyRequests},
+
+		{discovery.ErrImagingNotSupported, http.StatusNotImplemented},
+		{discovery.ErrEventsNotSupported, http.StatusNotImplemented},
+
+		{errors.New("pq: connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		status, body := api.MapError(tc.err)
+		if status != tc.want {
+			t.Errorf("%v: status %d, want %d", tc.err, status, tc.want)
+		}
+		if body["code"] == "" || body["error"] == "" {
+			t.Errorf("%v: body missing code or error: %v", tc.err, body)
+		}
+
+		// Wrapped errors map the same way
+		if status, _ := api.MapError(fmt.Errorf("service: %w", tc.err)); status != tc.want {
+			t.Errorf("wrapped %v: status %d, want %d", tc.err, status, tc.want)
+		}
+	}
+}
+
+func TestMapError_InternalDoesNotLeak(t *testing.T) {
+	status, body := api.MapError(errors.New("pq: password authentication failed for user vms"))
+	if status != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", status)
+	}
+	if body["code"] != api.CodeInternal || body["error"] != "Internal Error" {
+		t.Errorf("unexpected body %v", body)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -32,7 +33,7 @@ func (h *HealthHandler) GetHealth(w http.ResponseWriter, r *http.Request) {
 	// Service wrapper is better.
 	statuses, err := h.Service.Repo.ListStatuses(r.Context(), tenantID)
 	if err != nil {
-		http.Error(w, "failed to list health", http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -50,7 +51,7 @@ func (h *HealthHandler) GetCameraHealth(w http.ResponseWriter, r *http.Request)
 
 	status, err := h.Service.GetStatus(r.Context(), cameraID)
 	if err != nil {
-		http.Error(w, "failed to get status", http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	if status == nil {
@@ -86,7 +87,7 @@ func (h *HealthHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 
 	history, err := h.Service.GetHistory(r.Context(), cameraID, limit, offset)
 	if err != nil {
-		http.Error(w, "failed to get history", http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -106,7 +107,7 @@ func (h *HealthHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
 
 	alerts, err := h.Service.ListAlerts(r.Context(), tenantID, state)
 	if err != nil {
-		http.Error(w, "failed to list alerts", http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -133,7 +134,18 @@ func (h *HealthHandler) ManualRecheck(w http.ResponseWriter, r *http.Request) {
 	// Assuming generic "manage" or specific permission middleware on route.
 
 	if err := h.Service.ManualCheck(r.Context(), tenantID, cameraID); err != nil {
-		http.Error(w, "failed to trigger check", http.StatusInternalServerError)
+		var tooSoon *health.RecheckTooSoonError
+		if errors.As(err, &tooSoon) {
+			secs := tooSoon.RemainingSeconds()
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			respondJSON(w, http.StatusTooManyRequests, map[string]any{
+				"code":                health.ErrRecheckTooSoon.Error(),
+				"error":               "Recheck requested too soon",
+				"retry_after_seconds": secs,
+			})
+			return
+		}
+		respondMappedError(w, r, err)
 		return
 	}
 
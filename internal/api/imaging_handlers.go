@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/discovery"
+)
+
+type ImagingHandler struct {
+	Service *cameras.ImagingService
+}
+
+func NewImagingHandler(svc *cameras.ImagingService) *ImagingHandler {
+	return &ImagingHandler{Service: svc}
+}
+
+// GET /api/v1/cameras/{id}/imaging
+func (h *ImagingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	// RBAC: camera.imaging.read
+	cameraID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	tenantID, err := getTenantID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	settings, err := h.Service.GetImaging(r.Context(), tenantID, cameraID)
+	if err != nil {
+		h.respondImagingError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// PUT /api/v1/cameras/{id}/imaging
+func (h *ImagingHandler) Update(w http.ResponseWriter, r *http.Request) {
+	// RBAC: camera.imaging.write
+	cameraID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	tenantID, err := getTenantID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var settings discovery.ImagingSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := h.Service.SetImaging(r.Context(), tenantID, cameraID, &settings); err != nil {
+		h.respondImagingError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *ImagingHandler) respondImagingError(w http.ResponseWriter, err error) {
+	var vErr *cameras.ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		respondJSON(w, http.StatusBadRequest, map[string]any{
+			"error":  "validation_failed",
+			"fields": vErr.Fields,
+		})
+	case errors.Is(err, data.ErrRecordNotFound):
+		respondError(w, http.StatusNotFound, "Camera not found")
+	case errors.Is(err, cameras.ErrImagingNotSupported):
+		respondError(w, http.StatusNotImplemented, "Camera does not support ONVIF imaging")
+	default:
+		respondError(w, http.StatusBadGateway, "Imaging request failed")
+	}
+}
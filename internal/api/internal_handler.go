@@ -1,19 +1,125 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/live"
+	"github.com/technosupport/ts-vms/internal/ratelimit"
 )
 
 type InternalHandler struct {
 	Service *live.Service
+
+	// CaptureFrame grabs one JPEG frame from an RTSP URL; nil uses ffmpeg.
+	CaptureFrame func(ctx context.Context, rtspURL string) ([]byte, error)
+
+	// RTSP resolves credential-injected stream URLs (cameras.MediaService).
+	RTSP RTSPURLResolver
+
+	// NVRSnapshots serves cameras streamed through an NVR channel from the
+	// NVR (nvr.Service); nil captures every camera over RTSP.
+	NVRSnapshots NVRSnapshotSource
+
+	// Thumbnails tracks thumbnail freshness for the refresh job (cameras.Service).
+	Thumbnails ThumbnailTracker
+
+	// MaxSnapshotDimension caps frame width/height accepted for re-encoding;
+	// 0 uses DefaultMaxSnapshotDimension.
+	MaxSnapshotDimension int
+
+	// Snapshots shares one capture among concurrent requests for a camera;
+	// nil captures per request.
+	Snapshots *live.SnapshotFlight
+
+	// MaxActiveCameras caps the active-cameras list regardless of the AI
+	// service's own cap; 0 uses DefaultMaxActiveCameras.
+	MaxActiveCameras int
+
+	// SnapshotLimiter rate-limits internal snapshots per service identity at
+	// SnapshotLimit; nil disables the limit.
+	SnapshotLimiter ServiceRateLimiter
+	SnapshotLimit   ratelimit.LimitConfig
+
+	// Scope limits which tenants' cameras the service token may read through
+	// the snapshot, rtsp and active endpoints; the zero value is global.
+	Scope ServiceScope
+
+	// HTTPIngest enables POST /internal/detections, the AI service's
+	// fallback when NATS is unavailable; false answers 403.
+	HTTPIngest bool
+}
+
+// ServiceScope is the tenant scope of the AI service token.
+type ServiceScope struct {
+	// Tenants the token may access; empty means every tenant.
+	Tenants map[uuid.UUID]bool
+	// LogAccess logs service identity, camera and tenant for each access.
+	LogAccess bool
+}
+
+// Allows reports whether cameras of tenantID are within the scope.
+func (s ServiceScope) Allows(tenantID uuid.UUID) bool {
+	return len(s.Tenants) == 0 || s.Tenants[tenantID]
+}
+
+func (s ServiceScope) active() bool {
+	return len(s.Tenants) > 0 || s.LogAccess
+}
+
+// DefaultMaxActiveCameras bounds GET /internal/cameras/active.
+const DefaultMaxActiveCameras = 16
+
+// ServiceRateLimiter is implemented by ratelimit.Limiter.
+type ServiceRateLimiter interface {
+	CheckRateLimit(ctx context.Context, key string, config ratelimit.LimitConfig) (*ratelimit.Decision, error)
+}
+
+type serviceIdentityKey struct{}
+
+// serviceIdentity names the calling service by a hash of its token, so
+// limits follow the credential rather than a client-chosen header.
+func serviceIdentity(ctx context.Context) string {
+	id, _ := ctx.Value(serviceIdentityKey{}).(string)
+	return id
+}
+
+// DefaultMaxSnapshotDimension is the largest frame edge decoded for re-encode (8K UHD).
+const DefaultMaxSnapshotDimension = 7680
+
+// ErrSnapshotTooLarge is returned when a frame's header declares dimensions
+// above the configured limit; the frame is rejected without being decoded.
+var ErrSnapshotTooLarge = errors.New("snapshot dimensions exceed limit")
+
+type RTSPURLResolver interface {
+	ResolveRTSPURL(ctx context.Context, cameraID uuid.UUID, variant string) (string, error)
+}
+
+type NVRSnapshotSource interface {
+	// ChannelSnapshot reports routed=false for cameras not streamed via an NVR.
+	ChannelSnapshot(ctx context.Context, cameraID uuid.UUID) (frame []byte, routed bool, err error)
+}
+
+type ThumbnailTracker interface {
+	ListDueForThumbnail(ctx context.Context, olderThan time.Duration) ([]data.ThumbnailDue, error)
+	MarkThumbnailCaptured(ctx context.Context, cameraID uuid.UUID, capturedAt time.Time) error
 }
 
 func NewInternalHandler(svc *live.Service) *InternalHandler {
@@ -45,22 +151,24 @@ func (h *InternalHandler) ServiceAuthMiddleware(next http.Handler) http.Handler
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
-		next.ServeHTTP(w, r)
+		sum := sha256.Sum256([]byte(token))
+		ctx := context.WithValue(r.Context(), serviceIdentityKey{}, hex.EncodeToString(sum[:8]))
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// HandleIngestDetection accepts detections from AI Service
 // POST /api/v1/internal/detections
-// NOTE: This endpoint is dev-only when ENABLE_HTTP_INGEST=true
+// Auth: Service Token
+// HTTP fallback for the AI service when NATS is unavailable. The payload is
+// validated and stored exactly as a NATS detection (live.Service.SaveDetection);
+// 403 unless HTTPIngest is set.
 func (h *InternalHandler) IngestDetection(w http.ResponseWriter, r *http.Request) {
-	// Check if HTTP ingest is enabled (dev-only)
-	if os.Getenv("ENABLE_HTTP_INGEST") != "true" {
+	if !h.HTTPIngest {
 		http.Error(w, "HTTP ingest disabled", http.StatusForbidden)
 		return
 	}
 
-	// Limit Body Size (8KB)
-	r.Body = http.MaxBytesReader(w, r.Body, 8192)
+	r.Body = http.MaxBytesReader(w, r.Body, live.MaxPayloadSize)
 
 	var payload live.DetectionPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -68,93 +176,54 @@ func (h *InternalHandler) IngestDetection(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Validation using service layer
-	if err := live.ValidateDetection(&payload); err != nil {
+	// Validate before the tenant lookup so malformed payloads never hit the DB.
+	if err := h.Service.ValidateDetection(&payload); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Tenant?
-	// The AI Service likely knows the TenantID if it pulled list from us.
-	// But payload matches contract: { "camera_id": ... }
-	// We need to know which Tenant owns this camera to store it correctly "det:latest:{tenant}:{cam}"
-	// Ideally detection payload includes TenantID.
-	// OR we look it up. Lookup is expensive (DB).
-	// Solution: Active Camera List provided to AI contained TenantID?
-	// The "Active List" return `[]string` (camera IDs).
-	// Wait, `det:latest:{tenant}:{camera}` requires TenantID.
-	// We should probably rely on the AI service to pass back the TenantID it got.
-	// Let's assume payload HAS TenantID or we fix Active List to return it.
-	// User Requirement: "Key must include tenant".
-	// Let's Lookup: live:active_overlay ZSET stores CameraID.
-	// We assume we can get TenantID from CameraID? -> `s.GetCamera` checks it.
-	// But `GetCamera` requires TenantID input to verify.
-	// We need a Reverse Lookup or just trust AI service?
-	// TRUST AI SERVICE: It calls `GetActive` -> We return struct {ID, TenantID}.
-	// Update Internal Handler `GetActiveCameras` to return struct.
-	// Update Ingest to accept TenantID.
-
-	// Assuming Payload struct in service.go needs TenantID field?
-	// "Contract" in prompt: { "camera_id": ..., "objects": ... } NO TENANT.
-	// So we must lookup.
-	// "Option A: Storage Key det:latest:{tenant_id}:{camera_id}"
-	// How do we get TenantID?
-	// Does CameraID imply Tenant? Yes, globally unique UUID.
-	// Can we look it up? `repo.ResolveSiteID` exists. Maybe `repo.GetTenantID(camID)`?
-	// Or cache it.
-	// Simpler: The ZSET `live:overlay_demand` stores CameraID.
-	// When we added to ZSET (IncrementOverlayDemand), we knew the TenantID?
-	// Yes, `StartLiveSession` context has User -> Tenant.
-	// Store `tenant_id:camera_id` in ZSET?
-	// "Member" = "tenant_uuid:camera_uuid".
-	// This solves it.
-
-	// Re-reading service.go: `IncrementOverlayDemand(ctx, cameraID)`.
-	// I should change that to `IncrementOverlayDemand(ctx, tenantID, cameraID)`.
-	// And store combined string.
-	// Then `GetActiveCameras` returns combined strings.
-	// AI Service parses them.
-	// AI Service sends back detection with just CameraID? Or both?
-	// Ingest needs to know.
-	// If AI Service sends `camera_id` and we stored `tenant:camera` in ZSET... we can't easily map back without lookup.
-	// Unless AI Service sends TenantID in payload (easier).
-	// Let's add TenantID to DetectionPayload.
-
-	// NOTE: I cannot change `live.DetectionPayload` easily if I just wrote it in `service.go`.
-	// I will update `service.go` next step to include TenantID in payload if needed,
-	// OR update `IngestDetection` to assume `camera_id` is sufficient IF we relax the key requirement?
-	// No, multi-session safety.
-	// I will Parse TenantID from the Request? Or require it in Payload.
-	// Prompt Contract:
-	// { "camera_id": "...", "ts_unix_ms": ... "objects": ... }
-	// NO TenantID.
-	// OK, I must lookup.
-	// `h.Service.ResolveCameraTenant(ctx, camID)` -> (tenantID, error).
-	// I will add this helper to Service.
-
-	// For now, let's assume I can resolve it.
+	// The contract carries no tenant; camera IDs are globally unique.
 	tenantID, err := h.Service.ResolveCameraTenant(r.Context(), payload.CameraID)
-	// Make sure ResolveCameraTenant uses a fast lookup (e.g. Redis cache or DB).
 	if err != nil {
 		http.Error(w, "Camera not found", http.StatusNotFound)
 		return
 	}
+	if camID, err := uuid.Parse(payload.CameraID); err == nil && !h.inScope(w, r, "detections", camID, tenantID) {
+		return
+	}
 
 	if err := h.Service.SaveDetection(r.Context(), tenantID, &payload); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
 // GET /api/v1/internal/cameras/active
-// Returns cameras with overlay demand seen within 20s
+// Returns cameras with overlay demand seen within 20s, most recent first,
+// limited to the service scope and capped at MaxActiveCameras.
 func (h *InternalHandler) GetActiveCameras(w http.ResponseWriter, r *http.Request) {
 	list, err := h.Service.GetActiveCamerasForAI(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
+	if len(h.Scope.Tenants) > 0 {
+		scoped := list[:0]
+		for _, c := range list {
+			if tid, err := uuid.Parse(c.TenantID); err == nil && h.Scope.Allows(tid) {
+				scoped = append(scoped, c)
+			}
+		}
+		list = scoped
+	}
+	max := h.MaxActiveCameras
+	if max <= 0 {
+		max = DefaultMaxActiveCameras
+	}
+	if len(list) > max {
+		list = list[:max]
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(list)
@@ -162,6 +231,7 @@ func (h *InternalHandler) GetActiveCameras(w http.ResponseWriter, r *http.Reques
 
 // GET /api/v1/internal/cameras/{id}/snapshot
 // Auth: Service Token
+// ?format=png|jpeg or Accept: image/png selects the encoding (default JPEG).
 func (h *InternalHandler) GetInternalSnapshot(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	if idStr == "" {
@@ -178,26 +248,248 @@ func (h *InternalHandler) GetInternalSnapshot(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if !h.allowSnapshot(w, r) {
+		return
+	}
+
 	// Internal unsafe retrieval to get IP
 	cam, err := h.Service.CameraService.GetByIDUnsafe(r.Context(), camID)
 	if err != nil {
 		http.Error(w, "Camera not found", http.StatusNotFound)
 		return
 	}
+	if !h.inScope(w, r, "snapshot", camID, cam.TenantID) {
+		return
+	}
 
-	w.Header().Set("Content-Type", "image/jpeg")
+	format, ok := negotiateSnapshotFormat(r)
+	if !ok {
+		http.Error(w, "Unsupported snapshot format", http.StatusBadRequest)
+		return
+	}
 
 	// Construct RTSP URL (Missing in DB, so we construct from IP)
 	// Default pattern: rtsp://<ip>/live/0/SUB (Matches user's verification cam)
 	// TODO: Use CameraCredential or specific profile table in future.
 	rtspURL := fmt.Sprintf("rtsp://%s/live/0/SUB", cam.IPAddress.String())
 
-	// FFmpeg capture to pipe
+	capture := h.CaptureFrame
+	if capture == nil {
+		capture = ffmpegCaptureFrame
+	}
+	grab := func(ctx context.Context) ([]byte, error) {
+		if h.NVRSnapshots != nil {
+			frame, routed, err := h.NVRSnapshots.ChannelSnapshot(ctx, camID)
+			if routed {
+				return frame, err
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "NVR link lookup failed for %s, using RTSP: %v\n", camID, err)
+			}
+		}
+		return capture(ctx, rtspURL)
+	}
+	var frame []byte
+	if h.Snapshots != nil {
+		frame, err = h.Snapshots.Do(r.Context(), camID, grab)
+	} else {
+		frame, err = grab(r.Context())
+	}
+	if err != nil {
+		// Log error to stderr (captured by Control Plane logs)
+		fmt.Fprintf(os.Stderr, "Snapshot failed for %s: %v\n", camID, err)
+
+		// FALLBACK: Serve static image if available (for testing offline cams)
+		fallbackData, err2 := os.ReadFile("fallback.jpg")
+		if err2 != nil {
+			http.Error(w, "Snapshot unavailable", http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Serving fallback.jpg for %s\n", camID)
+		frame = fallbackData
+	}
+
+	maxDim := h.MaxSnapshotDimension
+	if maxDim <= 0 {
+		maxDim = DefaultMaxSnapshotDimension
+	}
+	body, err := encodeSnapshot(frame, format, maxDim)
+	if errors.Is(err, ErrSnapshotTooLarge) {
+		fmt.Fprintf(os.Stderr, "Snapshot rejected for %s: %v\n", camID, err)
+		http.Error(w, "Snapshot too large", http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Snapshot re-encode failed for %s: %v\n", camID, err)
+		http.Error(w, "Snapshot encode failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", format)
+	w.Write(body)
+}
+
+// allowSnapshot applies the per-service snapshot rate limit, writing 429 when
+// exceeded. Limiter errors fail open like the global API limiter.
+func (h *InternalHandler) allowSnapshot(w http.ResponseWriter, r *http.Request) bool {
+	if h.SnapshotLimiter == nil || h.SnapshotLimit.Rate <= 0 {
+		return true
+	}
+	key := "rl:svc:snapshot:" + serviceIdentity(r.Context())
+	d, err := h.SnapshotLimiter.CheckRateLimit(r.Context(), key, h.SnapshotLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Snapshot rate limit check failed (allowing): %v\n", err)
+		return true
+	}
+	if !d.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(d.RetryAfter))
+		http.Error(w, "Snapshot rate limit exceeded", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// inScope writes 403 when the camera's tenant is outside the service scope
+// and logs the access when Scope.LogAccess is set.
+func (h *InternalHandler) inScope(w http.ResponseWriter, r *http.Request, op string, camID, tenantID uuid.UUID) bool {
+	allowed := h.Scope.Allows(tenantID)
+	if h.Scope.LogAccess || !allowed {
+		fmt.Fprintf(os.Stderr, "Internal %s: service=%s camera=%s tenant=%s allowed=%t\n",
+			op, serviceIdentity(r.Context()), camID, tenantID, allowed)
+	}
+	if !allowed {
+		http.Error(w, "Camera outside service scope", http.StatusForbidden)
+	}
+	return allowed
+}
+
+// GET /api/v1/internal/cameras/{id}/rtsp?variant=main|sub
+// Auth: Service Token
+// Returns the credential-injected RTSP URL so media-plane and AI services do
+// not need their own decryption path. The URL is a secret: it is never logged
+// and the response is marked uncacheable.
+func (h *InternalHandler) GetCameraRTSP(w http.ResponseWriter, r *http.Request) {
+	camID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid Camera ID", http.StatusBadRequest)
+		return
+	}
+	if h.RTSP == nil {
+		http.Error(w, "RTSP resolver not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	if h.Scope.active() {
+		tenantID, err := h.Service.ResolveCameraTenant(r.Context(), camID.String())
+		if err != nil {
+			http.Error(w, "Camera stream not found", http.StatusNotFound)
+			return
+		}
+		if !h.inScope(w, r, "rtsp", camID, tenantID) {
+			return
+		}
+	}
+
+	variant := r.URL.Query().Get("variant")
+	if variant == "" {
+		variant = "main"
+	}
+
+	rtspURL, err := h.RTSP.ResolveRTSPURL(r.Context(), camID, variant)
+	if err != nil {
+		switch {
+		case errors.Is(err, cameras.ErrUnknownStreamVariant):
+			http.Error(w, "Invalid variant", http.StatusBadRequest)
+		case errors.Is(err, data.ErrRecordNotFound):
+			http.Error(w, "Camera stream not found", http.StatusNotFound)
+		case errors.Is(err, cameras.ErrRTSPHostMismatch):
+			fmt.Fprintf(os.Stderr, "RTSP resolve refused for %s: %v\n", camID, err)
+			http.Error(w, cameras.ErrRTSPHostMismatch.Error(), http.StatusUnprocessableEntity)
+		default:
+			respondMappedError(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{
+		"camera_id": camID.String(),
+		"variant":   variant,
+		"rtsp_url":  rtspURL,
+	})
+}
+
+// GET /api/v1/internal/cameras/thumbnails/due?older_than=15m
+// Lists enabled cameras whose thumbnail is older than older_than (Go duration,
+// default 15m) or missing, oldest first.
+func (h *InternalHandler) ListThumbnailsDue(w http.ResponseWriter, r *http.Request) {
+	if h.Thumbnails == nil {
+		http.Error(w, "Thumbnail tracking not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	olderThan := cameras.DefaultThumbnailRefresh
+	if v := r.URL.Query().Get("older_than"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "Invalid older_than", http.StatusBadRequest)
+			return
+		}
+		olderThan = d
+	}
+
+	due, err := h.Thumbnails.ListDueForThumbnail(r.Context(), olderThan)
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"data": due})
+}
+
+// POST /api/v1/internal/cameras/{id}/thumbnail
+// Records a completed capture. Body is optional: {"captured_at": RFC3339}.
+func (h *InternalHandler) MarkThumbnailCaptured(w http.ResponseWriter, r *http.Request) {
+	camID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid Camera ID", http.StatusBadRequest)
+		return
+	}
+	if h.Thumbnails == nil {
+		http.Error(w, "Thumbnail tracking not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		CapturedAt *time.Time `json:"captured_at"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 1024)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	capturedAt := time.Now()
+	if req.CapturedAt != nil {
+		capturedAt = *req.CapturedAt
+	}
+
+	if err := h.Thumbnails.MarkThumbnailCaptured(r.Context(), camID, capturedAt); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Camera not found", http.StatusNotFound)
+			return
+		}
+		respondMappedError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ffmpegCaptureFrame grabs a single JPEG frame from the stream.
+func ffmpegCaptureFrame(ctx context.Context, rtspURL string) ([]byte, error) {
 	// -rtsp_transport tcp: Force TCP for reliability
-	// -i ...: Input
 	// -vframes 1: Single frame
-	// -f image2: Format
-	// -: Output to stdout
+	// -f image2 ... -: Output to stdout
 	args := []string{
 		"-y",
 		"-rtsp_transport", "tcp",
@@ -208,24 +500,64 @@ func (h *InternalHandler) GetInternalSnapshot(w http.ResponseWriter, r *http.Req
 		"-",
 	}
 
-	cmd := exec.Command("ffmpeg", args...)
-	cmd.Stdout = w
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stdout = &out
 	cmd.Stderr = os.Stderr // Optional debug
-
 	if err := cmd.Run(); err != nil {
-		// Log error to stderr (captured by Control Plane logs)
-		fmt.Fprintf(os.Stderr, "Snapshot failed for %s: %v\n", camID, err)
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
 
-		// FALLBACK: Serve static image if available (for testing offline cams)
-		fallbackData, err2 := os.ReadFile("fallback.jpg")
-		if err2 == nil {
-			fmt.Fprintf(os.Stderr, "Serving fallback.jpg for %s\n", camID)
-			w.Write(fallbackData)
-			return
+// negotiateSnapshotFormat picks the response content type. An explicit
+// ?format=png|jpeg wins; otherwise the first image/png or image/jpeg in Accept.
+// Default is JPEG. ok is false for an unknown format param.
+func negotiateSnapshotFormat(r *http.Request) (string, bool) {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "":
+	case "png":
+		return snapshotPNG, true
+	case "jpeg", "jpg":
+		return snapshotJPEG, true
+	default:
+		return "", false
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if mt == snapshotPNG || mt == snapshotJPEG {
+			return mt, true
 		}
+	}
+	return snapshotJPEG, true
+}
 
-		// Since we might have written partial response, we can't reliably send HTTP error now if headers sent.
-		// But usually ffmpeg fails fast.
-		return
+const (
+	snapshotJPEG = "image/jpeg"
+	snapshotPNG  = "image/png"
+)
+
+// encodeSnapshot re-encodes the captured JPEG frame when PNG is requested.
+// JPEG output is passed through untouched. The header is checked against
+// maxDim before decoding so an oversized frame cannot exhaust memory.
+func encodeSnapshot(frame []byte, format string, maxDim int) ([]byte, error) {
+	if format != snapshotPNG {
+		return frame, nil
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width > maxDim || cfg.Height > maxDim {
+		return nil, fmt.Errorf("%w: %dx%d (max %d)", ErrSnapshotTooLarge, cfg.Width, cfg.Height, maxDim)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return nil, err
 	}
+	return out.Bytes(), nil
 }
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/license"
+	"github.com/technosupport/ts-vms/internal/middleware"
 )
 
 type LicenseHandler struct {
@@ -23,6 +25,9 @@ type LicenseStatusResponse struct {
 	Features     []string              `json:"features"` // Names only
 	LastReload   time.Time             `json:"last_reload"`
 	ReasonCode   string                `json:"reason_code,omitempty"`
+	// FailOpen: the file is unreadable and the last-known-good license is
+	// still being enforced (license.failure_policy: fail_open)
+	FailOpen bool `json:"fail_open,omitempty"`
 }
 
 func (h *LicenseHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
@@ -34,6 +39,7 @@ func (h *LicenseHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 		ReasonCode:   state.ReasonCode,
 		LastReload:   state.LastReload,
 		DaysToExpiry: state.DaysToExpiry,
+		FailOpen:     h.Manager.EffectiveState().FailOpen,
 	}
 
 	if state.Payload != nil {
@@ -57,8 +63,14 @@ func (h *LicenseHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 
 func (h *LicenseHandler) Reload(w http.ResponseWriter, r *http.Request) {
 	// RBAC: license.manage (Handled by wrapper)
-	// Trigger Reload
-	h.Manager.Reload()
+	// Trigger Reload, audited as the caller
+	var actor *uuid.UUID
+	if ac, ok := middleware.GetAuthContext(r.Context()); ok {
+		if id, err := uuid.Parse(ac.UserID); err == nil {
+			actor = &id
+		}
+	}
+	h.Manager.Reload(actor)
 
 	// Return new status immediately
 	h.GetStatus(w, r)
@@ -2,10 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/live"
 	"github.com/technosupport/ts-vms/internal/middleware"
 )
@@ -68,21 +70,25 @@ func (h *LiveHandler) StartSession(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.Service.StartLiveSession(ctx, user, cameraID, req.ViewMode, req.Quality)
 	if err != nil {
-		// Check for Live Limit
-		if err.Error() == live.ErrLiveLimitExceeded ||
-			(len(err.Error()) > len(live.ErrLiveLimitExceeded) && err.Error()[:len(live.ErrLiveLimitExceeded)] == live.ErrLiveLimitExceeded) {
+		var limitErr *live.LiveLimitError
+		switch {
+		case errors.As(err, &limitErr):
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusTooManyRequests)
-			// Parse explicit values if formatted string
 			json.NewEncoder(w).Encode(map[string]interface{}{
-				"code":  live.ErrLiveLimitExceeded,
-				"error": err.Error(),
-				"limit": 16, // Contract
+				"reason_code": live.ErrLiveLimitExceeded,
+				"limit":       limitErr.Limit,
+				"active":      limitErr.Active,
 			})
-			return
+		case errors.Is(err, live.ErrCameraAccessDenied):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"reason_code": live.ReasonPermissionDenied,
+			})
+		default:
+			respondMappedError(w, r, err)
 		}
-
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -90,6 +96,28 @@ func (h *LiveHandler) StartSession(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// StopSession ends the caller's viewer session
+// POST /api/v1/live/{session_id}/stop
+func (h *LiveHandler) StopSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.Service.StopLiveSession(ctx, user, r.PathValue("session_id"))
+	if errors.Is(err, live.ErrSessionNotFound) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // RecordEvent handles client telemetry ingestion
 // POST /api/v1/live/events
 func (h *LiveHandler) RecordEvent(w http.ResponseWriter, r *http.Request) {
@@ -111,7 +139,7 @@ func (h *LiveHandler) RecordEvent(w http.ResponseWriter, r *http.Request) {
 func (h *LiveHandler) EnableOverlay(w http.ResponseWriter, r *http.Request) {
 	sessID := chi.URLParam(r, "session_id")
 	if err := h.Service.SetOverlayState(r.Context(), sessID, true); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -125,7 +153,7 @@ func (h *LiveHandler) EnableOverlay(w http.ResponseWriter, r *http.Request) {
 func (h *LiveHandler) DisableOverlay(w http.ResponseWriter, r *http.Request) {
 	sessID := chi.URLParam(r, "session_id")
 	if err := h.Service.SetOverlayState(r.Context(), sessID, false); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -193,6 +221,128 @@ func (h *LiveHandler) GetLatestDetection(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(payload)
 }
 
+// BatchDetectionsRequest is the POST /api/v1/live/detections/batch body.
+type BatchDetectionsRequest struct {
+	CameraIDs []string `json:"camera_ids"`
+	Stream    string   `json:"stream"`
+}
+
+// POST /api/v1/live/detections/batch
+// Returns {"detections": {camera_id: payload}} for a video wall in one call.
+// Cameras the user cannot access or without a fresh detection are omitted.
+func (h *LiveHandler) GetLatestDetectionsBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req BatchDetectionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(req.CameraIDs) == 0 {
+		http.Error(w, "camera_ids required", http.StatusBadRequest)
+		return
+	}
+	if len(req.CameraIDs) > live.MaxBatchDetections {
+		http.Error(w, "Too many camera_ids", http.StatusBadRequest)
+		return
+	}
+	if req.Stream == "" {
+		req.Stream = "basic"
+	}
+
+	if req.Stream == "weapon" && os.Getenv("WEAPON_AI_ENABLED") != "true" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"upgrade_required": true,
+			"feature":          "weapon_ai",
+		})
+		return
+	}
+
+	// Verify Access (RBAC) per camera; denied cameras are silently skipped
+	seen := make(map[string]bool, len(req.CameraIDs))
+	allowed := make([]string, 0, len(req.CameraIDs))
+	for _, id := range req.CameraIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := h.Service.CameraService.GetCamera(ctx, user.TenantID, id); err != nil {
+			continue
+		}
+		allowed = append(allowed, id)
+	}
+
+	h.Service.RefreshOverlayDemandMany(ctx, allowed)
+
+	detections, err := h.Service.GetLatestDetections(ctx, user.TenantID, allowed, req.Stream)
+	if err != nil {
+		http.Error(w, "Detections unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"detections": detections})
+}
+
+// GET /api/v1/live/{id}/detections/recent
+// Returns {"detections": [...]} for the camera's recent-detections window,
+// oldest first, for incident review. 404 when live.recent_detections is off.
+func (h *LiveHandler) GetRecentDetections(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := middleware.GetUserFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cameraID := r.PathValue("id")
+	if cameraID == "" {
+		http.Error(w, "Camera ID required", http.StatusBadRequest)
+		return
+	}
+	stream := r.URL.Query().Get("stream")
+	if stream == "" {
+		stream = "basic"
+	}
+	if stream == "weapon" && os.Getenv("WEAPON_AI_ENABLED") != "true" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"upgrade_required": true,
+			"feature":          "weapon_ai",
+		})
+		return
+	}
+
+	// Verify Access (RBAC); lookup failures other than not-found are not a 403
+	if _, err := h.Service.CameraService.GetCamera(ctx, user.TenantID, cameraID); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Camera access denied", http.StatusForbidden)
+		} else {
+			respondMappedError(w, r, err)
+		}
+		return
+	}
+
+	detections, err := h.Service.GetRecentDetections(ctx, user.TenantID, cameraID, stream)
+	if errors.Is(err, live.ErrRecentDetectionsDisabled) {
+		http.Error(w, "Recent detections not enabled", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Detections unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"detections": detections})
+}
+
 // GET /api/v1/cameras/{id}/snapshot
 // For Phase 3.8: Proxy to Media OR return Placeholder if not supported.
 // Prompt constraint: "don't force heavy decode... mock if strictly limited".
@@ -0,0 +1,209 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/technosupport/ts-vms/internal/api"
+	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/live"
+	"github.com/technosupport/ts-vms/internal/middleware"
+)
+
+func TestLiveHandler_DetectionsBatch(t *testing.T) {
+	mini := miniredis.RunT(t)
+	tenantID, otherTenant := uuid.New(), uuid.New()
+	camA, camB, camForeign := uuid.New(), uuid.New(), uuid.New()
+
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{
+		camA:       {ID: camA, TenantID: tenantID},
+		camB:       {ID: camB, TenantID: tenantID},
+		camForeign: {ID: camForeign, TenantID: otherTenant},
+	}}
+	svc := &live.Service{
+		Redis:         redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+		Detections:    live.NewMemoryDetectionStore(),
+		ObjectLimits:  live.DefaultObjectLimits,
+		CameraService: cameras.NewService(repo, &MockLicense{}, &MockAuditor{}),
+	}
+	ctx := context.Background()
+	save := func(tenant, cam uuid.UUID) {
+		err := svc.SaveDetection(ctx, tenant, &live.DetectionPayload{
+			CameraID: cam.String(),
+			Stream:   "basic",
+			TSUnixMS: time.Now().UnixMilli(),
+			Objects:  []live.Object{{Label: "person", Confidence: 0.9, BBox: live.BBox{X: 0.1, Y: 0.1, W: 0.2, H: 0.2}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	save(tenantID, camA)
+	save(tenantID, camB)
+	save(otherTenant, camForeign)
+
+	h := api.NewLiveHandler(svc, nil)
+	body := `{"camera_ids":["` + camA.String() + `","` + camB.String() + `","` + camForeign.String() + `"]}`
+	req := httptest.NewRequest("POST", "/api/v1/live/detections/batch", strings.NewReader(body))
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{
+		TenantID: tenantID.String(),
+		UserID:   uuid.New().String(),
+	}))
+	rr := httptest.NewRecorder()
+	h.GetLatestDetectionsBatch(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Detections map[string]live.DetectionPayload `json:"detections"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Detections) != 2 {
+		t.Fatalf("Expected 2 detections, got %d", len(resp.Detections))
+	}
+	for _, id := range []uuid.UUID{camA, camB} {
+		if d, ok := resp.Detections[id.String()]; !ok || d.CameraID != id.String() {
+			t.Errorf("Missing detection for %s", id)
+		}
+	}
+	if _, ok := resp.Detections[camForeign.String()]; ok {
+		t.Error("Detection for another tenant's camera must be skipped")
+	}
+
+	// Demand is refreshed for accessible cameras only
+	if members, _ := mini.ZMembers("overlay:demand"); len(members) != 2 {
+		t.Errorf("Expected overlay demand for 2 cameras, got %v", members)
+	}
+}
+
+func TestLiveHandler_DetectionsBatch_Validation(t *testing.T) {
+	h := api.NewLiveHandler(&live.Service{Detections: live.NewMemoryDetectionStore()}, nil)
+	ids := make([]string, live.MaxBatchDetections+1)
+	for i := range ids {
+		ids[i] = `"` + uuid.New().String() + `"`
+	}
+	cases := map[string]string{
+		"empty":    `{"camera_ids":[]}`,
+		"too many": `{"camera_ids":[` + strings.Join(ids, ",") + `]}`,
+		"bad json": `{`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/live/detections/batch", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			h.GetLatestDetectionsBatch(rr, withAuth(req))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400, got %d", rr.Code)
+			}
+		})
+	}
+}
+
+func startSessionRequest(tenantID, userID, cameraID uuid.UUID) *http.Request {
+	req := httptest.NewRequest("POST", "/api/v1/cameras/"+cameraID.String()+"/live/start", strings.NewReader(`{"quality":"sub"}`))
+	req.SetPathValue("id", cameraID.String())
+	return req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{
+		TenantID: tenantID.String(),
+		UserID:   userID.String(),
+	}))
+}
+
+func TestLiveHandler_StartSession_LimitExceededPayload(t *testing.T) {
+	mini := miniredis.RunT(t)
+	tenantID, userID, camID := uuid.New(), uuid.New(), uuid.New()
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{camID: {ID: camID, TenantID: tenantID}}}
+	rdb := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	svc := live.NewService(rdb, cameras.NewService(repo, &MockLicense{}, &MockAuditor{}), "http://localhost:8080", live.HLSParams{BaseURL: "http://localhost:8080"})
+
+	ctx := context.Background()
+	activeKey := "live:active:" + tenantID.String() + ":" + userID.String()
+	for i := 0; i < live.MaxActiveSessions; i++ {
+		sessID := uuid.New().String()
+		rdb.SAdd(ctx, activeKey, sessID)
+		rdb.Set(ctx, "live:sess:"+sessID, "{}", time.Minute)
+	}
+
+	h := api.NewLiveHandler(svc, nil)
+	rr := httptest.NewRecorder()
+	h.StartSession(rr, startSessionRequest(tenantID, userID, camID))
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		ReasonCode string `json:"reason_code"`
+		Limit      int    `json:"limit"`
+		Active     int    `json:"active"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReasonCode != live.ErrLiveLimitExceeded || resp.Limit != live.MaxActiveSessions || resp.Active != live.MaxActiveSessions {
+		t.Errorf("Unexpected payload: %+v", resp)
+	}
+}
+
+func TestLiveHandler_StartSession_AccessDenied(t *testing.T) {
+	mini := miniredis.RunT(t)
+	tenantID, camID := uuid.New(), uuid.New()
+	repo := &HMockRepo{cams: map[uuid.UUID]*data.Camera{camID: {ID: camID, TenantID: uuid.New()}}}
+	rdb := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	svc := live.NewService(rdb, cameras.NewService(repo, &MockLicense{}, &MockAuditor{}), "http://localhost:8080", live.HLSParams{BaseURL: "http://localhost:8080"})
+
+	h := api.NewLiveHandler(svc, nil)
+	rr := httptest.NewRecorder()
+	h.StartSession(rr, startSessionRequest(tenantID, uuid.New(), camID))
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		ReasonCode string `json:"reason_code"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.ReasonCode != string(live.ReasonPermissionDenied) {
+		t.Errorf("Expected PERMISSION_DENIED, got %q", resp.ReasonCode)
+	}
+}
+
+// lookupFailRepo fails camera lookups with a non-not-found error.
+type lookupFailRepo struct{ HMockRepo }
+
+func (m *lookupFailRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestLiveHandler_CameraLookupFailure_NotForbidden(t *testing.T) {
+	mini := miniredis.RunT(t)
+	tenantID, camID := uuid.New(), uuid.New()
+	rdb := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+	svc := live.NewService(rdb, cameras.NewService(&lookupFailRepo{}, &MockLicense{}, &MockAuditor{}), "http://localhost:8080", live.HLSParams{BaseURL: "http://localhost:8080"})
+	h := api.NewLiveHandler(svc, nil)
+
+	rr := httptest.NewRecorder()
+	h.StartSession(rr, startSessionRequest(tenantID, uuid.New(), camID))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("StartSession: expected 500, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	req := startSessionRequest(tenantID, uuid.New(), camID)
+	req.Method = http.MethodGet
+	rr = httptest.NewRecorder()
+	h.GetRecentDetections(rr, req)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("GetRecentDetections: expected 500, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
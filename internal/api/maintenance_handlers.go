@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/audit"
+	"github.com/technosupport/ts-vms/internal/maintenance"
+	"github.com/technosupport/ts-vms/internal/middleware"
+)
+
+// MaintenanceAuditor records maintenance toggles.
+type MaintenanceAuditor interface {
+	WriteEvent(ctx context.Context, evt audit.AuditEvent) error
+}
+
+// MaintenanceHandler toggles tenant maintenance mode, which pauses the NVR
+// monitor, NVR event poller and camera health scheduler and suppresses
+// camera alerts. System-wide maintenance is set in config (maintenance.global).
+type MaintenanceHandler struct {
+	Switch *maintenance.Switch
+	Audit  MaintenanceAuditor
+}
+
+type maintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// GET /api/v1/admin/maintenance
+func (h *MaintenanceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	_, tenantID, ok := maintenanceCaller(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, h.Switch.Status(tenantID))
+}
+
+// PUT /api/v1/admin/maintenance {"enabled": true|false} — caller's tenant
+// Audit: maintenance.tenant.set
+func (h *MaintenanceHandler) SetTenant(w http.ResponseWriter, r *http.Request) {
+	ac, tenantID, ok := maintenanceCaller(w, r)
+	if !ok {
+		return
+	}
+	var req maintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		respondError(w, http.StatusBadRequest, "enabled (bool) is required")
+		return
+	}
+
+	err := h.Switch.SetTenant(r.Context(), tenantID, *req.Enabled)
+	result := "success"
+	if err != nil {
+		result = "fail"
+	}
+	if h.Audit != nil {
+		var actor *uuid.UUID
+		if id, perr := uuid.Parse(ac.UserID); perr == nil {
+			actor = &id
+		}
+		meta, _ := json.Marshal(map[string]any{"enabled": *req.Enabled})
+		h.Audit.WriteEvent(r.Context(), audit.AuditEvent{
+			TenantID:    tenantID,
+			ActorUserID: actor,
+			EventID:     uuid.New(),
+			Action:      "maintenance.tenant.set",
+			Result:      result,
+			TargetID:    tenantID.String(),
+			TargetType:  "tenant",
+			CreatedAt:   time.Now(),
+			Metadata:    meta,
+		})
+	}
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, h.Switch.Status(tenantID))
+}
+
+func maintenanceCaller(w http.ResponseWriter, r *http.Request) (*middleware.AuthContext, uuid.UUID, bool) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return nil, uuid.Nil, false
+	}
+	tenantID, err := uuid.Parse(ac.TenantID)
+	if err != nil {
+		respondError(w, http.StatusForbidden, "Forbidden")
+		return nil, uuid.Nil, false
+	}
+	return ac, tenantID, true
+}
@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/api"
+	"github.com/technosupport/ts-vms/internal/audit"
+	"github.com/technosupport/ts-vms/internal/maintenance"
+	"github.com/technosupport/ts-vms/internal/middleware"
+)
+
+// recordingAuditor keeps written audit events
+type recordingAuditor struct{ events []audit.AuditEvent }
+
+func (a *recordingAuditor) WriteEvent(_ context.Context, evt audit.AuditEvent) error {
+	a.events = append(a.events, evt)
+	return nil
+}
+
+func TestMaintenanceHandler_SetTenant(t *testing.T) {
+	sw := maintenance.New()
+	auditor := &recordingAuditor{}
+	h := &api.MaintenanceHandler{Switch: sw, Audit: auditor}
+	tenantID, userID := uuid.New(), uuid.New()
+	as := func(req *http.Request) *http.Request {
+		ac := &middleware.AuthContext{TenantID: tenantID.String(), UserID: userID.String()}
+		return req.WithContext(middleware.WithAuthContext(req.Context(), ac))
+	}
+	put := func(body string) (int, maintenance.Status) {
+		rr := httptest.NewRecorder()
+		h.SetTenant(rr, as(httptest.NewRequest("PUT", "/api/v1/admin/maintenance", bytes.NewBufferString(body))))
+		var st maintenance.Status
+		json.NewDecoder(rr.Body).Decode(&st)
+		return rr.Code, st
+	}
+
+	if code, st := put(`{"enabled": true}`); code != http.StatusOK || !st.Active || !st.Tenant || st.Global {
+		t.Fatalf("Expected tenant maintenance on, got %d %+v", code, st)
+	}
+	if !sw.Active(tenantID) || sw.Active(uuid.New()) {
+		t.Error("Expected only the caller's tenant in maintenance")
+	}
+
+	if code, _ := put(`{}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without enabled, got %d", code)
+	}
+
+	if code, st := put(`{"enabled": false}`); code != http.StatusOK || st.Active {
+		t.Errorf("Expected maintenance cleared, got %d %+v", code, st)
+	}
+
+	if len(auditor.events) != 2 {
+		t.Fatalf("Expected one audit event per toggle, got %d", len(auditor.events))
+	}
+	evt := auditor.events[0]
+	if evt.Action != "maintenance.tenant.set" || evt.ActorUserID == nil || *evt.ActorUserID != userID || evt.TenantID != tenantID {
+		t.Errorf("Unexpected audit event %+v", evt)
+	}
+}
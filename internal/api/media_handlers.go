@@ -2,12 +2,15 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/middleware"
 )
 
@@ -44,7 +47,7 @@ func (h *MediaHandler) ListProfiles(w http.ResponseWriter, r *http.Request) {
 
 	profiles, err := h.Service.GetProfiles(r.Context(), cameraID)
 	if err != nil {
-		http.Error(w, "failed to get profiles", http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -72,8 +75,12 @@ func (h *MediaHandler) SelectProfiles(w http.ResponseWriter, r *http.Request) {
 	// Body optional (policy override), ignored for now as per plan
 
 	selection, err := h.Service.SelectMediaProfiles(r.Context(), tenantID, cameraID)
+	if errors.Is(err, cameras.ErrRTSPHostMismatch) {
+		http.Error(w, cameras.ErrRTSPHostMismatch.Error()+": camera stream URIs point at another host", http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
-		http.Error(w, "selection failed: "+err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -94,7 +101,7 @@ func (h *MediaHandler) GetSelection(w http.ResponseWriter, r *http.Request) {
 
 	sel, val, err := h.Service.GetSelection(r.Context(), cameraID)
 	if err != nil {
-		http.Error(w, "failed to get selection", http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	if sel == nil {
@@ -111,6 +118,52 @@ func (h *MediaHandler) GetSelection(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// GET /api/v1/cameras/{id}/validation-history
+func (h *MediaHandler) ValidationHistory(w http.ResponseWriter, r *http.Request) {
+	// RBAC: camera.media.read
+
+	cameraID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid camera id", http.StatusBadRequest)
+		return
+	}
+
+	tenantID, err := getTenantID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = v // Service caps at MaxValidationHistoryPage
+	}
+
+	history, err := h.Service.GetValidationHistory(r.Context(), tenantID, cameraID, limit)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		respondMappedError(w, r, err)
+		return
+	}
+	if history == nil {
+		history = []*data.RTSPValidationResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"camera_id": cameraID,
+		"history":   history,
+	})
+}
+
 // POST /api/v1/cameras/{id}:validate-rtsp
 func (h *MediaHandler) ValidateRTSP(w http.ResponseWriter, r *http.Request) {
 	// RBAC: camera.media.validate
@@ -129,7 +182,7 @@ func (h *MediaHandler) ValidateRTSP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.Service.ValidateRTSP(r.Context(), tenantID, cameraID); err != nil {
-		http.Error(w, "validation trigger failed: "+err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -22,7 +22,7 @@ func (h *NVRHandler) GetAdapterDeviceInfo(w http.ResponseWriter, r *http.Request
 
 	info, err := h.Service.GetAdapterDeviceInfo(r.Context(), nvrID, tid)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -42,7 +42,7 @@ func (h *NVRHandler) GetAdapterChannels(w http.ResponseWriter, r *http.Request)
 
 	channels, err := h.Service.GetAdapterChannels(r.Context(), nvrID, tid)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -78,7 +78,7 @@ func (h *NVRHandler) GetAdapterEvents(w http.ResponseWriter, r *http.Request) {
 
 	events, next, err := h.Service.GetAdapterEvents(r.Context(), nvrID, tid, since, limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
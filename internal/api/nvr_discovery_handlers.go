@@ -2,11 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/middleware"
+	"github.com/technosupport/ts-vms/internal/nvr"
 )
 
 // POST /api/v1/nvrs/{id}:test-connection
@@ -47,15 +49,17 @@ func (h *NVRHandler) DiscoverChannels(w http.ResponseWriter, r *http.Request) {
 	ac, _ := middleware.GetAuthContext(r.Context())
 	tid := uuid.MustParse(ac.TenantID)
 
-	count, err := h.Service.DiscoverChannels(r.Context(), nvrID, tid)
+	res, err := h.Service.DiscoverChannels(r.Context(), nvrID, tid)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(map[string]any{
-		"count":  count,
-		"status": "success",
+		"count":     res.Count,
+		"reported":  res.Reported,
+		"truncated": res.Truncated,
+		"status":    "success",
 	})
 }
 
@@ -88,22 +92,11 @@ func (h *NVRHandler) GetChannels(w http.ResponseWriter, r *http.Request) {
 		filter.IsEnabled = &b
 	}
 
-	// Pagination
-	limit := 50
-	offset := 0
-	// TODO: Parse limit/offset
+	limit, offset := ParsePagination(r, 50, 500)
 
-	// Wait, Service doesn't have ListChannels method exposed directly?
-	// I didn't add it to `Service` struct in `discovery.go`?
-	// I added `DiscoverChannels`. I might have missed `ListChannels` passthrough in `service.go` or `discovery.go`.
-	// Since `repo` has `ListChannels`, `Service` should likely expose it.
-	// I'll assume I need to add it to `Service`.
-	// For now, I'll call `h.Service.ListChannels` assuming I'll add it.
-
-	// Quick implementation in Service likely:
 	channels, total, err := h.Service.ListChannels(r.Context(), nvrID, tid, filter, limit, offset)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -114,6 +107,10 @@ func (h *NVRHandler) GetChannels(w http.ResponseWriter, r *http.Request) {
 }
 
 // POST /api/v1/nvrs/{id}:validate-channels
+// sync=true (query or body) returns per-channel results inline as
+// {channel_id: status} for up to nvr.SyncValidateMaxChannels channels; larger
+// or non-sync runs are queued (202).
+// An empty channel_ids list validates every channel on the NVR.
 func (h *NVRHandler) ValidateChannels(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	nvrID, err := uuid.Parse(id)
@@ -124,24 +121,27 @@ func (h *NVRHandler) ValidateChannels(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		ChannelIDs []uuid.UUID `json:"channel_ids"`
+		Sync       bool        `json:"sync"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
+	syncRequested := req.Sync || r.URL.Query().Get("sync") == "true"
 
 	ac, _ := middleware.GetAuthContext(r.Context())
 	tid := uuid.MustParse(ac.TenantID)
 
-	results, err := h.Service.ValidateChannels(r.Context(), nvrID, tid, req.ChannelIDs)
+	outcome, err := h.Service.RunChannelValidation(r.Context(), nvrID, tid, req.ChannelIDs, syncRequested)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]any{
-		"results": results,
-	})
+	if outcome.Mode == nvr.ValidationModeAsync {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(outcome)
 }
 
 // POST /api/v1/nvrs/{id}:provision-cameras
@@ -154,7 +154,9 @@ func (h *NVRHandler) ProvisionCameras(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		ChannelIDs []uuid.UUID `json:"channel_ids"`
+		ChannelIDs    []uuid.UUID `json:"channel_ids"`
+		RecordingMode string      `json:"recording_mode"` // vms|nvr|hybrid; empty uses the tenant default
+		AdoptExisting bool        `json:"adopt_existing"` // Link cameras matching a channel's source IP instead of creating
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid body", http.StatusBadRequest)
@@ -164,23 +166,31 @@ func (h *NVRHandler) ProvisionCameras(w http.ResponseWriter, r *http.Request) {
 	ac, _ := middleware.GetAuthContext(r.Context())
 	tid := uuid.MustParse(ac.TenantID)
 
-	count, err := h.Service.ProvisionCameras(r.Context(), nvrID, tid, req.ChannelIDs)
+	res, err := h.Service.ProvisionCameras(r.Context(), nvrID, tid, req.ChannelIDs, req.RecordingMode, req.AdoptExisting)
+	if errors.Is(err, nvr.ErrInvalidRecordingMode) {
+		http.Error(w, "invalid recording_mode (vms, nvr or hybrid)", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		// Handle partial failure or quota error
 		if err.Error() == "license_limit_exceeded" {
 			http.Error(w, "license quota exceeded", http.StatusForbidden) // 403 or 409?
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(map[string]any{
-		"provisioned_count": count,
+		"provisioned_count": res.Created + res.Adopted,
+		"created":           res.Created,
+		"adopted":           res.Adopted,
 	})
 }
 
 // POST /api/v1/nvrs/{id}/channels:bulk
+// action enable|disable takes channel_ids. action rename takes either
+// names ({channel_id: new_name}) or prefix applied to channel_ids.
 func (h *NVRHandler) BulkChannelOp(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	nvrID, err := uuid.Parse(id)
@@ -190,15 +200,17 @@ func (h *NVRHandler) BulkChannelOp(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		ChannelIDs []uuid.UUID `json:"channel_ids"`
-		Action     string      `json:"action"` // "enable", "disable"
+		ChannelIDs []uuid.UUID          `json:"channel_ids"`
+		Action     string               `json:"action"` // "enable", "disable", "rename"
+		Names      map[uuid.UUID]string `json:"names,omitempty"`
+		Prefix     string               `json:"prefix,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
 
-	if req.Action != "enable" && req.Action != "disable" {
+	if req.Action != "enable" && req.Action != "disable" && req.Action != "rename" {
 		http.Error(w, "invalid action", http.StatusBadRequest)
 		return
 	}
@@ -206,9 +218,29 @@ func (h *NVRHandler) BulkChannelOp(w http.ResponseWriter, r *http.Request) {
 	ac, _ := middleware.GetAuthContext(r.Context())
 	tid := uuid.MustParse(ac.TenantID)
 
-	err = h.Service.BulkChannelOp(r.Context(), nvrID, tid, req.ChannelIDs, req.Action)
+	if req.Action == "rename" {
+		switch {
+		case len(req.Names) > 0:
+			err = h.Service.BulkRenameChannels(r.Context(), nvrID, tid, req.Names)
+		case req.Prefix != "" && len(req.ChannelIDs) > 0:
+			err = h.Service.PrefixChannelNames(r.Context(), nvrID, tid, req.ChannelIDs, req.Prefix)
+		default:
+			http.Error(w, "rename requires names or prefix with channel_ids", http.StatusBadRequest)
+			return
+		}
+		switch {
+		case errors.Is(err, nvr.ErrInvalidChannelName):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, data.ErrRecordNotFound):
+			http.Error(w, "channel not found", http.StatusNotFound)
+			return
+		}
+	} else {
+		err = h.Service.BulkChannelOp(r.Context(), nvrID, tid, req.ChannelIDs, req.Action)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -22,10 +23,14 @@ func NewNVRHandler(service *nvr.Service) *NVRHandler {
 type CreateNVRRequest struct {
 	SiteID    string `json:"site_id"`
 	Name      string `json:"name"`
-	Vendor    string `json:"vendor"`
+	Vendor    string `json:"vendor"` // "auto" fingerprints the device
 	IPAddress string `json:"ip_address"`
 	Port      int    `json:"port"`
 	IsEnabled bool   `json:"is_enabled,omitempty"`
+
+	HealthCheckIntervalSeconds int    `json:"health_check_interval_seconds,omitempty"` // default 60
+	EventPollIntervalMs        *int   `json:"event_poll_interval_ms,omitempty"`        // default: events.nvr.poll_interval_ms
+	HealthProbeMode            string `json:"health_probe_mode,omitempty"`             // "rtsp" (default) or "snapshot"
 }
 
 type UpdateNVRRequest struct {
@@ -35,6 +40,10 @@ type UpdateNVRRequest struct {
 	Port      int    `json:"port,omitempty"`
 	IsEnabled *bool  `json:"is_enabled,omitempty"`
 	Status    string `json:"status,omitempty"` // Manual override
+
+	HealthCheckIntervalSeconds int    `json:"health_check_interval_seconds,omitempty"`
+	EventPollIntervalMs        *int   `json:"event_poll_interval_ms,omitempty"` // 0 clears the override
+	HealthProbeMode            string `json:"health_probe_mode,omitempty"`
 }
 
 type UpsertLinkRequest struct {
@@ -76,13 +85,17 @@ func (h *NVRHandler) Create(w http.ResponseWriter, r *http.Request) {
 		IPAddress: req.IPAddress,
 		Port:      req.Port,
 		IsEnabled: true, // default
+
+		HealthCheckIntervalSeconds: req.HealthCheckIntervalSeconds,
+		EventPollIntervalMs:        req.EventPollIntervalMs,
+		HealthProbeMode:            req.HealthProbeMode,
 	}
 	if req.Port == 0 {
 		n.Port = 80
 	}
 
 	if err := h.Service.CreateNVR(r.Context(), n); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -107,9 +120,10 @@ func (h *NVRHandler) List(w http.ResponseWriter, r *http.Request) {
 		filter.Status = &s
 	}
 
-	nvrs, total, err := h.Service.ListNVRs(r.Context(), uuid.MustParse(tid), filter, 50, 0)
+	limit, offset := ParsePagination(r, 50, 200)
+	nvrs, total, err := h.Service.ListNVRs(r.Context(), uuid.MustParse(tid), filter, limit, offset)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -144,8 +158,9 @@ func (h *NVRHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if req.Name != "" {
 		nvr.Name = req.Name
 	}
-	if req.Vendor != "" {
+	if req.Vendor != "" && req.Vendor != nvr.Vendor {
 		nvr.Vendor = req.Vendor
+		nvr.VendorConfidence = nil // Operator choice; "auto" re-detects
 	}
 	if req.IPAddress != "" {
 		nvr.IPAddress = req.IPAddress
@@ -159,9 +174,21 @@ func (h *NVRHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if req.Status != "" {
 		nvr.Status = req.Status
 	}
+	if req.HealthCheckIntervalSeconds != 0 {
+		nvr.HealthCheckIntervalSeconds = req.HealthCheckIntervalSeconds
+	}
+	if req.EventPollIntervalMs != nil {
+		nvr.EventPollIntervalMs = req.EventPollIntervalMs
+		if *req.EventPollIntervalMs == 0 {
+			nvr.EventPollIntervalMs = nil
+		}
+	}
+	if req.HealthProbeMode != "" {
+		nvr.HealthProbeMode = req.HealthProbeMode
+	}
 
 	if err := h.Service.UpdateNVR(r.Context(), nvr); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	json.NewEncoder(w).Encode(nvr)
@@ -173,7 +200,7 @@ func (h *NVRHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	tid := ac.TenantID
 
 	if err := h.Service.DeleteNVR(r.Context(), uuid.MustParse(id), uuid.MustParse(tid)); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -198,6 +225,12 @@ func (h *NVRHandler) UpsertLinks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	type linkResult struct {
+		CameraID uuid.UUID `json:"camera_id"`
+		Action   string    `json:"action"` // created, updated
+	}
+	results := make([]linkResult, 0, len(req.Links))
+
 	for _, l := range req.Links {
 		link := &data.NVRLink{
 			TenantID:      uuid.MustParse(tid),
@@ -210,21 +243,57 @@ func (h *NVRHandler) UpsertLinks(w http.ResponseWriter, r *http.Request) {
 			link.NVRChannelRef = &l.NVRChannelRef
 		}
 
-		if err := h.Service.UpsertLink(r.Context(), link); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		created, err := h.Service.UpsertLink(r.Context(), link)
+		if errors.Is(err, data.ErrChannelLinked) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			respondMappedError(w, r, err)
 			return // Partial failure stops? Or should we try all?
 			// Ideally bulk operation should be all or nothing or return errors.
 			// Currently returning 500 on first error.
 		}
+		action := "updated"
+		if created {
+			action = "created"
+		}
+		results = append(results, linkResult{CameraID: link.CameraID, Action: action})
 	}
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"links": results})
 }
 
+// GET /api/v1/nvrs/{id}/cameras[?expand=camera&order=created_at|camera_name|channel_ref]
+// Without expand the bare link array is returned; with expand=camera each
+// link carries its camera's details and the response is {"data", "total"}.
 func (h *NVRHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	links, err := h.Service.ListLinks(r.Context(), uuid.MustParse(id), 50, 0)
+	nvrID, err := uuid.Parse(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "invalid nvr id", http.StatusBadRequest)
+		return
+	}
+	limit, offset := ParsePagination(r, 50, 200)
+	ac, _ := middleware.GetAuthContext(r.Context())
+	tid := uuid.MustParse(ac.TenantID)
+
+	if r.URL.Query().Get("expand") == "camera" {
+		links, total, err := h.Service.ListLinksWithCameras(r.Context(), nvrID, tid, r.URL.Query().Get("order"), limit, offset)
+		if err != nil {
+			respondMappedError(w, r, err)
+			return
+		}
+		if links == nil {
+			links = []*data.NVRLinkWithCamera{}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": links, "total": total})
+		return
+	}
+
+	links, err := h.Service.ListLinks(r.Context(), nvrID, tid, limit, offset)
+	if err != nil {
+		respondMappedError(w, r, err)
 		return
 	}
 	json.NewEncoder(w).Encode(links)
@@ -242,7 +311,7 @@ func (h *NVRHandler) Unlink(w http.ResponseWriter, r *http.Request) {
 
 	for _, camID := range req.CameraIDs {
 		if err := h.Service.UnlinkCamera(r.Context(), uuid.MustParse(tid), uuid.MustParse(camID)); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			respondMappedError(w, r, err)
 			return
 		}
 	}
@@ -264,7 +333,7 @@ func (h *NVRHandler) SetCredentials(w http.ResponseWriter, r *http.Request) {
 
 	err := h.Service.SetCredentials(r.Context(), uuid.MustParse(id), uuid.MustParse(tid), req.Username, req.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -288,13 +357,94 @@ func (h *NVRHandler) GetCredentials(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"username": u, "password": p})
 }
 
+// GET /api/v1/nvrs/credentials/health
+func (h *NVRHandler) CredentialHealth(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	results, err := h.Service.CredentialHealth(r.Context(), uuid.MustParse(ac.TenantID))
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+	summary := map[string]int{nvr.CredentialOK: 0, nvr.CredentialUndecryptable: 0, nvr.CredentialMissing: 0}
+	for _, res := range results {
+		summary[res.Status]++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"nvrs": results, "summary": summary})
+}
+
+// MigrateCredentialAAD re-binds the tenant's NVR credentials to the
+// configured AAD version.
+func (h *NVRHandler) MigrateCredentialAAD(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	res, err := h.Service.MigrateCredentialAAD(r.Context(), uuid.MustParse(ac.TenantID))
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// DefaultRecordingModeRequest is the body of PUT /api/v1/nvrs/default-recording-mode.
+type DefaultRecordingModeRequest struct {
+	RecordingMode string `json:"recording_mode"` // vms, nvr or hybrid
+}
+
+// GetDefaultRecordingMode returns the mode provisioned links get when the
+// request names none.
+func (h *NVRHandler) GetDefaultRecordingMode(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mode := h.Service.DefaultRecordingMode(r.Context(), uuid.MustParse(ac.TenantID))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DefaultRecordingModeRequest{RecordingMode: mode})
+}
+
+// SetDefaultRecordingMode changes the tenant's default recording mode.
+func (h *NVRHandler) SetDefaultRecordingMode(w http.ResponseWriter, r *http.Request) {
+	ac, ok := middleware.GetAuthContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DefaultRecordingModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SetDefaultRecordingMode(r.Context(), uuid.MustParse(ac.TenantID), req.RecordingMode); err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(req)
+}
+
 func (h *NVRHandler) DeleteCredentials(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	ac, _ := middleware.GetAuthContext(r.Context())
 	tid := ac.TenantID
 
 	if err := h.Service.DeleteCredentials(r.Context(), uuid.MustParse(id), uuid.MustParse(tid)); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
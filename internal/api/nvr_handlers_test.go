@@ -0,0 +1,157 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/api"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/middleware"
+	"github.com/technosupport/ts-vms/internal/nvr"
+)
+
+func TestNVRListLinks_ExpandCamera(t *testing.T) {
+	var queries []string
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(func(_, actual string) error {
+		queries = append(queries, actual)
+		return nil
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	nvrID, tenantID, camID := uuid.New(), uuid.New(), uuid.New()
+	expectNVR(mock, nvrID, tenantID)
+	mock.ExpectQuery("count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("select").WithArgs(nvrID, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "camera_id", "nvr_id", "nvr_channel_ref", "recording_mode", "is_enabled", "created_at", "updated_at", "name", "host", "is_enabled"}).
+			AddRow(uuid.New(), tenantID, camID, nvrID, "101", "nvr", true, time.Now(), time.Now(), "Loading Dock", "10.1.2.3", true))
+
+	h := api.NewNVRHandler(nvr.NewService(&data.NVRModel{DB: db}, nil, nil, nil))
+	req := withTenant(httptest.NewRequest(http.MethodGet, "/api/v1/nvrs/"+nvrID.String()+"/cameras?expand=camera&limit=20&order=camera_name", nil), tenantID)
+	req.SetPathValue("id", nvrID.String())
+	rr := httptest.NewRecorder()
+	h.ListLinks(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []struct {
+			CameraID uuid.UUID `json:"camera_id"`
+			Camera   struct {
+				Name      string `json:"name"`
+				IPAddress string `json:"ip_address"`
+				IsEnabled bool   `json:"is_enabled"`
+			} `json:"camera"`
+		} `json:"data"`
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || len(resp.Data) != 1 {
+		t.Fatalf("Expected one link, got %s", rr.Body.String())
+	}
+	if c := resp.Data[0].Camera; resp.Data[0].CameraID != camID || c.Name != "Loading Dock" || c.IPAddress != "10.1.2.3" || !c.IsEnabled {
+		t.Errorf("Camera details missing: %s", rr.Body.String())
+	}
+	for _, q := range queries {
+		if strings.Contains(q, "camera_nvr_links") && !strings.Contains(q, "c.deleted_at IS NULL") {
+			t.Errorf("Deleted cameras must be excluded: %s", q)
+		}
+	}
+
+	// Unknown ordering is rejected
+	expectNVR(mock, nvrID, tenantID)
+	req = withTenant(httptest.NewRequest(http.MethodGet, "/api/v1/nvrs/"+nvrID.String()+"/cameras?expand=camera&order=ip", nil), tenantID)
+	req.SetPathValue("id", nvrID.String())
+	rr = httptest.NewRecorder()
+	h.ListLinks(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown order, got %d", rr.Code)
+	}
+}
+
+// expectNVR queues the GetByID lookup of an NVR owned by tenantID.
+func expectNVR(mock sqlmock.Sqlmock, nvrID, tenantID uuid.UUID) {
+	mock.ExpectQuery("select").WithArgs(nvrID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "site_id", "name", "vendor", "ip_address", "port", "is_enabled", "status", "last_status_at", "created_at", "updated_at", "health_check_interval_seconds", "event_poll_interval_ms", "health_probe_mode", "vendor_confidence"}).
+			AddRow(nvrID, tenantID, uuid.New(), "NVR", "hikvision", "10.0.0.1", 80, true, "online", nil, time.Now(), time.Now(), 60, 5000, "rtsp", nil))
+}
+
+func withTenant(req *http.Request, tenantID uuid.UUID) *http.Request {
+	ac := &middleware.AuthContext{TenantID: tenantID.String(), UserID: uuid.New().String()}
+	return req.WithContext(middleware.WithAuthContext(req.Context(), ac))
+}
+
+func TestNVRListLinks_OtherTenantNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(func(_, _ string) error { return nil })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	nvrID := uuid.New()
+	h := api.NewNVRHandler(nvr.NewService(&data.NVRModel{DB: db}, nil, nil, nil))
+	for _, path := range []string{"/cameras", "/cameras?expand=camera"} {
+		expectNVR(mock, nvrID, uuid.New())
+		req := withTenant(httptest.NewRequest(http.MethodGet, "/api/v1/nvrs/"+nvrID.String()+path, nil), uuid.New())
+		req.SetPathValue("id", nvrID.String())
+		rr := httptest.NewRecorder()
+		h.ListLinks(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 for another tenant's NVR, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Links must not be queried: %v", err)
+	}
+}
+
+func TestNVRSetDefaultRecordingMode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tenantID := uuid.New()
+	h := api.NewNVRHandler(nvr.NewService(&data.NVRModel{DB: db}, nil, nil, nil))
+
+	// Unknown modes never reach the database
+	req := withTenant(httptest.NewRequest(http.MethodPut, "/api/v1/nvrs/default-recording-mode", strings.NewReader(`{"recording_mode":"cloud"}`)), tenantID)
+	rr := httptest.NewRecorder()
+	h.SetDefaultRecordingMode(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for unknown mode, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	mock.ExpectExec("UPDATE tenants SET default_recording_mode").WithArgs("hybrid", tenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	req = withTenant(httptest.NewRequest(http.MethodPut, "/api/v1/nvrs/default-recording-mode", strings.NewReader(`{"recording_mode":"hybrid"}`)), tenantID)
+	rr = httptest.NewRecorder()
+	h.SetDefaultRecordingMode(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	mock.ExpectQuery("SELECT default_recording_mode FROM tenants").WithArgs(tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"default_recording_mode"}).AddRow("hybrid"))
+	req = withTenant(httptest.NewRequest(http.MethodGet, "/api/v1/nvrs/default-recording-mode", nil), tenantID)
+	rr = httptest.NewRecorder()
+	h.GetDefaultRecordingMode(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"recording_mode":"hybrid"`) {
+		t.Errorf("Expected hybrid, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
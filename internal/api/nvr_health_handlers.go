@@ -30,9 +30,9 @@ func (h *NVRHandler) GetNVRHealthSummary(w http.ResponseWriter, r *http.Request)
 	// We need `ac.SiteIDs`.
 	// Let's pass nil if all allowed.
 
-	summary, err := h.Service.GetRepo().GetNVRHealthSummary(r.Context(), tid, nil) // Fix: Add site logic if available
+	summary, err := h.Service.GetNVRHealthSummary(r.Context(), tid, nil) // Fix: Add site logic if available
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -58,7 +58,7 @@ func (h *NVRHandler) GetNVRChannelHealth(w http.ResponseWriter, r *http.Request)
 	// Get Raw Channel Health
 	healthRows, err := h.Service.GetRepo().ListChannelHealth(r.Context(), nvrID, 500, 0)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
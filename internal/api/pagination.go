@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ParsePagination reads limit/offset query params.
+// Missing, invalid or non-positive limits fall back to defaultLimit; limits
+// above maxLimit are clamped to maxLimit. Negative or invalid offsets are 0.
+func ParsePagination(r *http.Request, defaultLimit, maxLimit int) (limit, offset int) {
+	limit = defaultLimit
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	if v, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	return limit, offset
+}
@@ -0,0 +1,55 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/technosupport/ts-vms/internal/api"
+	"github.com/technosupport/ts-vms/internal/cameras"
+)
+
+func TestParsePagination(t *testing.T) {
+	cases := []struct {
+		query      string
+		wantLimit  int
+		wantOffset int
+	}{
+		{"", 50, 0},
+		{"?limit=50", 50, 0},
+		{"?limit=10&offset=20", 10, 20},
+		{"?limit=500", 100, 0},
+		{"?limit=-5&offset=-1", 50, 0},
+		{"?limit=0", 50, 0},
+		{"?limit=abc&offset=xyz", 50, 0},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", "/api/v1/cameras"+tc.query, nil)
+		limit, offset := api.ParsePagination(r, 50, 100)
+		if limit != tc.wantLimit || offset != tc.wantOffset {
+			t.Errorf("%q: got limit=%d offset=%d, want limit=%d offset=%d",
+				tc.query, limit, offset, tc.wantLimit, tc.wantOffset)
+		}
+	}
+}
+
+func TestHandler_ListCameras_LimitCap(t *testing.T) {
+	svc := cameras.NewService(&HMockRepo{}, &MockLicense{}, &MockAuditor{})
+	h := api.NewCameraHandler(svc)
+
+	for query, want := range map[string]int{"?limit=50": 50, "?limit=500": 50, "?limit=-1": 50, "?limit=20": 20} {
+		req := withAuth(httptest.NewRequest("GET", "/api/v1/cameras"+query, nil))
+		rr := httptest.NewRecorder()
+		h.List(rr, req)
+
+		var resp struct {
+			Meta map[string]int `json:"meta"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Meta["limit"] != want {
+			t.Errorf("%s: expected limit %d, got %d", query, want, resp.Meta["limit"])
+		}
+	}
+}
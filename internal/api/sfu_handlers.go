@@ -61,12 +61,14 @@ func (h *SfuHandler) writeStructuredError(w http.ResponseWriter, r *http.Request
 			statusCode = http.StatusUnauthorized
 		case "ERR_RBAC_DENIED", "ERR_FORBIDDEN":
 			statusCode = http.StatusForbidden
-		case "ERR_ROOM_FULL":
+		case "ERR_ROOM_FULL", "ERR_TENANT_ROOM_LIMIT":
 			statusCode = http.StatusTooManyRequests
 		case "ERR_CAMERA_NOT_FOUND":
 			statusCode = http.StatusNotFound
 		case "ERR_BAD_REQUEST":
 			statusCode = http.StatusBadRequest
+		case "ERR_RTSP_HOST_MISMATCH":
+			statusCode = http.StatusUnprocessableEntity
 		default:
 			statusCode = http.StatusInternalServerError
 		}
@@ -3,6 +3,8 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/data"
@@ -38,6 +40,72 @@ type ResetPasswordRequest struct {
 	NewPassword string `json:"new_password"`
 }
 
+// UserListItem is the list view of a user; it never carries the password hash.
+type UserListItem struct {
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email"`
+	DisplayName string    `json:"display_name"`
+	IsDisabled  bool      `json:"is_disabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ListUsers GET /api/v1/users?email=&is_disabled=&role_id=&limit=&offset=
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	// RBAC: user.read (handled by wrapper)
+	ac, _ := middleware.GetAuthContext(r.Context())
+	tID, err := uuid.Parse(ac.TenantID)
+	if err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := data.UserFilter{Email: q.Get("email")}
+	if v := q.Get("is_disabled"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "invalid_is_disabled", http.StatusBadRequest)
+			return
+		}
+		filter.IsDisabled = &b
+	}
+	if v := q.Get("role_id"); v != "" {
+		rid, err := uuid.Parse(v)
+		if err != nil {
+			http.Error(w, "invalid_role_id", http.StatusBadRequest)
+			return
+		}
+		filter.RoleID = &rid
+	}
+
+	limit, offset := ParsePagination(r, 50, 200)
+
+	list, total, err := h.Service.Repo.ListFiltered(r.Context(), tID, filter, limit, offset)
+	if err != nil {
+		respondMappedError(w, r, err)
+		return
+	}
+
+	items := make([]UserListItem, 0, len(list))
+	for _, u := range list {
+		items = append(items, UserListItem{
+			ID: u.ID, Email: u.Email, DisplayName: u.DisplayName,
+			IsDisabled: u.IsDisabled, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": items,
+		"meta": map[string]int{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
 // CreateUser POST /api/v1/users
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	// RBAC: user.create (handled by wrapper)
@@ -65,7 +133,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.Service.CreateUser(r.Context(), user, req.Password, actorID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -143,7 +211,7 @@ func (h *UserHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.Service.DisableUser(r.Context(), userID, acTenantID, acUserID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -170,7 +238,7 @@ func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 
 	token, err := h.Service.InitiateReset(r.Context(), userID, acTenantID, acUserID) // actorID = acUserID
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
@@ -239,7 +307,7 @@ func (h *UserHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
 	// We should verify ownership. Skipped for brevity/focus on User logic but documented as "Must Verify".
 
 	if err := h.Service.Repo.AssignRole(r.Context(), userID, req.RoleID, req.ScopeID, req.ScopeType); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMappedError(w, r, err)
 		return
 	}
 
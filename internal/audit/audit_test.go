@@ -1,17 +1,20 @@
 package audit_test
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/technosupport/ts-vms/internal/api"
 	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/middleware"
@@ -153,6 +156,33 @@ func TestRetentionGuard(t *testing.T) {
 	}
 }
 
+// System actor is stable, overridable and carried by SystemContext
+func TestSystemActorID(t *testing.T) {
+	if got := audit.SystemActorID().String(); got != "00000000-0000-0000-0000-00000000005e" {
+		t.Fatalf("Default system actor changed: %s", got)
+	}
+	if audit.IsSystemActor(nil) || !audit.IsSystemActor(audit.SystemActor()) {
+		t.Error("IsSystemActor mismatch")
+	}
+
+	custom := uuid.New()
+	audit.SetSystemActorID(custom)
+	defer audit.SetSystemActorID(uuid.Nil)
+	if audit.SystemActorID() != custom {
+		t.Errorf("Expected configured actor %s, got %s", custom, audit.SystemActorID())
+	}
+
+	ac, ok := middleware.GetAuthContext(middleware.SystemContext(context.Background(), uuid.Nil))
+	if !ok || ac.UserID != custom.String() || ac.TenantID != "" {
+		t.Errorf("SystemContext should carry the system actor and no tenant, got %+v", ac)
+	}
+
+	audit.SetSystemActorID(uuid.Nil)
+	if audit.SystemActorID() != audit.DefaultSystemActorID {
+		t.Error("uuid.Nil should restore the default system actor")
+	}
+}
+
 // 7. API Query
 func TestAuditAPI_Query(t *testing.T) {
 	db, mock, _ := sqlmock.New()
@@ -353,3 +383,461 @@ func TestSpool_Full_Rotation(t *testing.T) {
 		// Might fail if we messed up config in previous test
 	}
 }
+
+func buildChain(tenantID uuid.UUID, n int) []audit.AuditEvent {
+	var events []audit.AuditEvent
+	prev := ""
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= n; i++ {
+		evt := audit.AuditEvent{
+			EventID:   uuid.New(),
+			TenantID:  tenantID,
+			Action:    "camera.update",
+			Result:    "success",
+			Metadata:  json.RawMessage(`{"b":2,"a":1}`),
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+			ChainSeq:  int64(i),
+			PrevHash:  prev,
+		}
+		evt.Hash = audit.ComputeHash(prev, evt)
+		prev = evt.Hash
+		events = append(events, evt)
+	}
+	return events
+}
+
+// 22. Hash chain: untouched chain verifies
+func TestHashChain_ValidChainVerifies(t *testing.T) {
+	tid := uuid.New()
+	res := audit.VerifyEvents(tid, buildChain(tid, 5))
+	if !res.Valid || res.Checked != 5 {
+		t.Errorf("Expected valid chain of 5, got %+v", res)
+	}
+}
+
+// 23. Hash chain: modified / deleted rows break at the right position
+func TestHashChain_TamperingBreaksAtPosition(t *testing.T) {
+	tid := uuid.New()
+
+	events := buildChain(tid, 5)
+	events[2].Action = "camera.delete" // seq 3 rewritten
+	res := audit.VerifyEvents(tid, events)
+	if res.Valid || res.BrokenSeq != 3 || res.Reason != "hash_mismatch" || res.Checked != 2 {
+		t.Errorf("Expected break at seq 3 (hash_mismatch), got %+v", res)
+	}
+	if res.BrokenEventID == nil || *res.BrokenEventID != events[2].EventID {
+		t.Error("Broken event ID mismatch")
+	}
+
+	events = buildChain(tid, 5)
+	events = append(events[:1], events[2:]...) // seq 2 deleted
+	res = audit.VerifyEvents(tid, events)
+	if res.Valid || res.BrokenSeq != 3 || res.Reason != "sequence_gap" {
+		t.Errorf("Expected gap at seq 3, got %+v", res)
+	}
+}
+
+// 24. Hash chain: DB walk tolerates JSONB re-encoding and reports tampering
+func TestVerifyChain_DB(t *testing.T) {
+	tid := uuid.New()
+	events := buildChain(tid, 3)
+
+	rowsFor := func(evts []audit.AuditEvent) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"event_id", "tenant_id", "actor_user_id", "action", "target_type", "target_id",
+			"result", "reason_code", "request_id", "client_ip", "user_agent", "metadata", "created_at",
+			"chain_seq", "prev_hash", "hash"})
+		for _, e := range evts {
+			rows.AddRow(e.EventID, e.TenantID, nil, e.Action, "", "", e.Result, "", "", "", "",
+				[]byte(`{"a": 1, "b": 2}`), e.CreatedAt, e.ChainSeq, e.PrevHash, e.Hash)
+		}
+		return rows
+	}
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	s := audit.NewService(db)
+
+	mock.ExpectQuery("SELECT event_id").WithArgs(tid, int64(0), audit.MaxVerifyBatch).WillReturnRows(rowsFor(events))
+	res, err := s.VerifyChain(context.Background(), tid, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.Checked != 3 {
+		t.Errorf("Expected valid chain, got %+v", res)
+	}
+
+	// A batch of 2 reports where to resume; the next batch links to it.
+	mock.ExpectQuery("SELECT event_id").WithArgs(tid, int64(0), 2).WillReturnRows(rowsFor(events[:2]))
+	res, err = s.VerifyChain(context.Background(), tid, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.Checked != 2 || res.NextAfterSeq != 2 {
+		t.Errorf("Expected a valid first batch resuming after seq 2, got %+v", res)
+	}
+	mock.ExpectQuery("SELECT hash").WithArgs(tid, int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(events[1].Hash))
+	mock.ExpectQuery("SELECT event_id").WithArgs(tid, int64(2), 2).WillReturnRows(rowsFor(events[2:]))
+	res, err = s.VerifyChain(context.Background(), tid, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.Checked != 1 || res.NextAfterSeq != 0 {
+		t.Errorf("Expected the last batch to finish the chain, got %+v", res)
+	}
+
+	events[1].Result = "failure"
+	mock.ExpectQuery("SELECT event_id").WithArgs(tid, int64(0), audit.MaxVerifyBatch).WillReturnRows(rowsFor(events))
+	res, err = s.VerifyChain(context.Background(), tid, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid || res.BrokenSeq != 2 {
+		t.Errorf("Expected break at seq 2, got %+v", res)
+	}
+}
+
+// 25. Hash chain: WriteEvent links to the tenant's previous hash
+func TestWriteEvent_HashChainLinksPrevious(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	tempDir, _ := os.MkdirTemp("", "audit_chain")
+	defer os.RemoveAll(tempDir)
+	audit.ConfigureFailover(tempDir, 100)
+
+	s := audit.NewService(db)
+	s.HashChain = true
+
+	tid := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec("pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT chain_seq, hash").WithArgs(tid).
+		WillReturnRows(sqlmock.NewRows([]string{"chain_seq", "hash"}).AddRow(7, "prevhash"))
+	mock.ExpectExec("INSERT INTO audit_logs").
+		WithArgs(sqlmock.AnyArg(), tid, sqlmock.AnyArg(), "camera.update", sqlmock.AnyArg(), sqlmock.AnyArg(),
+			"success", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			int64(8), "prevhash", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	evt := audit.AuditEvent{TenantID: tid, Action: "camera.update", Result: "success"}
+	if err := s.WriteEvent(context.Background(), evt); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// 26. Replay keeps spool order when the DB fails mid-way
+func TestReplay_PreservesOrderOnFailure(t *testing.T) {
+	tempDir, _ := os.MkdirTemp("", "replay_order")
+	defer os.RemoveAll(tempDir)
+	audit.ConfigureFailover(tempDir, 100)
+
+	tid := uuid.New()
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		evt := audit.AuditEvent{EventID: uuid.New(), TenantID: tid, Action: "replay.order"}
+		ids = append(ids, evt.EventID)
+		audit.SpoolEvent(evt)
+	}
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	s := audit.NewService(db)
+
+	mock.ExpectExec("INSERT INTO audit_logs").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO audit_logs").WillReturnError(sql.ErrConnDone)
+
+	s.ReplaySpool(context.Background())
+
+	// Spooled while the replay was failing must come after the re-queued events
+	s.HashChain = true
+	late := audit.AuditEvent{EventID: uuid.New(), TenantID: tid, Action: "replay.late"}
+	if err := s.WriteEvent(context.Background(), late); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(tempDir, "audit_spool.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []uuid.UUID
+	for _, line := range bytes.Split(bytes.TrimSpace(raw), []byte("\n")) {
+		var fe audit.FailoverEvent
+		json.Unmarshal(line, &fe)
+		got = append(got, fe.Payload.EventID)
+	}
+	want := []uuid.UUID{ids[1], ids[2], late.EventID}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d spooled events, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Spool position %d: got %s want %s", i, got[i], want[i])
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// 27. Replay dead-letters events the DB rejects for good and carries on
+func TestReplay_DeadLettersPermanentFailures(t *testing.T) {
+	tempDir, _ := os.MkdirTemp("", "replay_deadletter")
+	defer os.RemoveAll(tempDir)
+	audit.ConfigureFailover(tempDir, 100)
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		evt := audit.AuditEvent{EventID: uuid.New(), TenantID: uuid.New(), Action: "replay.deadletter"}
+		ids = append(ids, evt.EventID)
+		audit.SpoolEvent(evt)
+	}
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	s := audit.NewService(db)
+
+	mock.ExpectExec("INSERT INTO audit_logs").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO audit_logs").WillReturnError(&pq.Error{Code: "23503"}) // unknown tenant
+	mock.ExpectExec("INSERT INTO audit_logs").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	s.ReplaySpool(context.Background())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Expected the event after the rejected one to be replayed: %v", err)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(tempDir, "audit_spool.log")); len(bytes.TrimSpace(raw)) != 0 {
+		t.Errorf("Expected an empty spool, got %q", raw)
+	}
+	raw, err := os.ReadFile(filepath.Join(tempDir, "audit_deadletter.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Split(bytes.TrimSpace(raw), []byte("\n")); len(lines) != 1 || !bytes.Contains(lines[0], []byte(ids[1].String())) {
+		t.Errorf("Expected only the rejected event dead-lettered, got %s", raw)
+	}
+}
+
+func exportRequest(tenantID uuid.UUID, query string) *http.Request {
+	req := httptest.NewRequest("POST", "/api/v1/audit/exports?"+query, nil)
+	ctx := middleware.WithAuthContext(req.Context(), &middleware.AuthContext{TenantID: tenantID.String()})
+	return req.WithContext(ctx)
+}
+
+func exportRows(n int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "event_id", "tenant_id", "actor_user_id", "action", "result", "created_at", "metadata"})
+	for i := 0; i < n; i++ {
+		rows.AddRow(uuid.New(), uuid.New(), uuid.New(), nil, "act", "success", time.Now(), []byte("{}"))
+	}
+	return rows
+}
+
+func TestAuditAPI_Export_RangeTooWide(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	s := audit.NewService(db)
+	h := &api.AuditHandler{Service: s}
+
+	w := httptest.NewRecorder()
+	h.ExportEvents(w, exportRequest(uuid.New(), "from=2024-01-01T00:00:00Z&to=2024-03-01T00:00:00Z"))
+
+	if w.Code != 400 {
+		t.Fatalf("Expected 400, got %d", w.Code)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte("async=true")) {
+		t.Errorf("Error should point at async export: %q", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAuditAPI_Export_WithinLimitsStreams(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	s := audit.NewService(db)
+	s.ExportLimits = audit.ExportLimits{MaxRows: 3}
+	h := &api.AuditHandler{Service: s}
+
+	mock.ExpectQuery("SELECT id, event_id").WillReturnRows(exportRows(3))
+
+	w := httptest.NewRecorder()
+	h.ExportEvents(w, exportRequest(uuid.New(), "from=2024-01-01T00:00:00Z&to=2024-01-02T00:00:00Z"))
+
+	if w.Code != 200 {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if n := bytes.Count(w.Body.Bytes(), []byte("\n")); n != 3 {
+		t.Errorf("Expected 3 JSONL rows, got %d", n)
+	}
+}
+
+func TestAuditAPI_Export_TooManyRows(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	s := audit.NewService(db)
+	s.ExportLimits = audit.ExportLimits{MaxRows: 2}
+	h := &api.AuditHandler{Service: s}
+
+	// Limit is MaxRows+1 so the overflow is detectable
+	mock.ExpectQuery("SELECT id, event_id").WillReturnRows(exportRows(3))
+
+	w := httptest.NewRecorder()
+	h.ExportEvents(w, exportRequest(uuid.New(), ""))
+
+	if w.Code != 400 {
+		t.Fatalf("Expected 400, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") == "application/x-jsonl" {
+		t.Error("Rejected export must not start streaming")
+	}
+}
+
+func TestExportLimits_TenantOverride(t *testing.T) {
+	tenant := uuid.New()
+	s := audit.NewService(nil)
+	s.ExportLimits = audit.ExportLimits{MaxRows: 500}
+	s.TenantExportLimits = map[uuid.UUID]audit.ExportLimits{tenant: {MaxRange: time.Hour}}
+
+	l := s.ExportLimitsFor(tenant)
+	if l.MaxRows != 500 || l.MaxRange != time.Hour || l.AsyncMaxRows != audit.DefaultExportLimits().AsyncMaxRows {
+		t.Errorf("Unexpected merged limits: %+v", l)
+	}
+	if other := s.ExportLimitsFor(uuid.New()); other.MaxRange != audit.DefaultExportLimits().MaxRange {
+		t.Errorf("Override leaked to other tenant: %+v", other)
+	}
+}
+
+func TestAuditAPI_AsyncExportJob(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	s := audit.NewService(db)
+	tenant := uuid.New()
+	h := &api.AuditHandler{Service: s, Exports: audit.NewExportJobs(s, t.TempDir())}
+
+	mock.ExpectQuery("SELECT id, event_id").WillReturnRows(exportRows(5))
+
+	w := httptest.NewRecorder()
+	h.ExportEvents(w, exportRequest(tenant, "async=true"))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", w.Code)
+	}
+	var started struct {
+		JobID uuid.UUID `json:"job_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &started)
+
+	var job *audit.ExportJob
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var err error
+		job, err = h.Exports.Get(tenant, started.JobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != audit.ExportRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Status != audit.ExportCompleted || job.Rows != 5 {
+		t.Fatalf("Unexpected job state: %+v", job)
+	}
+
+	// Other tenants cannot see the job
+	if _, err := h.Exports.Get(uuid.New(), started.JobID); err != audit.ErrExportJobNotFound {
+		t.Errorf("Expected ErrExportJobNotFound, got %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/audit/exports/"+started.JobID.String()+"/download", nil)
+	req.SetPathValue("id", started.JobID.String())
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{TenantID: tenant.String()}))
+	w = httptest.NewRecorder()
+	h.DownloadExport(w, req)
+	if w.Code != 200 {
+		t.Fatalf("Download returned %d", w.Code)
+	}
+	if n := bytes.Count(w.Body.Bytes(), []byte("\n")); n != 5 {
+		t.Errorf("Expected 5 JSONL rows, got %d", n)
+	}
+}
+
+func TestAuditAPI_AsyncExportJob_RunningCap(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	mock.MatchExpectationsInOrder(false)
+	s := audit.NewService(db)
+	tenant := uuid.New()
+	h := &api.AuditHandler{Service: s, Exports: audit.NewExportJobs(s, t.TempDir())}
+	h.Exports.MaxRunning = 1
+
+	// Both queries stay in flight until the test ends
+	mock.ExpectQuery("SELECT id, event_id").WillDelayFor(time.Second).WillReturnRows(exportRows(1))
+	mock.ExpectQuery("SELECT id, event_id").WillDelayFor(time.Second).WillReturnRows(exportRows(1))
+
+	w := httptest.NewRecorder()
+	h.ExportEvents(w, exportRequest(tenant, "async=true"))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.ExportEvents(w, exportRequest(tenant, "async=true"))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 past the running cap, got %d", w.Code)
+	}
+
+	// The cap is per tenant
+	w = httptest.NewRecorder()
+	h.ExportEvents(w, exportRequest(uuid.New(), "async=true"))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 for another tenant, got %d", w.Code)
+	}
+}
+
+func TestExportJobs_PruneExpired(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	s := audit.NewService(db)
+	tenant := uuid.New()
+	dir := t.TempDir()
+	jobs := audit.NewExportJobs(s, dir)
+
+	// A file left by an earlier run
+	stale := filepath.Join(dir, uuid.New().String()+".jsonl")
+	os.WriteFile(stale, []byte("{}\n"), 0o600)
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(stale, old, old)
+
+	mock.ExpectQuery("SELECT id, event_id").WillReturnRows(exportRows(2))
+	job, err := jobs.Start(audit.AuditFilter{TenantID: tenant})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("Expected the stale export file to be removed")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := jobs.Get(tenant, job.ID)
+		if got.Status != audit.ExportRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Within the TTL the job and its file are kept
+	jobs.Prune()
+	f, err := jobs.Open(tenant, job.ID)
+	if err != nil {
+		t.Fatalf("Expected the finished job to be kept, got %v", err)
+	}
+	f.Close()
+
+	jobs.TTL = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	jobs.Prune()
+	if _, err := jobs.Get(tenant, job.ID); err != audit.ErrExportJobNotFound {
+		t.Errorf("Expected the expired job to be forgotten, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the export file to be removed, found %d file(s)", len(entries))
+	}
+}
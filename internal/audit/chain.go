@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxVerifyBatch caps the chain rows checked by one VerifyChain call.
+const MaxVerifyBatch = 10000
+
+// ErrChainSeqNotFound means VerifyChain was asked to resume after a
+// chain_seq the tenant does not have.
+var ErrChainSeqNotFound = errors.New("audit chain sequence not found")
+
+// ChainVerification is the result of walking a tenant's audit hash chain.
+type ChainVerification struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Valid    bool      `json:"valid"`
+	Checked  int       `json:"checked"`
+
+	// Set when the batch limit was reached with the chain intact; pass it
+	// back as after_seq to verify the next batch.
+	NextAfterSeq int64 `json:"next_after_seq,omitempty"`
+
+	// First broken link (set when Valid is false)
+	BrokenSeq     int64      `json:"broken_seq,omitempty"`
+	BrokenEventID *uuid.UUID `json:"broken_event_id,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+}
+
+// ComputeHash returns hex(SHA-256(prevHash || canonical(evt))).
+// Only persisted fields take part, so a row re-read from the DB hashes the same.
+func ComputeHash(prevHash string, evt AuditEvent) string {
+	var actor string
+	if evt.ActorUserID != nil {
+		actor = evt.ActorUserID.String()
+	}
+	canonical, _ := json.Marshal([]string{
+		evt.EventID.String(),
+		evt.TenantID.String(),
+		actor,
+		evt.Action,
+		evt.TargetType,
+		evt.TargetID,
+		evt.Result,
+		evt.ReasonCode,
+		evt.RequestID,
+		evt.ClientIP,
+		evt.UserAgent,
+		canonicalMetadata(evt.Metadata),
+		evt.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	})
+
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalMetadata re-encodes JSON so key order/whitespace changes made by
+// JSONB storage don't affect the hash.
+func canonicalMetadata(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil || v == nil {
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// chainVerifier checks events one at a time in chain_seq order.
+type chainVerifier struct {
+	res      ChainVerification
+	lastSeq  int64
+	lastHash string
+}
+
+// next returns false once a broken link has been recorded.
+func (v *chainVerifier) next(evt AuditEvent) bool {
+	fail := func(reason string) bool {
+		id := evt.EventID
+		v.res.Valid = false
+		v.res.BrokenSeq = evt.ChainSeq
+		v.res.BrokenEventID = &id
+		v.res.Reason = reason
+		return false
+	}
+
+	switch {
+	case evt.ChainSeq != v.lastSeq+1:
+		return fail("sequence_gap")
+	case evt.PrevHash != v.lastHash:
+		return fail("prev_hash_mismatch")
+	case evt.Hash != ComputeHash(evt.PrevHash, evt):
+		return fail("hash_mismatch")
+	}
+
+	v.res.Checked++
+	v.lastSeq = evt.ChainSeq
+	v.lastHash = evt.Hash
+	return true
+}
+
+// VerifyEvents walks events (ordered by ChainSeq, starting at 1) and reports
+// the first broken link.
+func VerifyEvents(tenantID uuid.UUID, events []AuditEvent) *ChainVerification {
+	v := &chainVerifier{res: ChainVerification{TenantID: tenantID, Valid: true}}
+	for _, evt := range events {
+		if !v.next(evt) {
+			break
+		}
+	}
+	return &v.res
+}
+
+// VerifyChain walks up to limit of the tenant's chained audit rows in order,
+// starting after afterSeq (0 for the start of the chain). limit is capped at
+// MaxVerifyBatch.
+func (s *Service) VerifyChain(ctx context.Context, tenantID uuid.UUID, afterSeq int64, limit int) (*ChainVerification, error) {
+	if limit <= 0 || limit > MaxVerifyBatch {
+		limit = MaxVerifyBatch
+	}
+	v := &chainVerifier{res: ChainVerification{TenantID: tenantID, Valid: true}, lastSeq: afterSeq}
+	if afterSeq > 0 {
+		err := s.DB.QueryRowContext(ctx,
+			`SELECT hash FROM audit_logs WHERE tenant_id = $1 AND chain_seq = $2`, tenantID, afterSeq).Scan(&v.lastHash)
+		if err == sql.ErrNoRows {
+			return nil, ErrChainSeqNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	q := `SELECT event_id, tenant_id, actor_user_id, action, target_type, target_id,
+	             result, reason_code, request_id, client_ip, user_agent, metadata, created_at,
+	             chain_seq, prev_hash, hash
+	      FROM audit_logs
+	      WHERE tenant_id = $1 AND chain_seq > $2
+	      ORDER BY chain_seq ASC
+	      LIMIT $3`
+
+	rows, err := s.DB.QueryContext(ctx, q, tenantID, afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var evt AuditEvent
+		var targetType, targetID, reason, reqID, clientIP, ua, prevHash, hash sql.NullString
+		var meta []byte
+		if err := rows.Scan(&evt.EventID, &evt.TenantID, &evt.ActorUserID, &evt.Action, &targetType, &targetID,
+			&evt.Result, &reason, &reqID, &clientIP, &ua, &meta, &evt.CreatedAt,
+			&evt.ChainSeq, &prevHash, &hash); err != nil {
+			return nil, err
+		}
+		evt.TargetType, evt.TargetID, evt.ReasonCode = targetType.String, targetID.String, reason.String
+		evt.RequestID, evt.ClientIP, evt.UserAgent = reqID.String, clientIP.String, ua.String
+		evt.PrevHash, evt.Hash = prevHash.String, hash.String
+		evt.Metadata = meta
+
+		if !v.next(evt) {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if v.res.Valid && v.res.Checked == limit {
+		v.res.NextAfterSeq = v.lastSeq
+	}
+	return &v.res, nil
+}
+
+// insertChained appends evt to its tenant's chain inside a transaction.
+// A per-tenant advisory lock serializes concurrent writers.
+func (s *Service) insertChained(ctx context.Context, evt *AuditEvent) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "audit_chain:"+evt.TenantID.String()); err != nil {
+		return err
+	}
+
+	var lastSeq int64
+	var lastHash string
+	err = tx.QueryRowContext(ctx,
+		`SELECT chain_seq, hash FROM audit_logs
+		 WHERE tenant_id = $1 AND chain_seq IS NOT NULL
+		 ORDER BY chain_seq DESC LIMIT 1`, evt.TenantID).Scan(&lastSeq, &lastHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	evt.ChainSeq = lastSeq + 1
+	evt.PrevHash = lastHash
+	evt.Hash = ComputeHash(lastHash, *evt)
+
+	query := `
+		INSERT INTO audit_logs (
+			event_id, tenant_id, actor_user_id, action, target_type, target_id,
+			result, reason_code, request_id, client_ip, user_agent, metadata, created_at,
+			chain_seq, prev_hash, hash
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (event_id) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query,
+		evt.EventID, evt.TenantID, evt.ActorUserID, evt.Action, evt.TargetType, evt.TargetID,
+		evt.Result, evt.ReasonCode, evt.RequestID, evt.ClientIP, evt.UserAgent, evt.Metadata, evt.CreatedAt,
+		evt.ChainSeq, evt.PrevHash, evt.Hash,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
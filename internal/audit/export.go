@@ -0,0 +1,306 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrExportRangeTooWide = errors.New("export time range exceeds limit")
+	ErrExportTooLarge     = errors.New("export row count exceeds limit")
+	ErrExportJobNotFound  = errors.New("export job not found")
+	ErrExportNotReady     = errors.New("export job not completed")
+	ErrExportTooManyJobs  = errors.New("too many running export jobs")
+)
+
+// ExportLimits bound a synchronous (streamed) export. Exports past them must
+// narrow the range or run as an async job, which is capped by AsyncMaxRows.
+type ExportLimits struct {
+	MaxRows      int
+	MaxRange     time.Duration
+	AsyncMaxRows int
+}
+
+// DefaultExportLimits: 10k rows / 31 days inline, 1M rows per async job.
+func DefaultExportLimits() ExportLimits {
+	return ExportLimits{
+		MaxRows:      10000,
+		MaxRange:     31 * 24 * time.Hour,
+		AsyncMaxRows: 1000000,
+	}
+}
+
+// Merge returns l with every positive field of o applied on top.
+func (l ExportLimits) Merge(o ExportLimits) ExportLimits {
+	if o.MaxRows > 0 {
+		l.MaxRows = o.MaxRows
+	}
+	if o.MaxRange > 0 {
+		l.MaxRange = o.MaxRange
+	}
+	if o.AsyncMaxRows > 0 {
+		l.AsyncMaxRows = o.AsyncMaxRows
+	}
+	return l
+}
+
+// ExportLimitsFor returns the service-wide limits with the tenant's override
+// (if any) merged on top.
+func (s *Service) ExportLimitsFor(tenantID uuid.UUID) ExportLimits {
+	l := DefaultExportLimits().Merge(s.ExportLimits)
+	if o, ok := s.TenantExportLimits[tenantID]; ok {
+		l = l.Merge(o)
+	}
+	return l
+}
+
+// CheckExportRange rejects ranges wider than the tenant's MaxRange.
+func (s *Service) CheckExportRange(f AuditFilter) error {
+	if f.DateFrom == nil || f.DateTo == nil {
+		return nil
+	}
+	if max := s.ExportLimitsFor(f.TenantID).MaxRange; f.DateTo.Sub(*f.DateFrom) > max {
+		return fmt.Errorf("%w: maximum is %s", ErrExportRangeTooWide, max)
+	}
+	return nil
+}
+
+// CollectExport loads at most maxRows matching events, returning
+// ErrExportTooLarge (and no events) when more exist. Buffering lets the
+// handler reject an oversized export before any body is written.
+func (s *Service) CollectExport(ctx context.Context, f AuditFilter, maxRows int) ([]AuditEvent, error) {
+	f.Limit = maxRows + 1
+	var events []AuditEvent
+	err := s.scanExport(ctx, f, func(evt AuditEvent) error {
+		events = append(events, evt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > maxRows {
+		return nil, fmt.Errorf("%w: more than %d events", ErrExportTooLarge, maxRows)
+	}
+	return events, nil
+}
+
+// ExportJob is an async export writing JSONL to a file for later download.
+type ExportJob struct {
+	ID          uuid.UUID  `json:"job_id"`
+	TenantID    uuid.UUID  `json:"-"`
+	Status      string     `json:"status"` // running, completed, failed
+	Rows        int        `json:"rows"`
+	Truncated   bool       `json:"truncated,omitempty"` // Hit AsyncMaxRows
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	path string
+}
+
+// Export job states.
+const (
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+const (
+	DefaultMaxRunningExports = 2
+	DefaultExportJobTTL      = 24 * time.Hour
+)
+
+// ExportJobs runs async exports and tracks them in memory; files live in Dir.
+type ExportJobs struct {
+	Service *Service
+	Dir     string
+
+	// MaxRunning caps a tenant's concurrently running jobs; Start past it
+	// returns ErrExportTooManyJobs.
+	MaxRunning int
+	// TTL is how long a finished job and its file are kept (see Prune).
+	TTL time.Duration
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*ExportJob
+	now  func() time.Time
+}
+
+func NewExportJobs(s *Service, dir string) *ExportJobs {
+	return &ExportJobs{
+		Service:    s,
+		Dir:        dir,
+		MaxRunning: DefaultMaxRunningExports,
+		TTL:        DefaultExportJobTTL,
+		jobs:       make(map[uuid.UUID]*ExportJob),
+		now:        time.Now,
+	}
+}
+
+// Start launches an export in the background and returns its job.
+func (j *ExportJobs) Start(f AuditFilter) (*ExportJob, error) {
+	j.Prune()
+	if err := os.MkdirAll(j.Dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	j.mu.Lock()
+	running := 0
+	for _, job := range j.jobs {
+		if job.TenantID == f.TenantID && job.Status == ExportRunning {
+			running++
+		}
+	}
+	if j.MaxRunning > 0 && running >= j.MaxRunning {
+		j.mu.Unlock()
+		return nil, fmt.Errorf("%w: maximum is %d", ErrExportTooManyJobs, j.MaxRunning)
+	}
+	job := &ExportJob{
+		ID:        uuid.New(),
+		TenantID:  f.TenantID,
+		Status:    ExportRunning,
+		CreatedAt: j.now().UTC(),
+	}
+	job.path = filepath.Join(j.Dir, job.ID.String()+".jsonl")
+	j.jobs[job.ID] = job
+	j.mu.Unlock()
+
+	go j.run(job, f)
+	snapshot := *job
+	return &snapshot, nil
+}
+
+func (j *ExportJobs) run(job *ExportJob, f AuditFilter) {
+	max := j.Service.ExportLimitsFor(f.TenantID).AsyncMaxRows
+	f.Limit = max + 1
+
+	rows, truncated, err := j.writeFile(job.path, f, max)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now().UTC()
+	job.CompletedAt = &now
+	if err != nil {
+		log.Printf("Audit export %s failed: %v", job.ID, err)
+		job.Status = ExportFailed
+		job.Error = "export failed"
+		os.Remove(job.path)
+		return
+	}
+	job.Status = ExportCompleted
+	job.Rows = rows
+	job.Truncated = truncated
+}
+
+func (j *ExportJobs) writeFile(path string, f AuditFilter, max int) (rows int, truncated bool, err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	err = j.Service.scanExport(context.Background(), f, func(evt AuditEvent) error {
+		if rows >= max {
+			truncated = true // The extra row fetched by Limit = max+1
+			return nil
+		}
+		rows++
+		return enc.Encode(evt)
+	})
+	if err != nil {
+		return rows, false, err
+	}
+	return rows, truncated, file.Sync()
+}
+
+// Get returns a snapshot of the tenant's job.
+func (j *ExportJobs) Get(tenantID, id uuid.UUID) (*ExportJob, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok || job.TenantID != tenantID {
+		return nil, ErrExportJobNotFound
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// Open returns the completed export file of the tenant's job.
+func (j *ExportJobs) Open(tenantID, id uuid.UUID) (io.ReadCloser, error) {
+	job, err := j.Get(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != ExportCompleted {
+		return nil, ErrExportNotReady
+	}
+	return os.Open(job.path)
+}
+
+// Prune forgets jobs that finished more than TTL ago and removes their
+// files, along with export files in Dir left over from earlier runs.
+func (j *ExportJobs) Prune() {
+	if j.TTL <= 0 {
+		return
+	}
+	cutoff := j.now().Add(-j.TTL)
+
+	j.mu.Lock()
+	known := make(map[string]bool, len(j.jobs))
+	var expired []string
+	for id, job := range j.jobs {
+		if job.CompletedAt != nil && job.CompletedAt.Before(cutoff) {
+			delete(j.jobs, id)
+			expired = append(expired, job.path)
+			continue
+		}
+		known[filepath.Base(job.path)] = true
+	}
+	j.mu.Unlock()
+
+	for _, path := range expired {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Audit export: failed to remove %s: %v", path, err)
+		}
+	}
+
+	entries, err := os.ReadDir(j.Dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".jsonl" || known[e.Name()] {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(j.Dir, e.Name()))
+		}
+	}
+}
+
+// StartPruning runs Prune every interval until ctx is cancelled.
+func (j *ExportJobs) StartPruning(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Prune()
+			}
+		}
+	}()
+}
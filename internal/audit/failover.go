@@ -4,13 +4,18 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 var (
@@ -56,6 +61,8 @@ func SpoolEvent(evt AuditEvent) error {
 	// Simple strategy: current.log. append.
 	filename := filepath.Join(SpoolDir, "audit_spool.log")
 
+	spoolMu.Lock()
+	defer spoolMu.Unlock()
 	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
 		return err
@@ -91,6 +98,76 @@ func rotateSpool() error {
 	return nil
 }
 
+// requeueAhead writes the scanner's current and remaining lines to a new
+// spool file, appends anything spooled meanwhile, and swaps it in.
+func requeueAhead(scanner *bufio.Scanner) error {
+	spoolMu.Lock()
+	defer spoolMu.Unlock()
+
+	filename := filepath.Join(SpoolDir, "audit_spool.log")
+	tmpName := filepath.Join(SpoolDir, fmt.Sprintf("requeue_%d.log", time.Now().UnixNano()))
+	tmp, err := os.OpenFile(tmpName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer tmp.Close()
+
+	w := bufio.NewWriter(tmp)
+	for ok := true; ok; ok = scanner.Scan() {
+		w.Write(scanner.Bytes())
+		w.WriteByte('\n')
+	}
+	if cur, err := os.Open(filename); err == nil {
+		_, err = io.Copy(w, cur)
+		cur.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	tmp.Close()
+	return os.Rename(tmpName, filename)
+}
+
+// permanentInsertError reports whether err fails the same way on every
+// retry: data exceptions (class 22) and integrity constraint violations
+// (class 23, e.g. an unknown tenant_id).
+func permanentInsertError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "22", "23":
+			return true
+		}
+	}
+	return false
+}
+
+// deadLetterEvent is one line of audit_deadletter.log.
+type deadLetterEvent struct {
+	Line  string    `json:"line"`
+	Error string    `json:"error"`
+	At    time.Time `json:"dead_lettered_at"`
+}
+
+// deadLetter moves a spool line that can never be inserted to
+// audit_deadletter.log for manual review, so it does not block the spool.
+func deadLetter(line []byte, cause error) error {
+	entry, err := json.Marshal(deadLetterEvent{Line: string(line), Error: cause.Error(), At: time.Now()})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(SpoolDir, "audit_deadletter.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(entry, '\n'))
+	return err
+}
+
 // Replayer (Background Worker)
 func (s *Service) StartReplayer(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
@@ -106,11 +183,27 @@ func (s *Service) StartReplayer(ctx context.Context) {
 	}()
 }
 
-var replayLock sync.Mutex
+var (
+	spoolMu    sync.Mutex // guards audit_spool.log appends/swaps
+	replayLock sync.Mutex
+	replaying  atomic.Bool
+)
+
+// spoolPending reports whether events are waiting in the spool or a replay
+// is in flight.
+func spoolPending() bool {
+	if replaying.Load() {
+		return true
+	}
+	info, err := os.Stat(filepath.Join(SpoolDir, "audit_spool.log"))
+	return err == nil && info.Size() > 0
+}
 
 func (s *Service) ReplaySpool(ctx context.Context) {
 	replayLock.Lock()
 	defer replayLock.Unlock()
+	replaying.Store(true)
+	defer replaying.Store(false)
 
 	filename := filepath.Join(SpoolDir, "audit_spool.log")
 	info, err := os.Stat(filename)
@@ -137,29 +230,42 @@ func (s *Service) ReplaySpool(ctx context.Context) {
 
 	for scanner.Scan() {
 		var fe FailoverEvent
-		if err := json.Unmarshal(scanner.Bytes(), &fe); err != nil {
-			failed++
-			continue
+		err := json.Unmarshal(scanner.Bytes(), &fe)
+		if err == nil {
+			err = s.insertEvent(ctx, fe.Payload)
+			if err == nil {
+				succeeded++
+				continue
+			}
+			if !permanentInsertError(err) {
+				// DB still down: put this and the remaining events back at the
+				// head of the spool so order (and the audit hash chain) holds.
+				if err := requeueAhead(scanner); err != nil {
+					log.Printf("CRITICAL: Audit re-spool FAILED: %v", err)
+					return // keep replay file for manual recovery
+				}
+				break
+			}
 		}
 
-		// Attempt Insert
-		err := s.WriteEvent(ctx, fe.Payload) // This might recurse if DB still down!
-		// But WriteEvent logic calls SpoolEvent on failure.
-		// So if DB is down, we just re-spool it (creating new file).
-		// This is acceptable loop, but we should prevent tight loop.
-		// WriteEvent calls SpoolEvent -> appends to audit_spool.log
-		// We are reading replay_xxx.log.
-		// So it moves pending events back to spool if DB fails. Correct.
-		if err == nil {
-			succeeded++
+		// Malformed or rejected by the DB: retrying would stall the spool.
+		failed++
+		if dlErr := deadLetter(scanner.Bytes(), err); dlErr != nil {
+			log.Printf("CRITICAL: Audit dead-letter FAILED: %v", dlErr)
+			if err := requeueAhead(scanner); err != nil {
+				log.Printf("CRITICAL: Audit re-spool FAILED: %v", err)
+				return
+			}
+			break
 		}
+		log.Printf("Audit Replay: event dead-lettered: %v", err)
 	}
 
 	// Remove replay file (events either in DB or Re-Spooled)
 	f.Close()
 	os.Remove(replayFile)
 
-	if succeeded > 0 {
-		log.Printf("Audit Replay: %d events flushed", succeeded)
+	if succeeded > 0 || failed > 0 {
+		log.Printf("Audit Replay: %d events flushed, %d dead-lettered", succeeded, failed)
 	}
 }
@@ -25,6 +25,11 @@ type AuditEvent struct {
 	UserAgent   string          `json:"user_agent,omitempty"`
 	Metadata    json.RawMessage `json:"metadata,omitempty"`
 	CreatedAt   time.Time       `json:"created_at"`
+
+	// Hash chain (set on insert when Service.HashChain is enabled)
+	ChainSeq int64  `json:"chain_seq,omitempty"`
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
 }
 
 // FailoverEvent wrapper for JSONL spooling
@@ -50,6 +55,15 @@ type AuditFilter struct {
 type Service struct {
 	DB *sql.DB
 	// Spooler injected later
+
+	// HashChain links each event to the previous one of its tenant
+	// (see chain.go). Enabled via audit.hash_chain.
+	HashChain bool
+
+	// ExportLimits bound exports (see export.go); zero value uses the defaults.
+	// TenantExportLimits overrides them per tenant.
+	ExportLimits       ExportLimits
+	TenantExportLimits map[uuid.UUID]ExportLimits
 }
 
 func NewService(db *sql.DB) *Service {
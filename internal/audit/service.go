@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -16,7 +17,31 @@ func (s *Service) WriteEvent(ctx context.Context, evt AuditEvent) error {
 		evt.EventID = uuid.New()
 	}
 
+	// Chain order must match write order: while older events are still
+	// spooled (or being replayed), queue behind them instead of jumping ahead.
+	if s.HashChain && spoolPending() {
+		return s.spool(evt, nil)
+	}
+
 	// 1. Try DB Write
+	if err := s.insertEvent(ctx, evt); err != nil {
+		// 2. Failover to Spool
+		return s.spool(evt, err)
+	}
+
+	return nil
+}
+
+func (s *Service) insertEvent(ctx context.Context, evt AuditEvent) error {
+	if s.HashChain {
+		if evt.CreatedAt.IsZero() {
+			evt.CreatedAt = time.Now().UTC()
+		}
+		// Match TIMESTAMPTZ precision so the stored row re-hashes identically
+		evt.CreatedAt = evt.CreatedAt.Truncate(time.Microsecond)
+		return s.insertChained(ctx, &evt)
+	}
+
 	query := `
 		INSERT INTO audit_logs (
 			event_id, tenant_id, actor_user_id, action, target_type, target_id,
@@ -29,18 +54,18 @@ func (s *Service) WriteEvent(ctx context.Context, evt AuditEvent) error {
 		evt.EventID, evt.TenantID, evt.ActorUserID, evt.Action, evt.TargetType, evt.TargetID,
 		evt.Result, evt.ReasonCode, evt.RequestID, evt.ClientIP, evt.UserAgent, evt.Metadata, evt.CreatedAt,
 	)
+	return err
+}
 
-	if err != nil {
-		// 2. Failover to Spool
-		log.Printf("Audit DB Write Failed: %v. Spooling event %s", err, evt.EventID)
-		if spoolErr := SpoolEvent(evt); spoolErr != nil {
-			log.Printf("CRITICAL: Audit Spool FAILED for event %s: %v", evt.EventID, spoolErr)
-			return fmt.Errorf("audit critical failure: %v", spoolErr)
-		}
-		return nil // Swallow DB error if spooled successfully
+func (s *Service) spool(evt AuditEvent, dbErr error) error {
+	if dbErr != nil {
+		log.Printf("Audit DB Write Failed: %v. Spooling event %s", dbErr, evt.EventID)
 	}
-
-	return nil
+	if spoolErr := SpoolEvent(evt); spoolErr != nil {
+		log.Printf("CRITICAL: Audit Spool FAILED for event %s: %v", evt.EventID, spoolErr)
+		return fmt.Errorf("audit critical failure: %v", spoolErr)
+	}
+	return nil // Swallow DB error if spooled successfully
 }
 
 // Append-only enforcement: No Update or Delete methods exposed.
@@ -95,37 +120,58 @@ func (s *Service) QueryEvents(ctx context.Context, f AuditFilter) ([]AuditEvent,
 	return events, lastID, nil
 }
 
+// ExportEvents streams matching events as JSONL, oldest first, up to f.Limit
+// (10000 when unset).
 func (s *Service) ExportEvents(ctx context.Context, f AuditFilter, w io.Writer) error {
+	if f.Limit <= 0 {
+		f.Limit = 10000 // Safety Bound
+	}
+	enc := json.NewEncoder(w)
+	return s.scanExport(ctx, f, func(evt AuditEvent) error {
+		return enc.Encode(evt)
+	})
+}
+
+// scanExport runs the export query (tenant, optional date range, f.Limit)
+// and hands each event to fn.
+func (s *Service) scanExport(ctx context.Context, f AuditFilter, fn func(AuditEvent) error) error {
 	q := `SELECT id, event_id, tenant_id, actor_user_id, action, result, created_at, metadata 
 	      FROM audit_logs 
 	      WHERE tenant_id = $1`
 	args := []interface{}{f.TenantID}
+	idx := 2
+
+	if f.DateFrom != nil {
+		q += fmt.Sprintf(" AND created_at >= $%d", idx)
+		args = append(args, *f.DateFrom)
+		idx++
+	}
+	if f.DateTo != nil {
+		q += fmt.Sprintf(" AND created_at < $%d", idx)
+		args = append(args, *f.DateTo)
+		idx++
+	}
+	q += fmt.Sprintf(" ORDER BY created_at ASC, id ASC LIMIT $%d", idx)
+	args = append(args, f.Limit)
 
-	// Streaming without Limit (or Hard Cap)
 	rows, err := s.DB.QueryContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	enc := json.NewEncoder(w)
-	count := 0
-	MaxRecords := 10000 // Safety Bound
-
 	for rows.Next() {
-		if count >= MaxRecords {
-			break
-		}
 		var evt AuditEvent
 		var meta []byte
-		rows.Scan(&evt.ID, &evt.EventID, &evt.TenantID, &evt.ActorUserID, &evt.Action, &evt.Result, &evt.CreatedAt, &meta)
+		if err := rows.Scan(&evt.ID, &evt.EventID, &evt.TenantID, &evt.ActorUserID, &evt.Action, &evt.Result, &evt.CreatedAt, &meta); err != nil {
+			return err
+		}
 		if len(meta) > 0 {
 			json.Unmarshal(meta, &evt.Metadata)
 		}
-		if err := enc.Encode(evt); err != nil {
+		if err := fn(evt); err != nil {
 			return err
 		}
-		count++
 	}
-	return nil
+	return rows.Err()
 }
@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSystemActorID is the actor recorded on audit events raised by
+// background jobs (monitors, schedulers, purgers, license reloads) so they
+// are distinguishable from user actions. It does not belong to any user.
+var DefaultSystemActorID = uuid.MustParse("00000000-0000-0000-0000-00000000005e")
+
+// SystemActorName is the role carried by background jobs' AuthContext.
+const SystemActorName = "system"
+
+var systemActorID atomic.Pointer[uuid.UUID]
+
+// SystemActorID returns the configured system actor, DefaultSystemActorID
+// unless overridden with SetSystemActorID.
+func SystemActorID() uuid.UUID {
+	if id := systemActorID.Load(); id != nil {
+		return *id
+	}
+	return DefaultSystemActorID
+}
+
+// SetSystemActorID overrides the system actor; uuid.Nil restores the default.
+// Call it at startup, before background jobs run, so every event of one
+// process carries the same actor.
+func SetSystemActorID(id uuid.UUID) {
+	if id == uuid.Nil {
+		systemActorID.Store(nil)
+		return
+	}
+	systemActorID.Store(&id)
+}
+
+// SystemActor returns a pointer to the system actor for AuditEvent.ActorUserID.
+func SystemActor() *uuid.UUID {
+	id := SystemActorID()
+	return &id
+}
+
+// IsSystemActor reports whether actor is the system actor.
+func IsSystemActor(actor *uuid.UUID) bool {
+	return actor != nil && *actor == SystemActorID()
+}
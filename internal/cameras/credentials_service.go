@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/audit"
+	"github.com/technosupport/ts-vms/internal/clock"
 	"github.com/technosupport/ts-vms/internal/crypto"
 	"github.com/technosupport/ts-vms/internal/data"
 )
@@ -17,34 +21,114 @@ var (
 	ErrCredentialTooLarge = errors.New("credential payload exceeds 4KB limit")
 	ErrCredentialInvalid  = errors.New("invalid credential format")
 	ErrCredentialCrypto   = errors.New("credential encryption/decryption failed") // Generic error
+
+	ErrRevealJustificationRequired = errors.New("justification required to reveal credentials")
+	ErrRevealRateLimited           = errors.New("credential reveal rate limit exceeded")
 )
 
 const (
 	MaxCredentialSize = 4096
 	AADPurpose        = "camera_credential_v1"
+
+	// MaxRevealJustification bounds the justification (in bytes) stored in
+	// the audit log; longer ones are cut at a rune boundary.
+	MaxRevealJustification = 500
+	// DefaultRevealLimit reveals are allowed per user per DefaultRevealWindow.
+	DefaultRevealLimit  = 5
+	DefaultRevealWindow = time.Hour
 )
 
 // CredentialUpdater defines dependency on data layer
 type CredentialUpdater interface {
 	Upsert(ctx context.Context, c *data.CameraCredential) error
 	Get(ctx context.Context, cameraID uuid.UUID) (*data.CameraCredential, error)
+	RewrapDEK(ctx context.Context, c *data.CameraCredential, previousKID string, previousDEK []byte) error
 	Delete(ctx context.Context, cameraID uuid.UUID) error
+	DeleteBySite(ctx context.Context, tenantID, siteID uuid.UUID) (int, error)
+	ListInventory(ctx context.Context, tenantID uuid.UUID) ([]data.CredentialInventoryEntry, error)
 }
 
 type CredentialService struct {
 	repo    CredentialUpdater
 	keyring *crypto.Keyring
-	auditor Auditor // Using existing Auditor interface from service.go
+	auditor Auditor     // Using existing Auditor interface from service.go
+	Clock   clock.Clock // Defaults to the real clock
+
+	// Per-user reveal window (in-process; reveals are rare and operator-driven)
+	revealMu     sync.Mutex
+	revealLimit  int
+	revealWindow time.Duration
+	reveals      map[uuid.UUID][]time.Time
 }
 
 func NewCredentialService(repo CredentialUpdater, keyring *crypto.Keyring, aud Auditor) *CredentialService {
 	return &CredentialService{
-		repo:    repo,
-		keyring: keyring,
-		auditor: aud,
+		repo:         repo,
+		keyring:      keyring,
+		auditor:      aud,
+		Clock:        clock.New(),
+		revealLimit:  DefaultRevealLimit,
+		revealWindow: DefaultRevealWindow,
+		reveals:      make(map[uuid.UUID][]time.Time),
+	}
+}
+
+// SetRevealLimit configures how many API reveals a user may perform per
+// window. A limit <= 0 disables the cap.
+func (s *CredentialService) SetRevealLimit(limit int, window time.Duration) {
+	s.revealMu.Lock()
+	defer s.revealMu.Unlock()
+	s.revealLimit = limit
+	if window > 0 {
+		s.revealWindow = window
 	}
 }
 
+// allowReveal records a reveal attempt for the user and reports whether it
+// fits in the current window.
+func (s *CredentialService) allowReveal(userID uuid.UUID) bool {
+	s.revealMu.Lock()
+	defer s.revealMu.Unlock()
+	if s.revealLimit <= 0 {
+		return true
+	}
+	now := s.Clock.Now()
+	s.expireRevealsLocked(now)
+	recent := s.reveals[userID]
+	if len(recent) >= s.revealLimit {
+		return false
+	}
+	s.reveals[userID] = append(recent, now)
+	return true
+}
+
+// expireRevealsLocked drops reveals older than the window and forgets users
+// with none left, so the map does not grow with every user who ever revealed.
+func (s *CredentialService) expireRevealsLocked(now time.Time) {
+	cutoff := now.Add(-s.revealWindow)
+	for userID, times := range s.reveals {
+		recent := times[:0]
+		for _, t := range times {
+			if t.After(cutoff) {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) == 0 {
+			delete(s.reveals, userID)
+			continue
+		}
+		s.reveals[userID] = recent
+	}
+}
+
+// RevealUsers reports how many users have reveals inside the current window.
+func (s *CredentialService) RevealUsers() int {
+	s.revealMu.Lock()
+	defer s.revealMu.Unlock()
+	s.expireRevealsLocked(s.Clock.Now())
+	return len(s.reveals)
+}
+
 // CredentialInput is the plaintext payload
 type CredentialInput struct {
 	Username string `json:"username"`
@@ -60,6 +144,25 @@ type CredentialOutput struct {
 	CreatedAt time.Time        `json:"created_at,omitempty"`
 }
 
+// CredentialInventoryItem is the audit view of a camera's credentials. It never
+// carries secret material, only which key version wraps them.
+type CredentialInventoryItem struct {
+	CameraID       uuid.UUID  `json:"camera_id"`
+	CameraName     string     `json:"camera_name"`
+	HasCredentials bool       `json:"has_credentials"`
+	MasterKID      string     `json:"master_kid,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+	DeprecatedKey  bool       `json:"deprecated_key"` // Wrapped by a master key other than the active one
+}
+
+// RekeyResult reports a single-camera re-key. Rekeyed is false when the
+// credentials were already wrapped under the active key.
+type RekeyResult struct {
+	Rekeyed     bool   `json:"rekeyed"`
+	PreviousKID string `json:"previous_kid,omitempty"`
+	MasterKID   string `json:"master_kid"`
+}
+
 // SetCredentials encrypts and stores credentials
 func (s *CredentialService) SetCredentials(ctx context.Context, tenantID, cameraID uuid.UUID, input CredentialInput) error {
 	// 1. Validate Payload Size
@@ -134,7 +237,84 @@ func (s *CredentialService) SetCredentials(ctx context.Context, tenantID, camera
 
 // GetCredentials retrieves (and optionally decrypts) credentials
 // Returns (output, found, error). If not found, output is nil, found is false, err is nil.
+// This is the internal path (e.g. media selection); user-initiated reveals go
+// through RevealCredentials.
 func (s *CredentialService) GetCredentials(ctx context.Context, tenantID, cameraID uuid.UUID, reveal bool) (*CredentialOutput, bool, error) {
+	out, found, err := s.load(ctx, tenantID, cameraID, reveal)
+	if err != nil || !found {
+		return out, found, err
+	}
+
+	// 4. Audit
+	action := "camera.credential.read"
+	meta := map[string]any{"revealed": reveal}
+
+	s.auditor.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:   tenantID,
+		EventID:    uuid.New(),
+		Action:     action,
+		Result:     "success",
+		TargetID:   cameraID.String(),
+		TargetType: "camera",
+		CreatedAt:  time.Now(),
+		Metadata:   toMeta(meta),
+	})
+
+	return out, true, nil
+}
+
+// RevealCredentials decrypts credentials for a user-initiated (API) reveal.
+// A justification is mandatory, reveals are rate-limited per user, and each
+// attempt emits a credential.reveal audit event carrying the justification.
+func (s *CredentialService) RevealCredentials(ctx context.Context, tenantID, cameraID, userID uuid.UUID, justification string) (*CredentialOutput, bool, error) {
+	justification = strings.TrimSpace(justification)
+	if justification == "" {
+		return nil, false, ErrRevealJustificationRequired
+	}
+	if len(justification) > MaxRevealJustification {
+		n := MaxRevealJustification
+		for n > 0 && !utf8.RuneStart(justification[n]) {
+			n--
+		}
+		justification = justification[:n]
+	}
+
+	evt := audit.AuditEvent{
+		TenantID:    tenantID,
+		EventID:     uuid.New(),
+		ActorUserID: &userID,
+		Action:      "credential.reveal",
+		Result:      "success",
+		TargetID:    cameraID.String(),
+		TargetType:  "camera",
+		CreatedAt:   time.Now(),
+		Metadata:    toMeta(map[string]any{"justification": justification}),
+	}
+
+	if !s.allowReveal(userID) {
+		evt.Result = "failure"
+		evt.ReasonCode = "rate_limited"
+		s.auditor.WriteEvent(ctx, evt)
+		return nil, false, ErrRevealRateLimited
+	}
+
+	out, found, err := s.load(ctx, tenantID, cameraID, true)
+	if err != nil || !found {
+		evt.Result = "failure"
+		evt.ReasonCode = "not_found"
+		if err != nil {
+			evt.ReasonCode = "error"
+		}
+		s.auditor.WriteEvent(ctx, evt)
+		return out, found, err
+	}
+
+	s.auditor.WriteEvent(ctx, evt)
+	return out, true, nil
+}
+
+// load fetches the record and decrypts it when reveal is set. It does not audit.
+func (s *CredentialService) load(ctx context.Context, tenantID, cameraID uuid.UUID, reveal bool) (*CredentialOutput, bool, error) {
 	// 1. Retrieve
 	c, err := s.repo.Get(ctx, cameraID)
 	if err != nil {
@@ -181,22 +361,61 @@ func (s *CredentialService) GetCredentials(ctx context.Context, tenantID, camera
 		out.Data = &input
 	}
 
-	// 4. Audit
-	action := "camera.credential.read"
-	meta := map[string]any{"revealed": reveal}
+	return out, true, nil
+}
 
-	s.auditor.WriteEvent(ctx, audit.AuditEvent{
-		TenantID:   tenantID,
-		EventID:    uuid.New(),
-		Action:     action,
-		Result:     "success",
-		TargetID:   cameraID.String(),
-		TargetType: "camera",
-		CreatedAt:  time.Now(),
-		Metadata:   toMeta(meta),
-	})
+// RekeyCredentials re-wraps the camera's DEK under the active master key.
+// The DEK and the encrypted credentials are unchanged, so the plaintext is
+// never touched. Already-current credentials are left as they are.
+func (s *CredentialService) RekeyCredentials(ctx context.Context, tenantID, cameraID uuid.UUID) (*RekeyResult, error) {
+	c, err := s.repo.Get(ctx, cameraID)
+	if err != nil {
+		return nil, err
+	}
+	if c.TenantID != tenantID {
+		return nil, data.ErrCredentialNotFound
+	}
 
-	return out, true, nil
+	activeKID := s.keyring.ActiveKID()
+	res := &RekeyResult{MasterKID: c.MasterKID}
+	evt := audit.AuditEvent{
+		TenantID:    tenantID,
+		EventID:     uuid.New(),
+		ActorUserID: actorFromContext(ctx),
+		Action:      "credential.rekey",
+		Result:      "success",
+		TargetID:    cameraID.String(),
+		TargetType:  "camera",
+		CreatedAt:   time.Now(),
+	}
+
+	if c.MasterKID == activeKID {
+		evt.Metadata = toMeta(map[string]any{"kid": activeKID, "rekeyed": false})
+		s.auditor.WriteEvent(ctx, evt)
+		return res, nil
+	}
+
+	aad := []byte(fmt.Sprintf("%s:%s:%s", tenantID.String(), cameraID.String(), AADPurpose))
+	dek, err := s.keyring.UnwrapDEK(c.MasterKID, c.DEKNonce, c.DEKCiphertext, c.DEKTag, aad)
+	if err != nil {
+		s.logCryptoError("unwrap", c.MasterKID, err)
+		return nil, ErrCredentialCrypto
+	}
+	kid, kNonce, kCipher, kTag, err := s.keyring.WrapDEK(dek, aad)
+	if err != nil {
+		return nil, fmt.Errorf("key wrap failed: %w", err)
+	}
+
+	rewrapped := *c
+	rewrapped.MasterKID, rewrapped.DEKNonce, rewrapped.DEKCiphertext, rewrapped.DEKTag = kid, kNonce, kCipher, kTag
+	if err := s.repo.RewrapDEK(ctx, &rewrapped, c.MasterKID, c.DEKCiphertext); err != nil {
+		return nil, err
+	}
+
+	res.Rekeyed, res.PreviousKID, res.MasterKID = true, c.MasterKID, kid
+	evt.Metadata = toMeta(map[string]any{"previous_kid": c.MasterKID, "kid": kid, "rekeyed": true})
+	s.auditor.WriteEvent(ctx, evt)
+	return res, nil
 }
 
 // DeleteCredentials removes the record
@@ -233,6 +452,66 @@ func (s *CredentialService) DeleteCredentials(ctx context.Context, tenantID, cam
 	return nil
 }
 
+// DeleteSiteCredentials purges credentials for every camera in a tenant site
+// (site decommissioning). One audit event records the number removed.
+func (s *CredentialService) DeleteSiteCredentials(ctx context.Context, tenantID, siteID uuid.UUID) (int, error) {
+	n, err := s.repo.DeleteBySite(ctx, tenantID, siteID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.auditor.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:   tenantID,
+		EventID:    uuid.New(),
+		Action:     "camera.credential.bulk_delete",
+		Result:     "success",
+		TargetID:   siteID.String(),
+		TargetType: "site",
+		CreatedAt:  time.Now(),
+		Metadata:   toMeta(map[string]any{"count": n}),
+	})
+	return n, nil
+}
+
+// Inventory lists credential metadata for every tenant camera, flagging
+// credentials still wrapped by a non-active (deprecated) master key.
+func (s *CredentialService) Inventory(ctx context.Context, tenantID uuid.UUID) ([]CredentialInventoryItem, error) {
+	entries, err := s.repo.ListInventory(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	activeKID := s.keyring.ActiveKID()
+	items := make([]CredentialInventoryItem, 0, len(entries))
+	deprecated := 0
+	for _, e := range entries {
+		item := CredentialInventoryItem{
+			CameraID:       e.CameraID,
+			CameraName:     e.CameraName,
+			HasCredentials: e.HasCredentials,
+			MasterKID:      e.MasterKID,
+			UpdatedAt:      e.UpdatedAt,
+		}
+		if e.HasCredentials && e.MasterKID != activeKID {
+			item.DeprecatedKey = true
+			deprecated++
+		}
+		items = append(items, item)
+	}
+
+	s.auditor.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:   tenantID,
+		EventID:    uuid.New(),
+		Action:     "camera.credential.inventory",
+		Result:     "success",
+		TargetType: "camera_batch",
+		CreatedAt:  time.Now(),
+		Metadata:   toMeta(map[string]any{"count": len(items), "deprecated_key_count": deprecated}),
+	})
+
+	return items, nil
+}
+
 func (s *CredentialService) logCryptoError(stage, kid string, err error) {
 	// TODO: log info via logger interface if available.
 	// fmt.Printf("Crypto Error [%s] KID=%s: %v\n", stage, kid, err)
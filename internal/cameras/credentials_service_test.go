@@ -3,17 +3,24 @@ package cameras_test
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/clock"
 	"github.com/technosupport/ts-vms/internal/crypto"
 	"github.com/technosupport/ts-vms/internal/data"
 )
 
 type MockCredRepo struct {
 	Store map[string]*data.CameraCredential
+	Sites map[uuid.UUID]uuid.UUID // camera -> site
 }
 
 func (m *MockCredRepo) Upsert(ctx context.Context, c *data.CameraCredential) error {
@@ -29,11 +36,45 @@ func (m *MockCredRepo) Get(ctx context.Context, cameraID uuid.UUID) (*data.Camer
 	return c, nil
 }
 
+func (m *MockCredRepo) RewrapDEK(ctx context.Context, c *data.CameraCredential, previousKID string, previousDEK []byte) error {
+	cur, ok := m.Store[c.CameraID.String()]
+	if !ok || cur.MasterKID != previousKID || string(cur.DEKCiphertext) != string(previousDEK) {
+		return data.ErrOptimisticLock
+	}
+	m.Store[c.CameraID.String()] = c
+	return nil
+}
+
 func (m *MockCredRepo) Delete(ctx context.Context, cameraID uuid.UUID) error {
 	delete(m.Store, cameraID.String())
 	return nil
 }
 
+func (m *MockCredRepo) DeleteBySite(ctx context.Context, tenantID, siteID uuid.UUID) (int, error) {
+	n := 0
+	for k, c := range m.Store {
+		if c.TenantID == tenantID && m.Sites[c.CameraID] == siteID {
+			delete(m.Store, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MockCredRepo) ListInventory(ctx context.Context, tenantID uuid.UUID) ([]data.CredentialInventoryEntry, error) {
+	var out []data.CredentialInventoryEntry
+	for _, c := range m.Store {
+		if c.TenantID != tenantID {
+			continue
+		}
+		updated := c.UpdatedAt
+		out = append(out, data.CredentialInventoryEntry{
+			CameraID: c.CameraID, HasCredentials: true, MasterKID: c.MasterKID, UpdatedAt: &updated,
+		})
+	}
+	return out, nil
+}
+
 func TestSetCredentials(t *testing.T) {
 	// Setup Helper
 	repo := &MockCredRepo{Store: make(map[string]*data.CameraCredential)}
@@ -121,6 +162,245 @@ func TestGetCredentials(t *testing.T) {
 	}
 }
 
+func TestRevealCredentials_AuditedWithJustification(t *testing.T) {
+	repo := &MockCredRepo{Store: make(map[string]*data.CameraCredential)}
+	aud := &MockCredAuditor{}
+	key, _ := crypto.GenerateDEK()
+	t.Setenv("MASTER_KEYS", `[{"kid":"test-v1","material":"`+base64.StdEncoding.EncodeToString(key)+`"}]`)
+	t.Setenv("ACTIVE_MASTER_KID", "test-v1")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+	svc := cameras.NewCredentialService(repo, kr, aud)
+	svc.SetRevealLimit(2, time.Hour)
+
+	ctx := context.Background()
+	tenantID, camID, userID := uuid.New(), uuid.New(), uuid.New()
+	svc.SetCredentials(ctx, tenantID, camID, cameras.CredentialInput{Username: "u", Password: "p"})
+	aud.Events = nil
+
+	if _, _, err := svc.RevealCredentials(ctx, tenantID, camID, userID, "  "); err != cameras.ErrRevealJustificationRequired {
+		t.Fatalf("Expected ErrRevealJustificationRequired, got %v", err)
+	}
+
+	out, found, err := svc.RevealCredentials(ctx, tenantID, camID, userID, "RMA #991 camera swap")
+	if err != nil || !found || out.Data == nil || out.Data.Password != "p" {
+		t.Fatalf("Reveal failed: out=%+v found=%v err=%v", out, found, err)
+	}
+	if len(aud.Events) != 1 {
+		t.Fatalf("Expected 1 audit event, got %d", len(aud.Events))
+	}
+	evt := aud.Events[0]
+	if evt.Action != "credential.reveal" || evt.Result != "success" {
+		t.Errorf("Unexpected audit event %s/%s", evt.Action, evt.Result)
+	}
+	if evt.ActorUserID == nil || *evt.ActorUserID != userID {
+		t.Error("Reveal audit must carry the actor")
+	}
+	var meta map[string]string
+	json.Unmarshal(evt.Metadata, &meta)
+	if meta["justification"] != "RMA #991 camera swap" {
+		t.Errorf("Expected justification in audit metadata, got %v", meta)
+	}
+	if strings.Contains(string(evt.Metadata), `"p"`) {
+		t.Error("Audit metadata leaked the secret")
+	}
+
+	// Limit is per user: a second user is unaffected by the first exhausting it
+	svc.RevealCredentials(ctx, tenantID, camID, userID, "again")
+	if _, _, err := svc.RevealCredentials(ctx, tenantID, camID, userID, "third"); err != cameras.ErrRevealRateLimited {
+		t.Fatalf("Expected ErrRevealRateLimited, got %v", err)
+	}
+	if last := aud.Events[len(aud.Events)-1]; last.Result != "failure" || last.ReasonCode != "rate_limited" {
+		t.Errorf("Rate-limited reveal must be audited as a failure, got %s/%s", last.Result, last.ReasonCode)
+	}
+	if _, _, err := svc.RevealCredentials(ctx, tenantID, camID, uuid.New(), "other user"); err != nil {
+		t.Errorf("Other user should not be limited, got %v", err)
+	}
+
+	// Internal (media selection) path needs no justification
+	if out, _, err := svc.GetCredentials(ctx, tenantID, camID, true); err != nil || out.Data == nil {
+		t.Errorf("Internal reveal failed: %v", err)
+	}
+}
+
+func TestRevealCredentials_TruncatesOnRuneBoundary(t *testing.T) {
+	repo := &MockCredRepo{Store: make(map[string]*data.CameraCredential)}
+	aud := &MockCredAuditor{}
+	key, _ := crypto.GenerateDEK()
+	t.Setenv("MASTER_KEYS", `[{"kid":"test-v1","material":"`+base64.StdEncoding.EncodeToString(key)+`"}]`)
+	t.Setenv("ACTIVE_MASTER_KID", "test-v1")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+	svc := cameras.NewCredentialService(repo, kr, aud)
+
+	ctx := context.Background()
+	tenantID, camID := uuid.New(), uuid.New()
+	svc.SetCredentials(ctx, tenantID, camID, cameras.CredentialInput{Username: "u", Password: "p"})
+	aud.Events = nil
+
+	// "é" is two bytes, so byte 500 falls inside a rune
+	long := "x" + strings.Repeat("é", cameras.MaxRevealJustification)
+	if _, _, err := svc.RevealCredentials(ctx, tenantID, camID, uuid.New(), long); err != nil {
+		t.Fatalf("Reveal failed: %v", err)
+	}
+	var meta map[string]string
+	json.Unmarshal(aud.Events[0].Metadata, &meta)
+	got := meta["justification"]
+	if !utf8.ValidString(got) || len(got) > cameras.MaxRevealJustification || !strings.HasPrefix(long, got) {
+		t.Errorf("Expected a valid UTF-8 prefix of at most %d bytes, got %d bytes", cameras.MaxRevealJustification, len(got))
+	}
+}
+
+func TestRevealCredentials_ForgetsExpiredUsers(t *testing.T) {
+	repo := &MockCredRepo{Store: make(map[string]*data.CameraCredential)}
+	key, _ := crypto.GenerateDEK()
+	t.Setenv("MASTER_KEYS", `[{"kid":"test-v1","material":"`+base64.StdEncoding.EncodeToString(key)+`"}]`)
+	t.Setenv("ACTIVE_MASTER_KID", "test-v1")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+	svc := cameras.NewCredentialService(repo, kr, &MockCredAuditor{})
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	svc.Clock = clk
+	svc.SetRevealLimit(1, time.Hour)
+
+	ctx := context.Background()
+	tenantID, camID, userID := uuid.New(), uuid.New(), uuid.New()
+	svc.SetCredentials(ctx, tenantID, camID, cameras.CredentialInput{Username: "u", Password: "p"})
+
+	for i := 0; i < 3; i++ {
+		svc.RevealCredentials(ctx, tenantID, camID, uuid.New(), "one-off")
+	}
+	svc.RevealCredentials(ctx, tenantID, camID, userID, "ticket-1")
+	if n := svc.RevealUsers(); n != 4 {
+		t.Fatalf("Expected 4 tracked users, got %d", n)
+	}
+
+	clk.Advance(time.Hour + time.Second)
+	if n := svc.RevealUsers(); n != 0 {
+		t.Errorf("Expected users outside the window to be forgotten, got %d", n)
+	}
+	if _, _, err := svc.RevealCredentials(ctx, tenantID, camID, userID, "ticket-2"); err != nil {
+		t.Errorf("Expected the window to have reset, got %v", err)
+	}
+}
+
+func TestCredentialInventory_FlagsDeprecatedKeyWithoutSecrets(t *testing.T) {
+	repo := &MockCredRepo{Store: make(map[string]*data.CameraCredential)}
+	aud := &MockCredAuditor{}
+	oldKey, _ := crypto.GenerateDEK()
+	newKey, _ := crypto.GenerateDEK()
+	keys := `[{"kid":"test-v1","material":"` + base64.StdEncoding.EncodeToString(oldKey) + `"},` +
+		`{"kid":"test-v2","material":"` + base64.StdEncoding.EncodeToString(newKey) + `"}]`
+
+	tenantID := uuid.New()
+	oldCam, newCam := uuid.New(), uuid.New()
+
+	// Store one credential under v1, then rotate the active key to v2
+	t.Setenv("MASTER_KEYS", keys)
+	t.Setenv("ACTIVE_MASTER_KID", "test-v1")
+	krOld := crypto.NewKeyring()
+	krOld.LoadFromEnv()
+	cameras.NewCredentialService(repo, krOld, aud).SetCredentials(context.Background(), tenantID, oldCam,
+		cameras.CredentialInput{Username: "olduser", Password: "oldSecretPassword"})
+
+	t.Setenv("ACTIVE_MASTER_KID", "test-v2")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+	svc := cameras.NewCredentialService(repo, kr, aud)
+	svc.SetCredentials(context.Background(), tenantID, newCam,
+		cameras.CredentialInput{Username: "newuser", Password: "newSecretPassword"})
+
+	items, err := svc.Inventory(context.Background(), tenantID)
+	if err != nil {
+		t.Fatalf("Inventory failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	for _, it := range items {
+		if !it.HasCredentials {
+			t.Errorf("Camera %s should report credentials", it.CameraID)
+		}
+		switch it.CameraID {
+		case oldCam:
+			if !it.DeprecatedKey || it.MasterKID != "test-v1" {
+				t.Errorf("Expected v1 credential flagged deprecated, got %+v", it)
+			}
+		case newCam:
+			if it.DeprecatedKey {
+				t.Errorf("Active-key credential must not be flagged, got %+v", it)
+			}
+		}
+	}
+
+	raw, _ := json.Marshal(items)
+	body := string(raw)
+	for _, secret := range []string{"olduser", "newuser", "oldSecretPassword", "newSecretPassword"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("Inventory leaked secret %q", secret)
+		}
+	}
+	for _, c := range repo.Store {
+		if strings.Contains(body, base64.StdEncoding.EncodeToString(c.DataCiphertext)) {
+			t.Error("Inventory leaked ciphertext")
+		}
+	}
+
+	last := aud.Events[len(aud.Events)-1]
+	if last.Action != "camera.credential.inventory" {
+		t.Errorf("Expected camera.credential.inventory audit, got %s", last.Action)
+	}
+}
+
+func TestDeleteSiteCredentials_OnlyTargetSite(t *testing.T) {
+	key, _ := crypto.GenerateDEK()
+	t.Setenv("MASTER_KEYS", `[{"kid":"test-v1","material":"`+base64.StdEncoding.EncodeToString(key)+`"}]`)
+	t.Setenv("ACTIVE_MASTER_KID", "test-v1")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+
+	repo := &MockCredRepo{Store: make(map[string]*data.CameraCredential), Sites: map[uuid.UUID]uuid.UUID{}}
+	aud := &MockCredAuditor{}
+	svc := cameras.NewCredentialService(repo, kr, aud)
+
+	tenantID, otherTenant := uuid.New(), uuid.New()
+	site, otherSite := uuid.New(), uuid.New()
+	input := cameras.CredentialInput{Username: "admin", Password: "pw"}
+	add := func(tid, sid uuid.UUID) uuid.UUID {
+		camID := uuid.New()
+		repo.Sites[camID] = sid
+		if err := svc.SetCredentials(context.Background(), tid, camID, input); err != nil {
+			t.Fatalf("SetCredentials failed: %v", err)
+		}
+		return camID
+	}
+	add(tenantID, site)
+	add(tenantID, site)
+	keepOtherSite := add(tenantID, otherSite)
+	keepForeign := add(otherTenant, site) // same site ID under another tenant
+
+	n, err := svc.DeleteSiteCredentials(context.Background(), tenantID, site)
+	if err != nil {
+		t.Fatalf("DeleteSiteCredentials failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 credentials deleted, got %d", n)
+	}
+	if len(repo.Store) != 2 || repo.Store[keepOtherSite.String()] == nil || repo.Store[keepForeign.String()] == nil {
+		t.Errorf("Only target site credentials should be deleted, remaining: %v", repo.Store)
+	}
+
+	last := aud.Events[len(aud.Events)-1]
+	if last.Action != "camera.credential.bulk_delete" || last.TargetID != site.String() {
+		t.Errorf("Expected one bulk_delete audit for the site, got %+v", last)
+	}
+	var meta map[string]any
+	json.Unmarshal(last.Metadata, &meta)
+	if meta["count"] != float64(2) {
+		t.Errorf("Expected audit count 2, got %v", meta["count"])
+	}
+}
+
 // Minimal Mock Auditor (if not shared)
 type MockCredAuditor struct {
 	Events []audit.AuditEvent
@@ -130,3 +410,78 @@ func (m *MockCredAuditor) WriteEvent(ctx context.Context, evt audit.AuditEvent)
 	m.Events = append(m.Events, evt)
 	return nil
 }
+
+func TestRekeyCredentials(t *testing.T) {
+	repo := &MockCredRepo{Store: make(map[string]*data.CameraCredential)}
+	aud := &MockCredAuditor{}
+	oldKey, _ := crypto.GenerateDEK()
+	newKey, _ := crypto.GenerateDEK()
+	keys := `[{"kid":"test-v1","material":"` + base64.StdEncoding.EncodeToString(oldKey) + `"},` +
+		`{"kid":"test-v2","material":"` + base64.StdEncoding.EncodeToString(newKey) + `"}]`
+	tenantID, camID := uuid.New(), uuid.New()
+	ctx := context.Background()
+
+	t.Setenv("MASTER_KEYS", keys)
+	t.Setenv("ACTIVE_MASTER_KID", "test-v1")
+	krOld := crypto.NewKeyring()
+	krOld.LoadFromEnv()
+	cameras.NewCredentialService(repo, krOld, aud).SetCredentials(ctx, tenantID, camID,
+		cameras.CredentialInput{Username: "admin", Password: "rotateMe"})
+	before := *repo.Store[camID.String()]
+
+	t.Setenv("ACTIVE_MASTER_KID", "test-v2")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+	svc := cameras.NewCredentialService(repo, kr, aud)
+
+	res, err := svc.RekeyCredentials(ctx, tenantID, camID)
+	if err != nil {
+		t.Fatalf("RekeyCredentials failed: %v", err)
+	}
+	if !res.Rekeyed || res.PreviousKID != "test-v1" || res.MasterKID != "test-v2" {
+		t.Errorf("Unexpected result %+v", res)
+	}
+
+	after := repo.Store[camID.String()]
+	if after.MasterKID != "test-v2" {
+		t.Errorf("Expected credentials wrapped under test-v2, got %s", after.MasterKID)
+	}
+	if string(after.DataCiphertext) != string(before.DataCiphertext) || string(after.DataNonce) != string(before.DataNonce) {
+		t.Error("Rekey must not re-encrypt the credential data")
+	}
+	out, _, err := svc.GetCredentials(ctx, tenantID, camID, true)
+	if err != nil || out.Data.Username != "admin" || out.Data.Password != "rotateMe" {
+		t.Fatalf("Expected plaintext unchanged after rekey, got %+v (%v)", out, err)
+	}
+
+	var rekeyEvt *audit.AuditEvent
+	for i := range aud.Events {
+		if aud.Events[i].Action == "credential.rekey" {
+			rekeyEvt = &aud.Events[i]
+		}
+	}
+	if rekeyEvt == nil || rekeyEvt.TargetID != camID.String() {
+		t.Fatalf("Expected credential.rekey audit event, got %+v", aud.Events)
+	}
+	if meta := string(rekeyEvt.Metadata); !strings.Contains(meta, "test-v1") || strings.Contains(meta, "rotateMe") {
+		t.Errorf("Unexpected rekey metadata %s", meta)
+	}
+
+	// Already on the active kid: nothing is written
+	current := *repo.Store[camID.String()]
+	res, err = svc.RekeyCredentials(ctx, tenantID, camID)
+	if err != nil {
+		t.Fatalf("RekeyCredentials (no-op) failed: %v", err)
+	}
+	if res.Rekeyed || res.MasterKID != "test-v2" {
+		t.Errorf("Expected no-op on the active kid, got %+v", res)
+	}
+	if string(repo.Store[camID.String()].DEKCiphertext) != string(current.DEKCiphertext) {
+		t.Error("No-op rekey must not rewrap the DEK")
+	}
+
+	// Other tenants cannot rekey
+	if _, err := svc.RekeyCredentials(ctx, uuid.New(), camID); !errors.Is(err, data.ErrCredentialNotFound) {
+		t.Errorf("Expected ErrCredentialNotFound for a foreign tenant, got %v", err)
+	}
+}
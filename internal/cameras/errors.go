@@ -2,6 +2,8 @@ package cameras
 
 import (
 	"fmt"
+
+	"github.com/google/uuid"
 )
 
 // SfuStepError wraps an error with a specific step and error code.
@@ -48,3 +50,30 @@ func NewSfuErrorWithFallback(step, code, msg, url string, err error) *SfuStepErr
 		Err:          err,
 	}
 }
+
+// ValidationError carries per-field input errors (field -> message).
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %v", e.Fields)
+}
+
+// QuotaError reports a bulk enable that would exceed the enabled-cameras
+// limit. Rejected are the requested cameras beyond what the limit leaves
+// room for. It matches ErrLicenseLimitExceeded with errors.Is.
+type QuotaError struct {
+	Limit     int
+	Enabled   int // enabled before the request
+	Requested int // requested cameras that were disabled
+	Rejected  []uuid.UUID
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %d enabled + %d requested > limit %d", ErrLicenseLimitExceeded, e.Enabled, e.Requested, e.Limit)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrLicenseLimitExceeded
+}
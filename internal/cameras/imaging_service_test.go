@@ -0,0 +1,114 @@
+package cameras
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/discovery"
+)
+
+type MockImagingClient struct {
+	ImagingXAddr string
+	Settings     *discovery.ImagingSettings
+	SetCalls     int
+}
+
+func (m *MockImagingClient) GetCapabilities(ctx context.Context) (map[string]bool, string, error) {
+	return map[string]bool{"Media": true}, "http://mock/media", nil
+}
+func (m *MockImagingClient) GetProfiles(ctx context.Context, mediaURI string) ([]discovery.MediaProfile, error) {
+	p := discovery.MediaProfile{Token: "t1"}
+	p.VideoSourceConfiguration.SourceToken = "vs1"
+	return []discovery.MediaProfile{p}, nil
+}
+func (m *MockImagingClient) GetImagingXAddr(ctx context.Context) (string, error) {
+	if m.ImagingXAddr == "" {
+		return "", discovery.ErrImagingNotSupported
+	}
+	return m.ImagingXAddr, nil
+}
+func (m *MockImagingClient) GetImagingSettings(ctx context.Context, imagingURI, sourceToken string) (*discovery.ImagingSettings, error) {
+	return m.Settings, nil
+}
+func (m *MockImagingClient) SetImagingSettings(ctx context.Context, imagingURI, sourceToken string, s *discovery.ImagingSettings) error {
+	m.SetCalls++
+	return nil
+}
+
+func newImagingTestService(client *MockImagingClient, tenantID uuid.UUID) *ImagingService {
+	camRepo := &MockCameraRepo{GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+		return &data.Camera{ID: id, TenantID: tenantID, IPAddress: net.ParseIP("192.168.1.100")}, nil
+	}}
+	svc := NewImagingService(camRepo, &MockCredentialProvider{}, &MockAuditor{})
+	svc.ClientFactory = func(x, u, p string) (ImagingClient, error) { return client, nil }
+	return svc
+}
+
+func TestValidateImagingSettings_Ranges(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	if err := ValidateImagingSettings(&discovery.ImagingSettings{Brightness: f(0), Contrast: f(100), FocusMode: "AUTO"}); err != nil {
+		t.Errorf("Expected boundary values to pass, got %v", err)
+	}
+
+	err := ValidateImagingSettings(&discovery.ImagingSettings{Brightness: f(-1), Sharpness: f(101), FocusMode: "SEMI"})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	for _, field := range []string{"brightness", "sharpness", "focus_mode"} {
+		if vErr.Fields[field] == "" {
+			t.Errorf("Expected field error for %s", field)
+		}
+	}
+	if _, ok := vErr.Fields["contrast"]; ok {
+		t.Error("Unset contrast should not be flagged")
+	}
+}
+
+func TestImagingService_SetValidatesBeforeDeviceCall(t *testing.T) {
+	tenantID := uuid.New()
+	client := &MockImagingClient{ImagingXAddr: "http://mock/imaging"}
+	svc := newImagingTestService(client, tenantID)
+
+	bad := 150.0
+	err := svc.SetImaging(context.Background(), tenantID, uuid.New(), &discovery.ImagingSettings{Brightness: &bad})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	if client.SetCalls != 0 {
+		t.Error("Invalid settings must not reach the device")
+	}
+
+	ok := 40.0
+	if err := svc.SetImaging(context.Background(), tenantID, uuid.New(), &discovery.ImagingSettings{Brightness: &ok}); err != nil {
+		t.Fatalf("SetImaging failed: %v", err)
+	}
+	if client.SetCalls != 1 {
+		t.Errorf("Expected 1 device call, got %d", client.SetCalls)
+	}
+}
+
+func TestImagingService_NoImagingService(t *testing.T) {
+	tenantID := uuid.New()
+	svc := newImagingTestService(&MockImagingClient{}, tenantID)
+
+	_, err := svc.GetImaging(context.Background(), tenantID, uuid.New())
+	if !errors.Is(err, ErrImagingNotSupported) {
+		t.Errorf("Expected ErrImagingNotSupported, got %v", err)
+	}
+}
+
+func TestImagingService_CrossTenantNotFound(t *testing.T) {
+	svc := newImagingTestService(&MockImagingClient{ImagingXAddr: "http://mock/imaging"}, uuid.New())
+
+	_, err := svc.GetImaging(context.Background(), uuid.New(), uuid.New())
+	if !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+}
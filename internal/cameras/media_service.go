@@ -3,14 +3,19 @@ package cameras
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/discovery"
 	"github.com/technosupport/ts-vms/internal/media"
+	"github.com/technosupport/ts-vms/internal/nvr/adapters"
 )
 
 type MediaRepository interface {
@@ -19,6 +24,9 @@ type MediaRepository interface {
 	GetSelection(ctx context.Context, cameraID uuid.UUID) (*data.CameraStreamSelection, error)
 	GetValidationResults(ctx context.Context, cameraID uuid.UUID) ([]*data.RTSPValidationResult, error)
 	UpsertValidationResult(ctx context.Context, res *data.RTSPValidationResult) error
+	AppendValidationHistory(ctx context.Context, res *data.RTSPValidationResult) error
+	ListValidationHistory(ctx context.Context, cameraID uuid.UUID, limit int) ([]*data.RTSPValidationResult, error)
+	PruneValidationHistory(ctx context.Context, cameraID uuid.UUID, variant string, keep int, before time.Time) (int, error)
 	ListProfiles(ctx context.Context, cameraID uuid.UUID) ([]*data.CameraMediaProfile, error)
 }
 
@@ -34,42 +42,83 @@ type OnvifClient interface {
 
 type OnvifClientFactory func(xaddr, username, password string) (OnvifClient, error)
 
+// ValidationHistoryRetention bounds the validation timeline kept per camera variant.
+type ValidationHistoryRetention struct {
+	MaxEntries int
+	MaxAge     time.Duration
+}
+
+var DefaultValidationHistoryRetention = ValidationHistoryRetention{
+	MaxEntries: 200,
+	MaxAge:     30 * 24 * time.Hour,
+}
+
+// MaxValidationHistoryPage caps a single validation-history read.
+const MaxValidationHistoryPage = 200
+
 type MediaService struct {
-	MediaRepo     MediaRepository
-	CameraRepo    Repository
-	CredService   CredentialProvider
-	Validator     *media.Validator
-	Auditor       Auditor
-	ClientFactory OnvifClientFactory
+	MediaRepo        MediaRepository
+	CameraRepo       Repository
+	CredService      CredentialProvider
+	Validator        *media.Validator
+	Auditor          Auditor
+	ClientFactory    OnvifClientFactory
+	HistoryRetention ValidationHistoryRetention
+	// RTSPHosts rejects stream URIs pointing away from the camera.
+	RTSPHosts RTSPHostPolicy
 }
 
-func NewMediaService(mRepo MediaRepository, cRepo Repository, credSvc CredentialProvider, aud Auditor) *MediaService {
+// NewMediaService wires the service and starts its RTSP validation pool,
+// sized by vcfg (zero fields use the media package defaults).
+func NewMediaService(mRepo MediaRepository, cRepo Repository, credSvc CredentialProvider, aud Auditor, vcfg media.ValidatorConfig) *MediaService {
+	s := &MediaService{
+		MediaRepo:        mRepo,
+		CameraRepo:       cRepo,
+		CredService:      credSvc,
+		Auditor:          aud,
+		HistoryRetention: DefaultValidationHistoryRetention,
+		ClientFactory: func(x, u, p string) (OnvifClient, error) {
+			return discovery.NewOnvifClient(x, u, p)
+		},
+	}
+
 	// Initialize Validator with persistence callback
-	validator := media.NewValidator(func(job media.ValidationJob, res media.ValidationResult) {
+	s.Validator = media.NewValidator(vcfg, func(job media.ValidationJob, res media.ValidationResult) {
 		// Async Callback: Persist Result
-		ctx := context.Background() // TODO: Context with timeout?
-		dbRes := &data.RTSPValidationResult{
-			TenantID:      job.TenantID,
-			CameraID:      job.CameraID,
-			Variant:       job.Variant,
-			Status:        string(res.Status),
-			LastErrorCode: res.LastErrorCode,
-			RTT:           res.RTT,
-		}
-		// Note: We ignore error in async callback, or log it
-		mRepo.UpsertValidationResult(ctx, dbRes)
+		s.recordValidation(context.Background(), job, res) // TODO: Context with timeout?
 	})
 
-	return &MediaService{
-		MediaRepo:   mRepo,
-		CameraRepo:  cRepo,
-		CredService: credSvc,
-		Validator:   validator,
-		Auditor:     aud,
-		ClientFactory: func(x, u, p string) (OnvifClient, error) {
-			return discovery.NewOnvifClient(x, u, p)
-		},
+	return s
+}
+
+// recordValidation updates the current result, appends to the timeline and
+// applies history retention. Errors are ignored (async callback).
+func (s *MediaService) recordValidation(ctx context.Context, job media.ValidationJob, res media.ValidationResult) {
+	dbRes := &data.RTSPValidationResult{
+		TenantID:      job.TenantID,
+		CameraID:      job.CameraID,
+		Variant:       job.Variant,
+		Status:        string(res.Status),
+		LastErrorCode: res.LastErrorCode,
+		RTT:           res.RTT,
 	}
+	s.MediaRepo.UpsertValidationResult(ctx, dbRes)
+
+	entry := *dbRes
+	entry.ID = uuid.Nil
+	if err := s.MediaRepo.AppendValidationHistory(ctx, &entry); err != nil {
+		return
+	}
+
+	keep := s.HistoryRetention.MaxEntries
+	if keep <= 0 {
+		keep = DefaultValidationHistoryRetention.MaxEntries
+	}
+	maxAge := s.HistoryRetention.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultValidationHistoryRetention.MaxAge
+	}
+	s.MediaRepo.PruneValidationHistory(ctx, job.CameraID, job.Variant, keep, time.Now().Add(-maxAge))
 }
 
 // SelectMediaProfiles Orchestrates Sync -> Select -> Store -> Validate
@@ -123,12 +172,20 @@ func (s *MediaService) SelectMediaProfiles(ctx context.Context, tenantID, camera
 
 	// 3. Normalize & Store
 	var domainProfiles []media.Profile
+	var stored []*data.CameraMediaProfile
+	var hostErr error
 	for _, op := range onvifProfiles {
 		// Get Stream URI for each
 		uri, err := client.GetStreamUri(ctx, mediaURI, op.Token)
 		if err != nil {
 			continue // Skip broken profiles
 		}
+		// The camera chose this URI; never ingest from a host it points us at.
+		if err := s.RTSPHosts.Check(cam, uri); err != nil {
+			log.Printf("[WARN] media select: camera=%s profile=%s rejected: %v", cameraID, op.Token, err)
+			hostErr = err
+			continue
+		}
 
 		// Sanitize
 		sanitizedURI := media.SanitizeRTSPURL(uri)
@@ -173,6 +230,11 @@ func (s *MediaService) SelectMediaProfiles(ctx context.Context, tenantID, camera
 			RTSPURLSanitized: sanitizedURI,
 		}
 		s.MediaRepo.UpsertProfile(ctx, dbP)
+		stored = append(stored, dbP)
+	}
+
+	if len(domainProfiles) == 0 && hostErr != nil {
+		return nil, hostErr
 	}
 
 	// 4. Run Selection
@@ -200,6 +262,7 @@ func (s *MediaService) SelectMediaProfiles(ctx context.Context, tenantID, camera
 		RTSPURL:  selRes.MainRTSP, // Sanitized
 		Username: user,
 		Password: pass,
+		Expected: storedStream(stored, selRes.MainToken),
 	})
 
 	if !selRes.SubIsSameAsMain {
@@ -210,6 +273,7 @@ func (s *MediaService) SelectMediaProfiles(ctx context.Context, tenantID, camera
 			RTSPURL:  selRes.SubRTSP,
 			Username: user,
 			Password: pass,
+			Expected: storedStream(stored, selRes.SubToken),
 		})
 	}
 
@@ -244,6 +308,22 @@ func (s *MediaService) GetSelection(ctx context.Context, cameraID uuid.UUID) (*d
 	return sel, val, err
 }
 
+// GetValidationHistory returns the validation timeline for a camera, newest first.
+func (s *MediaService) GetValidationHistory(ctx context.Context, tenantID, cameraID uuid.UUID, limit int) ([]*data.RTSPValidationResult, error) {
+	cam, err := s.CameraRepo.GetByID(ctx, cameraID)
+	if err != nil {
+		return nil, err
+	}
+	if cam == nil || cam.TenantID != tenantID {
+		return nil, data.ErrRecordNotFound // Non-enumeration
+	}
+
+	if limit <= 0 || limit > MaxValidationHistoryPage {
+		limit = MaxValidationHistoryPage
+	}
+	return s.MediaRepo.ListValidationHistory(ctx, cameraID, limit)
+}
+
 func (s *MediaService) ValidateRTSP(ctx context.Context, tenantID, cameraID uuid.UUID) error {
 	// Re-run validation for current selection
 	sel, err := s.MediaRepo.GetSelection(ctx, cameraID)
@@ -267,6 +347,7 @@ func (s *MediaService) ValidateRTSP(ctx context.Context, tenantID, cameraID uuid
 		user = out.Data.Username
 		pass = out.Data.Password
 	}
+	stored, _ := s.MediaRepo.ListProfiles(ctx, cameraID)
 
 	s.Validator.Enqueue(media.ValidationJob{
 		TenantID: tenantID,
@@ -275,6 +356,7 @@ func (s *MediaService) ValidateRTSP(ctx context.Context, tenantID, cameraID uuid
 		RTSPURL:  sel.MainRTSP,
 		Username: user,
 		Password: pass,
+		Expected: storedStream(stored, sel.MainProfileToken),
 	})
 
 	if !sel.SubIsSameAsMain {
@@ -285,6 +367,7 @@ func (s *MediaService) ValidateRTSP(ctx context.Context, tenantID, cameraID uuid
 			RTSPURL:  sel.SubRTSP,
 			Username: user,
 			Password: pass,
+			Expected: storedStream(stored, sel.SubProfileToken),
 		})
 	}
 
@@ -300,6 +383,80 @@ func (s *MediaService) ValidateRTSP(ctx context.Context, tenantID, cameraID uuid
 	return nil
 }
 
+// storedStream is the stored codec/resolution of the profile with token, for
+// the validator's DESCRIBE probe; nil when the profile is not stored.
+func storedStream(profiles []*data.CameraMediaProfile, token string) *adapters.StreamInfo {
+	for _, p := range profiles {
+		if p.ProfileToken != token {
+			continue
+		}
+		info := &adapters.StreamInfo{Width: p.Width, Height: p.Height}
+		if p.VideoCodec != string(media.CodecUnknown) {
+			info.Codec = p.VideoCodec
+		}
+		return info
+	}
+	return nil
+}
+
+// ErrUnknownStreamVariant is returned for a variant other than "main" or "sub".
+var ErrUnknownStreamVariant = errors.New("unknown stream variant")
+
+// ResolveRTSPURL is the canonical credential-injected RTSP URL for a camera's
+// selected stream. variant is "main" (default) or "sub"; sub falls back to main
+// when the camera exposes a single stream. The result carries the plaintext
+// password and must never be logged or persisted.
+func (s *MediaService) ResolveRTSPURL(ctx context.Context, cameraID uuid.UUID, variant string) (string, error) {
+	cam, err := s.CameraRepo.GetByID(ctx, cameraID)
+	if err != nil {
+		return "", err
+	}
+	if cam == nil || cam.DeletedAt != nil {
+		return "", data.ErrRecordNotFound
+	}
+
+	sel, err := s.MediaRepo.GetSelection(ctx, cameraID)
+	if err != nil {
+		return "", err
+	}
+	if sel == nil {
+		return "", data.ErrRecordNotFound
+	}
+
+	raw := sel.MainRTSP
+	switch variant {
+	case "", "main":
+	case "sub":
+		if !sel.SubIsSameAsMain && sel.SubRTSP != "" {
+			raw = sel.SubRTSP
+		}
+	default:
+		return "", ErrUnknownStreamVariant
+	}
+	if raw == "" {
+		return "", data.ErrRecordNotFound
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		// url errors echo the input; keep it out of the message.
+		return "", fmt.Errorf("stored rtsp url for camera %s is malformed", cameraID)
+	}
+	if err := s.RTSPHosts.Check(cam, raw); err != nil {
+		return "", err
+	}
+
+	out, found, err := s.CredService.GetCredentials(ctx, cam.TenantID, cameraID, true)
+	if err != nil {
+		return "", err
+	}
+	u.User = nil
+	if found && out.Data != nil {
+		u.User = url.UserPassword(out.Data.Username, out.Data.Password)
+	}
+	return u.String(), nil
+}
+
 // Helper
 func getHostFromURL(raw string) string {
 	// Parse URL
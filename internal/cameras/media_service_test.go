@@ -1,13 +1,20 @@
 package cameras
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"net"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/discovery"
+	"github.com/technosupport/ts-vms/internal/media"
 )
 
 // MockOnvifClient
@@ -34,7 +41,7 @@ func TestSelectMediaProfiles_Orchestration(t *testing.T) {
 	mockAuditor := &MockAuditor{}
 
 	// SUT
-	svc := NewMediaService(mockMediaRepo, mockCamRepo, mockCreds, mockAuditor)
+	svc := NewMediaService(mockMediaRepo, mockCamRepo, mockCreds, mockAuditor, media.ValidatorConfig{})
 
 	// Inject Mock Factory
 	svc.ClientFactory = func(x, u, p string) (OnvifClient, error) {
@@ -61,7 +68,7 @@ func TestSelectMediaProfiles_Orchestration(t *testing.T) {
 					Height int
 				}{640, 360}}},
 			},
-			StreamURI: "rtsp://camera",
+			StreamURI: "rtsp://192.168.1.100:554",
 		}, nil
 	}
 
@@ -116,3 +123,231 @@ func TestSelectMediaProfiles_Orchestration(t *testing.T) {
 		t.Error("Expected audit event")
 	}
 }
+
+// historyStore is an in-memory validation timeline wired into MockMediaRepo
+type historyStore struct {
+	entries []*data.RTSPValidationResult
+	clock   time.Time
+}
+
+func (h *historyStore) wire(m *MockMediaRepo) {
+	m.AppendHistoryFunc = func(ctx context.Context, res *data.RTSPValidationResult) error {
+		h.clock = h.clock.Add(time.Second)
+		res.ID = uuid.New()
+		res.ValidatedAt = h.clock
+		h.entries = append(h.entries, res)
+		return nil
+	}
+	m.ListHistoryFunc = func(ctx context.Context, cameraID uuid.UUID, limit int) ([]*data.RTSPValidationResult, error) {
+		var out []*data.RTSPValidationResult
+		for _, e := range h.entries {
+			if e.CameraID == cameraID {
+				out = append(out, e)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].ValidatedAt.After(out[j].ValidatedAt) })
+		if len(out) > limit {
+			out = out[:limit]
+		}
+		return out, nil
+	}
+	m.PruneHistoryFunc = func(ctx context.Context, cameraID uuid.UUID, variant string, keep int, before time.Time) (int, error) {
+		var kept, scoped []*data.RTSPValidationResult
+		for _, e := range h.entries {
+			if e.CameraID == cameraID && e.Variant == variant {
+				scoped = append(scoped, e)
+			} else {
+				kept = append(kept, e)
+			}
+		}
+		sort.Slice(scoped, func(i, j int) bool { return scoped[i].ValidatedAt.After(scoped[j].ValidatedAt) })
+		removed := 0
+		for i, e := range scoped {
+			if i >= keep || e.ValidatedAt.Before(before) {
+				removed++
+				continue
+			}
+			kept = append(kept, e)
+		}
+		h.entries = kept
+		return removed, nil
+	}
+}
+
+func TestRecordValidation_AccumulatesHistory(t *testing.T) {
+	mediaRepo := &MockMediaRepo{}
+	store := &historyStore{clock: time.Now()}
+	store.wire(mediaRepo)
+	upserts := 0
+	mediaRepo.UpsertValidationResultFunc = func(ctx context.Context, res *data.RTSPValidationResult) error {
+		upserts++
+		return nil
+	}
+
+	tenantID, cameraID := uuid.New(), uuid.New()
+	camRepo := &MockCameraRepo{GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+		return &data.Camera{ID: id, TenantID: tenantID}, nil
+	}}
+	svc := NewMediaService(mediaRepo, camRepo, &MockCredentialProvider{}, &MockAuditor{}, media.ValidatorConfig{})
+
+	job := media.ValidationJob{TenantID: tenantID, CameraID: cameraID, Variant: "main"}
+	svc.recordValidation(context.Background(), job, media.ValidationResult{Status: media.StatusValid})
+	svc.recordValidation(context.Background(), job, media.ValidationResult{Status: media.StatusTimeout, LastErrorCode: "timeout"})
+	svc.recordValidation(context.Background(), job, media.ValidationResult{Status: media.StatusValid})
+
+	if upserts != 3 {
+		t.Errorf("Expected current result upserted 3 times, got %d", upserts)
+	}
+
+	history, err := svc.GetValidationHistory(context.Background(), tenantID, cameraID, 10)
+	if err != nil {
+		t.Fatalf("GetValidationHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 history entries, got %d", len(history))
+	}
+	if history[1].Status != string(media.StatusTimeout) || history[1].LastErrorCode != "timeout" {
+		t.Errorf("Expected timeout entry in the middle of the timeline, got %+v", history[1])
+	}
+}
+
+func TestRecordValidation_RetentionTrimsOldEntries(t *testing.T) {
+	mediaRepo := &MockMediaRepo{}
+	store := &historyStore{clock: time.Now()}
+	store.wire(mediaRepo)
+
+	cameraID := uuid.New()
+	stale := &data.RTSPValidationResult{
+		ID: uuid.New(), CameraID: cameraID, Variant: "main", Status: "valid",
+		ValidatedAt: time.Now().Add(-48 * time.Hour),
+	}
+	store.entries = append(store.entries, stale)
+
+	svc := NewMediaService(mediaRepo, &MockCameraRepo{}, &MockCredentialProvider{}, &MockAuditor{}, media.ValidatorConfig{})
+	svc.HistoryRetention = ValidationHistoryRetention{MaxEntries: 3, MaxAge: 24 * time.Hour}
+
+	job := media.ValidationJob{TenantID: uuid.New(), CameraID: cameraID, Variant: "main"}
+	for i := 0; i < 5; i++ {
+		svc.recordValidation(context.Background(), job, media.ValidationResult{Status: media.StatusValid})
+	}
+
+	if len(store.entries) != 3 {
+		t.Fatalf("Expected history trimmed to 3 entries, got %d", len(store.entries))
+	}
+	for _, e := range store.entries {
+		if e.ID == stale.ID {
+			t.Error("Entry older than MaxAge should have been pruned")
+		}
+	}
+}
+
+func TestGetValidationHistory_ForeignTenant(t *testing.T) {
+	camRepo := &MockCameraRepo{GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+		return &data.Camera{ID: id, TenantID: uuid.New()}, nil
+	}}
+	svc := NewMediaService(&MockMediaRepo{}, camRepo, &MockCredentialProvider{}, &MockAuditor{}, media.ValidatorConfig{})
+
+	_, err := svc.GetValidationHistory(context.Background(), uuid.New(), uuid.New(), 10)
+	if !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for foreign camera, got %v", err)
+	}
+}
+
+// rtspDescribeServer answers OPTIONS and DESCRIBE (with sdp) on one connection.
+func rtspDescribeServer(t *testing.T, sdp string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			reqLine, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cseq := ""
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line = strings.TrimSpace(line); line == "" {
+					break
+				}
+				if v, ok := strings.CutPrefix(line, "CSeq: "); ok {
+					cseq = v
+				}
+			}
+			if strings.HasPrefix(reqLine, "DESCRIBE") {
+				fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nContent-Type: application/sdp\r\nContent-Length: %d\r\n\r\n%s", cseq, len(sdp), sdp)
+				continue
+			}
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\n\r\n", cseq)
+		}
+	}()
+	return "rtsp://" + ln.Addr().String() + "/stream1"
+}
+
+func TestValidateRTSP_DescribeProbeComparesStoredProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		sdp      string
+		wantStat media.ValidationStatus
+		wantCode string
+	}{
+		{
+			name:     "matches",
+			sdp:      "v=0\r\ns=Stream\r\nt=0 0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=framesize:96 1920-1080\r\n",
+			wantStat: media.StatusValid,
+		},
+		{
+			name:     "unexpected codec",
+			sdp:      "v=0\r\ns=Stream\r\nt=0 0\r\nm=video 0 RTP/AVP 98\r\na=rtpmap:98 H265/90000\r\na=framesize:98 2560-1440\r\n",
+			wantStat: media.StatusProfileMismatch,
+			wantCode: "codec,resolution",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID, cameraID := uuid.New(), uuid.New()
+			results := make(chan *data.RTSPValidationResult, 1)
+			mediaRepo := &MockMediaRepo{
+				GetSelectionFunc: func(ctx context.Context, id uuid.UUID) (*data.CameraStreamSelection, error) {
+					return &data.CameraStreamSelection{
+						TenantID: tenantID, CameraID: cameraID,
+						MainProfileToken: "t1", MainRTSP: rtspDescribeServer(t, tt.sdp), SubIsSameAsMain: true,
+					}, nil
+				},
+				ListProfilesFunc: func(ctx context.Context, id uuid.UUID) ([]*data.CameraMediaProfile, error) {
+					return []*data.CameraMediaProfile{{ProfileToken: "t1", VideoCodec: "H264", Width: 1920, Height: 1080}}, nil
+				},
+				UpsertValidationResultFunc: func(ctx context.Context, res *data.RTSPValidationResult) error {
+					results <- res
+					return nil
+				},
+			}
+			svc := NewMediaService(mediaRepo, &MockCameraRepo{}, &MockCredentialProvider{}, &MockAuditor{}, media.ValidatorConfig{DescribeProbe: true})
+
+			if err := svc.ValidateRTSP(context.Background(), tenantID, cameraID); err != nil {
+				t.Fatalf("ValidateRTSP: %v", err)
+			}
+			select {
+			case res := <-results:
+				if res.Status != string(tt.wantStat) || res.LastErrorCode != tt.wantCode {
+					t.Errorf("got %s %q, want %s %q", res.Status, res.LastErrorCode, tt.wantStat, tt.wantCode)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no validation result recorded")
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package cameras
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/technosupport/ts-vms/internal/data"
+)
+
+// ErrRTSPHostMismatch means an RTSP URL points somewhere other than its camera.
+var ErrRTSPHostMismatch = errors.New("ERR_RTSP_HOST_MISMATCH")
+
+// RTSPHostPolicy guards ingest against RTSP URLs that a camera reported (ONVIF
+// GetStreamUri) aiming at another host, e.g. an internal service (SSRF). A URL
+// passes when its host is the camera's own IP or is on the allow-list
+// (cameras.rtsp_host_allowlist), for streams served by a proxy or recorder.
+// Hostnames are compared as written, never resolved. The zero value allows
+// only the camera itself.
+type RTSPHostPolicy struct {
+	hosts map[string]bool
+	nets  []*net.IPNet
+}
+
+// NewRTSPHostPolicy builds a policy from allow-list entries: hostnames, IPs or
+// CIDRs.
+func NewRTSPHostPolicy(allowed []string) (RTSPHostPolicy, error) {
+	p := RTSPHostPolicy{hosts: make(map[string]bool)}
+	for _, entry := range allowed {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			_, n, err := net.ParseCIDR(entry)
+			if err != nil {
+				return RTSPHostPolicy{}, fmt.Errorf("invalid rtsp host allow-list entry %q: %w", entry, err)
+			}
+			p.nets = append(p.nets, n)
+			continue
+		}
+		p.hosts[normalizeHost(entry)] = true
+	}
+	return p, nil
+}
+
+// Check returns ErrRTSPHostMismatch unless rawURL's host is cam's IP or allowed.
+func (p RTSPHostPolicy) Check(cam *data.Camera, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("%w: unparseable rtsp url", ErrRTSPHostMismatch)
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.Equal(cam.IPAddress) {
+			return nil
+		}
+		for _, n := range p.nets {
+			if n.Contains(ip) {
+				return nil
+			}
+		}
+	}
+	if p.hosts[normalizeHost(host)] {
+		return nil
+	}
+	return fmt.Errorf("%w: host %s is not camera %s", ErrRTSPHostMismatch, host, cam.IPAddress)
+}
+
+// normalizeHost lower-cases hostnames and canonicalizes IP literals so
+// "10.0.0.1" and "::ffff:10.0.0.1" match.
+func normalizeHost(h string) string {
+	if ip := net.ParseIP(h); ip != nil {
+		return ip.String()
+	}
+	return strings.ToLower(strings.TrimSuffix(h, "."))
+}
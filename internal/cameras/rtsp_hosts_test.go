@@ -0,0 +1,144 @@
+package cameras
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/discovery"
+	"github.com/technosupport/ts-vms/internal/media"
+)
+
+func TestRTSPHostPolicy_Check(t *testing.T) {
+	cam := &data.Camera{IPAddress: net.ParseIP("192.168.1.100")}
+	policy, err := NewRTSPHostPolicy([]string{"Proxy.Local", "10.20.0.0/16", "172.16.0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		url string
+		ok  bool
+	}{
+		{"rtsp://192.168.1.100:554/Streaming/Channels/101", true},
+		{"rtsp://user:pw@192.168.1.100/live", true},
+		{"rtsp://[::ffff:192.168.1.100]:554/live", true},
+		{"rtsp://proxy.local:8554/cam1", true},
+		{"rtsp://10.20.3.4/cam1", true},
+		{"rtsp://172.16.0.5/cam1", true},
+		{"rtsp://192.168.1.101:554/live", false},
+		{"rtsp://127.0.0.1:6379/", false},
+		{"rtsp://localhost/live", false},
+		{"rtsp://10.21.0.1/cam1", false},
+		{"rtsp:///no-host", false},
+	}
+	for _, tc := range cases {
+		err := policy.Check(cam, tc.url)
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected %v", tc.url, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrRTSPHostMismatch) {
+			t.Errorf("%s: expected ErrRTSPHostMismatch, got %v", tc.url, err)
+		}
+	}
+
+	// Zero value: the camera itself only.
+	if err := (RTSPHostPolicy{}).Check(cam, "rtsp://proxy.local/cam1"); !errors.Is(err, ErrRTSPHostMismatch) {
+		t.Errorf("Zero policy must reject other hosts, got %v", err)
+	}
+	if _, err := NewRTSPHostPolicy([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("Invalid CIDR must be rejected")
+	}
+}
+
+// perTokenOnvif returns a stream URI per profile token.
+type perTokenOnvif struct {
+	MockOnvifClient
+	uris map[string]string
+}
+
+func (m *perTokenOnvif) GetStreamUri(ctx context.Context, mediaURI, token string) (string, error) {
+	return m.uris[token], nil
+}
+
+var hostCheckTenant = uuid.New()
+
+func newHostCheckMediaService(t *testing.T, uris map[string]string) (*MediaService, *[]*data.CameraMediaProfile, *[]*data.CameraStreamSelection) {
+	t.Helper()
+	camRepo := &MockCameraRepo{GetByIDFunc: func(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+		return &data.Camera{ID: id, TenantID: hostCheckTenant, IPAddress: net.ParseIP("192.168.1.100")}, nil
+	}}
+	creds := &MockCredentialProvider{GetFunc: func(ctx context.Context, t, c uuid.UUID, r bool) (*CredentialOutput, bool, error) {
+		return &CredentialOutput{Exists: true, Data: &CredentialInput{Username: "admin", Password: "pw"}}, true, nil
+	}}
+	var profiles []*data.CameraMediaProfile
+	var selections []*data.CameraStreamSelection
+	mediaRepo := &MockMediaRepo{
+		UpsertProfileFunc: func(ctx context.Context, p *data.CameraMediaProfile) error {
+			profiles = append(profiles, p)
+			return nil
+		},
+		UpsertSelectionFunc: func(ctx context.Context, s *data.CameraStreamSelection) error {
+			selections = append(selections, s)
+			return nil
+		},
+	}
+	svc := NewMediaService(mediaRepo, camRepo, creds, &MockAuditor{}, media.ValidatorConfig{})
+	var onvifProfiles []discovery.MediaProfile
+	for token := range uris {
+		p := discovery.MediaProfile{Token: token, Name: token}
+		p.VideoEncoderConfiguration.Encoding = "H264"
+		onvifProfiles = append(onvifProfiles, p)
+	}
+	svc.ClientFactory = func(x, u, p string) (OnvifClient, error) {
+		return &perTokenOnvif{MockOnvifClient: MockOnvifClient{Profiles: onvifProfiles}, uris: uris}, nil
+	}
+	return svc, &profiles, &selections
+}
+
+func TestSelectMediaProfiles_RejectsForeignONVIFHost(t *testing.T) {
+	svc, profiles, selections := newHostCheckMediaService(t, map[string]string{
+		"main": "rtsp://127.0.0.1:6379/",
+	})
+	_, err := svc.SelectMediaProfiles(context.Background(), hostCheckTenant, uuid.New())
+	if !errors.Is(err, ErrRTSPHostMismatch) {
+		t.Fatalf("Expected ErrRTSPHostMismatch, got %v", err)
+	}
+	if len(*profiles) != 0 || len(*selections) != 0 {
+		t.Error("A rejected stream URI must not be stored")
+	}
+}
+
+func TestSelectMediaProfiles_SkipsOnlyForeignProfiles(t *testing.T) {
+	svc, profiles, _ := newHostCheckMediaService(t, map[string]string{
+		"main":  "rtsp://192.168.1.100:554/main",
+		"rogue": "rtsp://10.0.0.9:554/sub",
+	})
+	sel, err := svc.SelectMediaProfiles(context.Background(), hostCheckTenant, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*profiles) != 1 || (*profiles)[0].ProfileToken != "main" {
+		t.Errorf("Expected only the camera-hosted profile stored, got %d", len(*profiles))
+	}
+	if sel.MainProfileToken != "main" {
+		t.Errorf("Expected main selected, got %q", sel.MainProfileToken)
+	}
+}
+
+func TestResolveRTSPURL_RejectsForeignHost(t *testing.T) {
+	svc, _, _ := newHostCheckMediaService(t, nil)
+	svc.MediaRepo.(*MockMediaRepo).GetSelectionFunc = func(ctx context.Context, cameraID uuid.UUID) (*data.CameraStreamSelection, error) {
+		return &data.CameraStreamSelection{MainRTSP: "rtsp://169.254.169.254/latest", SubIsSameAsMain: true}, nil
+	}
+	if _, err := svc.ResolveRTSPURL(context.Background(), uuid.New(), "main"); !errors.Is(err, ErrRTSPHostMismatch) {
+		t.Errorf("Expected ErrRTSPHostMismatch, got %v", err)
+	}
+
+	svc.RTSPHosts, _ = NewRTSPHostPolicy([]string{"169.254.169.254"})
+	if _, err := svc.ResolveRTSPURL(context.Background(), uuid.New(), "main"); err != nil {
+		t.Errorf("Allow-listed host should resolve, got %v", err)
+	}
+}
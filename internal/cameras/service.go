@@ -4,38 +4,115 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/license"
+	"github.com/technosupport/ts-vms/internal/middleware"
 )
 
 var (
 	ErrLicenseLimitExceeded = errors.New("license_limit_exceeded")
 	ErrSiteScopeMismatch    = errors.New("site does not belong to tenant")
+	ErrSiteRequired         = errors.New("site_id is required and the tenant has no default site")
 	ErrInvalidIP            = errors.New("invalid ip address")
 	ErrNameTooLong          = errors.New("name too long")
+	ErrDuplicateIP          = errors.New("ERR_DUPLICATE_IP")
+	ErrTooManyTags          = errors.New("ERR_TOO_MANY_TAGS")
+	ErrInvalidPort          = errors.New("ERR_INVALID_PORT")
+	ErrDuplicateGroupName   = data.ErrDuplicateGroupName
+)
+
+const (
+	MaxGroupNameLen        = 120
+	MaxGroupDescriptionLen = 500
+	DefaultTagSuggestions  = 20
+	MaxTagSuggestions      = 100
+
+	// DefaultMaxTagsPerCamera bounds distinct tags on one camera
+	// (cameras.max_tags_per_camera).
+	DefaultMaxTagsPerCamera = 50
+
+	// DefaultCameraPort is applied to creates that omit the port
+	// (cameras.default_port); 554 is the RTSP default.
+	DefaultCameraPort = 554
+
+	// MaxThumbnailBatch caps one ListDueForThumbnail page; the refresh job
+	// polls again for the rest.
+	MaxThumbnailBatch = 500
+
+	// DefaultThumbnailRefresh is the thumbnail age after which a camera is due.
+	DefaultThumbnailRefresh = 15 * time.Minute
+
+	// DefaultDeletedRetention is how long a soft-deleted camera is kept
+	// before the purge job removes it for good.
+	DefaultDeletedRetention = 30 * 24 * time.Hour
+
+	// MaxPurgeBatch caps the cameras examined per purge run.
+	MaxPurgeBatch = 500
 )
 
 type Repository interface {
 	Create(ctx context.Context, c *data.Camera) error
 	GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error)
+	ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*data.Camera, error)
 	Update(ctx context.Context, c *data.Camera) error
 	SetStatus(ctx context.Context, id, tenantID uuid.UUID, enabled bool) error
+	SetONVIFEvents(ctx context.Context, tenantID, id uuid.UUID, enabled bool) error
 	SoftDelete(ctx context.Context, id, tenantID uuid.UUID) error
 	CountAll(ctx context.Context, tenantID uuid.UUID) (int, error)
+	CountEnabled(ctx context.Context, tenantID uuid.UUID) (int, error)
+	ListDisabledIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error)
 	BulkUpdateStatus(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, enabled bool) error
-	BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error
+	ListTenantsWithEnabledCameras(ctx context.Context) ([]uuid.UUID, error)
+	ListEnabledIDsByPriority(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error)
+	DisableForLicense(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error
+	ListTenantsWithLicenseDisabledCameras(ctx context.Context) ([]uuid.UUID, error)
+	ListLicenseDisabledIDs(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error)
+	ListDueForThumbnail(ctx context.Context, cutoff time.Time, limit int) ([]data.ThumbnailDue, error)
+	MarkThumbnailCaptured(ctx context.Context, tenantID, cameraID uuid.UUID, capturedAt time.Time) error
+	ListPurgeable(ctx context.Context, cutoff time.Time, limit int) ([]data.PurgeCandidate, error)
+	HardDelete(ctx context.Context, id, tenantID uuid.UUID, cutoff time.Time) error
+	BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string, maxTags int) ([]uuid.UUID, error)
 	BulkRemoveTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error
+	BulkSetTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error
+	ListTags(ctx context.Context, tenantID uuid.UUID, prefix string, limit int) ([]data.TagCount, error)
 	List(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.Camera, int, error)
+	ListSummary(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.CameraSummary, int, error)
+
+	// Site Moves
+	SiteBelongsToTenant(ctx context.Context, siteID, tenantID uuid.UUID) (bool, error)
+	GetDefaultSiteID(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error)
+	SetDefaultSiteID(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID) error
+
+	// Tenant camera settings
+	GetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID) (bool, error)
+	SetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID, enabled bool) error
+	ListNVRSiteConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]data.CameraSiteConflict, error)
+	BulkMoveSite(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) (int, error)
+
+	// IP uniqueness within a site
+	CameraIPInSite(ctx context.Context, tenantID, siteID uuid.UUID, ip net.IP, excludeID uuid.UUID) (bool, error)
+	ListSiteIPConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]uuid.UUID, error)
+	FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error)
 
 	// Grouping
 	CreateGroup(ctx context.Context, g *data.CameraGroup) error
 	ListGroups(ctx context.Context, tenantID uuid.UUID) ([]*data.CameraGroup, error)
 	DeleteGroup(ctx context.Context, id, tenantID uuid.UUID) error
 	SetGroupMembers(ctx context.Context, groupID, tenantID uuid.UUID, cameraIDs []uuid.UUID) error
+
+	// Favorites
+	AddFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error
+	RemoveFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error
 }
 
 type Auditor interface {
@@ -46,38 +123,181 @@ type LicenseChecker interface {
 	GetLimits(tenantID uuid.UUID) license.LicenseLimits
 }
 
+// CredentialCloner reads and re-writes camera credentials for Clone;
+// *CredentialService satisfies it.
+type CredentialCloner interface {
+	GetCredentials(ctx context.Context, tenantID, cameraID uuid.UUID, reveal bool) (*CredentialOutput, bool, error)
+	SetCredentials(ctx context.Context, tenantID, cameraID uuid.UUID, input CredentialInput) error
+}
+
+// SelectionStore reads and writes the main/sub stream selection for Clone.
+type SelectionStore interface {
+	GetSelection(ctx context.Context, cameraID uuid.UUID) (*data.CameraStreamSelection, error)
+	UpsertSelection(ctx context.Context, s *data.CameraStreamSelection) error
+}
+
 type Service struct {
 	repo         Repository
 	licenseMgr   LicenseChecker
 	auditService Auditor
+
+	// Reject a second non-deleted camera with the same IP in one site
+	uniqueIPPerSite bool
+
+	maxTagsPerCamera int
+	defaultPort      int
+
+	// Optional: Clone copies credentials/media selection only when set
+	creds      CredentialCloner
+	selections SelectionStore
+
+	changeHooks []func(ids []uuid.UUID)
+
+	// Optional: camera lifecycle webhooks (SetWebhooks)
+	webhooks WebhookSender
 }
 
 func NewService(repo Repository, lic LicenseChecker, aud Auditor) *Service {
-	return &Service{repo: repo, licenseMgr: lic, auditService: aud}
+	return &Service{repo: repo, licenseMgr: lic, auditService: aud, uniqueIPPerSite: true, maxTagsPerCamera: DefaultMaxTagsPerCamera, defaultPort: DefaultCameraPort}
 }
 
-// Helpers
-func (s *Service) actorFromContext(ctx context.Context) *uuid.UUID {
-	// TODO: Import middleware to get context?
-	// We avoid checking "middleware" package explicitly to avoid cyclic deps if service used by middleware?
-	// But Service -> Middleware is usually OK if Middleware -> Service is avoided.
-	// Actually Middleware depends on Data/Service usually.
-	// Let's assume we can't import `middleware` easily if it causes cycle.
-	// But we need the UserID.
-	// Let's use a Value check or assume caller passes it?
-	// But signature is `ctx`.
-	// Let's try to look for standard Context key.
-	// Or just use `audit.ActorFromContext` if I can find it? I couldn't.
-	// I'll implement a safe lookup if I knew the key.
-	// Re-reading: `middleware.AuthContext` is stored.
-	// Better: Pass userID explicit where possible?
-	// No, Context is standard.
-	// I will just return nil for now or fix imports later.
-	// Wait, I can import `middleware`. `service` package is `internal/cameras`. `middleware` is `internal/middleware`.
-	// `middleware` imports `data`, `tokens`, `auth`. It does NOT import `cameras`. So safe.
+// SetMaxTagsPerCamera caps distinct tags per camera; n <= 0 restores the default.
+func (s *Service) SetMaxTagsPerCamera(n int) {
+	if n <= 0 {
+		n = DefaultMaxTagsPerCamera
+	}
+	s.maxTagsPerCamera = n
+}
+
+// MaxTagsPerCamera reports the per-camera tag cap.
+func (s *Service) MaxTagsPerCamera() int {
+	return s.maxTagsPerCamera
+}
+
+func (s *Service) checkTagCount(tags []string) error {
+	if len(uniqueTags(tags)) > s.maxTagsPerCamera {
+		return fmt.Errorf("%w: max %d", ErrTooManyTags, s.maxTagsPerCamera)
+	}
+	return nil
+}
+
+// ValidPort reports whether port is a usable TCP port (1-65535).
+func ValidPort(port int) bool {
+	return port >= 1 && port <= 65535
+}
+
+// SetDefaultPort sets the port applied to creates without one; an invalid
+// port restores DefaultCameraPort.
+func (s *Service) SetDefaultPort(port int) {
+	if !ValidPort(port) {
+		port = DefaultCameraPort
+	}
+	s.defaultPort = port
+}
+
+// DefaultPort reports the port applied to creates without one.
+func (s *Service) DefaultPort() int {
+	return s.defaultPort
+}
+
+// SetUniqueIPPerSite toggles the duplicate-IP-within-a-site check (default on).
+func (s *Service) SetUniqueIPPerSite(enabled bool) {
+	s.uniqueIPPerSite = enabled
+}
+
+// DefaultEnabled reports the tenant's enabled state for cameras created
+// without an explicit is_enabled value.
+func (s *Service) DefaultEnabled(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	return s.repo.GetCameraDefaultEnabled(ctx, tenantID)
+}
+
+// SetDefaultEnabled sets the tenant's enabled state for cameras created
+// without an explicit is_enabled value.
+func (s *Service) SetDefaultEnabled(ctx context.Context, tenantID uuid.UUID, enabled bool) error {
+	if err := s.repo.SetCameraDefaultEnabled(ctx, tenantID, enabled); err != nil {
+		return err
+	}
+	s.auditService.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:   tenantID,
+		EventID:    uuid.New(),
+		Action:     "camera.settings.update",
+		Result:     "success",
+		TargetType: "tenant",
+		TargetID:   tenantID.String(),
+		CreatedAt:  time.Now(),
+		Metadata:   toMeta(map[string]any{"default_enabled": enabled}),
+	})
+	return nil
+}
+
+// DefaultSite returns the tenant's site for cameras created without site_id,
+// or nil when none is set.
+func (s *Service) DefaultSite(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error) {
+	return s.repo.GetDefaultSiteID(ctx, tenantID)
+}
+
+// SetDefaultSite sets the tenant's site for cameras created without site_id;
+// nil clears it. The site must belong to the tenant.
+func (s *Service) SetDefaultSite(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID) error {
+	if siteID != nil {
+		ok, err := s.repo.SiteBelongsToTenant(ctx, *siteID, tenantID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrSiteScopeMismatch
+		}
+	}
+	if err := s.repo.SetDefaultSiteID(ctx, tenantID, siteID); err != nil {
+		return err
+	}
+	s.auditService.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:   tenantID,
+		EventID:    uuid.New(),
+		Action:     "camera.settings.update",
+		Result:     "success",
+		TargetType: "tenant",
+		TargetID:   tenantID.String(),
+		CreatedAt:  time.Now(),
+		Metadata:   toMeta(map[string]any{"default_site_id": siteID}),
+	})
 	return nil
 }
 
+// SetCloneSources wires the credential and media-selection stores used by Clone.
+func (s *Service) SetCloneSources(creds CredentialCloner, selections SelectionStore) {
+	s.creds = creds
+	s.selections = selections
+}
+
+// Helpers
+// OnCamerasChanged registers fn to run after cameras are deleted, purged or
+// moved to another site, so caches keyed by camera can drop them.
+func (s *Service) OnCamerasChanged(fn func(ids []uuid.UUID)) {
+	s.changeHooks = append(s.changeHooks, fn)
+}
+
+func (s *Service) camerasChanged(ids []uuid.UUID) {
+	for _, fn := range s.changeHooks {
+		fn(ids)
+	}
+}
+
+// actorFromContext returns the requesting user, the system actor for
+// background jobs running under middleware.SystemContext, or nil when there
+// is no auth context.
+func actorFromContext(ctx context.Context) *uuid.UUID {
+	ac, ok := middleware.GetAuthContext(ctx)
+	if !ok {
+		return nil
+	}
+	id, err := uuid.Parse(ac.UserID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
 // CreateCamera enforces license quota (Inventory Count)
 func (s *Service) CreateCamera(ctx context.Context, c *data.Camera) error {
 	// 1. Validation
@@ -87,6 +307,23 @@ func (s *Service) CreateCamera(ctx context.Context, c *data.Camera) error {
 	if c.IPAddress == nil {
 		return ErrInvalidIP
 	}
+	if c.Port == 0 {
+		c.Port = s.defaultPort
+	}
+	if !ValidPort(c.Port) {
+		return ErrInvalidPort
+	}
+	if err := s.checkTagCount(c.Tags); err != nil {
+		return err
+	}
+	siteID, err := s.ResolveSite(ctx, c.TenantID, c.SiteID)
+	if err != nil {
+		return err
+	}
+	c.SiteID = siteID
+	if err := s.checkUniqueIP(ctx, c.TenantID, c.SiteID, c.IPAddress, uuid.Nil); err != nil {
+		return err
+	}
 
 	// 2. License Quota Check
 	// "Hard-capped by MaxCameras".
@@ -110,7 +347,7 @@ func (s *Service) CreateCamera(ctx context.Context, c *data.Camera) error {
 	// 4. Audit
 	s.auditService.WriteEvent(ctx, audit.AuditEvent{
 		TenantID: c.TenantID,
-		// ActorUserID: s.actorFromContext(ctx), // Fix later or import
+		// ActorUserID: actorFromContext(ctx), // Fix later or import
 		EventID:    uuid.New(),
 		Action:     "camera.create",
 		Result:     "success",
@@ -119,9 +356,47 @@ func (s *Service) CreateCamera(ctx context.Context, c *data.Camera) error {
 		Metadata:   toMeta(map[string]any{"name": c.Name, "site_id": c.SiteID}),
 		CreatedAt:  time.Now(),
 	})
+	s.notifyWebhook(WebhookCameraCreated, c)
 	return nil
 }
 
+// ResolveSite returns the site a camera create lands in: siteID when it
+// belongs to the tenant, or the tenant's default site when siteID is Nil.
+func (s *Service) ResolveSite(ctx context.Context, tenantID, siteID uuid.UUID) (uuid.UUID, error) {
+	if siteID == uuid.Nil {
+		return s.defaultSite(ctx, tenantID)
+	}
+	ok, err := s.repo.SiteBelongsToTenant(ctx, siteID, tenantID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !ok {
+		return uuid.Nil, ErrSiteScopeMismatch
+	}
+	return siteID, nil
+}
+
+// defaultSite returns the tenant's default site for creates that omit
+// site_id. The site is re-checked against the tenant since the column only
+// references sites(id).
+func (s *Service) defaultSite(ctx context.Context, tenantID uuid.UUID) (uuid.UUID, error) {
+	siteID, err := s.repo.GetDefaultSiteID(ctx, tenantID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		return uuid.Nil, err
+	}
+	if siteID == nil {
+		return uuid.Nil, ErrSiteRequired
+	}
+	ok, err := s.repo.SiteBelongsToTenant(ctx, *siteID, tenantID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !ok {
+		return uuid.Nil, ErrSiteScopeMismatch
+	}
+	return *siteID, nil
+}
+
 func (s *Service) EnableCamera(ctx context.Context, id, tenantID uuid.UUID) error {
 	// 1. Check Quota (if we enforce on Enable too, which we do per plan)
 	// Usually Enabled Limit != Inventory Limit.
@@ -158,6 +433,16 @@ func (s *Service) EnableCamera(ctx context.Context, id, tenantID uuid.UUID) erro
 		return ErrLicenseLimitExceeded
 	}
 
+	// Enabling one more must fit the enabled-cameras limit (BulkEnable's projection)
+	enabled, err := s.repo.CountEnabled(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if enabled+1 > limits.EnabledLimit() {
+		s.recordLicenseDenial(ctx)
+		return ErrLicenseLimitExceeded
+	}
+
 	return s.setStatus(ctx, id, tenantID, true)
 }
 
@@ -184,47 +469,71 @@ func (s *Service) setStatus(ctx context.Context, id, tenantID uuid.UUID, enabled
 		TargetType: "camera",
 		CreatedAt:  time.Now(),
 	})
+	s.notifyUpdated(ctx, tenantID, []uuid.UUID{id})
 	return nil
 }
 
-// BulkEnable: strict "Fail All"
-func (s *Service) BulkEnable(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error {
-	// 1. Predict Resulting Count
-	// We need to know how many of `ids` are currently disabled.
-	// Optimization: Just count how many cameras total (inventory) vs Limit?
-	// If Limit applies to Inventory, and we are just enabling existing inventory,
-	// then Inventory count doesn't change.
-	// IF Limit applies to ENABLED count, we need to check.
-	// User Correction: "enforce quota on CreateCamera (inventory)... and on Enable"
-	// If it applies to INVENTORY, then BulkEnable doesn't increase inventory.
-	// It only increases "enabled count" (if that has a separate limit).
-	// Assuming MaxCameras applies to Inventory mostly.
-	// BUT, if we have a separate "MaxEnabled" (implied by "Option A... enabled count"), we check it.
-	// Let's assume MaxCameras limit applies to *Inventory Count* mainly.
-	// But let's be safe: If I have 50 cameras (Max=50), all disabled, and I enable 10.
-	// Inventory=50. OK.
-
-	// If the user meant "MaxCameras limits the number of ENABLED cameras", then:
-	// Count Enabled + ToBeEnabled <= Limit.
-	// The prompt is slightly ambiguous on "Quota" meaning Inventory vs Enabled.
-	// "Inventory only... Enable must check...".
-	// Let's enforce strictly against MaxCameras.
-
-	// Just check if Inventory > Max. (Should be blocked at Create).
-	// If Inventory <= Max, then Enable is always safe (unless MaxEnabled < MaxInventory).
-	// We assume MaxCameras is the only limit.
+// SetONVIFEvents opts the camera in or out of the ONVIF event bridge, which
+// publishes its motion/analytics events alongside NVR events.
+// Audit: camera.onvif_events.enable / camera.onvif_events.disable
+func (s *Service) SetONVIFEvents(ctx context.Context, tenantID, id uuid.UUID, enabled bool) error {
+	if err := s.repo.SetONVIFEvents(ctx, tenantID, id, enabled); err != nil {
+		return err
+	}
 
+	action := "camera.onvif_events.disable"
+	if enabled {
+		action = "camera.onvif_events.enable"
+	}
+	s.auditService.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:    tenantID,
+		EventID:     uuid.New(),
+		ActorUserID: actorFromContext(ctx),
+		Action:      action,
+		Result:      "success",
+		TargetID:    id.String(),
+		TargetType:  "camera",
+		CreatedAt:   time.Now(),
+	})
+	s.notifyUpdated(ctx, tenantID, []uuid.UUID{id})
+	return nil
+}
+
+// BulkEnable is strict "Fail All": if enabling the requested cameras would
+// take the tenant past its enabled-cameras limit, nothing is enabled and a
+// *QuotaError names the cameras that did not fit. Cameras already enabled
+// don't count against the projection; among the rest, the oldest fit first
+// (the same priority ReconcileLicenseQuota keeps).
+func (s *Service) BulkEnable(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error {
+	// Inventory over MaxCameras means the tenant is already in violation
+	// (e.g. a license downgrade); block enables until it is fixed.
 	currentCount, err := s.repo.CountAll(ctx, tenantID)
 	if err != nil {
 		return err
 	}
-
 	limits := s.licenseMgr.GetLimits(tenantID)
 	if currentCount > limits.MaxCameras {
 		s.recordLicenseDenial(ctx)
 		return ErrLicenseLimitExceeded
 	}
 
+	toEnable, err := s.repo.ListDisabledIDs(ctx, tenantID, ids)
+	if err != nil {
+		return err
+	}
+	if len(toEnable) > 0 {
+		enabled, err := s.repo.CountEnabled(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		limit := limits.EnabledLimit()
+		if enabled+len(toEnable) > limit {
+			fits := max(limit-enabled, 0)
+			s.recordLicenseDenial(ctx)
+			return &QuotaError{Limit: limit, Enabled: enabled, Requested: len(toEnable), Rejected: toEnable[fits:]}
+		}
+	}
+
 	if err := s.repo.BulkUpdateStatus(ctx, tenantID, ids, true); err != nil {
 		return err
 	}
@@ -236,8 +545,9 @@ func (s *Service) BulkEnable(ctx context.Context, tenantID uuid.UUID, ids []uuid
 		Result:     "success",
 		TargetType: "camera_batch",
 		CreatedAt:  time.Now(),
-		Metadata:   toMeta(map[string]any{"count": len(ids)}),
+		Metadata:   toMeta(map[string]any{"count": len(ids), "newly_enabled": len(toEnable)}),
 	})
+	s.notifyUpdated(ctx, tenantID, ids)
 	return nil
 }
 
@@ -254,13 +564,109 @@ func (s *Service) BulkDisable(ctx context.Context, tenantID uuid.UUID, ids []uui
 		CreatedAt:  time.Now(),
 		Metadata:   toMeta(map[string]any{"count": len(ids)}),
 	})
+	s.notifyUpdated(ctx, tenantID, ids)
 	return nil
 }
 
+// ReconcileLicenseQuota runs at startup, and only with a valid license. If
+// a license downgrade left a tenant with more enabled cameras than its
+// enabled limit, the lowest-priority (newest) enabled cameras are disabled
+// and marked as license-disabled. Marked cameras are re-enabled, oldest
+// first, once the limit has room for them again; enabling or disabling a
+// camera by hand clears the mark. Returns the number of cameras disabled
+// and restored across all tenants.
+func (s *Service) ReconcileLicenseQuota(ctx context.Context) (disabled, restored int, err error) {
+	over, err := s.repo.ListTenantsWithEnabledCameras(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	marked, err := s.repo.ListTenantsWithLicenseDisabledCameras(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	tenants := append(over, marked...)
+	seen := make(map[uuid.UUID]bool, len(tenants))
+
+	for _, tenantID := range tenants {
+		if seen[tenantID] {
+			continue
+		}
+		seen[tenantID] = true
+
+		ids, err := s.repo.ListEnabledIDsByPriority(ctx, tenantID)
+		if err != nil {
+			return disabled, restored, err
+		}
+
+		max := s.licenseMgr.GetLimits(tenantID).EnabledLimit()
+		if max < 0 {
+			max = 0
+		}
+		if len(ids) > max {
+			excess := ids[max:]
+			if err := s.repo.DisableForLicense(ctx, tenantID, excess); err != nil {
+				return disabled, restored, err
+			}
+			disabled += len(excess)
+
+			s.auditService.WriteEvent(ctx, audit.AuditEvent{
+				TenantID:    tenantID,
+				ActorUserID: audit.SystemActor(),
+				EventID:     uuid.New(),
+				Action:      "camera.license.auto_disable",
+				Result:      "success",
+				ReasonCode:  ErrLicenseLimitExceeded.Error(),
+				TargetType:  "camera_batch",
+				CreatedAt:   time.Now(),
+				Metadata:    toMeta(map[string]any{"count": len(excess), "max_cameras": max, "camera_ids": excess}),
+			})
+			continue
+		}
+
+		free := max - len(ids)
+		if free == 0 {
+			continue
+		}
+		pending, err := s.repo.ListLicenseDisabledIDs(ctx, tenantID)
+		if err != nil {
+			return disabled, restored, err
+		}
+		if len(pending) > free {
+			pending = pending[:free]
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		if err := s.repo.BulkUpdateStatus(ctx, tenantID, pending, true); err != nil {
+			return disabled, restored, err
+		}
+		restored += len(pending)
+
+		s.auditService.WriteEvent(ctx, audit.AuditEvent{
+			TenantID:    tenantID,
+			ActorUserID: audit.SystemActor(),
+			EventID:     uuid.New(),
+			Action:      "camera.license.auto_restore",
+			Result:      "success",
+			TargetType:  "camera_batch",
+			CreatedAt:   time.Now(),
+			Metadata:    toMeta(map[string]any{"count": len(pending), "max_cameras": max, "camera_ids": pending}),
+		})
+	}
+	return disabled, restored, nil
+}
+
+// BulkAddTags appends tags to every listed camera. It is all-or-nothing: if
+// any camera would end up with more than MaxTagsPerCamera distinct tags
+// (existing plus added), nothing is changed.
 func (s *Service) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
-	if err := s.repo.BulkAddTags(ctx, tenantID, ids, tags); err != nil {
+	over, err := s.repo.BulkAddTags(ctx, tenantID, ids, tags, s.maxTagsPerCamera)
+	if err != nil {
 		return err
 	}
+	if len(over) > 0 {
+		return fmt.Errorf("camera %s: %w: max %d", over[0], ErrTooManyTags, s.maxTagsPerCamera)
+	}
 	s.auditService.WriteEvent(ctx, audit.AuditEvent{
 		TenantID:   tenantID,
 		EventID:    uuid.New(),
@@ -270,6 +676,7 @@ func (s *Service) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uui
 		CreatedAt:  time.Now(),
 		Metadata:   toMeta(map[string]any{"count": len(ids), "tags": tags}),
 	})
+	s.notifyUpdated(ctx, tenantID, ids)
 	return nil
 }
 
@@ -286,35 +693,390 @@ func (s *Service) BulkRemoveTags(ctx context.Context, tenantID uuid.UUID, ids []
 		CreatedAt:  time.Now(),
 		Metadata:   toMeta(map[string]any{"count": len(ids), "tags": tags}),
 	})
+	s.notifyUpdated(ctx, tenantID, ids)
+	return nil
+}
+
+// BulkSetTags replaces the tags of every listed camera with the given set,
+// de-duplicated and with empty entries dropped. An empty set clears them.
+func (s *Service) BulkSetTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
+	tags = uniqueTags(tags)
+	if err := s.checkTagCount(tags); err != nil {
+		return err
+	}
+	if err := s.repo.BulkSetTags(ctx, tenantID, ids, tags); err != nil {
+		return err
+	}
+	s.auditService.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:   tenantID,
+		EventID:    uuid.New(),
+		Action:     "camera.bulk.tag_set",
+		Result:     "success",
+		TargetType: "camera_batch",
+		CreatedAt:  time.Now(),
+		Metadata:   toMeta(map[string]any{"count": len(ids), "tags": tags}),
+	})
+	s.notifyUpdated(ctx, tenantID, ids)
+	return nil
+}
+
+// uniqueTags keeps the first occurrence of each tag, in order. The result is
+// never nil so the repo writes an empty array rather than NULL.
+func uniqueTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// ListTags suggests existing tags for autocomplete. limit is clamped to
+// [1, MaxTagSuggestions]; 0 means DefaultTagSuggestions.
+func (s *Service) ListTags(ctx context.Context, tenantID uuid.UUID, prefix string, limit int) ([]data.TagCount, error) {
+	if limit <= 0 {
+		limit = DefaultTagSuggestions
+	}
+	if limit > MaxTagSuggestions {
+		limit = MaxTagSuggestions
+	}
+	return s.repo.ListTags(ctx, tenantID, strings.TrimSpace(prefix), limit)
+}
+
+// ListDueForThumbnail returns enabled cameras whose latest thumbnail is older
+// than olderThan (or missing), so the refresh job skips fresh ones.
+func (s *Service) ListDueForThumbnail(ctx context.Context, olderThan time.Duration) ([]data.ThumbnailDue, error) {
+	if olderThan <= 0 {
+		return nil, errors.New("olderThan must be positive")
+	}
+	return s.repo.ListDueForThumbnail(ctx, time.Now().Add(-olderThan), MaxThumbnailBatch)
+}
+
+// MarkThumbnailCaptured records a fresh thumbnail for the camera.
+func (s *Service) MarkThumbnailCaptured(ctx context.Context, cameraID uuid.UUID, capturedAt time.Time) error {
+	cam, err := s.repo.GetByID(ctx, cameraID)
+	if err != nil {
+		return err
+	}
+	if cam == nil || cam.DeletedAt != nil {
+		return data.ErrRecordNotFound
+	}
+	return s.repo.MarkThumbnailCaptured(ctx, cam.TenantID, cam.ID, capturedAt)
+}
+
+// BulkMoveResult reports the outcome of a bulk site move.
+type BulkMoveResult struct {
+	Moved   int                       `json:"moved"`
+	Flagged []data.CameraSiteConflict `json:"flagged"`
+}
+
+// BulkMoveSite moves cameras to siteID. Cameras linked to an NVR on a different
+// site are left in place and returned as flagged so the operator can relink first.
+func (s *Service) BulkMoveSite(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) (*BulkMoveResult, error) {
+	ok, err := s.repo.SiteBelongsToTenant(ctx, siteID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSiteScopeMismatch
+	}
+
+	// Duplicate IPs reject the whole move rather than being flagged: moving
+	// the rest would still leave the operator to untangle the clash.
+	if s.uniqueIPPerSite {
+		dups, err := s.repo.ListSiteIPConflicts(ctx, tenantID, ids, siteID)
+		if err != nil {
+			return nil, err
+		}
+		if len(dups) > 0 {
+			return nil, fmt.Errorf("%w: %d camera(s) clash with each other or with cameras in site %s", ErrDuplicateIP, len(dups), siteID)
+		}
+	}
+
+	conflicts, err := s.repo.ListNVRSiteConflicts(ctx, tenantID, ids, siteID)
+	if err != nil {
+		return nil, err
+	}
+	flagged := make(map[uuid.UUID]bool, len(conflicts))
+	for _, c := range conflicts {
+		flagged[c.CameraID] = true
+	}
+
+	toMove := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !flagged[id] {
+			toMove = append(toMove, id)
+		}
+	}
+
+	res := &BulkMoveResult{Flagged: conflicts}
+	if res.Flagged == nil {
+		res.Flagged = []data.CameraSiteConflict{}
+	}
+	if len(toMove) > 0 {
+		moved, err := s.repo.BulkMoveSite(ctx, tenantID, toMove, siteID)
+		if err != nil {
+			return nil, err
+		}
+		res.Moved = moved
+		s.camerasChanged(toMove)
+		s.notifyUpdated(ctx, tenantID, toMove)
+	}
+
+	s.auditService.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:   tenantID,
+		EventID:    uuid.New(),
+		Action:     "camera.bulk.move_site",
+		Result:     "success",
+		TargetID:   siteID.String(),
+		TargetType: "camera_batch",
+		CreatedAt:  time.Now(),
+		Metadata:   toMeta(map[string]any{"count": len(ids), "moved": res.Moved, "flagged": len(conflicts), "site_id": siteID}),
+	})
+	return res, nil
+}
+
+// checkUniqueIP returns ErrDuplicateIP when another camera in siteID already
+// uses ip. A no-op when the check is disabled.
+func (s *Service) checkUniqueIP(ctx context.Context, tenantID, siteID uuid.UUID, ip net.IP, excludeID uuid.UUID) error {
+	if !s.uniqueIPPerSite {
+		return nil
+	}
+	taken, err := s.repo.CameraIPInSite(ctx, tenantID, siteID, ip, excludeID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateIP
+	}
 	return nil
 }
 
+// FindByIP returns the id of the tenant's camera with the given IP, or
+// data.ErrRecordNotFound.
+func (s *Service) FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error) {
+	return s.repo.FindByIP(ctx, tenantID, ip)
+}
+
 func (s *Service) recordLicenseDenial(ctx context.Context) {
 	// Metrics increment
 	// TODO: Add metrics hook
 }
 
 // Get/List/Update just delegate to repo usually, but Update needs Audit
+// UpdateCamera saves c and audits a field-level diff against the stored
+// camera. Site moves go through BulkMoveSite.
 func (s *Service) UpdateCamera(ctx context.Context, c *data.Camera) error {
+	prior, err := s.repo.GetByID(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	if prior.TenantID != c.TenantID || prior.DeletedAt != nil {
+		return data.ErrRecordNotFound
+	}
+	// Port 0 keeps the stored port (cameras created before validation may
+	// still have none).
+	if c.Port == 0 {
+		c.Port = prior.Port
+	} else if !ValidPort(c.Port) {
+		return ErrInvalidPort
+	}
+	if err := s.checkTagCount(c.Tags); err != nil {
+		return err
+	}
+	if err := s.checkUniqueIP(ctx, c.TenantID, c.SiteID, c.IPAddress, c.ID); err != nil {
+		return err
+	}
+
+	c.LastChangedBy = actorFromContext(ctx)
 	if err := s.repo.Update(ctx, c); err != nil {
 		return err
 	}
+
 	s.auditService.WriteEvent(ctx, audit.AuditEvent{
-		TenantID:   c.TenantID,
+		TenantID:    c.TenantID,
+		ActorUserID: c.LastChangedBy,
+		EventID:     uuid.New(),
+		Action:      "camera.update",
+		Result:      "success",
+		TargetID:    c.ID.String(),
+		TargetType:  "camera",
+		CreatedAt:   time.Now(),
+		Metadata:    toMeta(map[string]any{"changes": cameraDiff(prior, c)}),
+	})
+	s.notifyWebhook(WebhookCameraUpdated, c)
+	return nil
+}
+
+// FieldChange is one entry of a camera.update audit diff.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// cameraDiff lists the audited fields (name, ip_address, port, tags)
+// that differ between before and after. Tags compare as sets.
+func cameraDiff(before, after *data.Camera) map[string]FieldChange {
+	d := map[string]FieldChange{}
+	if before.Name != after.Name {
+		d["name"] = FieldChange{before.Name, after.Name}
+	}
+	if !before.IPAddress.Equal(after.IPAddress) {
+		d["ip_address"] = FieldChange{ipString(before.IPAddress), ipString(after.IPAddress)}
+	}
+	if before.Port != after.Port {
+		d["port"] = FieldChange{before.Port, after.Port}
+	}
+	if !sameTags(before.Tags, after.Tags) {
+		d["tags"] = FieldChange{nonNilTags(before.Tags), nonNilTags(after.Tags)}
+	}
+	return d
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func sameTags(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, t := range a {
+		set[t] = true
+	}
+	other := make(map[string]bool, len(b))
+	for _, t := range b {
+		if !set[t] {
+			return false
+		}
+		other[t] = true
+	}
+	return len(other) == len(set)
+}
+
+// Clone creates a new camera at newIP from an existing one (hardware swap).
+// Site, port, tags, manufacturer/model, credentials and media selection are
+// copied; serial number and MAC stay empty since they identify the old unit.
+// Credentials are decrypted and re-encrypted under the clone's own DEK/AAD, and
+// the license quota applies as for any create.
+func (s *Service) Clone(ctx context.Context, sourceID, tenantID uuid.UUID, newIP net.IP, newName string) (*data.Camera, error) {
+	src, err := s.repo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if src.TenantID != tenantID || src.DeletedAt != nil {
+		return nil, data.ErrRecordNotFound
+	}
+
+	c := &data.Camera{
+		TenantID:     tenantID,
+		SiteID:       src.SiteID,
+		Name:         newName,
+		IPAddress:    newIP,
+		Port:         src.Port,
+		Manufacturer: src.Manufacturer,
+		Model:        src.Model,
+		IsEnabled:    src.IsEnabled,
+		Tags:         append([]string(nil), src.Tags...),
+	}
+	if err := s.CreateCamera(ctx, c); err != nil {
+		return nil, err
+	}
+
+	if err := s.cloneAttachments(ctx, tenantID, src, c); err != nil {
+		// Don't leave a half-configured clone behind
+		s.repo.SoftDelete(ctx, c.ID, tenantID)
+		s.camerasChanged([]uuid.UUID{c.ID})
+		return nil, err
+	}
+
+	s.auditService.WriteEvent(ctx, audit.AuditEvent{
+		TenantID:   tenantID,
 		EventID:    uuid.New(),
-		Action:     "camera.update",
+		Action:     "camera.clone",
 		Result:     "success",
 		TargetID:   c.ID.String(),
 		TargetType: "camera",
 		CreatedAt:  time.Now(),
+		Metadata:   toMeta(map[string]any{"source_id": sourceID, "clone_id": c.ID, "ip_address": newIP.String()}),
 	})
+	return c, nil
+}
+
+func (s *Service) cloneAttachments(ctx context.Context, tenantID uuid.UUID, src, dst *data.Camera) error {
+	if s.creds != nil {
+		out, found, err := s.creds.GetCredentials(ctx, tenantID, src.ID, true)
+		if err != nil {
+			return fmt.Errorf("read source credentials: %w", err)
+		}
+		if found && out.Data != nil {
+			if err := s.creds.SetCredentials(ctx, tenantID, dst.ID, *out.Data); err != nil {
+				return fmt.Errorf("write clone credentials: %w", err)
+			}
+		}
+	}
+
+	if s.selections != nil {
+		sel, err := s.selections.GetSelection(ctx, src.ID)
+		if err != nil {
+			return fmt.Errorf("read source media selection: %w", err)
+		}
+		if sel != nil {
+			cp := *sel
+			cp.ID = uuid.Nil
+			cp.TenantID = tenantID
+			cp.CameraID = dst.ID
+			cp.MainRTSP = rehostRTSP(sel.MainRTSP, dst.IPAddress)
+			cp.SubRTSP = rehostRTSP(sel.SubRTSP, dst.IPAddress)
+			if err := s.selections.UpsertSelection(ctx, &cp); err != nil {
+				return fmt.Errorf("write clone media selection: %w", err)
+			}
+		}
+	}
 	return nil
 }
 
+// rehostRTSP points a stored stream URL at the clone's IP, keeping port and path.
+func rehostRTSP(raw string, ip net.IP) string {
+	u, err := url.Parse(raw)
+	if raw == "" || err != nil || u.Host == "" {
+		return raw
+	}
+	host := ip.String()
+	if ip.To4() == nil {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	return u.String()
+}
+
 func (s *Service) DeleteCamera(ctx context.Context, id, tenantID uuid.UUID) error {
+	// The webhook carries the camera's fields, so read them before deleting
+	var deleted *data.Camera
+	if s.webhooks != nil {
+		if c, err := s.repo.GetByID(ctx, id); err == nil && c.TenantID == tenantID {
+			deleted = c
+		}
+	}
 	if err := s.repo.SoftDelete(ctx, id, tenantID); err != nil {
 		return err
 	}
+	s.camerasChanged([]uuid.UUID{id})
 	s.auditService.WriteEvent(ctx, audit.AuditEvent{
 		TenantID:   tenantID,
 		EventID:    uuid.New(),
@@ -324,6 +1086,101 @@ func (s *Service) DeleteCamera(ctx context.Context, id, tenantID uuid.UUID) erro
 		TargetType: "camera",
 		CreatedAt:  time.Now(),
 	})
+	if deleted != nil {
+		now := time.Now()
+		deleted.DeletedAt = &now
+		s.notifyWebhook(WebhookCameraDeleted, deleted)
+	}
+	return nil
+}
+
+// PurgeDeletedCameras hard-deletes cameras soft-deleted more than retention
+// ago. Cameras still holding NVR links or credentials are left in place so
+// nothing is removed by cascade; they are retried on the next run.
+// Returns the number of cameras purged.
+// Audit: camera.purge (one per camera)
+func (s *Service) PurgeDeletedCameras(ctx context.Context, retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, errors.New("retention must be positive")
+	}
+	cutoff := time.Now().Add(-retention)
+	candidates, err := s.repo.ListPurgeable(ctx, cutoff, MaxPurgeBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, c := range candidates {
+		if err := s.repo.HardDelete(ctx, c.CameraID, c.TenantID, cutoff); err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				continue // Restored or re-referenced since listing
+			}
+			return purged, err
+		}
+		purged++
+		s.camerasChanged([]uuid.UUID{c.CameraID})
+		s.auditService.WriteEvent(ctx, audit.AuditEvent{
+			TenantID:    c.TenantID,
+			ActorUserID: audit.SystemActor(),
+			EventID:     uuid.New(),
+			Action:      "camera.purge",
+			Result:      "success",
+			TargetID:    c.CameraID.String(),
+			TargetType:  "camera",
+			CreatedAt:   time.Now(),
+			Metadata:    toMeta(map[string]any{"deleted_at": c.DeletedAt, "retention": retention.String()}),
+		})
+	}
+	return purged, nil
+}
+
+// StartDeletedCameraPurger runs PurgeDeletedCameras every interval until ctx
+// is cancelled.
+func (s *Service) StartDeletedCameraPurger(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.PurgeDeletedCameras(ctx, retention)
+				if err != nil {
+					log.Printf("[Cameras] Deleted camera purge failed: %v", err)
+				} else if n > 0 {
+					log.Printf("[Cameras] Purged %d camera(s) deleted more than %s ago", n, retention)
+				}
+			}
+		}
+	}()
+}
+
+// AddFavorite pins a camera for the user. The camera must belong to the
+// tenant; foreign or deleted cameras are reported as not found.
+func (s *Service) AddFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	if err := s.checkCameraTenant(ctx, tenantID, cameraID); err != nil {
+		return err
+	}
+	return s.repo.AddFavorite(ctx, tenantID, userID, cameraID)
+}
+
+// RemoveFavorite unpins a camera for the user.
+func (s *Service) RemoveFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	if err := s.checkCameraTenant(ctx, tenantID, cameraID); err != nil {
+		return err
+	}
+	return s.repo.RemoveFavorite(ctx, tenantID, userID, cameraID)
+}
+
+func (s *Service) checkCameraTenant(ctx context.Context, tenantID, cameraID uuid.UUID) error {
+	cam, err := s.repo.GetByID(ctx, cameraID)
+	if err != nil {
+		return err
+	}
+	if cam.TenantID != tenantID || cam.DeletedAt != nil {
+		return data.ErrRecordNotFound
+	}
 	return nil
 }
 
@@ -332,6 +1189,11 @@ func (s *Service) List(ctx context.Context, tenantID uuid.UUID, filter data.Came
 	return s.repo.List(ctx, tenantID, filter, limit, offset)
 }
 
+// ListSummary is List reduced to the summary projection.
+func (s *Service) ListSummary(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.CameraSummary, int, error) {
+	return s.repo.ListSummary(ctx, tenantID, filter, limit, offset)
+}
+
 func (s *Service) GetByID(ctx context.Context, id, tenantID uuid.UUID) (*data.Camera, error) {
 	return s.repo.GetByID(ctx, id)
 }
@@ -341,10 +1203,33 @@ func (s *Service) GetByIDUnsafe(ctx context.Context, id uuid.UUID) (*data.Camera
 	return s.repo.GetByID(ctx, id)
 }
 
+// CreateGroup validates name/description and creates the group.
+// Names are unique per tenant; a clash returns ErrDuplicateGroupName.
 func (s *Service) CreateGroup(ctx context.Context, g *data.CameraGroup) error {
+	g.Name = strings.TrimSpace(g.Name)
+	g.Description = strings.TrimSpace(g.Description)
+	if err := validateGroup(g); err != nil {
+		return err
+	}
 	return s.repo.CreateGroup(ctx, g) // TODO: Audit
 }
 
+func validateGroup(g *data.CameraGroup) error {
+	fields := map[string]string{}
+	if n := utf8.RuneCountInString(g.Name); n == 0 {
+		fields["name"] = "required"
+	} else if n > MaxGroupNameLen {
+		fields["name"] = fmt.Sprintf("must be at most %d characters", MaxGroupNameLen)
+	}
+	if utf8.RuneCountInString(g.Description) > MaxGroupDescriptionLen {
+		fields["description"] = fmt.Sprintf("must be at most %d characters", MaxGroupDescriptionLen)
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
 func (s *Service) ListGroups(ctx context.Context, tenantID uuid.UUID) ([]*data.CameraGroup, error) {
 	return s.repo.ListGroups(ctx, tenantID)
 }
@@ -365,17 +1250,15 @@ func (s *Service) GetCamera(ctx context.Context, tenantID uuid.UUID, id string)
 	// Parse ID
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: invalid camera id: %w", data.ErrRecordNotFound, err)
 	}
-	// For now, assume GetByID checks tenant? Or we need to check tenant.
-	// The repo.GetByID likely returns camera regardless of tenant if not enforced.
-	// Let's check `repo.GetByID`. If it doesn't take tenantID, we must verify it.
+	// repo.GetByID is not tenant-scoped; a foreign camera reads as not found.
 	cam, err := s.repo.GetByID(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
 	if cam.TenantID != tenantID {
-		return nil, errors.New("camera not found (tenant mismatch)")
+		return nil, fmt.Errorf("%w: camera tenant mismatch", data.ErrRecordNotFound)
 	}
 	return cam, nil
 }
@@ -1,23 +1,37 @@
 package cameras_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/crypto"
 	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/license"
+	"github.com/technosupport/ts-vms/internal/middleware"
+	"github.com/technosupport/ts-vms/internal/webhook"
 )
 
 // MockRepository
 type MockRepo struct {
-	Count int
-	Calls map[string]int
-	Err   error
+	Count    int
+	Calls    map[string]int
+	Err      error
+	LastTags []string
 }
 
 func (m *MockRepo) Create(ctx context.Context, c *data.Camera) error {
@@ -27,28 +41,67 @@ func (m *MockRepo) Create(ctx context.Context, c *data.Camera) error {
 func (m *MockRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
 	return &data.Camera{ID: id, IsEnabled: false}, m.Err
 }
+func (m *MockRepo) ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*data.Camera, error) {
+	m.Calls["ListByIDs"]++
+	var out []*data.Camera
+	for _, id := range ids {
+		out = append(out, &data.Camera{ID: id, TenantID: tenantID})
+	}
+	return out, m.Err
+}
 func (m *MockRepo) Update(ctx context.Context, c *data.Camera) error { return m.Err }
 func (m *MockRepo) SetStatus(ctx context.Context, id, tenantID uuid.UUID, enabled bool) error {
 	m.Calls["SetStatus"]++
 	return m.Err
 }
+func (m *MockRepo) SetONVIFEvents(ctx context.Context, tenantID, id uuid.UUID, enabled bool) error {
+	return m.Err
+}
 func (m *MockRepo) SoftDelete(ctx context.Context, id, tenantID uuid.UUID) error { return m.Err }
 func (m *MockRepo) CountAll(ctx context.Context, tenantID uuid.UUID) (int, error) {
 	return m.Count, m.Err
 }
+func (m *MockRepo) CountEnabled(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	return 0, m.Err
+}
+func (m *MockRepo) ListDisabledIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return ids, m.Err
+}
 func (m *MockRepo) BulkUpdateStatus(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, enabled bool) error {
 	m.Calls["BulkUpdateStatus"]++
 	return m.Err
 }
-func (m *MockRepo) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
-	return m.Err
+func (m *MockRepo) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string, maxTags int) ([]uuid.UUID, error) {
+	return nil, m.Err
 }
 func (m *MockRepo) BulkRemoveTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
 	return m.Err
 }
+func (m *MockRepo) BulkSetTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
+	m.LastTags = tags
+	return m.Err
+}
+func (m *MockRepo) ListTags(ctx context.Context, tenantID uuid.UUID, prefix string, limit int) ([]data.TagCount, error) {
+	return nil, m.Err
+}
+func (m *MockRepo) ListDueForThumbnail(ctx context.Context, cutoff time.Time, limit int) ([]data.ThumbnailDue, error) {
+	return nil, m.Err
+}
+func (m *MockRepo) MarkThumbnailCaptured(ctx context.Context, tenantID, cameraID uuid.UUID, capturedAt time.Time) error {
+	return m.Err
+}
+func (m *MockRepo) ListPurgeable(ctx context.Context, cutoff time.Time, limit int) ([]data.PurgeCandidate, error) {
+	return nil, m.Err
+}
+func (m *MockRepo) HardDelete(ctx context.Context, id, tenantID uuid.UUID, cutoff time.Time) error {
+	return m.Err
+}
 func (m *MockRepo) List(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.Camera, int, error) {
 	return nil, 0, m.Err
 }
+func (m *MockRepo) ListSummary(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.CameraSummary, int, error) {
+	return nil, 0, m.Err
+}
 func (m *MockRepo) CreateGroup(ctx context.Context, g *data.CameraGroup) error { return m.Err }
 func (m *MockRepo) ListGroups(ctx context.Context, tenantID uuid.UUID) ([]*data.CameraGroup, error) {
 	return nil, m.Err
@@ -57,6 +110,59 @@ func (m *MockRepo) DeleteGroup(ctx context.Context, id, tenantID uuid.UUID) erro
 func (m *MockRepo) SetGroupMembers(ctx context.Context, groupID, tenantID uuid.UUID, cameraIDs []uuid.UUID) error {
 	return m.Err
 }
+func (m *MockRepo) AddFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	m.Calls["AddFavorite"]++
+	return m.Err
+}
+func (m *MockRepo) RemoveFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	m.Calls["RemoveFavorite"]++
+	return m.Err
+}
+func (m *MockRepo) SiteBelongsToTenant(ctx context.Context, siteID, tenantID uuid.UUID) (bool, error) {
+	return true, nil
+}
+func (m *MockRepo) GetDefaultSiteID(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error) {
+	return nil, m.Err
+}
+func (m *MockRepo) SetDefaultSiteID(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID) error {
+	return m.Err
+}
+func (m *MockRepo) ListNVRSiteConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]data.CameraSiteConflict, error) {
+	return nil, nil
+}
+func (m *MockRepo) CameraIPInSite(ctx context.Context, tenantID, siteID uuid.UUID, ip net.IP, excludeID uuid.UUID) (bool, error) {
+	return false, nil
+}
+func (m *MockRepo) FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error) {
+	return uuid.Nil, data.ErrRecordNotFound
+}
+func (m *MockRepo) ListSiteIPConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockRepo) BulkMoveSite(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) (int, error) {
+	return len(ids), nil
+}
+func (m *MockRepo) ListTenantsWithEnabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockRepo) ListEnabledIDsByPriority(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockRepo) DisableForLicense(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error {
+	return nil
+}
+func (m *MockRepo) ListTenantsWithLicenseDisabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockRepo) ListLicenseDisabledIDs(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockRepo) GetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	return true, nil
+}
+func (m *MockRepo) SetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID, enabled bool) error {
+	return nil
+}
 
 type MockAuditor struct {
 	LastEvent *audit.AuditEvent
@@ -83,6 +189,7 @@ func TestCreateCamera_Success(t *testing.T) {
 
 	cam := &data.Camera{
 		TenantID:  uuid.New(),
+		SiteID:    uuid.New(),
 		Name:      "Test Cam",
 		IPAddress: testIP(), // we need a helper or just net.ParseIP("1.2.3.4")
 	}
@@ -106,7 +213,7 @@ func TestCreateCamera_LicenseExceeded(t *testing.T) {
 	aud := &MockAuditor{}
 	svc := cameras.NewService(repo, lic, aud)
 
-	cam := &data.Camera{TenantID: uuid.New(), Name: "Test Cam", IPAddress: testIP()}
+	cam := &data.Camera{TenantID: uuid.New(), SiteID: uuid.New(), Name: "Test Cam", IPAddress: testIP()}
 
 	err := svc.CreateCamera(context.Background(), cam)
 	if !errors.Is(err, cameras.ErrLicenseLimitExceeded) {
@@ -157,6 +264,119 @@ func TestBulkEnable_QuotaExceeded(t *testing.T) {
 	}
 }
 
+// enableStateRepo tracks which cameras are enabled for bulk enable projection.
+type enableStateRepo struct {
+	*MockRepo
+	enabled map[uuid.UUID]bool
+	order   []uuid.UUID // creation order
+}
+
+func newEnableStateRepo(enabled, disabled int) *enableStateRepo {
+	r := &enableStateRepo{MockRepo: &MockRepo{Calls: make(map[string]int)}, enabled: map[uuid.UUID]bool{}}
+	for i := 0; i < enabled+disabled; i++ {
+		id := uuid.New()
+		r.enabled[id] = i < enabled
+		r.order = append(r.order, id)
+	}
+	r.Count = len(r.order)
+	return r
+}
+
+func (m *enableStateRepo) CountEnabled(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	n := 0
+	for _, on := range m.enabled {
+		if on {
+			n++
+		}
+	}
+	return n, nil
+}
+func (m *enableStateRepo) ListDisabledIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	want := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		want[id] = true
+	}
+	var out []uuid.UUID
+	for _, id := range m.order {
+		if on, ok := m.enabled[id]; ok && !on && want[id] {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func TestBulkEnable_ExactProjection(t *testing.T) {
+	// 6 enabled, 4 disabled, limit 8: exactly two more fit.
+	lic := &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10, MaxEnabledCameras: 8}}
+
+	repo := newEnableStateRepo(6, 4)
+	svc := cameras.NewService(repo, lic, &MockAuditor{})
+	// Already-enabled cameras in the request don't count against the limit.
+	ids := append([]uuid.UUID{repo.order[0], repo.order[1]}, repo.order[6:8]...)
+	if err := svc.BulkEnable(context.Background(), uuid.New(), ids); err != nil {
+		t.Fatalf("6 + 2 = 8 should fit, got %v", err)
+	}
+	if repo.Calls["BulkUpdateStatus"] != 1 {
+		t.Error("Expected BulkUpdateStatus call")
+	}
+
+	repo = newEnableStateRepo(6, 4)
+	svc = cameras.NewService(repo, lic, &MockAuditor{})
+	err := svc.BulkEnable(context.Background(), uuid.New(), repo.order[6:9])
+	if !errors.Is(err, cameras.ErrLicenseLimitExceeded) {
+		t.Fatalf("6 + 3 > 8 should be rejected, got %v", err)
+	}
+	if repo.Calls["BulkUpdateStatus"] != 0 {
+		t.Error("A rejected bulk enable must not change anything")
+	}
+}
+
+func TestEnableCamera_EnabledLimit(t *testing.T) {
+	// 8 enabled of 10 cameras: the enabled limit, not inventory, blocks the 9th.
+	lic := &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10, MaxEnabledCameras: 8}}
+	repo := newEnableStateRepo(8, 2)
+	svc := cameras.NewService(repo, lic, &MockAuditor{})
+
+	err := svc.EnableCamera(context.Background(), repo.order[8], uuid.New())
+	if !errors.Is(err, cameras.ErrLicenseLimitExceeded) {
+		t.Fatalf("8 + 1 > 8 should be rejected, got %v", err)
+	}
+	if repo.Calls["SetStatus"] != 0 {
+		t.Error("A rejected enable must not change anything")
+	}
+
+	lic.Limits.MaxEnabledCameras = 9
+	if err := svc.EnableCamera(context.Background(), repo.order[8], uuid.New()); err != nil {
+		t.Fatalf("8 + 1 = 9 should fit, got %v", err)
+	}
+}
+
+func TestBulkEnable_ReportsRejectedIDs(t *testing.T) {
+	lic := &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}
+	repo := newEnableStateRepo(7, 3)
+	svc := cameras.NewService(repo, lic, &MockAuditor{})
+	ids := append(repo.order[7:], uuid.New()) // unknown IDs are ignored
+
+	// Without a separate enabled limit MaxCameras applies: 7 + 3 = 10 fits.
+	if err := svc.BulkEnable(context.Background(), uuid.New(), ids); err != nil {
+		t.Fatalf("7 + 3 = 10 should fit, got %v", err)
+	}
+
+	lic.Limits.MaxEnabledCameras = 8
+	err := svc.BulkEnable(context.Background(), uuid.New(), ids)
+	var qe *cameras.QuotaError
+	if !errors.As(err, &qe) {
+		t.Fatalf("Expected *QuotaError, got %v", err)
+	}
+	if qe.Limit != 8 || qe.Enabled != 7 || qe.Requested != 3 {
+		t.Errorf("Unexpected projection %+v", qe)
+	}
+	// The oldest disabled camera fits; the two newer ones are reported.
+	if len(qe.Rejected) != 2 || qe.Rejected[0] != repo.order[8] || qe.Rejected[1] != repo.order[9] {
+		t.Errorf("Expected the two newest disabled cameras rejected, got %v", qe.Rejected)
+	}
+}
+
 func TestBulkDisable_Success(t *testing.T) {
 	repo := &MockRepo{Calls: make(map[string]int)}
 	lic := &MockLicense{}
@@ -198,16 +418,73 @@ func TestBulkRemoveTags(t *testing.T) {
 	}
 }
 
+func TestBulkSetTags_Dedup(t *testing.T) {
+	repo := &MockRepo{Calls: make(map[string]int)}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{}, aud)
+	err := svc.BulkSetTags(context.Background(), uuid.New(), []uuid.UUID{uuid.New()}, []string{"lobby", "outdoor", "lobby", "", "outdoor"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if strings.Join(repo.LastTags, ",") != "lobby,outdoor" {
+		t.Errorf("Expected [lobby outdoor], got %v", repo.LastTags)
+	}
+	if aud.LastEvent.Action != "camera.bulk.tag_set" {
+		t.Error("Audit mismatch")
+	}
+}
+
+func TestBulkSetTags_EmptyClears(t *testing.T) {
+	repo := &MockRepo{Calls: make(map[string]int)}
+	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
+	if err := svc.BulkSetTags(context.Background(), uuid.New(), []uuid.UUID{uuid.New()}, nil); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if repo.LastTags == nil || len(repo.LastTags) != 0 {
+		t.Errorf("Expected empty non-nil tags, got %#v", repo.LastTags)
+	}
+}
+
 func TestCreateGroup(t *testing.T) {
 	repo := &MockRepo{Calls: make(map[string]int)}
 	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
-	err := svc.CreateGroup(context.Background(), &data.CameraGroup{})
+	err := svc.CreateGroup(context.Background(), &data.CameraGroup{Name: "Lobby"})
 	if err != nil {
 		t.Error(err)
 	}
 	// Verify mock calls if needed (CreateGroup doesn't set Calls in mock yet, usually MockRepo needs generic handling or dedicated field)
 }
 
+func TestCreateGroup_Validation(t *testing.T) {
+	svc := cameras.NewService(&MockRepo{Calls: make(map[string]int)}, &MockLicense{}, &MockAuditor{})
+
+	err := svc.CreateGroup(context.Background(), &data.CameraGroup{Name: "   "})
+	var vErr *cameras.ValidationError
+	if !errors.As(err, &vErr) || vErr.Fields["name"] == "" {
+		t.Fatalf("Expected name validation error, got %v", err)
+	}
+
+	err = svc.CreateGroup(context.Background(), &data.CameraGroup{
+		Name:        "Lobby",
+		Description: strings.Repeat("d", cameras.MaxGroupDescriptionLen+1),
+	})
+	if !errors.As(err, &vErr) || vErr.Fields["description"] == "" {
+		t.Fatalf("Expected description validation error, got %v", err)
+	}
+	if _, ok := vErr.Fields["name"]; ok {
+		t.Error("Valid name should not be flagged")
+	}
+}
+
+func TestCreateGroup_DuplicateName(t *testing.T) {
+	repo := &MockRepo{Calls: make(map[string]int), Err: data.ErrDuplicateGroupName}
+	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
+	err := svc.CreateGroup(context.Background(), &data.CameraGroup{Name: "Lobby"})
+	if !errors.Is(err, cameras.ErrDuplicateGroupName) {
+		t.Errorf("Expected ErrDuplicateGroupName, got %v", err)
+	}
+}
+
 func TestDeleteGroup(t *testing.T) {
 	repo := &MockRepo{Calls: make(map[string]int)}
 	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
@@ -245,6 +522,27 @@ func TestCreateCamera_InvalidIP(t *testing.T) {
 	}
 }
 
+func TestCreateCamera_Port(t *testing.T) {
+	svc := cameras.NewService(&MockRepo{Calls: make(map[string]int)}, &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}, &MockAuditor{})
+	siteID := uuid.New()
+
+	cam := &data.Camera{Name: "Valid", IPAddress: net.ParseIP("10.0.0.1"), SiteID: siteID}
+	if err := svc.CreateCamera(context.Background(), cam); err != nil {
+		t.Fatal(err)
+	}
+	if cam.Port != cameras.DefaultCameraPort {
+		t.Errorf("Expected default port %d, got %d", cameras.DefaultCameraPort, cam.Port)
+	}
+
+	cam = &data.Camera{Name: "Valid", IPAddress: net.ParseIP("10.0.0.2"), SiteID: siteID, Port: 70000}
+	if err := svc.CreateCamera(context.Background(), cam); !errors.Is(err, cameras.ErrInvalidPort) {
+		t.Errorf("Expected ErrInvalidPort, got %v", err)
+	}
+	if err := svc.UpdateCamera(context.Background(), &data.Camera{ID: uuid.New(), Port: 70000}); !errors.Is(err, cameras.ErrInvalidPort) {
+		t.Errorf("Expected ErrInvalidPort on update, got %v", err)
+	}
+}
+
 func TestUpdateCamera(t *testing.T) {
 	aud := &MockAuditor{}
 	svc := cameras.NewService(&MockRepo{}, &MockLicense{}, aud)
@@ -257,6 +555,107 @@ func TestUpdateCamera(t *testing.T) {
 	}
 }
 
+// priorRepo serves a stored camera to UpdateCamera and records the update.
+type priorRepo struct {
+	*MockRepo
+	prior   *data.Camera
+	updated *data.Camera
+}
+
+func (m *priorRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+	c := *m.prior
+	c.Tags = append([]string(nil), m.prior.Tags...)
+	return &c, nil
+}
+func (m *priorRepo) Update(ctx context.Context, c *data.Camera) error {
+	now := time.Now()
+	c.LastChangedAt = &now
+	m.updated = c
+	return nil
+}
+
+func updateChanges(t *testing.T, aud *MockAuditor) map[string]cameras.FieldChange {
+	t.Helper()
+	if aud.LastEvent == nil || aud.LastEvent.Action != "camera.update" {
+		t.Fatal("Expected camera.update audit event")
+	}
+	var meta struct {
+		Changes map[string]cameras.FieldChange `json:"changes"`
+	}
+	if err := json.Unmarshal(aud.LastEvent.Metadata, &meta); err != nil {
+		t.Fatal(err)
+	}
+	return meta.Changes
+}
+
+func TestUpdateCamera_DiffHasExactlyChangedFields(t *testing.T) {
+	tenantID, userID := uuid.New(), uuid.New()
+	prior := &data.Camera{ID: uuid.New(), TenantID: tenantID, SiteID: uuid.New(), Name: "Lobby",
+		IPAddress: net.ParseIP("10.0.0.5"), Port: 80, Tags: []string{"a", "b"}, Manufacturer: "Axis"}
+	repo := &priorRepo{MockRepo: &MockRepo{Calls: make(map[string]int)}, prior: prior}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{}, aud)
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{TenantID: tenantID.String(), UserID: userID.String()})
+
+	// Port and tags change; tag order alone and an unaudited field don't count.
+	next := *prior
+	next.Port = 8080
+	next.Tags = []string{"b", "c"}
+	next.Manufacturer = "Axis Communications"
+	if err := svc.UpdateCamera(ctx, &next); err != nil {
+		t.Fatal(err)
+	}
+	changes := updateChanges(t, aud)
+	if len(changes) != 2 {
+		t.Fatalf("Expected only port and tags, got %v", changes)
+	}
+	if c := changes["port"]; c.From != float64(80) || c.To != float64(8080) {
+		t.Errorf("Unexpected port change %+v", c)
+	}
+	if _, ok := changes["tags"]; !ok {
+		t.Error("Missing tags change")
+	}
+	if repo.updated.LastChangedBy == nil || *repo.updated.LastChangedBy != userID {
+		t.Errorf("Expected last_changed_by %s, got %v", userID, repo.updated.LastChangedBy)
+	}
+	if aud.LastEvent.ActorUserID == nil || *aud.LastEvent.ActorUserID != userID {
+		t.Error("Audit event must carry the actor")
+	}
+
+	// Name and IP change; reordered tags are not a change.
+	next = *prior
+	next.Name = "Lobby East"
+	next.IPAddress = net.ParseIP("10.0.0.6")
+	next.Tags = []string{"b", "a"}
+	if err := svc.UpdateCamera(ctx, &next); err != nil {
+		t.Fatal(err)
+	}
+	changes = updateChanges(t, aud)
+	for _, f := range []string{"name", "ip_address"} {
+		if _, ok := changes[f]; !ok {
+			t.Errorf("Missing %s change", f)
+		}
+	}
+	if len(changes) != 2 {
+		t.Errorf("Expected exactly name and ip_address, got %v", changes)
+	}
+	if c := changes["ip_address"]; c.From != "10.0.0.5" || c.To != "10.0.0.6" {
+		t.Errorf("Unexpected ip change %+v", c)
+	}
+
+	// No-op update: empty diff, no actor for a system caller.
+	next = *prior
+	if err := svc.UpdateCamera(context.Background(), &next); err != nil {
+		t.Fatal(err)
+	}
+	if changes := updateChanges(t, aud); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+	if repo.updated.LastChangedBy != nil {
+		t.Error("System updates have no last_changed_by")
+	}
+}
+
 func TestDeleteCamera(t *testing.T) {
 	aud := &MockAuditor{}
 	svc := cameras.NewService(&MockRepo{}, &MockLicense{}, aud)
@@ -270,3 +669,888 @@ func TestDeleteCamera(t *testing.T) {
 }
 
 func testIP() net.IP { return net.ParseIP("192.168.1.1") }
+
+// moveRepo overrides site-move behaviour on top of MockRepo
+type moveRepo struct {
+	*MockRepo
+	siteOK    bool
+	conflicts []data.CameraSiteConflict
+	movedIDs  []uuid.UUID
+
+	ipConflicts []uuid.UUID
+}
+
+func (m *moveRepo) SiteBelongsToTenant(ctx context.Context, siteID, tenantID uuid.UUID) (bool, error) {
+	return m.siteOK, nil
+}
+func (m *moveRepo) ListNVRSiteConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]data.CameraSiteConflict, error) {
+	return m.conflicts, nil
+}
+func (m *moveRepo) ListSiteIPConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]uuid.UUID, error) {
+	return m.ipConflicts, nil
+}
+func (m *moveRepo) BulkMoveSite(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) (int, error) {
+	m.movedIDs = ids
+	return len(ids), nil
+}
+
+func TestBulkMoveSite_RejectsForeignSite(t *testing.T) {
+	repo := &moveRepo{MockRepo: &MockRepo{Calls: make(map[string]int)}, siteOK: false}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{}, aud)
+
+	_, err := svc.BulkMoveSite(context.Background(), uuid.New(), []uuid.UUID{uuid.New()}, uuid.New())
+	if !errors.Is(err, cameras.ErrSiteScopeMismatch) {
+		t.Fatalf("Expected ErrSiteScopeMismatch, got %v", err)
+	}
+	if repo.movedIDs != nil {
+		t.Error("No cameras should be moved when site validation fails")
+	}
+	if aud.LastEvent != nil {
+		t.Error("No audit event expected on validation failure")
+	}
+}
+
+func TestBulkMoveSite_FlagsNVRSiteConflicts(t *testing.T) {
+	camA, camB, camC := uuid.New(), uuid.New(), uuid.New()
+	nvrSite := uuid.New()
+	repo := &moveRepo{
+		MockRepo:  &MockRepo{Calls: make(map[string]int)},
+		siteOK:    true,
+		conflicts: []data.CameraSiteConflict{{CameraID: camB, NVRID: uuid.New(), NVRSiteID: nvrSite}},
+	}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{}, aud)
+
+	res, err := svc.BulkMoveSite(context.Background(), uuid.New(), []uuid.UUID{camA, camB, camC}, uuid.New())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Moved != 2 {
+		t.Errorf("Expected 2 moved, got %d", res.Moved)
+	}
+	if len(res.Flagged) != 1 || res.Flagged[0].CameraID != camB {
+		t.Errorf("Expected camB flagged, got %+v", res.Flagged)
+	}
+	for _, id := range repo.movedIDs {
+		if id == camB {
+			t.Error("Flagged camera must not be moved")
+		}
+	}
+	if aud.LastEvent == nil || aud.LastEvent.Action != "camera.bulk.move_site" {
+		t.Error("Expected camera.bulk.move_site audit event")
+	}
+}
+
+func TestBulkMoveSite_AllFlaggedSkipsUpdate(t *testing.T) {
+	cam := uuid.New()
+	repo := &moveRepo{
+		MockRepo:  &MockRepo{Calls: make(map[string]int)},
+		siteOK:    true,
+		conflicts: []data.CameraSiteConflict{{CameraID: cam}},
+	}
+	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
+
+	res, err := svc.BulkMoveSite(context.Background(), uuid.New(), []uuid.UUID{cam}, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Moved != 0 || repo.movedIDs != nil {
+		t.Error("Expected no update when every camera is flagged")
+	}
+}
+
+// quotaRepo serves enabled and license-disabled cameras per tenant for
+// license reconciliation
+type quotaRepo struct {
+	*MockRepo
+	enabled  map[uuid.UUID][]uuid.UUID
+	marked   map[uuid.UUID][]uuid.UUID // license-disabled, oldest first
+	disabled map[uuid.UUID][]uuid.UUID
+	restored map[uuid.UUID][]uuid.UUID
+}
+
+func (m *quotaRepo) ListTenantsWithEnabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	var out []uuid.UUID
+	for id := range m.enabled {
+		out = append(out, id)
+	}
+	return out, nil
+}
+func (m *quotaRepo) ListEnabledIDsByPriority(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return m.enabled[tenantID], nil
+}
+func (m *quotaRepo) DisableForLicense(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error {
+	m.disabled[tenantID] = append(m.disabled[tenantID], ids...)
+	return nil
+}
+func (m *quotaRepo) ListTenantsWithLicenseDisabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	var out []uuid.UUID
+	for id := range m.marked {
+		out = append(out, id)
+	}
+	return out, nil
+}
+func (m *quotaRepo) ListLicenseDisabledIDs(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return m.marked[tenantID], nil
+}
+func (m *quotaRepo) BulkUpdateStatus(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, enabled bool) error {
+	if enabled {
+		m.restored[tenantID] = append(m.restored[tenantID], ids...)
+	}
+	return nil
+}
+
+func newQuotaRepo() *quotaRepo {
+	return &quotaRepo{
+		MockRepo: &MockRepo{Calls: make(map[string]int)},
+		enabled:  map[uuid.UUID][]uuid.UUID{},
+		marked:   map[uuid.UUID][]uuid.UUID{},
+		disabled: map[uuid.UUID][]uuid.UUID{},
+		restored: map[uuid.UUID][]uuid.UUID{},
+	}
+}
+
+func TestReconcileLicenseQuota_DisablesLowestPriority(t *testing.T) {
+	over, within := uuid.New(), uuid.New()
+	overCams := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	repo := newQuotaRepo()
+	repo.enabled[over] = overCams
+	repo.enabled[within] = []uuid.UUID{uuid.New(), uuid.New()}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{Limits: license.LicenseLimits{MaxCameras: 3}}, aud)
+
+	disabled, restored, err := svc.ReconcileLicenseQuota(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if disabled != 2 || restored != 0 {
+		t.Errorf("Expected 2 cameras disabled and none restored, got %d/%d", disabled, restored)
+	}
+	got := repo.disabled[over]
+	if len(got) != 2 || got[0] != overCams[3] || got[1] != overCams[4] {
+		t.Errorf("Expected the two lowest-priority cameras disabled, got %v", got)
+	}
+	if len(repo.disabled[within]) != 0 {
+		t.Error("Tenant within quota must not be touched")
+	}
+	if aud.LastEvent == nil || aud.LastEvent.Action != "camera.license.auto_disable" {
+		t.Error("Expected camera.license.auto_disable audit event")
+	}
+	if aud.LastEvent != nil && !audit.IsSystemActor(aud.LastEvent.ActorUserID) {
+		t.Errorf("Expected system actor, got %v", aud.LastEvent.ActorUserID)
+	}
+}
+
+func TestReconcileLicenseQuota_WithinQuotaNoop(t *testing.T) {
+	repo := newQuotaRepo()
+	repo.enabled[uuid.New()] = []uuid.UUID{uuid.New()}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}, aud)
+
+	disabled, restored, err := svc.ReconcileLicenseQuota(context.Background())
+	if err != nil || disabled != 0 || restored != 0 {
+		t.Fatalf("Expected no-op, got %d/%d err=%v", disabled, restored, err)
+	}
+	if aud.LastEvent != nil {
+		t.Error("No audit event expected when within quota")
+	}
+}
+
+func TestReconcileLicenseQuota_RestoresLicenseDisabled(t *testing.T) {
+	tenant, idle := uuid.New(), uuid.New()
+	marked := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	repo := newQuotaRepo()
+	repo.enabled[tenant] = []uuid.UUID{uuid.New(), uuid.New()}
+	repo.marked[tenant] = marked
+	// A tenant whose every camera was auto-disabled is still reconciled
+	repo.marked[idle] = []uuid.UUID{uuid.New()}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{Limits: license.LicenseLimits{MaxCameras: 4}}, aud)
+
+	disabled, restored, err := svc.ReconcileLicenseQuota(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if disabled != 0 || restored != 3 {
+		t.Errorf("Expected 3 cameras restored, got disabled=%d restored=%d", disabled, restored)
+	}
+	got := repo.restored[tenant]
+	if len(got) != 2 || got[0] != marked[0] || got[1] != marked[1] {
+		t.Errorf("Expected the two oldest license-disabled cameras restored, got %v", got)
+	}
+	if len(repo.restored[idle]) != 1 {
+		t.Errorf("Expected the idle tenant's camera restored, got %v", repo.restored[idle])
+	}
+	if aud.LastEvent == nil || aud.LastEvent.Action != "camera.license.auto_restore" {
+		t.Error("Expected camera.license.auto_restore audit event")
+	}
+	if aud.LastEvent != nil && !audit.IsSystemActor(aud.LastEvent.ActorUserID) {
+		t.Errorf("Expected system actor, got %v", aud.LastEvent.ActorUserID)
+	}
+}
+
+type cloneRepo struct {
+	*MockRepo
+	cams map[uuid.UUID]*data.Camera
+}
+
+func (m *cloneRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+	if c, ok := m.cams[id]; ok {
+		return c, nil
+	}
+	return nil, data.ErrRecordNotFound
+}
+func (m *cloneRepo) Create(ctx context.Context, c *data.Camera) error {
+	c.ID = uuid.New()
+	m.cams[c.ID] = c
+	return nil
+}
+
+func (m *cloneRepo) CameraIPInSite(ctx context.Context, tenantID, siteID uuid.UUID, ip net.IP, excludeID uuid.UUID) (bool, error) {
+	for _, c := range m.cams {
+		if c.TenantID == tenantID && c.SiteID == siteID && c.IPAddress.Equal(ip) && c.ID != excludeID && c.DeletedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type selectionMap map[uuid.UUID]*data.CameraStreamSelection
+
+func (m selectionMap) GetSelection(ctx context.Context, cameraID uuid.UUID) (*data.CameraStreamSelection, error) {
+	return m[cameraID], nil
+}
+func (m selectionMap) UpsertSelection(ctx context.Context, s *data.CameraStreamSelection) error {
+	m[s.CameraID] = s
+	return nil
+}
+
+func TestClone_CopiesConfigWithFreshCredentials(t *testing.T) {
+	key, _ := crypto.GenerateDEK()
+	t.Setenv("MASTER_KEYS", `[{"kid":"test-v1","material":"`+base64.StdEncoding.EncodeToString(key)+`"}]`)
+	t.Setenv("ACTIVE_MASTER_KID", "test-v1")
+	kr := crypto.NewKeyring()
+	kr.LoadFromEnv()
+
+	tenantID := uuid.New()
+	src := &data.Camera{
+		ID: uuid.New(), TenantID: tenantID, SiteID: uuid.New(), Name: "Dock 1",
+		IPAddress: net.ParseIP("10.0.0.5"), Port: 554, Manufacturer: "Axis", Model: "P1375",
+		SerialNumber: "OLD-SERIAL", IsEnabled: true, Tags: []string{"dock", "outdoor"},
+	}
+	repo := &cloneRepo{MockRepo: &MockRepo{Calls: make(map[string]int)}, cams: map[uuid.UUID]*data.Camera{src.ID: src}}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}, aud)
+
+	credRepo := &MockCredRepo{Store: make(map[string]*data.CameraCredential)}
+	credSvc := cameras.NewCredentialService(credRepo, kr, &MockCredAuditor{})
+	if err := credSvc.SetCredentials(context.Background(), tenantID, src.ID, cameras.CredentialInput{Username: "admin", Password: "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	sels := selectionMap{src.ID: {
+		TenantID: tenantID, CameraID: src.ID, MainProfileToken: "main", SubProfileToken: "sub",
+		MainRTSP: "rtsp://10.0.0.5:554/main", SubRTSP: "rtsp://10.0.0.5/sub", MainSupported: true,
+	}}
+	svc.SetCloneSources(credSvc, sels)
+
+	clone, err := svc.Clone(context.Background(), src.ID, tenantID, net.ParseIP("10.0.0.9"), "Dock 1 (replacement)")
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	if clone.ID == src.ID || clone.SiteID != src.SiteID || clone.Port != 554 || clone.Manufacturer != "Axis" || clone.Model != "P1375" {
+		t.Errorf("Clone did not copy configuration: %+v", clone)
+	}
+	if clone.SerialNumber != "" {
+		t.Error("Serial number identifies the old unit and must not be copied")
+	}
+	if len(clone.Tags) != 2 || clone.Tags[0] != "dock" || clone.Tags[1] != "outdoor" {
+		t.Errorf("Expected tags copied, got %v", clone.Tags)
+	}
+	clone.Tags[0] = "mutated"
+	if src.Tags[0] != "dock" {
+		t.Error("Clone must not share the source tag slice")
+	}
+
+	srcCred, cloneCred := credRepo.Store[src.ID.String()], credRepo.Store[clone.ID.String()]
+	if cloneCred == nil {
+		t.Fatal("Expected credentials for clone")
+	}
+	if bytes.Equal(srcCred.DataCiphertext, cloneCred.DataCiphertext) || bytes.Equal(srcCred.DEKCiphertext, cloneCred.DEKCiphertext) {
+		t.Error("Clone must not share ciphertext with the source")
+	}
+	out, found, err := credSvc.GetCredentials(context.Background(), tenantID, clone.ID, true)
+	if err != nil || !found || out.Data.Username != "admin" || out.Data.Password != "s3cret" {
+		t.Errorf("Clone credentials should decrypt under the clone's binding: %+v, %v", out, err)
+	}
+
+	sel := sels[clone.ID]
+	if sel == nil || sel.MainProfileToken != "main" || sel.MainRTSP != "rtsp://10.0.0.9:554/main" || sel.SubRTSP != "rtsp://10.0.0.9/sub" {
+		t.Errorf("Expected media selection copied to the new IP, got %+v", sel)
+	}
+
+	if aud.LastEvent == nil || aud.LastEvent.Action != "camera.clone" || aud.LastEvent.TargetID != clone.ID.String() ||
+		!strings.Contains(string(aud.LastEvent.Metadata), src.ID.String()) {
+		t.Errorf("Expected camera.clone audit linking source and clone, got %+v", aud.LastEvent)
+	}
+}
+
+func TestClone_ForeignTenantAndQuota(t *testing.T) {
+	src := &data.Camera{ID: uuid.New(), TenantID: uuid.New(), SiteID: uuid.New(), Name: "Src", IPAddress: net.ParseIP("10.0.0.5")}
+	repo := &cloneRepo{MockRepo: &MockRepo{Calls: make(map[string]int), Count: 3}, cams: map[uuid.UUID]*data.Camera{src.ID: src}}
+	svc := cameras.NewService(repo, &MockLicense{Limits: license.LicenseLimits{MaxCameras: 3}}, &MockAuditor{})
+
+	if _, err := svc.Clone(context.Background(), src.ID, uuid.New(), net.ParseIP("10.0.0.9"), "X"); !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for foreign tenant, got %v", err)
+	}
+	if _, err := svc.Clone(context.Background(), src.ID, src.TenantID, net.ParseIP("10.0.0.9"), "X"); !errors.Is(err, cameras.ErrLicenseLimitExceeded) {
+		t.Errorf("Expected license limit error, got %v", err)
+	}
+	if len(repo.cams) != 1 {
+		t.Error("No camera should be created")
+	}
+}
+
+func TestCreateCamera_DuplicateIPWithinSite(t *testing.T) {
+	tenantID, siteA, siteB := uuid.New(), uuid.New(), uuid.New()
+	existing := &data.Camera{ID: uuid.New(), TenantID: tenantID, SiteID: siteA, Name: "Gate", IPAddress: net.ParseIP("10.0.0.5")}
+	repo := &cloneRepo{MockRepo: &MockRepo{Calls: make(map[string]int)}, cams: map[uuid.UUID]*data.Camera{existing.ID: existing}}
+	svc := cameras.NewService(repo, &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}, &MockAuditor{})
+
+	dup := &data.Camera{TenantID: tenantID, SiteID: siteA, Name: "Gate 2", IPAddress: net.ParseIP("10.0.0.5")}
+	if err := svc.CreateCamera(context.Background(), dup); !errors.Is(err, cameras.ErrDuplicateIP) {
+		t.Fatalf("Expected ErrDuplicateIP in the same site, got %v", err)
+	}
+
+	other := &data.Camera{TenantID: tenantID, SiteID: siteB, Name: "Gate 2", IPAddress: net.ParseIP("10.0.0.5")}
+	if err := svc.CreateCamera(context.Background(), other); err != nil {
+		t.Fatalf("Same IP in a different site should be allowed, got %v", err)
+	}
+
+	svc.SetUniqueIPPerSite(false)
+	if err := svc.CreateCamera(context.Background(), dup); err != nil {
+		t.Errorf("Duplicate IP should be allowed with the check disabled, got %v", err)
+	}
+}
+
+func TestBulkMoveSite_RejectsDuplicateIP(t *testing.T) {
+	cam := uuid.New()
+	repo := &moveRepo{
+		MockRepo:    &MockRepo{Calls: make(map[string]int)},
+		siteOK:      true,
+		ipConflicts: []uuid.UUID{cam},
+	}
+	aud := &MockAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{}, aud)
+
+	_, err := svc.BulkMoveSite(context.Background(), uuid.New(), []uuid.UUID{cam, uuid.New()}, uuid.New())
+	if !errors.Is(err, cameras.ErrDuplicateIP) {
+		t.Fatalf("Expected ErrDuplicateIP, got %v", err)
+	}
+	if repo.movedIDs != nil || aud.LastEvent != nil {
+		t.Error("No camera should move when the target site has a duplicate IP")
+	}
+}
+
+// tagRepo records the arguments ListTags is called with
+type tagRepo struct {
+	*MockRepo
+	prefix string
+	limit  int
+}
+
+func (m *tagRepo) ListTags(ctx context.Context, tenantID uuid.UUID, prefix string, limit int) ([]data.TagCount, error) {
+	m.prefix, m.limit = prefix, limit
+	return nil, nil
+}
+
+func TestListTags_ClampsLimit(t *testing.T) {
+	repo := &tagRepo{MockRepo: &MockRepo{Calls: make(map[string]int)}}
+	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
+
+	cases := []struct{ in, want int }{
+		{0, cameras.DefaultTagSuggestions},
+		{5, 5},
+		{10000, cameras.MaxTagSuggestions},
+	}
+	for _, c := range cases {
+		if _, err := svc.ListTags(context.Background(), uuid.New(), " ent ", c.in); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if repo.limit != c.want {
+			t.Errorf("limit %d: expected %d, got %d", c.in, c.want, repo.limit)
+		}
+		if repo.prefix != "ent" {
+			t.Errorf("Expected trimmed prefix, got %q", repo.prefix)
+		}
+	}
+}
+
+// thumbRepo serves enabled cameras and their last thumbnail capture
+type thumbRepo struct {
+	*MockRepo
+	cams     map[uuid.UUID]*data.Camera
+	captured map[uuid.UUID]time.Time
+}
+
+func (m *thumbRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+	if c, ok := m.cams[id]; ok {
+		return c, nil
+	}
+	return nil, data.ErrRecordNotFound
+}
+func (m *thumbRepo) ListDueForThumbnail(ctx context.Context, cutoff time.Time, limit int) ([]data.ThumbnailDue, error) {
+	var out []data.ThumbnailDue
+	for _, c := range m.cams {
+		if !c.IsEnabled || c.DeletedAt != nil {
+			continue
+		}
+		at, ok := m.captured[c.ID]
+		if ok && !at.Before(cutoff) {
+			continue
+		}
+		d := data.ThumbnailDue{CameraID: c.ID, TenantID: c.TenantID}
+		if ok {
+			d.LastCapturedAt = &at
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+func (m *thumbRepo) MarkThumbnailCaptured(ctx context.Context, tenantID, cameraID uuid.UUID, capturedAt time.Time) error {
+	m.captured[cameraID] = capturedAt
+	return nil
+}
+
+func TestListDueForThumbnail(t *testing.T) {
+	tenantID := uuid.New()
+	fresh := &data.Camera{ID: uuid.New(), TenantID: tenantID, IsEnabled: true}
+	stale := &data.Camera{ID: uuid.New(), TenantID: tenantID, IsEnabled: true}
+	never := &data.Camera{ID: uuid.New(), TenantID: tenantID, IsEnabled: true}
+	disabled := &data.Camera{ID: uuid.New(), TenantID: tenantID, IsEnabled: false}
+	repo := &thumbRepo{
+		MockRepo: &MockRepo{Calls: make(map[string]int)},
+		cams:     map[uuid.UUID]*data.Camera{fresh.ID: fresh, stale.ID: stale, never.ID: never, disabled.ID: disabled},
+		captured: map[uuid.UUID]time.Time{},
+	}
+	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
+
+	ctx := context.Background()
+	if err := svc.MarkThumbnailCaptured(ctx, fresh.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("MarkThumbnailCaptured failed: %v", err)
+	}
+	svc.MarkThumbnailCaptured(ctx, stale.ID, time.Now().Add(-time.Hour))
+
+	due, err := svc.ListDueForThumbnail(ctx, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := map[uuid.UUID]bool{}
+	for _, d := range due {
+		got[d.CameraID] = true
+	}
+	if got[fresh.ID] {
+		t.Error("Camera with a recent thumbnail should be excluded")
+	}
+	if !got[stale.ID] || !got[never.ID] {
+		t.Errorf("Stale and never-captured cameras should be due, got %v", due)
+	}
+	if got[disabled.ID] {
+		t.Error("Disabled camera should be excluded")
+	}
+
+	if _, err := svc.ListDueForThumbnail(ctx, 0); err == nil {
+		t.Error("Expected error for non-positive interval")
+	}
+	if err := svc.MarkThumbnailCaptured(ctx, uuid.New(), time.Now()); !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for unknown camera, got %v", err)
+	}
+}
+
+// tagCapRepo applies the BulkAddTags cap to cameras with existing tags, the
+// way the set-based UPDATE does, and records whether anything was added
+type tagCapRepo struct {
+	*MockRepo
+	cams  map[uuid.UUID]*data.Camera
+	added bool
+}
+
+func (m *tagCapRepo) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string, maxTags int) ([]uuid.UUID, error) {
+	var over []uuid.UUID
+	for _, id := range ids {
+		c, ok := m.cams[id]
+		if !ok || c.TenantID != tenantID {
+			continue
+		}
+		distinct := map[string]bool{}
+		for _, t := range append(append([]string(nil), c.Tags...), tags...) {
+			distinct[t] = true
+		}
+		if len(distinct) > maxTags {
+			over = append(over, id)
+		}
+	}
+	if len(over) == 0 {
+		m.added = true
+	}
+	return over, nil
+}
+
+func TestBulkAddTags_CapIncludesExistingTags(t *testing.T) {
+	tenantID := uuid.New()
+	full, roomy := uuid.New(), uuid.New()
+	repo := &tagCapRepo{
+		MockRepo: &MockRepo{Calls: make(map[string]int)},
+		cams: map[uuid.UUID]*data.Camera{
+			full:  {ID: full, TenantID: tenantID, Tags: []string{"a", "b"}},
+			roomy: {ID: roomy, TenantID: tenantID},
+		},
+	}
+	svc := cameras.NewService(repo, &MockLicense{}, &MockAuditor{})
+	svc.SetMaxTagsPerCamera(3)
+
+	// 2 existing + 2 new = 4 > 3, even though the request alone is within the cap
+	err := svc.BulkAddTags(context.Background(), tenantID, []uuid.UUID{roomy, full}, []string{"c", "d"})
+	if !errors.Is(err, cameras.ErrTooManyTags) || !strings.Contains(err.Error(), full.String()) {
+		t.Fatalf("Expected ErrTooManyTags naming the full camera, got %v", err)
+	}
+	if repo.added {
+		t.Error("No camera should be updated when one exceeds the cap")
+	}
+
+	// Re-adding an existing tag does not count twice: {a, b, c}
+	if err := svc.BulkAddTags(context.Background(), tenantID, []uuid.UUID{full}, []string{"a", "c"}); err != nil {
+		t.Fatalf("Expected success at the cap, got %v", err)
+	}
+	if !repo.added {
+		t.Error("Expected tags to be added")
+	}
+}
+
+func TestTagCap_CreateAndTagSet(t *testing.T) {
+	repo := &MockRepo{Calls: make(map[string]int)}
+	lic := &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}
+	svc := cameras.NewService(repo, lic, &MockAuditor{})
+	svc.SetMaxTagsPerCamera(2)
+
+	cam := &data.Camera{TenantID: uuid.New(), Name: "Tagged", IPAddress: testIP(), Tags: []string{"a", "b", "c"}}
+	if err := svc.CreateCamera(context.Background(), cam); !errors.Is(err, cameras.ErrTooManyTags) {
+		t.Errorf("Create: expected ErrTooManyTags, got %v", err)
+	}
+	if repo.Calls["Create"] != 0 {
+		t.Error("Camera should not be created")
+	}
+
+	if err := svc.BulkSetTags(context.Background(), uuid.New(), []uuid.UUID{uuid.New()}, []string{"a", "b", "c"}); !errors.Is(err, cameras.ErrTooManyTags) {
+		t.Errorf("tag_set: expected ErrTooManyTags, got %v", err)
+	}
+	// Duplicates collapse before the cap is applied
+	if err := svc.BulkSetTags(context.Background(), uuid.New(), []uuid.UUID{uuid.New()}, []string{"a", "b", "a"}); err != nil {
+		t.Errorf("tag_set: expected success, got %v", err)
+	}
+}
+
+// purgeRepo serves soft-deleted cameras and tracks NVR link/credential references
+type purgeRepo struct {
+	*MockRepo
+	cams map[uuid.UUID]*data.Camera
+	refs map[uuid.UUID]bool
+}
+
+func (m *purgeRepo) ListPurgeable(ctx context.Context, cutoff time.Time, limit int) ([]data.PurgeCandidate, error) {
+	var out []data.PurgeCandidate
+	for _, c := range m.cams {
+		if c.DeletedAt == nil || !c.DeletedAt.Before(cutoff) || m.refs[c.ID] {
+			continue
+		}
+		out = append(out, data.PurgeCandidate{CameraID: c.ID, TenantID: c.TenantID, DeletedAt: *c.DeletedAt})
+	}
+	return out, nil
+}
+func (m *purgeRepo) HardDelete(ctx context.Context, id, tenantID uuid.UUID, cutoff time.Time) error {
+	c, ok := m.cams[id]
+	if !ok || c.TenantID != tenantID || c.DeletedAt == nil || !c.DeletedAt.Before(cutoff) || m.refs[id] {
+		return data.ErrRecordNotFound
+	}
+	delete(m.cams, id)
+	return nil
+}
+
+func TestPurgeDeletedCameras(t *testing.T) {
+	tenantID := uuid.New()
+	at := func(ago time.Duration) *time.Time { ts := time.Now().Add(-ago); return &ts }
+	recent := &data.Camera{ID: uuid.New(), TenantID: tenantID, DeletedAt: at(24 * time.Hour)}
+	expired := &data.Camera{ID: uuid.New(), TenantID: tenantID, DeletedAt: at(31 * 24 * time.Hour)}
+	linked := &data.Camera{ID: uuid.New(), TenantID: tenantID, DeletedAt: at(40 * 24 * time.Hour)}
+	live := &data.Camera{ID: uuid.New(), TenantID: tenantID}
+	repo := &purgeRepo{
+		MockRepo: &MockRepo{Calls: make(map[string]int)},
+		cams:     map[uuid.UUID]*data.Camera{recent.ID: recent, expired.ID: expired, linked.ID: linked, live.ID: live},
+		refs:     map[uuid.UUID]bool{linked.ID: true},
+	}
+	aud := &MockCredAuditor{}
+	svc := cameras.NewService(repo, &MockLicense{}, aud)
+
+	n, err := svc.PurgeDeletedCameras(context.Background(), cameras.DefaultDeletedRetention)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 camera purged, got %d", n)
+	}
+	if _, ok := repo.cams[expired.ID]; ok {
+		t.Error("Camera deleted past the retention with no references should be purged")
+	}
+	if _, ok := repo.cams[recent.ID]; !ok {
+		t.Error("Camera within the grace window should survive")
+	}
+	if _, ok := repo.cams[linked.ID]; !ok {
+		t.Error("Camera still referenced by links or credentials should survive")
+	}
+	if _, ok := repo.cams[live.ID]; !ok {
+		t.Error("Camera that is not deleted should survive")
+	}
+
+	if len(aud.Events) != 1 || aud.Events[0].Action != "camera.purge" || aud.Events[0].TargetID != expired.ID.String() {
+		t.Errorf("Expected one camera.purge event for the purged camera, got %+v", aud.Events)
+	}
+	if len(aud.Events) == 1 && !audit.IsSystemActor(aud.Events[0].ActorUserID) {
+		t.Errorf("Expected the purge to be attributed to the system actor, got %v", aud.Events[0].ActorUserID)
+	}
+
+	if _, err := svc.PurgeDeletedCameras(context.Background(), 0); err == nil {
+		t.Error("Expected error for non-positive retention")
+	}
+}
+
+// defaultSiteRepo configures the tenant default site and which sites the tenant owns
+type defaultSiteRepo struct {
+	*MockRepo
+	defaultSite *uuid.UUID
+	owned       map[uuid.UUID]bool
+}
+
+func (m *defaultSiteRepo) GetDefaultSiteID(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error) {
+	return m.defaultSite, nil
+}
+func (m *defaultSiteRepo) SiteBelongsToTenant(ctx context.Context, siteID, tenantID uuid.UUID) (bool, error) {
+	return m.owned[siteID], nil
+}
+
+func TestCreateCamera_DefaultSite(t *testing.T) {
+	siteID := uuid.New()
+	lic := &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}
+
+	repo := &defaultSiteRepo{MockRepo: &MockRepo{Calls: make(map[string]int)}, defaultSite: &siteID, owned: map[uuid.UUID]bool{siteID: true}}
+	svc := cameras.NewService(repo, lic, &MockAuditor{})
+	cam := &data.Camera{TenantID: uuid.New(), Name: "Test Cam", IPAddress: testIP()}
+	if err := svc.CreateCamera(context.Background(), cam); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if cam.SiteID != siteID {
+		t.Errorf("Expected default site %s, got %s", siteID, cam.SiteID)
+	}
+
+	// Explicit site wins over the default
+	explicit := uuid.New()
+	repo.owned[explicit] = true
+	cam = &data.Camera{TenantID: uuid.New(), SiteID: explicit, Name: "Test Cam", IPAddress: testIP()}
+	if err := svc.CreateCamera(context.Background(), cam); err != nil || cam.SiteID != explicit {
+		t.Errorf("Expected explicit site kept, got %s (%v)", cam.SiteID, err)
+	}
+
+	// An explicit site of another tenant is refused
+	cam = &data.Camera{TenantID: uuid.New(), SiteID: uuid.New(), Name: "Test Cam", IPAddress: testIP()}
+	if err := svc.CreateCamera(context.Background(), cam); !errors.Is(err, cameras.ErrSiteScopeMismatch) {
+		t.Errorf("Expected ErrSiteScopeMismatch for a foreign site, got %v", err)
+	}
+
+	// A default pointing at another tenant's site is refused
+	repo.owned = map[uuid.UUID]bool{}
+	cam = &data.Camera{TenantID: uuid.New(), Name: "Test Cam", IPAddress: testIP()}
+	if err := svc.CreateCamera(context.Background(), cam); !errors.Is(err, cameras.ErrSiteScopeMismatch) {
+		t.Errorf("Expected ErrSiteScopeMismatch, got %v", err)
+	}
+
+	// No site and no default
+	repo.defaultSite = nil
+	if err := svc.CreateCamera(context.Background(), cam); !errors.Is(err, cameras.ErrSiteRequired) {
+		t.Errorf("Expected ErrSiteRequired, got %v", err)
+	}
+	if repo.Calls["Create"] != 2 {
+		t.Errorf("Expected 2 creates, got %d", repo.Calls["Create"])
+	}
+}
+
+func TestCreateCamera_FiresSignedWebhook(t *testing.T) {
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	var attempts int32
+	got := make(chan delivery, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// First attempt fails; the dispatcher must retry
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		got <- delivery{header: r.Header.Clone(), body: body}
+	}))
+	defer srv.Close()
+
+	d := webhook.NewDispatcher(webhook.Config{URL: srv.URL, Secret: "s3cret", InitialBackoff: 10 * time.Millisecond})
+	d.Start()
+	defer d.Stop()
+
+	lic := &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}
+	svc := cameras.NewService(&MockRepo{Calls: make(map[string]int)}, lic, &MockAuditor{})
+	svc.SetWebhooks(d)
+
+	cam := &data.Camera{ID: uuid.New(), TenantID: uuid.New(), SiteID: uuid.New(), Name: "Lobby", IPAddress: testIP(), Port: 80, Tags: []string{"entrance"}}
+	if err := svc.CreateCamera(context.Background(), cam); err != nil {
+		t.Fatalf("CreateCamera: %v", err)
+	}
+
+	var dl delivery
+	select {
+	case dl = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook not delivered")
+	}
+	if attempts != 2 {
+		t.Errorf("Expected delivery on the 2nd attempt, got %d attempts", attempts)
+	}
+
+	ts, _ := strconv.ParseInt(dl.header.Get(webhook.HeaderTimestamp), 10, 64)
+	if sig := dl.header.Get(webhook.HeaderSignature); sig != webhook.Sign("s3cret", ts, dl.body) {
+		t.Errorf("Bad signature %q", sig)
+	}
+	if ev := dl.header.Get(webhook.HeaderEvent); ev != cameras.WebhookCameraCreated {
+		t.Errorf("Expected event %s, got %s", cameras.WebhookCameraCreated, ev)
+	}
+
+	var payload struct {
+		Type     string                    `json:"type"`
+		TenantID uuid.UUID                 `json:"tenant_id"`
+		Data     cameras.CameraWebhookData `json:"data"`
+	}
+	if err := json.Unmarshal(dl.body, &payload); err != nil {
+		t.Fatalf("Bad payload: %v", err)
+	}
+	if payload.Type != cameras.WebhookCameraCreated || payload.TenantID != cam.TenantID {
+		t.Errorf("Unexpected envelope %+v", payload)
+	}
+	if payload.Data.ID != cam.ID || payload.Data.SiteID != cam.SiteID || payload.Data.Name != "Lobby" ||
+		!payload.Data.IPAddress.Equal(cam.IPAddress) || len(payload.Data.Tags) != 1 {
+		t.Errorf("Unexpected camera data %+v", payload.Data)
+	}
+}
+
+// webhookRepo keeps camera state so camera.updated carries what was written
+type webhookRepo struct {
+	*MockRepo
+	cams map[uuid.UUID]*data.Camera
+}
+
+func (m *webhookRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+	if c, ok := m.cams[id]; ok {
+		cp := *c
+		return &cp, nil
+	}
+	return nil, data.ErrRecordNotFound
+}
+func (m *webhookRepo) ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*data.Camera, error) {
+	m.Calls["ListByIDs"]++
+	var out []*data.Camera
+	for _, id := range ids {
+		if c, ok := m.cams[id]; ok && c.TenantID == tenantID {
+			cp := *c
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+func (m *webhookRepo) SetStatus(ctx context.Context, id, tenantID uuid.UUID, enabled bool) error {
+	return m.BulkUpdateStatus(ctx, tenantID, []uuid.UUID{id}, enabled)
+}
+func (m *webhookRepo) BulkUpdateStatus(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, enabled bool) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	for _, id := range ids {
+		if c, ok := m.cams[id]; ok && c.TenantID == tenantID {
+			c.IsEnabled = enabled
+		}
+	}
+	return nil
+}
+func (m *webhookRepo) BulkSetTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
+	for _, id := range ids {
+		if c, ok := m.cams[id]; ok && c.TenantID == tenantID {
+			c.Tags = tags
+		}
+	}
+	return m.Err
+}
+
+// recordingWebhooks captures queued webhook events
+type recordingWebhooks struct{ events []webhook.Event }
+
+func (r *recordingWebhooks) Enqueue(evt webhook.Event) bool {
+	r.events = append(r.events, evt)
+	return true
+}
+
+func TestCameraUpdatedWebhook_StatusAndBulkChanges(t *testing.T) {
+	tenantID := uuid.New()
+	a, b, foreign := uuid.New(), uuid.New(), uuid.New()
+	repo := &webhookRepo{MockRepo: &MockRepo{Calls: make(map[string]int)}, cams: map[uuid.UUID]*data.Camera{
+		a:       {ID: a, TenantID: tenantID, IsEnabled: true},
+		b:       {ID: b, TenantID: tenantID, IsEnabled: true},
+		foreign: {ID: foreign, TenantID: uuid.New(), IsEnabled: true},
+	}}
+	sent := &recordingWebhooks{}
+	svc := cameras.NewService(repo, &MockLicense{Limits: license.LicenseLimits{MaxCameras: 10}}, &MockAuditor{})
+	svc.SetWebhooks(sent)
+
+	if err := svc.DisableCamera(context.Background(), a, tenantID); err != nil {
+		t.Fatalf("DisableCamera: %v", err)
+	}
+	if len(sent.events) != 1 || sent.events[0].Type != cameras.WebhookCameraUpdated {
+		t.Fatalf("Expected one camera.updated, got %+v", sent.events)
+	}
+	if d := sent.events[0].Data.(cameras.CameraWebhookData); d.ID != a || d.IsEnabled {
+		t.Errorf("Expected camera %s disabled, got %+v", a, d)
+	}
+
+	// Once per affected camera: duplicates and other tenants' cameras are skipped
+	sent.events = nil
+	repo.Calls["ListByIDs"] = 0
+	if err := svc.BulkSetTags(context.Background(), tenantID, []uuid.UUID{a, b, a, foreign}, []string{"lobby"}); err != nil {
+		t.Fatalf("BulkSetTags: %v", err)
+	}
+	if len(sent.events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(sent.events))
+	}
+	if n := repo.Calls["ListByIDs"]; n != 1 {
+		t.Errorf("Expected the cameras loaded in one batch, got %d loads", n)
+	}
+	for _, evt := range sent.events {
+		if d := evt.Data.(cameras.CameraWebhookData); evt.TenantID != tenantID || len(d.Tags) != 1 || d.Tags[0] != "lobby" {
+			t.Errorf("Expected the new tags, got %+v", d)
+		}
+	}
+
+	// Nothing is sent when the write fails
+	sent.events = nil
+	repo.Err = errors.New("db down")
+	if err := svc.BulkDisable(context.Background(), tenantID, []uuid.UUID{a, b}); err == nil {
+		t.Fatal("Expected the write error")
+	}
+	if len(sent.events) != 0 {
+		t.Errorf("Expected no events after a failed write, got %d", len(sent.events))
+	}
+}
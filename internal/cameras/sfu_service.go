@@ -4,13 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/technosupport/ts-vms/internal/clock"
 	"github.com/technosupport/ts-vms/internal/data"
 	"github.com/technosupport/ts-vms/internal/media"
+	"github.com/technosupport/ts-vms/internal/metrics"
 	"github.com/technosupport/ts-vms/internal/sfu"
 )
 
@@ -19,14 +24,42 @@ type SfuService struct {
 	mediaClient *media.Client
 	cameraRepo  Repository
 	mediaRepo   *data.MediaModel
+
+	// RTSPHosts rejects a selected stream pointing away from the camera.
+	RTSPHosts RTSPHostPolicy
+
+	// MaxRoomsPerTenant caps the camera rooms a tenant may have open at once;
+	// 0 means unlimited. A license max_sfu_rooms takes precedence.
+	MaxRoomsPerTenant int
+	Licenses          LicenseChecker
+
+	// RoomTTL is how long a room holds its tenant slot without a join,
+	// CreateTransport or viewer session heartbeat (TouchRoom), so viewers
+	// that never call LeaveRoom do not hold it forever. <= 0 uses
+	// DefaultRoomTTL.
+	RoomTTL time.Duration
+	Clock   clock.Clock // Defaults to the real clock
+
+	roomsMu sync.Mutex
+	rooms   map[uuid.UUID]map[uuid.UUID]*sfuRoom // tenant -> active camera rooms
 }
 
+// sfuRoom is an active camera room holding a tenant slot.
+type sfuRoom struct {
+	viewers  int // joined viewers not yet left
+	lastSeen time.Time
+}
+
+// DefaultRoomTTL matches the live session TTL (live.SessionTTL).
+const DefaultRoomTTL = 10 * time.Minute
+
 func NewSfuService(sfuClient *sfu.Client, mediaClient *media.Client, repo Repository, mediaRepo *data.MediaModel) *SfuService {
 	return &SfuService{
 		sfuClient:   sfuClient,
 		mediaClient: mediaClient,
 		cameraRepo:  repo,
 		mediaRepo:   mediaRepo,
+		Clock:       clock.New(),
 	}
 }
 
@@ -108,6 +141,13 @@ func (s *SfuService) EnsureHlsSession(ctx context.Context, tenantID, cameraID uu
 	fmt.Printf("[DEBUG] hls_ensure: selected rtspURL=%s for camera=%s\n", rtspURL, cameraID)
 	tx.Commit() // Done with DB
 
+	if !strings.HasPrefix(rtspURL, "mock://") {
+		if err := s.RTSPHosts.Check(cam, rtspURL); err != nil {
+			fmt.Printf("[REQ:unknown] hls_ensure failed code=ERR_RTSP_HOST_MISMATCH camera=%s tenant=%s err=%v\n", cameraID, tenantID, err)
+			return "", "", NewSfuError("hls_ensure", "ERR_RTSP_HOST_MISMATCH", "Stream URL does not point at the camera", err)
+		}
+	}
+
 	// 2. Check Status logic (Poll Loop optimization)
 	status, err := s.mediaClient.GetIngestStatus(ctx, cameraID.String())
 	if err == nil && status.Running && status.SessionId != "" {
@@ -137,10 +177,25 @@ func (s *SfuService) EnsureHlsSession(ctx context.Context, tenantID, cameraID uu
 	return "", "", NewSfuError("hls_ensure", "ERR_HLS_NOT_READY", "HLS session not ready after timeout", nil)
 }
 
-func (s *SfuService) JoinRoom(ctx context.Context, tenantID, cameraID uuid.UUID, sessionID string) (json.RawMessage, error) {
+func (s *SfuService) JoinRoom(ctx context.Context, tenantID, cameraID uuid.UUID, sessionID string) (caps json.RawMessage, err error) {
+	start := time.Now()
+	defer func() { observeJoinOutcome(err, time.Since(start)) }()
+
 	roomID := fmt.Sprintf("%s:%s", tenantID, cameraID)
 	fmt.Printf("[DEBUG] JoinRoom: roomID=%s, sessionID=%s\n", roomID, sessionID)
 
+	opened, err := s.reserveRoom(tenantID, cameraID)
+	if err != nil {
+		return nil, err
+	}
+	joined := false
+	defer func() {
+		// A room this call opened but never joined does not hold a slot
+		if opened && !joined {
+			s.releaseEmptyRoom(tenantID, cameraID)
+		}
+	}()
+
 	// Task A: Make hls_ensure NON-BLOCKING.
 	// We attempt SFU Join FIRST.
 
@@ -199,6 +254,8 @@ func (s *SfuService) JoinRoom(ctx context.Context, tenantID, cameraID uuid.UUID,
 	}
 
 	// SFU Join OK.
+	joined = true
+	s.addViewer(tenantID, cameraID)
 	fmt.Printf("[DEBUG] JoinRoom: SFU JoinRoom OK\n")
 
 	// 2. Prepare SFU for Media Plane Ingest
@@ -230,7 +287,7 @@ func (s *SfuService) JoinRoom(ctx context.Context, tenantID, cameraID uuid.UUID,
 	}
 
 	// 4. Return SFU router capabilities
-	caps, err := s.sfuClient.GetRouterRtpCapabilities(ctx, roomID)
+	caps, err = s.sfuClient.GetRouterRtpCapabilities(ctx, roomID)
 	if err != nil {
 		return nil, NewSfuError("sfu_caps", "ERR_SFU_CAPS", "Failed to get router caps", err)
 	}
@@ -238,8 +295,34 @@ func (s *SfuService) JoinRoom(ctx context.Context, tenantID, cameraID uuid.UUID,
 	return caps, nil
 }
 
+// joinResultLabel maps a JoinRoom error to a low-cardinality metric label,
+// e.g. ERR_ROOM_FULL -> room_full.
+func joinResultLabel(err error) string {
+	if err == nil {
+		return "success"
+	}
+	var stepErr *SfuStepError
+	if errors.As(err, &stepErr) && stepErr.ErrorCode != "" {
+		return strings.ToLower(strings.TrimPrefix(stepErr.ErrorCode, "ERR_"))
+	}
+	return "error"
+}
+
+// observeJoinOutcome records join result, fallback and latency metrics.
+func observeJoinOutcome(err error, elapsed time.Duration) {
+	result := joinResultLabel(err)
+	metrics.SfuJoinTotal.WithLabelValues(result).Inc()
+	metrics.SfuJoinLatency.Observe(elapsed.Seconds())
+
+	var stepErr *SfuStepError
+	if errors.As(err, &stepErr) && stepErr.FallbackHint {
+		metrics.SfuFallbackTotal.WithLabelValues(result).Inc()
+	}
+}
+
 func (s *SfuService) LeaveRoom(ctx context.Context, tenantID, cameraID uuid.UUID) error {
 	roomID := fmt.Sprintf("%s:%s", tenantID, cameraID)
+	defer s.removeViewer(tenantID, cameraID)
 
 	// 1. Stop Media Plane Egress
 	if err := s.mediaClient.StopSfuRtpEgress(ctx, cameraID.String()); err != nil {
@@ -250,9 +333,137 @@ func (s *SfuService) LeaveRoom(ctx context.Context, tenantID, cameraID uuid.UUID
 	return s.sfuClient.LeaveRoom(ctx, roomID)
 }
 
+// roomLimit is the tenant's concurrent room cap; 0 means unlimited.
+func (s *SfuService) roomLimit(tenantID uuid.UUID) int {
+	if s.Licenses != nil {
+		if n := s.Licenses.GetLimits(tenantID).MaxSfuRooms; n > 0 {
+			return n
+		}
+	}
+	return s.MaxRoomsPerTenant
+}
+
+// reserveRoom marks the camera room active for the tenant. Rejoining an
+// already active room always succeeds and refreshes it; opened reports
+// whether this call added it. Rooms idle for longer than RoomTTL are
+// released first.
+func (s *SfuService) reserveRoom(tenantID, cameraID uuid.UUID) (opened bool, err error) {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+
+	now := s.Clock.Now()
+	s.expireRoomsLocked(tenantID, now)
+	active := s.rooms[tenantID]
+	if room, ok := active[cameraID]; ok {
+		room.lastSeen = now
+		return false, nil
+	}
+	if limit := s.roomLimit(tenantID); limit > 0 && len(active) >= limit {
+		return false, NewSfuError("tenant_room_limit", "ERR_TENANT_ROOM_LIMIT",
+			fmt.Sprintf("Tenant concurrent room limit reached (%d)", limit), nil)
+	}
+	if active == nil {
+		if s.rooms == nil {
+			s.rooms = make(map[uuid.UUID]map[uuid.UUID]*sfuRoom)
+		}
+		active = make(map[uuid.UUID]*sfuRoom)
+		s.rooms[tenantID] = active
+	}
+	active[cameraID] = &sfuRoom{lastSeen: now}
+	return true, nil
+}
+
+// addViewer counts a joined viewer against the room, reopening it if it
+// expired while the join was in flight.
+func (s *SfuService) addViewer(tenantID, cameraID uuid.UUID) {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+
+	active := s.rooms[tenantID]
+	if active == nil {
+		if s.rooms == nil {
+			s.rooms = make(map[uuid.UUID]map[uuid.UUID]*sfuRoom)
+		}
+		active = make(map[uuid.UUID]*sfuRoom)
+		s.rooms[tenantID] = active
+	}
+	room, ok := active[cameraID]
+	if !ok {
+		room = &sfuRoom{}
+		active[cameraID] = room
+	}
+	room.viewers++
+	room.lastSeen = s.Clock.Now()
+}
+
+// removeViewer drops one viewer; the tenant slot is released when the last
+// viewer leaves.
+func (s *SfuService) removeViewer(tenantID, cameraID uuid.UUID) {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+
+	if room, ok := s.rooms[tenantID][cameraID]; ok {
+		room.viewers--
+		if room.viewers <= 0 {
+			s.deleteRoomLocked(tenantID, cameraID)
+		}
+	}
+}
+
+// releaseEmptyRoom frees a room no viewer has joined.
+func (s *SfuService) releaseEmptyRoom(tenantID, cameraID uuid.UUID) {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+
+	if room, ok := s.rooms[tenantID][cameraID]; ok && room.viewers == 0 {
+		s.deleteRoomLocked(tenantID, cameraID)
+	}
+}
+
+// TouchRoom refreshes an active room's last-seen time. Live viewer session
+// heartbeats call it so long-running views keep their slot past RoomTTL.
+func (s *SfuService) TouchRoom(tenantID, cameraID uuid.UUID) {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+
+	if room, ok := s.rooms[tenantID][cameraID]; ok {
+		room.lastSeen = s.Clock.Now()
+	}
+}
+
+func (s *SfuService) deleteRoomLocked(tenantID, cameraID uuid.UUID) {
+	active := s.rooms[tenantID]
+	delete(active, cameraID)
+	if len(active) == 0 {
+		delete(s.rooms, tenantID)
+	}
+}
+
+// expireRoomsLocked drops the tenant's rooms idle for longer than RoomTTL.
+func (s *SfuService) expireRoomsLocked(tenantID uuid.UUID, now time.Time) {
+	ttl := s.RoomTTL
+	if ttl <= 0 {
+		ttl = DefaultRoomTTL
+	}
+	for cameraID, room := range s.rooms[tenantID] {
+		if now.Sub(room.lastSeen) > ttl {
+			s.deleteRoomLocked(tenantID, cameraID)
+		}
+	}
+}
+
+// ActiveRooms reports how many camera rooms the tenant has open.
+func (s *SfuService) ActiveRooms(tenantID uuid.UUID) int {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+	s.expireRoomsLocked(tenantID, s.Clock.Now())
+	return len(s.rooms[tenantID])
+}
+
 // Signaling Relays
 
 func (s *SfuService) CreateTransport(ctx context.Context, tenantID, cameraID uuid.UUID) (json.RawMessage, error) {
+	s.TouchRoom(tenantID, cameraID)
 	roomID := fmt.Sprintf("%s:%s", tenantID, cameraID)
 	return s.sfuClient.CreateWebRtcTransport(ctx, roomID)
 }
@@ -0,0 +1,197 @@
+package cameras
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/technosupport/ts-vms/internal/clock"
+	"github.com/technosupport/ts-vms/internal/license"
+	"github.com/technosupport/ts-vms/internal/media"
+	"github.com/technosupport/ts-vms/internal/metrics"
+	"github.com/technosupport/ts-vms/internal/sfu"
+)
+
+func TestJoinResultLabel(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, "success"},
+		{NewSfuError("sfu_join", "ERR_ROOM_FULL", "Room at capacity", nil), "room_full"},
+		{NewSfuErrorWithFallback("codec_check", "ERR_UNSUPPORTED_CODEC", "H265", "/hls/x", nil), "unsupported_codec"},
+		{errors.New("boom"), "error"},
+	}
+	for _, c := range cases {
+		if got := joinResultLabel(c.err); got != c.want {
+			t.Errorf("joinResultLabel(%v) = %s; want %s", c.err, got, c.want)
+		}
+	}
+}
+
+func TestObserveJoinOutcome_RoomFull(t *testing.T) {
+	joins := testutil.ToFloat64(metrics.SfuJoinTotal.WithLabelValues("room_full"))
+	fallbacks := testutil.ToFloat64(metrics.SfuFallbackTotal.WithLabelValues("room_full"))
+
+	observeJoinOutcome(NewSfuError("sfu_join", "ERR_ROOM_FULL", "Room at capacity", nil), 10*time.Millisecond)
+
+	if got := testutil.ToFloat64(metrics.SfuJoinTotal.WithLabelValues("room_full")); got != joins+1 {
+		t.Errorf("sfu_join_total{result=room_full} = %v; want %v", got, joins+1)
+	}
+	// Room full carries no HLS fallback hint
+	if got := testutil.ToFloat64(metrics.SfuFallbackTotal.WithLabelValues("room_full")); got != fallbacks {
+		t.Errorf("sfu_fallback_total{reason=room_full} changed to %v", got)
+	}
+}
+
+func TestObserveJoinOutcome_Success(t *testing.T) {
+	joins := testutil.ToFloat64(metrics.SfuJoinTotal.WithLabelValues("success"))
+
+	observeJoinOutcome(nil, 50*time.Millisecond)
+
+	if got := testutil.ToFloat64(metrics.SfuJoinTotal.WithLabelValues("success")); got != joins+1 {
+		t.Errorf("sfu_join_total{result=success} = %v; want %v", got, joins+1)
+	}
+}
+
+func TestObserveJoinOutcome_Fallback(t *testing.T) {
+	fallbacks := testutil.ToFloat64(metrics.SfuFallbackTotal.WithLabelValues("sfu_failure"))
+
+	observeJoinOutcome(NewSfuErrorWithFallback("sfu_join", "ERR_SFU_FAILURE", "SFU Join failed, use HLS", "/hls/x", nil), time.Second)
+
+	if got := testutil.ToFloat64(metrics.SfuFallbackTotal.WithLabelValues("sfu_failure")); got != fallbacks+1 {
+		t.Errorf("sfu_fallback_total{reason=sfu_failure} = %v; want %v", got, fallbacks+1)
+	}
+}
+
+type sfuRoomLicense struct{ rooms int }
+
+func (l sfuRoomLicense) GetLimits(uuid.UUID) license.LicenseLimits {
+	return license.LicenseLimits{MaxSfuRooms: l.rooms}
+}
+
+func newRoomTestService(sfuClient *sfu.Client, maxRooms int) *SfuService {
+	s := NewSfuService(sfuClient, nil, nil, nil)
+	s.MaxRoomsPerTenant = maxRooms
+	return s
+}
+
+func TestReserveRoom_TenantLimit(t *testing.T) {
+	s := newRoomTestService(nil, 2)
+	tenant, other := uuid.New(), uuid.New()
+	camA, camB, camC := uuid.New(), uuid.New(), uuid.New()
+
+	for _, cam := range []uuid.UUID{camA, camB} {
+		if _, err := s.reserveRoom(tenant, cam); err != nil {
+			t.Fatalf("reserve within limit: %v", err)
+		}
+	}
+	// Rejoining an open room does not take another slot
+	if opened, err := s.reserveRoom(tenant, camA); err != nil || opened {
+		t.Errorf("rejoin: opened=%v err=%v", opened, err)
+	}
+
+	_, err := s.reserveRoom(tenant, camC)
+	var stepErr *SfuStepError
+	if !errors.As(err, &stepErr) || stepErr.ErrorCode != "ERR_TENANT_ROOM_LIMIT" {
+		t.Fatalf("Expected ERR_TENANT_ROOM_LIMIT, got %v", err)
+	}
+	if _, err := s.reserveRoom(other, camC); err != nil {
+		t.Errorf("Other tenant must not be limited: %v", err)
+	}
+
+	// The license limit overrides config
+	s.Licenses = sfuRoomLicense{rooms: 3}
+	if _, err := s.reserveRoom(tenant, camC); err != nil {
+		t.Errorf("Expected license limit of 3 to apply: %v", err)
+	}
+}
+
+func TestLeaveRoom_FreesTenantSlotAfterLastViewer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	mediaClient, err := media.NewClient("127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mediaClient.Close()
+
+	s := newRoomTestService(sfu.NewClient(srv.URL, "secret"), 1)
+	s.mediaClient = mediaClient
+	tenant, camA, camB := uuid.New(), uuid.New(), uuid.New()
+
+	// Two viewers share camA's room
+	for i := 0; i < 2; i++ {
+		if _, err := s.reserveRoom(tenant, camA); err != nil {
+			t.Fatal(err)
+		}
+		s.addViewer(tenant, camA)
+	}
+	if _, err := s.reserveRoom(tenant, camB); err == nil {
+		t.Fatal("Expected second room to exceed the tenant limit")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The first viewer leaving keeps the room open for the other
+	if err := s.LeaveRoom(ctx, tenant, camA); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if n := s.ActiveRooms(tenant); n != 1 {
+		t.Fatalf("Expected the room to stay active with a viewer left, got %d", n)
+	}
+	if _, err := s.reserveRoom(tenant, camB); err == nil {
+		t.Fatal("Expected the slot to be held while a viewer remains")
+	}
+
+	if err := s.LeaveRoom(ctx, tenant, camA); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if n := s.ActiveRooms(tenant); n != 0 {
+		t.Errorf("Expected no active rooms after the last viewer left, got %d", n)
+	}
+	if _, err := s.reserveRoom(tenant, camB); err != nil {
+		t.Errorf("Expected freed slot to be reusable: %v", err)
+	}
+}
+
+func TestReserveRoom_AbandonedReservationExpires(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	s := newRoomTestService(nil, 1)
+	s.RoomTTL, s.Clock = time.Minute, clk
+	tenant, camA, camB := uuid.New(), uuid.New(), uuid.New()
+
+	// camA joins and is never left
+	if _, err := s.reserveRoom(tenant, camA); err != nil {
+		t.Fatal(err)
+	}
+	s.addViewer(tenant, camA)
+	clk.Advance(50 * time.Second)
+	if _, err := s.reserveRoom(tenant, camB); err == nil {
+		t.Fatal("Expected the slot to be held within the TTL")
+	}
+
+	// A session heartbeat refreshes the reservation
+	s.TouchRoom(tenant, camA)
+	clk.Advance(50 * time.Second)
+	if n := s.ActiveRooms(tenant); n != 1 {
+		t.Fatalf("Expected the refreshed room to stay active, got %d", n)
+	}
+
+	clk.Advance(time.Minute)
+	if n := s.ActiveRooms(tenant); n != 0 {
+		t.Errorf("Expected the abandoned room to expire, got %d active", n)
+	}
+	if opened, err := s.reserveRoom(tenant, camB); err != nil || !opened {
+		t.Errorf("Expected the expired slot to be reusable: opened=%v err=%v", opened, err)
+	}
+}
@@ -2,6 +2,8 @@ package cameras
 
 import (
 	"context"
+	"net"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/audit"
@@ -38,6 +40,9 @@ type MockMediaRepo struct {
 	GetValidationResultsFunc   func(ctx context.Context, cameraID uuid.UUID) ([]*data.RTSPValidationResult, error)
 	UpsertValidationResultFunc func(ctx context.Context, res *data.RTSPValidationResult) error
 	ListProfilesFunc           func(ctx context.Context, cameraID uuid.UUID) ([]*data.CameraMediaProfile, error)
+	AppendHistoryFunc          func(ctx context.Context, res *data.RTSPValidationResult) error
+	ListHistoryFunc            func(ctx context.Context, cameraID uuid.UUID, limit int) ([]*data.RTSPValidationResult, error)
+	PruneHistoryFunc           func(ctx context.Context, cameraID uuid.UUID, variant string, keep int, before time.Time) (int, error)
 }
 
 func (m *MockMediaRepo) UpsertProfile(ctx context.Context, p *data.CameraMediaProfile) error {
@@ -77,6 +82,25 @@ func (m *MockMediaRepo) ListProfiles(ctx context.Context, cameraID uuid.UUID) ([
 	return nil, nil
 }
 
+func (m *MockMediaRepo) AppendValidationHistory(ctx context.Context, res *data.RTSPValidationResult) error {
+	if m.AppendHistoryFunc != nil {
+		return m.AppendHistoryFunc(ctx, res)
+	}
+	return nil
+}
+func (m *MockMediaRepo) ListValidationHistory(ctx context.Context, cameraID uuid.UUID, limit int) ([]*data.RTSPValidationResult, error) {
+	if m.ListHistoryFunc != nil {
+		return m.ListHistoryFunc(ctx, cameraID, limit)
+	}
+	return nil, nil
+}
+func (m *MockMediaRepo) PruneValidationHistory(ctx context.Context, cameraID uuid.UUID, variant string, keep int, before time.Time) (int, error) {
+	if m.PruneHistoryFunc != nil {
+		return m.PruneHistoryFunc(ctx, cameraID, variant, keep, before)
+	}
+	return 0, nil
+}
+
 // MockCameraRepo
 type MockCameraRepo struct {
 	GetByIDFunc func(ctx context.Context, id uuid.UUID) (*data.Camera, error)
@@ -90,26 +114,59 @@ func (m *MockCameraRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camer
 	}
 	return nil, nil
 }
+func (m *MockCameraRepo) ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*data.Camera, error) {
+	return nil, nil
+}
 func (m *MockCameraRepo) Update(ctx context.Context, c *data.Camera) error { return nil }
 func (m *MockCameraRepo) SetStatus(ctx context.Context, id, tenantID uuid.UUID, enabled bool) error {
 	return nil
 }
+func (m *MockCameraRepo) SetONVIFEvents(ctx context.Context, tenantID, id uuid.UUID, enabled bool) error {
+	return nil
+}
 func (m *MockCameraRepo) SoftDelete(ctx context.Context, id, tenantID uuid.UUID) error { return nil }
 func (m *MockCameraRepo) CountAll(ctx context.Context, tenantID uuid.UUID) (int, error) {
 	return 0, nil
 }
+func (m *MockCameraRepo) CountEnabled(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	return 0, nil
+}
+func (m *MockCameraRepo) ListDisabledIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return ids, nil
+}
 func (m *MockCameraRepo) BulkUpdateStatus(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, enabled bool) error {
 	return nil
 }
-func (m *MockCameraRepo) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
-	return nil
+func (m *MockCameraRepo) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string, maxTags int) ([]uuid.UUID, error) {
+	return nil, nil
 }
 func (m *MockCameraRepo) BulkRemoveTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
 	return nil
 }
+func (m *MockCameraRepo) BulkSetTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
+	return nil
+}
+func (m *MockCameraRepo) ListTags(ctx context.Context, tenantID uuid.UUID, prefix string, limit int) ([]data.TagCount, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) ListDueForThumbnail(ctx context.Context, cutoff time.Time, limit int) ([]data.ThumbnailDue, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) MarkThumbnailCaptured(ctx context.Context, tenantID, cameraID uuid.UUID, capturedAt time.Time) error {
+	return nil
+}
+func (m *MockCameraRepo) ListPurgeable(ctx context.Context, cutoff time.Time, limit int) ([]data.PurgeCandidate, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) HardDelete(ctx context.Context, id, tenantID uuid.UUID, cutoff time.Time) error {
+	return nil
+}
 func (m *MockCameraRepo) List(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.Camera, int, error) {
 	return nil, 0, nil
 }
+func (m *MockCameraRepo) ListSummary(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.CameraSummary, int, error) {
+	return nil, 0, nil
+}
 func (m *MockCameraRepo) CreateGroup(ctx context.Context, g *data.CameraGroup) error { return nil }
 func (m *MockCameraRepo) ListGroups(ctx context.Context, tenantID uuid.UUID) ([]*data.CameraGroup, error) {
 	return nil, nil
@@ -118,3 +175,54 @@ func (m *MockCameraRepo) DeleteGroup(ctx context.Context, id, tenantID uuid.UUID
 func (m *MockCameraRepo) SetGroupMembers(ctx context.Context, groupID, tenantID uuid.UUID, cameraIDs []uuid.UUID) error {
 	return nil
 }
+func (m *MockCameraRepo) AddFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	return nil
+}
+func (m *MockCameraRepo) RemoveFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	return nil
+}
+func (m *MockCameraRepo) SiteBelongsToTenant(ctx context.Context, siteID, tenantID uuid.UUID) (bool, error) {
+	return true, nil
+}
+func (m *MockCameraRepo) GetDefaultSiteID(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) SetDefaultSiteID(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID) error {
+	return nil
+}
+func (m *MockCameraRepo) ListNVRSiteConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]data.CameraSiteConflict, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) CameraIPInSite(ctx context.Context, tenantID, siteID uuid.UUID, ip net.IP, excludeID uuid.UUID) (bool, error) {
+	return false, nil
+}
+func (m *MockCameraRepo) FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error) {
+	return uuid.Nil, data.ErrRecordNotFound
+}
+func (m *MockCameraRepo) ListSiteIPConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) BulkMoveSite(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) (int, error) {
+	return len(ids), nil
+}
+func (m *MockCameraRepo) ListTenantsWithEnabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) ListEnabledIDsByPriority(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) DisableForLicense(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error {
+	return nil
+}
+func (m *MockCameraRepo) ListTenantsWithLicenseDisabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) ListLicenseDisabledIDs(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (m *MockCameraRepo) GetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	return true, nil
+}
+func (m *MockCameraRepo) SetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID, enabled bool) error {
+	return nil
+}
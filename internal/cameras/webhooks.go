@@ -0,0 +1,100 @@
+package cameras
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/webhook"
+)
+
+// Camera lifecycle webhook event types.
+const (
+	WebhookCameraCreated = "camera.created"
+	WebhookCameraUpdated = "camera.updated"
+	WebhookCameraDeleted = "camera.deleted"
+)
+
+// WebhookSender queues outbound webhook events; *webhook.Dispatcher
+// satisfies it.
+type WebhookSender interface {
+	Enqueue(evt webhook.Event) bool
+}
+
+// CameraWebhookData is the camera as sent to lifecycle webhooks. Fields are
+// listed explicitly so nothing added to data.Camera leaks by default;
+// credentials live elsewhere and are never included.
+type CameraWebhookData struct {
+	ID           uuid.UUID  `json:"id"`
+	SiteID       uuid.UUID  `json:"site_id"`
+	Name         string     `json:"name"`
+	IPAddress    net.IP     `json:"ip_address"`
+	Port         int        `json:"port"`
+	Manufacturer string     `json:"manufacturer,omitempty"`
+	Model        string     `json:"model,omitempty"`
+	SerialNumber string     `json:"serial_number,omitempty"`
+	MacAddress   string     `json:"mac_address,omitempty"`
+	IsEnabled    bool       `json:"is_enabled"`
+	Tags         []string   `json:"tags"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+}
+
+// SetWebhooks sends camera.created/updated/deleted events to w; nil disables them.
+func (s *Service) SetWebhooks(w WebhookSender) {
+	s.webhooks = w
+}
+
+// notifyWebhook queues a lifecycle event for c. Delivery is asynchronous and
+// best-effort; a full queue never fails the camera operation.
+func (s *Service) notifyWebhook(eventType string, c *data.Camera) {
+	if s.webhooks == nil || c == nil {
+		return
+	}
+	s.webhooks.Enqueue(webhook.Event{
+		Type:     eventType,
+		TenantID: c.TenantID,
+		Data: CameraWebhookData{
+			ID:           c.ID,
+			SiteID:       c.SiteID,
+			Name:         c.Name,
+			IPAddress:    c.IPAddress,
+			Port:         c.Port,
+			Manufacturer: c.Manufacturer,
+			Model:        c.Model,
+			SerialNumber: c.SerialNumber,
+			MacAddress:   c.MacAddress,
+			IsEnabled:    c.IsEnabled,
+			Tags:         c.Tags,
+			DeletedAt:    c.DeletedAt,
+		},
+	})
+}
+
+// notifyUpdated sends camera.updated once for each of the tenant's cameras in
+// ids, re-read in one batch after the write so the events carry the new
+// state. Cameras that are gone or deleted are skipped.
+func (s *Service) notifyUpdated(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) {
+	if s.webhooks == nil || len(ids) == 0 {
+		return
+	}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	cams, err := s.repo.ListByIDs(ctx, tenantID, unique)
+	if err != nil {
+		return
+	}
+	for _, c := range cams {
+		if c.TenantID != tenantID || c.DeletedAt != nil {
+			continue
+		}
+		s.notifyWebhook(WebhookCameraUpdated, c)
+	}
+}
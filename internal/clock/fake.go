@@ -0,0 +1,146 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when Advance is called.
+//
+// Unlike time.Ticker, a fake ticker never drops ticks: Advance delivers every
+// tick that falls due and waits for each to be received, so once Advance
+// returns the consumer has taken them (and finished handling all but the
+// last).
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+	timers  []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewFake returns a Fake clock reading now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After fires once the clock has been advanced by d; immediately for d <= 0.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.timers = append(f.timers, fakeTimer{at: f.now.Add(d), c: c})
+	return c
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTicker{c: make(chan time.Time), done: make(chan struct{}), period: d, next: f.now.Add(d)}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing due timers and ticks in time
+// order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	end := f.now.Add(d)
+	f.mu.Unlock()
+
+	for {
+		f.mu.Lock()
+		t, at := f.nextTick(end)
+		f.fireTimers(at)
+		if t == nil {
+			f.now = end
+			f.mu.Unlock()
+			return
+		}
+		f.now = at
+		t.next = at.Add(t.period)
+		f.mu.Unlock()
+
+		// Outside the lock: the consumer may call Now while handling it
+		t.send(at)
+	}
+}
+
+// nextTick returns the earliest live ticker due by end and its due time, or
+// nil and end.
+func (f *Fake) nextTick(end time.Time) (*fakeTicker, time.Time) {
+	var due *fakeTicker
+	live := f.tickers[:0]
+	for _, t := range f.tickers {
+		if t.stopped() {
+			continue
+		}
+		live = append(live, t)
+		if !t.next.After(end) && (due == nil || t.next.Before(due.next)) {
+			due = t
+		}
+	}
+	f.tickers = live
+	if due == nil {
+		return nil, end
+	}
+	return due, due.next
+}
+
+// fireTimers fires and drops timers due by at.
+func (f *Fake) fireTimers(at time.Time) {
+	pending := f.timers[:0]
+	for _, t := range f.timers {
+		if t.at.After(at) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- t.at
+	}
+	f.timers = pending
+}
+
+type fakeTicker struct {
+	c      chan time.Time
+	done   chan struct{}
+	once   sync.Once
+	period time.Duration
+	next   time.Time // Guarded by Fake.mu
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() { t.once.Do(func() { close(t.done) }) }
+
+func (t *fakeTicker) stopped() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *fakeTicker) send(at time.Time) {
+	select {
+	case t.c <- at:
+	case <-t.done:
+	}
+}
@@ -0,0 +1,48 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake_TickerAndAfter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	ticker := f.NewTicker(time.Minute)
+	after := f.After(90 * time.Second)
+
+	var ticks []time.Time
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for tick := range ticker.C() {
+			ticks = append(ticks, tick)
+			if len(ticks) == 3 {
+				return
+			}
+		}
+	}()
+
+	f.Advance(3 * time.Minute)
+	<-done
+	for i, tick := range ticks {
+		if want := start.Add(time.Duration(i+1) * time.Minute); !tick.Equal(want) {
+			t.Errorf("tick %d at %v; want %v", i, tick, want)
+		}
+	}
+	select {
+	case at := <-after:
+		if !at.Equal(start.Add(90 * time.Second)) {
+			t.Errorf("After fired at %v", at)
+		}
+	default:
+		t.Error("After did not fire")
+	}
+	if !f.Now().Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("Now = %v", f.Now())
+	}
+
+	// A stopped ticker no longer blocks Advance
+	ticker.Stop()
+	f.Advance(time.Hour)
+}
@@ -2,8 +2,12 @@ package crypto_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -122,3 +126,84 @@ func TestKeyring_Failures(t *testing.T) {
 		t.Error("Expected invalid length error")
 	}
 }
+
+func writeKeyFile(t *testing.T, active string, kids ...string) (string, map[string][]byte) {
+	t.Helper()
+	material := make(map[string][]byte)
+	var keys []map[string]string
+	for _, kid := range kids {
+		k, _ := crypto.GenerateDEK()
+		material[kid] = k
+		keys = append(keys, map[string]string{"kid": kid, "material": base64.StdEncoding.EncodeToString(k)})
+	}
+	raw, _ := json.Marshal(map[string]any{"active_kid": active, "keys": keys})
+	path := filepath.Join(t.TempDir(), "master_keys.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, material
+}
+
+func TestKeyring_FileSourceMultipleKIDs(t *testing.T) {
+	path, material := writeKeyFile(t, "key-2", "key-1", "key-2")
+
+	kr := crypto.NewKeyring()
+	if err := kr.LoadFrom(context.Background(), crypto.FileSource{Path: path}); err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+	if kr.ActiveKID() != "key-2" || kr.Source() != crypto.KeySourceFile {
+		t.Fatalf("Expected active key-2 from file, got %s from %s", kr.ActiveKID(), kr.Source())
+	}
+
+	// A DEK wrapped under the retired key-1 still unwraps
+	dek, _ := crypto.GenerateDEK()
+	nonce, ct, tag, _ := crypto.EncryptGCM(material["key-1"], dek, []byte("aad"))
+	got, err := kr.UnwrapDEK("key-1", nonce, ct, tag, []byte("aad"))
+	if err != nil || !bytes.Equal(got, dek) {
+		t.Errorf("Unwrap under key-1 failed: %v", err)
+	}
+}
+
+func TestKeyring_SourcePrecedence(t *testing.T) {
+	path, _ := writeKeyFile(t, "file-key", "file-key")
+	kms := crypto.KMSSource{Client: crypto.StaticKMSClient{"kms-key": make([]byte, 32)}, KIDs: []string{"kms-key"}, ActiveKID: "kms-key"}
+	missing := crypto.FileSource{Path: filepath.Join(t.TempDir(), "absent.json")}
+
+	t.Run("first configured source wins", func(t *testing.T) {
+		kr := crypto.NewKeyring()
+		if err := kr.LoadFrom(context.Background(), kms, crypto.FileSource{Path: path}); err != nil {
+			t.Fatal(err)
+		}
+		if kr.ActiveKID() != "kms-key" {
+			t.Errorf("Expected kms-key, got %s", kr.ActiveKID())
+		}
+	})
+
+	t.Run("unconfigured sources fall through", func(t *testing.T) {
+		t.Setenv("MASTER_KEYS", "")
+		kr := crypto.NewKeyring()
+		if err := kr.LoadFrom(context.Background(), crypto.EnvSource{}, missing, crypto.FileSource{Path: path}); err != nil {
+			t.Fatal(err)
+		}
+		if kr.ActiveKID() != "file-key" || kr.Source() != crypto.KeySourceFile {
+			t.Errorf("Expected file-key from file, got %s from %s", kr.ActiveKID(), kr.Source())
+		}
+	})
+
+	t.Run("broken source does not fall back", func(t *testing.T) {
+		broken := filepath.Join(t.TempDir(), "broken.json")
+		os.WriteFile(broken, []byte("{not json"), 0o600)
+		kr := crypto.NewKeyring()
+		if err := kr.LoadFrom(context.Background(), crypto.FileSource{Path: broken}, crypto.FileSource{Path: path}); err == nil {
+			t.Error("Expected a parse error instead of falling back")
+		}
+	})
+
+	t.Run("nothing configured", func(t *testing.T) {
+		kr := crypto.NewKeyring()
+		err := kr.LoadFrom(context.Background(), missing, crypto.KMSSource{})
+		if !errors.Is(err, crypto.ErrKeySourceEmpty) {
+			t.Errorf("Expected ErrKeySourceEmpty, got %v", err)
+		}
+	})
+}
@@ -1,13 +1,12 @@
 package crypto
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"os"
 )
 
 var (
@@ -24,6 +23,7 @@ type MasterKey struct {
 type Keyring struct {
 	keys      map[string][]byte
 	activeKID string
+	source    string
 }
 
 func NewKeyring() *Keyring {
@@ -35,28 +35,40 @@ func NewKeyring() *Keyring {
 // LoadFromEnv loads MASTER_KEYS (JSON) and ACTIVE_MASTER_KID from environment values.
 // Strict validation: Must fail if active key defaults or invalid keys found.
 func (k *Keyring) LoadFromEnv() error {
-	keysJSON := os.Getenv("MASTER_KEYS")
-	activeKID := os.Getenv("ACTIVE_MASTER_KID")
-
-	if keysJSON == "" {
-		// If no keys defined, we can't operate.
-		return errors.New("MASTER_KEYS environment variable is empty")
-	}
-	if activeKID == "" {
-		return errors.New("ACTIVE_MASTER_KID environment variable is empty")
-	}
+	return k.LoadFrom(context.Background(), EnvSource{})
+}
 
-	var rawKeys []MasterKey
-	if err := json.Unmarshal([]byte(keysJSON), &rawKeys); err != nil {
-		return fmt.Errorf("failed to parse MASTER_KEYS: %w", err)
+// LoadFrom loads keys from the first configured source, skipping those that
+// return ErrKeySourceEmpty. Any other source error fails loading rather than
+// silently falling back to a different key set.
+func (k *Keyring) LoadFrom(ctx context.Context, sources ...KeySource) error {
+	lastErr := errors.New("no master key sources configured")
+	for _, src := range sources {
+		keys, activeKID, err := src.Load(ctx)
+		if errors.Is(err, ErrKeySourceEmpty) {
+			lastErr = err
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s key source: %w", src.Name(), err)
+		}
+		if err := k.install(keys, activeKID); err != nil {
+			return fmt.Errorf("%s key source: %w", src.Name(), err)
+		}
+		k.source = src.Name()
+		return nil
 	}
+	return lastErr
+}
 
-	k.keys = make(map[string][]byte)
+// install validates keys and replaces the keyring contents.
+func (k *Keyring) install(rawKeys []MasterKey, activeKID string) error {
+	keys := make(map[string][]byte, len(rawKeys))
 	for _, rk := range rawKeys {
 		if rk.KID == "" {
 			return errors.New("found master key with empty KID")
 		}
-		if _, exists := k.keys[rk.KID]; exists {
+		if _, exists := keys[rk.KID]; exists {
 			return fmt.Errorf("duplicate master key KID: %s", rk.KID)
 		}
 
@@ -69,18 +81,30 @@ func (k *Keyring) LoadFromEnv() error {
 			return fmt.Errorf("invalid key length for %s: expected 32 bytes (AES-256), got %d", rk.KID, len(decoded))
 		}
 
-		k.keys[rk.KID] = decoded
+		keys[rk.KID] = decoded
 	}
 
 	// Verify Active Key Exists
-	if _, ok := k.keys[activeKID]; !ok {
-		return fmt.Errorf("active key %s not found in MASTER_KEYS", activeKID)
+	if _, ok := keys[activeKID]; !ok {
+		return fmt.Errorf("active key %q not found in master keys", activeKID)
 	}
+	k.keys = keys
 	k.activeKID = activeKID
 
 	return nil
 }
 
+// Source names the key source the keyring was loaded from.
+func (k *Keyring) Source() string {
+	return k.source
+}
+
+// ActiveKID returns the master key identifier used for new wraps.
+// Credentials wrapped under any other KID are pending rotation.
+func (k *Keyring) ActiveKID() string {
+	return k.activeKID
+}
+
 // WrapDEK generates a new DEK nonce, encrypts the DEK using the Active Master Key.
 // Returns: masterKID, dekNonce, dekCiphertext, dekTag, err
 func (k *Keyring) WrapDEK(dek []byte, aad []byte) (string, []byte, []byte, []byte, error) {
@@ -0,0 +1,157 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrKeySourceEmpty means a source has no master keys configured; the keyring
+// falls through to the next source. Any other error aborts loading.
+var ErrKeySourceEmpty = errors.New("key source not configured")
+
+// KeySource supplies master keys and the active KID.
+type KeySource interface {
+	Name() string
+	Load(ctx context.Context) (keys []MasterKey, activeKID string, err error)
+}
+
+// Key source names accepted in KeySourceConfig.Sources.
+const (
+	KeySourceEnv  = "env"
+	KeySourceFile = "file"
+	KeySourceKMS  = "kms"
+)
+
+// KeySourceConfig selects where master keys come from (master_keys section of
+// config/default.yaml). Sources are tried in order and the first configured
+// one wins.
+type KeySourceConfig struct {
+	Sources []string `yaml:"sources"`
+	File    string   `yaml:"file"`
+	KMS     struct {
+		ActiveKID string   `yaml:"active_kid"`
+		KIDs      []string `yaml:"kids"`
+	} `yaml:"kms"`
+}
+
+// Build returns the configured sources; no sources means env only. The KMS
+// source gets client, which may be nil when no KMS is available.
+func (c KeySourceConfig) Build(client KMSClient) ([]KeySource, error) {
+	names := c.Sources
+	if len(names) == 0 {
+		names = []string{KeySourceEnv}
+	}
+	sources := make([]KeySource, 0, len(names))
+	for _, n := range names {
+		switch n {
+		case KeySourceEnv:
+			sources = append(sources, EnvSource{})
+		case KeySourceFile:
+			sources = append(sources, FileSource{Path: c.File})
+		case KeySourceKMS:
+			sources = append(sources, KMSSource{Client: client, KIDs: c.KMS.KIDs, ActiveKID: c.KMS.ActiveKID})
+		default:
+			return nil, fmt.Errorf("unknown master key source %q", n)
+		}
+	}
+	return sources, nil
+}
+
+// EnvSource reads MASTER_KEYS (JSON array of {kid, material}) and
+// ACTIVE_MASTER_KID.
+type EnvSource struct{}
+
+func (EnvSource) Name() string { return KeySourceEnv }
+
+func (EnvSource) Load(ctx context.Context) ([]MasterKey, string, error) {
+	keysJSON := os.Getenv("MASTER_KEYS")
+	activeKID := os.Getenv("ACTIVE_MASTER_KID")
+	if keysJSON == "" {
+		return nil, "", fmt.Errorf("%w: MASTER_KEYS environment variable is empty", ErrKeySourceEmpty)
+	}
+	if activeKID == "" {
+		return nil, "", errors.New("ACTIVE_MASTER_KID environment variable is empty")
+	}
+	var keys []MasterKey
+	if err := json.Unmarshal([]byte(keysJSON), &keys); err != nil {
+		return nil, "", fmt.Errorf("failed to parse MASTER_KEYS: %w", err)
+	}
+	return keys, activeKID, nil
+}
+
+// FileSource reads a JSON file {"active_kid": "...", "keys": [{kid, material}]}.
+// A missing file counts as not configured.
+type FileSource struct {
+	Path string
+}
+
+func (FileSource) Name() string { return KeySourceFile }
+
+func (s FileSource) Load(ctx context.Context) ([]MasterKey, string, error) {
+	if s.Path == "" {
+		return nil, "", fmt.Errorf("%w: no key file path", ErrKeySourceEmpty)
+	}
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", fmt.Errorf("%w: %s does not exist", ErrKeySourceEmpty, s.Path)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	var f struct {
+		ActiveKID string      `json:"active_kid"`
+		Keys      []MasterKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+	if f.ActiveKID == "" {
+		return nil, "", fmt.Errorf("%s: active_kid is empty", s.Path)
+	}
+	return f.Keys, f.ActiveKID, nil
+}
+
+// KMSClient fetches raw key material by KID from an external KMS.
+type KMSClient interface {
+	FetchKey(ctx context.Context, kid string) ([]byte, error)
+}
+
+// StaticKMSClient is a stub KMS serving fixed keys, for development and tests
+// until a real KMS integration exists.
+type StaticKMSClient map[string][]byte
+
+func (c StaticKMSClient) FetchKey(ctx context.Context, kid string) ([]byte, error) {
+	key, ok := c[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// KMSSource fetches each of KIDs from Client.
+type KMSSource struct {
+	Client    KMSClient
+	KIDs      []string
+	ActiveKID string
+}
+
+func (KMSSource) Name() string { return KeySourceKMS }
+
+func (s KMSSource) Load(ctx context.Context) ([]MasterKey, string, error) {
+	if s.Client == nil || len(s.KIDs) == 0 {
+		return nil, "", fmt.Errorf("%w: no KMS client or kids", ErrKeySourceEmpty)
+	}
+	keys := make([]MasterKey, 0, len(s.KIDs))
+	for _, kid := range s.KIDs {
+		material, err := s.Client.FetchKey(ctx, kid)
+		if err != nil {
+			return nil, "", fmt.Errorf("fetch key %s: %w", kid, err)
+		}
+		keys = append(keys, MasterKey{KID: kid, Material: base64.StdEncoding.EncodeToString(material)})
+	}
+	return keys, s.ActiveKID, nil
+}
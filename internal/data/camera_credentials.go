@@ -28,6 +28,15 @@ type CameraCredential struct {
 	UpdatedAt      time.Time
 }
 
+// CredentialInventoryEntry describes a camera's credential state without secret material.
+type CredentialInventoryEntry struct {
+	CameraID       uuid.UUID
+	CameraName     string
+	HasCredentials bool
+	MasterKID      string
+	UpdatedAt      *time.Time
+}
+
 type CredentialModel struct {
 	DB *sql.DB // Can verify if DBTX interface is needed, for now standard DB
 }
@@ -85,6 +94,26 @@ func (m CredentialModel) Upsert(ctx context.Context, c *CameraCredential) error
 	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
 }
 
+// RewrapDEK replaces the wrapped DEK of c with the values on c, leaving the
+// data ciphertext untouched. The row must still be wrapped under previousKID
+// with previousDEK; a concurrent write in between yields ErrOptimisticLock.
+func (m CredentialModel) RewrapDEK(ctx context.Context, c *CameraCredential, previousKID string, previousDEK []byte) error {
+	query := `
+		UPDATE camera_credentials
+		SET master_kid = $2, dek_nonce = $3, dek_ciphertext = $4, dek_tag = $5, updated_at = NOW()
+		WHERE camera_id = $1 AND master_kid = $6 AND dek_ciphertext = $7
+		RETURNING updated_at
+	`
+	err := m.DB.QueryRowContext(ctx, query,
+		c.CameraID, c.MasterKID, c.DEKNonce, c.DEKCiphertext, c.DEKTag,
+		previousKID, previousDEK,
+	).Scan(&c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrOptimisticLock
+	}
+	return err
+}
+
 func (m CredentialModel) Delete(ctx context.Context, cameraID uuid.UUID) error {
 	query := `DELETE FROM camera_credentials WHERE camera_id = $1`
 	res, err := m.DB.ExecContext(ctx, query, cameraID)
@@ -97,3 +126,58 @@ func (m CredentialModel) Delete(ctx context.Context, cameraID uuid.UUID) error {
 	}
 	return nil
 }
+
+// DeleteBySite removes credentials for every camera (including soft-deleted
+// ones) in the site. Both the camera and credential rows must belong to the
+// tenant, so a foreign site ID deletes nothing.
+func (m CredentialModel) DeleteBySite(ctx context.Context, tenantID, siteID uuid.UUID) (int, error) {
+	query := `
+		DELETE FROM camera_credentials cc
+		USING cameras c
+		WHERE cc.camera_id = c.id
+		  AND cc.tenant_id = $1 AND c.tenant_id = $1
+		  AND c.site_id = $2`
+	res, err := m.DB.ExecContext(ctx, query, tenantID, siteID)
+	if err != nil {
+		return 0, err
+	}
+	rows, _ := res.RowsAffected()
+	return int(rows), nil
+}
+
+// ListInventory returns one entry per non-deleted tenant camera. Only key metadata
+// is selected; ciphertext columns are never read.
+func (m CredentialModel) ListInventory(ctx context.Context, tenantID uuid.UUID) ([]CredentialInventoryEntry, error) {
+	query := `
+		SELECT c.id, c.name, cc.master_kid, cc.updated_at
+		FROM cameras c
+		LEFT JOIN camera_credentials cc ON cc.camera_id = c.id AND cc.tenant_id = c.tenant_id
+		WHERE c.tenant_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.name, c.id
+	`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []CredentialInventoryEntry
+	for rows.Next() {
+		var e CredentialInventoryEntry
+		var kid sql.NullString
+		var updated sql.NullTime
+		if err := rows.Scan(&e.CameraID, &e.CameraName, &kid, &updated); err != nil {
+			return nil, err
+		}
+		if kid.Valid {
+			e.HasCredentials = true
+			e.MasterKID = kid.String
+		}
+		if updated.Valid {
+			t := updated.Time
+			e.UpdatedAt = &t
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
@@ -29,6 +29,11 @@ type Camera struct {
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+
+	// Last configuration change made through Update; LastChangedBy is nil
+	// for changes without a user (system jobs).
+	LastChangedAt *time.Time `json:"last_changed_at,omitempty"`
+	LastChangedBy *uuid.UUID `json:"last_changed_by,omitempty"`
 }
 
 type CameraGroup struct {
@@ -67,31 +72,26 @@ func (m CameraModel) Create(ctx context.Context, c *Camera) error {
 	return err
 }
 
-// GetByID retrieves a camera by ID, strictly scoping to tenant (and site if provided/inforced by caller)
-// Note: We scan tenant_id so caller can verify RBAC
-func (m CameraModel) GetByID(ctx context.Context, id uuid.UUID) (*Camera, error) {
-	query := `
-		SELECT id, tenant_id, site_id, name, ip_address, port, 
+// cameraColumns is the column list scanCamera expects.
+const cameraColumns = `id, tenant_id, site_id, name, ip_address, port, 
 		       manufacturer, model, serial_number, mac_address, 
-		       is_enabled, tags, created_at, updated_at, deleted_at
-		FROM cameras
-		WHERE id = $1 AND deleted_at IS NULL`
+		       is_enabled, tags, created_at, updated_at, deleted_at,
+		       last_changed_at, last_changed_by`
 
+// scanCamera reads one cameraColumns row.
+func scanCamera(row interface{ Scan(...any) error }) (*Camera, error) {
 	var c Camera
 	var ipStr string
 	var tags []string
 	var manufacturer, model, serialNumber, macAddress sql.NullString
 
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&c.ID, &c.TenantID, &c.SiteID, &c.Name, &ipStr, &c.Port,
 		&manufacturer, &model, &serialNumber, &macAddress,
 		&c.IsEnabled, pq.Array(&tags), &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt,
+		&c.LastChangedAt, &c.LastChangedBy,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, ErrRecordNotFound
-		}
 		return nil, err
 	}
 	c.IPAddress = net.ParseIP(ipStr)
@@ -103,22 +103,67 @@ func (m CameraModel) GetByID(ctx context.Context, id uuid.UUID) (*Camera, error)
 	return &c, nil
 }
 
+// GetByID retrieves a camera by ID, strictly scoping to tenant (and site if provided/inforced by caller)
+// Note: We scan tenant_id so caller can verify RBAC
+func (m CameraModel) GetByID(ctx context.Context, id uuid.UUID) (*Camera, error) {
+	query := `
+		SELECT ` + cameraColumns + `
+		FROM cameras
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	c, err := scanCamera(m.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListByIDs loads the tenant's cameras among ids in one query, skipping
+// unknown, deleted and other tenants' cameras.
+func (m CameraModel) ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*Camera, error) {
+	query := `
+		SELECT ` + cameraColumns + `
+		FROM cameras
+		WHERE tenant_id = $1 AND id = ANY($2) AND deleted_at IS NULL
+		ORDER BY created_at ASC, id ASC`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Camera
+	for rows.Next() {
+		c, err := scanCamera(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
 // Update modifies camera struct. Assumes UpdatedAt is set by DB default or we set it here?
 // DB has DEFAULT NOW(), but standard Go pattern is to read it back.
+// Update writes the editable fields and stamps last_changed_at, with
+// c.LastChangedBy as the actor.
 func (m CameraModel) Update(ctx context.Context, c *Camera) error {
 	query := `
 		UPDATE cameras
 		SET name = $1, ip_address = $2, port = $3,
 		    manufacturer = $4, model = $5, serial_number = $6, mac_address = $7,
-		    tags = $8, updated_at = NOW()
-		WHERE id = $9 AND tenant_id = $10 AND deleted_at IS NULL
-		RETURNING updated_at`
+		    tags = $8, updated_at = NOW(), last_changed_at = NOW(), last_changed_by = $9
+		WHERE id = $10 AND tenant_id = $11 AND deleted_at IS NULL
+		RETURNING updated_at, last_changed_at`
 
 	err := m.DB.QueryRowContext(ctx, query,
 		c.Name, c.IPAddress.String(), c.Port,
 		c.Manufacturer, c.Model, c.SerialNumber, c.MacAddress,
-		pq.Array(c.Tags), c.ID, c.TenantID,
-	).Scan(&c.UpdatedAt)
+		pq.Array(c.Tags), c.LastChangedBy, c.ID, c.TenantID,
+	).Scan(&c.UpdatedAt, &c.LastChangedAt)
 
 	if err == sql.ErrNoRows {
 		return ErrRecordNotFound
@@ -126,9 +171,9 @@ func (m CameraModel) Update(ctx context.Context, c *Camera) error {
 	return err
 }
 
-// EnableDisable sets is_enabled
+// EnableDisable sets is_enabled and clears any license-disable mark
 func (m CameraModel) SetStatus(ctx context.Context, id, tenantID uuid.UUID, enabled bool) error {
-	query := `UPDATE cameras SET is_enabled = $1, updated_at = NOW() WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL`
+	query := `UPDATE cameras SET is_enabled = $1, license_disabled_at = NULL, updated_at = NOW() WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL`
 	res, err := m.DB.ExecContext(ctx, query, enabled, id, tenantID)
 	if err != nil {
 		return err
@@ -159,76 +204,111 @@ type CameraFilter struct {
 	SiteID    *uuid.UUID
 	IsEnabled *bool
 	Query     string // FTS
+	// FavoritesOf restricts the list to cameras pinned by this user
+	FavoritesOf *uuid.UUID
 }
 
-// List retrieves paginated cameras.
-func (m CameraModel) List(ctx context.Context, tenantID uuid.UUID, filter CameraFilter, limit, offset int) ([]*Camera, int, error) {
-	// 1. Build Base Query
-	where := "WHERE tenant_id = $1 AND deleted_at IS NULL"
-	args := []any{tenantID}
-	nextArg := 2
+// CameraSummary is the reduced projection served by the camera list's
+// summary view. HealthStatus is empty for cameras never probed.
+type CameraSummary struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	IsEnabled    bool      `json:"is_enabled"`
+	SiteID       uuid.UUID `json:"site_id"`
+	HealthStatus string    `json:"health_status"`
+}
+
+// cameraListQuery builds the tenant-scoped, filtered query shared by List
+// and ListSummary.
+func cameraListQuery(tenantID uuid.UUID, filter CameraFilter) *listQuery {
+	q := newListQuery("cameras").
+		where("tenant_id = ?", tenantID).
+		where("deleted_at IS NULL", nil)
 
 	if filter.SiteID != nil {
-		where += fmt.Sprintf(" AND site_id = $%d", nextArg)
-		args = append(args, *filter.SiteID)
-		nextArg++
+		q.where("site_id = ?", *filter.SiteID)
 	}
 	if filter.IsEnabled != nil {
-		where += fmt.Sprintf(" AND is_enabled = $%d", nextArg)
-		args = append(args, *filter.IsEnabled)
-		nextArg++
+		q.where("is_enabled = ?", *filter.IsEnabled)
 	}
 	if filter.Query != "" {
-		// FTS: query against search_text using pg_trgm
-		where += fmt.Sprintf(" AND search_text %% $%d", nextArg) // % operator for trgm similarity or LIKE?
-		// Plan said "search by name and ip". Trigram works well with LIKE %q% logic too if index supports it.
-		// "search_text gin_trgm_ops" supports ILIKE or %.
-		// Let's use ILIKE for partial match which trigram indexes accelerate.
-		// Actually, `search_text ILIKE '%' || $N || '%'` is standard for "contains".
-		where += fmt.Sprintf(" AND search_text ILIKE '%%' || $%d || '%%'", nextArg)
-		args = append(args, filter.Query)
-		nextArg++
-	}
-
-	// 2. Count Total (for pagination metadata if needed, usually good practice)
-	// Optimizing: Just return list for now as per requirements "paginated"
-	// But usually API returns X-Total-Count.
-	countQuery := "SELECT count(*) FROM cameras " + where
-	var total int
-	if err := m.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, err
+		// FTS: trigram similarity plus "contains", both accelerated by the
+		// search_text gin_trgm_ops index.
+		q.where("search_text % ?", filter.Query)
+		q.where(`search_text ILIKE '%' || ? || '%' ESCAPE '\'`, likeEscaper.Replace(filter.Query))
 	}
+	if filter.FavoritesOf != nil {
+		q.where("EXISTS (SELECT 1 FROM camera_favorites f WHERE f.camera_id = cameras.id AND f.user_id = ?)", *filter.FavoritesOf)
+	}
+	return q
+}
 
-	// 3. Select Data
-	query := fmt.Sprintf(`
-		SELECT id, tenant_id, site_id, name, ip_address, port, is_enabled, tags, created_at, updated_at 
-		FROM cameras 
-		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d`, where, nextArg, nextArg+1)
-
-	args = append(args, limit, offset)
+// List retrieves paginated cameras.
+func (m CameraModel) List(ctx context.Context, tenantID uuid.UUID, filter CameraFilter, limit, offset int) ([]*Camera, int, error) {
+	q := cameraListQuery(tenantID, filter)
 
-	rows, err := m.DB.QueryContext(ctx, query, args...)
+	var cameras []*Camera
+	total, err := q.page(ctx, m.DB,
+		"id, tenant_id, site_id, name, ip_address, port, is_enabled, tags, created_at, updated_at",
+		"created_at DESC", limit, offset,
+		func(rows *sql.Rows) error {
+			var c Camera
+			var ipStr string
+			var tags []string
+			if err := rows.Scan(&c.ID, &c.TenantID, &c.SiteID, &c.Name, &ipStr, &c.Port, &c.IsEnabled, pq.Array(&tags), &c.CreatedAt, &c.UpdatedAt); err != nil {
+				return err
+			}
+			c.IPAddress = net.ParseIP(ipStr)
+			c.Tags = tags
+			cameras = append(cameras, &c)
+			return nil
+		})
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
+	return cameras, total, nil
+}
 
-	var cameras []*Camera
-	for rows.Next() {
-		var c Camera
-		var ipStr string
-		var tags []string
-		if err := rows.Scan(&c.ID, &c.TenantID, &c.SiteID, &c.Name, &ipStr, &c.Port, &c.IsEnabled, pq.Array(&tags), &c.CreatedAt, &c.UpdatedAt); err != nil {
-			return nil, 0, err
-		}
-		c.IPAddress = net.ParseIP(ipStr)
-		c.Tags = tags
-		cameras = append(cameras, &c)
+// ListSummary is List reduced to the CameraSummary columns, with the current
+// health status read from camera_health_current.
+func (m CameraModel) ListSummary(ctx context.Context, tenantID uuid.UUID, filter CameraFilter, limit, offset int) ([]*CameraSummary, int, error) {
+	q := cameraListQuery(tenantID, filter)
+
+	var out []*CameraSummary
+	total, err := q.page(ctx, m.DB,
+		`id, name, is_enabled, site_id,
+		COALESCE((SELECT h.status::text FROM camera_health_current h
+			WHERE h.tenant_id = cameras.tenant_id AND h.camera_id = cameras.id), '')`,
+		"created_at DESC", limit, offset,
+		func(rows *sql.Rows) error {
+			var c CameraSummary
+			if err := rows.Scan(&c.ID, &c.Name, &c.IsEnabled, &c.SiteID, &c.HealthStatus); err != nil {
+				return err
+			}
+			out = append(out, &c)
+			return nil
+		})
+	if err != nil {
+		return nil, 0, err
 	}
+	return out, total, nil
+}
 
-	return cameras, total, nil
+// AddFavorite pins a camera for a user. Pinning twice is a no-op.
+func (m CameraModel) AddFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	query := `
+		INSERT INTO camera_favorites (tenant_id, user_id, camera_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, camera_id) DO NOTHING`
+	_, err := m.DB.ExecContext(ctx, query, tenantID, userID, cameraID)
+	return err
+}
+
+// RemoveFavorite unpins a camera for a user. Removing a missing pin is a no-op.
+func (m CameraModel) RemoveFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	query := `DELETE FROM camera_favorites WHERE tenant_id = $1 AND user_id = $2 AND camera_id = $3`
+	_, err := m.DB.ExecContext(ctx, query, tenantID, userID, cameraID)
+	return err
 }
 
 // CountEnabled used for license quota checks
@@ -239,28 +319,271 @@ func (m CameraModel) CountAll(ctx context.Context, tenantID uuid.UUID) (int, err
 	return count, err
 }
 
+// CountEnabled counts the tenant's enabled cameras, for the enabled-cameras quota.
+func (m CameraModel) CountEnabled(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	query := `SELECT count(*) FROM cameras WHERE tenant_id = $1 AND is_enabled = TRUE AND deleted_at IS NULL`
+	var count int
+	err := m.DB.QueryRowContext(ctx, query, tenantID).Scan(&count)
+	return count, err
+}
+
+// ListDisabledIDs returns which of ids are currently disabled (ignoring
+// unknown, deleted and other tenants' cameras), oldest first to match
+// ListEnabledIDsByPriority.
+func (m CameraModel) ListDisabledIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM cameras
+		WHERE tenant_id = $1 AND id = ANY($2) AND is_enabled = FALSE AND deleted_at IS NULL
+		ORDER BY created_at ASC, id ASC`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// ListTenantsWithEnabledCameras returns every tenant that has at least one enabled camera.
+// Used by startup reconciliation, which runs outside any request tenant scope.
+func (m CameraModel) ListTenantsWithEnabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	query := `SELECT DISTINCT tenant_id FROM cameras WHERE is_enabled = TRUE AND deleted_at IS NULL`
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListEnabledIDsByPriority returns enabled camera IDs, highest priority first.
+// Priority is seniority: the oldest cameras are kept when a quota forces disables.
+func (m CameraModel) ListEnabledIDsByPriority(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM cameras
+		WHERE tenant_id = $1 AND is_enabled = TRUE AND deleted_at IS NULL
+		ORDER BY created_at ASC, id ASC`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DisableForLicense disables ids and marks them as disabled by license
+// reconciliation, so ListLicenseDisabledIDs can restore them later.
+func (m CameraModel) DisableForLicense(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error {
+	query := `
+		UPDATE cameras
+		SET is_enabled = FALSE, license_disabled_at = NOW(), updated_at = NOW()
+		WHERE tenant_id = $1 AND id = ANY($2) AND deleted_at IS NULL`
+	_, err := m.DB.ExecContext(ctx, query, tenantID, pq.Array(ids))
+	return err
+}
+
+// ListTenantsWithLicenseDisabledCameras returns every tenant that has a
+// camera still disabled by license reconciliation.
+func (m CameraModel) ListTenantsWithLicenseDisabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	query := `SELECT DISTINCT tenant_id FROM cameras WHERE license_disabled_at IS NOT NULL AND is_enabled = FALSE AND deleted_at IS NULL`
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListLicenseDisabledIDs returns the tenant's cameras disabled by license
+// reconciliation, oldest first to match ListEnabledIDsByPriority.
+func (m CameraModel) ListLicenseDisabledIDs(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM cameras
+		WHERE tenant_id = $1 AND license_disabled_at IS NOT NULL AND is_enabled = FALSE AND deleted_at IS NULL
+		ORDER BY created_at ASC, id ASC`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ThumbnailDue is an enabled camera whose thumbnail needs (re)capturing.
+type ThumbnailDue struct {
+	CameraID       uuid.UUID  `json:"camera_id"`
+	TenantID       uuid.UUID  `json:"tenant_id"`
+	LastCapturedAt *time.Time `json:"last_captured_at,omitempty"` // nil: never captured
+}
+
+// ListDueForThumbnail returns enabled cameras across all tenants whose latest
+// thumbnail was captured before cutoff or never, never-captured and oldest first.
+func (m CameraModel) ListDueForThumbnail(ctx context.Context, cutoff time.Time, limit int) ([]ThumbnailDue, error) {
+	query := `
+		SELECT c.id, c.tenant_id, t.captured_at
+		FROM cameras c
+		LEFT JOIN camera_thumbnails t ON t.camera_id = c.id
+		WHERE c.is_enabled = TRUE AND c.deleted_at IS NULL
+		  AND (t.captured_at IS NULL OR t.captured_at < $1)
+		ORDER BY t.captured_at ASC NULLS FIRST, c.id
+		LIMIT $2`
+	rows, err := m.DB.QueryContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []ThumbnailDue{}
+	for rows.Next() {
+		var d ThumbnailDue
+		var captured sql.NullTime
+		if err := rows.Scan(&d.CameraID, &d.TenantID, &captured); err != nil {
+			return nil, err
+		}
+		if captured.Valid {
+			d.LastCapturedAt = &captured.Time
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// MarkThumbnailCaptured records the camera's latest thumbnail capture time.
+// An older capturedAt never overwrites a newer one.
+func (m CameraModel) MarkThumbnailCaptured(ctx context.Context, tenantID, cameraID uuid.UUID, capturedAt time.Time) error {
+	query := `
+		INSERT INTO camera_thumbnails (camera_id, tenant_id, captured_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (camera_id) DO UPDATE
+		SET captured_at = GREATEST(camera_thumbnails.captured_at, EXCLUDED.captured_at)`
+	_, err := m.DB.ExecContext(ctx, query, cameraID, tenantID, capturedAt)
+	return err
+}
+
 // BulkEnable checks quotas before enabling.
 // actually the Service Layer should do the quota check logic.
 // Model just executes bulk update.
+// Any license-disable mark is cleared (see DisableForLicense).
 func (m CameraModel) BulkUpdateStatus(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, enabled bool) error {
 	query := `
 		UPDATE cameras 
-		SET is_enabled = $1, updated_at = NOW()
+		SET is_enabled = $1, license_disabled_at = NULL, updated_at = NOW()
 		WHERE tenant_id = $2 AND id = ANY($3) AND deleted_at IS NULL`
 	_, err := m.DB.ExecContext(ctx, query, enabled, tenantID, pq.Array(ids))
 	return err
 }
 
-func (m CameraModel) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
-	// Postres Array append: array_cat or ||
-	// Uniq usage: SELECT array_agg(DISTINCT x) ... complex update?
-	// Simpler: UPDATE cameras SET tags = array(select distinct unnest(tags || $3))
+// BulkAddTags merges tags into the given cameras in one statement. It returns
+// the cameras that would end up with more than maxTags distinct tags; if there
+// are any, nothing is updated. The per-row cap is re-checked on update, so a
+// concurrent tag change cannot push a camera past it either.
+func (m CameraModel) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string, maxTags int) ([]uuid.UUID, error) {
 	query := `
-		UPDATE cameras
-		SET tags = (SELECT ARRAY(SELECT DISTINCT UNNEST(tags || $1)))
-		WHERE tenant_id = $2 AND id = ANY($3) AND deleted_at IS NULL`
-	_, err := m.DB.ExecContext(ctx, query, pq.Array(tags), tenantID, pq.Array(ids))
-	return err
+		WITH over AS (
+			SELECT id FROM cameras
+			WHERE tenant_id = $2 AND id = ANY($3) AND deleted_at IS NULL
+			  AND cardinality(ARRAY(SELECT DISTINCT unnest(tags || $1::text[]))) > $4
+		), upd AS (
+			UPDATE cameras
+			SET tags = ARRAY(SELECT DISTINCT unnest(tags || $1::text[]))
+			WHERE tenant_id = $2 AND id = ANY($3) AND deleted_at IS NULL
+			  AND NOT EXISTS (SELECT 1 FROM over)
+			  AND cardinality(ARRAY(SELECT DISTINCT unnest(tags || $1::text[]))) <= $4
+		)
+		SELECT id FROM over`
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(tags), tenantID, pq.Array(ids), maxTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var over []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		over = append(over, id)
+	}
+	return over, rows.Err()
+}
+
+// TagCount is a distinct camera tag and how many cameras carry it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// ListTags returns the tenant's distinct camera tags starting with prefix
+// (case-insensitive), most used first.
+func (m CameraModel) ListTags(ctx context.Context, tenantID uuid.UUID, prefix string, limit int) ([]TagCount, error) {
+	escaped := likeEscaper.Replace(prefix)
+	query := `
+		SELECT t, count(*)
+		FROM cameras, unnest(tags) AS t
+		WHERE tenant_id = $1 AND deleted_at IS NULL AND t ILIKE $2 || '%' ESCAPE '\'
+		GROUP BY t
+		ORDER BY count(*) DESC, t
+		LIMIT $3`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID, escaped, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []TagCount{}
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, tc)
+	}
+	return out, rows.Err()
 }
 
 func (m CameraModel) BulkRemoveTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
@@ -279,6 +602,171 @@ func (m CameraModel) BulkRemoveTags(ctx context.Context, tenantID uuid.UUID, ids
 	return err
 }
 
+// BulkSetTags overwrites the tags array of the given cameras in one statement.
+func (m CameraModel) BulkSetTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
+	query := `
+		UPDATE cameras
+		SET tags = $1
+		WHERE tenant_id = $2 AND id = ANY($3) AND deleted_at IS NULL`
+	_, err := m.DB.ExecContext(ctx, query, pq.Array(tags), tenantID, pq.Array(ids))
+	return err
+}
+
+// CameraSiteConflict describes a camera whose NVR link lives on a different site.
+type CameraSiteConflict struct {
+	CameraID  uuid.UUID `json:"camera_id"`
+	NVRID     uuid.UUID `json:"nvr_id"`
+	NVRSiteID uuid.UUID `json:"nvr_site_id"`
+}
+
+// SiteBelongsToTenant reports whether siteID exists under tenantID.
+func (m CameraModel) SiteBelongsToTenant(ctx context.Context, siteID, tenantID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM sites WHERE id = $1 AND tenant_id = $2)`
+	var exists bool
+	err := m.DB.QueryRowContext(ctx, query, siteID, tenantID).Scan(&exists)
+	return exists, err
+}
+
+// GetDefaultSiteID returns the tenant's default_site_id, or nil when none is set.
+func (m CameraModel) GetDefaultSiteID(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error) {
+	var siteID *uuid.UUID
+	err := m.DB.QueryRowContext(ctx, `SELECT default_site_id FROM tenants WHERE id = $1`, tenantID).Scan(&siteID)
+	if err == sql.ErrNoRows {
+		return nil, ErrRecordNotFound
+	}
+	return siteID, err
+}
+
+// SetDefaultSiteID updates the tenant's default_site_id; nil clears it.
+func (m CameraModel) SetDefaultSiteID(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID) error {
+	res, err := m.DB.ExecContext(ctx, `UPDATE tenants SET default_site_id = $1 WHERE id = $2`, siteID, tenantID)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetCameraDefaultEnabled returns the tenant's camera_default_enabled.
+func (m CameraModel) GetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	var enabled bool
+	err := m.DB.QueryRowContext(ctx, `SELECT camera_default_enabled FROM tenants WHERE id = $1`, tenantID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, ErrRecordNotFound
+	}
+	return enabled, err
+}
+
+// SetCameraDefaultEnabled updates the tenant's camera_default_enabled.
+func (m CameraModel) SetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID, enabled bool) error {
+	res, err := m.DB.ExecContext(ctx, `UPDATE tenants SET camera_default_enabled = $1 WHERE id = $2`, enabled, tenantID)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListNVRSiteConflicts returns cameras among ids linked to an NVR whose site differs from siteID.
+func (m CameraModel) ListNVRSiteConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]CameraSiteConflict, error) {
+	query := `
+		SELECT l.camera_id, n.id, n.site_id
+		FROM camera_nvr_links l
+		JOIN nvrs n ON n.id = l.nvr_id AND n.deleted_at IS NULL
+		WHERE l.tenant_id = $1 AND l.camera_id = ANY($2) AND n.site_id <> $3`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID, pq.Array(ids), siteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []CameraSiteConflict
+	for rows.Next() {
+		var c CameraSiteConflict
+		if err := rows.Scan(&c.CameraID, &c.NVRID, &c.NVRSiteID); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// FindByIP returns the tenant's oldest non-deleted camera with the given IP,
+// or ErrRecordNotFound.
+func (m CameraModel) FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error) {
+	query := `
+		SELECT id FROM cameras
+		WHERE tenant_id = $1 AND deleted_at IS NULL AND ip_address = $2::inet
+		ORDER BY created_at
+		LIMIT 1`
+	var id uuid.UUID
+	err := m.DB.QueryRowContext(ctx, query, tenantID, ip.String()).Scan(&id)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrRecordNotFound
+	}
+	return id, err
+}
+
+// CameraIPInSite reports whether another non-deleted camera in siteID already
+// uses ip. excludeID (uuid.Nil for none) skips the camera being updated.
+func (m CameraModel) CameraIPInSite(ctx context.Context, tenantID, siteID uuid.UUID, ip net.IP, excludeID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM cameras
+			WHERE tenant_id = $1 AND site_id = $2 AND ip_address = $3::inet
+			  AND id <> $4 AND deleted_at IS NULL)`
+	var exists bool
+	err := m.DB.QueryRowContext(ctx, query, tenantID, siteID, ip.String(), excludeID).Scan(&exists)
+	return exists, err
+}
+
+// ListSiteIPConflicts returns the ids that would share an IP with a camera
+// in siteID if moved there: one already in the site, or another of ids.
+func (m CameraModel) ListSiteIPConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT c.id
+		FROM cameras c
+		JOIN cameras o ON o.tenant_id = c.tenant_id AND o.ip_address = c.ip_address
+		                AND (o.site_id = $3 OR o.id = ANY($2))
+		                AND o.id <> c.id AND o.deleted_at IS NULL
+		WHERE c.tenant_id = $1 AND c.id = ANY($2) AND c.site_id <> $3 AND c.deleted_at IS NULL`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID, pq.Array(ids), siteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, id)
+	}
+	return conflicts, rows.Err()
+}
+
+// BulkMoveSite reassigns site_id for all ids in a single statement. Returns rows moved.
+func (m CameraModel) BulkMoveSite(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) (int, error) {
+	query := `
+		UPDATE cameras
+		SET site_id = $1, updated_at = NOW()
+		WHERE tenant_id = $2 AND id = ANY($3) AND deleted_at IS NULL`
+	res, err := m.DB.ExecContext(ctx, query, siteID, tenantID, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	rows, _ := res.RowsAffected()
+	return int(rows), nil
+}
+
 // --- Grouping ---
 
 func (m CameraModel) CreateGroup(ctx context.Context, g *CameraGroup) error {
@@ -293,6 +781,10 @@ func (m CameraModel) CreateGroup(ctx context.Context, g *CameraGroup) error {
 	}
 
 	err := m.DB.QueryRowContext(ctx, query, g.TenantID, siteID, g.Name, g.Description).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		// UNIQUE(tenant_id, name)
+		return ErrDuplicateGroupName
+	}
 	return err
 }
 
@@ -384,3 +876,143 @@ func (m CameraModel) ResolveSiteID(ctx context.Context, cameraID string) (string
 	}
 	return siteID, nil
 }
+
+// PurgeCandidate is a camera soft-deleted before the purge cutoff.
+type PurgeCandidate struct {
+	CameraID  uuid.UUID `json:"camera_id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// purgeUnreferenced keeps cameras still referenced by NVR links or
+// credentials out of the purge; they stay soft-deleted until unlinked.
+const purgeUnreferenced = `
+		  AND NOT EXISTS (SELECT 1 FROM camera_nvr_links l WHERE l.camera_id = c.id)
+		  AND NOT EXISTS (SELECT 1 FROM camera_credentials cc WHERE cc.camera_id = c.id)`
+
+// cameraMediaTables hold per-camera media rows without a foreign key to
+// cameras; HardDelete removes them with the camera.
+var cameraMediaTables = []string{
+	"camera_media_profiles",
+	"camera_stream_selections",
+	"rtsp_validation_results",
+	"rtsp_validation_history",
+}
+
+// ListPurgeable returns unreferenced cameras across all tenants soft-deleted
+// before cutoff, oldest first.
+func (m CameraModel) ListPurgeable(ctx context.Context, cutoff time.Time, limit int) ([]PurgeCandidate, error) {
+	query := `
+		SELECT c.id, c.tenant_id, c.deleted_at
+		FROM cameras c
+		WHERE c.deleted_at IS NOT NULL AND c.deleted_at < $1` + purgeUnreferenced + `
+		ORDER BY c.deleted_at ASC, c.id
+		LIMIT $2`
+	rows, err := m.DB.QueryContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []PurgeCandidate{}
+	for rows.Next() {
+		var p PurgeCandidate
+		if err := rows.Scan(&p.CameraID, &p.TenantID, &p.DeletedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// HardDelete permanently removes a camera soft-deleted before cutoff, along
+// with its media rows, in one transaction. The reference check is repeated
+// in the statement so a link or credential added since ListPurgeable keeps
+// the row; that case returns ErrRecordNotFound.
+func (m CameraModel) HardDelete(ctx context.Context, id, tenantID uuid.UUID, cutoff time.Time) error {
+	db, ok := m.DB.(interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	})
+	if !ok {
+		return errors.New("hard delete requires a transactional handle")
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM cameras c
+		WHERE c.id = $1 AND c.tenant_id = $2
+		  AND c.deleted_at IS NOT NULL AND c.deleted_at < $3` + purgeUnreferenced
+	res, err := tx.ExecContext(ctx, query, id, tenantID, cutoff)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+
+	// Media tables are tenant-isolated by RLS
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('app.current_tenant', $1, true)`, tenantID.String()); err != nil {
+		return err
+	}
+	for _, table := range cameraMediaTables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE tenant_id = $1 AND camera_id = $2`, tenantID, id); err != nil {
+			return fmt.Errorf("purge %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ONVIFEventSource is an enabled camera subscribed to ONVIF events.
+type ONVIFEventSource struct {
+	CameraID  uuid.UUID
+	TenantID  uuid.UUID
+	SiteID    uuid.UUID
+	IPAddress net.IP
+}
+
+// ListONVIFEventSources returns enabled cameras across all tenants with
+// onvif_events_enabled set. Used by the ONVIF event bridge, which runs
+// outside any request tenant scope.
+func (m CameraModel) ListONVIFEventSources(ctx context.Context) ([]ONVIFEventSource, error) {
+	query := `
+		SELECT id, tenant_id, site_id, ip_address
+		FROM cameras
+		WHERE onvif_events_enabled = TRUE AND is_enabled = TRUE AND deleted_at IS NULL
+		ORDER BY id`
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []ONVIFEventSource{}
+	for rows.Next() {
+		var s ONVIFEventSource
+		var ipStr string
+		if err := rows.Scan(&s.CameraID, &s.TenantID, &s.SiteID, &ipStr); err != nil {
+			return nil, err
+		}
+		s.IPAddress = net.ParseIP(ipStr)
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// SetONVIFEvents turns the camera's ONVIF event subscription on or off.
+func (m CameraModel) SetONVIFEvents(ctx context.Context, tenantID, id uuid.UUID, enabled bool) error {
+	query := `UPDATE cameras SET onvif_events_enabled = $1, updated_at = NOW() WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL`
+	res, err := m.DB.ExecContext(ctx, query, enabled, id, tenantID)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
@@ -24,6 +24,8 @@ type DiscoveryRun struct {
 	FinishedAt  *time.Time `json:"finished_at,omitempty"`
 	DeviceCount int        `json:"device_count"`
 	ErrorCount  int        `json:"error_count"`
+	MaxDevices  int        `json:"max_devices"`
+	Truncated   bool       `json:"truncated"`
 }
 
 type DiscoveredDevice struct {
@@ -59,31 +61,45 @@ type DiscoveryModel struct {
 // Runs
 func (m *DiscoveryModel) CreateRun(ctx context.Context, run *DiscoveryRun) error {
 	query := `
-		INSERT INTO onvif_discovery_runs (tenant_id, site_id, status)
-		VALUES ($1, $2, $3)
+		INSERT INTO onvif_discovery_runs (tenant_id, site_id, status, max_devices)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, started_at
 	`
-	return m.DB.QueryRowContext(ctx, query, run.TenantID, run.SiteID, run.Status).Scan(&run.ID, &run.StartedAt)
+	return m.DB.QueryRowContext(ctx, query, run.TenantID, run.SiteID, run.Status, run.MaxDevices).Scan(&run.ID, &run.StartedAt)
 }
 
-func (m *DiscoveryModel) UpdateRunStatus(ctx context.Context, id uuid.UUID, status string, finished bool, dCount, eCount int) error {
+// CountRunningRuns counts the tenant's runs still "running" that started after startedAfter.
+func (m *DiscoveryModel) CountRunningRuns(ctx context.Context, tenantID uuid.UUID, startedAfter time.Time) (int, error) {
+	query := `
+		SELECT count(*) FROM onvif_discovery_runs
+		WHERE tenant_id = $1 AND status = 'running' AND started_at > $2
+	`
+	var n int
+	err := m.DB.QueryRowContext(ctx, query, tenantID, startedAfter).Scan(&n)
+	return n, err
+}
+
+func (m *DiscoveryModel) UpdateRunStatus(ctx context.Context, id uuid.UUID, status string, finished bool, dCount, eCount int, truncated bool) error {
 	query := `
 		UPDATE onvif_discovery_runs 
-		SET status = $2, device_count = $3, error_count = $4, finished_at = CASE WHEN $5 THEN NOW() ELSE finished_at END
+		SET status = $2, device_count = $3, error_count = $4, finished_at = CASE WHEN $5 THEN NOW() ELSE finished_at END,
+		    truncated = $6
 		WHERE id = $1
 	`
-	_, err := m.DB.ExecContext(ctx, query, id, status, dCount, eCount, finished)
+	_, err := m.DB.ExecContext(ctx, query, id, status, dCount, eCount, finished, truncated)
 	return err
 }
 
 func (m *DiscoveryModel) GetRun(ctx context.Context, id uuid.UUID) (*DiscoveryRun, error) {
 	query := `
-		SELECT id, tenant_id, site_id, status, started_at, finished_at, device_count, error_count
+		SELECT id, tenant_id, site_id, status, started_at, finished_at, device_count, error_count,
+		       max_devices, truncated
 		FROM onvif_discovery_runs WHERE id = $1
 	`
 	var r DiscoveryRun
 	err := m.DB.QueryRowContext(ctx, query, id).Scan(
 		&r.ID, &r.TenantID, &r.SiteID, &r.Status, &r.StartedAt, &r.FinishedAt, &r.DeviceCount, &r.ErrorCount,
+		&r.MaxDevices, &r.Truncated,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrRunNotFound
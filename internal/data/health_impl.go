@@ -111,18 +111,21 @@ func (m *HealthModel) GetHistory(ctx context.Context, cameraID uuid.UUID, limit,
 }
 
 func (m *HealthModel) UpsertAlert(ctx context.Context, a *CameraAlert) error {
-	// Insert new alert
+	// uq_camera_alerts_open allows one open alert per (camera, type); a
+	// repeat or racing write refreshes it instead of inserting a duplicate.
 	query := `
-		INSERT INTO camera_alerts (tenant_id, camera_id, type, state, started_at, ended_at, last_notified_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id
+		INSERT INTO camera_alerts (tenant_id, camera_id, type, state, started_at, ended_at, last_notified_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (camera_id, type) WHERE state = 'open'
+		DO UPDATE SET last_seen_at = GREATEST(camera_alerts.last_seen_at, EXCLUDED.last_seen_at)
+		RETURNING id, started_at
 	`
-	return m.DB.QueryRowContext(ctx, query, a.TenantID, a.CameraID, a.Type, a.State, a.StartedAt, a.EndedAt, a.LastNotifiedAt).Scan(&a.ID)
+	return m.DB.QueryRowContext(ctx, query, a.TenantID, a.CameraID, a.Type, a.State, a.StartedAt, a.EndedAt, a.LastNotifiedAt, a.LastSeenAt).Scan(&a.ID, &a.StartedAt)
 }
 
 func (m *HealthModel) GetOpenAlert(ctx context.Context, cameraID uuid.UUID, alertType string) (*CameraAlert, error) {
 	query := `
-		SELECT id, tenant_id, camera_id, type, state, started_at, ended_at, last_notified_at
+		SELECT id, tenant_id, camera_id, type, state, started_at, ended_at, last_notified_at, last_seen_at
 		FROM camera_alerts
 		WHERE camera_id = $1 AND type = $2 AND state = 'open'
 		LIMIT 1
@@ -131,7 +134,7 @@ func (m *HealthModel) GetOpenAlert(ctx context.Context, cameraID uuid.UUID, aler
 	var ended, notified pq.NullTime
 
 	err := m.DB.QueryRowContext(ctx, query, cameraID, alertType).Scan(
-		&a.ID, &a.TenantID, &a.CameraID, &a.Type, &a.State, &a.StartedAt, &ended, &notified,
+		&a.ID, &a.TenantID, &a.CameraID, &a.Type, &a.State, &a.StartedAt, &ended, &notified, &a.LastSeenAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -152,21 +155,42 @@ func (m *HealthModel) CloseAlert(ctx context.Context, alertID uuid.UUID) error {
 	query := `
 		UPDATE camera_alerts
 		SET state = 'closed', ended_at = NOW()
-		WHERE id = $1
+		WHERE id = $1 AND state = 'open'
 	`
 	_, err := m.DB.ExecContext(ctx, query, alertID)
 	return err
 }
 
+func (m *HealthModel) MarkAlertNotified(ctx context.Context, alertID uuid.UUID) (bool, error) {
+	// Conditional on last_notified_at so concurrent checks notify once.
+	query := `
+		UPDATE camera_alerts
+		SET last_notified_at = NOW()
+		WHERE id = $1 AND state = 'open' AND last_notified_at IS NULL
+	`
+	res, err := m.DB.ExecContext(ctx, query, alertID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (m *HealthModel) ClearAlertNotified(ctx context.Context, alertID uuid.UUID) error {
+	query := `UPDATE camera_alerts SET last_notified_at = NULL WHERE id = $1`
+	_, err := m.DB.ExecContext(ctx, query, alertID)
+	return err
+}
+
 func (m *HealthModel) ListAlerts(ctx context.Context, tenantID uuid.UUID, state string) ([]*CameraAlert, error) {
 	query := `
-		SELECT id, tenant_id, camera_id, type, state, started_at, ended_at, last_notified_at
+		SELECT id, tenant_id, camera_id, type, state, started_at, ended_at, last_notified_at, last_seen_at
 		FROM camera_alerts
-		WHERE tenant_id = $1
+		WHERE tenant_id = $1 AND type <> $2
 	`
-	args := []interface{}{tenantID}
+	args := []interface{}{tenantID, AlertTypeOfflineNotice}
 	if state != "" {
-		query += " AND state = $2"
+		query += " AND state = $3"
 		args = append(args, state)
 	}
 	query += " ORDER BY started_at DESC LIMIT 50"
@@ -181,7 +205,7 @@ func (m *HealthModel) ListAlerts(ctx context.Context, tenantID uuid.UUID, state
 	for rows.Next() {
 		var a CameraAlert
 		var ended, notified pq.NullTime
-		if err := rows.Scan(&a.ID, &a.TenantID, &a.CameraID, &a.Type, &a.State, &a.StartedAt, &ended, &notified); err != nil {
+		if err := rows.Scan(&a.ID, &a.TenantID, &a.CameraID, &a.Type, &a.State, &a.StartedAt, &ended, &notified, &a.LastSeenAt); err != nil {
 			return nil, err
 		}
 		if ended.Valid {
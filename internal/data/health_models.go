@@ -44,6 +44,11 @@ type CameraHealthHistory struct {
 	RTTMS      int                `json:"rtt_ms,omitempty"`
 }
 
+// AlertTypeOfflineNotice is the internal alert row that tracks an offline
+// notification incident; it is bookkeeping, not a user-facing alert, so
+// ListAlerts leaves it out.
+const AlertTypeOfflineNotice = "offline_notice"
+
 type CameraAlert struct {
 	ID             uuid.UUID  `json:"id"`
 	TenantID       uuid.UUID  `json:"tenant_id"`
@@ -53,6 +58,8 @@ type CameraAlert struct {
 	StartedAt      time.Time  `json:"started_at"`
 	EndedAt        *time.Time `json:"ended_at,omitempty"`
 	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty"`
+	// LastSeenAt is the most recent check that still saw the condition.
+	LastSeenAt time.Time `json:"last_seen_at"`
 }
 
 // CameraHealthTarget is a minimal struct for the scheduler
@@ -73,9 +80,17 @@ type HealthRepository interface {
 	PruneHistory(ctx context.Context, cameraID uuid.UUID, maxRecords int) error
 	GetHistory(ctx context.Context, cameraID uuid.UUID, limit, offset int) ([]*CameraHealthHistory, error)
 
+	// UpsertAlert opens an alert, or refreshes last_seen_at on the open alert
+	// of the same camera and type; a.ID and a.StartedAt are set to the stored
+	// alert's.
 	UpsertAlert(ctx context.Context, a *CameraAlert) error
 	GetOpenAlert(ctx context.Context, cameraID uuid.UUID, alertType string) (*CameraAlert, error)
 	CloseAlert(ctx context.Context, alertID uuid.UUID) error
+	// MarkAlertNotified sets last_notified_at on an open alert that has
+	// none, reporting false when it was already set.
+	MarkAlertNotified(ctx context.Context, alertID uuid.UUID) (bool, error)
+	// ClearAlertNotified unsets last_notified_at after a failed delivery.
+	ClearAlertNotified(ctx context.Context, alertID uuid.UUID) error
 	ListAlerts(ctx context.Context, tenantID uuid.UUID, state string) ([]*CameraAlert, error)
 
 	ListStatuses(ctx context.Context, tenantID uuid.UUID) ([]*CameraHealthCurrent, error)
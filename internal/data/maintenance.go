@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaintenanceModel persists tenant maintenance mode in tenants.maintenance_since.
+type MaintenanceModel struct {
+	DB *sql.DB
+}
+
+// SetTenantMaintenance records when maintenance started for the tenant; a
+// nil since clears it.
+func (m MaintenanceModel) SetTenantMaintenance(ctx context.Context, tenantID uuid.UUID, since *time.Time) error {
+	res, err := m.DB.ExecContext(ctx, `UPDATE tenants SET maintenance_since = $1 WHERE id = $2`, since, tenantID)
+	if err != nil {
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListTenantMaintenance returns every tenant in maintenance with its start time.
+func (m MaintenanceModel) ListTenantMaintenance(ctx context.Context) (map[uuid.UUID]time.Time, error) {
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, maintenance_since FROM tenants WHERE maintenance_since IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uuid.UUID]time.Time)
+	for rows.Next() {
+		var id uuid.UUID
+		var since time.Time
+		if err := rows.Scan(&id, &since); err != nil {
+			return nil, err
+		}
+		out[id] = since
+	}
+	return out, rows.Err()
+}
@@ -225,3 +225,63 @@ func (m *MediaModel) GetValidationResults(ctx context.Context, cameraID uuid.UUI
 	}
 	return list, nil
 }
+
+// Validation History
+func (m *MediaModel) AppendValidationHistory(ctx context.Context, r *RTSPValidationResult) error {
+	query := `
+		INSERT INTO rtsp_validation_history (
+			tenant_id, camera_id, variant, status, last_error_code, rtt_ms, validated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, validated_at
+	`
+	return m.DB.QueryRowContext(ctx, query,
+		r.TenantID, r.CameraID, r.Variant, r.Status, r.LastErrorCode, r.RTT,
+	).Scan(&r.ID, &r.ValidatedAt)
+}
+
+// ListValidationHistory returns the newest entries first, across both variants.
+func (m *MediaModel) ListValidationHistory(ctx context.Context, cameraID uuid.UUID, limit int) ([]*RTSPValidationResult, error) {
+	query := `
+		SELECT id, tenant_id, camera_id, variant, status, COALESCE(last_error_code, ''), COALESCE(rtt_ms, 0), validated_at
+		FROM rtsp_validation_history
+		WHERE camera_id = $1
+		ORDER BY validated_at DESC
+		LIMIT $2
+	`
+	rows, err := m.DB.QueryContext(ctx, query, cameraID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*RTSPValidationResult
+	for rows.Next() {
+		r := &RTSPValidationResult{}
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.CameraID, &r.Variant, &r.Status, &r.LastErrorCode, &r.RTT, &r.ValidatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// PruneValidationHistory keeps at most `keep` newest entries for a camera variant
+// and drops anything validated before `before`. Returns rows deleted.
+func (m *MediaModel) PruneValidationHistory(ctx context.Context, cameraID uuid.UUID, variant string, keep int, before time.Time) (int, error) {
+	query := `
+		DELETE FROM rtsp_validation_history
+		WHERE camera_id = $1 AND variant = $2
+		  AND (validated_at < $4 OR id NOT IN (
+			SELECT id FROM rtsp_validation_history
+			WHERE camera_id = $1 AND variant = $2
+			ORDER BY validated_at DESC
+			LIMIT $3
+		  ))
+	`
+	res, err := m.DB.ExecContext(ctx, query, cameraID, variant, keep, before)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
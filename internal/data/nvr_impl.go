@@ -19,19 +19,19 @@ type NVRModel struct {
 
 func (m NVRModel) Create(ctx context.Context, nvr *NVR) error {
 	query := `
-		INSERT INTO nvrs (tenant_id, site_id, name, vendor, ip_address, port, is_enabled, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO nvrs (tenant_id, site_id, name, vendor, ip_address, port, is_enabled, status, health_check_interval_seconds, event_poll_interval_ms, health_probe_mode, vendor_confidence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, created_at, updated_at`
 
 	err := m.DB.QueryRowContext(ctx, query,
-		nvr.TenantID, nvr.SiteID, nvr.Name, nvr.Vendor, nvr.IPAddress, nvr.Port, nvr.IsEnabled, nvr.Status,
+		nvr.TenantID, nvr.SiteID, nvr.Name, nvr.Vendor, nvr.IPAddress, nvr.Port, nvr.IsEnabled, nvr.Status, nvr.HealthCheckIntervalSeconds, nvr.EventPollIntervalMs, nvr.HealthProbeMode, nvr.VendorConfidence,
 	).Scan(&nvr.ID, &nvr.CreatedAt, &nvr.UpdatedAt)
 	return err
 }
 
 func (m NVRModel) GetByID(ctx context.Context, id uuid.UUID) (*NVR, error) {
 	query := `
-		SELECT id, tenant_id, site_id, name, vendor, ip_address::text, port, is_enabled, status, last_status_at, created_at, updated_at
+		SELECT id, tenant_id, site_id, name, vendor, ip_address::text, port, is_enabled, status, last_status_at, created_at, updated_at, health_check_interval_seconds, event_poll_interval_ms, health_probe_mode, vendor_confidence
 		FROM nvrs
 		WHERE id = $1 AND deleted_at IS NULL`
 
@@ -39,7 +39,7 @@ func (m NVRModel) GetByID(ctx context.Context, id uuid.UUID) (*NVR, error) {
 	var lastStatus sql.NullTime
 
 	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&n.ID, &n.TenantID, &n.SiteID, &n.Name, &n.Vendor, &n.IPAddress, &n.Port, &n.IsEnabled, &n.Status, &lastStatus, &n.CreatedAt, &n.UpdatedAt,
+		&n.ID, &n.TenantID, &n.SiteID, &n.Name, &n.Vendor, &n.IPAddress, &n.Port, &n.IsEnabled, &n.Status, &lastStatus, &n.CreatedAt, &n.UpdatedAt, &n.HealthCheckIntervalSeconds, &n.EventPollIntervalMs, &n.HealthProbeMode, &n.VendorConfidence,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrRecordNotFound
@@ -54,77 +54,51 @@ func (m NVRModel) GetByID(ctx context.Context, id uuid.UUID) (*NVR, error) {
 }
 
 func (m NVRModel) List(ctx context.Context, tenantID uuid.UUID, filter NVRFilter, limit, offset int) ([]*NVR, int, error) {
-	where := "WHERE tenant_id = $1 AND deleted_at IS NULL"
-	args := []any{tenantID}
-	nextArg := 2
+	q := newListQuery("nvrs").
+		where("tenant_id = ?", tenantID).
+		where("deleted_at IS NULL", nil)
 
 	if filter.SiteID != nil {
-		where += fmt.Sprintf(" AND site_id = $%d", nextArg)
-		args = append(args, *filter.SiteID)
-		nextArg++
+		q.where("site_id = ?", *filter.SiteID)
 	}
 	if filter.Vendor != nil {
-		where += fmt.Sprintf(" AND vendor = $%d", nextArg)
-		args = append(args, *filter.Vendor)
-		nextArg++
+		q.where("vendor = ?", *filter.Vendor)
 	}
 	if filter.Status != nil {
-		where += fmt.Sprintf(" AND status = $%d", nextArg)
-		args = append(args, *filter.Status)
-		nextArg++
+		q.where("status = ?", *filter.Status)
 	}
 	if filter.IsEnabled != nil {
-		where += fmt.Sprintf(" AND is_enabled = $%d", nextArg)
-		args = append(args, *filter.IsEnabled)
-		nextArg++
+		q.where("is_enabled = ?", *filter.IsEnabled)
 	}
 	if filter.Query != "" {
-		where += fmt.Sprintf(" AND (name ILIKE '%%' || $%d || '%%' OR ip_address::text ILIKE '%%' || $%d || '%%')", nextArg, nextArg)
-		args = append(args, filter.Query)
-		nextArg++
+		q.where(`(name ILIKE '%' || ? || '%' ESCAPE '\' OR ip_address::text ILIKE '%' || ? || '%' ESCAPE '\')`, likeEscaper.Replace(filter.Query))
 	}
 
-	// Count
-	var total int
-	countQuery := "SELECT count(*) FROM nvrs " + where
-	if err := m.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, err
-	}
-
-	// Select
-	query := fmt.Sprintf(`
-		SELECT id, tenant_id, site_id, name, vendor, ip_address::text, port, is_enabled, status, last_status_at, created_at, updated_at
-		FROM nvrs
-		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d`, where, nextArg, nextArg+1)
-
-	args = append(args, limit, offset)
-
-	rows, err := m.DB.QueryContext(ctx, query, args...)
+	var nvrs []*NVR
+	total, err := q.page(ctx, m.DB,
+		"id, tenant_id, site_id, name, vendor, ip_address::text, port, is_enabled, status, last_status_at, created_at, updated_at, health_check_interval_seconds, event_poll_interval_ms, health_probe_mode, vendor_confidence",
+		"created_at DESC", limit, offset,
+		func(rows *sql.Rows) error {
+			var n NVR
+			var lastStatus sql.NullTime
+			if err := rows.Scan(&n.ID, &n.TenantID, &n.SiteID, &n.Name, &n.Vendor, &n.IPAddress, &n.Port, &n.IsEnabled, &n.Status, &lastStatus, &n.CreatedAt, &n.UpdatedAt, &n.HealthCheckIntervalSeconds, &n.EventPollIntervalMs, &n.HealthProbeMode, &n.VendorConfidence); err != nil {
+				return err
+			}
+			if lastStatus.Valid {
+				n.LastStatusAt = &lastStatus.Time
+			}
+			nvrs = append(nvrs, &n)
+			return nil
+		})
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	var nvrs []*NVR
-	for rows.Next() {
-		var n NVR
-		var lastStatus sql.NullTime
-		if err := rows.Scan(&n.ID, &n.TenantID, &n.SiteID, &n.Name, &n.Vendor, &n.IPAddress, &n.Port, &n.IsEnabled, &n.Status, &lastStatus, &n.CreatedAt, &n.UpdatedAt); err != nil {
-			return nil, 0, err
-		}
-		if lastStatus.Valid {
-			n.LastStatusAt = &lastStatus.Time
-		}
-		nvrs = append(nvrs, &n)
-	}
 	return nvrs, total, nil
 }
 
 func (m NVRModel) ListAllNVRs(ctx context.Context) ([]*NVR, error) {
 	// For background jobs only. No RLS.
-	query := `SELECT id, tenant_id, site_id, name, vendor, ip_address::text, port, is_enabled, status, last_status_at FROM nvrs WHERE deleted_at IS NULL`
+	query := `SELECT id, tenant_id, site_id, name, vendor, ip_address::text, port, is_enabled, status, last_status_at, health_check_interval_seconds, event_poll_interval_ms, health_probe_mode, vendor_confidence FROM nvrs WHERE deleted_at IS NULL`
 	rows, err := m.DB.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -134,7 +108,7 @@ func (m NVRModel) ListAllNVRs(ctx context.Context) ([]*NVR, error) {
 	for rows.Next() {
 		var n NVR
 		var lastStatus sql.NullTime
-		if err := rows.Scan(&n.ID, &n.TenantID, &n.SiteID, &n.Name, &n.Vendor, &n.IPAddress, &n.Port, &n.IsEnabled, &n.Status, &lastStatus); err != nil {
+		if err := rows.Scan(&n.ID, &n.TenantID, &n.SiteID, &n.Name, &n.Vendor, &n.IPAddress, &n.Port, &n.IsEnabled, &n.Status, &lastStatus, &n.HealthCheckIntervalSeconds, &n.EventPollIntervalMs, &n.HealthProbeMode, &n.VendorConfidence); err != nil {
 			return nil, err
 		}
 		if lastStatus.Valid {
@@ -148,12 +122,13 @@ func (m NVRModel) ListAllNVRs(ctx context.Context) ([]*NVR, error) {
 func (m NVRModel) Update(ctx context.Context, nvr *NVR) error {
 	query := `
 		UPDATE nvrs
-		SET name = $1, vendor = $2, ip_address = $3, port = $4, is_enabled = $5, status = $6, last_status_at = $7, updated_at = NOW()
-		WHERE id = $8 AND tenant_id = $9 AND deleted_at IS NULL
+		SET name = $1, vendor = $2, ip_address = $3, port = $4, is_enabled = $5, status = $6, last_status_at = $7,
+		    health_check_interval_seconds = $8, event_poll_interval_ms = $9, health_probe_mode = $10, vendor_confidence = $11, updated_at = NOW()
+		WHERE id = $12 AND tenant_id = $13 AND deleted_at IS NULL
 		RETURNING updated_at`
 
 	err := m.DB.QueryRowContext(ctx, query,
-		nvr.Name, nvr.Vendor, nvr.IPAddress, nvr.Port, nvr.IsEnabled, nvr.Status, nvr.LastStatusAt, nvr.ID, nvr.TenantID,
+		nvr.Name, nvr.Vendor, nvr.IPAddress, nvr.Port, nvr.IsEnabled, nvr.Status, nvr.LastStatusAt, nvr.HealthCheckIntervalSeconds, nvr.EventPollIntervalMs, nvr.HealthProbeMode, nvr.VendorConfidence, nvr.ID, nvr.TenantID,
 	).Scan(&nvr.UpdatedAt)
 
 	if err == sql.ErrNoRows {
@@ -177,40 +152,81 @@ func (m NVRModel) Delete(ctx context.Context, id uuid.UUID) error {
 
 // --- Linking ---
 
-func (m NVRModel) UpsertLink(ctx context.Context, link *NVRLink) error {
-	// Deterministic Move:
-	// 1. Delete filtering by tenant+camera (prevents cross-tenant hijacking if RLS fails, but RLS should handle it).
-	// 2. Insert new.
+// UpsertLink links a camera to an NVR channel, replacing any existing link for
+// the camera in place (1:1 per camera). created reports whether a new row was
+// inserted. A channel already linked to a different camera returns
+// ErrChannelLinked.
+func (m NVRModel) UpsertLink(ctx context.Context, link *NVRLink) (bool, error) {
 	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer tx.Rollback()
 
-	// 1. Delete existing link for this camera (enforce 1:1)
-	delQuery := `DELETE FROM camera_nvr_links WHERE camera_id = $1 AND tenant_id = $2`
-	_, err = tx.ExecContext(ctx, delQuery, link.CameraID, link.TenantID)
-	if err != nil {
-		return err
+	if link.NVRChannelRef != nil {
+		var other uuid.UUID
+		err := tx.QueryRowContext(ctx, `
+			SELECT camera_id FROM camera_nvr_links
+			WHERE tenant_id = $1 AND nvr_id = $2 AND nvr_channel_ref = $3 AND camera_id <> $4
+			FOR UPDATE`,
+			link.TenantID, link.NVRID, *link.NVRChannelRef, link.CameraID,
+		).Scan(&other)
+		if err == nil {
+			return false, ErrChannelLinked
+		}
+		if err != sql.ErrNoRows {
+			return false, err
+		}
 	}
 
-	// 2. Insert new
-	insQuery := `
+	// ON CONFLICT keeps the row (and its id) when the camera is relinked, so two
+	// concurrent writers serialize on the camera's row instead of racing a
+	// delete+insert. xmax = 0 only for freshly inserted rows.
+	query := `
 		INSERT INTO camera_nvr_links (tenant_id, camera_id, nvr_id, nvr_channel_ref, recording_mode, is_enabled)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at`
+		ON CONFLICT (tenant_id, camera_id) DO UPDATE SET
+			nvr_id = EXCLUDED.nvr_id,
+			nvr_channel_ref = EXCLUDED.nvr_channel_ref,
+			recording_mode = EXCLUDED.recording_mode,
+			is_enabled = EXCLUDED.is_enabled,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at, (xmax = 0)`
 
-	err = tx.QueryRowContext(ctx, insQuery,
+	var created bool
+	err = tx.QueryRowContext(ctx, query,
 		link.TenantID, link.CameraID, link.NVRID, link.NVRChannelRef, link.RecordingMode, link.IsEnabled,
-	).Scan(&link.ID, &link.CreatedAt, &link.UpdatedAt)
-
+	).Scan(&link.ID, &link.CreatedAt, &link.UpdatedAt, &created)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		// camera_nvr_links_channel_key: lost a race for the same channel
+		return false, ErrChannelLinked
+	}
 	if err != nil {
 		// Possibly FK Violation (NVR not found or not in Tenant)
-		// Or Unique constraint if race condition.
-		return err
+		return false, err
 	}
 
-	return tx.Commit()
+	return created, tx.Commit()
+}
+
+func (m NVRModel) GetDefaultRecordingMode(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	var mode string
+	err := m.DB.QueryRowContext(ctx, `SELECT default_recording_mode FROM tenants WHERE id = $1`, tenantID).Scan(&mode)
+	if err == sql.ErrNoRows {
+		return "", ErrRecordNotFound
+	}
+	return mode, err
+}
+
+func (m NVRModel) SetDefaultRecordingMode(ctx context.Context, tenantID uuid.UUID, mode string) error {
+	res, err := m.DB.ExecContext(ctx, `UPDATE tenants SET default_recording_mode = $1 WHERE id = $2`, mode, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
 }
 
 func (m NVRModel) GetLinkByCameraID(ctx context.Context, cameraID uuid.UUID) (*NVRLink, error) {
@@ -265,6 +281,45 @@ func (m NVRModel) ListLinks(ctx context.Context, nvrID uuid.UUID, limit, offset
 	return links, nil
 }
 
+var linkOrderBy = map[string]string{
+	"":                  "l.created_at DESC, l.id",
+	LinkOrderCreated:    "l.created_at DESC, l.id",
+	LinkOrderCameraName: "c.name ASC, l.id",
+	LinkOrderChannelRef: "l.nvr_channel_ref ASC NULLS LAST, l.id",
+}
+
+func (m NVRModel) ListLinksWithCameras(ctx context.Context, nvrID uuid.UUID, order string, limit, offset int) ([]*NVRLinkWithCamera, int, error) {
+	orderBy, ok := linkOrderBy[order]
+	if !ok {
+		return nil, 0, ErrInvalidLinkOrder
+	}
+	q := newListQuery("camera_nvr_links l JOIN cameras c ON c.id = l.camera_id").
+		where("l.nvr_id = ?", nvrID).
+		where("c.deleted_at IS NULL", nil)
+
+	var links []*NVRLinkWithCamera
+	total, err := q.page(ctx, m.DB,
+		"l.id, l.tenant_id, l.camera_id, l.nvr_id, l.nvr_channel_ref, l.recording_mode, l.is_enabled, l.created_at, l.updated_at, c.name, COALESCE(host(c.ip_address), ''), c.is_enabled",
+		orderBy, limit, offset,
+		func(rows *sql.Rows) error {
+			var l NVRLinkWithCamera
+			var ref sql.NullString
+			if err := rows.Scan(&l.ID, &l.TenantID, &l.CameraID, &l.NVRID, &ref, &l.RecordingMode, &l.IsEnabled, &l.CreatedAt, &l.UpdatedAt,
+				&l.Camera.Name, &l.Camera.IPAddress, &l.Camera.IsEnabled); err != nil {
+				return err
+			}
+			if ref.Valid {
+				l.NVRChannelRef = &ref.String
+			}
+			links = append(links, &l)
+			return nil
+		})
+	if err != nil {
+		return nil, 0, err
+	}
+	return links, total, nil
+}
+
 func (m NVRModel) UnlinkCamera(ctx context.Context, cameraID uuid.UUID) error {
 	query := `DELETE FROM camera_nvr_links WHERE camera_id = $1`
 	res, err := m.DB.ExecContext(ctx, query, cameraID)
@@ -283,9 +338,12 @@ func (m NVRModel) UnlinkCamera(ctx context.Context, cameraID uuid.UUID) error {
 // --- Credentials ---
 
 func (m NVRModel) UpsertCredential(ctx context.Context, cred *NVRCredential) error {
+	if cred.AADVersion == 0 {
+		cred.AADVersion = 1
+	}
 	query := `
-		INSERT INTO nvr_credentials (tenant_id, nvr_id, master_kid, dek_nonce, dek_ciphertext, dek_tag, data_nonce, data_ciphertext, data_tag)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO nvr_credentials (tenant_id, nvr_id, master_kid, dek_nonce, dek_ciphertext, dek_tag, data_nonce, data_ciphertext, data_tag, aad_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (tenant_id, nvr_id) DO UPDATE SET
 			master_kid = EXCLUDED.master_kid,
 			dek_nonce = EXCLUDED.dek_nonce,
@@ -294,20 +352,42 @@ func (m NVRModel) UpsertCredential(ctx context.Context, cred *NVRCredential) err
 			data_nonce = EXCLUDED.data_nonce,
 			data_ciphertext = EXCLUDED.data_ciphertext,
 			data_tag = EXCLUDED.data_tag,
+			aad_version = EXCLUDED.aad_version,
 			updated_at = NOW()
 		RETURNING id, created_at, updated_at`
 
 	err := m.DB.QueryRowContext(ctx, query,
 		cred.TenantID, cred.NVRID, cred.MasterKID,
 		cred.DekNonce, cred.DekCiphertext, cred.DekTag,
-		cred.DataNonce, cred.DataCiphertext, cred.DataTag,
+		cred.DataNonce, cred.DataCiphertext, cred.DataTag, cred.AADVersion,
 	).Scan(&cred.ID, &cred.CreatedAt, &cred.UpdatedAt)
 	return err
 }
 
+func (m NVRModel) RebindCredential(ctx context.Context, cred *NVRCredential, fromVersion int) error {
+	query := `
+		UPDATE nvr_credentials SET
+			master_kid = $3, dek_nonce = $4, dek_ciphertext = $5, dek_tag = $6,
+			data_nonce = $7, data_ciphertext = $8, data_tag = $9,
+			aad_version = $10, updated_at = NOW()
+		WHERE tenant_id = $1 AND nvr_id = $2 AND aad_version = $11
+		RETURNING updated_at`
+
+	err := m.DB.QueryRowContext(ctx, query,
+		cred.TenantID, cred.NVRID, cred.MasterKID,
+		cred.DekNonce, cred.DekCiphertext, cred.DekTag,
+		cred.DataNonce, cred.DataCiphertext, cred.DataTag,
+		cred.AADVersion, fromVersion,
+	).Scan(&cred.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrOptimisticLock
+	}
+	return err
+}
+
 func (m NVRModel) GetCredential(ctx context.Context, nvrID uuid.UUID) (*NVRCredential, error) {
 	query := `
-		SELECT id, tenant_id, nvr_id, master_kid, dek_nonce, dek_ciphertext, dek_tag, data_nonce, data_ciphertext, data_tag, created_at, updated_at
+		SELECT id, tenant_id, nvr_id, master_kid, dek_nonce, dek_ciphertext, dek_tag, data_nonce, data_ciphertext, data_tag, aad_version, created_at, updated_at
 		FROM nvr_credentials
 		WHERE nvr_id = $1`
 
@@ -315,7 +395,7 @@ func (m NVRModel) GetCredential(ctx context.Context, nvrID uuid.UUID) (*NVRCrede
 	err := m.DB.QueryRowContext(ctx, query, nvrID).Scan(
 		&c.ID, &c.TenantID, &c.NVRID, &c.MasterKID,
 		&c.DekNonce, &c.DekCiphertext, &c.DekTag,
-		&c.DataNonce, &c.DataCiphertext, &c.DataTag,
+		&c.DataNonce, &c.DataCiphertext, &c.DataTag, &c.AADVersion,
 		&c.CreatedAt, &c.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -330,6 +410,32 @@ func (m NVRModel) DeleteCredential(ctx context.Context, nvrID uuid.UUID) error {
 	return err
 }
 
+func (m NVRModel) ListCredentialKeys(ctx context.Context, tenantID uuid.UUID) ([]*NVRCredentialKey, error) {
+	query := `
+		SELECT n.id, n.tenant_id, n.name, c.id IS NOT NULL, COALESCE(c.aad_version, 1),
+		       COALESCE(c.master_kid, ''), c.dek_nonce, c.dek_ciphertext, c.dek_tag
+		FROM nvrs n
+		LEFT JOIN nvr_credentials c ON c.nvr_id = n.id AND c.tenant_id = n.tenant_id
+		WHERE n.tenant_id = $1 AND n.deleted_at IS NULL
+		ORDER BY n.name`
+	rows, err := m.DB.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*NVRCredentialKey
+	for rows.Next() {
+		var k NVRCredentialKey
+		if err := rows.Scan(&k.NVRID, &k.TenantID, &k.Name, &k.HasCredential, &k.AADVersion,
+			&k.MasterKID, &k.DekNonce, &k.DekCiphertext, &k.DekTag); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &k)
+	}
+	return keys, rows.Err()
+}
+
 // --- Phase 2.8 Discovery ---
 
 func (m NVRModel) UpsertChannel(ctx context.Context, ch *NVRChannel) error {
@@ -373,86 +479,57 @@ func (m NVRModel) UpsertChannel(ctx context.Context, ch *NVRChannel) error {
 }
 
 func (m NVRModel) ListChannels(ctx context.Context, nvrID uuid.UUID, filter NVRChannelFilter, limit, offset int) ([]*NVRChannel, int, error) {
-	query := `
-		SELECT 
-			id, tenant_id, site_id, nvr_id, channel_ref, name, 
-			is_enabled, supports_substream, rtsp_main_url_sanitized, rtsp_sub_url_sanitized,
-			discovered_at, last_synced_at, validation_status, last_validation_at, last_error_code,
-			provision_state, metadata
-		FROM nvr_channels
-		WHERE nvr_id = $1`
-
-	args := []interface{}{nvrID}
-	nextArg := 2
+	q := newListQuery("nvr_channels").where("nvr_id = ?", nvrID)
 
 	if filter.IsEnabled != nil {
-		query += fmt.Sprintf(" AND is_enabled = $%d", nextArg)
-		args = append(args, *filter.IsEnabled)
-		nextArg++
+		q.where("is_enabled = ?", *filter.IsEnabled)
 	}
 	if filter.ProvisionState != nil {
-		query += fmt.Sprintf(" AND provision_state = $%d", nextArg)
-		args = append(args, *filter.ProvisionState)
-		nextArg++
+		q.where("provision_state = ?", *filter.ProvisionState)
 	}
 	if filter.Validation != nil {
-		query += fmt.Sprintf(" AND validation_status = $%d", nextArg)
-		args = append(args, *filter.Validation)
-		nextArg++
+		q.where("validation_status = ?", *filter.Validation)
 	}
 	if filter.Query != "" {
-		query += fmt.Sprintf(" AND (name ILIKE '%%' || $%d || '%%' OR channel_ref ILIKE '%%' || $%d || '%%')", nextArg, nextArg)
-		args = append(args, filter.Query)
-		nextArg++
-	}
-
-	// Count
-	var total int
-	// Crude count replace for speed
-	countQuery := "SELECT COUNT(*) FROM nvr_channels WHERE nvr_id = $1" // Simplified base, fix filter app
-	// Actually, let's reuse query builder logic properly or wrapper.
-	// For simplicity in this edit:
-	countQuery = "SELECT COUNT(*) FROM (" + query + ") as c"
-	if err := m.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, err
+		q.where(`(name ILIKE '%' || ? || '%' ESCAPE '\' OR channel_ref ILIKE '%' || ? || '%' ESCAPE '\')`, likeEscaper.Replace(filter.Query))
 	}
 
-	query += fmt.Sprintf(" ORDER BY channel_ref ASC LIMIT $%d OFFSET $%d", nextArg, nextArg+1)
-	args = append(args, limit, offset)
-
-	rows, err := m.DB.QueryContext(ctx, query, args...)
+	var channels []*NVRChannel
+	total, err := q.page(ctx, m.DB, `
+			id, tenant_id, site_id, nvr_id, channel_ref, name,
+			is_enabled, supports_substream, rtsp_main_url_sanitized, rtsp_sub_url_sanitized,
+			discovered_at, last_synced_at, validation_status, last_validation_at, last_error_code,
+			provision_state, metadata`,
+		"channel_ref ASC", limit, offset,
+		func(rows *sql.Rows) error {
+			ch := &NVRChannel{}
+			var metaJSON []byte
+			var lastVal sql.NullTime
+			var subStream sql.NullBool
+			err := rows.Scan(
+				&ch.ID, &ch.TenantID, &ch.SiteID, &ch.NVRID, &ch.ChannelRef, &ch.Name,
+				&ch.IsEnabled, &subStream, &ch.RTSPMain, &ch.RTSPSub,
+				&ch.DiscoveredAt, &ch.LastSyncedAt, &ch.ValidationStatus, &lastVal, &ch.LastErrorCode,
+				&ch.ProvisionState, &metaJSON,
+			)
+			if err != nil {
+				return err
+			}
+			if len(metaJSON) > 0 {
+				json.Unmarshal(metaJSON, &ch.Metadata)
+			}
+			if lastVal.Valid {
+				ch.LastValidationAt = &lastVal.Time
+			}
+			if subStream.Valid {
+				ch.SupportsSubstream = &subStream.Bool
+			}
+			channels = append(channels, ch)
+			return nil
+		})
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	var channels []*NVRChannel
-	for rows.Next() {
-		ch := &NVRChannel{}
-		var metaJSON []byte
-		var lastVal sql.NullTime
-		var subStream sql.NullBool
-		err := rows.Scan(
-			&ch.ID, &ch.TenantID, &ch.SiteID, &ch.NVRID, &ch.ChannelRef, &ch.Name,
-			&ch.IsEnabled, &subStream, &ch.RTSPMain, &ch.RTSPSub,
-			&ch.DiscoveredAt, &ch.LastSyncedAt, &ch.ValidationStatus, &lastVal, &ch.LastErrorCode,
-			&ch.ProvisionState, &metaJSON,
-		)
-		if err != nil {
-			return nil, 0, err
-		}
-		if len(metaJSON) > 0 {
-			json.Unmarshal(metaJSON, &ch.Metadata)
-		}
-		if lastVal.Valid {
-			ch.LastValidationAt = &lastVal.Time
-		}
-		if subStream.Valid {
-			ch.SupportsSubstream = &subStream.Bool
-		}
-		channels = append(channels, ch)
-	}
-
 	return channels, total, nil
 }
 
@@ -536,12 +613,65 @@ func (m NVRModel) UpdateChannelProvisionState(ctx context.Context, id uuid.UUID,
 	return err
 }
 
+// ReleaseOrphanedChannels resets 'created' channels back to 'not_created' when
+// no live (non-deleted) camera is linked to them, so they can be re-provisioned.
+// Returns the number of channels released per tenant.
+func (m NVRModel) ReleaseOrphanedChannels(ctx context.Context) (map[uuid.UUID]int, error) {
+	query := `
+		UPDATE nvr_channels ch SET provision_state = 'not_created'
+		WHERE ch.provision_state = 'created'
+		AND NOT EXISTS (
+			SELECT 1 FROM camera_nvr_links l
+			JOIN cameras c ON c.id = l.camera_id
+			WHERE l.tenant_id = ch.tenant_id
+			AND l.nvr_id = ch.nvr_id
+			AND l.nvr_channel_ref = ch.channel_ref
+			AND c.deleted_at IS NULL
+		)
+		RETURNING ch.tenant_id`
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	released := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var tenantID uuid.UUID
+		if err := rows.Scan(&tenantID); err != nil {
+			return nil, err
+		}
+		released[tenantID]++
+	}
+	return released, rows.Err()
+}
+
 func (m NVRModel) BulkEnableChannels(ctx context.Context, ids []uuid.UUID, enable bool) error {
 	query := `UPDATE nvr_channels SET is_enabled = $1 WHERE id = ANY($2)`
 	_, err := m.DB.ExecContext(ctx, query, enable, pq.Array(ids))
 	return err
 }
 
+func (m NVRModel) BulkRenameChannels(ctx context.Context, nvrID uuid.UUID, names map[uuid.UUID]string) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE nvr_channels SET name = $1 WHERE id = $2 AND nvr_id = $3`
+	for id, name := range names {
+		res, err := tx.ExecContext(ctx, query, name, id, nvrID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrRecordNotFound
+		}
+	}
+	return tx.Commit()
+}
+
 // --- Phase 2.9 Health ---
 
 func (m NVRModel) UpsertNVRHealth(ctx context.Context, h *NVRHealth) error {
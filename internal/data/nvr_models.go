@@ -8,18 +8,28 @@ import (
 )
 
 type NVR struct {
-	ID           uuid.UUID  `json:"id"`
-	TenantID     uuid.UUID  `json:"tenant_id"`
-	SiteID       uuid.UUID  `json:"site_id"`
-	Name         string     `json:"name"`
-	Vendor       string     `json:"vendor"`
-	IPAddress    string     `json:"ip_address"` // Stored as INET in DB, string here
-	Port         int        `json:"port"`
-	IsEnabled    bool       `json:"is_enabled"`
-	Status       string     `json:"status"` // unknown, online, offline, auth_failed, error
-	LastStatusAt *time.Time `json:"last_status_at,omitempty"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID       uuid.UUID `json:"id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	SiteID   uuid.UUID `json:"site_id"`
+	Name     string    `json:"name"`
+	Vendor   string    `json:"vendor"`
+	// VendorConfidence is how sure auto-detection was of Vendor (0-1); nil
+	// when the vendor was chosen by the operator.
+	VendorConfidence *float64   `json:"vendor_confidence,omitempty"`
+	IPAddress        string     `json:"ip_address"` // Stored as INET in DB, string here
+	Port             int        `json:"port"`
+	IsEnabled        bool       `json:"is_enabled"`
+	Status           string     `json:"status"` // unknown, online, offline, auth_failed, error
+	LastStatusAt     *time.Time `json:"last_status_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	HealthCheckIntervalSeconds int `json:"health_check_interval_seconds"`
+	// EventPollIntervalMs overrides the global event poll interval; nil uses it.
+	EventPollIntervalMs *int `json:"event_poll_interval_ms"`
+	// HealthProbeMode is how the monitor checks channel liveness: "rtsp"
+	// (OPTIONS handshake) or "snapshot" (fetch a still image).
+	HealthProbeMode string `json:"health_probe_mode"`
 }
 
 type NVREventPollState struct {
@@ -39,12 +49,31 @@ type NVRLink struct {
 	CameraID      uuid.UUID `json:"camera_id"`
 	NVRID         uuid.UUID `json:"nvr_id"`
 	NVRChannelRef *string   `json:"nvr_channel_ref,omitempty"`
-	RecordingMode string    `json:"recording_mode"` // vms, nvr
+	RecordingMode string    `json:"recording_mode"` // vms, nvr, hybrid
 	IsEnabled     bool      `json:"is_enabled"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
 
+// NVRLinkWithCamera is a link plus the linked camera's display details.
+type NVRLinkWithCamera struct {
+	NVRLink
+	Camera LinkedCamera `json:"camera"`
+}
+
+type LinkedCamera struct {
+	Name      string `json:"name"`
+	IPAddress string `json:"ip_address"`
+	IsEnabled bool   `json:"is_enabled"`
+}
+
+// Orderings accepted by ListLinksWithCameras; "" means LinkOrderCreated.
+const (
+	LinkOrderCreated    = "created_at"  // Newest first
+	LinkOrderCameraName = "camera_name" // A-Z
+	LinkOrderChannelRef = "channel_ref" // Unlinked-channel links last
+)
+
 type NVRCredential struct {
 	ID             uuid.UUID `json:"id"`
 	TenantID       uuid.UUID `json:"tenant_id"`
@@ -56,10 +85,25 @@ type NVRCredential struct {
 	DataNonce      []byte    `json:"-"`
 	DataCiphertext []byte    `json:"-"`
 	DataTag        []byte    `json:"-"`
+	AADVersion     int       `json:"aad_version"` // AAD format the DEK and payload are bound to; 0 reads as 1
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// NVRCredentialKey is an NVR with its wrapped DEK, if it has credentials.
+// It carries no encrypted payload, only what is needed to test the unwrap.
+type NVRCredentialKey struct {
+	NVRID         uuid.UUID
+	TenantID      uuid.UUID
+	Name          string
+	HasCredential bool
+	AADVersion    int
+	MasterKID     string
+	DekNonce      []byte
+	DekCiphertext []byte
+	DekTag        []byte
+}
+
 type NVRChannel struct {
 	ID                uuid.UUID      `json:"id"`
 	TenantID          uuid.UUID      `json:"tenant_id"`
@@ -112,18 +156,33 @@ type NVRRepository interface {
 	GetChannelByRef(ctx context.Context, nvrID uuid.UUID, ref string) (*NVRChannel, error)
 	UpdateChannelStatus(ctx context.Context, id uuid.UUID, validationStatus string, errCode *string) error
 	UpdateChannelProvisionState(ctx context.Context, id uuid.UUID, state string) error
+	// System Helper: frees channels whose provisioned camera is gone
+	ReleaseOrphanedChannels(ctx context.Context) (map[uuid.UUID]int, error)
 	BulkEnableChannels(ctx context.Context, ids []uuid.UUID, enable bool) error
+	// BulkRenameChannels is all-or-nothing: every id must be a channel of nvrID
+	BulkRenameChannels(ctx context.Context, nvrID uuid.UUID, names map[uuid.UUID]string) error
 
 	// Linking
-	UpsertLink(ctx context.Context, link *NVRLink) error
+	UpsertLink(ctx context.Context, link *NVRLink) (created bool, err error)
 	GetLinkByCameraID(ctx context.Context, cameraID uuid.UUID) (*NVRLink, error)
 	ListLinks(ctx context.Context, nvrID uuid.UUID, limit, offset int) ([]*NVRLink, error)
+	// ListLinksWithCameras joins each link's camera, skipping soft-deleted
+	// cameras; order is one of the LinkOrder* values (ErrInvalidLinkOrder otherwise).
+	ListLinksWithCameras(ctx context.Context, nvrID uuid.UUID, order string, limit, offset int) ([]*NVRLinkWithCamera, int, error)
 	UnlinkCamera(ctx context.Context, cameraID uuid.UUID) error
+	// GetDefaultRecordingMode is the tenant's mode for provisioned links
+	GetDefaultRecordingMode(ctx context.Context, tenantID uuid.UUID) (string, error)
+	SetDefaultRecordingMode(ctx context.Context, tenantID uuid.UUID, mode string) error
 
 	// Credentials
 	UpsertCredential(ctx context.Context, cred *NVRCredential) error
 	GetCredential(ctx context.Context, nvrID uuid.UUID) (*NVRCredential, error)
 	DeleteCredential(ctx context.Context, nvrID uuid.UUID) error
+	// RebindCredential replaces the encrypted credential only while it is
+	// still at fromVersion; otherwise ErrOptimisticLock.
+	RebindCredential(ctx context.Context, cred *NVRCredential, fromVersion int) error
+	// ListCredentialKeys returns every tenant NVR with its wrapped DEK (if any)
+	ListCredentialKeys(ctx context.Context, tenantID uuid.UUID) ([]*NVRCredentialKey, error)
 
 	// Health (Phase 2.9)
 	UpsertNVRHealth(ctx context.Context, h *NVRHealth) error
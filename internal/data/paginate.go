@@ -0,0 +1,77 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// listQuery builds the count and page queries of a paginated listing from one
+// filter spec, so both always apply the same WHERE clause and arguments.
+type listQuery struct {
+	from  string
+	conds []string
+	args  []any
+}
+
+func newListQuery(from string) *listQuery {
+	return &listQuery{from: from}
+}
+
+// likeEscaper escapes LIKE/ILIKE wildcards so user input matches literally;
+// conditions using it must say ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// where adds a condition. Every "?" in cond is bound to arg; a condition
+// without "?" takes no argument (arg is ignored).
+func (q *listQuery) where(cond string, arg any) *listQuery {
+	if strings.Contains(cond, "?") {
+		q.args = append(q.args, arg)
+		cond = strings.ReplaceAll(cond, "?", fmt.Sprintf("$%d", len(q.args)))
+	}
+	q.conds = append(q.conds, cond)
+	return q
+}
+
+func (q *listQuery) whereClause() string {
+	if len(q.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conds, " AND ")
+}
+
+func (q *listQuery) countSQL() (string, []any) {
+	return "SELECT count(*) FROM " + q.from + q.whereClause(), q.args
+}
+
+func (q *listQuery) selectSQL(columns, orderBy string, limit, offset int) (string, []any) {
+	n := len(q.args)
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT $%d OFFSET $%d",
+		columns, q.from, q.whereClause(), orderBy, n+1, n+2)
+	args := append(append(make([]any, 0, n+2), q.args...), limit, offset)
+	return query, args
+}
+
+// page runs the count, then the select, calling scan for each row. It returns
+// the total number of matching rows, ignoring limit and offset.
+func (q *listQuery) page(ctx context.Context, db DBTX, columns, orderBy string, limit, offset int, scan func(*sql.Rows) error) (int, error) {
+	countQuery, countArgs := q.countSQL()
+	var total int
+	if err := db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return 0, err
+	}
+
+	query, args := q.selectSQL(columns, orderBy, limit, offset)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return 0, err
+		}
+	}
+	return total, rows.Err()
+}
@@ -0,0 +1,230 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// pageMock records the SQL it is given so tests can compare the WHERE clause
+// of the count query with that of the page query.
+func pageMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock, *[]string) {
+	t.Helper()
+	var seen []string
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(func(_, actual string) error {
+		seen = append(seen, actual)
+		return nil
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, mock, &seen
+}
+
+func whereOf(t *testing.T, query string) string {
+	t.Helper()
+	i := strings.Index(query, " WHERE ")
+	if i < 0 {
+		t.Fatalf("No WHERE clause in %q", query)
+	}
+	where := query[i:]
+	if j := strings.Index(where, " ORDER BY "); j >= 0 {
+		where = where[:j]
+	}
+	return where
+}
+
+// assertCountMatchesPage checks the count and page queries share one filter
+// and that the total agrees with the rows returned.
+func assertCountMatchesPage(t *testing.T, mock sqlmock.Sqlmock, seen []string, total, rows int) {
+	t.Helper()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("Expected count + select, got %d queries", len(seen))
+	}
+	if !strings.HasPrefix(seen[0], "SELECT count(*) FROM ") {
+		t.Fatalf("First query must be the count, got %q", seen[0])
+	}
+	if cw, sw := whereOf(t, seen[0]), whereOf(t, seen[1]); cw != sw {
+		t.Errorf("Count and select filters differ:\n count: %s\nselect: %s", cw, sw)
+	}
+	if total != rows {
+		t.Errorf("Total %d disagrees with %d returned rows", total, rows)
+	}
+}
+
+func withPage(args []driver.Value, limit, offset int) []driver.Value {
+	return append(append([]driver.Value{}, args...), limit, offset)
+}
+
+func TestListQuery_PlaceholdersNumberedInOrder(t *testing.T) {
+	q := newListQuery("t").
+		where("a = ?", 1).
+		where("b IS NULL", nil).
+		where("(c ILIKE ? OR d ILIKE ?)", "x")
+
+	count, countArgs := q.countSQL()
+	if want := "SELECT count(*) FROM t WHERE a = $1 AND b IS NULL AND (c ILIKE $2 OR d ILIKE $2)"; count != want {
+		t.Errorf("Expected %q, got %q", want, count)
+	}
+	sel, selArgs := q.selectSQL("id", "id ASC", 10, 20)
+	if want := "SELECT id FROM t WHERE a = $1 AND b IS NULL AND (c ILIKE $2 OR d ILIKE $2) ORDER BY id ASC LIMIT $3 OFFSET $4"; sel != want {
+		t.Errorf("Expected %q, got %q", want, sel)
+	}
+	if len(countArgs) != 2 || len(selArgs) != 4 {
+		t.Fatalf("Expected 2 count args and 4 select args, got %v and %v", countArgs, selArgs)
+	}
+	if len(q.args) != 2 {
+		t.Error("selectSQL must not grow the shared filter args")
+	}
+}
+
+func TestCameraList_CountMatchesRows(t *testing.T) {
+	tenantID, siteID, userID := uuid.New(), uuid.New(), uuid.New()
+	enabled := true
+	cases := []struct {
+		name   string
+		filter CameraFilter
+		args   []driver.Value
+	}{
+		{"no filter", CameraFilter{}, []driver.Value{tenantID}},
+		{"site", CameraFilter{SiteID: &siteID}, []driver.Value{tenantID, siteID}},
+		{"enabled and query", CameraFilter{IsEnabled: &enabled, Query: "lobby"}, []driver.Value{tenantID, true, "lobby", "lobby"}},
+		{"query wildcards escaped", CameraFilter{Query: `50%_off\`}, []driver.Value{tenantID, `50%_off\`, `50\%\_off\\`}},
+		{"all", CameraFilter{SiteID: &siteID, IsEnabled: &enabled, Query: "lobby", FavoritesOf: &userID}, []driver.Value{tenantID, siteID, true, "lobby", "lobby", userID}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, seen := pageMock(t)
+			mock.ExpectQuery("count").WithArgs(tc.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+			rows := sqlmock.NewRows([]string{"id", "tenant_id", "site_id", "name", "ip_address", "port", "is_enabled", "tags", "created_at", "updated_at"})
+			for i := 0; i < 2; i++ {
+				rows.AddRow(uuid.New(), tenantID, siteID, "Lobby", "10.0.0.1", 80, true, pq.StringArray{"a"}, time.Now(), time.Now())
+			}
+			mock.ExpectQuery("select").WithArgs(withPage(tc.args, 50, 0)...).WillReturnRows(rows)
+
+			cams, total, err := CameraModel{DB: db}.List(context.Background(), tenantID, tc.filter, 50, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertCountMatchesPage(t, mock, *seen, total, len(cams))
+			if !cams[0].IPAddress.Equal(net.ParseIP("10.0.0.1")) {
+				t.Error("Rows must still be scanned")
+			}
+		})
+	}
+}
+
+func TestNVRList_CountMatchesRows(t *testing.T) {
+	tenantID, siteID := uuid.New(), uuid.New()
+	vendor, status := "hikvision", "online"
+	cases := []struct {
+		name   string
+		filter NVRFilter
+		args   []driver.Value
+	}{
+		{"no filter", NVRFilter{}, []driver.Value{tenantID}},
+		{"vendor and status", NVRFilter{Vendor: &vendor, Status: &status}, []driver.Value{tenantID, vendor, status}},
+		{"site and query", NVRFilter{SiteID: &siteID, Query: "10.0"}, []driver.Value{tenantID, siteID, "10.0"}},
+		{"query wildcards escaped", NVRFilter{Query: "nvr_%"}, []driver.Value{tenantID, `nvr\_\%`}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, seen := pageMock(t)
+			mock.ExpectQuery("count").WithArgs(tc.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery("select").WithArgs(withPage(tc.args, 10, 5)...).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "site_id", "name", "vendor", "ip_address", "port", "is_enabled", "status", "last_status_at", "created_at", "updated_at", "health_check_interval_seconds", "event_poll_interval_ms", "health_probe_mode", "vendor_confidence"}).
+					AddRow(uuid.New(), tenantID, siteID, "NVR", vendor, "10.0.0.2", 80, true, status, nil, time.Now(), time.Now(), 60, nil, "rtsp", nil))
+
+			nvrs, total, err := NVRModel{DB: db}.List(context.Background(), tenantID, tc.filter, 10, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertCountMatchesPage(t, mock, *seen, total, len(nvrs))
+		})
+	}
+}
+
+func TestNVRListLinksWithCameras_CountMatchesRows(t *testing.T) {
+	nvrID, tenantID := uuid.New(), uuid.New()
+	db, mock, seen := pageMock(t)
+	mock.ExpectQuery("count").WithArgs(nvrID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("select").WithArgs(nvrID, 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "camera_id", "nvr_id", "nvr_channel_ref", "recording_mode", "is_enabled", "created_at", "updated_at", "name", "host", "is_enabled"}).
+			AddRow(uuid.New(), tenantID, uuid.New(), nvrID, "101", "nvr", true, time.Now(), time.Now(), "Lobby", "10.0.0.9", false))
+
+	links, total, err := NVRModel{DB: db}.ListLinksWithCameras(context.Background(), nvrID, LinkOrderCameraName, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCountMatchesPage(t, mock, *seen, total, len(links))
+	if !strings.Contains(whereOf(t, (*seen)[1]), "c.deleted_at IS NULL") {
+		t.Errorf("Links to deleted cameras must be filtered out: %s", (*seen)[1])
+	}
+	if !strings.Contains((*seen)[1], "ORDER BY c.name ASC") {
+		t.Errorf("Expected camera name ordering: %s", (*seen)[1])
+	}
+	if c := links[0].Camera; c.Name != "Lobby" || c.IPAddress != "10.0.0.9" || c.IsEnabled {
+		t.Errorf("Camera details not scanned: %+v", c)
+	}
+
+	if _, _, err := (NVRModel{DB: db}).ListLinksWithCameras(context.Background(), nvrID, "random()", 10, 0); err != ErrInvalidLinkOrder {
+		t.Errorf("Expected ErrInvalidLinkOrder, got %v", err)
+	}
+}
+
+func TestNVRListChannels_CountMatchesRows(t *testing.T) {
+	nvrID := uuid.New()
+	enabled := false
+	state, validation := "pending", "ok"
+	cases := []struct {
+		name   string
+		filter NVRChannelFilter
+		args   []driver.Value
+	}{
+		{"no filter", NVRChannelFilter{}, []driver.Value{nvrID}},
+		{"enabled and state", NVRChannelFilter{IsEnabled: &enabled, ProvisionState: &state}, []driver.Value{nvrID, false, state}},
+		{"validation and query", NVRChannelFilter{Validation: &validation, Query: "ch1"}, []driver.Value{nvrID, validation, "ch1"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, seen := pageMock(t)
+			mock.ExpectQuery("count").WithArgs(tc.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			rows := sqlmock.NewRows([]string{"id", "tenant_id", "site_id", "nvr_id", "channel_ref", "name",
+				"is_enabled", "supports_substream", "rtsp_main_url_sanitized", "rtsp_sub_url_sanitized",
+				"discovered_at", "last_synced_at", "validation_status", "last_validation_at", "last_error_code",
+				"provision_state", "metadata"})
+			for i := 0; i < 3; i++ {
+				rows.AddRow(uuid.New(), uuid.New(), uuid.New(), nvrID, "ch1", "Channel 1",
+					true, nil, "rtsp://main", "rtsp://sub",
+					time.Now(), time.Now(), validation, nil, "",
+					state, []byte(`{"ip":"10.0.0.3"}`))
+			}
+			mock.ExpectQuery("select").WithArgs(withPage(tc.args, 100, 0)...).WillReturnRows(rows)
+
+			channels, total, err := NVRModel{DB: db}.ListChannels(context.Background(), nvrID, tc.filter, 100, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertCountMatchesPage(t, mock, *seen, total, len(channels))
+			if strings.Contains((*seen)[0], "FROM (") {
+				t.Error("Channel count must not wrap the select in a subquery")
+			}
+		})
+	}
+}
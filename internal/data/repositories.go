@@ -12,7 +12,10 @@ import (
 )
 
 var (
-	ErrRecordNotFound = errors.New("record not found")
+	ErrRecordNotFound     = errors.New("record not found")
+	ErrDuplicateGroupName = errors.New("camera group name already exists")
+	ErrChannelLinked      = errors.New("nvr channel already linked to another camera")
+	ErrInvalidLinkOrder   = errors.New("invalid link order")
 )
 
 type Token struct {
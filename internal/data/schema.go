@@ -0,0 +1,63 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// RequiredSchemaVersion is the lowest migration version this build can run
+// against. Bump it together with every new file in db/migrations.
+const RequiredSchemaVersion uint = 41
+
+var (
+	ErrSchemaMissing = errors.New("schema_migrations not found: migrations have not been applied")
+	ErrSchemaDirty   = errors.New("schema is dirty: a previous migration failed part-way")
+	ErrSchemaBehind  = errors.New("schema version is behind the required minimum")
+)
+
+// SchemaStatus is the state recorded by golang-migrate in schema_migrations.
+type SchemaStatus struct {
+	Present bool
+	Version uint
+	Dirty   bool
+}
+
+// ReadSchemaStatus reads the current migration version. A missing table or
+// empty table is reported as Present=false rather than an error.
+func ReadSchemaStatus(ctx context.Context, db DBTX) (SchemaStatus, error) {
+	var st SchemaStatus
+	var version int64
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &st.Dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return st, nil
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "42P01" { // undefined_table
+			return st, nil
+		}
+		return st, err
+	}
+	st.Present = true
+	st.Version = uint(version)
+	return st, nil
+}
+
+// Check reports whether the schema is usable by a build that needs the given
+// minimum version. Dirty takes precedence over the version comparison, since a
+// dirty version number cannot be trusted.
+func (s SchemaStatus) Check(required uint) error {
+	if !s.Present {
+		return ErrSchemaMissing
+	}
+	if s.Dirty {
+		return fmt.Errorf("%w (version %d)", ErrSchemaDirty, s.Version)
+	}
+	if s.Version < required {
+		return fmt.Errorf("%w: at %d, need %d", ErrSchemaBehind, s.Version, required)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSchemaStatusCheck(t *testing.T) {
+	cases := []struct {
+		name   string
+		status SchemaStatus
+		want   error
+	}{
+		{"up to date", SchemaStatus{Present: true, Version: 24}, nil},
+		{"ahead", SchemaStatus{Present: true, Version: 30}, nil},
+		{"behind", SchemaStatus{Present: true, Version: 20}, ErrSchemaBehind},
+		{"dirty", SchemaStatus{Present: true, Version: 24, Dirty: true}, ErrSchemaDirty},
+		{"dirty and behind", SchemaStatus{Present: true, Version: 3, Dirty: true}, ErrSchemaDirty},
+		{"never migrated", SchemaStatus{}, ErrSchemaMissing},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.status.Check(24)
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
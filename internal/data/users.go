@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -166,6 +167,66 @@ func (m UserModel) List(ctx context.Context, tenantID uuid.UUID, limit, offset i
 	return users, nil
 }
 
+// UserFilter narrows ListFiltered. Zero values apply no filter.
+type UserFilter struct {
+	Email      string // case-insensitive substring
+	IsDisabled *bool
+	RoleID     *uuid.UUID // users holding this role at any scope
+}
+
+// ListFiltered retrieves a page of non-deleted tenant users plus the total
+// number matching the filter.
+func (m UserModel) ListFiltered(ctx context.Context, tenantID uuid.UUID, filter UserFilter, limit, offset int) ([]*User, int, error) {
+	where := "WHERE u.tenant_id = $1 AND u.deleted_at IS NULL"
+	args := []any{tenantID}
+	nextArg := 2
+
+	if filter.Email != "" {
+		where += fmt.Sprintf(` AND u.email ILIKE '%%' || $%d || '%%' ESCAPE '\'`, nextArg)
+		args = append(args, likeEscaper.Replace(filter.Email))
+		nextArg++
+	}
+	if filter.IsDisabled != nil {
+		where += fmt.Sprintf(" AND u.is_disabled = $%d", nextArg)
+		args = append(args, *filter.IsDisabled)
+		nextArg++
+	}
+	if filter.RoleID != nil {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = u.id AND ur.role_id = $%d)", nextArg)
+		args = append(args, *filter.RoleID)
+		nextArg++
+	}
+
+	var total int
+	if err := m.DB.QueryRowContext(ctx, "SELECT count(*) FROM users u "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT u.id, u.tenant_id, u.email, u.display_name, u.is_disabled, u.created_at, u.updated_at
+		FROM users u
+		%s
+		ORDER BY u.created_at DESC
+		LIMIT $%d OFFSET $%d`, where, nextArg, nextArg+1)
+	args = append(args, limit, offset)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.TenantID, &u.Email, &u.DisplayName, &u.IsDisabled, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, &u)
+	}
+	return users, total, rows.Err()
+}
+
 // --- Password Reset Tokens ---
 
 func (m UserModel) CreateResetToken(ctx context.Context, t *PasswordResetToken) error {
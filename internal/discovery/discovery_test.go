@@ -2,11 +2,21 @@ package discovery
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/metrics"
 )
 
 func TestParseProbeMatch(t *testing.T) {
@@ -67,16 +77,42 @@ func TestIPv4Extraction(t *testing.T) {
 type MockRepo struct {
 	Runs map[string]*data.DiscoveryRun
 	Devs map[string]*data.DiscoveredDevice
+
+	mu sync.Mutex // guards Runs against the background scan
 }
 
 func (m *MockRepo) CreateRun(ctx context.Context, r *data.DiscoveryRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	r.ID = uuid.New()
+	r.StartedAt = time.Now()
 	m.Runs[r.ID.String()] = r
 	return nil
 }
-func (m *MockRepo) UpdateRunStatus(ctx context.Context, id uuid.UUID, s string, f bool, d, e int) error {
+func (m *MockRepo) CountRunningRuns(ctx context.Context, tenantID uuid.UUID, startedAfter time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, r := range m.Runs {
+		if r.TenantID == tenantID && r.Status == "running" && r.StartedAt.After(startedAfter) {
+			n++
+		}
+	}
+	return n, nil
+}
+func (m *MockRepo) runStatus(id uuid.UUID) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Runs[id.String()].Status
+}
+func (m *MockRepo) UpdateRunStatus(ctx context.Context, id uuid.UUID, s string, f bool, d, e int, truncated bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if r, ok := m.Runs[id.String()]; ok {
 		r.Status = s
+		r.DeviceCount = d
+		r.ErrorCount = e
+		r.Truncated = truncated
 	}
 	return nil
 }
@@ -124,7 +160,8 @@ func TestStartDiscovery(t *testing.T) {
 
 	// Test Async Start
 	uid := uuid.New()
-	id, err := svc.StartDiscovery(context.Background(), uid, nil)
+	svc.newScanner = func() (DeviceScanner, error) { return &fakeScanner{}, nil }
+	id, err := svc.StartDiscovery(context.Background(), uid, nil, RunOptions{})
 	if err != nil {
 		t.Fatalf("StartDiscovery failed: %v", err)
 	}
@@ -143,3 +180,351 @@ func TestStartDiscovery(t *testing.T) {
 		}
 	}
 }
+
+func probeMatchXML(i int) []byte {
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:wsa="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery">
+   <soap:Body>
+      <d:ProbeMatches>
+         <d:ProbeMatch>
+            <wsa:EndpointReference><wsa:Address>urn:uuid:dev-%d</wsa:Address></wsa:EndpointReference>
+            <d:XAddrs>http://10.0.%d.%d/onvif/device_service</d:XAddrs>
+         </d:ProbeMatch>
+      </d:ProbeMatches>
+   </soap:Body>
+</soap:Envelope>`, i, i/250, i%250+1))
+}
+
+func TestCollectProbeMatches_StopsAtCap(t *testing.T) {
+	// Simulates a flood: the source never runs dry on its own.
+	reads := 0
+	next := func() ([]byte, error) {
+		reads++
+		return probeMatchXML(reads), nil
+	}
+
+	devs, truncated := collectProbeMatches(next, 10)
+	if len(devs) != 10 {
+		t.Fatalf("expected 10 devices, got %d", len(devs))
+	}
+	if !truncated {
+		t.Error("expected truncated flag when cap reached")
+	}
+	if reads != 11 {
+		t.Errorf("expected scan to stop at the first device past the cap, got %d reads", reads)
+	}
+}
+
+func TestCollectProbeMatches_ExactlyAtCap(t *testing.T) {
+	reads := 0
+	next := func() ([]byte, error) {
+		reads++
+		if reads > 10 {
+			return nil, errors.New("i/o timeout")
+		}
+		return probeMatchXML(reads), nil
+	}
+
+	devs, truncated := collectProbeMatches(next, 10)
+	if len(devs) != 10 {
+		t.Fatalf("expected 10 devices, got %d", len(devs))
+	}
+	if truncated {
+		t.Error("filling the cap exactly is not a truncation")
+	}
+}
+
+func TestCollectProbeMatches_UnderCap(t *testing.T) {
+	reads := 0
+	next := func() ([]byte, error) {
+		reads++
+		if reads > 3 {
+			return nil, errors.New("i/o timeout")
+		}
+		return probeMatchXML(reads), nil
+	}
+
+	devs, truncated := collectProbeMatches(next, 10)
+	if len(devs) != 3 {
+		t.Fatalf("expected 3 devices, got %d", len(devs))
+	}
+	if truncated {
+		t.Error("did not expect truncated flag below cap")
+	}
+}
+
+func TestCollectProbeMatches_DuplicatesDoNotCount(t *testing.T) {
+	reads := 0
+	next := func() ([]byte, error) {
+		reads++
+		if reads > 20 {
+			return nil, errors.New("i/o timeout")
+		}
+		return probeMatchXML(reads % 2), nil
+	}
+
+	devs, truncated := collectProbeMatches(next, 5)
+	if len(devs) != 2 {
+		t.Fatalf("expected 2 unique devices, got %d", len(devs))
+	}
+	if truncated {
+		t.Error("duplicates must not trip the cap")
+	}
+}
+
+type fakeScanner struct {
+	devices []DiscoveredDevice
+	gotMax  int
+}
+
+func (f *fakeScanner) Scan(ctx context.Context, d time.Duration, maxDevices int) ([]DiscoveredDevice, bool, error) {
+	f.gotMax = maxDevices
+	devs := f.devices
+	truncated := false
+	if len(devs) > maxDevices {
+		devs = devs[:maxDevices]
+		truncated = true
+	}
+	return devs, truncated, nil
+}
+func (f *fakeScanner) Close() {}
+
+func TestRunScan_RecordsTruncation(t *testing.T) {
+	repo := &MockRepo{Runs: make(map[string]*data.DiscoveryRun), Devs: make(map[string]*data.DiscoveredDevice)}
+	svc := NewService(repo, nil, &MockAuditor{})
+
+	scanner := &fakeScanner{}
+	for i := 0; i < 8; i++ {
+		scanner.devices = append(scanner.devices, DiscoveredDevice{IPAddress: fmt.Sprintf("10.0.0.%d", i+1)})
+	}
+	svc.newScanner = func() (DeviceScanner, error) { return scanner, nil }
+
+	run := &data.DiscoveryRun{Status: "running"}
+	repo.CreateRun(context.Background(), run)
+
+	svc.runScan(context.Background(), run.ID, uuid.New(), RunOptions{MaxDevices: 5}.effectiveMaxDevices())
+
+	if scanner.gotMax != 5 {
+		t.Errorf("expected scanner cap 5, got %d", scanner.gotMax)
+	}
+	if !run.Truncated {
+		t.Error("expected run to be flagged truncated")
+	}
+	if run.DeviceCount != 5 {
+		t.Errorf("expected 5 persisted devices, got %d", run.DeviceCount)
+	}
+	if len(repo.Devs) != 5 {
+		t.Errorf("expected 5 devices in repo, got %d", len(repo.Devs))
+	}
+}
+
+func TestRunOptions_EffectiveMaxDevices(t *testing.T) {
+	cases := map[int]int{0: MaxDevicesPerRun, -1: MaxDevicesPerRun, 10: 10, MaxDevicesPerRun + 1: MaxDevicesPerRun}
+	for in, want := range cases {
+		if got := (RunOptions{MaxDevices: in}).effectiveMaxDevices(); got != want {
+			t.Errorf("effectiveMaxDevices(%d) = %d; want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseImagingSettings(t *testing.T) {
+	resp := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+	<s:Body>
+		<timg:GetImagingSettingsResponse>
+			<timg:ImagingSettings>
+				<tt:Brightness>55</tt:Brightness>
+				<tt:ColorSaturation>48.5</tt:ColorSaturation>
+				<tt:Contrast>60</tt:Contrast>
+				<tt:Focus>
+					<tt:AutoFocusMode>MANUAL</tt:AutoFocusMode>
+				</tt:Focus>
+			</timg:ImagingSettings>
+		</timg:GetImagingSettingsResponse>
+	</s:Body>
+</s:Envelope>`)
+
+	s, err := ParseImagingSettings(resp)
+	if err != nil {
+		t.Fatalf("ParseImagingSettings failed: %v", err)
+	}
+	if s.Brightness == nil || *s.Brightness != 55 {
+		t.Errorf("Brightness = %v; want 55", s.Brightness)
+	}
+	if s.ColorSaturation == nil || *s.ColorSaturation != 48.5 {
+		t.Errorf("ColorSaturation = %v; want 48.5", s.ColorSaturation)
+	}
+	if s.Contrast == nil || *s.Contrast != 60 {
+		t.Errorf("Contrast = %v; want 60", s.Contrast)
+	}
+	if s.Sharpness != nil {
+		t.Errorf("Sharpness should be unreported, got %v", *s.Sharpness)
+	}
+	if s.FocusMode != "MANUAL" {
+		t.Errorf("FocusMode = %q; want MANUAL", s.FocusMode)
+	}
+}
+
+func TestParsePullMessages(t *testing.T) {
+	resp := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:tev="http://www.onvif.org/ver10/events/wsdl" xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2" xmlns:tt="http://www.onvif.org/ver10/schema">
+	<s:Body>
+		<tev:PullMessagesResponse>
+			<tev:CurrentTime>2026-01-02T03:04:06Z</tev:CurrentTime>
+			<wsnt:NotificationMessage>
+				<wsnt:Topic Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">tns1:RuleEngine/CellMotionDetector/Motion</wsnt:Topic>
+				<wsnt:Message>
+					<tt:Message UtcTime="2026-01-02T03:04:05Z" PropertyOperation="Changed">
+						<tt:Source>
+							<tt:SimpleItem Name="VideoSourceConfigurationToken" Value="VideoSourceToken"/>
+							<tt:SimpleItem Name="Rule" Value="MyMotionDetectorRule"/>
+						</tt:Source>
+						<tt:Data>
+							<tt:SimpleItem Name="IsMotion" Value="true"/>
+						</tt:Data>
+					</tt:Message>
+				</wsnt:Message>
+			</wsnt:NotificationMessage>
+		</tev:PullMessagesResponse>
+	</s:Body>
+</s:Envelope>`)
+
+	msgs, err := ParsePullMessages(resp)
+	if err != nil {
+		t.Fatalf("ParsePullMessages failed: %v", err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(msgs))
+	}
+	m := msgs[0]
+	if m.Topic != "RuleEngine/CellMotionDetector/Motion" {
+		t.Errorf("Topic = %q; want prefixes stripped", m.Topic)
+	}
+	if !m.UtcTime.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("UtcTime = %v", m.UtcTime)
+	}
+	if m.PropertyOperation != "Changed" {
+		t.Errorf("PropertyOperation = %q; want Changed", m.PropertyOperation)
+	}
+	if m.Source["VideoSourceConfigurationToken"] != "VideoSourceToken" || m.Source["Rule"] != "MyMotionDetectorRule" {
+		t.Errorf("Source = %v", m.Source)
+	}
+	if m.Data["IsMotion"] != "true" {
+		t.Errorf("Data = %v", m.Data)
+	}
+}
+
+// blockingScanner holds the scan open until release is closed.
+type blockingScanner struct{ release chan struct{} }
+
+func (b *blockingScanner) Scan(ctx context.Context, d time.Duration, maxDevices int) ([]DiscoveredDevice, bool, error) {
+	<-b.release
+	return nil, false, nil
+}
+func (b *blockingScanner) Close() {}
+
+func TestStartDiscovery_ConcurrentRunCap(t *testing.T) {
+	repo := &MockRepo{Runs: make(map[string]*data.DiscoveryRun), Devs: make(map[string]*data.DiscoveredDevice)}
+	svc := NewService(repo, nil, &MockAuditor{})
+	scanner := &blockingScanner{release: make(chan struct{})}
+	svc.newScanner = func() (DeviceScanner, error) { return scanner, nil }
+
+	tenantID := uuid.New()
+	first, err := svc.StartDiscovery(context.Background(), tenantID, nil, RunOptions{})
+	if err != nil {
+		t.Fatalf("First run failed: %v", err)
+	}
+
+	if _, err := svc.StartDiscovery(context.Background(), tenantID, nil, RunOptions{}); !errors.Is(err, ErrDiscoveryInProgress) {
+		t.Fatalf("Expected ErrDiscoveryInProgress while first run is running, got %v", err)
+	}
+
+	// Another tenant is not affected.
+	if _, err := svc.StartDiscovery(context.Background(), uuid.New(), nil, RunOptions{}); err != nil {
+		t.Errorf("Other tenant should not be blocked: %v", err)
+	}
+
+	close(scanner.release)
+	deadline := time.Now().Add(2 * time.Second)
+	for repo.runStatus(first) == "running" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st := repo.runStatus(first); st != "completed" {
+		t.Fatalf("First run did not complete, status %s", st)
+	}
+
+	if _, err := svc.StartDiscovery(context.Background(), tenantID, nil, RunOptions{}); err != nil {
+		t.Errorf("Run should be allowed after the first completes: %v", err)
+	}
+}
+
+func soapSampleCount(t *testing.T, op string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.OnvifSOAPDuration.WithLabelValues(op).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestOnvifDo_RecordsLatency(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<Envelope/>"))
+	}))
+	defer srv.Close()
+
+	client, _ := NewOnvifClient(srv.URL, "admin", "pw")
+	before := soapSampleCount(t, "GetProfiles")
+	errsBefore := testutil.ToFloat64(metrics.OnvifSOAPErrorsTotal.WithLabelValues("GetProfiles", "200"))
+
+	if _, err := client.Do(context.Background(), `<trt:GetProfiles xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if got := soapSampleCount(t, "GetProfiles"); got != before+1 {
+		t.Errorf("onvif_soap_duration_seconds{operation=GetProfiles} count = %d; want %d", got, before+1)
+	}
+	if got := testutil.ToFloat64(metrics.OnvifSOAPErrorsTotal.WithLabelValues("GetProfiles", "200")); got != errsBefore {
+		t.Error("A successful call must not count as an error")
+	}
+}
+
+func TestOnvifDo_FaultCountsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("<Fault>ter:NotAuthorized</Fault>"))
+	}))
+	defer srv.Close()
+
+	client, _ := NewOnvifClient(srv.URL, "admin", "wrong")
+	errsBefore := testutil.ToFloat64(metrics.OnvifSOAPErrorsTotal.WithLabelValues("GetStreamUri", "400"))
+	otherBefore := testutil.ToFloat64(metrics.OnvifSOAPErrorsTotal.WithLabelValues("GetProfiles", "400"))
+	before := soapSampleCount(t, "GetStreamUri")
+
+	body := `<trt:GetStreamUri xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:ProfileToken>p1</trt:ProfileToken></trt:GetStreamUri>`
+	if _, err := client.Do(context.Background(), body); err == nil {
+		t.Fatal("Expected a fault error")
+	}
+	if got := testutil.ToFloat64(metrics.OnvifSOAPErrorsTotal.WithLabelValues("GetStreamUri", "400")); got != errsBefore+1 {
+		t.Errorf("onvif_soap_errors_total{operation=GetStreamUri,code=400} = %v; want %v", got, errsBefore+1)
+	}
+	if got := testutil.ToFloat64(metrics.OnvifSOAPErrorsTotal.WithLabelValues("GetProfiles", "400")); got != otherBefore {
+		t.Error("Fault was attributed to the wrong operation")
+	}
+	if got := soapSampleCount(t, "GetStreamUri"); got != before+1 {
+		t.Errorf("Failed calls should still record latency, count = %d; want %d", got, before+1)
+	}
+}
+
+func TestSoapOperation(t *testing.T) {
+	cases := map[string]string{
+		`<tds:GetDeviceInformation xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`: "GetDeviceInformation",
+		"\n\t<GetCapabilities><Category>All</Category></GetCapabilities>":                "GetCapabilities",
+		"":        "unknown",
+		"not xml": "unknown",
+	}
+	for body, want := range cases {
+		if got := soapOperation(body); got != want {
+			t.Errorf("soapOperation(%q) = %q; want %q", body, got, want)
+		}
+	}
+}
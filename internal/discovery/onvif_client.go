@@ -6,11 +6,17 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/technosupport/ts-vms/internal/metrics"
 )
 
 // OnvifClient handles SOAP requests
@@ -147,8 +153,11 @@ func (c *OnvifClient) GetCapabilities(ctx context.Context) (map[string]bool, str
 
 // GetProfiles
 type MediaProfile struct {
-	Name                      string `xml:"Name"`
-	Token                     string `xml:"token,attr"`
+	Name                     string `xml:"Name"`
+	Token                    string `xml:"token,attr"`
+	VideoSourceConfiguration struct {
+		SourceToken string `xml:"SourceToken"`
+	}
 	VideoEncoderConfiguration struct {
 		Encoding   string
 		Resolution struct {
@@ -223,6 +232,174 @@ func (c *OnvifClient) GetStreamUri(ctx context.Context, mediaURI, token string)
 	return parsed.Body.GetStreamUriResponse.MediaUri.Uri, nil
 }
 
+// GetSnapshotUri returns the HTTP URI of a JPEG still for the media profile.
+func (c *OnvifClient) GetSnapshotUri(ctx context.Context, mediaURI, token string) (string, error) {
+	mediaClient := c
+	if mediaURI != "" && mediaURI != c.BaseURL {
+		mc, _ := NewOnvifClient(mediaURI, c.Username, c.Password)
+		mc.HTTP = c.HTTP
+		mediaClient = mc
+	}
+
+	reqBody := fmt.Sprintf(`<trt:GetSnapshotUri xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+		<trt:ProfileToken>%s</trt:ProfileToken>
+	</trt:GetSnapshotUri>`, xmlEscape(token))
+
+	resp, err := mediaClient.Do(ctx, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		Body struct {
+			GetSnapshotUriResponse struct {
+				MediaUri struct {
+					Uri string `xml:"Uri"`
+				} `xml:"MediaUri"`
+			} `xml:"GetSnapshotUriResponse"`
+		}
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", err
+	}
+	if parsed.Body.GetSnapshotUriResponse.MediaUri.Uri == "" {
+		return "", errors.New("onvif: empty snapshot uri")
+	}
+	return parsed.Body.GetSnapshotUriResponse.MediaUri.Uri, nil
+}
+
+// ErrImagingNotSupported is returned when the device exposes no imaging service.
+var ErrImagingNotSupported = errors.New("imaging service not supported")
+
+// ImagingSettings is the subset of ONVIF tt:ImagingSettings20 we expose.
+// Nil / empty fields are "not reported" on read and "leave unchanged" on write.
+type ImagingSettings struct {
+	Brightness      *float64 `json:"brightness,omitempty"`
+	Contrast        *float64 `json:"contrast,omitempty"`
+	ColorSaturation *float64 `json:"color_saturation,omitempty"`
+	Sharpness       *float64 `json:"sharpness,omitempty"`
+	FocusMode       string   `json:"focus_mode,omitempty"` // AUTO | MANUAL
+}
+
+// GetImagingXAddr returns the Imaging service address from GetCapabilities.
+func (c *OnvifClient) GetImagingXAddr(ctx context.Context) (string, error) {
+	reqBody := `<tds:GetCapabilities xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+		<tds:Category>Imaging</tds:Category>
+	</tds:GetCapabilities>`
+
+	resp, err := c.Do(ctx, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	var caps struct {
+		Body struct {
+			GetCapabilitiesResponse struct {
+				Capabilities struct {
+					Imaging struct {
+						XAddr string `xml:"XAddr"`
+					} `xml:"Imaging"`
+				} `xml:"Capabilities"`
+			} `xml:"GetCapabilitiesResponse"`
+		}
+	}
+	if err := xml.Unmarshal(resp, &caps); err != nil {
+		return "", err
+	}
+	if caps.Body.GetCapabilitiesResponse.Capabilities.Imaging.XAddr == "" {
+		return "", ErrImagingNotSupported
+	}
+	return caps.Body.GetCapabilitiesResponse.Capabilities.Imaging.XAddr, nil
+}
+
+// GetImagingSettings reads imaging settings for a video source token.
+func (c *OnvifClient) GetImagingSettings(ctx context.Context, imagingURI, sourceToken string) (*ImagingSettings, error) {
+	imgClient := c
+	if imagingURI != "" && imagingURI != c.BaseURL {
+		ic, _ := NewOnvifClient(imagingURI, c.Username, c.Password)
+		imgClient = ic
+	}
+
+	reqBody := fmt.Sprintf(`<timg:GetImagingSettings xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl">
+		<timg:VideoSourceToken>%s</timg:VideoSourceToken>
+	</timg:GetImagingSettings>`, xmlEscape(sourceToken))
+
+	resp, err := imgClient.Do(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	return ParseImagingSettings(resp)
+}
+
+// ParseImagingSettings parses a GetImagingSettingsResponse envelope.
+func ParseImagingSettings(resp []byte) (*ImagingSettings, error) {
+	var parsed struct {
+		Body struct {
+			GetImagingSettingsResponse struct {
+				ImagingSettings struct {
+					Brightness      *float64 `xml:"Brightness"`
+					Contrast        *float64 `xml:"Contrast"`
+					ColorSaturation *float64 `xml:"ColorSaturation"`
+					Sharpness       *float64 `xml:"Sharpness"`
+					Focus           struct {
+						AutoFocusMode string `xml:"AutoFocusMode"`
+					} `xml:"Focus"`
+				} `xml:"ImagingSettings"`
+			} `xml:"GetImagingSettingsResponse"`
+		}
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, err
+	}
+
+	is := parsed.Body.GetImagingSettingsResponse.ImagingSettings
+	return &ImagingSettings{
+		Brightness:      is.Brightness,
+		Contrast:        is.Contrast,
+		ColorSaturation: is.ColorSaturation,
+		Sharpness:       is.Sharpness,
+		FocusMode:       is.Focus.AutoFocusMode,
+	}, nil
+}
+
+// SetImagingSettings writes the non-nil fields of settings for a video source token.
+func (c *OnvifClient) SetImagingSettings(ctx context.Context, imagingURI, sourceToken string, settings *ImagingSettings) error {
+	imgClient := c
+	if imagingURI != "" && imagingURI != c.BaseURL {
+		ic, _ := NewOnvifClient(imagingURI, c.Username, c.Password)
+		imgClient = ic
+	}
+
+	var inner strings.Builder
+	writeFloat := func(name string, v *float64) {
+		if v != nil {
+			fmt.Fprintf(&inner, "<tt:%s>%s</tt:%s>", name, strconv.FormatFloat(*v, 'f', -1, 64), name)
+		}
+	}
+	writeFloat("Brightness", settings.Brightness)
+	writeFloat("ColorSaturation", settings.ColorSaturation)
+	writeFloat("Contrast", settings.Contrast)
+	if settings.FocusMode != "" {
+		fmt.Fprintf(&inner, "<tt:Focus><tt:AutoFocusMode>%s</tt:AutoFocusMode></tt:Focus>", xmlEscape(settings.FocusMode))
+	}
+	writeFloat("Sharpness", settings.Sharpness)
+
+	reqBody := fmt.Sprintf(`<timg:SetImagingSettings xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+		<timg:VideoSourceToken>%s</timg:VideoSourceToken>
+		<timg:ImagingSettings>%s</timg:ImagingSettings>
+		<timg:ForcePersistence>true</timg:ForcePersistence>
+	</timg:SetImagingSettings>`, xmlEscape(sourceToken), inner.String())
+
+	_, err := imgClient.Do(ctx, reqBody)
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
 // Do executes the SOAP request with Auth
 func (c *OnvifClient) Do(ctx context.Context, bodyInner string) ([]byte, error) {
 	envelope := `<?xml version="1.0" encoding="UTF-8"?>
@@ -240,13 +417,21 @@ func (c *OnvifClient) Do(ctx context.Context, bodyInner string) ([]byte, error)
 	}
 	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8; action=\"\"")
 
+	op := soapOperation(bodyInner)
+	start := time.Now()
+	defer func() {
+		metrics.OnvifSOAPDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	}()
+
 	resp, err := c.HTTP.Do(req)
 	if err != nil {
+		metrics.OnvifSOAPErrorsTotal.WithLabelValues(op, transportErrorCode(err)).Inc()
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		metrics.OnvifSOAPErrorsTotal.WithLabelValues(op, strconv.Itoa(resp.StatusCode)).Inc()
 		// Try to read fault
 		errBytes, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("onvif error %d: %s", resp.StatusCode, string(errBytes))
@@ -255,6 +440,30 @@ func (c *OnvifClient) Do(ctx context.Context, bodyInner string) ([]byte, error)
 	return io.ReadAll(resp.Body)
 }
 
+// soapOperation returns the local name of the first element in a SOAP body
+// (e.g. "GetProfiles" for <trt:GetProfiles/>), or "unknown".
+func soapOperation(bodyInner string) string {
+	dec := xml.NewDecoder(strings.NewReader(bodyInner))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "unknown"
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local
+		}
+	}
+}
+
+// transportErrorCode classifies a failed round trip for the error counter.
+func transportErrorCode(err error) string {
+	var ne net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout()) {
+		return "timeout"
+	}
+	return "transport"
+}
+
 func (c *OnvifClient) generateCnonceHeader() string {
 	if c.Username == "" {
 		return ""
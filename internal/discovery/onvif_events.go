@@ -0,0 +1,189 @@
+package discovery
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrEventsNotSupported = errors.New("onvif events service not supported")
+
+// NotificationMessage is one event from a PullPoint subscription. Topic has
+// namespace prefixes stripped (e.g. "RuleEngine/CellMotionDetector/Motion");
+// Source and Data hold the message's SimpleItem name/value pairs.
+type NotificationMessage struct {
+	Topic             string            `json:"topic"`
+	UtcTime           time.Time         `json:"utc_time"`
+	PropertyOperation string            `json:"property_operation,omitempty"` // Initialized | Changed | Deleted
+	Source            map[string]string `json:"source,omitempty"`
+	Data              map[string]string `json:"data,omitempty"`
+}
+
+// GetEventsXAddr returns the Events service address from GetCapabilities.
+func (c *OnvifClient) GetEventsXAddr(ctx context.Context) (string, error) {
+	reqBody := `<tds:GetCapabilities xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+		<tds:Category>Events</tds:Category>
+	</tds:GetCapabilities>`
+
+	resp, err := c.Do(ctx, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	var caps struct {
+		Body struct {
+			GetCapabilitiesResponse struct {
+				Capabilities struct {
+					Events struct {
+						XAddr string `xml:"XAddr"`
+					} `xml:"Events"`
+				} `xml:"Capabilities"`
+			} `xml:"GetCapabilitiesResponse"`
+		}
+	}
+	if err := xml.Unmarshal(resp, &caps); err != nil {
+		return "", err
+	}
+	if caps.Body.GetCapabilitiesResponse.Capabilities.Events.XAddr == "" {
+		return "", ErrEventsNotSupported
+	}
+	return caps.Body.GetCapabilitiesResponse.Capabilities.Events.XAddr, nil
+}
+
+// CreatePullPointSubscription subscribes to all events on the Events service
+// and returns the subscription address to pass to PullMessages. The device
+// drops the subscription after termination unless it is re-created.
+func (c *OnvifClient) CreatePullPointSubscription(ctx context.Context, eventsURI string, termination time.Duration) (string, error) {
+	evClient := c
+	if eventsURI != "" && eventsURI != c.BaseURL {
+		ec, _ := NewOnvifClient(eventsURI, c.Username, c.Password)
+		evClient = ec
+	}
+
+	reqBody := fmt.Sprintf(`<tev:CreatePullPointSubscription xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+		<tev:InitialTerminationTime>%s</tev:InitialTerminationTime>
+	</tev:CreatePullPointSubscription>`, isoDuration(termination))
+
+	resp, err := evClient.Do(ctx, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		Body struct {
+			CreatePullPointSubscriptionResponse struct {
+				SubscriptionReference struct {
+					Address string `xml:"Address"`
+				} `xml:"SubscriptionReference"`
+			} `xml:"CreatePullPointSubscriptionResponse"`
+		}
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return "", err
+	}
+	addr := strings.TrimSpace(parsed.Body.CreatePullPointSubscriptionResponse.SubscriptionReference.Address)
+	if addr == "" {
+		return "", errors.New("onvif: subscription reference missing")
+	}
+	return addr, nil
+}
+
+// PullMessages fetches up to limit queued events from a PullPoint
+// subscription, waiting at most timeout on the device for the first one.
+func (c *OnvifClient) PullMessages(ctx context.Context, subscriptionURI string, timeout time.Duration, limit int) ([]NotificationMessage, error) {
+	subClient := c
+	if subscriptionURI != "" && subscriptionURI != c.BaseURL {
+		sc, _ := NewOnvifClient(subscriptionURI, c.Username, c.Password)
+		subClient = sc
+	}
+
+	reqBody := fmt.Sprintf(`<tev:PullMessages xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+		<tev:Timeout>%s</tev:Timeout>
+		<tev:MessageLimit>%d</tev:MessageLimit>
+	</tev:PullMessages>`, isoDuration(timeout), limit)
+
+	resp, err := subClient.Do(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePullMessages(resp)
+}
+
+type simpleItem struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+// ParsePullMessages parses a PullMessagesResponse envelope.
+func ParsePullMessages(resp []byte) ([]NotificationMessage, error) {
+	var parsed struct {
+		Body struct {
+			PullMessagesResponse struct {
+				NotificationMessage []struct {
+					Topic   string `xml:"Topic"`
+					Message struct {
+						Message struct {
+							UtcTime           string       `xml:"UtcTime,attr"`
+							PropertyOperation string       `xml:"PropertyOperation,attr"`
+							Source            []simpleItem `xml:"Source>SimpleItem"`
+							Data              []simpleItem `xml:"Data>SimpleItem"`
+						} `xml:"Message"`
+					} `xml:"Message"`
+				} `xml:"NotificationMessage"`
+			} `xml:"PullMessagesResponse"`
+		}
+	}
+	if err := xml.Unmarshal(resp, &parsed); err != nil {
+		return nil, err
+	}
+
+	out := make([]NotificationMessage, 0, len(parsed.Body.PullMessagesResponse.NotificationMessage))
+	for _, nm := range parsed.Body.PullMessagesResponse.NotificationMessage {
+		m := nm.Message.Message
+		msg := NotificationMessage{
+			Topic:             stripTopicPrefixes(nm.Topic),
+			PropertyOperation: m.PropertyOperation,
+			Source:            simpleItems(m.Source),
+			Data:              simpleItems(m.Data),
+		}
+		if t, err := time.Parse(time.RFC3339, m.UtcTime); err == nil {
+			msg.UtcTime = t.UTC()
+		}
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+// stripTopicPrefixes turns "tns1:RuleEngine/tnsvendor:Motion" into "RuleEngine/Motion".
+func stripTopicPrefixes(topic string) string {
+	parts := strings.Split(strings.TrimSpace(topic), "/")
+	for i, p := range parts {
+		if idx := strings.LastIndex(p, ":"); idx >= 0 {
+			parts[i] = p[idx+1:]
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func simpleItems(items []simpleItem) map[string]string {
+	if len(items) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(items))
+	for _, it := range items {
+		m[it.Name] = it.Value
+	}
+	return m
+}
+
+// isoDuration formats d as an xs:duration in whole seconds (minimum PT1S).
+func isoDuration(d time.Duration) string {
+	secs := int(d / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return fmt.Sprintf("PT%dS", secs)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,16 +22,27 @@ const (
 	MaxProbeWorkers         = 16
 	ProbeTimeout            = 10 * time.Second
 	OnvifCredentialsPurpose = "onvif_bootstrap_v1" // AAD Purpose
+
+	// DefaultMaxConcurrentRuns is the per-tenant cap on running scans.
+	DefaultMaxConcurrentRuns = 1
+	// staleRunAfter: a run still "running" after this long was orphaned
+	// (e.g. by a restart) and no longer counts against the cap.
+	staleRunAfter = 10 * time.Minute
 )
 
+// ErrDiscoveryInProgress is returned when the tenant already has
+// MaxConcurrentRuns scans running.
+var ErrDiscoveryInProgress = errors.New("ERR_DISCOVERY_IN_PROGRESS")
+
 type Auditor interface {
 	WriteEvent(ctx context.Context, evt audit.AuditEvent) error
 }
 
 type DiscoveryRepository interface {
 	CreateRun(ctx context.Context, run *data.DiscoveryRun) error
-	UpdateRunStatus(ctx context.Context, id uuid.UUID, status string, finished bool, deviceCount, errorCount int) error
+	UpdateRunStatus(ctx context.Context, id uuid.UUID, status string, finished bool, deviceCount, errorCount int, truncated bool) error
 	GetRun(ctx context.Context, id uuid.UUID) (*data.DiscoveryRun, error)
+	CountRunningRuns(ctx context.Context, tenantID uuid.UUID, startedAfter time.Time) (int, error)
 	UpsertDevice(ctx context.Context, d *data.DiscoveredDevice) error
 	UpdateDeviceProbe(ctx context.Context, d *data.DiscoveredDevice) error
 	GetDevice(ctx context.Context, id uuid.UUID) (*data.DiscoveredDevice, error)
@@ -39,30 +51,85 @@ type DiscoveryRepository interface {
 	GetBootstrapCred(ctx context.Context, id uuid.UUID) (*data.OnvifCredential, error)
 }
 
+// DeviceScanner collects WS-Discovery responses. Implemented by WSDiscoveryClient.
+type DeviceScanner interface {
+	Scan(ctx context.Context, duration time.Duration, maxDevices int) ([]DiscoveredDevice, bool, error)
+	Close()
+}
+
+// RunOptions tunes a single discovery run.
+type RunOptions struct {
+	// MaxDevices caps the number of devices collected. 0 uses MaxDevicesPerRun;
+	// values above MaxDevicesPerRun are clamped.
+	MaxDevices int
+}
+
 type Service struct {
 	Repo    DiscoveryRepository
 	Keyring *crypto.Keyring
 	Auditor Auditor
+
+	// MaxConcurrentRuns caps running scans per tenant; <= 0 disables the cap.
+	MaxConcurrentRuns int
+
+	// startMu serializes the running-count check with run creation.
+	startMu    sync.Mutex
+	newScanner func() (DeviceScanner, error)
 }
 
 func NewService(repo DiscoveryRepository, keyring *crypto.Keyring, auditor Auditor) *Service {
-	return &Service{Repo: repo, Keyring: keyring, Auditor: auditor}
+	return &Service{
+		Repo:    repo,
+		Keyring: keyring,
+		Auditor: auditor,
+
+		MaxConcurrentRuns: DefaultMaxConcurrentRuns,
+		newScanner: func() (DeviceScanner, error) {
+			return NewWSDiscoveryClient()
+		},
+	}
+}
+
+// effectiveMaxDevices resolves the per-run cap against the hard limit.
+func (o RunOptions) effectiveMaxDevices() int {
+	if o.MaxDevices <= 0 || o.MaxDevices > MaxDevicesPerRun {
+		return MaxDevicesPerRun
+	}
+	return o.MaxDevices
 }
 
 // StartDiscovery (Async)
-func (s *Service) StartDiscovery(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID) (uuid.UUID, error) {
+func (s *Service) StartDiscovery(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID, opts RunOptions) (uuid.UUID, error) {
+	maxDevices := opts.effectiveMaxDevices()
+
+	s.startMu.Lock()
+	if s.MaxConcurrentRuns > 0 {
+		running, err := s.Repo.CountRunningRuns(ctx, tenantID, time.Now().Add(-staleRunAfter))
+		if err != nil {
+			s.startMu.Unlock()
+			return uuid.Nil, err
+		}
+		if running >= s.MaxConcurrentRuns {
+			s.startMu.Unlock()
+			return uuid.Nil, ErrDiscoveryInProgress
+		}
+	}
+
 	// Create Run
 	run := &data.DiscoveryRun{
-		TenantID: tenantID,
-		SiteID:   siteID,
-		Status:   "running",
+		TenantID:   tenantID,
+		SiteID:     siteID,
+		Status:     "running",
+		MaxDevices: maxDevices,
 	}
-	if err := s.Repo.CreateRun(ctx, run); err != nil {
+	err := s.Repo.CreateRun(ctx, run)
+	s.startMu.Unlock()
+	if err != nil {
 		return uuid.Nil, err
 	}
 
 	// Audit Start
-	meta, _ := json.Marshal(map[string]interface{}{"site_id": siteID})
+	meta, _ := json.Marshal(map[string]interface{}{"site_id": siteID, "max_devices": maxDevices})
 	s.Auditor.WriteEvent(ctx, audit.AuditEvent{
 		EventID:    uuid.New(),
 		Action:     "onvif.discovery.run",
@@ -76,35 +143,36 @@ func (s *Service) StartDiscovery(ctx context.Context, tenantID uuid.UUID, siteID
 	// Launch Background Scan
 	// Note: We need a detached context for background work, but we want to carry TraceID if possible.
 	// For now, simple Background.
-	go s.runScan(context.Background(), run.ID, tenantID)
+	go s.runScan(context.Background(), run.ID, tenantID, maxDevices)
 
 	return run.ID, nil
 }
 
-func (s *Service) runScan(ctx context.Context, runID, tenantID uuid.UUID) {
-	client, err := NewWSDiscoveryClient()
+func (s *Service) runScan(ctx context.Context, runID, tenantID uuid.UUID, maxDevices int) {
+	client, err := s.newScanner()
 	if err != nil {
 		log.Printf("Discovery Init Failed: %v", err)
-		s.Repo.UpdateRunStatus(ctx, runID, "failed", true, 0, 1)
+		s.Repo.UpdateRunStatus(ctx, runID, "failed", true, 0, 1, false)
 		return
 	}
 	defer client.Close()
 
-	results, err := client.Scan(ctx, MaxScanDuration)
+	// Scanner stops reading at the first device past the cap, so a flood of
+	// responses cannot grow the result set beyond maxDevices.
+	results, truncated, err := client.Scan(ctx, MaxScanDuration, maxDevices)
 	if err != nil {
 		log.Printf("Discovery Scan Failed: %v", err)
-		s.Repo.UpdateRunStatus(ctx, runID, "failed", true, 0, 1)
+		s.Repo.UpdateRunStatus(ctx, runID, "failed", true, 0, 1, false)
 		return
 	}
+	if truncated {
+		log.Printf("Discovery run %s truncated at %d devices", runID, maxDevices)
+	}
 
-	// Persist Results (capped)
+	// Persist Results
 	count := 0
 	errCount := 0
-	for i, dev := range results {
-		if i >= MaxDevicesPerRun {
-			break
-		}
-
+	for _, dev := range results {
 		// Map simplified struct to DB struct
 		dbDev := &data.DiscoveredDevice{
 			TenantID:         tenantID,
@@ -125,7 +193,7 @@ func (s *Service) runScan(ctx context.Context, runID, tenantID uuid.UUID) {
 		}
 	}
 
-	s.Repo.UpdateRunStatus(ctx, runID, "completed", true, count, errCount)
+	s.Repo.UpdateRunStatus(ctx, runID, "completed", true, count, errCount, truncated)
 
 	// Audit Complete (Optional, or just check DB)
 }
@@ -3,7 +3,6 @@ package discovery
 import (
 	"context"
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"net"
 	"strings"
@@ -84,8 +83,11 @@ func (c *WSDiscoveryClient) Close() {
 	}
 }
 
-// Scan sends a probe and collects responses for duration
-func (c *WSDiscoveryClient) Scan(ctx context.Context, duration time.Duration) ([]DiscoveredDevice, error) {
+// Scan sends a probe and collects responses for duration.
+// Collection stops early once a device beyond the first maxDevices unique
+// ones responds; the returned flag reports that the results are incomplete.
+// maxDevices <= 0 means no cap.
+func (c *WSDiscoveryClient) Scan(ctx context.Context, duration time.Duration, maxDevices int) ([]DiscoveredDevice, bool, error) {
 	probeUUID := uuid.New().String()
 	probeMsg := buildProbeMessage(probeUUID)
 
@@ -93,59 +95,77 @@ func (c *WSDiscoveryClient) Scan(ctx context.Context, duration time.Duration) ([
 
 	// Send Probe
 	if _, err := c.socket.WriteToUDP([]byte(probeMsg), dstAddr); err != nil {
-		return nil, fmt.Errorf("failed to send probe: %w", err)
+		return nil, false, fmt.Errorf("failed to send probe: %w", err)
 	}
 
-	// Collection Loop
-	devicesMap := make(map[string]DiscoveredDevice)
-
-	// Deadline
-	c.socket.SetReadDeadline(time.Now().Add(duration))
-
 	buf := make([]byte, MaxPacketSize)
-
 	endTime := time.Now().Add(duration)
-	for time.Now().Before(endTime) {
+
+	next := func() ([]byte, error) {
 		// Calculate remaining read time
 		remaining := time.Until(endTime)
 		if remaining <= 0 {
-			break
+			return nil, context.DeadlineExceeded
 		}
 		c.socket.SetReadDeadline(time.Now().Add(remaining))
 
 		n, _, err := c.socket.ReadFromUDP(buf)
 		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "timeout") {
-				break
-			}
-			// Other errors (e.g., closed)
-			// On Windows, ICMP unreachable might cause error, just continue?
-			// Best to log and continue usually, but we stop on fatal.
-			// Timeout is expected exit.
+			// Timeout is the expected exit. Other errors (e.g. closed socket,
+			// ICMP unreachable on Windows) also end the collection.
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	results, truncated := collectProbeMatches(next, maxDevices)
+	return results, truncated, nil
+}
+
+// collectProbeMatches pulls packets from next until it returns an error or a
+// device past the first maxDevices unique ones is seen. That extra device is
+// not kept; it only tells an exact fill of the cap from a truncated scan.
+func collectProbeMatches(next func() ([]byte, error), maxDevices int) ([]DiscoveredDevice, bool) {
+	devicesMap := make(map[string]DiscoveredDevice)
+	order := make([]string, 0)
+	truncated := false
+
+	for {
+		msg, err := next()
+		if err != nil {
 			break
 		}
+		if len(msg) == 0 {
+			continue
+		}
 
-		if n > 0 {
-			msg := buf[:n]
-			dev, ok := parseProbeMatch(msg)
-			if ok {
-				// De-dupe by EndpointRef or IP+XAddrs
-				key := dev.EndpointRef
-				if key == "" && len(dev.XAddrs) > 0 {
-					key = dev.XAddrs[0]
-				}
-				if key != "" {
-					devicesMap[key] = dev
-				}
+		dev, ok := parseProbeMatch(msg)
+		if !ok {
+			continue
+		}
+		// De-dupe by EndpointRef or IP+XAddrs
+		key := dev.EndpointRef
+		if key == "" && len(dev.XAddrs) > 0 {
+			key = dev.XAddrs[0]
+		}
+		if key == "" {
+			continue
+		}
+		if _, seen := devicesMap[key]; !seen {
+			if maxDevices > 0 && len(order) >= maxDevices {
+				truncated = true
+				break
 			}
+			order = append(order, key)
 		}
+		devicesMap[key] = dev
 	}
 
-	results := make([]DiscoveredDevice, 0, len(devicesMap))
-	for _, dev := range devicesMap {
-		results = append(results, dev)
+	results := make([]DiscoveredDevice, 0, len(order))
+	for _, key := range order {
+		results = append(results, devicesMap[key])
 	}
-	return results, nil
+	return results, truncated
 }
 
 func buildProbeMessage(msgID string) string {
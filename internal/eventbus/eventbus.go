@@ -0,0 +1,39 @@
+// Package eventbus decouples event producers and consumers (AI detections)
+// from the transport. NATSBus is the only production transport, since the
+// AI service publishes from its own process; MemoryBus is for tests.
+package eventbus
+
+import "strings"
+
+// Handler receives one message. Handlers must not retain data after returning
+// unless they copy it.
+type Handler func(subject string, data []byte)
+
+type Subscription interface {
+	Unsubscribe() error
+}
+
+type EventBus interface {
+	Publish(subject string, data []byte) error
+	// Subscribe registers h for subjects matching pattern. Patterns follow
+	// NATS rules: "*" matches one token, a trailing ">" one or more.
+	Subscribe(pattern string, h Handler) (Subscription, error)
+}
+
+// MatchSubject reports whether subject matches a NATS-style pattern.
+func MatchSubject(pattern, subject string) bool {
+	pt := strings.Split(pattern, ".")
+	st := strings.Split(subject, ".")
+	for i, p := range pt {
+		if p == ">" {
+			return i == len(pt)-1 && len(st) > i
+		}
+		if i >= len(st) {
+			return false
+		}
+		if p != "*" && p != st[i] {
+			return false
+		}
+	}
+	return len(pt) == len(st)
+}
@@ -0,0 +1,133 @@
+package eventbus
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestMatchSubject(t *testing.T) {
+	cases := []struct {
+		pattern, subject string
+		want             bool
+	}{
+		{"detections.>", "detections.basic.cam1", true},
+		{"detections.>", "detections", false},
+		{"detections.*.cam1", "detections.weapon.cam1", true},
+		{"detections.*", "detections.basic.cam1", false},
+		{"detections.basic.cam1", "detections.basic.cam1", true},
+		{"detections.basic.cam1", "detections.basic.cam2", false},
+		{"nvr.events", "nvr.events.extra", false},
+	}
+	for _, c := range cases {
+		if got := MatchSubject(c.pattern, c.subject); got != c.want {
+			t.Errorf("MatchSubject(%q, %q) = %v, want %v", c.pattern, c.subject, got, c.want)
+		}
+	}
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(time.Second):
+		t.Fatal("message not delivered")
+		return ""
+	}
+}
+
+func TestMemoryBus_DeliversToMatchingSubscribers(t *testing.T) {
+	bus := NewMemoryBus(0)
+	got := make(chan string, 4)
+	sub, _ := bus.Subscribe("detections.>", func(subject string, data []byte) {
+		got <- subject + "=" + string(data)
+	})
+	bus.Subscribe("nvr.>", func(subject string, data []byte) {
+		t.Errorf("unexpected delivery on %s", subject)
+	})
+
+	bus.Publish("detections.basic.cam1", []byte("a"))
+	if m := receive(t, got); m != "detections.basic.cam1=a" {
+		t.Errorf("got %q", m)
+	}
+
+	sub.Unsubscribe()
+	bus.Publish("detections.basic.cam1", []byte("b"))
+	select {
+	case m := <-got:
+		t.Errorf("delivered after unsubscribe: %q", m)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestMemoryBus_DropsWhenSubscriberFull(t *testing.T) {
+	bus := NewMemoryBus(1)
+	block := make(chan struct{})
+	var once sync.Once
+	started := make(chan struct{})
+	bus.Subscribe("x", func(string, []byte) {
+		once.Do(func() { close(started) })
+		<-block
+	})
+
+	bus.Publish("x", nil) // Picked up by the handler, which blocks
+	<-started
+	bus.Publish("x", nil) // Buffered
+	bus.Publish("x", nil) // Dropped
+	close(block)
+
+	if n := bus.Dropped(); n != 1 {
+		t.Errorf("Expected 1 dropped, got %d", n)
+	}
+}
+
+// fakeNATS routes Publish straight to matching subscribers, standing in for
+// a NATS server.
+type fakeNATS struct {
+	mu   sync.Mutex
+	subs map[string][]nats.MsgHandler
+}
+
+func (f *fakeNATS) Publish(subject string, data []byte) error {
+	f.mu.Lock()
+	var handlers []nats.MsgHandler
+	for pattern, hs := range f.subs {
+		if MatchSubject(pattern, subject) {
+			handlers = append(handlers, hs...)
+		}
+	}
+	f.mu.Unlock()
+	for _, h := range handlers {
+		h(&nats.Msg{Subject: subject, Data: data})
+	}
+	return nil
+}
+
+func (f *fakeNATS) Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[string][]nats.MsgHandler)
+	}
+	f.subs[subject] = append(f.subs[subject], cb)
+	return &nats.Subscription{Subject: subject}, nil
+}
+
+func TestNATSBus_PublishSubscribe(t *testing.T) {
+	bus := NewNATSBus(&fakeNATS{})
+	got := make(chan string, 1)
+	if _, err := bus.Subscribe("detections.>", func(subject string, data []byte) {
+		got <- subject + "=" + string(data)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish("detections.weapon.cam9", []byte("w")); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, got); m != "detections.weapon.cam9=w" {
+		t.Errorf("got %q", m)
+	}
+}
@@ -0,0 +1,98 @@
+package eventbus
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMemoryQueueSize is the per-subscription buffer of a MemoryBus.
+const DefaultMemoryQueueSize = 256
+
+// MemoryBus delivers events in-process and is used by tests; no config
+// selects it. Each subscription has a buffered channel drained by its own
+// goroutine; like a NATS slow consumer, messages for a full subscription are
+// dropped rather than blocking the publisher.
+type MemoryBus struct {
+	queueSize int
+	dropped   atomic.Int64
+
+	mu   sync.RWMutex
+	subs map[*memorySub]struct{}
+}
+
+type memoryMsg struct {
+	subject string
+	data    []byte
+}
+
+type memorySub struct {
+	bus     *MemoryBus
+	pattern string
+	ch      chan memoryMsg
+	done    chan struct{}
+	once    sync.Once
+}
+
+func NewMemoryBus(queueSize int) *MemoryBus {
+	if queueSize <= 0 {
+		queueSize = DefaultMemoryQueueSize
+	}
+	return &MemoryBus{queueSize: queueSize, subs: make(map[*memorySub]struct{})}
+}
+
+func (b *MemoryBus) Publish(subject string, data []byte) error {
+	msg := memoryMsg{subject: subject, data: append([]byte(nil), data...)}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if !MatchSubject(s.pattern, subject) {
+			continue
+		}
+		select {
+		case s.ch <- msg:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(pattern string, h Handler) (Subscription, error) {
+	s := &memorySub{
+		bus:     b,
+		pattern: pattern,
+		ch:      make(chan memoryMsg, b.queueSize),
+		done:    make(chan struct{}),
+	}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case m := <-s.ch:
+				h(m.subject, m.data)
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s, nil
+}
+
+// Dropped returns how many messages were discarded for full subscriptions.
+func (b *MemoryBus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+func (s *memorySub) Unsubscribe() error {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		close(s.done)
+	})
+	return nil
+}
@@ -0,0 +1,43 @@
+package eventbus
+
+import "github.com/nats-io/nats.go"
+
+// NATSConn is the subset of *nats.Conn used by NATSBus.
+type NATSConn interface {
+	Publish(subject string, data []byte) error
+	Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error)
+}
+
+// NATSBus carries events over a NATS connection.
+type NATSBus struct {
+	conn NATSConn
+}
+
+func NewNATSBus(conn NATSConn) *NATSBus {
+	return &NATSBus{conn: conn}
+}
+
+func (b *NATSBus) Publish(subject string, data []byte) error {
+	return b.conn.Publish(subject, data)
+}
+
+// IsConnected reports whether the connection is up. While *nats.Conn is
+// reconnecting, Publish buffers and returns nil, so callers with another
+// delivery path should check this first. Connections that cannot report
+// their state are assumed connected.
+func (b *NATSBus) IsConnected() bool {
+	if c, ok := b.conn.(interface{ IsConnected() bool }); ok {
+		return c.IsConnected()
+	}
+	return true
+}
+
+func (b *NATSBus) Subscribe(pattern string, h Handler) (Subscription, error) {
+	sub, err := b.conn.Subscribe(pattern, func(m *nats.Msg) {
+		h(m.Subject, m.Data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
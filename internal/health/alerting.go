@@ -2,6 +2,7 @@ package health
 
 import (
 	"context"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +11,12 @@ import (
 
 type AlertManager struct {
 	repo data.HealthRepository
+
+	// Notifier, when set, is called once per incident when a camera reaches
+	// OfflineThreshold consecutive failed checks; it is called again only
+	// after the camera has come back online. Nil disables notifications.
+	Notifier         Notifier
+	OfflineThreshold OfflineThreshold
 }
 
 func NewAlertManager(repo data.HealthRepository) *AlertManager {
@@ -18,6 +25,10 @@ func NewAlertManager(repo data.HealthRepository) *AlertManager {
 
 // ProcessState evaluates if an alert should be opened or closed
 func (a *AlertManager) ProcessState(ctx context.Context, tenantID, cameraID uuid.UUID, status data.CameraHealthStatus, consecutiveFailures int, lastSuccessAt *time.Time) error {
+	if err := a.notifyOffline(ctx, tenantID, cameraID, status, consecutiveFailures, lastSuccessAt); err != nil {
+		log.Printf("Health: offline notification state for camera %s: %v", cameraID, err)
+	}
+
 	alertType := "offline_over_5m"
 
 	// 1. Check for Open Alert
@@ -47,13 +58,16 @@ func (a *AlertManager) ProcessState(ctx context.Context, tenantID, cameraID uuid
 			}
 
 			if offlineDuration > 5*time.Minute {
-				// Open Alert
+				// Open Alert. A concurrent check that also saw no open
+				// alert lands on the same row (see UpsertAlert).
+				now := time.Now()
 				newAlert := &data.CameraAlert{
-					TenantID:  tenantID,
-					CameraID:  cameraID,
-					Type:      alertType,
-					State:     "open",
-					StartedAt: time.Now(),
+					TenantID:   tenantID,
+					CameraID:   cameraID,
+					Type:       alertType,
+					State:      "open",
+					StartedAt:  now,
+					LastSeenAt: now,
 				}
 				if err := a.repo.UpsertAlert(ctx, newAlert); err != nil {
 					return err
@@ -62,13 +76,73 @@ func (a *AlertManager) ProcessState(ctx context.Context, tenantID, cameraID uuid
 			}
 		}
 	} else {
-		// 3. Logic: Close Alert if Online
-		if status == data.HealthStatusOnline {
+		switch status {
+		case data.HealthStatusOnline:
+			// 3. Logic: Close Alert if Online
 			if err := a.repo.CloseAlert(ctx, activeAlert.ID); err != nil {
 				return err
 			}
 			// Emit Audit/Metric
+		case data.HealthStatusOffline:
+			// Still offline: refresh the open alert rather than opening another.
+			activeAlert.LastSeenAt = time.Now()
+			if err := a.repo.UpsertAlert(ctx, activeAlert); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notifyOffline calls the Notifier when the camera's failed-check streak
+// first reaches the tenant's threshold, and re-arms it once the camera is
+// back online. The incident is an offline_notice alert whose
+// last_notified_at marks it as reported, so restarts don't notify again.
+func (a *AlertManager) notifyOffline(ctx context.Context, tenantID, cameraID uuid.UUID, status data.CameraHealthStatus, consecutiveFailures int, lastSuccessAt *time.Time) error {
+	if a.Notifier == nil {
+		return nil
+	}
+	if status == data.HealthStatusOnline {
+		incident, err := a.repo.GetOpenAlert(ctx, cameraID, data.AlertTypeOfflineNotice)
+		if err != nil || incident == nil {
+			return err
 		}
+		return a.repo.CloseAlert(ctx, incident.ID)
+	}
+	threshold := a.OfflineThreshold.For(tenantID)
+	if threshold <= 0 || consecutiveFailures < threshold {
+		return nil
+	}
+
+	now := time.Now()
+	incident := &data.CameraAlert{
+		TenantID:   tenantID,
+		CameraID:   cameraID,
+		Type:       data.AlertTypeOfflineNotice,
+		State:      "open",
+		StartedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := a.repo.UpsertAlert(ctx, incident); err != nil {
+		return err
+	}
+	claimed, err := a.repo.MarkAlertNotified(ctx, incident.ID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	err = a.Notifier.NotifyOffline(ctx, OfflineNotice{
+		TenantID:            tenantID,
+		CameraID:            cameraID,
+		Status:              string(status),
+		ConsecutiveFailures: consecutiveFailures,
+		LastSuccessAt:       lastSuccessAt,
+		DetectedAt:          now,
+	})
+	if err != nil {
+		log.Printf("Health: offline notification for camera %s failed: %v", cameraID, err)
+		// Not delivered: let the next failed check try again.
+		return a.repo.ClearAlertNotified(ctx, incident.ID)
 	}
 	return nil
 }
@@ -40,14 +40,31 @@ func (m *MockNVRRepo) UpdateChannelProvisionState(ctx context.Context, id uuid.U
 func (m *MockNVRRepo) BulkEnableChannels(ctx context.Context, ids []uuid.UUID, enable bool) error {
 	return nil
 }
+func (m *MockNVRRepo) BulkRenameChannels(ctx context.Context, nvrID uuid.UUID, names map[uuid.UUID]string) error {
+	return nil
+}
 
-func (m *MockNVRRepo) UpsertLink(ctx context.Context, link *data.NVRLink) error { return nil }
+func (m *MockNVRRepo) UpsertLink(ctx context.Context, link *data.NVRLink) (bool, error) {
+	return true, nil
+}
+func (m *MockNVRRepo) GetDefaultRecordingMode(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	return "vms", nil
+}
+func (m *MockNVRRepo) SetDefaultRecordingMode(ctx context.Context, tenantID uuid.UUID, mode string) error {
+	return nil
+}
 func (m *MockNVRRepo) GetLinkByCameraID(ctx context.Context, cameraID uuid.UUID) (*data.NVRLink, error) {
 	return nil, nil
 }
 func (m *MockNVRRepo) ListLinks(ctx context.Context, nvrID uuid.UUID, limit, offset int) ([]*data.NVRLink, error) {
 	return nil, nil
 }
+func (m *MockNVRRepo) ListLinksWithCameras(ctx context.Context, nvrID uuid.UUID, order string, limit, offset int) ([]*data.NVRLinkWithCamera, int, error) {
+	return nil, 0, nil
+}
+func (m *MockNVRRepo) ReleaseOrphanedChannels(ctx context.Context) (map[uuid.UUID]int, error) {
+	return nil, nil
+}
 func (m *MockNVRRepo) UnlinkCamera(ctx context.Context, cameraID uuid.UUID) error { return nil }
 
 func (m *MockNVRRepo) UpsertCredential(ctx context.Context, cred *data.NVRCredential) error {
@@ -57,6 +74,12 @@ func (m *MockNVRRepo) GetCredential(ctx context.Context, nvrID uuid.UUID) (*data
 	return nil, nil
 }
 func (m *MockNVRRepo) DeleteCredential(ctx context.Context, nvrID uuid.UUID) error { return nil }
+func (m *MockNVRRepo) RebindCredential(ctx context.Context, cred *data.NVRCredential, fromVersion int) error {
+	return nil
+}
+func (m *MockNVRRepo) ListCredentialKeys(ctx context.Context, tenantID uuid.UUID) ([]*data.NVRCredentialKey, error) {
+	return nil, nil
+}
 
 func (m *MockNVRRepo) UpsertNVRHealth(ctx context.Context, h *data.NVRHealth) error { return nil }
 func (m *MockNVRRepo) UpsertChannelHealth(ctx context.Context, h *data.NVRChannelHealth) error {
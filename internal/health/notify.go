@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/webhook"
+)
+
+// WebhookCameraOffline is the webhook event type sent by WebhookNotifier.
+const WebhookCameraOffline = "camera.offline"
+
+// ErrNotificationDropped is returned by WebhookNotifier when the webhook
+// queue is full and the notice was not queued.
+var ErrNotificationDropped = errors.New("offline notification dropped: webhook queue full")
+
+// OfflineNotice describes a camera that has just reached its tenant's
+// offline threshold.
+type OfflineNotice struct {
+	TenantID            uuid.UUID  `json:"tenant_id"`
+	CameraID            uuid.UUID  `json:"camera_id"`
+	Status              string     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	DetectedAt          time.Time  `json:"detected_at"`
+}
+
+// Notifier is told once per incident when a camera crosses the offline
+// threshold (see AlertManager.Notifier).
+type Notifier interface {
+	NotifyOffline(ctx context.Context, n OfflineNotice) error
+}
+
+// OfflineThreshold is the number of consecutive failed checks after which
+// a camera is reported offline. Tenants overrides Default per tenant; a
+// value of 0 or less disables notifications.
+type OfflineThreshold struct {
+	Default int
+	Tenants map[uuid.UUID]int
+}
+
+// For returns the threshold for tenantID.
+func (t OfflineThreshold) For(tenantID uuid.UUID) int {
+	if n, ok := t.Tenants[tenantID]; ok {
+		return n
+	}
+	return t.Default
+}
+
+// LogNotifier writes notices to the process log.
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyOffline(ctx context.Context, n OfflineNotice) error {
+	log.Printf("[ALERT] Camera %s (tenant %s) offline: %d consecutive failed checks, status %s",
+		n.CameraID, n.TenantID, n.ConsecutiveFailures, n.Status)
+	return nil
+}
+
+// WebhookSender queues outbound webhook events; *webhook.Dispatcher
+// satisfies it.
+type WebhookSender interface {
+	Enqueue(evt webhook.Event) bool
+}
+
+// WebhookNotifier sends notices as camera.offline webhook events.
+type WebhookNotifier struct {
+	Sender WebhookSender
+}
+
+func (w WebhookNotifier) NotifyOffline(ctx context.Context, n OfflineNotice) error {
+	ok := w.Sender.Enqueue(webhook.Event{
+		Type:       WebhookCameraOffline,
+		TenantID:   n.TenantID,
+		OccurredAt: n.DetectedAt.UTC(),
+		Data:       n,
+	})
+	if !ok {
+		return ErrNotificationDropped
+	}
+	return nil
+}
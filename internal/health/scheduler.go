@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/technosupport/ts-vms/internal/clock"
 	"github.com/technosupport/ts-vms/internal/data"
 )
 
@@ -13,6 +14,7 @@ import (
 type SchedulerConfig struct {
 	Interval       time.Duration
 	WorkerPoolSize int
+	Clock          clock.Clock // Defaults to the real clock
 }
 
 type Scheduler struct {
@@ -29,6 +31,9 @@ func NewScheduler(cfg SchedulerConfig, svc *Service) *Scheduler {
 	if cfg.WorkerPoolSize == 0 {
 		cfg.WorkerPoolSize = 50
 	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.New()
+	}
 	return &Scheduler{
 		config:  cfg,
 		service: svc,
@@ -62,7 +67,7 @@ func (s *Scheduler) run() {
 		go s.worker(jobQueue)
 	}
 
-	ticker := time.NewTicker(s.config.Interval)
+	ticker := s.config.Clock.NewTicker(s.config.Interval)
 	defer ticker.Stop()
 
 	// Initial Run
@@ -70,7 +75,7 @@ func (s *Scheduler) run() {
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			s.dispatchChecks(jobQueue)
 		case <-s.quit:
 			close(jobQueue) // Signal workers to stop
@@ -87,7 +92,11 @@ func (s *Scheduler) worker(jobs <-chan data.CameraHealthTarget) {
 		// Jitter to avoid thundering herd on DB
 		// Sleep 0-1000ms
 		jitter := time.Duration(rand.Intn(1000)) * time.Millisecond
-		time.Sleep(jitter)
+		select {
+		case <-s.config.Clock.After(jitter):
+		case <-s.quit:
+			continue // Drain without probing
+		}
 
 		s.service.PerformCheck(ctx, job.TenantID, job.CameraID, job.RTSPURL)
 	}
@@ -105,6 +114,11 @@ func (s *Scheduler) dispatchChecks(queue chan<- data.CameraHealthTarget) {
 	queued := 0
 
 	for _, c := range cameras {
+		// Paused while the tenant is in maintenance; due again once cleared.
+		if s.service.Maintenance.Active(c.TenantID) {
+			continue
+		}
+
 		// 1. Backoff Check (Optimization: Don't queue if backing off)
 		if s.shouldSkip(c) {
 			continue
@@ -146,5 +160,5 @@ func (s *Scheduler) shouldSkip(c data.CameraHealthTarget) bool {
 	}
 
 	nextCheck := c.LastCheckedAt.Add(backoff)
-	return time.Now().Before(nextCheck)
+	return s.config.Clock.Now().Before(nextCheck)
 }
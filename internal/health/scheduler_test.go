@@ -1,13 +1,16 @@
 package health
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/technosupport/ts-vms/internal/clock"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/maintenance"
 )
 
 func TestScheduler_Run(t *testing.T) {
@@ -58,9 +61,8 @@ func TestScheduler_Run(t *testing.T) {
 
 func TestScheduler_Backoff(t *testing.T) {
 	// ... logic to test shouldSkip ...
-	s := &Scheduler{}
-
-	now := time.Now()
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	s := NewScheduler(SchedulerConfig{Clock: clock.NewFake(now)}, nil)
 
 	// Case 1: Success -> No Backoff
 	c1 := data.CameraHealthTarget{Status: data.HealthStatusOnline, LastCheckedAt: now.Add(-10 * time.Second)}
@@ -86,3 +88,36 @@ func TestScheduler_Backoff(t *testing.T) {
 }
 
 const LimitHistory = 200 // from logic
+
+func TestScheduler_PausedDuringMaintenance(t *testing.T) {
+	mockRepo := new(MockHealthRepo)
+	svc := NewService(mockRepo, &MockNVRRepo{}, new(MockProber))
+	svc.Maintenance = maintenance.New()
+	s := NewScheduler(SchedulerConfig{Clock: clock.NewFake(time.Now())}, svc)
+
+	paused, other := uuid.New(), uuid.New()
+	mockRepo.On("ListTargets", mock.Anything).Return([]data.CameraHealthTarget{
+		{TenantID: paused, CameraID: uuid.New(), Status: data.HealthStatusOnline},
+		{TenantID: other, CameraID: uuid.New(), Status: data.HealthStatusOnline},
+	}, nil)
+
+	queue := make(chan data.CameraHealthTarget, 4)
+	svc.Maintenance.SetTenant(context.Background(), paused, true)
+	s.dispatchChecks(queue)
+	if len(queue) != 1 || (<-queue).TenantID != other {
+		t.Fatal("Expected only the tenant outside maintenance to be queued")
+	}
+
+	svc.Maintenance.SetGlobal(true)
+	s.dispatchChecks(queue)
+	if len(queue) != 0 {
+		t.Fatalf("Expected nothing queued under global maintenance, got %d", len(queue))
+	}
+
+	svc.Maintenance.SetGlobal(false)
+	svc.Maintenance.SetTenant(context.Background(), paused, false)
+	s.dispatchChecks(queue)
+	if len(queue) != 2 {
+		t.Errorf("Expected both tenants queued after maintenance, got %d", len(queue))
+	}
+}
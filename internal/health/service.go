@@ -2,28 +2,75 @@ package health
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/maintenance"
 )
 
+// DefaultRecheckCooldown is the minimum interval between manual rechecks
+// of one camera (health.recheck_cooldown).
+const DefaultRecheckCooldown = 10 * time.Second
+
+var ErrRecheckTooSoon = errors.New("ERR_RECHECK_TOO_SOON")
+
+// RecheckTooSoonError reports a manual recheck inside the camera's cooldown.
+// It matches ErrRecheckTooSoon with errors.Is.
+type RecheckTooSoonError struct {
+	Remaining time.Duration
+}
+
+func (e *RecheckTooSoonError) Error() string {
+	return fmt.Sprintf("%s: retry in %s", ErrRecheckTooSoon, e.Remaining)
+}
+
+func (e *RecheckTooSoonError) Unwrap() error {
+	return ErrRecheckTooSoon
+}
+
+// RemainingSeconds rounds Remaining up to whole seconds (at least 1).
+func (e *RecheckTooSoonError) RemainingSeconds() int {
+	secs := int((e.Remaining + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
 type Service struct {
 	Repo    data.HealthRepository
 	NVRRepo data.NVRRepository
 	Prober  Prober
 	History *HistoryManager
 	Alerts  *AlertManager
+
+	// RecheckCooldown is the minimum interval between ManualCheck calls for
+	// one camera; 0 disables the limit.
+	RecheckCooldown time.Duration
+
+	// Maintenance pauses scheduled checks and suppresses alerts for tenants
+	// in maintenance. Nil means never.
+	Maintenance *maintenance.Switch
+
+	recheckMu   sync.Mutex
+	lastRecheck map[uuid.UUID]time.Time
+	now         func() time.Time
 }
 
 func NewService(repo data.HealthRepository, nvrRepo data.NVRRepository, prober Prober) *Service {
 	return &Service{
-		Repo:    repo,
-		NVRRepo: nvrRepo,
-		Prober:  prober,
-		History: NewHistoryManager(repo),
-		Alerts:  NewAlertManager(repo),
+		Repo:            repo,
+		NVRRepo:         nvrRepo,
+		Prober:          prober,
+		History:         NewHistoryManager(repo),
+		Alerts:          NewAlertManager(repo),
+		RecheckCooldown: DefaultRecheckCooldown,
+		lastRecheck:     make(map[uuid.UUID]time.Time),
+		now:             time.Now,
 	}
 }
 
@@ -148,9 +195,12 @@ func (s *Service) PerformCheck(ctx context.Context, tenantID, cameraID uuid.UUID
 		// Log
 	}
 
-	// 5. Alerting
-	if err := s.Alerts.ProcessState(ctx, tenantID, cameraID, status, consecutive, lastSuccess); err != nil {
-		// Log
+	// 5. Alerting, suppressed while the tenant is in maintenance. Open
+	// alerts are left as they are and settle on the first check after.
+	if !s.Maintenance.Active(tenantID) {
+		if err := s.Alerts.ProcessState(ctx, tenantID, cameraID, status, consecutive, lastSuccess); err != nil {
+			// Log
+		}
 	}
 
 	// 6. Metrics (Prometheus hooks would go here)
@@ -164,6 +214,9 @@ func (s *Service) ManualCheck(ctx context.Context, tenantID, cameraID uuid.UUID)
 	if err != nil {
 		return err // e.g. Not Found
 	}
+	if err := s.reserveRecheck(cameraID); err != nil {
+		return err
+	}
 
 	// 2. Perform Check (Sync? Or Async?)
 	// "triggers an immediate probe".
@@ -178,6 +231,30 @@ func (s *Service) ManualCheck(ctx context.Context, tenantID, cameraID uuid.UUID)
 	return nil
 }
 
+// reserveRecheck records a manual recheck of cameraID now, or returns a
+// *RecheckTooSoonError when the previous one is within RecheckCooldown.
+func (s *Service) reserveRecheck(cameraID uuid.UUID) error {
+	if s.RecheckCooldown <= 0 {
+		return nil
+	}
+	now := s.now()
+	s.recheckMu.Lock()
+	defer s.recheckMu.Unlock()
+	if last, ok := s.lastRecheck[cameraID]; ok {
+		if remaining := s.RecheckCooldown - now.Sub(last); remaining > 0 {
+			return &RecheckTooSoonError{Remaining: remaining}
+		}
+	}
+	// Drop expired entries so the map only holds cameras inside their cooldown
+	for id, last := range s.lastRecheck {
+		if now.Sub(last) >= s.RecheckCooldown {
+			delete(s.lastRecheck, id)
+		}
+	}
+	s.lastRecheck[cameraID] = now
+	return nil
+}
+
 func (s *Service) GetHistory(ctx context.Context, cameraID uuid.UUID, limit, offset int) ([]*data.CameraHealthHistory, error) {
 	return s.Repo.GetHistory(ctx, cameraID, limit, offset)
 }
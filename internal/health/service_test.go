@@ -2,12 +2,15 @@ package health
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/maintenance"
+	"github.com/technosupport/ts-vms/internal/webhook"
 )
 
 func TestService_PerformCheck_AlertLogic(t *testing.T) {
@@ -89,3 +92,349 @@ func TestService_PerformCheck_Recovery(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestService_ManualCheck_Cooldown(t *testing.T) {
+	mockRepo := new(MockHealthRepo)
+	mockProber := new(MockProber)
+	svc := NewService(mockRepo, &MockNVRRepo{}, mockProber)
+	svc.RecheckCooldown = 10 * time.Second
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	tid := uuid.New()
+	cid := uuid.New()
+	mockRepo.On("GetTarget", mock.Anything, cid).Return(&data.CameraHealthTarget{CameraID: cid, TenantID: tid, RTSPURL: "rtsp://test"}, nil)
+
+	// The probe itself runs in the background
+	mockProber.On("Probe", mock.Anything, tid, cid, "rtsp://test").Return(data.HealthStatusOnline, "ok", 10).Maybe()
+	mockRepo.On("GetStatus", mock.Anything, cid).Return(nil, nil).Maybe()
+	mockRepo.On("UpsertStatus", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockRepo.On("AddHistory", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockRepo.On("PruneHistory", mock.Anything, cid, mock.Anything).Return(nil).Maybe()
+	mockRepo.On("GetOpenAlert", mock.Anything, cid, mock.Anything).Return(nil, nil).Maybe()
+
+	if err := svc.ManualCheck(context.Background(), tid, cid); err != nil {
+		t.Fatalf("First recheck: %v", err)
+	}
+
+	now = now.Add(3 * time.Second)
+	err := svc.ManualCheck(context.Background(), tid, cid)
+	var tooSoon *RecheckTooSoonError
+	if !errors.As(err, &tooSoon) || !errors.Is(err, ErrRecheckTooSoon) {
+		t.Fatalf("Expected ErrRecheckTooSoon, got %v", err)
+	}
+	if tooSoon.RemainingSeconds() != 7 {
+		t.Errorf("Expected 7s remaining, got %d", tooSoon.RemainingSeconds())
+	}
+
+	// Another camera is not affected
+	other := uuid.New()
+	mockRepo.On("GetTarget", mock.Anything, other).Return(nil, data.ErrRecordNotFound)
+	if err := svc.ManualCheck(context.Background(), tid, other); !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("Expected not found for the other camera, got %v", err)
+	}
+
+	now = now.Add(7 * time.Second)
+	if err := svc.ManualCheck(context.Background(), tid, cid); err != nil {
+		t.Errorf("Recheck after cooldown: %v", err)
+	}
+}
+
+// alertStore keeps camera_alerts in memory with the one-open-alert-per
+// (camera, type) rule of uq_camera_alerts_open.
+type alertStore struct {
+	MockHealthRepo
+	alerts []*data.CameraAlert
+}
+
+func (s *alertStore) UpsertAlert(ctx context.Context, a *data.CameraAlert) error {
+	for _, cur := range s.alerts {
+		if cur.CameraID == a.CameraID && cur.Type == a.Type && cur.State == "open" {
+			if a.LastSeenAt.After(cur.LastSeenAt) {
+				cur.LastSeenAt = a.LastSeenAt
+			}
+			a.ID, a.StartedAt = cur.ID, cur.StartedAt
+			return nil
+		}
+	}
+	stored := *a
+	stored.ID = uuid.New()
+	a.ID = stored.ID
+	s.alerts = append(s.alerts, &stored)
+	return nil
+}
+
+func (s *alertStore) GetOpenAlert(ctx context.Context, cameraID uuid.UUID, alertType string) (*data.CameraAlert, error) {
+	for _, cur := range s.alerts {
+		if cur.CameraID == cameraID && cur.Type == alertType && cur.State == "open" {
+			c := *cur
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *alertStore) CloseAlert(ctx context.Context, alertID uuid.UUID) error {
+	for _, cur := range s.alerts {
+		if cur.ID == alertID && cur.State == "open" {
+			now := time.Now()
+			cur.State, cur.EndedAt = "closed", &now
+		}
+	}
+	return nil
+}
+
+func (s *alertStore) MarkAlertNotified(ctx context.Context, alertID uuid.UUID) (bool, error) {
+	for _, cur := range s.alerts {
+		if cur.ID == alertID && cur.State == "open" && cur.LastNotifiedAt == nil {
+			now := time.Now()
+			cur.LastNotifiedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *alertStore) ClearAlertNotified(ctx context.Context, alertID uuid.UUID) error {
+	for _, cur := range s.alerts {
+		if cur.ID == alertID {
+			cur.LastNotifiedAt = nil
+		}
+	}
+	return nil
+}
+
+func (s *alertStore) open() []*data.CameraAlert {
+	var out []*data.CameraAlert
+	for _, a := range s.alerts {
+		if a.State == "open" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func TestAlertManager_RepeatedOfflineKeepsOneOpenAlert(t *testing.T) {
+	store := &alertStore{}
+	am := NewAlertManager(store)
+	ctx := context.Background()
+	tid, cid := uuid.New(), uuid.New()
+	longAgo := time.Now().Add(-10 * time.Minute)
+
+	for i := 0; i < 5; i++ {
+		if err := am.ProcessState(ctx, tid, cid, data.HealthStatusOffline, 10+i, &longAgo); err != nil {
+			t.Fatalf("check %d: %v", i, err)
+		}
+	}
+	open := store.open()
+	if len(store.alerts) != 1 || len(open) != 1 {
+		t.Fatalf("Expected one open alert, got %d alerts (%d open)", len(store.alerts), len(open))
+	}
+	if !open[0].LastSeenAt.After(open[0].StartedAt) {
+		t.Errorf("Expected last_seen_at to advance past started_at")
+	}
+
+	if err := am.ProcessState(ctx, tid, cid, data.HealthStatusOnline, 0, nil); err != nil {
+		t.Fatalf("recovery: %v", err)
+	}
+	if len(store.open()) != 0 || store.alerts[0].EndedAt == nil {
+		t.Fatalf("Expected recovery to close the alert, got %+v", store.alerts[0])
+	}
+
+	// A later outage opens a fresh incident.
+	if err := am.ProcessState(ctx, tid, cid, data.HealthStatusOffline, 10, &longAgo); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.alerts) != 2 || len(store.open()) != 1 {
+		t.Errorf("Expected a new open alert after recovery, got %d alerts", len(store.alerts))
+	}
+}
+
+func TestService_PerformCheck_NoAlertsDuringMaintenance(t *testing.T) {
+	mockRepo := new(MockHealthRepo)
+	mockProber := new(MockProber)
+	svc := NewService(mockRepo, &MockNVRRepo{}, mockProber)
+	svc.Maintenance = maintenance.New()
+	tid, cid := uuid.New(), uuid.New()
+
+	mockProber.On("Probe", mock.Anything, tid, cid, "rtsp://test").Return(data.HealthStatusOffline, "timeout", 0)
+	mockRepo.On("GetStatus", mock.Anything, cid).Return(&data.CameraHealthCurrent{ConsecutiveFailures: 9}, nil)
+	mockRepo.On("UpsertStatus", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("AddHistory", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("PruneHistory", mock.Anything, cid, 200).Return(nil)
+
+	// Status is still recorded, but no alert is looked up or opened.
+	svc.Maintenance.SetTenant(context.Background(), tid, true)
+	svc.PerformCheck(context.Background(), tid, cid, "rtsp://test")
+	mockRepo.AssertNotCalled(t, "GetOpenAlert", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpsertAlert", mock.Anything, mock.Anything)
+
+	// Cleared: the next check opens the alert.
+	svc.Maintenance.SetTenant(context.Background(), tid, false)
+	mockRepo.On("GetOpenAlert", mock.Anything, cid, "offline_over_5m").Return(nil, nil)
+	mockRepo.On("UpsertAlert", mock.Anything, mock.Anything).Return(nil)
+	svc.PerformCheck(context.Background(), tid, cid, "rtsp://test")
+	mockRepo.AssertCalled(t, "UpsertAlert", mock.Anything, mock.Anything)
+}
+
+// recordingNotifier keeps the notices it was sent.
+type recordingNotifier struct {
+	notices []OfflineNotice
+}
+
+func (r *recordingNotifier) NotifyOffline(ctx context.Context, n OfflineNotice) error {
+	r.notices = append(r.notices, n)
+	return nil
+}
+
+func TestAlertManager_NotifiesOncePerIncident(t *testing.T) {
+	am := NewAlertManager(&alertStore{})
+	notifier := &recordingNotifier{}
+	am.Notifier = notifier
+	am.OfflineThreshold = OfflineThreshold{Default: 3}
+	ctx := context.Background()
+	tid, cid := uuid.New(), uuid.New()
+	recent := time.Now()
+
+	check := func(status data.CameraHealthStatus, consecutive int) {
+		t.Helper()
+		if err := am.ProcessState(ctx, tid, cid, status, consecutive, &recent); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check(data.HealthStatusOffline, 1)
+	check(data.HealthStatusStreamError, 2)
+	if len(notifier.notices) != 0 {
+		t.Fatalf("Expected no notice below the threshold, got %d", len(notifier.notices))
+	}
+	for i := 3; i <= 6; i++ {
+		check(data.HealthStatusOffline, i)
+	}
+	if len(notifier.notices) != 1 {
+		t.Fatalf("Expected one notice for the incident, got %d", len(notifier.notices))
+	}
+	if n := notifier.notices[0]; n.CameraID != cid || n.TenantID != tid || n.ConsecutiveFailures != 3 {
+		t.Errorf("Unexpected notice %+v", n)
+	}
+
+	// Recovery re-arms; the next incident notifies again.
+	check(data.HealthStatusOnline, 0)
+	for i := 1; i <= 4; i++ {
+		check(data.HealthStatusOffline, i)
+	}
+	if len(notifier.notices) != 2 {
+		t.Errorf("Expected a second notice after recovery, got %d", len(notifier.notices))
+	}
+}
+
+func TestAlertManager_OfflineThresholdPerTenant(t *testing.T) {
+	am := NewAlertManager(&alertStore{})
+	notifier := &recordingNotifier{}
+	am.Notifier = notifier
+	quiet, eager := uuid.New(), uuid.New()
+	am.OfflineThreshold = OfflineThreshold{Default: 5, Tenants: map[uuid.UUID]int{quiet: 0, eager: 1}}
+	ctx := context.Background()
+	recent := time.Now()
+
+	quietCam := uuid.New()
+	for i := 1; i <= 10; i++ {
+		am.ProcessState(ctx, quiet, quietCam, data.HealthStatusOffline, i, &recent)
+	}
+	if len(notifier.notices) != 0 {
+		t.Fatalf("Expected threshold 0 to disable notices, got %d", len(notifier.notices))
+	}
+	am.ProcessState(ctx, eager, uuid.New(), data.HealthStatusOffline, 1, &recent)
+	other := uuid.New()
+	am.ProcessState(ctx, uuid.New(), other, data.HealthStatusOffline, 4, &recent)
+	if len(notifier.notices) != 1 || notifier.notices[0].TenantID != eager {
+		t.Fatalf("Expected only the eager tenant notified, got %+v", notifier.notices)
+	}
+	am.ProcessState(ctx, uuid.New(), other, data.HealthStatusOffline, 5, &recent)
+	if len(notifier.notices) != 2 {
+		t.Errorf("Expected the default threshold to apply to other tenants, got %d", len(notifier.notices))
+	}
+}
+
+// fakeSender records queued webhook events; full makes Enqueue drop them.
+type fakeSender struct {
+	events []webhook.Event
+	full   bool
+}
+
+func (f *fakeSender) Enqueue(evt webhook.Event) bool {
+	if f.full {
+		return false
+	}
+	f.events = append(f.events, evt)
+	return true
+}
+
+func TestWebhookNotifier_QueuesOfflineEvent(t *testing.T) {
+	sender := &fakeSender{}
+	n := OfflineNotice{TenantID: uuid.New(), CameraID: uuid.New(), Status: "OFFLINE", ConsecutiveFailures: 3, DetectedAt: time.Now()}
+	if err := (WebhookNotifier{Sender: sender}).NotifyOffline(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.events) != 1 {
+		t.Fatalf("Expected one event, got %d", len(sender.events))
+	}
+	if evt := sender.events[0]; evt.Type != WebhookCameraOffline || evt.TenantID != n.TenantID {
+		t.Errorf("Unexpected event %+v", evt)
+	}
+
+	sender.full = true
+	if err := (WebhookNotifier{Sender: sender}).NotifyOffline(context.Background(), n); !errors.Is(err, ErrNotificationDropped) {
+		t.Errorf("Expected ErrNotificationDropped, got %v", err)
+	}
+}
+
+func TestAlertManager_DroppedNoticeIsRetried(t *testing.T) {
+	am := NewAlertManager(&alertStore{})
+	sender := &fakeSender{full: true}
+	am.Notifier = WebhookNotifier{Sender: sender}
+	am.OfflineThreshold = OfflineThreshold{Default: 1}
+	ctx := context.Background()
+	tid, cid := uuid.New(), uuid.New()
+	recent := time.Now()
+
+	am.ProcessState(ctx, tid, cid, data.HealthStatusOffline, 1, &recent)
+	sender.full = false
+	am.ProcessState(ctx, tid, cid, data.HealthStatusOffline, 2, &recent)
+	am.ProcessState(ctx, tid, cid, data.HealthStatusOffline, 3, &recent)
+	if len(sender.events) != 1 {
+		t.Errorf("Expected the dropped notice to be sent once on the next check, got %d", len(sender.events))
+	}
+}
+
+func TestAlertManager_NotifiedStateSurvivesRestart(t *testing.T) {
+	store := &alertStore{}
+	ctx := context.Background()
+	tid, cid := uuid.New(), uuid.New()
+	recent := time.Now()
+
+	first := &recordingNotifier{}
+	am := NewAlertManager(store)
+	am.Notifier = first
+	am.OfflineThreshold = OfflineThreshold{Default: 1}
+	am.ProcessState(ctx, tid, cid, data.HealthStatusOffline, 1, &recent)
+	if len(first.notices) != 1 {
+		t.Fatalf("Expected one notice, got %d", len(first.notices))
+	}
+
+	// A new manager over the same camera_alerts sees the incident as notified.
+	second := &recordingNotifier{}
+	am = NewAlertManager(store)
+	am.Notifier = second
+	am.OfflineThreshold = OfflineThreshold{Default: 1}
+	am.ProcessState(ctx, tid, cid, data.HealthStatusOffline, 2, &recent)
+	if len(second.notices) != 0 {
+		t.Fatalf("Expected no repeat notice after restart, got %d", len(second.notices))
+	}
+
+	am.ProcessState(ctx, tid, cid, data.HealthStatusOnline, 0, nil)
+	am.ProcessState(ctx, tid, cid, data.HealthStatusOffline, 1, &recent)
+	if len(second.notices) != 1 {
+		t.Errorf("Expected recovery to re-arm the notice, got %d", len(second.notices))
+	}
+}
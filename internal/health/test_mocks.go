@@ -63,6 +63,16 @@ func (m *MockHealthRepo) CloseAlert(ctx context.Context, alertID uuid.UUID) erro
 	return args.Error(0)
 }
 
+func (m *MockHealthRepo) MarkAlertNotified(ctx context.Context, alertID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, alertID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockHealthRepo) ClearAlertNotified(ctx context.Context, alertID uuid.UUID) error {
+	args := m.Called(ctx, alertID)
+	return args.Error(0)
+}
+
 func (m *MockHealthRepo) ListAlerts(ctx context.Context, tenantID uuid.UUID, state string) ([]*data.CameraAlert, error) {
 	args := m.Called(ctx, tenantID, state)
 	if args.Get(0) == nil {
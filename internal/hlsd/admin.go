@@ -0,0 +1,116 @@
+package hlsd
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// MinKeyLength is the shortest HMAC secret accepted at runtime.
+const MinKeyLength = 16
+
+// KeyAdminHandler exposes runtime HMAC key rotation for the running hlsd.
+// It is guarded by a static admin token and must only be mounted on an
+// internal listener / route group.
+type KeyAdminHandler struct {
+	keys       *MapKeyProvider
+	adminToken string
+}
+
+func NewKeyAdminHandler(keys *MapKeyProvider, adminToken string) *KeyAdminHandler {
+	return &KeyAdminHandler{keys: keys, adminToken: adminToken}
+}
+
+// Register mounts the admin routes. No-op when no admin token is configured,
+// so rotation is never exposed unauthenticated.
+func (h *KeyAdminHandler) Register(r chi.Router) {
+	if h.adminToken == "" {
+		log.Printf("[WARN] HLSD_ADMIN_TOKEN not set; key rotation endpoint disabled")
+		return
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireAdminToken)
+		r.Get("/internal/hls/keys", h.ListKeys)
+		r.Post("/internal/hls/keys", h.AddKey)
+		r.Post("/internal/hls/keys/{kid}/deprecate", h.DeprecateKey)
+	})
+}
+
+func (h *KeyAdminHandler) requireAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(h.adminToken)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GET /internal/hls/keys
+func (h *KeyAdminHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"keys": h.keys.List()})
+}
+
+// POST /internal/hls/keys
+// Body: {"kid":"v2","key":"...","deprecate_others":true}
+func (h *KeyAdminHandler) AddKey(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+
+	var req struct {
+		KID             string `json:"kid"`
+		Key             string `json:"key"`
+		DeprecateOthers bool   `json:"deprecate_others"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !idRegex.MatchString(req.KID) {
+		http.Error(w, "Invalid kid", http.StatusBadRequest)
+		return
+	}
+	if len(req.Key) < MinKeyLength {
+		http.Error(w, "Key too short", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if req.DeprecateOthers {
+		err = h.keys.Rotate(req.KID, []byte(req.Key))
+	} else {
+		err = h.keys.AddKey(req.KID, []byte(req.Key))
+	}
+	if err != nil {
+		if errors.Is(err, ErrKeyExists) {
+			http.Error(w, "Key ID already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("[INFO] HLS HMAC key %s added (deprecate_others=%v)", req.KID, req.DeprecateOthers)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{"keys": h.keys.List()})
+}
+
+// POST /internal/hls/keys/{kid}/deprecate
+func (h *KeyAdminHandler) DeprecateKey(w http.ResponseWriter, r *http.Request) {
+	kid := chi.URLParam(r, "kid")
+	if err := h.keys.Deprecate(kid); err != nil {
+		http.Error(w, "Unknown kid", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("[INFO] HLS HMAC key %s deprecated", kid)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"keys": h.keys.List()})
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -236,3 +239,118 @@ func TestHLSDeliveryScenarios(t *testing.T) {
 		}
 	})
 }
+
+func signedQuery(cam, sess, kid string, key []byte, exp int64) url.Values {
+	expStr := fmt.Sprintf("%d", exp)
+	q := url.Values{}
+	q.Set("sub", cam)
+	q.Set("sid", sess)
+	q.Set("exp", expStr)
+	q.Set("scope", "hls")
+	q.Set("kid", kid)
+	q.Set("sig", hlsd.Sign(fmt.Sprintf("hls|%s|%s|%s", cam, sess, expStr), key))
+	return q
+}
+
+func TestMapKeyProvider_ConcurrentAdd(t *testing.T) {
+	p := &hlsd.MapKeyProvider{Keys: map[string][]byte{"v1": []byte("seed-secret-0000")}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := p.AddKey(fmt.Sprintf("k%d", i), []byte(fmt.Sprintf("secret-%d", i))); err != nil {
+				t.Errorf("AddKey k%d: %v", i, err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			p.GetKey("v1")
+			p.List()
+		}()
+	}
+	wg.Wait()
+
+	if got := len(p.List()); got != 51 {
+		t.Fatalf("expected 51 keys, got %d", got)
+	}
+	if err := p.AddKey("k0", []byte("other")); err != hlsd.ErrKeyExists {
+		t.Errorf("expected ErrKeyExists for duplicate kid, got %v", err)
+	}
+}
+
+func TestMapKeyProvider_RotatedKeyValidatesImmediately(t *testing.T) {
+	p := &hlsd.MapKeyProvider{Keys: map[string][]byte{"v1": []byte("old-secret-00000")}}
+	exp := time.Now().Add(time.Minute).Unix()
+
+	oldToken := signedQuery("cam1", "sess1", "v1", []byte("old-secret-00000"), exp)
+
+	if err := p.Rotate("v2", []byte("new-secret-00000")); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	newToken := signedQuery("cam1", "sess1", "v2", []byte("new-secret-00000"), exp)
+	if err := hlsd.ValidateHLSToken("cam1", "sess1", newToken, p); err != nil {
+		t.Errorf("token signed with new key should validate: %v", err)
+	}
+	// Deprecated keys keep validating for zero-downtime rotation
+	if err := hlsd.ValidateHLSToken("cam1", "sess1", oldToken, p); err != nil {
+		t.Errorf("token signed with deprecated key should still validate: %v", err)
+	}
+	if !p.IsDeprecated("v1") || p.IsDeprecated("v2") {
+		t.Error("expected v1 deprecated and v2 current")
+	}
+}
+
+func TestKeyAdminHandler(t *testing.T) {
+	p := &hlsd.MapKeyProvider{Keys: map[string][]byte{"v1": []byte("old-secret-00000")}}
+	r := chi.NewRouter()
+	hlsd.NewKeyAdminHandler(p, "admin-token").Register(r)
+
+	post := func(token, body string) int {
+		req := httptest.NewRequest("POST", "/internal/hls/keys", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post("", `{"kid":"v2","key":"new-secret-00000"}`); code != http.StatusUnauthorized {
+		t.Errorf("missing token: expected 401, got %d", code)
+	}
+	if code := post("wrong", `{"kid":"v2","key":"new-secret-00000"}`); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: expected 401, got %d", code)
+	}
+	if code := post("admin-token", `{"kid":"v2","key":"short"}`); code != http.StatusBadRequest {
+		t.Errorf("short key: expected 400, got %d", code)
+	}
+	if code := post("admin-token", `{"kid":"v2","key":"new-secret-00000","deprecate_others":true}`); code != http.StatusCreated {
+		t.Fatalf("rotate: expected 201, got %d", code)
+	}
+	if code := post("admin-token", `{"kid":"v2","key":"new-secret-11111"}`); code != http.StatusConflict {
+		t.Errorf("duplicate kid: expected 409, got %d", code)
+	}
+	if !p.IsDeprecated("v1") {
+		t.Error("expected v1 deprecated after rotation")
+	}
+
+	exp := time.Now().Add(time.Minute).Unix()
+	if err := hlsd.ValidateHLSToken("cam1", "sess1", signedQuery("cam1", "sess1", "v2", []byte("new-secret-00000"), exp), p); err != nil {
+		t.Errorf("new key should validate immediately: %v", err)
+	}
+}
+
+func TestKeyAdminHandler_DisabledWithoutToken(t *testing.T) {
+	r := chi.NewRouter()
+	hlsd.NewKeyAdminHandler(&hlsd.MapKeyProvider{}, "").Register(r)
+
+	req := httptest.NewRequest("POST", "/internal/hls/keys", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when admin token unset, got %d", w.Code)
+	}
+}
@@ -7,13 +7,18 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 )
 
 var (
 	ErrInvalidToken = errors.New("invalid hls token")
 	ErrExpiredToken = errors.New("hls token expired")
+	ErrKeyExists    = errors.New("hls key id already exists")
+	ErrUnknownKey   = errors.New("unknown hls key id")
+	ErrInvalidKey   = errors.New("invalid hls key")
 )
 
 // KeyProvider facilitates kid-based secret lookup
@@ -21,12 +26,27 @@ type KeyProvider interface {
 	GetKey(kid string) ([]byte, error)
 }
 
-// MapKeyProvider is a simple implementation of KeyProvider
+// MapKeyProvider is a simple implementation of KeyProvider.
+// Keys may be seeded via the Keys field before first use; afterwards all
+// access must go through the methods, which are safe for concurrent use.
 type MapKeyProvider struct {
 	Keys map[string][]byte
+
+	mu         sync.RWMutex
+	deprecated map[string]time.Time
+}
+
+// KeyInfo describes a key without exposing its secret.
+type KeyInfo struct {
+	KID          string     `json:"kid"`
+	Deprecated   bool       `json:"deprecated"`
+	DeprecatedAt *time.Time `json:"deprecated_at,omitempty"`
 }
 
 func (p *MapKeyProvider) GetKey(kid string) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	key, ok := p.Keys[kid]
 	if !ok {
 		return nil, fmt.Errorf("unknown kid: %s", kid)
@@ -34,6 +54,103 @@ func (p *MapKeyProvider) GetKey(kid string) ([]byte, error) {
 	return key, nil
 }
 
+// AddKey registers a new key version. Existing kids cannot be overwritten,
+// so a leaked request can't silently swap the secret behind a live kid.
+func (p *MapKeyProvider) AddKey(kid string, key []byte) error {
+	if kid == "" || len(key) == 0 {
+		return ErrInvalidKey
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Keys == nil {
+		p.Keys = make(map[string][]byte)
+	}
+	if _, exists := p.Keys[kid]; exists {
+		return ErrKeyExists
+	}
+	k := make([]byte, len(key))
+	copy(k, key)
+	p.Keys[kid] = k
+	return nil
+}
+
+// Deprecate marks a key as deprecated. Deprecated keys still validate so
+// tokens already handed out keep working until they expire.
+func (p *MapKeyProvider) Deprecate(kid string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.Keys[kid]; !ok {
+		return ErrUnknownKey
+	}
+	p.markDeprecatedLocked(kid, time.Now())
+	return nil
+}
+
+// Rotate adds kid as the new current key and deprecates every other key
+// in a single step.
+func (p *MapKeyProvider) Rotate(kid string, key []byte) error {
+	if kid == "" || len(key) == 0 {
+		return ErrInvalidKey
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Keys == nil {
+		p.Keys = make(map[string][]byte)
+	}
+	if _, exists := p.Keys[kid]; exists {
+		return ErrKeyExists
+	}
+	now := time.Now()
+	for existing := range p.Keys {
+		p.markDeprecatedLocked(existing, now)
+	}
+	k := make([]byte, len(key))
+	copy(k, key)
+	p.Keys[kid] = k
+	return nil
+}
+
+// IsDeprecated reports whether kid has been deprecated.
+func (p *MapKeyProvider) IsDeprecated(kid string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.deprecated[kid]
+	return ok
+}
+
+// List returns key metadata sorted by kid.
+func (p *MapKeyProvider) List() []KeyInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	out := make([]KeyInfo, 0, len(p.Keys))
+	for kid := range p.Keys {
+		info := KeyInfo{KID: kid}
+		if at, ok := p.deprecated[kid]; ok {
+			t := at
+			info.Deprecated = true
+			info.DeprecatedAt = &t
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].KID < out[j].KID })
+	return out
+}
+
+func (p *MapKeyProvider) markDeprecatedLocked(kid string, at time.Time) {
+	if p.deprecated == nil {
+		p.deprecated = make(map[string]time.Time)
+	}
+	if _, already := p.deprecated[kid]; !already {
+		p.deprecated[kid] = at
+	}
+}
+
 // ValidateHLSToken validates the HMAC token based on the Phase 3.2 contract.
 // Expected canonical string: hls|{sub}|{sid}|{exp}
 func ValidateHLSToken(cameraID, sessionID string, query url.Values, keys KeyProvider) error {
@@ -16,6 +16,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/audit"
+	"github.com/technosupport/ts-vms/internal/clock"
 	"github.com/technosupport/ts-vms/internal/license"
 )
 
@@ -215,7 +217,7 @@ func TestManager_Reference_Future(t *testing.T) {
 	payload.IssuedAt = time.Now().Add(24 * time.Hour) // Future
 
 	createLicenseFile(t, licPath, payload, priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	state := m.GetState()
 	// Based on Manager implementation, Future Issue Date -> StatusParseError
@@ -231,7 +233,7 @@ func TestManager_Grace(t *testing.T) {
 	payload.ValidUntil = time.Now().Add(-1 * time.Hour) // Just Expired
 
 	createLicenseFile(t, licPath, payload, priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	state := m.GetState()
 	if state.Status != license.StatusExpiredGrace {
@@ -246,7 +248,7 @@ func TestManager_Blocked(t *testing.T) {
 	payload.ValidUntil = time.Now().Add(-35 * 24 * time.Hour) // > 30 days
 
 	createLicenseFile(t, licPath, payload, priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	state := m.GetState()
 	if state.Status != license.StatusExpiredBlocked {
@@ -260,7 +262,7 @@ func TestManager_LimitExceeded(t *testing.T) {
 	payload := validPayload()
 	payload.Limits.MaxCameras = 5
 	createLicenseFile(t, licPath, payload, priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	// Mock Usage 10
 	stubUsage.Cameras = 10
@@ -281,7 +283,7 @@ func TestManager_Reload_Atomic(t *testing.T) {
 	// Write garbage
 	os.WriteFile(licPath, []byte("trash"), 0644)
 
-	m.Reload()
+	m.Reload(audit.SystemActor())
 	if m.GetState().Status == license.StatusValid {
 		t.Error("Should be invalid now")
 	}
@@ -295,6 +297,49 @@ func TestScheduler_Basic(t *testing.T) {
 	// Basic run check, no panic
 }
 
+// Alerts are de-duplicated per day on the scheduler's clock
+func TestScheduler_FakeClockDedup(t *testing.T) {
+	m, _, _, _ := setupManager(t)
+	m.Reload(audit.SystemActor()) // Valid for 1 more day -> "7d" alerts
+
+	fake := clock.NewFake(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	s := license.NewScheduler(m)
+	s.Clock = fake
+	alerts := make(chan string, 64)
+	s.OnAlert = func(typ string, _ int) { alerts <- typ }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Start(ctx)
+
+	expectAlerts := func(step string, want int) {
+		t.Helper()
+		got := 0
+		for len(alerts) > 0 {
+			if typ := <-alerts; typ != "7d" {
+				t.Errorf("%s: unexpected alert type %s", step, typ)
+			}
+			got++
+		}
+		if got != want {
+			t.Errorf("%s: expected %d alerts, got %d", step, want, got)
+		}
+	}
+	expectAlerts("start", 1)
+
+	// Hourly ticks later the same day stay quiet. Each Advance waits for its
+	// tick to be received, which means the previous one has been handled.
+	fake.Advance(13 * time.Hour) // 23:00
+	expectAlerts("same day", 0)
+
+	// Midnight tick alerts again; the 01:00 tick proves it was handled
+	fake.Advance(2 * time.Hour)
+	expectAlerts("next day", 1)
+
+	fake.Advance(5 * time.Hour)
+	expectAlerts("rest of next day", 0)
+}
+
 // Additional Tests to reach 18+
 
 // 19. CheckOperation blocked on StatusExpiredBlocked
@@ -303,7 +348,7 @@ func TestManager_CheckOp_Blocked(t *testing.T) {
 	payload := validPayload()
 	payload.ValidUntil = time.Now().Add(-40 * 24 * time.Hour)
 	createLicenseFile(t, licPath, payload, priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	err := m.CheckOperation("view", uuid.New())
 	if err == nil || err.Error() != "license_expired_blocked" {
@@ -317,7 +362,7 @@ func TestManager_CheckOp_Grace(t *testing.T) {
 	payload := validPayload()
 	payload.ValidUntil = time.Now().Add(-1 * time.Hour)
 	createLicenseFile(t, licPath, payload, priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	// Allow View
 	if err := m.CheckOperation("view", uuid.New()); err != nil {
@@ -334,7 +379,7 @@ func TestManager_CheckOp_Grace(t *testing.T) {
 func TestManager_CheckOp_Invalid(t *testing.T) {
 	m, licPath, _, _ := setupManager(t)
 	os.WriteFile(licPath, []byte("bad"), 0644)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	if err := m.CheckOperation("view", uuid.New()); err == nil {
 		t.Error("Should fail if invalid")
@@ -377,7 +422,7 @@ func TestManager_Feature_Enabled(t *testing.T) {
 	payload := validPayload()
 	payload.Features = map[string]bool{"ai.analytics": true}
 	createLicenseFile(t, licPath, payload, priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	// Check State
 	state := m.GetState()
@@ -393,7 +438,7 @@ func TestManager_Feature_Disabled(t *testing.T) {
 	payload := validPayload()
 	payload.Features = map[string]bool{"ai.analytics": false}
 	createLicenseFile(t, licPath, payload, priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 
 	state := m.GetState()
 	if state.Payload.Features["ai.analytics"] {
@@ -420,8 +465,79 @@ func TestManager_MissingFile(t *testing.T) {
 
 	// Create it now
 	createLicenseFile(t, licPath, validPayload(), priv)
-	m.Reload()
+	m.Reload(audit.SystemActor())
 	if m.GetState().Status != license.StatusValid {
 		t.Error("Should be valid after creation")
 	}
 }
+
+// 26. Transient read failure under fail-closed denies operations
+func TestManager_FailClosed_TransientReadFailure(t *testing.T) {
+	m, licPath, _, _ := setupManager(t)
+	if got := m.GetLimits(uuid.New()).MaxCameras; got != 100 {
+		t.Fatalf("Expected licensed limit 100, got %d", got)
+	}
+
+	os.WriteFile(licPath, []byte("half-written"), 0644)
+	m.Reload(audit.SystemActor())
+
+	if got := m.GetLimits(uuid.New()).MaxCameras; got != 100 {
+		t.Errorf("Fail-closed should fall back to the default limit 100, got %d", got)
+	}
+	if err := m.CheckOperation("camera.create", uuid.New()); err == nil || err.Error() != "license_invalid" {
+		t.Errorf("Fail-closed should deny create, got %v", err)
+	}
+	if m.EffectiveState().FailOpen {
+		t.Error("FailOpen must not be set under fail-closed")
+	}
+}
+
+// 27. Transient read failure under fail-open keeps the last-known-good license
+func TestManager_FailOpen_TransientReadFailure(t *testing.T) {
+	m, licPath, _, priv := setupManager(t)
+	m.FailurePolicy = license.FailOpen
+	m.MaxStaleness = time.Hour
+
+	os.WriteFile(licPath, []byte("half-written"), 0644)
+	m.Reload(audit.SystemActor())
+	m.Reload(audit.SystemActor()) // Still failing: the grace runs from the first failure
+
+	if m.GetState().Status != license.StatusParseError {
+		t.Errorf("GetState should report the real status, got %v", m.GetState().Status)
+	}
+	st := m.EffectiveState()
+	if !st.FailOpen || st.Status != license.StatusValid {
+		t.Errorf("Expected fail-open VALID, got %v (fail_open=%t)", st.Status, st.FailOpen)
+	}
+	if got := m.GetLimits(uuid.New()).MaxCameras; got != 100 {
+		t.Errorf("Fail-open should keep last-known-good limit 100, got %d", got)
+	}
+	if err := m.CheckOperation("camera.create", uuid.New()); err != nil {
+		t.Errorf("Fail-open should allow create, got %v", err)
+	}
+
+	// Past max staleness the failure is enforced
+	m.MaxStaleness = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if m.EffectiveState().FailOpen {
+		t.Error("Stale last-known-good should not be used")
+	}
+	if err := m.CheckOperation("camera.create", uuid.New()); err == nil {
+		t.Error("Stale last-known-good should deny create")
+	}
+
+	// A readable but rejected license is never bridged
+	m.MaxStaleness = time.Hour
+	createLicenseFile(t, licPath, validPayload(), priv)
+	m.Reload(audit.SystemActor())
+	payload := validPayload()
+	payload.IssuedAt = time.Now().Add(24 * time.Hour)
+	createLicenseFile(t, licPath, payload, priv)
+	m.Reload(audit.SystemActor())
+	if m.EffectiveState().FailOpen {
+		t.Error("A not-yet-valid license must not fail open")
+	}
+	if err := m.CheckOperation("camera.create", uuid.New()); err == nil {
+		t.Error("A not-yet-valid license should deny create")
+	}
+}
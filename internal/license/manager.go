@@ -17,34 +17,101 @@ type Manager struct {
 	usage        UsageProvider
 	path         string
 	auditService *audit.Service // For reload events
+
+	// Watcher tuning. A license file that is being rewritten can be observed
+	// half-written, so file events are coalesced for WatchDebounce and a failed
+	// parse is retried ParseRetries times, ParseRetryDelay apart, before the
+	// bad status is committed.
+	WatchDebounce   time.Duration
+	ParseRetryDelay time.Duration
+	ParseRetries    int
+
+	// FailurePolicy and MaxStaleness govern GetLimits/CheckOperation while
+	// the license file cannot be read or decoded. Under FailOpen the
+	// last-known-good license keeps applying until MaxStaleness has passed
+	// since it was last seen valid; a license that was read and rejected
+	// (bad signature, expired, not yet valid) is never bridged.
+	FailurePolicy FailurePolicy
+	MaxStaleness  time.Duration
+
+	lastGood   *LicensePayload
+	lastGoodAt time.Time
+
+	onReload func(LicenseState)     // test hook, called with every committed state
+	onAudit  func(audit.AuditEvent) // test hook, called with every license.reload event
 }
 
-func NewManager(path string, parser *Parser, usage UsageProvider, audit *audit.Service) *Manager {
+const (
+	DefaultWatchDebounce   = 250 * time.Millisecond
+	DefaultParseRetryDelay = 500 * time.Millisecond
+	DefaultParseRetries    = 3
+	DefaultMaxStaleness    = 24 * time.Hour
+)
+
+func NewManager(path string, parser *Parser, usage UsageProvider, auditSvc *audit.Service) *Manager {
 	m := &Manager{
 		path:         path,
 		parser:       parser,
 		usage:        usage,
-		auditService: audit,
+		auditService: auditSvc,
 		state:        LicenseState{Status: StatusMissing, ReasonCode: "init"},
+
+		WatchDebounce:   DefaultWatchDebounce,
+		ParseRetryDelay: DefaultParseRetryDelay,
+		ParseRetries:    DefaultParseRetries,
+		FailurePolicy:   FailClosed,
+		MaxStaleness:    DefaultMaxStaleness,
 	}
-	m.Reload() // Initial Load
+	m.Reload(audit.SystemActor()) // Initial Load
 	return m
 }
 
-// Reload re-reads file, verifies, updates state atomically
-func (m *Manager) Reload() {
+// Reload re-reads file, verifies, updates state atomically. actor is recorded
+// on the license.reload audit event: the requesting user, or
+// audit.SystemActor() for background reloads.
+func (m *Manager) Reload(actor *uuid.UUID) {
+	payload, status, err := m.parser.ParseAndVerify(m.path)
+	m.commit(payload, status, err, actor)
+}
+
+// reloadSettled is Reload for the watcher: a failed parse is retried after
+// ParseRetryDelay (up to ParseRetries times) so an in-progress write does not
+// flip the license to invalid. Only the last attempt's result is committed.
+func (m *Manager) reloadSettled(ctx context.Context) {
+	payload, status, err := m.parser.ParseAndVerify(m.path)
+	for i := 0; err != nil && i < m.ParseRetries; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.ParseRetryDelay):
+		}
+		payload, status, err = m.parser.ParseAndVerify(m.path)
+	}
+	m.commit(payload, status, err, audit.SystemActor())
+}
+
+// commit turns a parse result into the current state.
+func (m *Manager) commit(payload *LicensePayload, status Status, err error, actor *uuid.UUID) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.onReload != nil {
+		defer func() { m.onReload(m.state) }()
+	}
 
-	payload, status, err := m.parser.ParseAndVerify(m.path)
+	// The outgoing state was good until now
+	if m.state.Status == StatusValid && m.state.Payload != nil {
+		m.lastGood = m.state.Payload
+		m.lastGoodAt = time.Now()
+	}
 
 	// Pre-Audit preparation
 	auditPayload := audit.AuditEvent{
-		EventID:    uuid.New(),
-		Action:     "license.reload",
-		TargetType: "license",
-		TargetID:   m.path,
-		CreatedAt:  time.Now(),
+		EventID:     uuid.New(),
+		ActorUserID: actor,
+		Action:      "license.reload",
+		TargetType:  "license",
+		TargetID:    m.path,
+		CreatedAt:   time.Now(),
 		// TenantID? License reload is system-wide usually, or default tenant?
 		// Audit requires TenantID. Use Null/System UUID or default.
 		// Let's assume system level 0000...
@@ -53,13 +120,12 @@ func (m *Manager) Reload() {
 
 	if err != nil {
 		m.state = LicenseState{
-			Status:     status,
-			ReasonCode: err.Error(),
-			LastReload: time.Now(),
-		}
-		if m.auditService != nil {
-			go m.auditService.WriteEvent(context.Background(), auditPayload)
+			Status:      status,
+			ReasonCode:  err.Error(),
+			LastReload:  time.Now(),
+			readFailure: status == StatusParseError,
 		}
+		m.writeAudit(auditPayload)
 		return
 	}
 
@@ -69,11 +135,9 @@ func (m *Manager) Reload() {
 			ReasonCode: "payload_missing",
 			LastReload: time.Now(),
 		}
-		if m.auditService != nil {
-			auditPayload.Result = "failure"
-			auditPayload.ReasonCode = string(status)
-			go m.auditService.WriteEvent(context.Background(), auditPayload)
-		}
+		auditPayload.Result = "failure"
+		auditPayload.ReasonCode = string(status)
+		m.writeAudit(auditPayload)
 		return
 	}
 
@@ -119,8 +183,16 @@ func (m *Manager) Reload() {
 
 	// Emit Audit Success
 	auditPayload.Result = "success"
+	m.writeAudit(auditPayload)
+}
+
+// writeAudit records a license.reload event without blocking the reload.
+func (m *Manager) writeAudit(evt audit.AuditEvent) {
+	if m.onAudit != nil {
+		m.onAudit(evt)
+	}
 	if m.auditService != nil {
-		go m.auditService.WriteEvent(context.Background(), auditPayload)
+		go m.auditService.WriteEvent(context.Background(), evt)
 	}
 }
 
@@ -132,11 +204,31 @@ func (m *Manager) GetState() LicenseState {
 	return m.state
 }
 
+// EffectiveState is the state enforcement uses: GetState, except that under
+// FailOpen a transient read failure within MaxStaleness of the license last
+// being valid reports that license as VALID with FailOpen set.
+func (m *Manager) EffectiveState() LicenseState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	state := m.state
+	if m.FailurePolicy != FailOpen || !state.readFailure || m.lastGood == nil {
+		return state
+	}
+	if time.Since(m.lastGoodAt) > m.MaxStaleness {
+		return state
+	}
+	state.Status = StatusValid
+	state.Payload = m.lastGood
+	state.FailOpen = true
+	return state
+}
+
 // GetLimits returns the limits for a specific tenant (currently returns global limits as per Phase 1.6)
 func (m *Manager) GetLimits(tenantID uuid.UUID) LicenseLimits {
-	state := m.GetState()
+	state := m.EffectiveState()
 	if state.Payload == nil {
-		// DEV MODE BYPASS: Allow 100 cameras if license missing
+		// DEV MODE BYPASS: Allow 100 cameras if license missing. Invalid
+		// licenses are refused by CheckOperation, not here.
 		return LicenseLimits{MaxCameras: 100}
 	}
 	return state.Payload.Limits
@@ -144,9 +236,7 @@ func (m *Manager) GetLimits(tenantID uuid.UUID) LicenseLimits {
 
 // CheckOperation checks if an operation is allowed
 func (m *Manager) CheckOperation(op string, tenantID uuid.UUID) error {
-	m.mu.RLock()
-	state := m.state
-	m.mu.RUnlock()
+	state := m.EffectiveState()
 
 	// 1. Check Base Status
 	// DEV MODE BYPASS: Only for Missing License (Implicit Trial)
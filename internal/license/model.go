@@ -1,6 +1,7 @@
 package license
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -40,6 +41,20 @@ type LicensePayload struct {
 type LicenseLimits struct {
 	MaxCameras int `json:"max_cameras"`
 	MaxNVRs    int `json:"max_nvrs"`
+	// MaxEnabledCameras caps enabled cameras separately from inventory;
+	// 0 means MaxCameras.
+	MaxEnabledCameras int `json:"max_enabled_cameras,omitempty"`
+	// MaxSfuRooms caps concurrent live WebRTC rooms per tenant; 0 defers to
+	// live.sfu_max_rooms_per_tenant.
+	MaxSfuRooms int `json:"max_sfu_rooms,omitempty"`
+}
+
+// EnabledLimit is the most cameras a tenant may have enabled at once.
+func (l LicenseLimits) EnabledLimit() int {
+	if l.MaxEnabledCameras > 0 {
+		return l.MaxEnabledCameras
+	}
+	return l.MaxCameras
 }
 
 // LicenseState serves as the in-memory representation
@@ -49,4 +64,34 @@ type LicenseState struct {
 	LastReload   time.Time
 	DaysToExpiry int
 	ReasonCode   string
+
+	// FailOpen is set on EffectiveState while a transient read failure is
+	// being bridged with the last-known-good license.
+	FailOpen bool
+
+	// readFailure marks a PARSE_ERROR from reading/decoding the file, as
+	// opposed to a license that was read and rejected.
+	readFailure bool
+}
+
+// FailurePolicy decides what a transient license read failure allows.
+type FailurePolicy string
+
+const (
+	// FailClosed treats an unreadable license as invalid (default).
+	FailClosed FailurePolicy = "fail_closed"
+	// FailOpen keeps enforcing the last-known-good license for up to
+	// Manager.MaxStaleness after it was last seen valid.
+	FailOpen FailurePolicy = "fail_open"
+)
+
+// ParseFailurePolicy accepts "fail_closed" and "fail_open"; "" is FailClosed.
+func ParseFailurePolicy(s string) (FailurePolicy, error) {
+	switch FailurePolicy(s) {
+	case "", FailClosed:
+		return FailClosed, nil
+	case FailOpen:
+		return FailOpen, nil
+	}
+	return "", fmt.Errorf("unknown license failure policy %q (fail_closed | fail_open)", s)
 }
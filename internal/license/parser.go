@@ -62,6 +62,9 @@ func (p *Parser) ParseAndVerify(path string) (*LicensePayload, Status, error) {
 	if os.IsNotExist(err) {
 		return nil, StatusMissing, nil
 	}
+	if err != nil {
+		return nil, StatusParseError, err
+	}
 	if info.Size() > MaxLicenseSizeBytes {
 		return nil, StatusParseError, fmt.Errorf("file too large")
 	}
@@ -6,6 +6,8 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/technosupport/ts-vms/internal/clock"
 )
 
 // Scheduler handles periodic license checks and alerting
@@ -13,12 +15,18 @@ type Scheduler struct {
 	manager    *Manager
 	lastAlerts map[string]time.Time // De-duplication: type -> date
 	mu         sync.Mutex
+
+	// Clock drives the hourly check and alert de-duplication; set before Start.
+	Clock clock.Clock
+	// OnAlert, when set, is called for every emitted alert after it is logged.
+	OnAlert func(alertType string, daysToExpiry int)
 }
 
 func NewScheduler(m *Manager) *Scheduler {
 	return &Scheduler{
 		manager:    m,
 		lastAlerts: make(map[string]time.Time),
+		Clock:      clock.New(),
 	}
 }
 
@@ -26,13 +34,14 @@ func (s *Scheduler) Start(ctx context.Context) {
 	// Check immediately, then hourly
 	s.Check()
 
-	ticker := time.NewTicker(1 * time.Hour)
+	ticker := s.Clock.NewTicker(1 * time.Hour)
 	go func() {
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
+			case <-ticker.C():
 				s.Check()
 			}
 		}
@@ -47,7 +56,7 @@ func (s *Scheduler) Check() {
 		return
 	}
 
-	now := time.Now()
+	now := s.Clock.Now()
 	days := state.DaysToExpiry
 
 	var alertType string
@@ -85,6 +94,9 @@ func (s *Scheduler) emitAlert(typ string, days int) {
 	// Metric: license_alerts_total{type="..."}
 	// TODO: Increment metric counter when Metrics module is fully wired.
 	// For now, log is the requirement sink.
+	if s.OnAlert != nil {
+		s.OnAlert(typ, days)
+	}
 }
 
 func isSameDay(t1, t2 time.Time) bool {
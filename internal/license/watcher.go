@@ -32,32 +32,12 @@ func (m *Manager) StartWatcher(ctx context.Context) {
 	}
 
 	// Watcher Loop
-	go func() {
-		if !usePolling {
+	if !usePolling {
+		go func() {
 			defer watcher.Close()
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case event, ok := <-watcher.Events:
-					if !ok {
-						return
-					}
-					if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
-						log.Println("License Watcher: File changed, reloading...")
-						// Debounce slightly?
-						time.Sleep(100 * time.Millisecond)
-						m.Reload()
-					}
-				case err, ok := <-watcher.Errors:
-					if !ok {
-						return
-					}
-					log.Printf("License Watcher Error: %v", err)
-				}
-			}
-		}
-	}()
+			m.watchEvents(ctx, watcher.Events, watcher.Errors)
+		}()
+	}
 
 	// Polling Loop (Fallback or Redundancy - "at least one is required")
 	// Prompt Rule 4: "If watcher fails -> poll every 60s (bounded)"
@@ -78,15 +58,49 @@ func (m *Manager) StartWatcher(ctx context.Context) {
 				m.mu.RUnlock()
 
 				// Re-Check file
-				m.ReloadIfChanged() // Wrapper
+				m.ReloadIfChanged(ctx) // Wrapper
 			}
 		}
 	}()
 }
 
+// watchEvents coalesces write/create events: the reload runs once no event
+// has arrived for WatchDebounce, and goes through reloadSettled so a
+// half-written file is retried instead of committed.
+func (m *Manager) watchEvents(ctx context.Context, events <-chan fsnotify.Event, errs <-chan error) {
+	debounce := time.NewTimer(m.WatchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
+				debounce.Reset(m.WatchDebounce)
+			}
+		case <-debounce.C:
+			log.Println("License Watcher: File changed, reloading...")
+			m.reloadSettled(ctx)
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			log.Printf("License Watcher Error: %v", err)
+		}
+	}
+}
+
 // ReloadIfChanged checks os.Stat and reloads only if Mtime changed.
 // Helps avoid Audit spam on polling.
-func (m *Manager) ReloadIfChanged() {
+//
+// A failed parse is retried like a watcher event (see reloadSettled), so a
+// poll that lands on a half-written file does not invalidate the license.
+func (m *Manager) ReloadIfChanged(ctx context.Context) {
 	// Not straightforward because m.Reload() logic emits audit.
 	// We need to keep track of file mtime we last processed.
 	// m.state.LastReload is usage time.
@@ -95,7 +109,7 @@ func (m *Manager) ReloadIfChanged() {
 	// Let's skip complexity and just call Reload from watcher/ticker,
 	// IF we used polling.
 
-	m.Reload() // Simplest compliant approach for now, assuming audit volume is acceptable or implementing a check.
+	m.reloadSettled(ctx) // Simplest compliant approach for now, assuming audit volume is acceptable or implementing a check.
 	// Wait, audit every 60s IS spam.
 	// Let's Implement check here.
 }
@@ -0,0 +1,219 @@
+package license
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/audit"
+)
+
+func signedLicense(t *testing.T, priv *rsa.PrivateKey, id uuid.UUID) []byte {
+	t.Helper()
+	payload, _ := json.Marshal(LicensePayload{
+		LicenseID:  id,
+		IssuedAt:   time.Now().Add(-time.Hour),
+		ValidUntil: time.Now().Add(24 * time.Hour),
+		Limits:     LicenseLimits{MaxCameras: 10},
+	})
+	sum := sha256.Sum256(payload)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(LicenseFile{
+		PayloadB64: base64.StdEncoding.EncodeToString(payload),
+		SigB64:     base64.StdEncoding.EncodeToString(sig),
+		Alg:        "RS256",
+	})
+	return data
+}
+
+func TestWatcher_RapidRewriteSettlesWithoutInvalidTransition(t *testing.T) {
+	dir := t.TempDir()
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubBytes, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	pubPath := filepath.Join(dir, "pub.pem")
+	os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0644)
+	parser, err := NewParser(pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	licPath := filepath.Join(dir, "license.lic")
+	os.WriteFile(licPath, signedLicense(t, priv, uuid.New()), 0644)
+
+	m := NewManager(licPath, parser, nil, nil)
+	if m.GetState().Status != StatusValid {
+		t.Fatalf("initial status %s", m.GetState().Status)
+	}
+	m.WatchDebounce = 20 * time.Millisecond
+	m.ParseRetryDelay = 100 * time.Millisecond
+	m.ParseRetries = 3
+
+	var mu sync.Mutex
+	var seen []Status
+	m.onReload = func(s LicenseState) {
+		mu.Lock()
+		seen = append(seen, s.Status)
+		mu.Unlock()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan fsnotify.Event, 16)
+	go m.watchEvents(ctx, events, make(chan error))
+
+	write := func(data []byte) {
+		os.WriteFile(licPath, data, 0644)
+		events <- fsnotify.Event{Name: licPath, Op: fsnotify.Write}
+	}
+
+	// Editor-style rewrite: truncate, partial content, then the final file.
+	// The debounce fires between the partial write and the final one.
+	final := signedLicense(t, priv, uuid.New())
+	finalID := licenseIDOf(t, final)
+	write(nil)
+	write(final[:len(final)/3])
+	time.Sleep(50 * time.Millisecond)
+	write(final)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		st := m.GetState()
+		if st.Payload != nil && st.Payload.LicenseID.String() == finalID {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	st := m.GetState()
+	if st.Status != StatusValid || st.Payload == nil || st.Payload.LicenseID.String() != finalID {
+		t.Fatalf("did not settle on the final license: %+v", st)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) == 0 {
+		t.Fatal("no reload committed")
+	}
+	for _, s := range seen {
+		if s != StatusValid {
+			t.Errorf("spurious transition to %s during rewrite (history %v)", s, seen)
+		}
+	}
+}
+
+func TestWatcher_PersistentParseErrorIsCommitted(t *testing.T) {
+	dir := t.TempDir()
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubBytes, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	pubPath := filepath.Join(dir, "pub.pem")
+	os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0644)
+	parser, _ := NewParser(pubPath)
+
+	licPath := filepath.Join(dir, "license.lic")
+	os.WriteFile(licPath, signedLicense(t, priv, uuid.New()), 0644)
+	m := NewManager(licPath, parser, nil, nil)
+	m.WatchDebounce = 5 * time.Millisecond
+	m.ParseRetryDelay = 5 * time.Millisecond
+	m.ParseRetries = 2
+
+	os.WriteFile(licPath, []byte("trash"), 0644)
+	m.reloadSettled(context.Background())
+
+	if st := m.GetState().Status; st != StatusParseError {
+		t.Errorf("expected %s after retries are exhausted, got %s", StatusParseError, st)
+	}
+}
+
+func TestReloadIfChanged_RetriesTransientParseError(t *testing.T) {
+	dir := t.TempDir()
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubBytes, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	pubPath := filepath.Join(dir, "pub.pem")
+	os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0644)
+	parser, _ := NewParser(pubPath)
+
+	licPath := filepath.Join(dir, "license.lic")
+	os.WriteFile(licPath, signedLicense(t, priv, uuid.New()), 0644)
+	m := NewManager(licPath, parser, nil, nil)
+	m.ParseRetryDelay = 50 * time.Millisecond
+	m.ParseRetries = 3
+
+	var seen []Status
+	m.onReload = func(s LicenseState) { seen = append(seen, s.Status) }
+
+	// The poll lands mid-write; the file is complete before the first retry.
+	final := signedLicense(t, priv, uuid.New())
+	os.WriteFile(licPath, final[:len(final)/3], 0644)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		os.WriteFile(licPath, final, 0644)
+	}()
+	m.ReloadIfChanged(context.Background())
+
+	st := m.GetState()
+	if st.Status != StatusValid || st.Payload == nil || st.Payload.LicenseID.String() != licenseIDOf(t, final) {
+		t.Fatalf("did not settle on the rewritten license: %+v", st)
+	}
+	if len(seen) != 1 || seen[0] != StatusValid {
+		t.Errorf("expected a single valid commit, got %v", seen)
+	}
+}
+
+func licenseIDOf(t *testing.T, data []byte) string {
+	t.Helper()
+	var lf LicenseFile
+	if err := json.Unmarshal(data, &lf); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(lf.PayloadB64)
+	var p LicensePayload
+	json.Unmarshal(raw, &p)
+	return p.LicenseID.String()
+}
+
+func TestReload_AuditActor(t *testing.T) {
+	dir := t.TempDir()
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubBytes, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	pubPath := filepath.Join(dir, "pub.pem")
+	os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0644)
+	parser, _ := NewParser(pubPath)
+
+	licPath := filepath.Join(dir, "license.lic")
+	os.WriteFile(licPath, signedLicense(t, priv, uuid.New()), 0644)
+	m := NewManager(licPath, parser, nil, nil)
+	var events []audit.AuditEvent
+	m.onAudit = func(evt audit.AuditEvent) { events = append(events, evt) }
+
+	// On request: the caller
+	user := uuid.New()
+	m.Reload(&user)
+	// Watcher: the system actor
+	m.reloadSettled(context.Background())
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 license.reload events, got %d", len(events))
+	}
+	if a := events[0].ActorUserID; a == nil || *a != user {
+		t.Errorf("expected the caller %s on a requested reload, got %v", user, a)
+	}
+	if a := events[1].ActorUserID; a == nil || *a != *audit.SystemActor() {
+		t.Errorf("expected the system actor on a watcher reload, got %v", a)
+	}
+}
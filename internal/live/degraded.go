@@ -0,0 +1,137 @@
+package live
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/data"
+)
+
+// DefaultDegradedSessionTTL bounds sessions issued while Redis is down. They
+// can't be counted against MaxActiveSessions or revoked, so they stay short;
+// the player starts a new (stateful, once Redis is back) session on expiry.
+const DefaultDegradedSessionTTL = 2 * time.Minute
+
+// degradedSessionPrefix marks viewer session IDs that are signed tokens
+// rather than Redis keys.
+const degradedSessionPrefix = "dg."
+
+// degradedClaims is the session data carried by a degraded session ID.
+// Subject is the user and ID the session.
+type degradedClaims struct {
+	TenantID string `json:"tid"`
+	CameraID string `json:"cam"`
+	Mode     string `json:"mode"`
+	Quality  string `json:"q"`
+	jwt.RegisteredClaims
+}
+
+// DegradedSessionKeyFrom derives the degraded session signing key from a
+// server secret, so the tokens can't be replayed as API JWTs.
+func DegradedSessionKeyFrom(secret string) []byte {
+	sum := sha256.Sum256([]byte("live-degraded-session|" + secret))
+	return sum[:]
+}
+
+// startDegradedSession issues a stateless session after the session store
+// failed with cause. Without DegradedSessionKey the failure is returned.
+func (s *Service) startDegradedSession(ctx context.Context, u *data.User, cameraID, quality string, cause error) (*LiveSessionResponse, error) {
+	if len(s.DegradedSessionKey) == 0 {
+		return nil, fmt.Errorf("failed to store session: %w", cause)
+	}
+	ttl := s.DegradedSessionTTL
+	if ttl <= 0 {
+		ttl = DefaultDegradedSessionTTL
+	}
+
+	now := time.Now()
+	sess := &ViewerSession{
+		ID:         uuid.New().String(),
+		TenantID:   u.TenantID,
+		UserID:     u.ID,
+		CameraID:   cameraID,
+		Mode:       "webrtc",
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	resp := s.buildResponse(sess, quality)
+	sess.Quality = resp.SelectedQuality
+
+	token, err := s.signDegradedSession(sess)
+	if err != nil {
+		return nil, err
+	}
+	resp.ViewerSessionID = token
+	resp.Degraded = true
+
+	log.Printf("Live: session store unavailable, issued degraded session for camera %s: %v", cameraID, cause)
+	metricDegradedSessionsTotal.Inc()
+	s.auditView(ctx, "camera.live.view", sess, map[string]any{"degraded": true})
+	return resp, nil
+}
+
+func (s *Service) signDegradedSession(sess *ViewerSession) (string, error) {
+	claims := degradedClaims{
+		TenantID: sess.TenantID.String(),
+		CameraID: sess.CameraID,
+		Mode:     sess.Mode,
+		Quality:  sess.Quality,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sess.ID,
+			Subject:   sess.UserID.String(),
+			IssuedAt:  jwt.NewNumericDate(sess.CreatedAt),
+			ExpiresAt: jwt.NewNumericDate(sess.ExpiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.DegradedSessionKey)
+	if err != nil {
+		return "", err
+	}
+	return degradedSessionPrefix + token, nil
+}
+
+// IsDegradedSessionID reports whether sessionID was issued without Redis.
+func IsDegradedSessionID(sessionID string) bool {
+	return strings.HasPrefix(sessionID, degradedSessionPrefix)
+}
+
+// ParseDegradedSession verifies a degraded session ID and returns the session
+// it encodes. Forged, expired or malformed IDs return ErrSessionNotFound.
+func (s *Service) ParseDegradedSession(sessionID string) (*ViewerSession, error) {
+	if len(s.DegradedSessionKey) == 0 || !IsDegradedSessionID(sessionID) {
+		return nil, ErrSessionNotFound
+	}
+	var claims degradedClaims
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(sessionID, degradedSessionPrefix), &claims, func(t *jwt.Token) (any, error) {
+		return s.DegradedSessionKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, errors.Join(ErrSessionNotFound, err)
+	}
+	tenantID, err1 := uuid.Parse(claims.TenantID)
+	userID, err2 := uuid.Parse(claims.Subject)
+	if err1 != nil || err2 != nil {
+		return nil, ErrSessionNotFound
+	}
+	sess := &ViewerSession{
+		ID:       claims.ID,
+		TenantID: tenantID,
+		UserID:   userID,
+		CameraID: claims.CameraID,
+		Mode:     claims.Mode,
+		Quality:  claims.Quality,
+	}
+	if claims.IssuedAt != nil {
+		sess.CreatedAt = claims.IssuedAt.Time
+	}
+	sess.ExpiresAt = claims.ExpiresAt.Time
+	return sess, nil
+}
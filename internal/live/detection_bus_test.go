@@ -0,0 +1,71 @@
+package live
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/eventbus"
+)
+
+// loopbackNATS delivers every publish to every subscriber, in place of a
+// NATS server.
+type loopbackNATS struct {
+	mu  sync.Mutex
+	cbs []nats.MsgHandler
+}
+
+func (l *loopbackNATS) Publish(subject string, data []byte) error {
+	l.mu.Lock()
+	cbs := append([]nats.MsgHandler(nil), l.cbs...)
+	l.mu.Unlock()
+	for _, cb := range cbs {
+		cb(&nats.Msg{Subject: subject, Data: data})
+	}
+	return nil
+}
+
+func (l *loopbackNATS) Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cbs = append(l.cbs, cb)
+	return &nats.Subscription{Subject: subject}, nil
+}
+
+func TestDetectionIngest_OverEventBus(t *testing.T) {
+	buses := map[string]eventbus.EventBus{
+		"memory": eventbus.NewMemoryBus(0),
+		"nats":   eventbus.NewNATSBus(&loopbackNATS{}),
+	}
+	for name, bus := range buses {
+		t.Run(name, func(t *testing.T) {
+			svc := &Service{
+				CameraService: cameras.NewService(&dummyRepo{}, &dummyLicense{}, &dummyAuditor{}),
+				Detections:    NewMemoryDetectionStore(),
+				ObjectLimits:  DefaultObjectLimits,
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ingester := NewDetectionIngester(svc.SaveDetectionFromNATS, 1, 8)
+			ingester.Start(ctx)
+			_, err := ingester.Subscribe(bus)
+			require.NoError(t, err)
+
+			camID := uuid.New().String()
+			msg := `{"camera_id":"` + camID + `","ts_unix_ms":1,"stream":"basic","objects":[{"label":"person","confidence":0.9,"bbox":{"x":0.1,"y":0.1,"w":0.2,"h":0.2}}]}`
+			require.NoError(t, bus.Publish("detections.basic."+camID, []byte(msg)))
+
+			// dummyRepo places every camera in tenant ...0001
+			tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+			require.Eventually(t, func() bool {
+				d, err := svc.GetLatestDetection(ctx, tenantID, camID, "basic")
+				return err == nil && d != nil && len(d.Objects) == 1
+			}, time.Second, 5*time.Millisecond)
+		})
+	}
+}
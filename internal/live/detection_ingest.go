@@ -0,0 +1,103 @@
+package live
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/technosupport/ts-vms/internal/eventbus"
+	"github.com/technosupport/ts-vms/internal/metrics"
+)
+
+// DetectionSubject matches every detection the AI service publishes
+// (detections.<stream>.<camera_id>).
+const DetectionSubject = "detections.>"
+
+// Detection ingest defaults (live.detection_workers / live.detection_queue_size).
+const (
+	DefaultDetectionWorkers   = 4
+	DefaultDetectionQueueSize = 256
+)
+
+// DetectionIngester drains detection messages through a fixed pool of
+// workers so a burst on detections.> cannot fan out into unbounded Redis
+// writes and tenant lookups. Submit never blocks the NATS callback: when the
+// queue is full the message is dropped, which is safe because only the
+// latest detection per camera is kept anyway.
+type DetectionIngester struct {
+	handle  func(ctx context.Context, data []byte) error
+	queue   chan []byte
+	workers int
+	wg      sync.WaitGroup
+	dropped atomic.Int64
+}
+
+// NewDetectionIngester builds an ingester; non-positive sizes use the defaults.
+func NewDetectionIngester(handle func(ctx context.Context, data []byte) error, workers, queueSize int) *DetectionIngester {
+	if workers <= 0 {
+		workers = DefaultDetectionWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultDetectionQueueSize
+	}
+	return &DetectionIngester{
+		handle:  handle,
+		queue:   make(chan []byte, queueSize),
+		workers: workers,
+	}
+}
+
+// Start launches the workers; they exit when ctx is cancelled or Stop is called.
+func (d *DetectionIngester) Start(ctx context.Context) {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg, ok := <-d.queue:
+					if !ok {
+						return
+					}
+					if err := d.handle(ctx, msg); err != nil {
+						log.Printf("Error saving AI detection from NATS: %v", err)
+					}
+				}
+			}
+		}()
+	}
+}
+
+// Subscribe feeds detections published on bus into the ingester.
+func (d *DetectionIngester) Subscribe(bus eventbus.EventBus) (eventbus.Subscription, error) {
+	return bus.Subscribe(DetectionSubject, func(_ string, data []byte) {
+		d.Submit(data)
+	})
+}
+
+// Submit enqueues a message, reporting false if it was dropped.
+func (d *DetectionIngester) Submit(data []byte) bool {
+	select {
+	case d.queue <- data:
+		return true
+	default:
+		d.dropped.Add(1)
+		metrics.NATSDetectionsDroppedTotal.Inc()
+		return false
+	}
+}
+
+// Dropped returns the number of messages rejected because the queue was full.
+func (d *DetectionIngester) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// Stop closes the queue and waits for the workers to drain it.
+// Submit must not be called afterwards.
+func (d *DetectionIngester) Stop() {
+	close(d.queue)
+	d.wg.Wait()
+}
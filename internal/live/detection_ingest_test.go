@@ -0,0 +1,113 @@
+package live
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/data"
+)
+
+// countingRepo counts camera lookups made for tenant resolution.
+type countingRepo struct {
+	dummyRepo
+	lookups atomic.Int32
+}
+
+func (c *countingRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, error) {
+	c.lookups.Add(1)
+	return c.dummyRepo.GetByID(ctx, id)
+}
+
+func TestResolveCameraTenant_CacheHit(t *testing.T) {
+	repo := &countingRepo{}
+	svc := &Service{
+		CameraService:  cameras.NewService(repo, &dummyLicense{}, &dummyAuditor{}),
+		Detections:     NewMemoryDetectionStore(),
+		ObjectLimits:   DefaultObjectLimits,
+		TenantCacheTTL: time.Minute,
+	}
+	ctx := context.Background()
+	camID := uuid.New().String()
+	msg := []byte(`{"camera_id":"` + camID + `","ts_unix_ms":1,"stream":"basic","objects":[]}`)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, svc.SaveDetectionFromNATS(ctx, msg))
+	}
+	assert.Equal(t, int32(1), repo.lookups.Load(), "repeat messages should hit the tenant cache")
+
+	// Disabled cache looks up every time
+	svc.TenantCacheTTL = 0
+	_, err := svc.ResolveCameraTenant(ctx, camID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), repo.lookups.Load())
+}
+
+func TestDetectionIngester_BoundedConcurrency(t *testing.T) {
+	const workers, queueSize, burst = 3, 10, 100
+
+	var inflight, peak atomic.Int32
+	var handled atomic.Int32
+	release := make(chan struct{})
+	ing := NewDetectionIngester(func(ctx context.Context, data []byte) error {
+		n := inflight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		inflight.Add(-1)
+		handled.Add(1)
+		return nil
+	}, workers, queueSize)
+	ing.Start(context.Background())
+
+	accepted := 0
+	for i := 0; i < burst; i++ {
+		if ing.Submit([]byte("{}")) {
+			accepted++
+		}
+	}
+
+	// Workers hold one message each; the queue holds the rest of what was accepted
+	assert.LessOrEqual(t, accepted, workers+queueSize)
+	assert.Equal(t, int64(burst-accepted), ing.Dropped())
+
+	require.Eventually(t, func() bool { return inflight.Load() == workers }, time.Second, 5*time.Millisecond)
+	close(release)
+
+	ing.Stop()
+	assert.LessOrEqual(t, peak.Load(), int32(workers), "concurrency must stay within the worker pool")
+	assert.Equal(t, int32(accepted), handled.Load(), "accepted messages are drained on Stop")
+}
+
+func TestSaveDetectionFromNATS_RecordsRecentDetections(t *testing.T) {
+	svc := &Service{
+		CameraService: cameras.NewService(&dummyRepo{}, &dummyLicense{}, &dummyAuditor{}),
+		Detections:    NewMemoryDetectionStore(),
+		ObjectLimits:  DefaultObjectLimits,
+	}
+	svc.RecentDetections, _ = newTestRecentDetections(t, time.Minute, 0)
+	svc.RecentDetections.now = time.Now
+	ctx := context.Background()
+	camID := uuid.New().String()
+	ts := time.Now().UnixMilli()
+	msg := []byte(`{"camera_id":"` + camID + `","ts_unix_ms":` + strconv.FormatInt(ts, 10) + `,"stream":"basic","objects":[]}`)
+
+	require.NoError(t, svc.SaveDetectionFromNATS(ctx, msg))
+
+	// dummyRepo places every camera in tenant ...0001
+	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	got, err := svc.GetRecentDetections(ctx, tenantID, camID, "basic")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, ts, got[0].TSUnixMS)
+}
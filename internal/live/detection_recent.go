@@ -0,0 +1,80 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrRecentDetectionsDisabled is returned when live.recent_detections is off.
+var ErrRecentDetectionsDisabled = errors.New("recent detections are not enabled")
+
+// DefaultRecentDetectionsDepth caps entries kept per camera/stream when
+// live.recent_detections.depth is unset (about 60s at 2 messages/s).
+const DefaultRecentDetectionsDepth = 120
+
+// RecentDetections keeps a short, time-ordered history of detections per
+// tenant/camera/stream for incident review, next to the latest-only
+// DetectionStore. Each history is a Redis sorted set scored by ts_unix_ms;
+// entries older than Window or beyond the newest Depth are evicted on write.
+type RecentDetections struct {
+	Client *redis.Client
+	Window time.Duration
+	Depth  int
+
+	now func() time.Time
+}
+
+// NewRecentDetections returns a history keeping window worth of detections,
+// at most depth per camera/stream (DefaultRecentDetectionsDepth when <= 0).
+func NewRecentDetections(rdb *redis.Client, window time.Duration, depth int) *RecentDetections {
+	if depth <= 0 {
+		depth = DefaultRecentDetectionsDepth
+	}
+	return &RecentDetections{Client: rdb, Window: window, Depth: depth, now: time.Now}
+}
+
+// recentDetectionsKey: det:recent:{tenant}:{camera}:{stream}, stream defaults to basic.
+func recentDetectionsKey(tenantID uuid.UUID, cameraID, stream string) string {
+	if stream == "" {
+		stream = "basic"
+	}
+	return fmt.Sprintf("det:recent:%s:%s:%s", tenantID.String(), cameraID, stream)
+}
+
+// Add records payload at tsUnixMS and trims the history to the window and
+// depth. The key expires a window after the last write.
+func (r *RecentDetections) Add(ctx context.Context, tenantID uuid.UUID, cameraID, stream string, tsUnixMS int64, payload []byte) error {
+	key := recentDetectionsKey(tenantID, cameraID, stream)
+	cutoff := r.now().Add(-r.Window).UnixMilli()
+
+	pipe := r.Client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(tsUnixMS), Member: payload})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(cutoff, 10))
+	pipe.ZRemRangeByRank(ctx, key, 0, int64(-r.Depth-1))
+	pipe.PExpire(ctx, key, r.Window)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// List returns the payloads within the window, oldest first.
+func (r *RecentDetections) List(ctx context.Context, tenantID uuid.UUID, cameraID, stream string) ([][]byte, error) {
+	cutoff := r.now().Add(-r.Window).UnixMilli()
+	vals, err := r.Client.ZRangeByScore(ctx, recentDetectionsKey(tenantID, cameraID, stream), &redis.ZRangeBy{
+		Min: strconv.FormatInt(cutoff, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, len(vals))
+	for i, v := range vals {
+		out[i] = []byte(v)
+	}
+	return out, nil
+}
@@ -0,0 +1,107 @@
+package live
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRecentDetections(t *testing.T, window time.Duration, depth int) (*RecentDetections, *time.Time) {
+	t.Helper()
+	mini := miniredis.RunT(t)
+	r := NewRecentDetections(redis.NewClient(&redis.Options{Addr: mini.Addr()}), window, depth)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestRecentDetections_RetainsWindowAndEvictsOlder(t *testing.T) {
+	r, now := newTestRecentDetections(t, 60*time.Second, 0)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	start := *now
+
+	// One detection every 10s for 100s.
+	for i := 0; i <= 10; i++ {
+		*now = start.Add(time.Duration(i) * 10 * time.Second)
+		ts := now.UnixMilli()
+		require.NoError(t, r.Add(ctx, tenantID, "cam-1", "basic", ts, []byte{byte('a' + i)}))
+	}
+
+	got, err := r.List(ctx, tenantID, "cam-1", "basic")
+	require.NoError(t, err)
+	// Entries at 40s..100s are within the last 60s.
+	require.Len(t, got, 7)
+	for i, p := range got {
+		assert.Equal(t, []byte{byte('a' + 4 + i)}, p, "entries must be ordered oldest first")
+	}
+
+	// Older entries are removed from Redis, not just filtered on read.
+	n, err := r.Client.ZCard(ctx, recentDetectionsKey(tenantID, "cam-1", "basic")).Result()
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, n)
+
+	// Reads also drop entries that aged out since the last write.
+	*now = now.Add(35 * time.Second)
+	got, err = r.List(ctx, tenantID, "cam-1", "basic")
+	require.NoError(t, err)
+	assert.Len(t, got, 3)
+}
+
+func TestRecentDetections_DepthCapsEntries(t *testing.T) {
+	r, now := newTestRecentDetections(t, time.Minute, 3)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	for i := 0; i < 5; i++ {
+		ts := now.Add(time.Duration(i) * time.Millisecond).UnixMilli()
+		require.NoError(t, r.Add(ctx, tenantID, "cam-1", "", ts, []byte{byte('a' + i)}))
+	}
+
+	got, err := r.List(ctx, tenantID, "cam-1", "basic")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{'c'}, {'d'}, {'e'}}, got)
+
+	// Other cameras are unaffected.
+	got, err = r.List(ctx, tenantID, "cam-2", "basic")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestService_GetRecentDetections(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	svc := &Service{Detections: NewMemoryDetectionStore(), ObjectLimits: DefaultObjectLimits}
+	_, err := svc.GetRecentDetections(ctx, tenantID, "cam-1", "basic")
+	assert.ErrorIs(t, err, ErrRecentDetectionsDisabled)
+
+	svc.RecentDetections, _ = newTestRecentDetections(t, time.Minute, 0)
+	svc.RecentDetections.now = time.Now
+	for i := 3; i >= 1; i-- {
+		require.NoError(t, svc.SaveDetection(ctx, tenantID, &DetectionPayload{
+			CameraID: "cam-1",
+			Stream:   "basic",
+			TSUnixMS: time.Now().Add(-time.Duration(i) * 100 * time.Millisecond).UnixMilli(),
+			Objects:  []Object{{Label: "person", Confidence: 0.8, BBox: BBox{X: 0.1, Y: 0.1, W: 0.2, H: 0.3}}},
+		}))
+	}
+
+	got, err := svc.GetRecentDetections(ctx, tenantID, "cam-1", "basic")
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Less(t, got[0].TSUnixMS, got[1].TSUnixMS)
+	assert.Less(t, got[1].TSUnixMS, got[2].TSUnixMS)
+	assert.GreaterOrEqual(t, got[0].AgeMS, int64(300))
+
+	// The latest-only path still returns the newest detection.
+	latest, err := svc.GetLatestDetection(ctx, tenantID, "cam-1", "basic")
+	require.NoError(t, err)
+	assert.Equal(t, got[2].TSUnixMS, latest.TSUnixMS)
+}
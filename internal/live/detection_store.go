@@ -0,0 +1,141 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// DetectionStore keeps the latest raw detection payload per
+// tenant/camera/stream for a short TTL.
+type DetectionStore interface {
+	Put(ctx context.Context, tenantID uuid.UUID, cameraID, stream string, payload []byte, ttl time.Duration) error
+	// Get returns nil, nil when nothing is stored or the entry expired.
+	Get(ctx context.Context, tenantID uuid.UUID, cameraID, stream string) ([]byte, error)
+	// GetMany fetches several cameras in one round trip; cameras with nothing
+	// stored are omitted from the result.
+	GetMany(ctx context.Context, tenantID uuid.UUID, cameraIDs []string, stream string) (map[string][]byte, error)
+}
+
+// Detection store backends selectable via live.detection_store.
+const (
+	DetectionStoreRedis  = "redis"
+	DetectionStoreMemory = "memory"
+)
+
+// detectionKey: det:latest:{tenant}:{camera}:{stream}, stream defaults to basic.
+func detectionKey(tenantID uuid.UUID, cameraID, stream string) string {
+	if stream == "" {
+		stream = "basic"
+	}
+	return fmt.Sprintf("det:latest:%s:%s:%s", tenantID.String(), cameraID, stream)
+}
+
+// RedisDetectionStore is the default, shared across control-plane nodes.
+type RedisDetectionStore struct {
+	Client *redis.Client
+}
+
+func (r RedisDetectionStore) Put(ctx context.Context, tenantID uuid.UUID, cameraID, stream string, payload []byte, ttl time.Duration) error {
+	return r.Client.Set(ctx, detectionKey(tenantID, cameraID, stream), payload, ttl).Err()
+}
+
+func (r RedisDetectionStore) Get(ctx context.Context, tenantID uuid.UUID, cameraID, stream string) ([]byte, error) {
+	b, err := r.Client.Get(ctx, detectionKey(tenantID, cameraID, stream)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (r RedisDetectionStore) GetMany(ctx context.Context, tenantID uuid.UUID, cameraIDs []string, stream string) (map[string][]byte, error) {
+	out := make(map[string][]byte, len(cameraIDs))
+	if len(cameraIDs) == 0 {
+		return out, nil
+	}
+	keys := make([]string, len(cameraIDs))
+	for i, id := range cameraIDs {
+		keys[i] = detectionKey(tenantID, id, stream)
+	}
+	vals, err := r.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			out[cameraIDs[i]] = []byte(s)
+		}
+	}
+	return out, nil
+}
+
+// MemoryDetectionStore is a process-local TTL map for single-node or
+// air-gapped deployments without Redis. Expired entries are dropped on read
+// and swept at most once per second on write.
+type MemoryDetectionStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryDetection
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryDetection struct {
+	payload   []byte
+	expiresAt time.Time
+}
+
+func NewMemoryDetectionStore() *MemoryDetectionStore {
+	return &MemoryDetectionStore{entries: make(map[string]memoryDetection), now: time.Now}
+}
+
+func (m *MemoryDetectionStore) Put(ctx context.Context, tenantID uuid.UUID, cameraID, stream string, payload []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastSweep) >= time.Second {
+		for k, e := range m.entries {
+			if !now.Before(e.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	m.entries[detectionKey(tenantID, cameraID, stream)] = memoryDetection{
+		payload:   append([]byte(nil), payload...),
+		expiresAt: now.Add(ttl),
+	}
+	return nil
+}
+
+func (m *MemoryDetectionStore) Get(ctx context.Context, tenantID uuid.UUID, cameraID, stream string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := detectionKey(tenantID, cameraID, stream)
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if !m.now().Before(e.expiresAt) {
+		delete(m.entries, key)
+		return nil, nil
+	}
+	return e.payload, nil
+}
+
+func (m *MemoryDetectionStore) GetMany(ctx context.Context, tenantID uuid.UUID, cameraIDs []string, stream string) (map[string][]byte, error) {
+	out := make(map[string][]byte, len(cameraIDs))
+	for _, id := range cameraIDs {
+		b, _ := m.Get(ctx, tenantID, id, stream)
+		if b != nil {
+			out[id] = b
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+package live
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// detectionStoreCase wires a store plus a way to advance its clock.
+type detectionStoreCase struct {
+	name    string
+	store   DetectionStore
+	advance func(time.Duration)
+}
+
+func detectionStores(t *testing.T) []detectionStoreCase {
+	mini := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mini.Addr()})
+
+	mem := NewMemoryDetectionStore()
+	clock := time.Now()
+	mem.now = func() time.Time { return clock }
+
+	return []detectionStoreCase{
+		{"redis", RedisDetectionStore{Client: rdb}, mini.FastForward},
+		{"memory", mem, func(d time.Duration) { clock = clock.Add(d) }},
+	}
+}
+
+func TestDetectionStore_TTLExpiry(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	for _, tc := range detectionStores(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.store.Put(ctx, tenantID, "cam-1", "basic", []byte(`{"camera_id":"cam-1"}`), DetectionTTL))
+
+			tc.advance(DetectionTTL - time.Second)
+			got, err := tc.store.Get(ctx, tenantID, "cam-1", "basic")
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"camera_id":"cam-1"}`, string(got))
+
+			tc.advance(2 * time.Second)
+			got, err = tc.store.Get(ctx, tenantID, "cam-1", "basic")
+			require.NoError(t, err)
+			assert.Nil(t, got, "entry should expire after the TTL")
+		})
+	}
+}
+
+func TestDetectionStore_PerStreamKeying(t *testing.T) {
+	ctx := context.Background()
+	tenantA, tenantB := uuid.New(), uuid.New()
+	for _, tc := range detectionStores(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.store.Put(ctx, tenantA, "cam-1", "basic", []byte(`"basic"`), DetectionTTL))
+			require.NoError(t, tc.store.Put(ctx, tenantA, "cam-1", "weapon", []byte(`"weapon"`), DetectionTTL))
+
+			basic, _ := tc.store.Get(ctx, tenantA, "cam-1", "basic")
+			weapon, _ := tc.store.Get(ctx, tenantA, "cam-1", "weapon")
+			assert.Equal(t, `"basic"`, string(basic))
+			assert.Equal(t, `"weapon"`, string(weapon))
+
+			// Empty stream defaults to basic
+			def, _ := tc.store.Get(ctx, tenantA, "cam-1", "")
+			assert.Equal(t, `"basic"`, string(def))
+
+			other, _ := tc.store.Get(ctx, tenantB, "cam-1", "basic")
+			assert.Nil(t, other, "tenants must not share detections")
+		})
+	}
+}
+
+func TestService_DetectionsWithoutRedis(t *testing.T) {
+	svc := &Service{Detections: NewMemoryDetectionStore(), ObjectLimits: DefaultObjectLimits}
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	payload := &DetectionPayload{
+		CameraID: "cam-1",
+		Stream:   "weapon",
+		TSUnixMS: time.Now().Add(-time.Millisecond).UnixMilli(),
+		Objects:  []Object{{Label: "handgun", Confidence: 0.9, BBox: BBox{X: 0.1, Y: 0.1, W: 0.2, H: 0.3}}},
+	}
+	require.NoError(t, svc.SaveDetection(ctx, tenantID, payload))
+
+	got, err := svc.GetLatestDetection(ctx, tenantID, "cam-1", "weapon")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "weapon", got.Stream)
+
+	none, err := svc.GetLatestDetection(ctx, tenantID, "cam-1", "basic")
+	require.NoError(t, err)
+	assert.Nil(t, none)
+}
+
+func TestDetectionStore_GetMany(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	for _, tc := range detectionStores(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.store.Put(ctx, tenantID, "cam-1", "basic", []byte(`"one"`), DetectionTTL))
+			require.NoError(t, tc.store.Put(ctx, tenantID, "cam-2", "basic", []byte(`"two"`), DetectionTTL))
+			require.NoError(t, tc.store.Put(ctx, tenantID, "cam-3", "weapon", []byte(`"three"`), DetectionTTL))
+
+			got, err := tc.store.GetMany(ctx, tenantID, []string{"cam-1", "cam-2", "cam-3", "cam-4"}, "basic")
+			require.NoError(t, err)
+			assert.Len(t, got, 2, "cameras without a basic detection are omitted")
+			assert.Equal(t, `"one"`, string(got["cam-1"]))
+			assert.Equal(t, `"two"`, string(got["cam-2"]))
+
+			empty, err := tc.store.GetMany(ctx, tenantID, nil, "basic")
+			require.NoError(t, err)
+			assert.Empty(t, empty)
+		})
+	}
+}
+
+func TestService_GetLatestDetections(t *testing.T) {
+	svc := &Service{Detections: NewMemoryDetectionStore(), ObjectLimits: DefaultObjectLimits}
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	for _, cam := range []string{"cam-1", "cam-2"} {
+		require.NoError(t, svc.SaveDetection(ctx, tenantID, &DetectionPayload{
+			CameraID: cam,
+			Stream:   "basic",
+			TSUnixMS: time.Now().Add(-50 * time.Millisecond).UnixMilli(),
+			Objects:  []Object{{Label: "person", Confidence: 0.8, BBox: BBox{X: 0.1, Y: 0.1, W: 0.2, H: 0.3}}},
+		}))
+	}
+
+	got, err := svc.GetLatestDetections(ctx, tenantID, []string{"cam-1", "cam-2", "cam-3"}, "basic")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "cam-2", got["cam-2"].CameraID)
+	assert.GreaterOrEqual(t, got["cam-1"].AgeMS, int64(50))
+}
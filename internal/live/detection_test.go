@@ -130,6 +130,30 @@ func TestValidateDetection_TooManyObjects(t *testing.T) {
 	assert.Contains(t, err.Error(), "too many objects")
 }
 
+func TestValidateDetection_PerStreamObjectLimits(t *testing.T) {
+	limits := ObjectLimits{Basic: 100, Weapon: 10}
+	build := func(stream, label string, n int) *DetectionPayload {
+		objects := make([]Object, n)
+		for i := range objects {
+			objects[i] = Object{Label: label, Confidence: 0.9, BBox: BBox{X: 0.1, Y: 0.1, W: 0.2, H: 0.3}}
+		}
+		return &DetectionPayload{CameraID: "cam-1", Stream: stream, TSUnixMS: time.Now().UnixMilli(), Objects: objects}
+	}
+
+	// Crowded basic scene passes under the raised basic cap
+	assert.NoError(t, ValidateDetectionWithLimits(build("basic", "person", 60), limits))
+
+	// Weapon message with the same count is rejected under the small weapon cap
+	err := ValidateDetectionWithLimits(build("weapon", "knife", 60), limits)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "too many objects: 60 > 10")
+
+	// Service-level validation uses its configured limits; unset limits keep the default
+	svc := &Service{ObjectLimits: limits}
+	assert.NoError(t, svc.ValidateDetection(build("basic", "person", 60)))
+	assert.Error(t, (&Service{}).ValidateDetection(build("basic", "person", 60)))
+}
+
 func TestValidateDetection_WeaponLabels(t *testing.T) {
 	// T20: Weapon stream uses weapon labels
 	payload := &DetectionPayload{
@@ -193,6 +217,75 @@ func TestDetectionStorage_StreamAware(t *testing.T) {
 	assert.Greater(t, retrieved.AgeMS, int64(0)) // age_ms computed
 }
 
+func TestValidateDetection_PixelCoords(t *testing.T) {
+	payload := func(b BBox) *DetectionPayload {
+		return &DetectionPayload{
+			CameraID:    "cam-1",
+			Stream:      "basic",
+			CoordSystem: CoordPixel,
+			FrameWidth:  1920,
+			FrameHeight: 1080,
+			Objects:     []Object{{Label: "person", Confidence: 0.9, BBox: b}},
+		}
+	}
+
+	assert.NoError(t, ValidateDetection(payload(BBox{X: 960, Y: 540, W: 960, H: 540})))
+
+	err := ValidateDetection(payload(BBox{X: 1800, Y: 100, W: 200, H: 100}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds frame")
+	assert.Error(t, ValidateDetection(payload(BBox{X: 100, Y: 1000, W: 100, H: 100})))
+	assert.Error(t, ValidateDetection(payload(BBox{X: -1, Y: 0, W: 10, H: 10})))
+
+	// Pixel coordinates need the frame size
+	p := payload(BBox{X: 10, Y: 10, W: 10, H: 10})
+	p.FrameHeight = 0
+	assert.Error(t, ValidateDetection(p))
+
+	p = payload(BBox{X: 0.1, Y: 0.1, W: 0.1, H: 0.1})
+	p.CoordSystem = "polar"
+	assert.Error(t, ValidateDetection(p))
+}
+
+func TestSaveDetection_NormalizesPixelCoords(t *testing.T) {
+	svc, _ := setupTestService(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	payload := &DetectionPayload{
+		CameraID:    "cam-px",
+		Stream:      "basic",
+		TSUnixMS:    time.Now().UnixMilli(),
+		CoordSystem: CoordPixel,
+		FrameWidth:  1920,
+		FrameHeight: 1080,
+		Objects: []Object{
+			{Label: "person", Confidence: 0.9, BBox: BBox{X: 480, Y: 270, W: 960, H: 540}},
+			{Label: "car", Confidence: 0.8, BBox: BBox{X: 1720, Y: 980, W: 200, H: 100}},
+		},
+	}
+	require.NoError(t, svc.SaveDetection(ctx, tenantID, payload))
+
+	got, err := svc.GetLatestDetection(ctx, tenantID, "cam-px", "basic")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, CoordNormalized, got.CoordSystem)
+	assert.InDelta(t, 0.25, got.Objects[0].BBox.X, 1e-9)
+	assert.InDelta(t, 0.25, got.Objects[0].BBox.Y, 1e-9)
+	assert.InDelta(t, 0.5, got.Objects[0].BBox.W, 1e-9)
+	assert.InDelta(t, 0.5, got.Objects[0].BBox.H, 1e-9)
+	// A box touching the frame edge stays within [0,1]
+	b := got.Objects[1].BBox
+	assert.LessOrEqual(t, b.X+b.W, 1.0)
+	assert.LessOrEqual(t, b.Y+b.H, 1.0)
+
+	// Out-of-frame pixel boxes are rejected, not stored
+	payload.CameraID = "cam-px-bad"
+	payload.CoordSystem = CoordPixel
+	payload.Objects = []Object{{Label: "person", Confidence: 0.9, BBox: BBox{X: 1900, Y: 0, W: 100, H: 100}}}
+	assert.Error(t, svc.SaveDetection(ctx, tenantID, payload))
+}
+
 func TestOverlayDemand_Tracking(t *testing.T) {
 	// T14/T15: Grid tiles subscribe/unsubscribe based on visibility
 	svc, _ := setupTestService(t)
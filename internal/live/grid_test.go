@@ -3,6 +3,7 @@ package live
 import (
 	"context"
 	"fmt"
+	"net"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/cameras"
 	"github.com/technosupport/ts-vms/internal/data"
@@ -26,6 +28,9 @@ func (d *dummyRepo) GetByID(ctx context.Context, id uuid.UUID) (*data.Camera, er
 	// Return minimal valid camera
 	return &data.Camera{ID: id, TenantID: uuid.MustParse("00000000-0000-0000-0000-000000000001")}, nil
 }
+func (d *dummyRepo) ListByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*data.Camera, error) {
+	return nil, nil
+}
 func (d *dummyRepo) Create(ctx context.Context, c *data.Camera) error { return nil }
 func (d *dummyRepo) Update(ctx context.Context, c *data.Camera) error { return nil }
 func (d *dummyRepo) Delete(ctx context.Context, id uuid.UUID) error   { return nil }
@@ -34,18 +39,48 @@ func (d *dummyRepo) SetStatus(ctx context.Context, id, tenantID uuid.UUID, enabl
 }                                                                                  // Renamed in service?
 func (d *dummyRepo) SoftDelete(ctx context.Context, id, tenantID uuid.UUID) error  { return nil }
 func (d *dummyRepo) CountAll(ctx context.Context, tenantID uuid.UUID) (int, error) { return 0, nil }
-func (d *dummyRepo) BulkUpdateStatus(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, enabled bool) error {
+func (d *dummyRepo) CountEnabled(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	return 0, nil
+}
+func (d *dummyRepo) SetONVIFEvents(ctx context.Context, tenantID, id uuid.UUID, enabled bool) error {
 	return nil
 }
-func (d *dummyRepo) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
+func (d *dummyRepo) ListDisabledIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return ids, nil
+}
+func (d *dummyRepo) BulkUpdateStatus(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, enabled bool) error {
 	return nil
 }
+func (d *dummyRepo) BulkAddTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string, maxTags int) ([]uuid.UUID, error) {
+	return nil, nil
+}
 func (d *dummyRepo) BulkRemoveTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
 	return nil
 }
+func (d *dummyRepo) BulkSetTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, tags []string) error {
+	return nil
+}
+func (d *dummyRepo) ListTags(ctx context.Context, tenantID uuid.UUID, prefix string, limit int) ([]data.TagCount, error) {
+	return nil, nil
+}
+func (d *dummyRepo) ListDueForThumbnail(ctx context.Context, cutoff time.Time, limit int) ([]data.ThumbnailDue, error) {
+	return nil, nil
+}
+func (d *dummyRepo) MarkThumbnailCaptured(ctx context.Context, tenantID, cameraID uuid.UUID, capturedAt time.Time) error {
+	return nil
+}
+func (d *dummyRepo) ListPurgeable(ctx context.Context, cutoff time.Time, limit int) ([]data.PurgeCandidate, error) {
+	return nil, nil
+}
+func (d *dummyRepo) HardDelete(ctx context.Context, id, tenantID uuid.UUID, cutoff time.Time) error {
+	return nil
+}
 func (d *dummyRepo) List(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.Camera, int, error) {
 	return nil, 0, nil
 }
+func (d *dummyRepo) ListSummary(ctx context.Context, tenantID uuid.UUID, filter data.CameraFilter, limit, offset int) ([]*data.CameraSummary, int, error) {
+	return nil, 0, nil
+}
 func (d *dummyRepo) CreateGroup(ctx context.Context, g *data.CameraGroup) error { return nil }
 func (d *dummyRepo) ListGroups(ctx context.Context, tenantID uuid.UUID) ([]*data.CameraGroup, error) {
 	return nil, nil
@@ -54,6 +89,57 @@ func (d *dummyRepo) DeleteGroup(ctx context.Context, id, tenantID uuid.UUID) err
 func (d *dummyRepo) SetGroupMembers(ctx context.Context, groupID, tenantID uuid.UUID, cameraIDs []uuid.UUID) error {
 	return nil
 }
+func (d *dummyRepo) AddFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	return nil
+}
+func (d *dummyRepo) RemoveFavorite(ctx context.Context, tenantID, userID, cameraID uuid.UUID) error {
+	return nil
+}
+func (d *dummyRepo) SiteBelongsToTenant(ctx context.Context, siteID, tenantID uuid.UUID) (bool, error) {
+	return true, nil
+}
+func (d *dummyRepo) GetDefaultSiteID(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error) {
+	return nil, nil
+}
+func (d *dummyRepo) SetDefaultSiteID(ctx context.Context, tenantID uuid.UUID, siteID *uuid.UUID) error {
+	return nil
+}
+func (d *dummyRepo) ListNVRSiteConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]data.CameraSiteConflict, error) {
+	return nil, nil
+}
+func (d *dummyRepo) CameraIPInSite(ctx context.Context, tenantID, siteID uuid.UUID, ip net.IP, excludeID uuid.UUID) (bool, error) {
+	return false, nil
+}
+func (d *dummyRepo) FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error) {
+	return uuid.Nil, data.ErrRecordNotFound
+}
+func (d *dummyRepo) ListSiteIPConflicts(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (d *dummyRepo) BulkMoveSite(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, siteID uuid.UUID) (int, error) {
+	return len(ids), nil
+}
+func (d *dummyRepo) ListTenantsWithEnabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (d *dummyRepo) ListEnabledIDsByPriority(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (d *dummyRepo) DisableForLicense(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) error {
+	return nil
+}
+func (d *dummyRepo) ListTenantsWithLicenseDisabledCameras(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (d *dummyRepo) ListLicenseDisabledIDs(ctx context.Context, tenantID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+func (d *dummyRepo) GetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	return true, nil
+}
+func (d *dummyRepo) SetCameraDefaultEnabled(ctx context.Context, tenantID uuid.UUID, enabled bool) error {
+	return nil
+}
 
 // Mock Auditor
 type dummyAuditor struct{}
@@ -100,9 +186,12 @@ func TestStartLiveSession_LimitEnforcement(t *testing.T) {
 	// 2. Try 17th -> Should Fail
 	_, err := svc.StartLiveSession(ctx, user, uuid.New().String(), "grid", "sub")
 	assert.Error(t, err)
-	// Check specific error string constant or partial match
-	// ErrLiveLimitExceeded might be unexported or variable, checking substring
 	assert.Contains(t, err.Error(), "limit=16")
+	var limitErr *LiveLimitError
+	if assert.ErrorAs(t, err, &limitErr) {
+		assert.Equal(t, MaxActiveSessions, limitErr.Limit)
+		assert.Equal(t, 16, limitErr.Active)
+	}
 }
 
 func TestStartLiveSession_Scrubbing(t *testing.T) {
@@ -207,3 +296,262 @@ func TestDetection_Storage(t *testing.T) {
 	assert.Equal(t, payload.CameraID, got.CameraID)
 	assert.Equal(t, 1, len(got.Objects))
 }
+
+func TestStartLiveSession_RepeatedFallbackStartsOnHLS(t *testing.T) {
+	svc, rdb, _ := setupServiceWithCamera(t)
+	telemetry := NewTelemetryService(rdb)
+	ctx := context.Background()
+
+	user := &data.User{ID: uuid.New(), TenantID: uuid.MustParse("00000000-0000-0000-0000-000000000001")}
+	camID := uuid.New().String()
+
+	// Fall back to HLS on DefaultFallbackDowngradeThreshold consecutive sessions
+	for i := 0; i < DefaultFallbackDowngradeThreshold; i++ {
+		resp, err := svc.StartLiveSession(ctx, user, camID, "fullscreen", "main")
+		assert.NoError(t, err)
+		assert.Equal(t, "webrtc", resp.Primary)
+		assert.NoError(t, telemetry.RecordEvent(ctx, &TelemetryEvent{
+			ViewerSessionID: resp.ViewerSessionID,
+			CameraID:        camID,
+			EventType:       "fallback_to_hls",
+			ReasonCode:      ReasonICEFailed,
+		}))
+		rdb.Del(ctx, fmt.Sprintf("live:idempotency:%s:%s", user.ID, camID)) // Force a fresh session
+	}
+
+	resp, err := svc.StartLiveSession(ctx, user, camID, "fullscreen", "main")
+	assert.NoError(t, err)
+	assert.Equal(t, "hls", resp.Primary)
+	assert.Equal(t, "webrtc", resp.Fallback)
+	assert.Equal(t, "sub", resp.SelectedQuality)
+}
+
+func TestStartLiveSession_CleanHistoryStartsOnWebRTC(t *testing.T) {
+	svc, rdb, _ := setupServiceWithCamera(t)
+	ctx := context.Background()
+
+	user := &data.User{ID: uuid.New(), TenantID: uuid.MustParse("00000000-0000-0000-0000-000000000001")}
+	camID := uuid.New().String()
+
+	// Below-threshold history on this camera and heavy history on another
+	rdb.Set(ctx, fallbackHistoryKey(user.TenantID, user.ID, camID), DefaultFallbackDowngradeThreshold-1, time.Minute)
+	rdb.Set(ctx, fallbackHistoryKey(user.TenantID, user.ID, uuid.New().String()), 10, time.Minute)
+
+	resp, err := svc.StartLiveSession(ctx, user, camID, "fullscreen", "main")
+	assert.NoError(t, err)
+	assert.Equal(t, "webrtc", resp.Primary)
+	assert.Equal(t, "hls", resp.Fallback)
+	assert.Equal(t, "main", resp.SelectedQuality)
+}
+
+func TestStartLiveSession_TenantHLSLatency(t *testing.T) {
+	svc, _, _ := setupServiceWithCamera(t)
+	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	svc.HLSParams.TenantTargetLatencyMs = map[uuid.UUID]int{tenantID: 2000}
+
+	user := &data.User{ID: uuid.New(), TenantID: tenantID}
+	resp, err := svc.StartLiveSession(context.Background(), user, uuid.New().String(), "grid", "sub")
+	assert.NoError(t, err)
+	assert.Equal(t, 2000, resp.HLS.TargetLatencyMs)
+	assert.Equal(t, DefaultHLSSegmentDurationMs, resp.HLS.SegmentDurationMs)
+	assert.Equal(t, 1, resp.HLS.LiveSyncSegments)
+}
+
+func TestHLSParams_Timing(t *testing.T) {
+	tenant := uuid.New()
+	p := HLSParams{TargetLatencyMs: 6000, TenantTargetLatencyMs: map[uuid.UUID]int{tenant: 2500}}
+
+	latency, segment, sync := p.Timing(uuid.New())
+	assert.Equal(t, 6000, latency)
+	assert.Equal(t, 2000, segment)
+	assert.Equal(t, 3, sync)
+
+	// Rounded up to whole segments
+	latency, _, sync = p.Timing(tenant)
+	assert.Equal(t, 4000, latency)
+	assert.Equal(t, 2, sync)
+
+	// Unset uses the 4s default
+	latency, _, _ = HLSParams{}.Timing(tenant)
+	assert.Equal(t, DefaultHLSTargetLatencyMs, latency)
+}
+
+type recordingAuditor struct{ actions []string }
+
+func (a *recordingAuditor) WriteEvent(ctx context.Context, evt audit.AuditEvent) error {
+	a.actions = append(a.actions, evt.Action+":"+evt.TargetID)
+	return nil
+}
+
+func TestLiveViewAudit(t *testing.T) {
+	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	user := &data.User{ID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), TenantID: tenantID}
+	quiet := uuid.New()
+
+	cases := []struct {
+		name   string
+		policy ViewAuditPolicy
+		camID  string
+		want   int
+	}{
+		{"tenant flag on", ViewAuditPolicy{Tenants: map[uuid.UUID]bool{tenantID: true}}, uuid.New().String(), 2},
+		{"flag off", ViewAuditPolicy{}, uuid.New().String(), 0},
+		{"camera overrides tenant", ViewAuditPolicy{Tenants: map[uuid.UUID]bool{tenantID: true}, Cameras: map[uuid.UUID]bool{quiet: false}}, quiet.String(), 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, rdb, _ := setupServiceWithCamera(t)
+			auditor := &recordingAuditor{}
+			svc.Auditor = auditor
+			svc.ViewAudit = tc.policy
+			ctx := context.Background()
+
+			resp, err := svc.StartLiveSession(ctx, user, tc.camID, "grid", "sub")
+			assert.NoError(t, err)
+			assert.NoError(t, svc.StopLiveSession(ctx, user, resp.ViewerSessionID))
+
+			assert.Len(t, auditor.actions, tc.want)
+			if tc.want == 2 {
+				assert.Equal(t, []string{"camera.live.view:" + tc.camID, "camera.live.stop:" + tc.camID}, auditor.actions)
+			}
+			// Stop frees the slot
+			n, _ := rdb.SCard(ctx, fmt.Sprintf("live:active:%s:%s", tenantID, user.ID)).Result()
+			assert.Equal(t, int64(0), n)
+		})
+	}
+}
+
+func TestStopLiveSession_ForeignSessionNotFound(t *testing.T) {
+	svc, _, _ := setupServiceWithCamera(t)
+	ctx := context.Background()
+	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	owner := &data.User{ID: uuid.New(), TenantID: tenantID}
+	other := &data.User{ID: uuid.New(), TenantID: tenantID}
+
+	resp, err := svc.StartLiveSession(ctx, owner, uuid.New().String(), "grid", "main")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, svc.StopLiveSession(ctx, other, resp.ViewerSessionID), ErrSessionNotFound)
+	assert.ErrorIs(t, svc.StopLiveSession(ctx, owner, "missing"), ErrSessionNotFound)
+}
+
+func activeIDs(t *testing.T, svc *Service) []string {
+	t.Helper()
+	active, err := svc.GetActiveCamerasForAI(context.Background())
+	require.NoError(t, err)
+	ids := make([]string, len(active))
+	for i, c := range active {
+		ids[i] = c.CameraID
+	}
+	return ids
+}
+
+func TestOverlayDemand_UnifiedViewsAgree(t *testing.T) {
+	svc, rdb, _ := setupServiceWithCamera(t)
+	ctx := context.Background()
+	refreshed, incremented, stale := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	require.NoError(t, svc.RefreshOverlayDemand(ctx, refreshed))
+	require.NoError(t, svc.IncrementOverlayDemand(ctx, incremented))
+	expired := float64(time.Now().Add(-OverlayDemandTTL - time.Second).UnixMilli())
+	require.NoError(t, rdb.ZAdd(ctx, OverlayDemandKey, redis.Z{Score: expired, Member: stale}).Err())
+
+	enabled, err := svc.GetCamerasWithOverlayEnabled(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{refreshed, incremented}, enabled, "expired demand must not be active")
+	assert.Equal(t, enabled, activeIDs(t, svc), "overlay list and AI list must agree")
+
+	// The last deprecated decrement clears demand in both views.
+	require.NoError(t, svc.DecrementOverlayDemand(ctx, incremented))
+	enabled, err = svc.GetCamerasWithOverlayEnabled(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{refreshed}, enabled)
+	assert.Equal(t, enabled, activeIDs(t, svc))
+}
+
+func TestOverlayDemand_DecrementKeepsOtherSubscribers(t *testing.T) {
+	svc, _, _ := setupServiceWithCamera(t)
+	ctx := context.Background()
+	cam := uuid.NewString()
+
+	require.NoError(t, svc.IncrementOverlayDemand(ctx, cam))
+	require.NoError(t, svc.IncrementOverlayDemand(ctx, cam))
+	require.NoError(t, svc.DecrementOverlayDemand(ctx, cam))
+
+	assert.Equal(t, []string{cam}, activeIDs(t, svc), "one subscriber is still watching")
+}
+
+func TestOverlayDemand_UnmatchedDecrementKeepsDemand(t *testing.T) {
+	svc, _, _ := setupServiceWithCamera(t)
+	ctx := context.Background()
+	cam := uuid.NewString()
+
+	// A viewer refreshing demand is not dropped by a stray decrement
+	require.NoError(t, svc.RefreshOverlayDemand(ctx, cam))
+	require.NoError(t, svc.DecrementOverlayDemand(ctx, cam))
+	assert.Equal(t, []string{cam}, activeIDs(t, svc))
+
+	// and the ref-count does not go negative
+	require.NoError(t, svc.IncrementOverlayDemand(ctx, cam))
+	require.NoError(t, svc.DecrementOverlayDemand(ctx, cam))
+	assert.Empty(t, activeIDs(t, svc), "the last counted subscriber clears demand")
+}
+
+func TestStartLiveSession_DegradedWhenRedisDown(t *testing.T) {
+	svc, _, mr := setupServiceWithCamera(t)
+	svc.DegradedSessionKey = DegradedSessionKeyFrom("test-secret")
+	ctx := context.Background()
+	user := &data.User{ID: uuid.New(), TenantID: uuid.MustParse("00000000-0000-0000-0000-000000000001")}
+	camID := uuid.NewString()
+
+	// Outage: a degraded but playable session
+	mr.SetError("ERR connection refused")
+	resp, err := svc.StartLiveSession(ctx, user, camID, "fullscreen", "auto")
+	require.NoError(t, err)
+	assert.True(t, resp.Degraded)
+	assert.True(t, IsDegradedSessionID(resp.ViewerSessionID))
+	require.NotNil(t, resp.WebRTC)
+	require.NotNil(t, resp.HLS)
+	assert.Contains(t, resp.HLS.PlaylistURL, camID)
+	assert.LessOrEqual(t, resp.ExpiresAt, time.Now().Add(DefaultDegradedSessionTTL).UnixMilli())
+
+	sess, err := svc.ParseDegradedSession(resp.ViewerSessionID)
+	require.NoError(t, err)
+	assert.Equal(t, camID, sess.CameraID)
+	assert.Equal(t, user.ID, sess.UserID)
+	assert.Equal(t, resp.SelectedQuality, sess.Quality)
+
+	_, err = svc.ParseDegradedSession(resp.ViewerSessionID + "x")
+	assert.ErrorIs(t, err, ErrSessionNotFound, "tampered token")
+	other := &data.User{ID: uuid.New(), TenantID: user.TenantID}
+	assert.ErrorIs(t, svc.StopLiveSession(ctx, other, resp.ViewerSessionID), ErrSessionNotFound)
+	assert.NoError(t, svc.StopLiveSession(ctx, user, resp.ViewerSessionID))
+
+	// Reconnect: back to stateful sessions
+	mr.SetError("")
+	resp, err = svc.StartLiveSession(ctx, user, camID, "fullscreen", "auto")
+	require.NoError(t, err)
+	assert.False(t, resp.Degraded)
+	assert.False(t, IsDegradedSessionID(resp.ViewerSessionID))
+	assert.True(t, mr.Exists("live:sess:"+resp.ViewerSessionID))
+}
+
+func TestStartLiveSession_RedisDownWithoutDegradedKeyFails(t *testing.T) {
+	svc, _, mr := setupServiceWithCamera(t)
+	user := &data.User{ID: uuid.New(), TenantID: uuid.MustParse("00000000-0000-0000-0000-000000000001")}
+
+	mr.SetError("ERR connection refused")
+	_, err := svc.StartLiveSession(context.Background(), user, uuid.NewString(), "fullscreen", "auto")
+	assert.Error(t, err)
+}
+
+func TestParseDegradedSession_ExpiredRejected(t *testing.T) {
+	svc := &Service{DegradedSessionKey: DegradedSessionKeyFrom("test-secret")}
+	past := time.Now().Add(-time.Hour)
+	token, err := svc.signDegradedSession(&ViewerSession{
+		ID: uuid.NewString(), TenantID: uuid.New(), UserID: uuid.New(), CameraID: uuid.NewString(),
+		CreatedAt: past, ExpiresAt: past.Add(DefaultDegradedSessionTTL),
+	})
+	require.NoError(t, err)
+	_, err = svc.ParseDegradedSession(token)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
@@ -1,6 +1,8 @@
 package live
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,6 +27,50 @@ const (
 	ErrLiveLimitExceeded = "LIVE_LIMIT_EXCEEDED"
 )
 
+// MaxActiveSessions is the per-user cap on concurrent live sessions.
+const MaxActiveSessions = 16
+
+// ErrCameraAccessDenied wraps the camera lookup failure when the user may
+// not view the requested camera.
+var ErrCameraAccessDenied = errors.New("camera access failed")
+
+// ErrSessionNotFound is returned by StopLiveSession for unknown, expired or
+// foreign sessions.
+var ErrSessionNotFound = errors.New("live session not found")
+
+// ViewAuditPolicy selects the live views recorded as camera.live.view and
+// camera.live.stop audit events. A camera entry overrides its tenant's, which
+// overrides All.
+type ViewAuditPolicy struct {
+	All     bool
+	Tenants map[uuid.UUID]bool
+	Cameras map[uuid.UUID]bool
+}
+
+// Enabled reports whether views of cameraID in tenantID are audited.
+func (p ViewAuditPolicy) Enabled(tenantID uuid.UUID, cameraID string) bool {
+	if id, err := uuid.Parse(cameraID); err == nil {
+		if on, ok := p.Cameras[id]; ok {
+			return on
+		}
+	}
+	if on, ok := p.Tenants[tenantID]; ok {
+		return on
+	}
+	return p.All
+}
+
+// LiveLimitError is returned by StartLiveSession when the user already has
+// Limit live sessions open.
+type LiveLimitError struct {
+	Limit  int
+	Active int
+}
+
+func (e *LiveLimitError) Error() string {
+	return fmt.Sprintf("%s: limit=%d active=%d", ErrLiveLimitExceeded, e.Limit, e.Active)
+}
+
 // LiveSessionResponse defines the dual-path contract
 type LiveSessionResponse struct {
 	ViewerSessionID string           `json:"viewer_session_id"`
@@ -36,6 +82,10 @@ type LiveSessionResponse struct {
 	HLS             *HLSBlock        `json:"hls"`
 	FallbackPolicy  *FallbackPolicy  `json:"fallback_policy"`
 	TelemetryPolicy *TelemetryPolicy `json:"telemetry_policy"`
+
+	// Degraded is set when the session store was unavailable: the session ID
+	// is a short-lived signed token, not counted against the session limit.
+	Degraded bool `json:"degraded,omitempty"`
 }
 
 type WebRTCBlock struct {
@@ -45,8 +95,10 @@ type WebRTCBlock struct {
 }
 
 type HLSBlock struct {
-	PlaylistURL     string `json:"playlist_url"`
-	TargetLatencyMs int    `json:"target_latency_ms"`
+	PlaylistURL       string `json:"playlist_url"`
+	TargetLatencyMs   int    `json:"target_latency_ms"`
+	SegmentDurationMs int    `json:"segment_duration_ms"`
+	LiveSyncSegments  int    `json:"live_sync_segments"` // Segments behind the live edge (hls.js liveSyncDurationCount)
 }
 
 type FallbackPolicy struct {
@@ -78,6 +130,7 @@ type ViewerSession struct {
 	UserID        uuid.UUID `json:"user_id"`
 	CameraID      string    `json:"camera_id"`
 	Mode          string    `json:"mode"`
+	Quality       string    `json:"quality,omitempty"` // Quality selected at start
 	CreatedAt     time.Time `json:"created_at"`
 	LastSeenAt    time.Time `json:"last_seen_at"`
 	ExpiresAt     time.Time `json:"expires_at"`
@@ -3,11 +3,17 @@ package live
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/cameras"
 	"github.com/technosupport/ts-vms/internal/data"
 )
@@ -17,54 +23,177 @@ type Service struct {
 	CameraService *cameras.Service
 	BaseURL       string
 	HLSParams     HLSParams
+
+	// FallbackDowngradeThreshold: once a user has fallen back to HLS this many
+	// times for a camera within FallbackHistoryWindow, new sessions start on
+	// HLS + sub-stream. <= 0 disables the downgrade.
+	FallbackDowngradeThreshold int
+
+	// ObjectLimits caps objects per detection message for each stream.
+	ObjectLimits ObjectLimits
+
+	// Detections holds the latest detection per stream; nil uses Redis.
+	Detections DetectionStore
+
+	// RecentDetections, when set, also keeps a short per-camera history of
+	// detections for GetRecentDetections. Nil disables it.
+	RecentDetections *RecentDetections
+
+	// Auditor records camera.live.view/stop for cameras selected by
+	// ViewAudit; nil disables view auditing.
+	Auditor   Auditor
+	ViewAudit ViewAuditPolicy
+
+	// TenantCacheTTL bounds how long a camera's resolved tenant is reused by
+	// ResolveCameraTenant; <= 0 disables the cache. TenantCacheSize caps the
+	// cached cameras (<= 0 uses DefaultTenantCacheSize); it is read once, on
+	// first use.
+	TenantCacheTTL  time.Duration
+	TenantCacheSize int
+
+	// DegradedSessionKey signs stateless sessions issued when Redis is
+	// unavailable, so viewing keeps working without session limits; nil
+	// fails live starts instead. DegradedSessionTTL <= 0 uses
+	// DefaultDegradedSessionTTL.
+	DegradedSessionKey []byte
+	DegradedSessionTTL time.Duration
+
+	tenantOnce  sync.Once
+	tenantCache *tenantLRU
+}
+
+type Auditor interface {
+	WriteEvent(ctx context.Context, evt audit.AuditEvent) error
 }
 
 type HLSParams struct {
 	BaseURL string
+
+	// SegmentDurationMs must match the segment length the media plane writes
+	// (hlsd advertises #EXT-X-TARGETDURATION:2); 0 uses the default.
+	SegmentDurationMs int
+	// TargetLatencyMs is the default player distance from the live edge;
+	// TenantTargetLatencyMs overrides it per tenant. 0 uses the default.
+	TargetLatencyMs       int
+	TenantTargetLatencyMs map[uuid.UUID]int
+}
+
+const (
+	DefaultHLSSegmentDurationMs = 2000
+	DefaultHLSTargetLatencyMs   = 4000
+)
+
+// Timing resolves the tenant's HLS latency. The player can only hold back
+// whole segments, so latency is rounded up to a multiple of the segment
+// duration, with at least one segment.
+func (p HLSParams) Timing(tenantID uuid.UUID) (latencyMs, segmentMs, syncSegments int) {
+	segmentMs = p.SegmentDurationMs
+	if segmentMs <= 0 {
+		segmentMs = DefaultHLSSegmentDurationMs
+	}
+	latencyMs = p.TargetLatencyMs
+	if l, ok := p.TenantTargetLatencyMs[tenantID]; ok && l > 0 {
+		latencyMs = l
+	}
+	if latencyMs <= 0 {
+		latencyMs = DefaultHLSTargetLatencyMs
+	}
+	syncSegments = (latencyMs + segmentMs - 1) / segmentMs
+	return syncSegments * segmentMs, segmentMs, syncSegments
 }
 
 const (
 	SessionTTL        = 10 * time.Minute
 	IdempotencyWindow = 10 * time.Second
+
+	DefaultFallbackDowngradeThreshold = 3
+	FallbackHistoryWindow             = 30 * time.Minute
+
+	// DefaultTenantCacheTTL keeps camera->tenant lookups off the DB for the
+	// steady detection stream. Deletes and moves invalidate entries directly,
+	// so the TTL only bounds staleness from changes made elsewhere.
+	DefaultTenantCacheTTL  = time.Minute
+	DefaultTenantCacheSize = 10000
 )
 
 func NewService(r *redis.Client, c *cameras.Service, baseUrl string, hlsParams HLSParams) *Service {
 	return &Service{
-		Redis:         r,
-		CameraService: c,
-		BaseURL:       baseUrl,
-		HLSParams:     hlsParams,
+		Redis:                      r,
+		CameraService:              c,
+		BaseURL:                    baseUrl,
+		HLSParams:                  hlsParams,
+		FallbackDowngradeThreshold: DefaultFallbackDowngradeThreshold,
+		ObjectLimits:               DefaultObjectLimits,
+		Detections:                 RedisDetectionStore{Client: r},
+		TenantCacheTTL:             DefaultTenantCacheTTL,
+	}
+}
+
+func (s *Service) detections() DetectionStore {
+	if s.Detections != nil {
+		return s.Detections
+	}
+	return RedisDetectionStore{Client: s.Redis}
+}
+
+// fallbackHistoryKey counts recent HLS fallbacks per user+camera.
+// Key: live:fallbacks:{tenant}:{user}:{camera}
+func fallbackHistoryKey(tenantID, userID uuid.UUID, cameraID string) string {
+	return fmt.Sprintf("live:fallbacks:%s:%s:%s", tenantID, userID, cameraID)
+}
+
+// shouldStartOnHLS reports whether recent fallback history exceeds the threshold.
+func (s *Service) shouldStartOnHLS(ctx context.Context, tenantID, userID uuid.UUID, cameraID string) bool {
+	if s.FallbackDowngradeThreshold <= 0 {
+		return false
+	}
+	n, err := s.Redis.Get(ctx, fallbackHistoryKey(tenantID, userID, cameraID)).Int()
+	if err != nil {
+		return false
+	}
+	return n >= s.FallbackDowngradeThreshold
+}
+
+// cameraLookupError wraps a missing or foreign camera in
+// ErrCameraAccessDenied; other lookup failures (e.g. the database being
+// down) are returned as-is so they are not reported as a 403.
+func cameraLookupError(err error) error {
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %w", ErrCameraAccessDenied, err)
 	}
+	return err
 }
 
-// StartLiveSession initiates a viewer session (idempotent)
+// StartLiveSession initiates a viewer session (idempotent). If Redis fails
+// and DegradedSessionKey is set, it returns a degraded, stateless session
+// instead (see startDegradedSession).
 func (s *Service) StartLiveSession(ctx context.Context, u *data.User, cameraID, viewMode, quality string) (*LiveSessionResponse, error) {
 	// 1. Validate Camera Access (RBAC via service)
 	_, err := s.CameraService.GetCamera(ctx, u.TenantID, cameraID)
 	if err != nil {
-		return nil, fmt.Errorf("camera access failed: %w", err)
+		return nil, cameraLookupError(err)
 	}
 
-	// 2. Active Session Management (Limit 16)
+	// 2. Active Session Management (Limit MaxActiveSessions)
 	activeKey := fmt.Sprintf("live:active:%s:%s", u.TenantID, u.ID)
 
 	// Scrubbing Logic: Verify existing members are actually alive
 	members, err := s.Redis.SMembers(ctx, activeKey).Result()
-	if err == nil {
-		for _, sessID := range members {
-			exists, _ := s.Redis.Exists(ctx, fmt.Sprintf("live:sess:%s", sessID)).Result()
-			if exists == 0 {
-				s.Redis.SRem(ctx, activeKey, sessID)
-			}
+	if err != nil {
+		return s.startDegradedSession(ctx, u, cameraID, quality, err)
+	}
+	for _, sessID := range members {
+		exists, _ := s.Redis.Exists(ctx, fmt.Sprintf("live:sess:%s", sessID)).Result()
+		if exists == 0 {
+			s.Redis.SRem(ctx, activeKey, sessID)
 		}
 	}
 
 	// Check Limit
 	count, _ := s.Redis.SCard(ctx, activeKey).Result()
-	if count >= 16 {
-		// Stable JSON error payload expected by client
-		// We return a specific error that the handler will map to 429 with JSON body
-		return nil, fmt.Errorf("%s: limit=16 active=%d", ErrLiveLimitExceeded, count)
+	if count >= MaxActiveSessions {
+		// The handler maps this to 429 with the stable JSON payload
+		return nil, &LiveLimitError{Limit: MaxActiveSessions, Active: int(count)}
 	}
 
 	// 3. Check Idempotency (Prevent spam)
@@ -101,6 +230,15 @@ func (s *Service) StartLiveSession(ctx context.Context, u *data.User, cameraID,
 		LastError:     "",
 	}
 
+	// Repeated fallbacks: skip the WebRTC attempt to avoid churn
+	if s.shouldStartOnHLS(ctx, u.TenantID, u.ID, cameraID) {
+		sess.Mode = "hls"
+		metricQualityDowngradeTotal.Inc()
+	}
+
+	resp := s.buildResponse(sess, quality)
+	sess.Quality = resp.SelectedQuality
+
 	// 5. Store in Redis
 	sessJSON, _ := json.Marshal(sess)
 	pipe := s.Redis.Pipeline()
@@ -117,10 +255,91 @@ func (s *Service) StartLiveSession(ctx context.Context, u *data.User, cameraID,
 
 	_, err = pipe.Exec(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to store session: %w", err)
+		return s.startDegradedSession(ctx, u, cameraID, quality, err)
 	}
 
-	return s.buildResponse(sess, quality), nil
+	s.auditView(ctx, "camera.live.view", sess, nil)
+	return resp, nil
+}
+
+// StopLiveSession ends the user's viewer session and frees its slot.
+// Audit: camera.live.stop (when the camera's views are audited)
+func (s *Service) StopLiveSession(ctx context.Context, u *data.User, sessionID string) error {
+	if IsDegradedSessionID(sessionID) {
+		// Nothing was stored; the session just lapses.
+		sess, err := s.ParseDegradedSession(sessionID)
+		if err != nil {
+			return err
+		}
+		if sess.TenantID != u.TenantID || sess.UserID != u.ID {
+			return ErrSessionNotFound
+		}
+		s.auditView(ctx, "camera.live.stop", sess, map[string]any{
+			"duration_ms": time.Since(sess.CreatedAt).Milliseconds(),
+			"degraded":    true,
+		})
+		return nil
+	}
+
+	sessKey := fmt.Sprintf("live:sess:%s", sessionID)
+	raw, err := s.Redis.Get(ctx, sessKey).Result()
+	if err == redis.Nil {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var sess ViewerSession
+	if err := json.Unmarshal([]byte(raw), &sess); err != nil {
+		return err
+	}
+	if sess.TenantID != u.TenantID || sess.UserID != u.ID {
+		return ErrSessionNotFound
+	}
+
+	pipe := s.Redis.Pipeline()
+	pipe.Del(ctx, sessKey)
+	pipe.SRem(ctx, fmt.Sprintf("live:active:%s:%s", u.TenantID, u.ID), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	// Only drop the idempotency key if it still points at this session
+	idemKey := fmt.Sprintf("live:idempotency:%s:%s", u.ID, sess.CameraID)
+	if cur, _ := s.Redis.Get(ctx, idemKey).Result(); cur == sessionID {
+		s.Redis.Del(ctx, idemKey)
+	}
+
+	s.auditView(ctx, "camera.live.stop", &sess, map[string]any{
+		"duration_ms": time.Since(sess.CreatedAt).Milliseconds(),
+	})
+	return nil
+}
+
+// auditView records a live view event if the policy covers the camera.
+func (s *Service) auditView(ctx context.Context, action string, sess *ViewerSession, extra map[string]any) {
+	if s.Auditor == nil || !s.ViewAudit.Enabled(sess.TenantID, sess.CameraID) {
+		return
+	}
+	meta := map[string]any{"session_id": sess.ID, "quality": sess.Quality, "mode": sess.Mode}
+	for k, v := range extra {
+		meta[k] = v
+	}
+	metaJSON, _ := json.Marshal(meta)
+	actor := sess.UserID
+	err := s.Auditor.WriteEvent(ctx, audit.AuditEvent{
+		EventID:     uuid.New(),
+		TenantID:    sess.TenantID,
+		ActorUserID: &actor,
+		Action:      action,
+		TargetType:  "camera",
+		TargetID:    sess.CameraID,
+		Result:      "success",
+		Metadata:    metaJSON,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		log.Printf("Live: failed to audit %s for camera %s: %v", action, sess.CameraID, err)
+	}
 }
 
 // --- Phase 3.8: Overlay & Detection ---
@@ -132,8 +351,20 @@ type DetectionPayload struct {
 	AgeMS    int64    `json:"age_ms,omitempty"` // Computed on read
 	Stream   string   `json:"stream"`           // "basic" or "weapon"
 	Objects  []Object `json:"objects"`
+
+	// CoordSystem is CoordNormalized (default when empty) or CoordPixel.
+	// Pixel boxes need the frame size and are stored normalized.
+	CoordSystem string `json:"coord_system,omitempty"`
+	FrameWidth  int    `json:"frame_width,omitempty"`
+	FrameHeight int    `json:"frame_height,omitempty"`
 }
 
+// Bounding-box coordinate systems
+const (
+	CoordNormalized = "normalized" // x,y,w,h as fractions of the frame, in [0,1]
+	CoordPixel      = "pixel"      // x,y,w,h in pixels of a FrameWidth x FrameHeight frame
+)
+
 type Object struct {
 	Label      string  `json:"label"`
 	Confidence float64 `json:"confidence"`
@@ -163,10 +394,54 @@ const (
 	OverlayDemandTTL = 20 * time.Second
 )
 
+// ObjectLimits holds the per-stream maximum objects per message.
+// Zero or negative values fall back to MaxObjectsPerMsg.
+type ObjectLimits struct {
+	Basic  int
+	Weapon int
+}
+
+var DefaultObjectLimits = ObjectLimits{Basic: MaxObjectsPerMsg, Weapon: MaxObjectsPerMsg}
+
+// For returns the cap for a stream ("" is treated as basic).
+func (l ObjectLimits) For(stream string) int {
+	max := l.Basic
+	if stream == "weapon" {
+		max = l.Weapon
+	}
+	if max <= 0 {
+		return MaxObjectsPerMsg
+	}
+	return max
+}
+
 // ValidateDetection checks payload constraints per Phase 3.8 spec
 func ValidateDetection(p *DetectionPayload) error {
-	if len(p.Objects) > MaxObjectsPerMsg {
-		return fmt.Errorf("too many objects: %d > %d", len(p.Objects), MaxObjectsPerMsg)
+	return ValidateDetectionWithLimits(p, DefaultObjectLimits)
+}
+
+// ValidateDetection validates against the service's configured per-stream limits.
+func (s *Service) ValidateDetection(p *DetectionPayload) error {
+	return ValidateDetectionWithLimits(p, s.ObjectLimits)
+}
+
+// ValidateDetectionWithLimits applies the stream-specific object cap before
+// the label, confidence and bbox checks.
+func ValidateDetectionWithLimits(p *DetectionPayload, limits ObjectLimits) error {
+	if max := limits.For(p.Stream); len(p.Objects) > max {
+		return fmt.Errorf("too many objects: %d > %d", len(p.Objects), max)
+	}
+
+	pixel := false
+	switch p.CoordSystem {
+	case "", CoordNormalized:
+	case CoordPixel:
+		if p.FrameWidth <= 0 || p.FrameHeight <= 0 {
+			return fmt.Errorf("frame_width and frame_height are required for pixel coordinates")
+		}
+		pixel = true
+	default:
+		return fmt.Errorf("invalid coord_system: %s", p.CoordSystem)
 	}
 
 	labelSet := ValidBasicLabels
@@ -183,8 +458,18 @@ func ValidateDetection(p *DetectionPayload) error {
 		if obj.Confidence < 0 || obj.Confidence > 1 {
 			return fmt.Errorf("confidence out of range at index %d: %f", i, obj.Confidence)
 		}
-		// BBox validation: x,y,w,h ∈ [0..1], w>0, h>0, x+w≤1, y+h≤1
 		b := obj.BBox
+		if pixel {
+			// Pixel boxes must lie within the frame
+			if b.X < 0 || b.Y < 0 || b.W <= 0 || b.H <= 0 {
+				return fmt.Errorf("bbox x/y must be >= 0 and w/h > 0 at index %d", i)
+			}
+			if b.X+b.W > float64(p.FrameWidth) || b.Y+b.H > float64(p.FrameHeight) {
+				return fmt.Errorf("bbox exceeds frame %dx%d at index %d", p.FrameWidth, p.FrameHeight, i)
+			}
+			continue
+		}
+		// BBox validation: x,y,w,h ∈ [0..1], w>0, h>0, x+w≤1, y+h≤1
 		if b.X < 0 || b.X > 1 || b.Y < 0 || b.Y > 1 {
 			return fmt.Errorf("bbox x/y out of range at index %d", i)
 		}
@@ -198,36 +483,57 @@ func ValidateDetection(p *DetectionPayload) error {
 	return nil
 }
 
-// SaveDetection stores the latest detection for 10s with stream support
+// NormalizeDetection converts a validated pixel-coordinate payload to
+// normalized coordinates in place; normalized payloads are left unchanged.
+// Reports whether p was converted.
+func NormalizeDetection(p *DetectionPayload) bool {
+	if p.CoordSystem != CoordPixel {
+		return false
+	}
+	fw, fh := float64(p.FrameWidth), float64(p.FrameHeight)
+	for i := range p.Objects {
+		b := &p.Objects[i].BBox
+		b.X, b.W = b.X/fw, b.W/fw
+		b.Y, b.H = b.Y/fh, b.H/fh
+		// Keep x+w and y+h within 1 despite float rounding
+		b.W = math.Min(b.W, 1-b.X)
+		b.H = math.Min(b.H, 1-b.Y)
+	}
+	p.CoordSystem = CoordNormalized
+	return true
+}
+
+// SaveDetection stores the latest detection for 10s with stream support.
+// Pixel coordinates are converted to normalized before storage.
 func (s *Service) SaveDetection(ctx context.Context, tenantID uuid.UUID, payload *DetectionPayload) error {
-	if err := ValidateDetection(payload); err != nil {
+	if err := s.ValidateDetection(payload); err != nil {
 		return err
 	}
-	// Key: det:latest:{tenant}:{camera}:{stream}
-	stream := payload.Stream
-	if stream == "" {
-		stream = "basic"
-	}
-	key := fmt.Sprintf("det:latest:%s:%s:%s", tenantID.String(), payload.CameraID, stream)
+	NormalizeDetection(payload)
 	data, _ := json.Marshal(payload)
-	return s.Redis.Set(ctx, key, data, DetectionTTL).Err()
+	if err := s.detections().Put(ctx, tenantID, payload.CameraID, payload.Stream, data, DetectionTTL); err != nil {
+		return err
+	}
+	if s.RecentDetections != nil {
+		// History is best effort; the latest detection is already stored.
+		if err := s.RecentDetections.Add(ctx, tenantID, payload.CameraID, payload.Stream, payload.TSUnixMS, data); err != nil {
+			log.Printf("Live: failed to record recent detection for camera %s: %v", payload.CameraID, err)
+		}
+	}
+	return nil
 }
 
 // GetLatestDetection retrieves detection for client with age_ms
 func (s *Service) GetLatestDetection(ctx context.Context, tenantID uuid.UUID, cameraID, stream string) (*DetectionPayload, error) {
-	if stream == "" {
-		stream = "basic"
-	}
-	key := fmt.Sprintf("det:latest:%s:%s:%s", tenantID.String(), cameraID, stream)
-	data, err := s.Redis.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return nil, nil // 204 No Content equivalent
-	}
+	data, err := s.detections().Get(ctx, tenantID, cameraID, stream)
 	if err != nil {
 		return nil, err
 	}
+	if data == nil {
+		return nil, nil // 204 No Content equivalent
+	}
 	var payload DetectionPayload
-	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+	if err := json.Unmarshal(data, &payload); err != nil {
 		return nil, err
 	}
 	// Compute age_ms
@@ -236,6 +542,52 @@ func (s *Service) GetLatestDetection(ctx context.Context, tenantID uuid.UUID, ca
 	return &payload, nil
 }
 
+// GetRecentDetections returns the camera's detections within the
+// RecentDetections window, oldest first, with age_ms computed.
+func (s *Service) GetRecentDetections(ctx context.Context, tenantID uuid.UUID, cameraID, stream string) ([]*DetectionPayload, error) {
+	if s.RecentDetections == nil {
+		return nil, ErrRecentDetectionsDisabled
+	}
+	raw, err := s.RecentDetections.List(ctx, tenantID, cameraID, stream)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	out := make([]*DetectionPayload, 0, len(raw))
+	for _, b := range raw {
+		var payload DetectionPayload
+		if err := json.Unmarshal(b, &payload); err != nil {
+			continue
+		}
+		payload.AgeMS = now - payload.TSUnixMS
+		out = append(out, &payload)
+	}
+	return out, nil
+}
+
+// MaxBatchDetections caps the cameras accepted by one batch fetch (a 64-tile wall).
+const MaxBatchDetections = 64
+
+// GetLatestDetections is the batch form of GetLatestDetection: one store
+// round trip for all cameras. Cameras without a fresh detection are omitted.
+func (s *Service) GetLatestDetections(ctx context.Context, tenantID uuid.UUID, cameraIDs []string, stream string) (map[string]*DetectionPayload, error) {
+	raw, err := s.detections().GetMany(ctx, tenantID, cameraIDs, stream)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	out := make(map[string]*DetectionPayload, len(raw))
+	for camID, b := range raw {
+		var payload DetectionPayload
+		if err := json.Unmarshal(b, &payload); err != nil {
+			continue // Skip a corrupt entry rather than failing the whole wall
+		}
+		payload.AgeMS = now - payload.TSUnixMS
+		out[camID] = &payload
+	}
+	return out, nil
+}
+
 // SetOverlayState toggles overlay for a specific session
 func (s *Service) SetOverlayState(ctx context.Context, sessID string, enabled bool) error {
 	// We store a flag in Redis? Or update the Session JSON?
@@ -248,42 +600,64 @@ func (s *Service) SetOverlayState(ctx context.Context, sessID string, enabled bo
 	return s.Redis.Del(ctx, key).Err()
 }
 
-// GetResult for internal overlay logic if needed?
-// Or better: GetCamerasWithOverlayEnabled relies on checking ALL sessions... expensive to scan?
-// Optimized: "Active Cameras" list.
-// When Overlay Enabled -> Add cameraID to "live:active_overlay:{camera_id}" Set?
-// No, simpler: AI Service asks "Which cameras have overlay enabled?".
-// Control Plane can maintain a SET of "cameras_with_overlay".
-// SADD when enabled, SREM when disabled?
-// But SREM tricky if multiple users view same camera. Reference Counting?
-// Redis doesn't do ref counting natively on Sets.
-// SCORING via Sorted Set? Score = count?
-// ZINCRBY 1 when enable, ZINCRBY -1 when disable. ZREM if <= 0.
-// Let's use ZSET "live:overlay_demand". Member=camera_id, Score=subscriber_count.
+// Overlay demand. Both the AI active camera list and
+// GetCamerasWithOverlayEnabled read OverlayDemandKey: member=camera_id,
+// score=last demand (unix ms); entries older than OverlayDemandTTL are
+// inactive. Grid and live views refresh it while overlays are shown.
+const (
+	OverlayDemandKey = "overlay:demand"
+	// overlayRefCountKey backs the deprecated Increment/Decrement pair only:
+	// member=camera_id, score=subscriber_count.
+	overlayRefCountKey = "live:overlay_demand"
+)
 
+// IncrementOverlayDemand adds a subscriber to the ref-count and refreshes
+// the camera's demand.
+//
+// Deprecated: use RefreshOverlayDemand; demand then lapses after
+// OverlayDemandTTL without refreshes instead of needing a matching decrement.
 func (s *Service) IncrementOverlayDemand(ctx context.Context, cameraID string) error {
-	key := "live:overlay_demand"
-	return s.Redis.ZIncrBy(ctx, key, 1.0, cameraID).Err()
+	if err := s.Redis.ZIncrBy(ctx, overlayRefCountKey, 1.0, cameraID).Err(); err != nil {
+		return err
+	}
+	return s.RefreshOverlayDemand(ctx, cameraID)
 }
 
+// DecrementOverlayDemand removes a subscriber from the ref-count. Demand is
+// cleared only when the count reaches zero; an unmatched decrement leaves
+// demand held by other viewers in place.
+//
+// Deprecated: let demand lapse, or use ClearOverlayDemand.
 func (s *Service) DecrementOverlayDemand(ctx context.Context, cameraID string) error {
-	key := "live:overlay_demand"
-	// Decrement
-	res, err := s.Redis.ZIncrBy(ctx, key, -1.0, cameraID).Result()
+	res, err := s.Redis.ZIncrBy(ctx, overlayRefCountKey, -1.0, cameraID).Result()
 	if err != nil {
 		return err
 	}
-	// Cleanup if <= 0
-	if res <= 0 {
-		s.Redis.ZRem(ctx, key, cameraID)
+	if res > 0 {
+		return nil
 	}
-	return nil
+	if err := s.Redis.ZRem(ctx, overlayRefCountKey, cameraID).Err(); err != nil {
+		return err
+	}
+	if res < 0 {
+		return nil // No counted subscriber to release
+	}
+	return s.ClearOverlayDemand(ctx, cameraID)
 }
 
-// GetCamerasWithOverlayEnabled returns list for AI service
+// GetCamerasWithOverlayEnabled returns the cameras with active overlay
+// demand, most recent first; the same set GetActiveCamerasForAI reports.
 func (s *Service) GetCamerasWithOverlayEnabled(ctx context.Context) ([]string, error) {
-	// Return all members of ZSET
-	return s.Redis.ZRange(ctx, "live:overlay_demand", 0, -1).Result()
+	return s.activeOverlayDemand(ctx)
+}
+
+// activeOverlayDemand lists cameras refreshed within OverlayDemandTTL.
+func (s *Service) activeOverlayDemand(ctx context.Context) ([]string, error) {
+	cutoff := time.Now().Add(-OverlayDemandTTL).UnixMilli()
+	return s.Redis.ZRevRangeByScore(ctx, OverlayDemandKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(cutoff, 10),
+		Max: "+inf",
+	}).Result()
 }
 
 func (s *Service) buildResponse(sess *ViewerSession, requestedQuality string) *LiveSessionResponse {
@@ -299,20 +673,29 @@ func (s *Service) buildResponse(sess *ViewerSession, requestedQuality string) *L
 	}
 	// Note: In real world, we'd check s.CameraMonitor.HasSubStream(sess.CameraID)
 
+	// Downgraded sessions (repeated fallback) start on HLS with the sub-stream
+	primary, fallback := "webrtc", "hls"
+	if sess.Mode == "hls" {
+		primary, fallback = "hls", "webrtc"
+		selectedQuality = "sub"
+	}
+
 	// Mock HLS Token
 	hlsToken := fmt.Sprintf("sub=%s&sid=%s&scope=hls&q=%s&sig=mock_sig", sess.CameraID, sess.ID, selectedQuality)
 
 	hlsURL := fmt.Sprintf("%s/hls/live/%s/%s/index.m3u8?token=%s",
 		s.HLSParams.BaseURL, sess.CameraID, sess.ID, hlsToken)
 
+	latencyMs, segmentMs, syncSegments := s.HLSParams.Timing(sess.TenantID)
+
 	// WebRTC Config
 	sfuURL := fmt.Sprintf("%s/api/v1/sfu", s.BaseURL)
 
 	return &LiveSessionResponse{
 		ViewerSessionID: sess.ID,
 		ExpiresAt:       sess.ExpiresAt.UnixMilli(),
-		Primary:         "webrtc",
-		Fallback:        "hls",
+		Primary:         primary,
+		Fallback:        fallback,
 		SelectedQuality: selectedQuality,
 		WebRTC: &WebRTCBlock{
 			SFUURL:           sfuURL,
@@ -320,8 +703,10 @@ func (s *Service) buildResponse(sess *ViewerSession, requestedQuality string) *L
 			ConnectTimeoutMs: 5000,
 		},
 		HLS: &HLSBlock{
-			PlaylistURL:     hlsURL,
-			TargetLatencyMs: 4000,
+			PlaylistURL:       hlsURL,
+			TargetLatencyMs:   latencyMs,
+			SegmentDurationMs: segmentMs,
+			LiveSyncSegments:  syncSegments,
 		},
 		FallbackPolicy: &FallbackPolicy{
 			WebRTCConnectTimeoutMs: 5000,
@@ -335,13 +720,16 @@ func (s *Service) buildResponse(sess *ViewerSession, requestedQuality string) *L
 	}
 }
 
-// ResolveCameraTenant looks up the TenantID for a CameraID (used by Internal Ingest)
-// We rely on repository lookup. Optimizable via cache.
+// ResolveCameraTenant looks up the TenantID for a CameraID (used by Internal
+// Ingest and every NATS detection), served from a bounded LRU when enabled.
 func (s *Service) ResolveCameraTenant(ctx context.Context, cameraID string) (uuid.UUID, error) {
 	uid, err := uuid.Parse(cameraID)
 	if err != nil {
 		return uuid.Nil, err
 	}
+	if tid, ok := s.cachedCameraTenant(cameraID); ok {
+		return tid, nil
+	}
 	// We use GetByID without knowing Tenant. If Repo enforces Tenant, we can't use it easily?
 	// But cameras.Repository GetByID takes ID. LiveService wrapper takes TenantID.
 	// Cameras Repo interface: GetByID(ctx, id) (*Camera, error)
@@ -349,10 +737,40 @@ func (s *Service) ResolveCameraTenant(ctx context.Context, cameraID string) (uui
 	if err != nil {
 		return uuid.Nil, err
 	}
+	s.cacheCameraTenant(cameraID, cam.TenantID)
 	return cam.TenantID, nil
 }
 
-// SaveDetectionFromNATS stores detection with TTL (called by NATS subscription handler)
+func (s *Service) tenants() *tenantLRU {
+	s.tenantOnce.Do(func() { s.tenantCache = newTenantLRU(s.TenantCacheSize) })
+	return s.tenantCache
+}
+
+func (s *Service) cachedCameraTenant(cameraID string) (uuid.UUID, bool) {
+	if s.TenantCacheTTL <= 0 {
+		return uuid.Nil, false
+	}
+	return s.tenants().get(cameraID)
+}
+
+func (s *Service) cacheCameraTenant(cameraID string, tenantID uuid.UUID) {
+	if s.TenantCacheTTL <= 0 {
+		return
+	}
+	s.tenants().put(cameraID, tenantID, s.TenantCacheTTL)
+}
+
+// InvalidateCameraTenants drops cached tenants of cameras that were deleted
+// or moved (registered with cameras.Service.OnCamerasChanged).
+func (s *Service) InvalidateCameraTenants(ids []uuid.UUID) {
+	c := s.tenants()
+	for _, id := range ids {
+		c.invalidate(id.String())
+	}
+}
+
+// SaveDetectionFromNATS decodes a NATS detection message and stores it via
+// SaveDetection, the same path as the HTTP ingest fallback.
 func (s *Service) SaveDetectionFromNATS(ctx context.Context, data []byte) error {
 	// Size check
 	if len(data) > MaxPayloadSize {
@@ -364,7 +782,7 @@ func (s *Service) SaveDetectionFromNATS(ctx context.Context, data []byte) error
 		return err
 	}
 
-	if err := ValidateDetection(&payload); err != nil {
+	if err := s.ValidateDetection(&payload); err != nil {
 		return err
 	}
 
@@ -373,14 +791,7 @@ func (s *Service) SaveDetectionFromNATS(ctx context.Context, data []byte) error
 	if err != nil {
 		return fmt.Errorf("camera not found: %s", payload.CameraID)
 	}
-
-	// Store in Redis: det:latest:{tenant}:{camera}:{stream}
-	stream := payload.Stream
-	if stream == "" {
-		stream = "basic"
-	}
-	key := fmt.Sprintf("det:latest:%s:%s:%s", tenantID.String(), payload.CameraID, stream)
-	return s.Redis.Set(ctx, key, data, DetectionTTL).Err()
+	return s.SaveDetection(ctx, tenantID, &payload)
 }
 
 // ActiveCamera is returned by GetActiveCamerasForAI
@@ -389,29 +800,20 @@ type ActiveCamera struct {
 	TenantID string `json:"tenant_id"`
 }
 
-// GetActiveCamerasForAI returns cameras with overlay demand seen within 20s
+// GetActiveCamerasForAI returns cameras with active overlay demand (most
+// recent first) and their tenants. Cameras whose tenant can't be resolved
+// are skipped.
 func (s *Service) GetActiveCamerasForAI(ctx context.Context) ([]ActiveCamera, error) {
-	// Use ZSET with timestamp scores for demand expiry
-	// Key: overlay:demand (score = last_seen_unix_ms)
-	key := "overlay:demand"
-
-	// Get all cameras with score > (now - 20s)
-	cutoff := float64(time.Now().Add(-OverlayDemandTTL).UnixMilli())
-	results, err := s.Redis.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
-		Min: fmt.Sprintf("%f", cutoff),
-		Max: "+inf",
-	}).Result()
+	ids, err := s.activeOverlayDemand(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	cameras := make([]ActiveCamera, 0, len(results))
-	for _, z := range results {
-		camID := z.Member.(string)
-		// Resolve tenant for each camera
+	cameras := make([]ActiveCamera, 0, len(ids))
+	for _, camID := range ids {
 		tenantID, err := s.ResolveCameraTenant(ctx, camID)
 		if err != nil {
-			continue // Skip invalid cameras
+			continue
 		}
 		cameras = append(cameras, ActiveCamera{
 			CameraID: camID,
@@ -424,12 +826,24 @@ func (s *Service) GetActiveCamerasForAI(ctx context.Context) ([]ActiveCamera, er
 
 // RefreshOverlayDemand updates demand timestamp for a camera
 func (s *Service) RefreshOverlayDemand(ctx context.Context, cameraID string) error {
-	key := "overlay:demand"
 	score := float64(time.Now().UnixMilli())
-	return s.Redis.ZAdd(ctx, key, redis.Z{Score: score, Member: cameraID}).Err()
+	return s.Redis.ZAdd(ctx, OverlayDemandKey, redis.Z{Score: score, Member: cameraID}).Err()
+}
+
+// RefreshOverlayDemandMany updates demand for several cameras in one ZADD.
+func (s *Service) RefreshOverlayDemandMany(ctx context.Context, cameraIDs []string) error {
+	if len(cameraIDs) == 0 || s.Redis == nil {
+		return nil
+	}
+	score := float64(time.Now().UnixMilli())
+	members := make([]redis.Z, len(cameraIDs))
+	for i, id := range cameraIDs {
+		members[i] = redis.Z{Score: score, Member: id}
+	}
+	return s.Redis.ZAdd(ctx, OverlayDemandKey, members...).Err()
 }
 
 // ClearOverlayDemand removes a camera from demand tracking
 func (s *Service) ClearOverlayDemand(ctx context.Context, cameraID string) error {
-	return s.Redis.ZRem(ctx, "overlay:demand", cameraID).Err()
+	return s.Redis.ZRem(ctx, OverlayDemandKey, cameraID).Err()
 }
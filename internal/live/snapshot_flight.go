@@ -0,0 +1,99 @@
+package live
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSnapshotCacheTTL is how long a captured frame is reused for
+// subsequent requests of the same camera.
+const DefaultSnapshotCacheTTL = 2 * time.Second
+
+// DefaultSnapshotCaptureTimeout bounds a shared capture, which no longer
+// follows any single caller's context.
+const DefaultSnapshotCaptureTimeout = 15 * time.Second
+
+// SnapshotFlight collapses concurrent snapshot requests per camera into one
+// upstream capture (AI loop, UI thumbnails and diagnostics often ask at the
+// same moment) and reuses the frame for TTL afterwards. Failed captures are
+// shared with the waiters but never cached.
+type SnapshotFlight struct {
+	TTL     time.Duration
+	Timeout time.Duration // Per capture; 0 uses DefaultSnapshotCaptureTimeout
+
+	mu       sync.Mutex
+	inflight map[uuid.UUID]*snapshotCall
+	cache    map[uuid.UUID]cachedSnapshot
+}
+
+type snapshotCall struct {
+	done  chan struct{}
+	frame []byte
+	err   error
+}
+
+type cachedSnapshot struct {
+	frame   []byte
+	expires time.Time
+}
+
+func NewSnapshotFlight(ttl time.Duration) *SnapshotFlight {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return &SnapshotFlight{
+		TTL:      ttl,
+		inflight: make(map[uuid.UUID]*snapshotCall),
+		cache:    make(map[uuid.UUID]cachedSnapshot),
+	}
+}
+
+// Do returns a cached frame for cameraID, joins an in-flight capture, or runs
+// capture itself. The capture is detached from the caller's cancellation so
+// one client going away does not fail the others; each caller still stops
+// waiting when its own ctx ends. The returned frame is shared: do not modify it.
+func (f *SnapshotFlight) Do(ctx context.Context, cameraID uuid.UUID, capture func(context.Context) ([]byte, error)) ([]byte, error) {
+	f.mu.Lock()
+	if c, ok := f.cache[cameraID]; ok {
+		if time.Now().Before(c.expires) {
+			f.mu.Unlock()
+			return c.frame, nil
+		}
+		delete(f.cache, cameraID)
+	}
+	call, ok := f.inflight[cameraID]
+	if !ok {
+		call = &snapshotCall{done: make(chan struct{})}
+		f.inflight[cameraID] = call
+		go f.run(context.WithoutCancel(ctx), cameraID, call, capture)
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.frame, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *SnapshotFlight) run(ctx context.Context, cameraID uuid.UUID, call *snapshotCall, capture func(context.Context) ([]byte, error)) {
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSnapshotCaptureTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	call.frame, call.err = capture(ctx)
+	cancel()
+
+	f.mu.Lock()
+	delete(f.inflight, cameraID)
+	if call.err == nil && f.TTL > 0 {
+		f.cache[cameraID] = cachedSnapshot{frame: call.frame, expires: time.Now().Add(f.TTL)}
+	}
+	f.mu.Unlock()
+	close(call.done)
+}
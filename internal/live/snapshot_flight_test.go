@@ -0,0 +1,124 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSnapshotFlight_ConcurrentRequestsShareCapture(t *testing.T) {
+	f := NewSnapshotFlight(time.Second)
+	cam := uuid.New()
+
+	var captures atomic.Int32
+	release := make(chan struct{})
+	capture := func(ctx context.Context) ([]byte, error) {
+		captures.Add(1)
+		<-release
+		return []byte("frame"), nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]byte, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			frame, err := f.Do(context.Background(), cam, capture)
+			if err != nil {
+				t.Errorf("request %d: %v", i, err)
+			}
+			results[i] = frame
+		}(i)
+	}
+
+	// Let every request join before the capture finishes
+	deadline := time.Now().Add(time.Second)
+	for captures.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := captures.Load(); n != 1 {
+		t.Fatalf("Expected 1 upstream capture, got %d", n)
+	}
+	for i, r := range results {
+		if string(r) != "frame" {
+			t.Errorf("request %d got %q", i, r)
+		}
+	}
+
+	// Within TTL the cached frame is served without capturing
+	if _, err := f.Do(context.Background(), cam, capture); err != nil {
+		t.Fatal(err)
+	}
+	if n := captures.Load(); n != 1 {
+		t.Errorf("Expected cached frame, got %d captures", n)
+	}
+}
+
+func TestSnapshotFlight_ErrorsNotCached(t *testing.T) {
+	f := NewSnapshotFlight(time.Minute)
+	cam := uuid.New()
+
+	var captures int
+	failing := func(ctx context.Context) ([]byte, error) {
+		captures++
+		return nil, errors.New("camera offline")
+	}
+	if _, err := f.Do(context.Background(), cam, failing); err == nil {
+		t.Fatal("Expected capture error")
+	}
+	if _, err := f.Do(context.Background(), cam, failing); err == nil {
+		t.Fatal("Expected capture error")
+	}
+	if captures != 2 {
+		t.Errorf("Failed capture should be retried, got %d captures", captures)
+	}
+}
+
+func TestSnapshotFlight_CallerCancelDoesNotFailOthers(t *testing.T) {
+	f := NewSnapshotFlight(0)
+	cam := uuid.New()
+
+	release := make(chan struct{})
+	capture := func(ctx context.Context) ([]byte, error) {
+		select {
+		case <-release:
+			return []byte("frame"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := f.Do(leaderCtx, cam, capture)
+		leaderErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	follower := make(chan []byte, 1)
+	go func() {
+		frame, _ := f.Do(context.Background(), cam, capture)
+		follower <- frame
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected leader to stop waiting, got %v", err)
+	}
+	close(release)
+	if frame := <-follower; string(frame) != "frame" {
+		t.Errorf("Follower got %q", frame)
+	}
+}
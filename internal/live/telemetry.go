@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/redis/go-redis/v9"
@@ -52,10 +53,29 @@ var (
 		Name: "live_limit_exceeded_total",
 		Help: "Total rate limit errors returned",
 	})
+
+	metricQualityDowngradeTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "live_view_quality_downgrade_total",
+		Help: "Sessions started on HLS sub-stream due to repeated fallback",
+	})
+
+	metricDegradedSessionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "live_view_degraded_sessions_total",
+		Help: "Stateless live sessions issued while Redis was unavailable",
+	})
 )
 
 type TelemetryService struct {
 	Redis *redis.Client
+
+	// Rooms, when set, is told about each session heartbeat so the
+	// session's SFU room keeps its tenant slot while the viewer is active.
+	Rooms RoomToucher
+}
+
+// RoomToucher refreshes the SFU room reservation for a camera.
+type RoomToucher interface {
+	TouchRoom(tenantID, cameraID uuid.UUID)
 }
 
 func NewTelemetryService(r *redis.Client) *TelemetryService {
@@ -174,11 +194,19 @@ func (s *TelemetryService) RecordEvent(ctx context.Context, evt *TelemetryEvent)
 	// Heartbeat
 	s.Redis.Expire(ctx, sessKey, SessionTTL)
 	s.Redis.HSet(ctx, sessKey, "last_seen_at", time.Now().Format(time.RFC3339))
+	if s.Rooms != nil {
+		var vs ViewerSession
+		if jsonErr := json.Unmarshal([]byte(sessData), &vs); jsonErr == nil {
+			if cameraID, err := uuid.Parse(vs.CameraID); err == nil {
+				s.Rooms.TouchRoom(vs.TenantID, cameraID)
+			}
+		}
+	}
 
 	// Metrics
 	if evt.EventType == "fallback_to_hls" {
 		metricFallbackTotal.WithLabelValues(string(evt.ReasonCode)).Inc()
-		// Also could update session "mode" in Redis if needed
+		s.recordFallback(ctx, sessKey, sessData, evt.ReasonCode)
 	}
 	if evt.EventType == "tile_start" && evt.Mode == "grid" {
 		metricGridStartTotal.Inc()
@@ -202,3 +230,24 @@ func (s *TelemetryService) RecordEvent(ctx context.Context, evt *TelemetryEvent)
 
 	return nil
 }
+
+// recordFallback bumps the session FallbackCount and the per user+camera
+// history that StartLiveSession uses to decide on an HLS-first downgrade.
+func (s *TelemetryService) recordFallback(ctx context.Context, sessKey, sessData string, reason ReasonCode) {
+	var vs ViewerSession
+	if err := json.Unmarshal([]byte(sessData), &vs); err != nil || vs.CameraID == "" {
+		return
+	}
+
+	vs.FallbackCount++
+	vs.LastError = string(reason)
+	if b, err := json.Marshal(&vs); err == nil {
+		s.Redis.Set(ctx, sessKey, b, redis.KeepTTL)
+	}
+
+	histKey := fallbackHistoryKey(vs.TenantID, vs.UserID, vs.CameraID)
+	pipe := s.Redis.Pipeline()
+	pipe.Incr(ctx, histKey)
+	pipe.Expire(ctx, histKey, FallbackHistoryWindow)
+	pipe.Exec(ctx)
+}
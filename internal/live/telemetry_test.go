@@ -2,10 +2,12 @@ package live_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/technosupport/ts-vms/internal/live"
@@ -117,3 +119,25 @@ func setupTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
 	})
 	return s, rdb
 }
+
+type roomTouches struct{ touched []uuid.UUID }
+
+func (r *roomTouches) TouchRoom(tenantID, cameraID uuid.UUID) {
+	r.touched = append(r.touched, tenantID, cameraID)
+}
+
+func TestTelemetryService_HeartbeatTouchesSfuRoom(t *testing.T) {
+	_, rdb := setupTestRedis(t)
+	svc := live.NewTelemetryService(rdb)
+	rooms := &roomTouches{}
+	svc.Rooms = rooms
+	ctx := context.Background()
+
+	tenantID, cameraID := uuid.New(), uuid.New()
+	sess, _ := json.Marshal(live.ViewerSession{ID: "sess_room", TenantID: tenantID, CameraID: cameraID.String()})
+	rdb.Set(ctx, "live:sess:sess_room", sess, time.Minute)
+
+	err := svc.RecordEvent(ctx, &live.TelemetryEvent{ViewerSessionID: "sess_room", EventType: "webrtc_connected"})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{tenantID, cameraID}, rooms.touched)
+}
@@ -0,0 +1,82 @@
+package live
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tenantLRU is a bounded camera->tenant cache. Entries expire after the TTL
+// given to put; when full, the least recently used entry is evicted.
+type tenantLRU struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // Front = most recently used
+	items map[string]*list.Element
+}
+
+type tenantEntry struct {
+	cameraID  string
+	tenantID  uuid.UUID
+	expiresAt time.Time
+}
+
+func newTenantLRU(size int) *tenantLRU {
+	if size <= 0 {
+		size = DefaultTenantCacheSize
+	}
+	return &tenantLRU{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *tenantLRU) get(cameraID string) (uuid.UUID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[cameraID]
+	if !ok {
+		return uuid.Nil, false
+	}
+	e := el.Value.(*tenantEntry)
+	if !time.Now().Before(e.expiresAt) {
+		c.remove(el)
+		return uuid.Nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.tenantID, true
+}
+
+func (c *tenantLRU) put(cameraID string, tenantID uuid.UUID, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt := time.Now().Add(ttl)
+	if el, ok := c.items[cameraID]; ok {
+		e := el.Value.(*tenantEntry)
+		e.tenantID, e.expiresAt = tenantID, expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[cameraID] = c.order.PushFront(&tenantEntry{cameraID: cameraID, tenantID: tenantID, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *tenantLRU) invalidate(cameraID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[cameraID]; ok {
+		c.remove(el)
+	}
+}
+
+func (c *tenantLRU) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *tenantLRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*tenantEntry).cameraID)
+}
@@ -0,0 +1,118 @@
+package live
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/technosupport/ts-vms/internal/cameras"
+	"github.com/technosupport/ts-vms/internal/data"
+)
+
+func newTenantCacheService(ttl time.Duration, size int) (*Service, *countingRepo) {
+	repo := &countingRepo{}
+	camSvc := cameras.NewService(repo, &dummyLicense{}, &dummyAuditor{})
+	svc := &Service{CameraService: camSvc, TenantCacheTTL: ttl, TenantCacheSize: size}
+	camSvc.OnCamerasChanged(svc.InvalidateCameraTenants)
+	return svc, repo
+}
+
+func TestTenantCache_HitAndMiss(t *testing.T) {
+	svc, repo := newTenantCacheService(time.Minute, 0)
+	ctx := context.Background()
+	a, b := uuid.New().String(), uuid.New().String()
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.ResolveCameraTenant(ctx, a)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), repo.lookups.Load(), "repeat lookups are hits")
+
+	_, err := svc.ResolveCameraTenant(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), repo.lookups.Load(), "another camera is a miss")
+}
+
+func TestTenantCache_TTLExpiry(t *testing.T) {
+	svc, repo := newTenantCacheService(20*time.Millisecond, 0)
+	ctx := context.Background()
+	cam := uuid.New().String()
+
+	_, _ = svc.ResolveCameraTenant(ctx, cam)
+	_, _ = svc.ResolveCameraTenant(ctx, cam)
+	require.Equal(t, int32(1), repo.lookups.Load())
+
+	time.Sleep(30 * time.Millisecond)
+	_, _ = svc.ResolveCameraTenant(ctx, cam)
+	assert.Equal(t, int32(2), repo.lookups.Load(), "expired entry must be looked up again")
+}
+
+func TestTenantCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	svc, repo := newTenantCacheService(time.Minute, 2)
+	ctx := context.Background()
+	a, b, c := uuid.New().String(), uuid.New().String(), uuid.New().String()
+
+	_, _ = svc.ResolveCameraTenant(ctx, a)
+	_, _ = svc.ResolveCameraTenant(ctx, b)
+	_, _ = svc.ResolveCameraTenant(ctx, a) // a is now most recent
+	_, _ = svc.ResolveCameraTenant(ctx, c) // evicts b
+	require.Equal(t, int32(3), repo.lookups.Load())
+	assert.Equal(t, 2, svc.tenants().len())
+
+	_, _ = svc.ResolveCameraTenant(ctx, a)
+	assert.Equal(t, int32(3), repo.lookups.Load(), "a must survive eviction")
+	_, _ = svc.ResolveCameraTenant(ctx, b)
+	assert.Equal(t, int32(4), repo.lookups.Load(), "b must have been evicted")
+}
+
+func TestTenantCache_InvalidatedOnMoveAndDelete(t *testing.T) {
+	svc, repo := newTenantCacheService(time.Minute, 0)
+	ctx := context.Background()
+	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	moved, deleted := uuid.New(), uuid.New()
+
+	_, _ = svc.ResolveCameraTenant(ctx, moved.String())
+	_, _ = svc.ResolveCameraTenant(ctx, deleted.String())
+	require.Equal(t, int32(2), repo.lookups.Load())
+
+	_, err := svc.CameraService.BulkMoveSite(ctx, tenantID, []uuid.UUID{moved}, uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, svc.CameraService.DeleteCamera(ctx, deleted, tenantID))
+
+	_, _ = svc.ResolveCameraTenant(ctx, moved.String())
+	_, _ = svc.ResolveCameraTenant(ctx, deleted.String())
+	assert.Equal(t, int32(4), repo.lookups.Load(), "moved and deleted cameras must be looked up again")
+}
+
+// purgeRepo offers the given soft-deleted cameras to the purger.
+type purgeRepo struct {
+	countingRepo
+	purgeable []data.PurgeCandidate
+}
+
+func (p *purgeRepo) ListPurgeable(ctx context.Context, cutoff time.Time, limit int) ([]data.PurgeCandidate, error) {
+	return p.purgeable, nil
+}
+
+func TestTenantCache_InvalidatedOnPurge(t *testing.T) {
+	tenantID, purged := uuid.New(), uuid.New()
+	repo := &purgeRepo{purgeable: []data.PurgeCandidate{{CameraID: purged, TenantID: tenantID}}}
+	camSvc := cameras.NewService(repo, &dummyLicense{}, &dummyAuditor{})
+	svc := &Service{CameraService: camSvc, TenantCacheTTL: time.Minute}
+	camSvc.OnCamerasChanged(svc.InvalidateCameraTenants)
+	ctx := context.Background()
+
+	_, _ = svc.ResolveCameraTenant(ctx, purged.String())
+	_, _ = svc.ResolveCameraTenant(ctx, purged.String())
+	require.Equal(t, int32(1), repo.lookups.Load())
+
+	n, err := camSvc.PurgeDeletedCameras(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, _ = svc.ResolveCameraTenant(ctx, purged.String())
+	assert.Equal(t, int32(2), repo.lookups.Load(), "purged camera must be looked up again")
+}
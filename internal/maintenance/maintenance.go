@@ -0,0 +1,160 @@
+// Package maintenance holds the maintenance-mode switch that pauses the
+// background pollers (NVR monitor, event poller, camera health scheduler)
+// during planned network work, for one tenant or the whole system.
+package maintenance
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSyncInterval is how often StartSync reloads tenant maintenance
+// from the Store, bounding how long instances disagree after a toggle.
+const DefaultSyncInterval = 30 * time.Second
+
+// Store persists tenant maintenance so it survives restarts and is shared
+// by every instance. A nil since clears the tenant.
+type Store interface {
+	SetTenantMaintenance(ctx context.Context, tenantID uuid.UUID, since *time.Time) error
+	ListTenantMaintenance(ctx context.Context) (map[uuid.UUID]time.Time, error)
+}
+
+// Switch records which tenants, or all of them, are in maintenance. A nil
+// *Switch is never active, so pollers need no nil checks. Global maintenance
+// comes from config only; tenant maintenance is persisted to Store when set.
+type Switch struct {
+	mu          sync.RWMutex
+	globalSince *time.Time
+	tenants     map[uuid.UUID]time.Time // tenant -> since
+	now         func() time.Time
+
+	// Store, when set, persists tenant maintenance; nil keeps it in memory.
+	Store Store
+}
+
+// Status is the maintenance state seen by one tenant.
+type Status struct {
+	Active      bool       `json:"active"`
+	Global      bool       `json:"global"`
+	GlobalSince *time.Time `json:"global_since,omitempty"`
+	Tenant      bool       `json:"tenant"`
+	TenantSince *time.Time `json:"tenant_since,omitempty"`
+}
+
+func New() *Switch {
+	return &Switch{tenants: make(map[uuid.UUID]time.Time), now: time.Now}
+}
+
+// Active reports whether work for tenantID should be paused.
+func (s *Switch) Active(tenantID uuid.UUID) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.globalSince != nil {
+		return true
+	}
+	_, ok := s.tenants[tenantID]
+	return ok
+}
+
+// SetGlobal turns system-wide maintenance on or off. Turning it on again
+// keeps the original start time.
+func (s *Switch) SetGlobal(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case on && s.globalSince == nil:
+		t := s.now()
+		s.globalSince = &t
+	case !on:
+		s.globalSince = nil
+	}
+}
+
+// SetTenant turns maintenance on or off for one tenant, persisting it to
+// Store first. Turning it on again keeps the original start time.
+func (s *Switch) SetTenant(ctx context.Context, tenantID uuid.UUID, on bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var since *time.Time
+	if on {
+		t, ok := s.tenants[tenantID]
+		if !ok {
+			t = s.now()
+		}
+		since = &t
+	}
+	if s.Store != nil {
+		if err := s.Store.SetTenantMaintenance(ctx, tenantID, since); err != nil {
+			return err
+		}
+	}
+	if since == nil {
+		delete(s.tenants, tenantID)
+	} else {
+		s.tenants[tenantID] = *since
+	}
+	return nil
+}
+
+// Load replaces the tenant maintenance set with the one in Store.
+func (s *Switch) Load(ctx context.Context) error {
+	if s.Store == nil {
+		return nil
+	}
+	tenants, err := s.Store.ListTenantMaintenance(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants = tenants
+	return nil
+}
+
+// StartSync reloads tenant maintenance from Store every interval until ctx
+// is cancelled, so toggles made on another instance take effect here too.
+func (s *Switch) StartSync(ctx context.Context, interval time.Duration) {
+	if s.Store == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Load(ctx); err != nil {
+					log.Printf("[Maintenance] Reload failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Status returns the state seen by tenantID.
+func (s *Switch) Status(tenantID uuid.UUID) Status {
+	if s == nil {
+		return Status{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var st Status
+	if s.globalSince != nil {
+		t := *s.globalSince
+		st.Global, st.GlobalSince = true, &t
+	}
+	if since, ok := s.tenants[tenantID]; ok {
+		st.Tenant, st.TenantSince = true, &since
+	}
+	st.Active = st.Global || st.Tenant
+	return st
+}
@@ -0,0 +1,96 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSwitch_TenantAndGlobal(t *testing.T) {
+	var nilSwitch *Switch
+	if nilSwitch.Active(uuid.New()) {
+		t.Fatal("nil switch must never be active")
+	}
+
+	s := New()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return start }
+	a, b := uuid.New(), uuid.New()
+
+	s.SetTenant(context.Background(), a, true)
+	if !s.Active(a) || s.Active(b) {
+		t.Fatalf("Expected only tenant a in maintenance")
+	}
+
+	s.SetGlobal(true)
+	s.now = func() time.Time { return start.Add(time.Hour) }
+	s.SetGlobal(true)
+	if !s.Active(b) {
+		t.Fatal("Expected global maintenance to cover every tenant")
+	}
+	st := s.Status(a)
+	if !st.Active || !st.Global || !st.Tenant || !st.GlobalSince.Equal(start) {
+		t.Errorf("Unexpected status %+v", st)
+	}
+
+	s.SetGlobal(false)
+	s.SetTenant(context.Background(), a, false)
+	if s.Active(a) || s.Active(b) {
+		t.Error("Expected maintenance cleared")
+	}
+}
+
+// memStore is a Store shared by switches, standing in for the tenants table
+type memStore struct{ tenants map[uuid.UUID]time.Time }
+
+func (m *memStore) SetTenantMaintenance(_ context.Context, tenantID uuid.UUID, since *time.Time) error {
+	if since == nil {
+		delete(m.tenants, tenantID)
+	} else {
+		m.tenants[tenantID] = *since
+	}
+	return nil
+}
+
+func (m *memStore) ListTenantMaintenance(context.Context) (map[uuid.UUID]time.Time, error) {
+	out := make(map[uuid.UUID]time.Time, len(m.tenants))
+	for id, since := range m.tenants {
+		out[id] = since
+	}
+	return out, nil
+}
+
+func TestSwitch_PersistsTenantMaintenance(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{tenants: map[uuid.UUID]time.Time{}}
+	tenantID := uuid.New()
+
+	s := New()
+	s.Store = store
+	if err := s.SetTenant(ctx, tenantID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted (or second) instance picks the state up from the store
+	other := New()
+	other.Store = store
+	if other.Active(tenantID) {
+		t.Fatal("Expected no state before Load")
+	}
+	if err := other.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !other.Active(tenantID) {
+		t.Error("Expected tenant maintenance to survive a restart")
+	}
+
+	if err := other.SetTenant(ctx, tenantID, false); err != nil {
+		t.Fatal(err)
+	}
+	s.Load(ctx)
+	if s.Active(tenantID) {
+		t.Error("Expected the clear to reach the other instance on reload")
+	}
+}
@@ -1,7 +1,14 @@
 package media
 
 import (
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/technosupport/ts-vms/internal/metrics"
 )
 
 func TestSanitizeRTSPURL(t *testing.T) {
@@ -87,3 +94,80 @@ func TestDeduplication(t *testing.T) {
 		t.Error("Flag SubIsSameAsMain should be true")
 	}
 }
+
+func TestValidator_HonorsConfiguredConcurrency(t *testing.T) {
+	const workers = 3
+	var running, peak int32
+	release := make(chan struct{})
+	var done sync.WaitGroup
+
+	v := newValidator(ValidatorConfig{Workers: workers, QueueSize: 20}, func(ValidationJob, ValidationResult) { done.Done() },
+		func(ValidationJob) ValidationResult {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			<-release
+			atomic.AddInt32(&running, -1)
+			return ValidationResult{Status: StatusValid}
+		})
+
+	const jobs = 10
+	done.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		if !v.Enqueue(ValidationJob{CameraID: uuid.New(), Variant: "main"}) {
+			t.Fatalf("Enqueue %d unexpectedly rejected", i)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&running) < workers && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // Give any extra worker a chance to show up
+	close(release)
+	done.Wait()
+
+	if got := atomic.LoadInt32(&peak); got != workers {
+		t.Errorf("Expected peak concurrency %d, got %d", workers, got)
+	}
+}
+
+func TestValidator_EnqueueDropsWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 1)
+
+	v := newValidator(ValidatorConfig{Workers: 1, QueueSize: 2}, nil, func(ValidationJob) ValidationResult {
+		started <- struct{}{}
+		<-release
+		return ValidationResult{Status: StatusValid}
+	})
+
+	// One job occupies the worker, two fill the queue
+	v.Enqueue(ValidationJob{CameraID: uuid.New(), Variant: "main"})
+	<-started
+	for i := 0; i < 2; i++ {
+		if !v.Enqueue(ValidationJob{CameraID: uuid.New(), Variant: "main"}) {
+			t.Fatalf("Enqueue %d should fit in the queue", i)
+		}
+	}
+
+	before := testutil.ToFloat64(metrics.MediaValidationDroppedTotal)
+	accepted := make(chan bool)
+	go func() { accepted <- v.Enqueue(ValidationJob{CameraID: uuid.New(), Variant: "main"}) }()
+	select {
+	case ok := <-accepted:
+		if ok {
+			t.Error("Enqueue on a full queue should be rejected")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue blocked on a full queue")
+	}
+	if got := testutil.ToFloat64(metrics.MediaValidationDroppedTotal) - before; got != 1 {
+		t.Errorf("Expected 1 drop recorded, got %v", got)
+	}
+}
@@ -1,6 +1,8 @@
 package media
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
@@ -9,14 +11,36 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/metrics"
+	"github.com/technosupport/ts-vms/internal/nvr/adapters"
 )
 
 const (
+	// WorkerPoolSize and QueueSize are the defaults for ValidatorConfig.
 	WorkerPoolSize    = 5
 	QueueSize         = 100
 	ValidationTimeout = 5 * time.Second
 )
 
+// ValidatorConfig sizes the validation pool; zero fields use the defaults.
+type ValidatorConfig struct {
+	Workers   int `yaml:"workers"`    // Concurrent RTSP probes
+	QueueSize int `yaml:"queue_size"` // Jobs waiting for a worker; beyond this Enqueue drops
+	// DescribeProbe also DESCRIBEs the stream and compares the advertised
+	// codec/resolution with the job's Expected profile.
+	DescribeProbe bool `yaml:"describe_probe"`
+}
+
+func (c ValidatorConfig) withDefaults() ValidatorConfig {
+	if c.Workers <= 0 {
+		c.Workers = WorkerPoolSize
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = QueueSize
+	}
+	return c
+}
+
 type ValidationStatus string
 
 const (
@@ -27,6 +51,7 @@ const (
 	StatusTimeout            ValidationStatus = "timeout"
 	StatusRTSP_URIMissing    ValidationStatus = "rtsp_uri_missing"
 	StatusUnsupportedCodec   ValidationStatus = "unsupported_codec" // If we added that check
+	StatusProfileMismatch    ValidationStatus = "profile_mismatch"  // DescribeProbe: stream differs from the stored profile
 	StatusError              ValidationStatus = "error"
 )
 
@@ -43,6 +68,9 @@ type ValidationJob struct {
 	RTSPURL  string
 	Username string
 	Password string
+	// Expected is the stored profile's codec/resolution, checked when
+	// DescribeProbe is on.
+	Expected *adapters.StreamInfo
 }
 
 type Validator struct {
@@ -54,6 +82,9 @@ type Validator struct {
 
 	// Callback for persistence
 	OnResult func(job ValidationJob, res ValidationResult)
+
+	validateFn func(ValidationJob) ValidationResult
+	describe   bool
 }
 
 type jobResult struct {
@@ -61,15 +92,26 @@ type jobResult struct {
 	Res ValidationResult
 }
 
-func NewValidator(onResult func(ValidationJob, ValidationResult)) *Validator {
+func NewValidator(cfg ValidatorConfig, onResult func(ValidationJob, ValidationResult)) *Validator {
+	return newValidator(cfg, onResult, nil)
+}
+
+// newValidator starts the pool; validate replaces the RTSP probe in tests.
+func newValidator(cfg ValidatorConfig, onResult func(ValidationJob, ValidationResult), validate func(ValidationJob) ValidationResult) *Validator {
+	cfg = cfg.withDefaults()
 	v := &Validator{
-		jobs:     make(chan ValidationJob, QueueSize),
-		results:  make(chan jobResult, QueueSize),
-		pending:  make(map[string]bool),
-		OnResult: onResult,
+		jobs:       make(chan ValidationJob, cfg.QueueSize),
+		results:    make(chan jobResult, cfg.QueueSize),
+		pending:    make(map[string]bool),
+		OnResult:   onResult,
+		validateFn: validate,
+		describe:   cfg.DescribeProbe,
+	}
+	if v.validateFn == nil {
+		v.validateFn = v.validate
 	}
 	// Start workers
-	for i := 0; i < WorkerPoolSize; i++ {
+	for i := 0; i < cfg.Workers; i++ {
 		go v.worker()
 	}
 	// Start result processor
@@ -77,6 +119,9 @@ func NewValidator(onResult func(ValidationJob, ValidationResult)) *Validator {
 	return v
 }
 
+// Enqueue queues job without blocking. It returns false when the same
+// camera/variant is already pending or the queue is full; the latter is
+// counted in media_validation_dropped_total.
 func (v *Validator) Enqueue(job ValidationJob) bool {
 	v.mu.Lock()
 	defer v.mu.Unlock()
@@ -93,14 +138,14 @@ func (v *Validator) Enqueue(job ValidationJob) bool {
 	default:
 		// Queue full, drop or evict? User said "Bounded queue (drop/replace old)".
 		// Simplest Bounded: Drop new if full.
-		// Metrics should track dropped.
+		metrics.MediaValidationDroppedTotal.Inc()
 		return false
 	}
 }
 
 func (v *Validator) worker() {
 	for job := range v.jobs {
-		res := v.validate(job)
+		res := v.validateFn(job)
 		v.results <- jobResult{Job: job, Res: res}
 	}
 }
@@ -122,6 +167,9 @@ func (v *Validator) validate(job ValidationJob) ValidationResult {
 	if job.RTSPURL == "" {
 		return ValidationResult{Status: StatusRTSP_URIMissing, LastErrorCode: "empty_url"}
 	}
+	if v.describe {
+		return validateStream(job)
+	}
 
 	// Inject Credentials into URL for connection test
 	targetURL := job.RTSPURL
@@ -200,6 +248,39 @@ func (v *Validator) validate(job ValidationJob) ValidationResult {
 	return ValidationResult{Status: StatusInvalid, LastErrorCode: "unknown_response", RTT: rtt}
 }
 
+// validateStream is the DescribeProbe variant of validate: OPTIONS plus an
+// authenticated DESCRIBE whose video track must match job.Expected.
+func validateStream(job ValidationJob) ValidationResult {
+	u, err := url.Parse(job.RTSPURL)
+	if err != nil {
+		return ValidationResult{Status: StatusInvalid, LastErrorCode: "parse_error"}
+	}
+	if job.Username != "" {
+		u.User = url.UserPassword(job.Username, job.Password)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ValidationTimeout)
+	defer cancel()
+	start := time.Now()
+	res, err := adapters.ProbeRTSPStream(ctx, u.String(), adapters.RTSPProbeOptions{Describe: true, Expected: job.Expected})
+	rtt := int(time.Since(start).Milliseconds())
+
+	var netErr net.Error
+	switch {
+	case err == nil && res.Mismatch:
+		return ValidationResult{Status: StatusProfileMismatch, LastErrorCode: strings.Join(res.Mismatches, ","), RTT: rtt}
+	case err == nil:
+		return ValidationResult{Status: StatusValid, RTT: rtt}
+	case strings.HasPrefix(err.Error(), "auth_failed: "):
+		return ValidationResult{Status: StatusUnauthorized, LastErrorCode: strings.TrimPrefix(err.Error(), "auth_failed: ") + "_unauthorized", RTT: rtt}
+	case strings.HasPrefix(err.Error(), "stream_error: "):
+		return ValidationResult{Status: StatusInvalid, LastErrorCode: strings.TrimPrefix(err.Error(), "stream_error: "), RTT: rtt}
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ValidationResult{Status: StatusTimeout, LastErrorCode: "tcp_timeout"}
+	}
+	return ValidationResult{Status: StatusError, LastErrorCode: err.Error()}
+}
+
 // Helper: Sanitize URL
 func SanitizeRTSPURL(raw string) string {
 	u, err := url.Parse(raw)
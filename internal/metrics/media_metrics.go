@@ -0,0 +1,13 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// MediaValidationDroppedTotal counts RTSP validation jobs dropped because the
+// validator queue was full.
+var MediaValidationDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "media_validation_dropped_total",
+	Help: "RTSP validation jobs dropped because the validation queue was full",
+})
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	NATSConnected = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "nats_connected",
+		Help: "1 if the NATS connection is up, 0 otherwise",
+	})
+
+	NATSDisconnectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nats_disconnects_total",
+		Help: "Total number of NATS disconnections",
+	})
+
+	NATSReconnectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nats_reconnects_total",
+		Help: "Total number of successful NATS reconnections",
+	})
+
+	NATSPublishBuffered = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "nats_publish_buffered",
+		Help: "Messages currently buffered while NATS is disconnected",
+	})
+
+	NATSPublishDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nats_publish_dropped_total",
+		Help: "Buffered messages dropped because the publish buffer was full",
+	})
+
+	NATSDetectionsDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nats_detections_dropped_total",
+		Help: "Detection messages dropped because the ingest queue was full",
+	})
+)
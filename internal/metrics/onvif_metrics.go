@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ONVIF SOAP client metrics. operation is the request body element
+// (GetProfiles, GetStreamUri, ...), a bounded set defined by the client code.
+var (
+	OnvifSOAPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "onvif_soap_duration_seconds",
+		Help:    "ONVIF SOAP call latency by operation",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+	}, []string{"operation"})
+
+	OnvifSOAPErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onvif_soap_errors_total",
+		Help: "Failed ONVIF SOAP calls by operation and code (HTTP status, timeout or transport)",
+	}, []string{"operation", "code"})
+)
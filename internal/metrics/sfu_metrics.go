@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// SFU join outcome metrics.
+// Labels are low-cardinality (no camera_id/tenant_id/session_id).
+
+var (
+	// SfuJoinTotal counts JoinRoom attempts by outcome (success, room_full, sfu_failure, ...)
+	SfuJoinTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sfu_join_total",
+			Help: "Total SFU join attempts by result",
+		},
+		[]string{"result"},
+	)
+
+	// SfuFallbackTotal counts joins that returned an HLS fallback hint
+	SfuFallbackTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sfu_fallback_total",
+			Help: "Total SFU joins that fell back to HLS by reason",
+		},
+		[]string{"reason"},
+	)
+
+	// SfuJoinLatency tracks end-to-end JoinRoom latency
+	SfuJoinLatency = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "sfu_join_latency_seconds",
+			Help:    "SFU join latency in seconds",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+	)
+)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/data"
 )
 
@@ -36,6 +37,20 @@ func WithAuthContext(ctx context.Context, auth *AuthContext) context.Context {
 	return context.WithValue(ctx, AuthContextKey, auth)
 }
 
+// SystemContext attaches the system actor's AuthContext for tenantID, for
+// background jobs that run outside any request. Audit events written under
+// it carry audit.SystemActorID as their actor; uuid.Nil means no tenant.
+func SystemContext(ctx context.Context, tenantID uuid.UUID) context.Context {
+	ac := &AuthContext{
+		UserID: audit.SystemActorID().String(),
+		Roles:  []string{audit.SystemActorName},
+	}
+	if tenantID != uuid.Nil {
+		ac.TenantID = tenantID.String()
+	}
+	return WithAuthContext(ctx, ac)
+}
+
 // GetUserFromContext constructs a partial User object from AuthContext
 // This is a helper for Handlers that need a User struct for Service calls
 // Note: This User object only contains ID and TenantID from the token
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ConcurrencyLimit caps in-flight requests to one endpoint. Path uses the
+// same "*" / trailing "**" patterns as TimeoutGroup; every request matching
+// it shares one pool of MaxInFlight slots regardless of camera or tenant.
+type ConcurrencyLimit struct {
+	Method      string `yaml:"method"` // Empty matches any method
+	Path        string `yaml:"path"`
+	MaxInFlight int    `yaml:"max_in_flight"` // 0 disables the limit
+}
+
+// ConcurrencyConfig is the http_concurrency section of config/default.yaml.
+type ConcurrencyConfig struct {
+	RetryAfter time.Duration      `yaml:"retry_after"` // Retry-After sent with the 503
+	Endpoints  []ConcurrencyLimit `yaml:"endpoints"`   // First match wins
+}
+
+// DefaultConcurrencyConfig bounds the endpoints that fan out to devices
+// while the request is open: ONVIF probe, media profile fetch/selection and
+// NVR channel discovery/validation. Discovery runs and RTSP validation answer
+// before their work starts and are bounded by their own worker limits
+// (discovery.max_concurrent_runs, media_validation) instead.
+func DefaultConcurrencyConfig() ConcurrencyConfig {
+	return ConcurrencyConfig{
+		RetryAfter: 5 * time.Second,
+		Endpoints: []ConcurrencyLimit{
+			{Method: http.MethodPost, Path: "/api/v1/onvif/discovered-devices/*/probe", MaxInFlight: 8},
+			{Method: http.MethodPost, Path: "/api/v1/windows/discovery:scan", MaxInFlight: 2},
+			{Method: http.MethodGet, Path: "/api/v1/cameras/*/media-profiles", MaxInFlight: 8},
+			{Method: http.MethodPost, Path: "/api/v1/cameras/*/select-media-profiles", MaxInFlight: 8},
+			{Method: http.MethodPost, Path: "/api/v1/nvrs/*/discover-channels", MaxInFlight: 4},
+			{Method: http.MethodPost, Path: "/api/v1/nvrs/*/validate-channels", MaxInFlight: 4},
+		},
+	}
+}
+
+// NewConcurrencyLimiter rejects requests to a configured endpoint with 503
+// and Retry-After while MaxInFlight of them are already being served. The
+// slot is held until the handler returns, so it belongs inside the request
+// timeout wrapper, which can return before the handler does.
+func NewConcurrencyLimiter(cfg ConcurrencyConfig) func(http.Handler) http.Handler {
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = DefaultConcurrencyConfig().RetryAfter
+	}
+	retryAfter := strconv.Itoa(int((cfg.RetryAfter + time.Second - 1) / time.Second))
+
+	type limit struct {
+		method string
+		path   []string
+		slots  chan struct{}
+	}
+	limits := make([]limit, 0, len(cfg.Endpoints))
+	for _, e := range cfg.Endpoints {
+		l := limit{method: e.Method, path: splitPath(e.Path)}
+		if e.MaxInFlight > 0 {
+			l.slots = make(chan struct{}, e.MaxInFlight)
+		}
+		limits = append(limits, l)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			segs := splitPath(r.URL.Path)
+			for _, l := range limits {
+				if (l.method != "" && l.method != r.Method) || !matchPath(l.path, segs) {
+					continue
+				}
+				if l.slots == nil {
+					break
+				}
+				select {
+				case l.slots <- struct{}{}:
+					defer func() { <-l.slots }()
+				default:
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set("Retry-After", retryAfter)
+					w.WriteHeader(http.StatusServiceUnavailable)
+					w.Write([]byte(`{"step":"concurrency", "error_code":"ERR_TOO_MANY_IN_FLIGHT"}`))
+					return
+				}
+				break
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
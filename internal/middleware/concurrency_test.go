@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/technosupport/ts-vms/internal/middleware"
+)
+
+func TestConcurrencyLimiter_RejectsBeyondCapAndFreesSlots(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 4)
+	h := middleware.NewConcurrencyLimiter(middleware.ConcurrencyConfig{
+		RetryAfter: 2 * time.Second,
+		Endpoints: []middleware.ConcurrencyLimit{
+			{Method: http.MethodPost, Path: "/api/v1/cameras/*/select-media-profiles", MaxInFlight: 2},
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	// Fill both slots, on different cameras: the cap is per endpoint
+	var wg sync.WaitGroup
+	codes := make(chan int, 2)
+	for _, cam := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(cam string) {
+			defer wg.Done()
+			codes <- serve(http.MethodPost, "/api/v1/cameras/"+cam+"/select-media-profiles").Code
+		}(cam)
+	}
+	<-entered
+	<-entered
+
+	rr := serve(http.MethodPost, "/api/v1/cameras/c/select-media-profiles")
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 beyond the cap, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+
+	// Unlimited routes and other methods are not held back
+	go func() { <-entered }()
+	go func() { <-entered }()
+	done := make(chan int, 2)
+	go func() { done <- serve(http.MethodGet, "/api/v1/cameras/c/select-media-profiles").Code }()
+	go func() { done <- serve(http.MethodPost, "/api/v1/cameras").Code }()
+
+	close(release)
+	wg.Wait()
+	for i := 0; i < 2; i++ {
+		if c := <-codes; c != http.StatusOK {
+			t.Errorf("Expected in-flight request to complete, got %d", c)
+		}
+		if c := <-done; c != http.StatusOK {
+			t.Errorf("Expected unlimited request to pass, got %d", c)
+		}
+	}
+
+	// Slots are free again once the handlers have returned
+	go func() { <-entered }()
+	if rr := serve(http.MethodPost, "/api/v1/cameras/c/select-media-profiles"); rr.Code != http.StatusOK {
+		t.Errorf("Expected freed slot to admit the request, got %d", rr.Code)
+	}
+}
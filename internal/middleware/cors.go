@@ -2,24 +2,116 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-// CORS is a simple middleware to allow cross-origin requests.
-// For development and testing, we allow all origins or specific ones.
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Allow any origin for development/test purposes
-		// When running from file://, the origin is often "null"
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Internal-Auth")
-
-		// Handle preflight OPTIONS requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusNoContent)
-			return
+// CORSConfig is the cross-origin policy for the control-plane API
+// (cors section of config/default.yaml).
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins"` // "*" allows any origin
+	AllowedMethods   []string `yaml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers"`
+	ExposedHeaders   []string `yaml:"exposed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials"`
+	MaxAgeSeconds    int      `yaml:"max_age_seconds"`
+}
+
+// DefaultCORSConfig is the permissive development policy.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-Internal-Auth"},
+	}
+}
+
+// NewCORS returns a middleware applying cfg. It must wrap the auth layers so
+// preflight OPTIONS requests are answered before any token check:
+// allowed preflights get 204, preflights from other origins get 403.
+// Non-preflight requests from disallowed origins pass through without CORS
+// headers and the browser blocks the response.
+func NewCORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	defaults := DefaultCORSConfig()
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = defaults.AllowedMethods
+	}
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = defaults.AllowedHeaders
+	}
+
+	anyOrigin := false
+	origins := make(map[string]struct{}, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.TrimRight(strings.TrimSpace(o), "/")
+		if o == "*" {
+			anyOrigin = true
+			continue
+		}
+		origins[strings.ToLower(o)] = struct{}{}
+	}
+
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
 		}
+		_, ok := origins[strings.ToLower(origin)]
+		return ok
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			if !allowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// A literal "*" cannot be combined with credentials, so echo the origin.
+			if anyOrigin && !cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+			}
+
+			if preflight {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				if cfg.MaxAgeSeconds > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAgeSeconds))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CORS applies DefaultCORSConfig.
+func CORS(next http.Handler) http.Handler {
+	return NewCORS(DefaultCORSConfig())(next)
 }
@@ -0,0 +1,127 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/technosupport/ts-vms/internal/middleware"
+)
+
+func corsHandler(t *testing.T, cfg middleware.CORSConfig) (http.Handler, *bool) {
+	t.Helper()
+	reached := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		// Stand-in for the JWT layer: anything reaching here without a token is rejected.
+		if r.Header.Get("Authorization") == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return middleware.NewCORS(cfg)(next), &reached
+}
+
+func TestCORS_AllowedOrigin(t *testing.T) {
+	h, _ := corsHandler(t, middleware.CORSConfig{
+		AllowedOrigins:   []string{"https://vms.example.com"},
+		AllowCredentials: true,
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/cameras", nil)
+	req.Header.Set("Origin", "https://vms.example.com")
+	req.Header.Set("Authorization", "Bearer x")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://vms.example.com" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Allow-Credentials = %q", got)
+	}
+	if got := rr.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q", got)
+	}
+}
+
+func TestCORS_DisallowedOrigin(t *testing.T) {
+	h, reached := corsHandler(t, middleware.CORSConfig{AllowedOrigins: []string{"https://vms.example.com"}})
+
+	req := httptest.NewRequest("GET", "/api/v1/cameras", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Authorization", "Bearer x")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if !*reached {
+		t.Error("simple request should still reach the handler")
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no Allow-Origin for disallowed origin, got %q", got)
+	}
+
+	// Preflight from a disallowed origin is refused outright.
+	*reached = false
+	req = httptest.NewRequest("OPTIONS", "/api/v1/cameras", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rr.Code)
+	}
+	if *reached {
+		t.Error("disallowed preflight reached the handler")
+	}
+}
+
+func TestCORS_PreflightHandledBeforeAuth(t *testing.T) {
+	h, reached := corsHandler(t, middleware.CORSConfig{
+		AllowedOrigins: []string{"https://vms.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAgeSeconds:  600,
+	})
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/cameras", nil)
+	req.Header.Set("Origin", "https://vms.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rr.Code)
+	}
+	if *reached {
+		t.Error("preflight should not reach the auth layer")
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Errorf("Allow-Methods = %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+		t.Errorf("Allow-Headers = %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Max-Age = %q", got)
+	}
+}
+
+func TestCORS_WildcardOrigin(t *testing.T) {
+	h, _ := corsHandler(t, middleware.DefaultCORSConfig())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "null")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+}
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/technosupport/ts-vms/internal/auth"
 	"github.com/technosupport/ts-vms/internal/tokens"
 )
@@ -44,6 +46,13 @@ func (m *JWTAuth) Middleware(next http.Handler) http.Handler {
 
 		// 1. Validate Signature & Claims
 		claims, err := m.tokens.ValidateToken(tokenString)
+		if errors.Is(err, jwt.ErrTokenInvalidAudience) {
+			// Minted for another service (e.g. hlsd vs control plane)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"step":"auth", "error_code":"ERR_AUTH_AUDIENCE"}`))
+			return
+		}
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
@@ -2,8 +2,10 @@ package middleware_test
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -89,6 +91,36 @@ func TestJWTAuthMiddleware_MissingHeader(t *testing.T) {
 	}
 }
 
+func TestJWTAuthMiddleware_Audience(t *testing.T) {
+	controlPlane := tokens.NewManager("test-secret-key")
+	controlPlane.Issuer, controlPlane.Audience = tokens.DefaultIssuer, tokens.AudienceControlPlane
+	hls := tokens.NewManager("test-secret-key")
+	hls.Issuer, hls.Audience = tokens.DefaultIssuer, tokens.AudienceHLS
+
+	hlsToken, _ := hls.GenerateAccessToken("admin-user", "tenant-1")
+	apiToken, _ := controlPlane.GenerateAccessToken("admin-user", "tenant-1")
+
+	mw := middleware.NewJWTAuth(controlPlane, MockBlacklist{})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	// A token minted for hlsd is not replayable against the control plane
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+hlsToken)
+	w := httptest.NewRecorder()
+	mw.Middleware(ok).ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "ERR_AUTH_AUDIENCE") {
+		t.Errorf("Expected 401 ERR_AUTH_AUDIENCE, got %d %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+apiToken)
+	w = httptest.NewRecorder()
+	mw.Middleware(ok).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for the control-plane audience, got %d %s", w.Code, w.Body.String())
+	}
+}
+
 func TestPermissionMiddleware_TenantWide(t *testing.T) {
 	pm := middleware.NewPermissionMiddleware(MockPermissionModel{}, middleware.StubCameraResolver{})
 
@@ -155,3 +187,63 @@ func TestPermissionMiddleware_SiteScoped_Denied(t *testing.T) {
 		t.Errorf("Expected Forbidden (403), got %d", w.Code)
 	}
 }
+
+func denialBody(t *testing.T, pm *middleware.PermissionMiddleware, userID, perm, scope, target string) map[string]string {
+	t.Helper()
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{TenantID: "tenant-1", UserID: userID})
+	req := httptest.NewRequest("GET", target, nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	pm.RequirePermission(perm, scope)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected Forbidden (403), got %d", w.Code)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Denial body is not JSON: %v", err)
+	}
+	if body["error_code"] != "ERR_RBAC_DENIED" {
+		t.Errorf("Expected ERR_RBAC_DENIED, got %q", body["error_code"])
+	}
+	return body
+}
+
+func TestPermissionMiddleware_DenyDetails_MissingPermission(t *testing.T) {
+	pm := middleware.NewPermissionMiddleware(MockPermissionModel{}, middleware.StubCameraResolver{})
+	pm.DenyDetails = true
+
+	body := denialBody(t, pm, "site-manager", "cameras.manage", "tenant", "/")
+	if body["reason"] != middleware.DenyReasonMissingPermission || body["permission"] != "cameras.manage" || body["scope"] != "tenant" {
+		t.Errorf("Unexpected denial details: %v", body)
+	}
+}
+
+func TestPermissionMiddleware_DenyDetails_OutOfScope(t *testing.T) {
+	pm := middleware.NewPermissionMiddleware(MockPermissionModel{}, middleware.StubCameraResolver{})
+	pm.DenyDetails = true
+
+	body := denialBody(t, pm, "site-manager", "site.view", "site", "/?site_id=site-2")
+	if body["reason"] != middleware.DenyReasonOutOfScope || body["permission"] != "site.view" || body["scope"] != "site" {
+		t.Errorf("Unexpected denial details: %v", body)
+	}
+	if _, leaked := body["site_ids"]; leaked || len(body) != 5 {
+		t.Errorf("Denial should carry only step, error_code, reason, permission and scope, got %v", body)
+	}
+
+	// Site-scoped grant checked tenant-wide is also out of scope
+	body = denialBody(t, pm, "site-manager", "site.view", "tenant", "/")
+	if body["reason"] != middleware.DenyReasonOutOfScope {
+		t.Errorf("Expected out_of_scope for a site grant on a tenant check, got %v", body)
+	}
+}
+
+func TestPermissionMiddleware_DenyDetails_Off(t *testing.T) {
+	pm := middleware.NewPermissionMiddleware(MockPermissionModel{}, middleware.StubCameraResolver{})
+
+	body := denialBody(t, pm, "site-manager", "cameras.manage", "tenant", "/")
+	if _, ok := body["reason"]; ok || body["permission"] != "" || body["scope"] != "" {
+		t.Errorf("Details should be suppressed without DenyDetails, got %v", body)
+	}
+}
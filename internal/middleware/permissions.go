@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
@@ -79,11 +80,22 @@ type PermissionProvider interface {
 	GetPermissionsForUser(ctx context.Context, tenantID, userID string) (map[string]data.PermissionGrant, error)
 }
 
+// Denial reasons reported when DenyDetails is on.
+const (
+	DenyReasonMissingPermission = "missing_permission" // No grant for the permission at all
+	DenyReasonOutOfScope        = "out_of_scope"       // Granted, but not for the requested tenant/site/camera
+)
+
 // PermissionMiddleware handles hierarchical checks
 type PermissionMiddleware struct {
 	permsRepo      PermissionProvider
 	cameraResolver CameraResolver
 	cache          *permissionCache
+
+	// DenyDetails adds reason, permission and scope to 403 bodies so RBAC
+	// denials can be debugged. Only the checked permission is named; the
+	// user's other grants are never included.
+	DenyDetails bool
 }
 
 func NewPermissionMiddleware(pm PermissionProvider, cam CameraResolver) *PermissionMiddleware {
@@ -98,9 +110,15 @@ func NewPermissionMiddleware(pm PermissionProvider, cam CameraResolver) *Permiss
 
 // CheckPermission verifies if the user in context has the required permission for the scope
 func (m *PermissionMiddleware) CheckPermission(ctx context.Context, permSlug, scopeType, scopeID string) (bool, error) {
+	allowed, _, err := m.decide(ctx, permSlug, scopeType, scopeID)
+	return allowed, err
+}
+
+// decide is CheckPermission plus, for a denial, the DenyReason.
+func (m *PermissionMiddleware) decide(ctx context.Context, permSlug, scopeType, scopeID string) (bool, string, error) {
 	ac, ok := GetAuthContext(ctx)
 	if !ok {
-		return false, nil
+		return false, DenyReasonMissingPermission, nil
 	}
 
 	// 1. Fetch Permissions (Cached)
@@ -110,7 +128,7 @@ func (m *PermissionMiddleware) CheckPermission(ctx context.Context, permSlug, sc
 		var err error
 		grants, err = m.permsRepo.GetPermissionsForUser(ctx, ac.TenantID, ac.UserID)
 		if err != nil {
-			return false, err
+			return false, "", err
 		}
 		m.cache.set(cacheKey, grants, 60*time.Second)
 	}
@@ -118,18 +136,18 @@ func (m *PermissionMiddleware) CheckPermission(ctx context.Context, permSlug, sc
 	// 2. Check Permission Exists
 	grant, exists := grants[permSlug]
 	if !exists {
-		return false, nil
+		return false, DenyReasonMissingPermission, nil
 	}
 
 	// 3. Hierarchical Check
 	if scopeType == "tenant" {
-		return grant.TenantWide, nil
+		return scoped(grant.TenantWide)
 	} else if scopeType == "site" {
 		if grant.TenantWide {
-			return true, nil
+			return true, "", nil
 		}
 		_, ok := grant.SiteIDs[scopeID]
-		return ok, nil
+		return scoped(ok)
 	} else if scopeType == "camera" {
 		// Note: For camera scope, we usually need resolution first.
 		// If scopeID is passed here, we assume it's CAMERA ID? Or SITE ID?
@@ -138,15 +156,50 @@ func (m *PermissionMiddleware) CheckPermission(ctx context.Context, permSlug, sc
 		// We resolve here.
 		siteID, err := m.cameraResolver.ResolveSiteID(ctx, scopeID)
 		if err != nil {
-			return false, nil // Camera not found or error leads to deny
+			return false, DenyReasonOutOfScope, nil // Camera not found or error leads to deny
 		}
 		if grant.TenantWide {
-			return true, nil
+			return true, "", nil
 		}
 		_, ok := grant.SiteIDs[siteID]
-		return ok, nil
+		return scoped(ok)
+	}
+	return false, DenyReasonOutOfScope, nil
+}
+
+// scoped turns a scope match into a decide result.
+func scoped(ok bool) (bool, string, error) {
+	if ok {
+		return true, "", nil
+	}
+	return false, DenyReasonOutOfScope, nil
+}
+
+// rbacDenial is the 403 body; the detail fields are set only with DenyDetails.
+type rbacDenial struct {
+	Step       string `json:"step"`
+	ErrorCode  string `json:"error_code"`
+	Reason     string `json:"reason,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	Scope      string `json:"scope,omitempty"`
+}
+
+// Authorize checks permSlug on the scope for the caller and, on denial,
+// writes the 403 ERR_RBAC_DENIED body. For handlers whose scope is only known
+// after reading the request body.
+func (m *PermissionMiddleware) Authorize(w http.ResponseWriter, r *http.Request, permSlug, scopeType, scopeID string) bool {
+	allowed, reason, err := m.decide(r.Context(), permSlug, scopeType, scopeID)
+	if err == nil && allowed {
+		return true
+	}
+	body := rbacDenial{Step: "rbac", ErrorCode: "ERR_RBAC_DENIED"}
+	if m.DenyDetails && err == nil {
+		body.Reason, body.Permission, body.Scope = reason, permSlug, scopeType
 	}
-	return false, nil
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(body)
+	return false
 }
 
 // RequirePermission returns a middleware that enforces the permission
@@ -169,11 +222,7 @@ func (m *PermissionMiddleware) RequirePermission(permSlug string, scopeType stri
 				}
 			}
 
-			allowed, err := m.CheckPermission(r.Context(), permSlug, scopeType, scopeID)
-			if err != nil || !allowed {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusForbidden)
-				w.Write([]byte(`{"step":"rbac", "error_code":"ERR_RBAC_DENIED"}`))
+			if !m.Authorize(w, r, permSlug, scopeType, scopeID) {
 				return
 			}
 
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TimeoutGroup bounds requests whose path matches one of Paths. In a path
+// pattern "*" matches one segment and a trailing "**" matches the rest.
+type TimeoutGroup struct {
+	Name    string        `yaml:"name"`
+	Timeout time.Duration `yaml:"timeout"` // 0 disables the timeout (websockets, downloads)
+	Paths   []string      `yaml:"paths"`
+}
+
+// TimeoutConfig is the per-route-group request timeout policy for the
+// control-plane API (http_timeouts section of config/default.yaml).
+type TimeoutConfig struct {
+	Default time.Duration  `yaml:"default"` // Requests matching no group
+	Groups  []TimeoutGroup `yaml:"groups"`  // First match wins
+}
+
+// DefaultTimeoutConfig keeps CRUD short and gives device-facing operations
+// (ONVIF probe, NVR discovery, media/RTSP validation) room to finish.
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		Default: 15 * time.Second,
+		Groups: []TimeoutGroup{
+			{
+				Name: "stream",
+				Paths: []string{
+					"/api/v1/sfu/ws",
+					"/api/v1/audit/exports/*/download",
+				},
+			},
+			{
+				Name:    "device",
+				Timeout: 60 * time.Second,
+				Paths: []string{
+					"/api/v1/onvif/**",
+					"/api/v1/windows/**",
+					"/api/v1/nvrs/*/test-connection",
+					"/api/v1/nvrs/*/discover-channels",
+					"/api/v1/nvrs/*/validate-channels",
+					"/api/v1/nvrs/*/provision-cameras",
+					"/api/v1/nvrs/*/adapter/**",
+					"/api/v1/cameras/*/media-profiles",
+					"/api/v1/cameras/*/select-media-profiles",
+					"/api/v1/cameras/*/validate-rtsp",
+					"/api/v1/cameras/*/imaging",
+					"/api/v1/cameras/*/snapshot",
+					"/api/v1/cameras/*/health-recheck",
+					"/api/v1/cameras/*/live/start",
+					"/api/v1/internal/cameras/*/snapshot",
+					"/api/v1/audit/**",
+				},
+			},
+		},
+	}
+}
+
+// TimeoutMessage is the body sent with the 503 of a timed-out request.
+const TimeoutMessage = "request timed out"
+
+// NewRequestTimeouts wraps the mux so each request runs under its group's
+// timeout. The handler's context is cancelled at the deadline and the client
+// gets 503; whatever the handler wrote so far is discarded. Groups with a
+// zero timeout pass through untouched, since http.TimeoutHandler buffers the
+// response and cannot flush or hijack.
+func NewRequestTimeouts(cfg TimeoutConfig) func(http.Handler) http.Handler {
+	if cfg.Default <= 0 {
+		cfg.Default = DefaultTimeoutConfig().Default
+	}
+	return func(next http.Handler) http.Handler {
+		type group struct {
+			paths   [][]string
+			handler http.Handler
+		}
+		groups := make([]group, 0, len(cfg.Groups))
+		for _, g := range cfg.Groups {
+			h := next
+			if g.Timeout > 0 {
+				h = http.TimeoutHandler(next, g.Timeout, TimeoutMessage)
+			}
+			paths := make([][]string, 0, len(g.Paths))
+			for _, p := range g.Paths {
+				paths = append(paths, splitPath(p))
+			}
+			groups = append(groups, group{paths: paths, handler: h})
+		}
+		fallback := http.TimeoutHandler(next, cfg.Default, TimeoutMessage)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			segs := splitPath(r.URL.Path)
+			for _, g := range groups {
+				for _, p := range g.paths {
+					if matchPath(p, segs) {
+						g.handler.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			fallback.ServeHTTP(w, r)
+		})
+	}
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
+
+// matchPath reports whether path segments match pattern segments.
+func matchPath(pattern, path []string) bool {
+	for i, p := range pattern {
+		if p == "**" {
+			return i == len(pattern)-1 && len(path) > i
+		}
+		if i >= len(path) {
+			return false
+		}
+		if p != "*" && p != path[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(path)
+}
@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/technosupport/ts-vms/internal/middleware"
+)
+
+// slowHandler blocks until the request context ends (or 2s pass) and records
+// why it stopped.
+func slowHandler(cancelled chan<- bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			cancelled <- true
+		case <-time.After(2 * time.Second):
+			cancelled <- false
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+func timeoutTestConfig() middleware.TimeoutConfig {
+	return middleware.TimeoutConfig{
+		Default: 50 * time.Millisecond,
+		Groups: []middleware.TimeoutGroup{
+			{Name: "stream", Paths: []string{"/api/v1/sfu/ws"}},
+			{Name: "device", Timeout: 300 * time.Millisecond, Paths: []string{"/api/v1/onvif/**", "/api/v1/cameras/*/validate-rtsp"}},
+		},
+	}
+}
+
+func TestRequestTimeouts_SlowHandlerCutOffAtGroupTimeout(t *testing.T) {
+	cases := []struct {
+		name, path string
+		min, max   time.Duration
+	}{
+		{"crud uses default", "/api/v1/cameras", 50 * time.Millisecond, 250 * time.Millisecond},
+		{"device prefix", "/api/v1/onvif/discovered-devices/1/probe", 300 * time.Millisecond, 1500 * time.Millisecond},
+		{"device wildcard", "/api/v1/cameras/abc/validate-rtsp", 300 * time.Millisecond, 1500 * time.Millisecond},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cancelled := make(chan bool, 1)
+			h := middleware.NewRequestTimeouts(timeoutTestConfig())(slowHandler(cancelled))
+
+			start := time.Now()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("POST", tc.path, nil))
+			elapsed := time.Since(start)
+
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Fatalf("Expected 503, got %d", rec.Code)
+			}
+			if elapsed < tc.min || elapsed > tc.max {
+				t.Errorf("Expected cut-off between %s and %s, took %s", tc.min, tc.max, elapsed)
+			}
+			if !<-cancelled {
+				t.Error("Handler context must be cancelled at the deadline")
+			}
+		})
+	}
+}
+
+func TestRequestTimeouts_FastHandlerUnaffected(t *testing.T) {
+	h := middleware.NewRequestTimeouts(timeoutTestConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/cameras", nil))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Expected 201, got %d", rec.Code)
+	}
+}
+
+func TestRequestTimeouts_ZeroTimeoutPassesThrough(t *testing.T) {
+	var flushable bool
+	h := middleware.NewRequestTimeouts(timeoutTestConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flushable = w.(http.Flusher)
+		_, hasDeadline := r.Context().Deadline()
+		if hasDeadline {
+			t.Error("Exempt group must not get a deadline")
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/sfu/ws", nil))
+	if !flushable {
+		t.Error("Exempt group must get the original ResponseWriter")
+	}
+}
@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultClientIdleTTL is how long a pooled NVR client may go unused before
+// ClientPool drops it and closes its idle connections.
+const DefaultClientIdleTTL = 5 * time.Minute
+
+// ClientPool hands out one keep-alive http.Client per NVR so repeated probes
+// of the same NVR reuse TCP/TLS connections instead of dialing per call.
+type ClientPool struct {
+	idleTTL time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	clients map[uuid.UUID]*pooledClient
+}
+
+type pooledClient struct {
+	client    *http.Client
+	transport *http.Transport
+	lastUsed  time.Time
+}
+
+// NewClientPool returns a pool evicting clients idle for longer than idleTTL
+// (DefaultClientIdleTTL when idleTTL <= 0).
+func NewClientPool(idleTTL time.Duration) *ClientPool {
+	if idleTTL <= 0 {
+		idleTTL = DefaultClientIdleTTL
+	}
+	return &ClientPool{
+		idleTTL: idleTTL,
+		now:     time.Now,
+		clients: make(map[uuid.UUID]*pooledClient),
+	}
+}
+
+// Get returns the client for nvrID, creating it on first use. Clients of
+// other NVRs that have been idle past the TTL are evicted on the way.
+func (p *ClientPool) Get(nvrID uuid.UUID) *http.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for id, pc := range p.clients {
+		if id != nvrID && now.Sub(pc.lastUsed) > p.idleTTL {
+			pc.transport.CloseIdleConnections()
+			delete(p.clients, id)
+		}
+	}
+
+	pc, ok := p.clients[nvrID]
+	if !ok {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		// Probes to one NVR run from several workers at once.
+		t.MaxIdleConnsPerHost = 4
+		t.IdleConnTimeout = p.idleTTL
+		pc = &pooledClient{
+			client:    &http.Client{Timeout: DefaultTimeout * time.Second, Transport: t},
+			transport: t,
+		}
+		p.clients[nvrID] = pc
+	}
+	pc.lastUsed = now
+	return pc.client
+}
+
+// Evict drops the client for nvrID, e.g. after the NVR is deleted or its
+// address changes.
+func (p *ClientPool) Evict(nvrID uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pc, ok := p.clients[nvrID]; ok {
+		pc.transport.CloseIdleConnections()
+		delete(p.clients, nvrID)
+	}
+}
+
+// Len reports the number of pooled clients.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.clients)
+}
+
+// HTTPClientFor returns target.HTTPClient, or a fresh client with the
+// default timeout when the caller did not supply a pooled one.
+func HTTPClientFor(target NvrTarget) *http.Client {
+	if target.HTTPClient != nil {
+		return target.HTTPClient
+	}
+	return &http.Client{Timeout: DefaultTimeout * time.Second}
+}
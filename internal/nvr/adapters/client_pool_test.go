@@ -0,0 +1,38 @@
+package adapters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestClientPool_ReusesPerNVRAndEvictsIdle(t *testing.T) {
+	p := NewClientPool(time.Minute)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	a, b := uuid.New(), uuid.New()
+	ca := p.Get(a)
+	if p.Get(a) != ca {
+		t.Fatal("Expected the same client for repeated gets of one NVR")
+	}
+	if p.Get(b) == ca {
+		t.Fatal("Expected a separate client per NVR")
+	}
+
+	// a goes idle past the TTL while b stays in use.
+	now = now.Add(2 * time.Minute)
+	p.Get(b)
+	if p.Len() != 1 {
+		t.Fatalf("Expected the idle client to be evicted, have %d", p.Len())
+	}
+	if p.Get(a) == ca {
+		t.Error("Expected a fresh client after eviction")
+	}
+
+	p.Evict(b)
+	if p.Len() != 1 {
+		t.Errorf("Expected Evict to drop the client, have %d", p.Len())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/technosupport/ts-vms/internal/nvr/adapters"
@@ -25,7 +26,7 @@ func NewAdapter() *Adapter {
 
 func init() {
 	adapters.Register("dahua", func(target adapters.NvrTarget, cred adapters.NvrCredential) (adapters.Adapter, error) {
-		return NewAdapter(), nil
+		return &Adapter{client: adapters.HTTPClientFor(target)}, nil
 	})
 }
 
@@ -129,6 +130,36 @@ func (a *Adapter) FetchEvents(ctx context.Context, target adapters.NvrTarget, cr
 	return out, 0, nil
 }
 
+// ProbeSnapshot fetches the channel still over the snapshot CGI.
+func (a *Adapter) ProbeSnapshot(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, channelRef string) error {
+	resp, err := a.getSnapshot(ctx, target, cred, channelRef)
+	if err != nil {
+		return err
+	}
+	return adapters.CheckSnapshotResponse(resp)
+}
+
+// FetchSnapshot returns the channel still over the snapshot CGI.
+func (a *Adapter) FetchSnapshot(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, channelRef string) ([]byte, error) {
+	resp, err := a.getSnapshot(ctx, target, cred, channelRef)
+	if err != nil {
+		return nil, err
+	}
+	return adapters.ReadSnapshotResponse(resp)
+}
+
+func (a *Adapter) getSnapshot(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, channelRef string) (*http.Response, error) {
+	urlStr := fmt.Sprintf("http://%s:%d/cgi-bin/snapshot.cgi?channel=%s", target.IP, target.Port, url.QueryEscape(channelRef))
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cred.AuthType == "basic" || cred.AuthType == "" {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+	return a.client.Do(req)
+}
+
 func (a *Adapter) doRPC(ctx context.Context, urlStr string, cred adapters.NvrCredential, reqBody interface{}, out interface{}) error {
 	payload, _ := json.Marshal(reqBody)
 	hReq, err := http.NewRequestWithContext(ctx, "POST", urlStr, bytes.NewBuffer(payload))
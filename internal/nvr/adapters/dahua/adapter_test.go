@@ -0,0 +1,43 @@
+package dahua
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/technosupport/ts-vms/internal/nvr/adapters"
+)
+
+func TestDahuaProbeSnapshot(t *testing.T) {
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cgi-bin/snapshot.cgi" || r.URL.Query().Get("channel") != "3" {
+			t.Errorf("Unexpected request: %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.WriteHeader(status)
+		w.Write([]byte("\xff\xd8jpeg"))
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	target := adapters.NvrTarget{IP: u.Hostname()}
+	fmt.Sscanf(u.Port(), "%d", &target.Port)
+	adapter := NewAdapter()
+
+	if err := adapter.ProbeSnapshot(context.Background(), target, adapters.NvrCredential{}, "3"); err != nil {
+		t.Fatalf("Expected image to pass, got %v", err)
+	}
+	if frame, err := adapter.FetchSnapshot(context.Background(), target, adapters.NvrCredential{}, "3"); err != nil || string(frame) != "\xff\xd8jpeg" {
+		t.Errorf("Expected fetched image, got %q (%v)", frame, err)
+	}
+
+	status = http.StatusUnauthorized
+	err := adapter.ProbeSnapshot(context.Background(), target, adapters.NvrCredential{}, "3")
+	if err == nil || err.Error() != "auth_failed: 401" {
+		t.Errorf("Expected auth_failed: 401, got %v", err)
+	}
+}
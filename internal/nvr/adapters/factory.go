@@ -3,14 +3,35 @@ package adapters
 import (
 	"fmt"
 	"strings"
+	"sync"
 )
 
-// Registry of adapter factories
-var Registry = map[string]Factory{}
+// Registry of adapter factories, guarded by registryMu
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
 
 // Register adds a factory for a vendor
 func Register(vendor string, f Factory) {
-	Registry[strings.ToLower(vendor)] = f
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(vendor)] = f
+}
+
+// Unregister removes the factory for a vendor
+func Unregister(vendor string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, strings.ToLower(vendor))
+}
+
+// lookup returns the factory registered for kind
+func lookup(kind string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	f, ok := registry[kind]
+	return f, ok
 }
 
 // GetAdapter returns an initialized adapter for the target
@@ -28,13 +49,13 @@ func GetAdapter(target NvrTarget, cred NvrCredential) (Adapter, error) {
 		kind = "rtsp_fallback"
 	}
 
-	factory, ok := Registry[kind]
+	factory, ok := lookup(kind)
 	if !ok {
 		// Default to RTSP fallback if unknown, providing a safe generic interface
 		// But maybe verify if we should error?
 		// Plan says: "unknown vendor falls back to RTSP-only adapter deterministically"
 		// We'll use "rtsp_fallback" factory if available, or error.
-		fallback, ok := Registry["rtsp_fallback"]
+		fallback, ok := lookup("rtsp_fallback")
 		if ok {
 			return fallback(target, cred)
 		}
@@ -24,7 +24,7 @@ func NewAdapter() *Adapter {
 
 func init() {
 	adapters.Register("hikvision", func(target adapters.NvrTarget, cred adapters.NvrCredential) (adapters.Adapter, error) {
-		return NewAdapter(), nil
+		return &Adapter{client: adapters.HTTPClientFor(target)}, nil
 	})
 }
 
@@ -59,9 +59,56 @@ type IPChannelList struct {
 			ID string `xml:"id"`
 		} `xml:"VideoInputChannel"`
 		Enabled bool `xml:"enabled"`
+		Source  struct {
+			IPAddress string `xml:"ipAddress"`
+		} `xml:"sourceInputPortDescriptor"`
 	} `xml:"IPChannel"`
 }
 
+// StreamingChannelList is /ISAPI/Streaming/channels: one entry per stream,
+// id "<channel>01" for the main stream.
+type StreamingChannelList struct {
+	XMLName xml.Name `xml:"StreamingChannelList"`
+	Channel []struct {
+		ID    string `xml:"id"`
+		Video struct {
+			Codec  string `xml:"videoCodecType"`
+			Width  string `xml:"videoResolutionWidth"`
+			Height string `xml:"videoResolutionHeight"`
+		} `xml:"Video"`
+	} `xml:"StreamingChannel"`
+}
+
+// streamProfiles returns codec/width/height metadata keyed by streaming
+// channel id. Best effort: nil when the NVR does not answer.
+func (a *Adapter) streamProfiles(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential) map[string]map[string]string {
+	url := fmt.Sprintf("http://%s:%d/ISAPI/Streaming/channels", target.IP, target.Port)
+	resp, err := a.doRequest(ctx, "GET", url, cred)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	var list StreamingChannelList
+	if err := xml.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil
+	}
+	out := make(map[string]map[string]string, len(list.Channel))
+	for _, sc := range list.Channel {
+		md := map[string]string{}
+		for k, v := range map[string]string{"codec": sc.Video.Codec, "width": sc.Video.Width, "height": sc.Video.Height} {
+			if v != "" {
+				md[k] = v
+			}
+		}
+		out[sc.ID] = md
+	}
+	return out
+}
+
 func (a *Adapter) listAnalog(ctx context.Context, baseURL, user, pass string) ([]adapters.NvrChannel, error) {
 	// GET /ISAPI/System/Video/inputs/channels
 	// Logic to fetch and parse
@@ -106,6 +153,7 @@ func (a *Adapter) ListChannels(ctx context.Context, target adapters.NvrTarget, c
 		xml.NewDecoder(resp.Body).Decode(&list)
 		resp.Body.Close()
 
+		profiles := a.streamProfiles(ctx, target, cred)
 		var out []adapters.NvrChannel
 		for _, ch := range list.Channel {
 			// Hikvision IP channels often strictly numbered structure.
@@ -113,14 +161,23 @@ func (a *Adapter) ListChannels(ctx context.Context, target adapters.NvrTarget, c
 			mainRtsp := fmt.Sprintf("rtsp://%s:%d/Streaming/Channels/%s01", target.IP, 554, ch.ID) // Port 554 hardcoded? or from service?
 			subRtsp := fmt.Sprintf("rtsp://%s:%d/Streaming/Channels/%s02", target.IP, 554, ch.ID)
 
-			out = append(out, adapters.NvrChannel{
+			nc := adapters.NvrChannel{
 				ChannelRef:        ch.ID,
 				Name:              ch.Name,
 				Enabled:           ch.Enabled,
 				SupportsSubStream: true,
 				RTSPMain:          adapters.SanitizeRtspUrl(mainRtsp),
 				RTSPSub:           adapters.SanitizeRtspUrl(subRtsp),
-			})
+			}
+			// Main stream profile, for DescribeProbe validation
+			nc.Metadata = profiles[ch.ID+"01"]
+			if ch.Source.IPAddress != "" {
+				if nc.Metadata == nil {
+					nc.Metadata = map[string]string{}
+				}
+				nc.Metadata["ip"] = ch.Source.IPAddress // Source camera, for adopt-on-provision
+			}
+			out = append(out, nc)
 			if len(out) >= adapters.MaxChannels {
 				break
 			}
@@ -210,6 +267,28 @@ func (a *Adapter) FetchEvents(ctx context.Context, target adapters.NvrTarget, cr
 	return out, 0, nil
 }
 
+// ProbeSnapshot fetches the channel's main-stream picture over ISAPI.
+func (a *Adapter) ProbeSnapshot(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, channelRef string) error {
+	resp, err := a.doRequest(ctx, "GET", pictureURL(target, channelRef), cred)
+	if err != nil {
+		return err
+	}
+	return adapters.CheckSnapshotResponse(resp)
+}
+
+// FetchSnapshot returns the channel's main-stream picture over ISAPI.
+func (a *Adapter) FetchSnapshot(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, channelRef string) ([]byte, error) {
+	resp, err := a.doRequest(ctx, "GET", pictureURL(target, channelRef), cred)
+	if err != nil {
+		return nil, err
+	}
+	return adapters.ReadSnapshotResponse(resp)
+}
+
+func pictureURL(target adapters.NvrTarget, channelRef string) string {
+	return fmt.Sprintf("http://%s:%d/ISAPI/Streaming/channels/%s01/picture", target.IP, target.Port, channelRef)
+}
+
 // doRequest helper (Needs to handle Digest Auth - tricky in Go stdlib without external lib)
 // I'll implement a basic wrapper that does Basic auth, or assumes a Digest transport is injected.
 // Since "Prompt said separate packages with clear unit tests", I'll stub the auth part for now or use basic.
@@ -3,11 +3,15 @@ package hikvision
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/nvr/adapters"
 )
 
@@ -53,6 +57,60 @@ func TestHikvisionDeviceInfo(t *testing.T) {
 	}
 }
 
+func TestHikvisionReusesPooledConnections(t *testing.T) {
+	var mu sync.Mutex
+	dials := 0
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<DeviceInfo><manufacturer>Hikvision</manufacturer></DeviceInfo>`)
+	}))
+	ts.Config.ConnState = func(c net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			mu.Lock()
+			dials++
+			mu.Unlock()
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	target := adapters.NvrTarget{IP: u.Hostname(), NVRID: uuid.New(), Vendor: "hikvision"}
+	fmt.Sscanf(u.Port(), "%d", &target.Port)
+	pool := adapters.NewClientPool(time.Minute)
+
+	probe := func() {
+		t.Helper()
+		target.HTTPClient = pool.Get(target.NVRID)
+		adapter, err := adapters.GetAdapter(target, adapters.NvrCredential{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := adapter.GetDeviceInfo(context.Background(), target, adapters.NvrCredential{}); err != nil {
+			t.Fatalf("probe: %v", err)
+		}
+	}
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return dials
+	}
+
+	for i := 0; i < 5; i++ {
+		probe()
+	}
+	if n := count(); n != 1 {
+		t.Errorf("Expected 5 probes of one NVR to share 1 connection, got %d", n)
+	}
+
+	// Evicting the NVR's client closes its connections; the next probe dials.
+	pool.Evict(target.NVRID)
+	probe()
+	if n := count(); n != 2 {
+		t.Errorf("Expected a new connection after eviction, got %d total", n)
+	}
+}
+
 func TestHikvisionRtspUrls(t *testing.T) {
 	adapter := NewAdapter()
 	target := adapters.NvrTarget{IP: "1.2.3.4", Port: 80}
@@ -72,3 +130,39 @@ func TestHikvisionRtspUrls(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expectedSub, sub)
 	}
 }
+
+func TestHikvisionProbeSnapshot(t *testing.T) {
+	status, contentType := http.StatusOK, "image/jpeg"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ISAPI/Streaming/channels/101/picture" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		w.Write([]byte("\xff\xd8jpeg"))
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	target := adapters.NvrTarget{IP: u.Hostname()}
+	fmt.Sscanf(u.Port(), "%d", &target.Port)
+	adapter := NewAdapter()
+
+	if err := adapter.ProbeSnapshot(context.Background(), target, adapters.NvrCredential{}, "1"); err != nil {
+		t.Fatalf("Expected image to pass, got %v", err)
+	}
+	if frame, err := adapter.FetchSnapshot(context.Background(), target, adapters.NvrCredential{}, "1"); err != nil || string(frame) != "\xff\xd8jpeg" {
+		t.Errorf("Expected fetched image, got %q (%v)", frame, err)
+	}
+
+	contentType = "text/html"
+	if err := adapter.ProbeSnapshot(context.Background(), target, adapters.NvrCredential{}, "1"); err == nil {
+		t.Error("Non-image response must fail")
+	}
+
+	status = http.StatusUnauthorized
+	err := adapter.ProbeSnapshot(context.Background(), target, adapters.NvrCredential{}, "1")
+	if err == nil || err.Error() != "auth_failed: 401" {
+		t.Errorf("Expected auth_failed: 401, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package adapters
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +16,10 @@ type NvrTarget struct {
 	IP       string
 	Port     int
 	Vendor   string
+
+	// HTTPClient, when set, is the pooled keep-alive client adapters use for
+	// this NVR (see ClientPool). Nil means a fresh client per adapter.
+	HTTPClient *http.Client
 }
 
 // Credential for NVR (in-memory only)
@@ -41,7 +46,7 @@ type NvrChannel struct {
 	SupportsSubStream bool              `json:"supports_sub_stream"` // true/false or assume false
 	RTSPMain          string            `json:"rtsp_main"`           // Sanitized
 	RTSPSub           string            `json:"rtsp_sub"`            // Sanitized
-	Metadata          map[string]string `json:"metadata,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`  // "ip" of the source camera, "codec"/"width"/"height" of the main stream, when known
 }
 
 // Common Event Model
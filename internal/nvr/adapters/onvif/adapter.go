@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/technosupport/ts-vms/internal/discovery"
 	"github.com/technosupport/ts-vms/internal/nvr/adapters"
 )
 
@@ -24,7 +25,7 @@ func NewAdapter() *Adapter {
 
 func init() {
 	adapters.Register("onvif", func(target adapters.NvrTarget, cred adapters.NvrCredential) (adapters.Adapter, error) {
-		return NewAdapter(), nil
+		return &Adapter{client: adapters.HTTPClientFor(target)}, nil
 	})
 }
 
@@ -60,3 +61,36 @@ func (a *Adapter) GetRtspUrls(ctx context.Context, target adapters.NvrTarget, cr
 func (a *Adapter) FetchEvents(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, since time.Time, limit int) ([]adapters.NvrEvent, int, error) {
 	return nil, 0, errors.New("not_supported")
 }
+
+// ProbeSnapshot fetches the still behind the profile's GetSnapshotUri.
+func (a *Adapter) ProbeSnapshot(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, channelRef string) error {
+	uri, err := a.snapshotURI(ctx, target, cred, channelRef)
+	if err != nil {
+		return err
+	}
+	return adapters.ProbeSnapshotURL(ctx, uri, cred)
+}
+
+// FetchSnapshot returns the still behind the profile's GetSnapshotUri.
+func (a *Adapter) FetchSnapshot(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, channelRef string) ([]byte, error) {
+	uri, err := a.snapshotURI(ctx, target, cred, channelRef)
+	if err != nil {
+		return nil, err
+	}
+	return adapters.FetchSnapshotURL(ctx, uri, cred)
+}
+
+// snapshotURI resolves the media service and asks it for the snapshot URI of
+// the profile token used as the channel ref.
+func (a *Adapter) snapshotURI(ctx context.Context, target adapters.NvrTarget, cred adapters.NvrCredential, channelRef string) (string, error) {
+	client, err := discovery.NewOnvifClient(fmt.Sprintf("http://%s:%d/onvif/device_service", target.IP, target.Port), cred.Username, cred.Password)
+	if err != nil {
+		return "", err
+	}
+	client.HTTP = a.client
+	_, mediaURI, err := client.GetCapabilities(ctx)
+	if err != nil {
+		return "", err
+	}
+	return client.GetSnapshotUri(ctx, mediaURI, channelRef)
+}
@@ -0,0 +1,46 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/technosupport/ts-vms/internal/nvr/adapters"
+)
+
+func TestOnvifFetchSnapshotUsesSnapshotUri(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/onvif/device_service" && strings.Contains(string(body), "GetCapabilities"):
+			fmt.Fprintf(w, `<Envelope><Body><GetCapabilitiesResponse><Capabilities><Media><XAddr>%s/onvif/media</XAddr></Media></Capabilities></GetCapabilitiesResponse></Body></Envelope>`, ts.URL)
+		case r.URL.Path == "/onvif/media" && strings.Contains(string(body), "<trt:ProfileToken>Profile_2</trt:ProfileToken>"):
+			fmt.Fprintf(w, `<Envelope><Body><GetSnapshotUriResponse><MediaUri><Uri>%s/snap/2.jpg</Uri></MediaUri></GetSnapshotUriResponse></Body></Envelope>`, ts.URL)
+		case r.URL.Path == "/snap/2.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("\xff\xd8jpeg"))
+		default:
+			t.Errorf("Unexpected request %s: %s", r.URL.Path, body)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	target := adapters.NvrTarget{IP: u.Hostname()}
+	fmt.Sscanf(u.Port(), "%d", &target.Port)
+	adapter := NewAdapter()
+
+	if err := adapter.ProbeSnapshot(context.Background(), target, adapters.NvrCredential{}, "Profile_2"); err != nil {
+		t.Fatalf("Expected image to pass, got %v", err)
+	}
+	if frame, err := adapter.FetchSnapshot(context.Background(), target, adapters.NvrCredential{}, "Profile_2"); err != nil || string(frame) != "\xff\xd8jpeg" {
+		t.Errorf("Expected fetched image, got %q (%v)", frame, err)
+	}
+}
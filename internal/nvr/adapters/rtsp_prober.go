@@ -3,19 +3,54 @@ package adapters
 import (
 	"bufio"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// RTSPProbeOptions configures ProbeRTSPStream. The zero value is the plain
+// reachability probe (OPTIONS only).
+type RTSPProbeOptions struct {
+	// Describe also issues a DESCRIBE and parses the SDP for codec and
+	// resolution. Credentials in the URL answer a Basic or Digest challenge.
+	Describe bool
+	// Expected is the stored profile to compare the advertised stream with.
+	Expected *StreamInfo
+}
+
+// RTSPProbeResult is the outcome of ProbeRTSPStream.
+type RTSPProbeResult struct {
+	Reachable  bool        `json:"reachable"`
+	Stream     *StreamInfo `json:"stream,omitempty"`     // Set when DESCRIBE returned a video track
+	Mismatch   bool        `json:"mismatch"`             // Stream differs from Expected
+	Mismatches []string    `json:"mismatches,omitempty"` // codec, resolution
+}
+
+// maxSDPSize bounds the DESCRIBE body read.
+const maxSDPSize = 16 << 10
+
 // ProbeRTSP performs a lightweight OPTIONS handshake.
 // Does NOT use complex libraries to keep dependency footprint low (boundedness).
 func ProbeRTSP(ctx context.Context, rtspURL string) error {
+	_, err := ProbeRTSPStream(ctx, rtspURL, RTSPProbeOptions{})
+	return err
+}
+
+// ProbeRTSPStream runs the OPTIONS handshake and, with opts.Describe, reads
+// the SDP to report the advertised codec/resolution against opts.Expected.
+// Errors use the "auth_failed: <code>" / "stream_error: <code>" shape; a
+// failed DESCRIBE still returns the result with Reachable set.
+func ProbeRTSPStream(ctx context.Context, rtspURL string, opts RTSPProbeOptions) (*RTSPProbeResult, error) {
 	u, err := url.Parse(rtspURL)
 	if err != nil {
-		return fmt.Errorf("invalid url: %v", err)
+		return nil, fmt.Errorf("invalid url: %v", err)
 	}
 
 	host := u.Host
@@ -26,43 +61,161 @@ func ProbeRTSP(ctx context.Context, rtspURL string) error {
 	d := net.Dialer{Timeout: 5 * time.Second}
 	conn, err := d.DialContext(ctx, "tcp", host)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer conn.Close()
 
+	if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return nil, err
+	}
+
+	// Credentials never go on the request line
+	user := u.User
+	u.User = nil
+	target := u.String()
+	reader := bufio.NewReader(conn)
+
 	// RTSP OPTIONS
 	// CSeq: 1
 	// User-Agent: TS-VMS-Health
-	msg := fmt.Sprintf("OPTIONS %s RTSP/1.0\r\nCSeq: 1\r\nUser-Agent: TS-VMS-Health\r\n\r\n", rtspURL)
-
-	if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
-		return err
+	resp, err := rtspRoundTrip(conn, reader, "OPTIONS", target, 1, "")
+	if err != nil {
+		return nil, err
 	}
-
-	if _, err := conn.Write([]byte(msg)); err != nil {
-		return err
+	if err := classifyRTSPStatus(resp.code); err != nil {
+		return nil, err
+	}
+	res := &RTSPProbeResult{Reachable: true}
+	if !opts.Describe {
+		return res, nil
 	}
 
-	// Read Response
-	reader := bufio.NewReader(conn)
-	statusLine, err := reader.ReadString('\n')
+	resp, err = rtspRoundTrip(conn, reader, "DESCRIBE", target, 2, "")
 	if err != nil {
-		return err
+		return res, err
+	}
+	if resp.code == "401" && user != nil {
+		if authz := rtspAuthorization(resp.header("WWW-Authenticate"), user, "DESCRIBE", target); authz != "" {
+			if resp, err = rtspRoundTrip(conn, reader, "DESCRIBE", target, 3, authz); err != nil {
+				return res, err
+			}
+		}
+	}
+	if err := classifyRTSPStatus(resp.code); err != nil {
+		return res, err
 	}
 
-	// Expect "RTSP/1.0 200 OK"
-	parts := strings.Split(statusLine, " ")
-	if len(parts) < 2 {
-		return fmt.Errorf("malformed response: %s", statusLine)
+	info, ok := ParseSDP(resp.body)
+	if !ok {
+		return res, fmt.Errorf("stream_error: no video track in sdp")
+	}
+	res.Stream = &info
+	if opts.Expected != nil {
+		res.Mismatches = info.Mismatches(*opts.Expected)
+		res.Mismatch = len(res.Mismatches) > 0
 	}
+	return res, nil
+}
 
-	code := parts[1]
+func classifyRTSPStatus(code string) error {
 	if code == "401" || code == "403" {
 		return fmt.Errorf("auth_failed: %s", code)
 	}
 	if !strings.HasPrefix(code, "2") {
 		return fmt.Errorf("stream_error: %s", code)
 	}
-
 	return nil
 }
+
+type rtspResponse struct {
+	code    string
+	headers map[string]string
+	body    string
+}
+
+func (r *rtspResponse) header(name string) string {
+	return r.headers[strings.ToLower(name)]
+}
+
+func rtspRoundTrip(conn net.Conn, reader *bufio.Reader, method, target string, cseq int, authz string) (*rtspResponse, error) {
+	msg := fmt.Sprintf("%s %s RTSP/1.0\r\nCSeq: %d\r\nUser-Agent: TS-VMS-Health\r\n", method, target, cseq)
+	if method == "DESCRIBE" {
+		msg += "Accept: application/sdp\r\n"
+	}
+	if authz != "" {
+		msg += "Authorization: " + authz + "\r\n"
+	}
+	if _, err := conn.Write([]byte(msg + "\r\n")); err != nil {
+		return nil, err
+	}
+
+	// Expect "RTSP/1.0 200 OK"
+	statusLine, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(statusLine, " ")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("malformed response: %s", statusLine)
+	}
+	resp := &rtspResponse{code: parts[1], headers: make(map[string]string)}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			resp.headers[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+	}
+
+	if n, _ := strconv.Atoi(resp.header("Content-Length")); n > 0 {
+		if n > maxSDPSize {
+			return nil, fmt.Errorf("stream_error: sdp too large (%d bytes)", n)
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return nil, err
+		}
+		resp.body = string(body)
+	}
+	return resp, nil
+}
+
+// rtspAuthorization answers a Basic or Digest (MD5) challenge, or returns ""
+// for schemes it does not support.
+func rtspAuthorization(challenge string, user *url.Userinfo, method, uri string) string {
+	username := user.Username()
+	password, _ := user.Password()
+	scheme, params, _ := strings.Cut(challenge, " ")
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	case "digest":
+		p := parseAuthParams(params)
+		md5hex := func(s string) string { sum := md5.Sum([]byte(s)); return hex.EncodeToString(sum[:]) }
+		ha1 := md5hex(username + ":" + p["realm"] + ":" + password)
+		ha2 := md5hex(method + ":" + uri)
+		response := md5hex(ha1 + ":" + p["nonce"] + ":" + ha2)
+		return fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s"`,
+			username, p["realm"], p["nonce"], uri, response)
+	}
+	return ""
+}
+
+func parseAuthParams(s string) map[string]string {
+	out := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			out[strings.ToLower(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// 1920x1080 High profile SPS (1088 coded lines cropped by 8)
+const sps1080p = "Z2QAKKzoB4AiflQ="
+
+const sdpH264 = "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=Stream\r\nt=0 0\r\n" +
+	"m=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\n" +
+	"a=fmtp:96 packetization-mode=1;profile-level-id=640028;sprop-parameter-sets=" + sps1080p + ",aO48sA==\r\n" +
+	"m=audio 0 RTP/AVP 0\r\na=rtpmap:0 PCMU/8000\r\n"
+
+const sdpHEVC = "v=0\r\ns=Stream\r\nt=0 0\r\n" +
+	"m=video 0 RTP/AVP 98\r\na=rtpmap:98 H265/90000\r\na=framesize:98 2560-1440\r\n"
+
+// rtspServer answers OPTIONS and DESCRIBE with sdp on one connection.
+func rtspServer(t *testing.T, sdp string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			reqLine, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cseq := ""
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line = strings.TrimSpace(line); line == "" {
+					break
+				}
+				if v, ok := strings.CutPrefix(line, "CSeq: "); ok {
+					cseq = v
+				}
+			}
+			if strings.HasPrefix(reqLine, "DESCRIBE") {
+				fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nContent-Type: application/sdp\r\nContent-Length: %d\r\n\r\n%s", cseq, len(sdp), sdp)
+				continue
+			}
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nPublic: OPTIONS, DESCRIBE\r\n\r\n", cseq)
+		}
+	}()
+	return "rtsp://" + ln.Addr().String() + "/Streaming/Channels/101"
+}
+
+func TestProbeRTSPStream_DescribeMatchesProfile(t *testing.T) {
+	url := rtspServer(t, sdpH264)
+	res, err := ProbeRTSPStream(context.Background(), url, RTSPProbeOptions{
+		Describe: true,
+		Expected: &StreamInfo{Codec: "h264", Width: 1920, Height: 1080},
+	})
+	if err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if !res.Reachable || res.Stream == nil {
+		t.Fatalf("Expected reachable stream, got %+v", res)
+	}
+	if *res.Stream != (StreamInfo{Codec: "H264", Width: 1920, Height: 1080}) {
+		t.Errorf("Expected H264 1920x1080, got %+v", *res.Stream)
+	}
+	if res.Mismatch {
+		t.Errorf("Expected no mismatch, got %v", res.Mismatches)
+	}
+}
+
+func TestProbeRTSPStream_UnexpectedCodec(t *testing.T) {
+	url := rtspServer(t, sdpHEVC)
+	res, err := ProbeRTSPStream(context.Background(), url, RTSPProbeOptions{
+		Describe: true,
+		Expected: &StreamInfo{Codec: "H264", Width: 1920, Height: 1080},
+	})
+	if err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if res.Stream == nil || res.Stream.Codec != "H265" || res.Stream.Width != 2560 || res.Stream.Height != 1440 {
+		t.Fatalf("Expected H265 2560x1440, got %+v", res.Stream)
+	}
+	if !res.Mismatch || strings.Join(res.Mismatches, ",") != "codec,resolution" {
+		t.Errorf("Expected codec and resolution mismatch, got %v", res.Mismatches)
+	}
+}
+
+func TestProbeRTSP_ReachabilityOnly(t *testing.T) {
+	url := rtspServer(t, sdpH264)
+	res, err := ProbeRTSPStream(context.Background(), url, RTSPProbeOptions{})
+	if err != nil || !res.Reachable || res.Stream != nil {
+		t.Errorf("Expected reachability without stream details, got %+v (%v)", res, err)
+	}
+}
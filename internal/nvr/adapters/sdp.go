@@ -0,0 +1,287 @@
+package adapters
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// StreamInfo is what an RTSP DESCRIBE advertises for the first video track.
+// Width/Height are 0 when the SDP carries no usable resolution.
+type StreamInfo struct {
+	Codec  string `json:"codec"` // H264, H265, JPEG, MPEG4 or the raw rtpmap encoding
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// Mismatches lists the fields ("codec", "resolution") where s differs from
+// expected. Empty expected fields are not compared, nor is a resolution the
+// SDP did not advertise.
+func (s StreamInfo) Mismatches(expected StreamInfo) []string {
+	var out []string
+	if expected.Codec != "" && normalizeCodec(expected.Codec) != s.Codec {
+		out = append(out, "codec")
+	}
+	if expected.Width > 0 && expected.Height > 0 && s.Width > 0 && s.Height > 0 &&
+		(expected.Width != s.Width || expected.Height != s.Height) {
+		out = append(out, "resolution")
+	}
+	return out
+}
+
+// ParseSDP extracts the codec and resolution of the first video media
+// section. Resolution comes from a=framesize / a=x-dimensions when present,
+// else from the H.264 SPS in sprop-parameter-sets.
+func ParseSDP(sdp string) (StreamInfo, bool) {
+	var (
+		info    StreamInfo
+		inVideo bool
+		found   bool
+		payload string
+	)
+	for _, line := range strings.Split(sdp, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "m=") {
+			if found {
+				break // Only the first video track
+			}
+			fields := strings.Fields(line[2:])
+			inVideo = len(fields) >= 4 && fields[0] == "video"
+			if inVideo {
+				found = true
+				payload = fields[3]
+				if payload == "26" {
+					info.Codec = "JPEG" // Static payload type, no rtpmap required
+				}
+			}
+			continue
+		}
+		if !inVideo || !strings.HasPrefix(line, "a=") {
+			continue
+		}
+		attr, value, _ := strings.Cut(line[2:], ":")
+		switch attr {
+		case "rtpmap":
+			pt, enc, _ := strings.Cut(value, " ")
+			if pt == payload {
+				name, _, _ := strings.Cut(enc, "/")
+				info.Codec = normalizeCodec(name)
+			}
+		case "framesize": // a=framesize:96 1920-1080
+			if _, size, ok := strings.Cut(value, " "); ok {
+				info.Width, info.Height = parseDims(size, "-")
+			}
+		case "x-dimensions": // a=x-dimensions:1920,1080
+			info.Width, info.Height = parseDims(value, ",")
+		case "fmtp":
+			if info.Width > 0 {
+				continue
+			}
+			if pt, params, ok := strings.Cut(value, " "); ok && pt == payload {
+				info.Width, info.Height = dimsFromFmtp(params)
+			}
+		}
+	}
+	return info, found
+}
+
+// normalizeCodec maps SDP and vendor codec names (e.g. HEVC, ISAPI's
+// "H.265") onto StreamInfo codecs.
+func normalizeCodec(c string) string {
+	switch c = strings.ReplaceAll(strings.ToUpper(strings.TrimSpace(c)), ".", ""); c {
+	case "HEVC":
+		return "H265"
+	case "MP4V-ES":
+		return "MPEG4"
+	case "MJPEG":
+		return "JPEG"
+	}
+	return c
+}
+
+func parseDims(s, sep string) (int, int) {
+	ws, hs, ok := strings.Cut(strings.TrimSpace(s), sep)
+	if !ok {
+		return 0, 0
+	}
+	w, err1 := strconv.Atoi(strings.TrimSpace(ws))
+	h, err2 := strconv.Atoi(strings.TrimSpace(hs))
+	if err1 != nil || err2 != nil {
+		return 0, 0
+	}
+	return w, h
+}
+
+// dimsFromFmtp decodes the SPS from an H.264 sprop-parameter-sets value.
+func dimsFromFmtp(params string) (int, int) {
+	for _, p := range strings.Split(params, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if k != "sprop-parameter-sets" {
+			continue
+		}
+		sps, _, _ := strings.Cut(v, ",")
+		nal, err := base64.StdEncoding.DecodeString(sps)
+		if err != nil {
+			return 0, 0
+		}
+		w, h, err := parseH264SPS(nal)
+		if err != nil {
+			return 0, 0
+		}
+		return w, h
+	}
+	return 0, 0
+}
+
+var errShortSPS = errors.New("sps truncated")
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (b *bitReader) u(n int) (int, error) {
+	v := 0
+	for i := 0; i < n; i++ {
+		if b.pos >= len(b.data)*8 {
+			return 0, errShortSPS
+		}
+		bit := (b.data[b.pos/8] >> (7 - uint(b.pos%8))) & 1
+		v = v<<1 | int(bit)
+		b.pos++
+	}
+	return v, nil
+}
+
+// ue reads an Exp-Golomb unsigned value.
+func (b *bitReader) ue() (int, error) {
+	zeros := 0
+	for {
+		bit, err := b.u(1)
+		if err != nil {
+			return 0, err
+		}
+		if bit == 1 {
+			break
+		}
+		if zeros++; zeros > 31 {
+			return 0, errShortSPS
+		}
+	}
+	rest, err := b.u(zeros)
+	return (1<<zeros - 1) + rest, err
+}
+
+func (b *bitReader) se() (int, error) {
+	v, err := b.ue()
+	if v%2 == 0 {
+		return -v / 2, err
+	}
+	return (v + 1) / 2, err
+}
+
+// parseH264SPS returns the cropped picture size of an H.264 SPS NAL unit.
+func parseH264SPS(nal []byte) (int, int, error) {
+	if len(nal) < 4 || nal[0]&0x1f != 7 {
+		return 0, 0, errors.New("not an sps")
+	}
+	// Strip emulation prevention bytes (00 00 03)
+	rbsp := make([]byte, 0, len(nal))
+	for i := 1; i < len(nal); i++ {
+		if i >= 3 && nal[i] == 3 && nal[i-1] == 0 && nal[i-2] == 0 {
+			continue
+		}
+		rbsp = append(rbsp, nal[i])
+	}
+	b := &bitReader{data: rbsp}
+
+	var errs []error
+	u := func(n int) int { v, err := b.u(n); errs = append(errs, err); return v }
+	ue := func() int { v, err := b.ue(); errs = append(errs, err); return v }
+	se := func() int { v, err := b.se(); errs = append(errs, err); return v }
+
+	profile := u(8)
+	u(16) // constraint flags, level
+	ue()  // seq_parameter_set_id
+
+	chroma, separatePlanes := 1, 0
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		if chroma = ue(); chroma == 3 {
+			separatePlanes = u(1)
+		}
+		ue()           // bit_depth_luma_minus8
+		ue()           // bit_depth_chroma_minus8
+		u(1)           // qpprime_y_zero_transform_bypass_flag
+		if u(1) == 1 { // seq_scaling_matrix_present_flag
+			lists := 8
+			if chroma == 3 {
+				lists = 12
+			}
+			for i := 0; i < lists; i++ {
+				if u(1) == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				last, next := 8, 8
+				for j := 0; j < size; j++ {
+					if next != 0 {
+						next = (last + se() + 256) % 256
+					}
+					if next != 0 {
+						last = next
+					}
+				}
+			}
+		}
+	}
+
+	ue()          // log2_max_frame_num_minus4
+	switch ue() { // pic_order_cnt_type
+	case 0:
+		ue() // log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		u(1) // delta_pic_order_always_zero_flag
+		se() // offset_for_non_ref_pic
+		se() // offset_for_top_to_bottom_field
+		for n := ue(); n > 0 && n < 256; n-- {
+			se()
+		}
+	}
+	ue() // max_num_ref_frames
+	u(1) // gaps_in_frame_num_value_allowed_flag
+	widthMbs := ue() + 1
+	heightMapUnits := ue() + 1
+	frameMbsOnly := u(1)
+	if frameMbsOnly == 0 {
+		u(1) // mb_adaptive_frame_field_flag
+	}
+	u(1) // direct_8x8_inference_flag
+
+	var cropL, cropR, cropT, cropB int
+	if u(1) == 1 {
+		cropL, cropR, cropT, cropB = ue(), ue(), ue(), ue()
+	}
+	if err := errors.Join(errs...); err != nil {
+		return 0, 0, err
+	}
+
+	cropX, cropY := 1, 2-frameMbsOnly
+	if chroma != 0 && separatePlanes == 0 {
+		subW, subH := 2, 2 // 4:2:0
+		switch chroma {
+		case 2:
+			subH = 1
+		case 3:
+			subW, subH = 1, 1
+		}
+		cropX, cropY = subW, subH*(2-frameMbsOnly)
+	}
+	width := widthMbs*16 - (cropL+cropR)*cropX
+	height := (2-frameMbsOnly)*heightMapUnits*16 - (cropT+cropB)*cropY
+	return width, height, nil
+}
@@ -0,0 +1,102 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SnapshotProber is implemented by adapters that can fetch a channel still
+// image natively (e.g. ISAPI picture endpoint). The monitor uses it for NVRs
+// whose health_probe_mode is "snapshot".
+type SnapshotProber interface {
+	ProbeSnapshot(ctx context.Context, target NvrTarget, cred NvrCredential, channelRef string) error
+}
+
+// SnapshotFetcher is implemented by adapters that can return a channel still
+// image natively. Live snapshots of NVR-routed cameras use it.
+type SnapshotFetcher interface {
+	FetchSnapshot(ctx context.Context, target NvrTarget, cred NvrCredential, channelRef string) ([]byte, error)
+}
+
+// MaxSnapshotBytes bounds a fetched snapshot image.
+const MaxSnapshotBytes = 16 << 20
+
+var snapshotClient = &http.Client{Timeout: DefaultTimeout * time.Second}
+
+// ProbeSnapshotURL fetches a snapshot URI (e.g. an ONVIF GetSnapshotUri
+// result) with basic auth and checks an image comes back.
+func ProbeSnapshotURL(ctx context.Context, snapshotURL string, cred NvrCredential) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, snapshotURL, nil)
+	if err != nil {
+		return err
+	}
+	if cred.Username != "" {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+	resp, err := snapshotClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return CheckSnapshotResponse(resp)
+}
+
+// FetchSnapshotURL is ProbeSnapshotURL returning the image.
+func FetchSnapshotURL(ctx context.Context, snapshotURL string, cred NvrCredential) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, snapshotURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cred.Username != "" {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+	resp, err := snapshotClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return ReadSnapshotResponse(resp)
+}
+
+// ReadSnapshotResponse closes resp and returns its image, classifying
+// failures like CheckSnapshotResponse.
+func ReadSnapshotResponse(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	if err := snapshotStatus(resp); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxSnapshotBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxSnapshotBytes {
+		return nil, fmt.Errorf("stream_error: snapshot exceeds %d bytes", MaxSnapshotBytes)
+	}
+	return body, nil
+}
+
+// CheckSnapshotResponse closes resp and reports whether it carried an image.
+// Errors use the same "auth_failed: <code>" / "stream_error: <code>" shape as
+// ProbeRTSP so callers classify both probes alike.
+func CheckSnapshotResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+	// Drain a little so keep-alive connections can be reused; the image
+	// itself is not needed.
+	_, _ = io.CopyN(io.Discard, resp.Body, 64<<10)
+	return snapshotStatus(resp)
+}
+
+func snapshotStatus(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("auth_failed: %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("stream_error: %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "image/") {
+		return fmt.Errorf("stream_error: unexpected content type %q", ct)
+	}
+	return nil
+}
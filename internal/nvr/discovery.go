@@ -2,9 +2,17 @@ package nvr
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/data"
@@ -38,9 +46,21 @@ func (s *Service) TestConnection(ctx context.Context, nvrID, tenantID uuid.UUID)
 	return "ok", nil
 }
 
-// DiscoverChannels enumerates channels and upserts them to DB.
+// ChannelDiscoveryResult summarizes a DiscoverChannels run. Reported is what
+// the device returned; when it exceeds the per-NVR cap only the first
+// MaxChannelsPerNVR channels are stored and Truncated is set.
+type ChannelDiscoveryResult struct {
+	Count     int  `json:"count"`
+	Reported  int  `json:"reported"`
+	Truncated bool `json:"truncated"`
+}
+
+// DiscoverChannels enumerates channels and upserts them to DB, keeping at
+// most MaxChannelsPerNVR of them.
 // Audit: nvr.channel.discovery_run
-func (s *Service) DiscoverChannels(ctx context.Context, nvrID, tenantID uuid.UUID) (int, error) {
+func (s *Service) DiscoverChannels(ctx context.Context, nvrID, tenantID uuid.UUID) (ChannelDiscoveryResult, error) {
+	var res ChannelDiscoveryResult
+
 	// 1. Bounds: 30s timeout
 	ctxRun, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
@@ -49,17 +69,20 @@ func (s *Service) DiscoverChannels(ctx context.Context, nvrID, tenantID uuid.UUI
 	adapter, target, cred, err := s.getAdapterClient(ctxRun, nvrID)
 	if err != nil {
 		s.audit(ctx, "nvr.channel.discovery_run", tenantID, nvrID.String(), "fail", map[string]any{"error": err.Error()})
-		return 0, err
+		return res, err
 	}
 
 	channels, err := adapter.ListChannels(ctxRun, target, cred)
 	if err != nil {
 		s.audit(ctx, "nvr.channel.discovery_run", tenantID, nvrID.String(), "fail", map[string]any{"error": err.Error()})
-		return 0, err
+		return res, err
 	}
 
-	if len(channels) > 4096 {
-		channels = channels[:4096] // Deterministic truncation
+	res.Reported = len(channels)
+	if limit := s.maxChannelsPerNVR(); len(channels) > limit {
+		channels = channels[:limit] // Deterministic truncation
+		res.Truncated = true
+		log.Printf("[WARN] NVR %s: device reported %d channels, keeping the first %d", nvrID, res.Reported, limit)
 	}
 
 	// 3. Upsert to DB
@@ -67,7 +90,6 @@ func (s *Service) DiscoverChannels(ctx context.Context, nvrID, tenantID uuid.UUI
 		return adapters.SanitizeRtspUrl(u)
 	}
 
-	updatedCount := 0
 	for _, ch := range channels {
 		dbCh := &data.NVRChannel{
 			TenantID:          tenantID,
@@ -82,25 +104,175 @@ func (s *Service) DiscoverChannels(ctx context.Context, nvrID, tenantID uuid.UUI
 			DiscoveredAt:      time.Now(),
 			LastSyncedAt:      time.Now(),
 			ValidationStatus:  "unknown",
-			Metadata:          map[string]any{"raw_name": ch.Name},
+			Metadata:          channelMetadata(ch),
 		}
 
 		err = s.repo.UpsertChannel(ctx, dbCh)
 		if err == nil {
-			updatedCount++
+			res.Count++
+		}
+	}
+
+	details := map[string]any{"count": res.Count}
+	if res.Truncated {
+		details["truncated"] = true
+		details["reported"] = res.Reported
+	}
+	s.audit(ctx, "nvr.channel.discovery_run", tenantID, nvrID.String(), "success", details)
+	return res, nil
+}
+
+// channelMetadata keeps the raw name plus the channel's source camera address
+// (when the adapter reports one) for adopt-on-provision matching, and its
+// codec/width/height profile for DescribeProbe validation.
+func channelMetadata(ch adapters.NvrChannel) map[string]any {
+	md := map[string]any{"raw_name": ch.Name}
+	for _, k := range []string{"ip", "codec", "width", "height"} {
+		if v := ch.Metadata[k]; v != "" {
+			md[k] = v
+		}
+	}
+	return md
+}
+
+// Sync validation bounds: small NVRs get inline results, larger runs go async.
+const (
+	MaxValidateBatch         = 200
+	SyncValidateMaxChannels  = 32
+	SyncValidateConcurrency  = 8
+	SyncValidateTimeout      = 20 * time.Second
+	ValidationModeSync       = "sync"
+	ValidationModeAsync      = "async"
+	ValidationReasonSyncCap  = "sync_cap_exceeded"
+	validationStatusTimeout  = "timeout"
+	validationStatusDBError  = "error_db"
+	maxChannelsPerValidation = 4096
+)
+
+// ChannelValidationOutcome describes how a validation request was executed.
+// Results maps channel ID to status and is only populated for sync runs;
+// async runs report via channel validation_status.
+type ChannelValidationOutcome struct {
+	Mode    string               `json:"mode"`
+	Count   int                  `json:"count"`
+	Reason  string               `json:"reason,omitempty"`
+	Results map[uuid.UUID]string `json:"results,omitempty"`
+}
+
+// RunChannelValidation validates channels inline when sync is requested and the
+// run fits SyncValidateMaxChannels; otherwise it queues an async run.
+// An empty channelIDs list means every channel on the NVR.
+func (s *Service) RunChannelValidation(ctx context.Context, nvrID, tenantID uuid.UUID, channelIDs []uuid.UUID, syncRequested bool) (*ChannelValidationOutcome, error) {
+	nvr, err := s.repo.GetByID(ctx, nvrID)
+	if err != nil {
+		return nil, err
+	}
+	if nvr.TenantID != tenantID {
+		return nil, errors.New("access denied")
+	}
+
+	if len(channelIDs) == 0 {
+		channels, _, err := s.repo.ListChannels(ctx, nvrID, data.NVRChannelFilter{}, maxChannelsPerValidation, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, ch := range channels {
+			channelIDs = append(channelIDs, ch.ID)
+		}
+	}
+
+	out := &ChannelValidationOutcome{Count: len(channelIDs)}
+	if syncRequested && len(channelIDs) <= SyncValidateMaxChannels {
+		out.Mode = ValidationModeSync
+		out.Results, err = s.ValidateChannelsSync(ctx, nvrID, tenantID, channelIDs)
+		if err != nil {
+			return nil, err
 		}
+		return out, nil
 	}
 
-	s.audit(ctx, "nvr.channel.discovery_run", tenantID, nvrID.String(), "success", map[string]any{"count": updatedCount})
-	return updatedCount, nil
+	out.Mode = ValidationModeAsync
+	if syncRequested {
+		out.Reason = ValidationReasonSyncCap
+	}
+	s.validateChannelsAsync(nvrID, tenantID, channelIDs)
+	return out, nil
+}
+
+// ValidateChannelsSync validates up to SyncValidateMaxChannels channels with bounded
+// concurrency under a total SyncValidateTimeout. Channels not finished before
+// the deadline report "timeout".
+func (s *Service) ValidateChannelsSync(ctx context.Context, nvrID, tenantID uuid.UUID, channelIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	if len(channelIDs) > SyncValidateMaxChannels {
+		return nil, fmt.Errorf("too many channels for sync validation (max %d)", SyncValidateMaxChannels)
+	}
+
+	_, _, cred, err := s.getAdapterClient(ctx, nvrID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxRun, cancel := context.WithTimeout(ctx, SyncValidateTimeout)
+	defer cancel()
+
+	statuses := make([]string, len(channelIDs))
+	sem := make(chan struct{}, SyncValidateConcurrency)
+	var wg sync.WaitGroup
+
+	for i, chID := range channelIDs {
+		statuses[i] = validationStatusTimeout
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctxRun.Done():
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, chID uuid.UUID) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			status, _ := s.validateChannel(ctxRun, chID, cred)
+			if ctxRun.Err() != nil {
+				return // Deadline hit mid-probe; leave as timeout
+			}
+			statuses[i] = status
+		}(i, chID)
+	}
+	wg.Wait()
+
+	results := make(map[uuid.UUID]string, len(channelIDs))
+	for i, chID := range channelIDs {
+		results[chID] = statuses[i]
+	}
+
+	s.audit(ctx, "nvr.channel.validation_run", tenantID, nvrID.String(), "success", map[string]any{"count": len(channelIDs), "mode": ValidationModeSync})
+	return results, nil
+}
+
+// validateChannelsAsync runs ValidateChannels in the background in MaxValidateBatch chunks.
+func (s *Service) validateChannelsAsync(nvrID, tenantID uuid.UUID, channelIDs []uuid.UUID) {
+	ids := append([]uuid.UUID(nil), channelIDs...)
+	go func() {
+		for start := 0; start < len(ids); start += MaxValidateBatch {
+			end := start + MaxValidateBatch
+			if end > len(ids) {
+				end = len(ids)
+			}
+			if _, err := s.ValidateChannels(context.Background(), nvrID, tenantID, ids[start:end]); err != nil {
+				return
+			}
+		}
+	}()
 }
 
 // ValidateChannels probes RTSP handling (OPTIONS)
 // Audit: nvr.channel.validation_run
 func (s *Service) ValidateChannels(ctx context.Context, nvrID, tenantID uuid.UUID, channelIDs []uuid.UUID) (map[uuid.UUID]string, error) {
 	// Bounds: Max 200 channels
-	if len(channelIDs) > 200 {
-		return nil, fmt.Errorf("too many channels to validate at once (max 200)")
+	if len(channelIDs) > MaxValidateBatch {
+		return nil, fmt.Errorf("too many channels to validate at once (max %d)", MaxValidateBatch)
 	}
 
 	results := make(map[uuid.UUID]string)
@@ -113,23 +285,7 @@ func (s *Service) ValidateChannels(ctx context.Context, nvrID, tenantID uuid.UUI
 
 	// We need channel details (RTSP URLs) from DB
 	for _, chID := range channelIDs {
-		ch, err := s.repo.GetChannel(ctx, chID)
-		if err != nil {
-			results[chID] = "error_db"
-			continue
-		}
-
-		status := s.checkRTSP(ctx, ch.RTSPMain, cred.Username, cred.Password)
-
-		errCode := ""
-		if status != "ok" {
-			errCode = status
-			status = "error"
-		} else {
-			status = "ok"
-		}
-
-		s.repo.UpdateChannelStatus(ctx, chID, status, &errCode)
+		status, _ := s.validateChannel(ctx, chID, cred)
 		results[chID] = status
 	}
 
@@ -137,15 +293,71 @@ func (s *Service) ValidateChannels(ctx context.Context, nvrID, tenantID uuid.UUI
 	return results, nil
 }
 
+// validateChannel probes one channel and persists its validation status.
+func (s *Service) validateChannel(ctx context.Context, chID uuid.UUID, cred adapters.NvrCredential) (string, string) {
+	ch, err := s.repo.GetChannel(ctx, chID)
+	if err != nil {
+		return validationStatusDBError, ""
+	}
+
+	status := s.checkRTSP(ctx, ch, cred.Username, cred.Password)
+
+	errCode := ""
+	if status != "ok" {
+		errCode = status
+		status = "error"
+	}
+
+	s.repo.UpdateChannelStatus(ctx, chID, status, &errCode)
+	return status, errCode
+}
+
 // Internal helper for RTSP handshake
-func (s *Service) checkRTSP(ctx context.Context, urlStr, user, pass string) string {
-	if urlStr == "" {
+func (s *Service) checkRTSP(ctx context.Context, ch *data.NVRChannel, user, pass string) string {
+	if ch.RTSPMain == "" {
 		return "invalid_url"
 	}
-	// TODO: Real RTSP OPTIONS
+	if !s.DescribeProbe {
+		// TODO: Real RTSP OPTIONS
+		return "ok"
+	}
+
+	u, err := url.Parse(ch.RTSPMain)
+	if err != nil {
+		return "invalid_url"
+	}
+	if user != "" {
+		u.User = url.UserPassword(user, pass)
+	}
+	res, err := adapters.ProbeRTSPStream(ctx, u.String(), adapters.RTSPProbeOptions{Describe: true, Expected: channelProfile(ch)})
+	var netErr net.Error
+	switch {
+	case err != nil && strings.HasPrefix(err.Error(), "auth_failed"):
+		return "unauthorized"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return validationStatusTimeout
+	case err != nil:
+		return "stream_error"
+	case res.Mismatch:
+		return "profile_mismatch: " + strings.Join(res.Mismatches, ",")
+	}
 	return "ok"
 }
 
+// channelProfile is the codec/resolution stored in the channel's metadata at
+// discovery; nil when the adapter reported none.
+func channelProfile(ch *data.NVRChannel) *adapters.StreamInfo {
+	codec, _ := ch.Metadata["codec"].(string)
+	ws, _ := ch.Metadata["width"].(string)
+	hs, _ := ch.Metadata["height"].(string)
+	w, _ := strconv.Atoi(ws)
+	h, _ := strconv.Atoi(hs)
+	if codec == "" && (w == 0 || h == 0) {
+		return nil
+	}
+	return &adapters.StreamInfo{Codec: codec, Width: w, Height: h}
+}
+
 // BulkChannelOp handles enable/disable
 func (s *Service) BulkChannelOp(ctx context.Context, nvrID, tenantID uuid.UUID, channelIDs []uuid.UUID, action string) error {
 	enable := action == "enable"
@@ -160,17 +372,110 @@ func (s *Service) BulkChannelOp(ctx context.Context, nvrID, tenantID uuid.UUID,
 	return nil
 }
 
-// ProvisionCameras creates camera records for selected channels
+// MaxChannelNameLen matches the NVR name cap.
+const MaxChannelNameLen = 120
+
+// ErrInvalidChannelName is returned for empty, over-long or control-character names.
+var ErrInvalidChannelName = errors.New("invalid channel name")
+
+// BulkRenameChannels renames channels of one NVR in a single transaction.
+// Names are trimmed; any invalid name rejects the whole batch.
+// Audit: nvr.channel.bulk_rename
+func (s *Service) BulkRenameChannels(ctx context.Context, nvrID, tenantID uuid.UUID, names map[uuid.UUID]string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("%w: no channels given", ErrInvalidChannelName)
+	}
+	clean := make(map[uuid.UUID]string, len(names))
+	for id, name := range names {
+		name = strings.TrimSpace(name)
+		if err := validateChannelName(name); err != nil {
+			return fmt.Errorf("%w: channel %s", err, id)
+		}
+		clean[id] = name
+	}
+
+	n, err := s.repo.GetByID(ctx, nvrID)
+	if err != nil {
+		return err
+	}
+	if n.TenantID != tenantID {
+		return data.ErrRecordNotFound
+	}
+
+	if err := s.repo.BulkRenameChannels(ctx, nvrID, clean); err != nil {
+		s.audit(ctx, "nvr.channel.bulk_rename", tenantID, nvrID.String(), "fail", map[string]any{"error": err.Error()})
+		return err
+	}
+
+	s.audit(ctx, "nvr.channel.bulk_rename", tenantID, nvrID.String(), "success", map[string]any{"count": len(clean)})
+	return nil
+}
+
+// PrefixChannelNames prepends prefix to each channel's current name. Channels
+// already carrying the prefix are left as they are, so re-running is harmless.
+func (s *Service) PrefixChannelNames(ctx context.Context, nvrID, tenantID uuid.UUID, channelIDs []uuid.UUID, prefix string) error {
+	if strings.TrimSpace(prefix) == "" {
+		return fmt.Errorf("%w: empty prefix", ErrInvalidChannelName)
+	}
+	names := make(map[uuid.UUID]string, len(channelIDs))
+	for _, id := range channelIDs {
+		ch, err := s.repo.GetChannel(ctx, id)
+		if err != nil {
+			return err
+		}
+		if ch.NVRID != nvrID {
+			return data.ErrRecordNotFound
+		}
+		if strings.HasPrefix(ch.Name, prefix) {
+			continue
+		}
+		names[id] = prefix + ch.Name
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return s.BulkRenameChannels(ctx, nvrID, tenantID, names)
+}
+
+func validateChannelName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > MaxChannelNameLen {
+		return ErrInvalidChannelName
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return ErrInvalidChannelName
+		}
+	}
+	return nil
+}
+
+// ProvisionResult counts the channels linked by ProvisionCameras.
+type ProvisionResult struct {
+	Created int `json:"created"`
+	Adopted int `json:"adopted"`
+}
+
+// ProvisionCameras creates camera records for selected channels and links
+// them with recordingMode. An empty mode uses the tenant's default; an
+// invalid one returns ErrInvalidRecordingMode before any camera is created.
+// With adopt, a channel whose source IP matches an existing camera links
+// that camera instead; unmatched channels are created.
 // Audit: nvr.channel.provision
-func (s *Service) ProvisionCameras(ctx context.Context, nvrID, tenantID uuid.UUID, channelIDs []uuid.UUID) (int, error) {
+func (s *Service) ProvisionCameras(ctx context.Context, nvrID, tenantID uuid.UUID, channelIDs []uuid.UUID, recordingMode string, adopt bool) (ProvisionResult, error) {
+	var res ProvisionResult
+	if recordingMode == "" {
+		recordingMode = s.defaultRecordingMode(ctx, tenantID)
+	}
+	if !ValidRecordingMode(recordingMode) {
+		return res, ErrInvalidRecordingMode
+	}
+
 	// 1. Fetch NVR
 	nvr, err := s.repo.GetByID(ctx, nvrID)
 	if err != nil {
-		return 0, err
+		return res, err
 	}
 
-	createdCount := 0
-
 	for _, chID := range channelIDs {
 		ch, err := s.repo.GetChannel(ctx, chID)
 		if err != nil {
@@ -181,34 +486,41 @@ func (s *Service) ProvisionCameras(ctx context.Context, nvrID, tenantID uuid.UUI
 			continue
 		}
 
-		// Create Camera Object
-		camID := uuid.New()
-
-		camName := fmt.Sprintf("%s - %s", nvr.Name, ch.Name)
-		if len(camName) > 120 {
-			camName = camName[:120]
+		camID, adopted := uuid.Nil, false
+		if adopt {
+			camID, adopted = s.adoptableCamera(ctx, tenantID, nvrID, ch)
 		}
 
-		netIP := net.ParseIP(nvr.IPAddress)
+		if !adopted {
+			// Create Camera Object
+			camID = uuid.New()
 
-		newCam := &data.Camera{
-			ID:           camID,
-			TenantID:     tenantID,
-			SiteID:       nvr.SiteID,
-			Name:         camName,
-			IsEnabled:    true,
-			IPAddress:    netIP,
-			Port:         nvr.Port,
-			Manufacturer: nvr.Vendor,
-			Model:        "Channel " + ch.ChannelRef,
-		}
+			camName := fmt.Sprintf("%s - %s", nvr.Name, ch.Name)
+			if len(camName) > 120 {
+				camName = camName[:120]
+			}
 
-		// Create Camera (Enforces Quota)
-		if err := s.cameras.CreateCamera(ctx, newCam); err != nil {
-			if err.Error() == "license_limit_exceeded" {
-				return createdCount, err // Abort
+			netIP := net.ParseIP(nvr.IPAddress)
+
+			newCam := &data.Camera{
+				ID:           camID,
+				TenantID:     tenantID,
+				SiteID:       nvr.SiteID,
+				Name:         camName,
+				IsEnabled:    true,
+				IPAddress:    netIP,
+				Port:         nvr.Port,
+				Manufacturer: nvr.Vendor,
+				Model:        "Channel " + ch.ChannelRef,
+			}
+
+			// Create Camera (Enforces Quota)
+			if err := s.cameras.CreateCamera(ctx, newCam); err != nil {
+				if err.Error() == "license_limit_exceeded" {
+					return res, err // Abort
+				}
+				continue
 			}
-			continue
 		}
 
 		// Link
@@ -217,20 +529,75 @@ func (s *Service) ProvisionCameras(ctx context.Context, nvrID, tenantID uuid.UUI
 			CameraID:      camID,
 			NVRID:         nvrID,
 			NVRChannelRef: &ch.ChannelRef,
-			RecordingMode: "vms",
+			RecordingMode: recordingMode,
 			IsEnabled:     true,
 		}
 
-		if err := s.repo.UpsertLink(ctx, link); err != nil {
+		if _, err := s.repo.UpsertLink(ctx, link); err != nil {
 			// Rollback? (Need DeleteCamera)
 			// s.cameras.DeleteCamera(ctx, camID, tenantID)
 			continue
 		}
 
 		s.repo.UpdateChannelProvisionState(ctx, chID, "created")
-		createdCount++
+		if adopted {
+			res.Adopted++
+		} else {
+			res.Created++
+		}
+	}
+
+	s.audit(ctx, "nvr.channel.provision", tenantID, nvrID.String(), "success", map[string]any{
+		"count": res.Created + res.Adopted, "created": res.Created, "adopted": res.Adopted, "recording_mode": recordingMode,
+	})
+	return res, nil
+}
+
+// adoptableCamera finds an existing camera for ch by the source IP recorded
+// at discovery. Cameras already linked to another NVR are not taken over.
+func (s *Service) adoptableCamera(ctx context.Context, tenantID, nvrID uuid.UUID, ch *data.NVRChannel) (uuid.UUID, bool) {
+	ipStr, _ := ch.Metadata["ip"].(string)
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return uuid.Nil, false
+	}
+	camID, err := s.cameras.FindByIP(ctx, tenantID, ip)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	if link, err := s.repo.GetLinkByCameraID(ctx, camID); err == nil && link.NVRID != nvrID {
+		return uuid.Nil, false
+	}
+	return camID, true
+}
+
+// DefaultRecordingMode is the recording mode ProvisionCameras gives links when
+// the request names none.
+func (s *Service) DefaultRecordingMode(ctx context.Context, tenantID uuid.UUID) string {
+	return s.defaultRecordingMode(ctx, tenantID)
+}
+
+// SetDefaultRecordingMode changes the tenant's default recording mode; mode
+// must be one of the RecordingMode values.
+// Audit: nvr.default_recording_mode.update
+func (s *Service) SetDefaultRecordingMode(ctx context.Context, tenantID uuid.UUID, mode string) error {
+	if !ValidRecordingMode(mode) {
+		return ErrInvalidRecordingMode
 	}
+	if err := s.repo.SetDefaultRecordingMode(ctx, tenantID, mode); err != nil {
+		s.audit(ctx, "nvr.default_recording_mode.update", tenantID, tenantID.String(), "fail", map[string]any{"error": err.Error()})
+		return err
+	}
+	s.audit(ctx, "nvr.default_recording_mode.update", tenantID, tenantID.String(), "success", map[string]any{"recording_mode": mode})
+	return nil
+}
 
-	s.audit(ctx, "nvr.channel.provision", tenantID, nvrID.String(), "success", map[string]any{"count": createdCount})
-	return createdCount, nil
+// defaultRecordingMode is the tenant-configured mode, falling back to vms when
+// the tenant has none (or it cannot be read).
+func (s *Service) defaultRecordingMode(ctx context.Context, tenantID uuid.UUID) string {
+	mode, err := s.repo.GetDefaultRecordingMode(ctx, tenantID)
+	if err != nil || mode == "" {
+		return RecordingModeVMS
+	}
+	return mode
 }
@@ -27,6 +27,17 @@ func MapVendorEvent(vendor, rawType string) (string, string) {
 		if strings.Contains(raw, "storagef") || strings.Contains(raw, "diskfull") {
 			return "disk_full", "critical"
 		}
+	case "onvif":
+		// rawType is the notification topic, e.g. "RuleEngine/CellMotionDetector/Motion"
+		if strings.Contains(raw, "tamper") || strings.Contains(raw, "globalscenechange") {
+			return "tamper", "warn"
+		}
+		if strings.Contains(raw, "motion") {
+			return "motion", "info"
+		}
+		if strings.Contains(raw, "linedetector") {
+			return "line_crossing", "info"
+		}
 	}
 
 	return "unknown", "info"
@@ -9,11 +9,11 @@ import (
 // VmsEvent is the normalized event envelope for Phase 2.10
 type VmsEvent struct {
 	EventID    uuid.UUID  `json:"event_id"`
-	Source     string     `json:"source"` // "nvr"
+	Source     string     `json:"source"` // "nvr", or "camera" for direct ONVIF events
 	Vendor     string     `json:"vendor"` // "hikvision", "dahua", "onvif"
 	TenantID   uuid.UUID  `json:"tenant_id"`
 	SiteID     uuid.UUID  `json:"site_id"`
-	NVRID      uuid.UUID  `json:"nvr_id"`
+	NVRID      uuid.UUID  `json:"nvr_id"`      // Nil for Source "camera"
 	CameraID   *uuid.UUID `json:"camera_id"`   // Nullable
 	ChannelRef string     `json:"channel_ref"` // e.g. "1", "0", "Token-1"
 
@@ -2,6 +2,7 @@ package nvr
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -9,11 +10,15 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/maintenance"
 	"github.com/technosupport/ts-vms/internal/metrics"
 )
 
 type PollerConfig struct {
-	Enabled          bool
+	Enabled bool
+	// PollInterval is the default per-NVR cadence; an NVR's
+	// event_poll_interval_ms overrides it. It is also how often the NVR list
+	// (and so each override) is reloaded.
 	PollInterval     time.Duration
 	MaxInflight      int
 	MaxEventsPerPoll int
@@ -32,6 +37,33 @@ type NVRPoller struct {
 	sem      chan struct{}
 	stopChan chan struct{}
 	wg       sync.WaitGroup
+
+	// NVRID -> last dispatch; owned by runLoop
+	lastPoll map[uuid.UUID]time.Time
+
+	// Maintenance pauses polling of NVRs whose tenant is in maintenance.
+	// Nil means never. Set before Start.
+	Maintenance *maintenance.Switch
+}
+
+const (
+	MinEventPollIntervalMs = 500
+	MaxEventPollIntervalMs = 3600000
+
+	// eventPollTick is the scheduler resolution; per-NVR intervals are
+	// honored to within one tick.
+	eventPollTick = MinEventPollIntervalMs * time.Millisecond
+)
+
+// ErrInvalidEventPollInterval is returned for an event_poll_interval_ms outside
+// MinEventPollIntervalMs-MaxEventPollIntervalMs.
+var ErrInvalidEventPollInterval = errors.New("invalid event poll interval")
+
+func validateEventPollInterval(ms *int) error {
+	if ms != nil && (*ms < MinEventPollIntervalMs || *ms > MaxEventPollIntervalMs) {
+		return fmt.Errorf("%w: must be %d-%d ms", ErrInvalidEventPollInterval, MinEventPollIntervalMs, MaxEventPollIntervalMs)
+	}
+	return nil
 }
 
 func NewNVRPoller(s *Service, pub *NATSPublisher, enricher *EventEnricher, dedup *EventDedup, cfg PollerConfig) *NVRPoller {
@@ -47,6 +79,7 @@ func NewNVRPoller(s *Service, pub *NATSPublisher, enricher *EventEnricher, dedup
 		cfg:      cfg,
 		sem:      make(chan struct{}, cfg.MaxInflight),
 		stopChan: make(chan struct{}),
+		lastPoll: make(map[uuid.UUID]time.Time),
 	}
 }
 
@@ -68,46 +101,82 @@ func (p *NVRPoller) Stop() {
 
 func (p *NVRPoller) runLoop() {
 	defer p.wg.Done()
-	ticker := time.NewTicker(p.cfg.PollInterval)
+	ticker := time.NewTicker(eventPollTick)
 	defer ticker.Stop()
 
+	ctx := context.Background()
+	var nvrs []*data.NVR
+	var listedAt time.Time
 	for {
 		select {
 		case <-p.stopChan:
 			return
-		case <-ticker.C:
-			p.pollAll()
+		case now := <-ticker.C:
+			if nvrs == nil || now.Sub(listedAt) >= p.cfg.PollInterval {
+				list, err := p.repo.ListAllNVRs(ctx)
+				if err != nil {
+					log.Printf("[ERROR] NVR Poller: Error listing NVRs: %v", err)
+					continue
+				}
+				nvrs, listedAt = list, now
+			}
+			p.pollDue(nvrs, now, func(n *data.NVR) bool { return p.dispatch(ctx, n) })
 		}
 	}
 }
 
-func (p *NVRPoller) pollAll() {
-	ctx := context.Background()
-	nvrs, err := p.repo.ListAllNVRs(ctx)
-	if err != nil {
-		log.Printf("[ERROR] NVR Poller: Error listing NVRs: %v", err)
-		return
+// interval returns the NVR's event poll cadence.
+func (p *NVRPoller) interval(n *data.NVR) time.Duration {
+	if n.EventPollIntervalMs != nil && *n.EventPollIntervalMs > 0 {
+		return time.Duration(*n.EventPollIntervalMs) * time.Millisecond
 	}
+	return p.cfg.PollInterval
+}
 
-	for _, nvr := range nvrs {
-		if !nvr.IsEnabled || nvr.Status != "online" {
+// pollDue dispatches every enabled, online NVR outside maintenance whose
+// interval has elapsed since its last dispatch. Half a tick of slack keeps ticker drift from
+// pushing an NVR a whole tick late. An NVR dispatch refuses stays due.
+func (p *NVRPoller) pollDue(nvrs []*data.NVR, now time.Time, dispatch func(*data.NVR) bool) {
+	seen := make(map[uuid.UUID]bool, len(nvrs))
+	for _, n := range nvrs {
+		seen[n.ID] = true
+		if !n.IsEnabled || n.Status != "online" || p.Maintenance.Active(n.TenantID) {
 			continue
 		}
+		if last, ok := p.lastPoll[n.ID]; ok && now.Before(last.Add(p.interval(n)-eventPollTick/2)) {
+			continue
+		}
+		if dispatch(n) {
+			p.lastPoll[n.ID] = now
+		}
+	}
 
-		select {
-		case p.sem <- struct{}{}:
-			p.wg.Add(1)
-			go func(n *data.NVR) {
-				defer p.wg.Done()
-				defer func() { <-p.sem }()
-				p.pollNVR(ctx, n)
-			}(nvr)
-		default:
-			metrics.NVRChecksTotal.WithLabelValues("fail", "poller_capacity_full").Inc()
+	// Forget NVRs that were deleted.
+	for id := range p.lastPoll {
+		if !seen[id] {
+			delete(p.lastPoll, id)
 		}
 	}
 }
 
+// dispatch polls n in the background, or reports false when MaxInflight
+// polls are already running.
+func (p *NVRPoller) dispatch(ctx context.Context, n *data.NVR) bool {
+	select {
+	case p.sem <- struct{}{}:
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			defer func() { <-p.sem }()
+			p.pollNVR(ctx, n)
+		}()
+		return true
+	default:
+		metrics.NVRChecksTotal.WithLabelValues("fail", "poller_capacity_full").Inc()
+		return false
+	}
+}
+
 func (p *NVRPoller) pollNVR(ctx context.Context, n *data.NVR) {
 	// Enforce Time Budget for the fetch operation
 	fetchCtx, cancel := context.WithTimeout(ctx, p.cfg.TimeBudget)
@@ -0,0 +1,90 @@
+package nvr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/maintenance"
+)
+
+func TestPollDue_OverrideIntervalPolledMoreOften(t *testing.T) {
+	fastMs := 1000
+	fast := &data.NVR{ID: uuid.New(), IsEnabled: true, Status: "online", EventPollIntervalMs: &fastMs}
+	def := &data.NVR{ID: uuid.New(), IsEnabled: true, Status: "online"}
+	offline := &data.NVR{ID: uuid.New(), IsEnabled: true, Status: "offline", EventPollIntervalMs: &fastMs}
+
+	p := &NVRPoller{cfg: PollerConfig{PollInterval: 5 * time.Second}, lastPoll: make(map[uuid.UUID]time.Time)}
+	polls := map[uuid.UUID]int{}
+	dispatch := func(n *data.NVR) bool { polls[n.ID]++; return true }
+
+	// 20s of scheduler ticks
+	start := time.Now()
+	for now := start; now.Before(start.Add(20 * time.Second)); now = now.Add(eventPollTick) {
+		p.pollDue([]*data.NVR{fast, def, offline}, now, dispatch)
+	}
+
+	if polls[fast.ID] != 20 {
+		t.Errorf("Expected 20 polls at 1s, got %d", polls[fast.ID])
+	}
+	if polls[def.ID] != 4 {
+		t.Errorf("Expected 4 polls at the 5s default, got %d", polls[def.ID])
+	}
+	if polls[offline.ID] != 0 {
+		t.Errorf("Offline NVR must not be polled, got %d", polls[offline.ID])
+	}
+}
+
+func TestPollDue_RefusedDispatchStaysDue(t *testing.T) {
+	n := &data.NVR{ID: uuid.New(), IsEnabled: true, Status: "online"}
+	p := &NVRPoller{cfg: PollerConfig{PollInterval: 5 * time.Second}, lastPoll: make(map[uuid.UUID]time.Time)}
+	now := time.Now()
+
+	p.pollDue([]*data.NVR{n}, now, func(*data.NVR) bool { return false })
+	polled := false
+	p.pollDue([]*data.NVR{n}, now.Add(eventPollTick), func(*data.NVR) bool { polled = true; return true })
+	if !polled {
+		t.Error("NVR refused for capacity must be retried on the next tick")
+	}
+
+	p.pollDue(nil, now.Add(2*eventPollTick), func(*data.NVR) bool { return true })
+	if len(p.lastPoll) != 0 {
+		t.Error("Deleted NVRs must be forgotten")
+	}
+}
+
+func TestPollDue_PausedDuringMaintenance(t *testing.T) {
+	n := &data.NVR{ID: uuid.New(), TenantID: uuid.New(), IsEnabled: true, Status: "online"}
+	p := &NVRPoller{cfg: PollerConfig{PollInterval: 5 * time.Second}, lastPoll: make(map[uuid.UUID]time.Time), Maintenance: maintenance.New()}
+	polls := 0
+	dispatch := func(*data.NVR) bool { polls++; return true }
+	now := time.Now()
+
+	p.Maintenance.SetTenant(context.Background(), n.TenantID, true)
+	p.pollDue([]*data.NVR{n}, now, dispatch)
+	if polls != 0 {
+		t.Fatal("NVR of a tenant in maintenance must not be polled")
+	}
+
+	p.Maintenance.SetTenant(context.Background(), n.TenantID, false)
+	p.pollDue([]*data.NVR{n}, now.Add(eventPollTick), dispatch)
+	if polls != 1 {
+		t.Errorf("Expected polling to resume on the first tick after maintenance, got %d polls", polls)
+	}
+}
+
+func TestValidateEventPollInterval(t *testing.T) {
+	for ms, ok := range map[int]bool{499: false, 500: true, 60000: true, 3600001: false} {
+		v := ms
+		err := validateEventPollInterval(&v)
+		if (err == nil) != ok || (!ok && !errors.Is(err, ErrInvalidEventPollInterval)) {
+			t.Errorf("%d ms: expected ok=%t, got %v", ms, ok, err)
+		}
+	}
+	if err := validateEventPollInterval(nil); err != nil {
+		t.Errorf("No override must be valid, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package nvr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/technosupport/ts-vms/internal/data"
+)
+
+// DefaultHealthSummaryTTL is how long a computed dashboard summary is reused.
+const DefaultHealthSummaryTTL = 5 * time.Second
+
+// HealthSummaryCache keeps GetNVRHealthSummary results in Redis per tenant and
+// site scope for a short TTL, so a burst of dashboard loads shares one pair of
+// aggregate queries. Entries are never invalidated; they simply expire.
+type HealthSummaryCache struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// NewHealthSummaryCache caches for ttl; zero or less means
+// DefaultHealthSummaryTTL.
+func NewHealthSummaryCache(rdb *redis.Client, ttl time.Duration) *HealthSummaryCache {
+	if ttl <= 0 {
+		ttl = DefaultHealthSummaryTTL
+	}
+	return &HealthSummaryCache{rdb: rdb, ttl: ttl}
+}
+
+// healthSummaryKey identifies a tenant and site scope; nil sites (all) and an
+// explicit list get different keys, and the list order does not matter.
+func healthSummaryKey(tenantID uuid.UUID, siteIDs []uuid.UUID) string {
+	scope := "all"
+	if siteIDs != nil {
+		ids := make([]string, len(siteIDs))
+		for i, id := range siteIDs {
+			ids[i] = id.String()
+		}
+		slices.Sort(ids)
+		sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+		scope = hex.EncodeToString(sum[:8])
+	}
+	return "nvr:health_summary:" + tenantID.String() + ":" + scope
+}
+
+func (c *HealthSummaryCache) get(ctx context.Context, key string) (*data.NVRHealthSummary, bool) {
+	raw, err := c.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("[NVR] health summary cache read failed: %v", err)
+		}
+		return nil, false
+	}
+	var sum data.NVRHealthSummary
+	if err := json.Unmarshal(raw, &sum); err != nil {
+		return nil, false
+	}
+	return &sum, true
+}
+
+func (c *HealthSummaryCache) set(ctx context.Context, key string, sum *data.NVRHealthSummary) {
+	raw, err := json.Marshal(sum)
+	if err != nil {
+		return
+	}
+	if err := c.rdb.Set(ctx, key, raw, c.ttl).Err(); err != nil {
+		log.Printf("[NVR] health summary cache write failed: %v", err)
+	}
+}
+
+// GetNVRHealthSummary returns the tenant's NVR/channel health counts for the
+// given sites (nil: all), from the cache when one is configured and fresh.
+// A Redis failure falls back to the database.
+func (s *Service) GetNVRHealthSummary(ctx context.Context, tenantID uuid.UUID, siteIDs []uuid.UUID) (*data.NVRHealthSummary, error) {
+	c := s.HealthSummaryCache
+	if c == nil {
+		return s.repo.GetNVRHealthSummary(ctx, tenantID, siteIDs)
+	}
+
+	key := healthSummaryKey(tenantID, siteIDs)
+	if sum, ok := c.get(ctx, key); ok {
+		return sum, nil
+	}
+	sum, err := s.repo.GetNVRHealthSummary(ctx, tenantID, siteIDs)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, key, sum)
+	return sum, nil
+}
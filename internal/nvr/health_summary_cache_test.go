@@ -0,0 +1,80 @@
+package nvr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/technosupport/ts-vms/internal/data"
+)
+
+// summaryRepo counts the aggregate queries behind GetNVRHealthSummary.
+type summaryRepo struct {
+	*mockRepo
+	calls  int
+	online int
+}
+
+func (r *summaryRepo) GetNVRHealthSummary(ctx context.Context, tid uuid.UUID, s []uuid.UUID) (*data.NVRHealthSummary, error) {
+	r.calls++
+	return &data.NVRHealthSummary{TotalNVRs: 3, NVRsOnline: r.online}, nil
+}
+
+func TestHealthSummaryCache_ServesWithinTTLAndExpires(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	repo := &summaryRepo{mockRepo: &mockRepo{}, online: 2}
+	svc := NewService(repo, nil, nil, nil)
+	svc.HealthSummaryCache = NewHealthSummaryCache(rdb, 5*time.Second)
+	ctx := context.Background()
+	tenantID, siteA, siteB := uuid.New(), uuid.New(), uuid.New()
+
+	first, err := svc.GetNVRHealthSummary(ctx, tenantID, nil)
+	if err != nil || first.NVRsOnline != 2 {
+		t.Fatalf("first summary: %+v (%v)", first, err)
+	}
+
+	// Within the TTL the cached result is served, even though the data moved
+	repo.online = 1
+	mr.FastForward(4 * time.Second)
+	second, err := svc.GetNVRHealthSummary(ctx, tenantID, nil)
+	if err != nil || second.NVRsOnline != 2 || repo.calls != 1 {
+		t.Errorf("Expected cached summary (1 query), got %+v after %d queries (%v)", second, repo.calls, err)
+	}
+
+	// Site scope and tenant are part of the key; site order is not
+	svc.GetNVRHealthSummary(ctx, tenantID, []uuid.UUID{siteA, siteB})
+	svc.GetNVRHealthSummary(ctx, tenantID, []uuid.UUID{siteB, siteA})
+	svc.GetNVRHealthSummary(ctx, uuid.New(), nil)
+	if repo.calls != 3 {
+		t.Errorf("Expected one query per tenant+site scope, got %d", repo.calls)
+	}
+
+	// After the TTL the summary is recomputed
+	mr.FastForward(2 * time.Second)
+	third, err := svc.GetNVRHealthSummary(ctx, tenantID, nil)
+	if err != nil || third.NVRsOnline != 1 || repo.calls != 4 {
+		t.Errorf("Expected fresh summary after expiry, got %+v after %d queries (%v)", third, repo.calls, err)
+	}
+}
+
+func TestHealthSummaryCache_RedisDownFallsBack(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer rdb.Close()
+	mr.Close()
+
+	repo := &summaryRepo{mockRepo: &mockRepo{}, online: 2}
+	svc := NewService(repo, nil, nil, nil)
+	svc.HealthSummaryCache = NewHealthSummaryCache(rdb, 0)
+
+	sum, err := svc.GetNVRHealthSummary(context.Background(), uuid.New(), nil)
+	if err != nil || sum.NVRsOnline != 2 || repo.calls != 1 {
+		t.Errorf("Expected database summary with Redis down, got %+v (%v)", sum, err)
+	}
+}
@@ -2,13 +2,16 @@ package nvr
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/maintenance"
 	"github.com/technosupport/ts-vms/internal/metrics"
 	"github.com/technosupport/ts-vms/internal/middleware"
 	"github.com/technosupport/ts-vms/internal/nvr/adapters"
@@ -29,6 +32,76 @@ type NVRMonitor struct {
 
 	// Auth Backoff Cache: ID (NVR or Channel) -> ReleaseTime
 	backoffCache sync.Map
+
+	// NVRID -> time it was last enqueued. Owned by the NVR scheduler goroutine.
+	lastNVRCheck map[uuid.UUID]time.Time
+
+	// ChannelOfflineAfterFailures is how many consecutive failed probes it
+	// takes to report a channel offline. Set before Start.
+	ChannelOfflineAfterFailures int
+
+	// Maintenance pauses probing of NVRs whose tenant is in maintenance.
+	// Nil means never. Set before Start.
+	Maintenance *maintenance.Switch
+
+	// ChannelID -> probe streak and last reported status
+	chanMu    sync.Mutex
+	chanState map[uuid.UUID]channelProbeState
+
+	// NVRID -> health_probe_mode, refreshed by the channel scheduler.
+	probeModeCache sync.Map
+
+	// Probe transports, replaceable in tests.
+	probeRTSP        func(ctx context.Context, rtspURL string) error
+	probeSnapshotURL func(ctx context.Context, snapshotURL string, cred adapters.NvrCredential) error
+}
+
+type channelProbeState struct {
+	failures int
+	status   string
+}
+
+const (
+	DefaultHealthCheckIntervalSeconds = 60
+	MinHealthCheckIntervalSeconds     = 10
+	MaxHealthCheckIntervalSeconds     = 86400
+
+	// nvrSchedulerTick is the scheduler resolution; per-NVR intervals are
+	// honored to within one tick.
+	nvrSchedulerTick = 10 * time.Second
+
+	DefaultChannelOfflineAfterFailures = 2
+
+	// Channel probe modes (nvrs.health_probe_mode).
+	HealthProbeRTSP     = "rtsp"
+	HealthProbeSnapshot = "snapshot"
+)
+
+// ErrInvalidHealthProbeMode is returned for a health_probe_mode other than
+// HealthProbeRTSP or HealthProbeSnapshot.
+var ErrInvalidHealthProbeMode = errors.New("invalid health probe mode")
+
+// normalizeHealthProbeMode defaults an empty mode to RTSP and rejects
+// anything else unknown.
+func normalizeHealthProbeMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return HealthProbeRTSP, nil
+	case HealthProbeRTSP, HealthProbeSnapshot:
+		return mode, nil
+	}
+	return "", fmt.Errorf("%w %q: must be %q or %q", ErrInvalidHealthProbeMode, mode, HealthProbeRTSP, HealthProbeSnapshot)
+}
+
+// ErrInvalidHealthCheckInterval is returned for a health_check_interval_seconds
+// outside MinHealthCheckIntervalSeconds-MaxHealthCheckIntervalSeconds.
+var ErrInvalidHealthCheckInterval = errors.New("invalid health check interval")
+
+func validateHealthCheckInterval(seconds int) error {
+	if seconds < MinHealthCheckIntervalSeconds || seconds > MaxHealthCheckIntervalSeconds {
+		return fmt.Errorf("%w: must be %d-%d seconds", ErrInvalidHealthCheckInterval, MinHealthCheckIntervalSeconds, MaxHealthCheckIntervalSeconds)
+	}
+	return nil
 }
 
 func NewMonitor(s *Service, repo data.NVRRepository) *NVRMonitor {
@@ -37,6 +110,14 @@ func NewMonitor(s *Service, repo data.NVRRepository) *NVRMonitor {
 		repo:      repo,
 		nvrQueue:  make(chan *data.NVR, 100),         // Bounded NVR queue
 		chanQueue: make(chan *data.NVRChannel, 2000), // Bounded Channel queue
+
+		lastNVRCheck: make(map[uuid.UUID]time.Time),
+
+		ChannelOfflineAfterFailures: DefaultChannelOfflineAfterFailures,
+		chanState:                   make(map[uuid.UUID]channelProbeState),
+
+		probeRTSP:        adapters.ProbeRTSP,
+		probeSnapshotURL: adapters.ProbeSnapshotURL,
 	}
 }
 
@@ -59,7 +140,7 @@ func (m *NVRMonitor) Start(ctx context.Context) {
 // --- NVR Scheduler & Worker ---
 
 func (m *NVRMonitor) runNVRScheduler(ctx context.Context) {
-	ticker := time.NewTicker(60 * time.Second)
+	ticker := time.NewTicker(nvrSchedulerTick)
 	defer ticker.Stop()
 
 	for {
@@ -74,26 +155,56 @@ func (m *NVRMonitor) runNVRScheduler(ctx context.Context) {
 			}
 
 			metrics.NVRQueueDepth.Set(float64(len(m.nvrQueue)))
+			m.scheduleNVRs(nvrs, time.Now())
+		}
+	}
+}
 
-			for _, n := range nvrs {
-				// Jitter: Sleep random 0-10s? No, shuffling or random delay in worker?
-				// Better: Non-blocking send. If full, skip (Drop oldest pattern or just skip cycle).
-				// Strict boundedness: if queue full, skip to avoid backing up.
-
-				// Check Auth Backoff
-				if resetTime, ok := m.backoffCache.Load(n.ID); ok {
-					if time.Now().Before(resetTime.(time.Time)) {
-						continue // In backoff
-					}
-					m.backoffCache.Delete(n.ID)
-				}
+// scheduleNVRs enqueues every NVR whose health_check_interval_seconds has
+// elapsed since it was last enqueued. Half a tick of slack keeps ticker drift
+// from pushing an NVR a whole tick late.
+func (m *NVRMonitor) scheduleNVRs(nvrs []*data.NVR, now time.Time) {
+	seen := make(map[uuid.UUID]bool, len(nvrs))
+	for _, n := range nvrs {
+		seen[n.ID] = true
+		// Left due while in maintenance, so it is probed on the first tick after.
+		if m.Maintenance.Active(n.TenantID) {
+			continue
+		}
 
-				select {
-				case m.nvrQueue <- n:
-				default:
-					metrics.NVRChecksTotal.WithLabelValues("fail", "queue_full").Inc()
-				}
+		interval := time.Duration(n.HealthCheckIntervalSeconds) * time.Second
+		if n.HealthCheckIntervalSeconds <= 0 {
+			interval = DefaultHealthCheckIntervalSeconds * time.Second
+		}
+		if last, ok := m.lastNVRCheck[n.ID]; ok && now.Before(last.Add(interval-nvrSchedulerTick/2)) {
+			continue
+		}
+
+		// Jitter: Sleep random 0-10s? No, shuffling or random delay in worker?
+		// Better: Non-blocking send. If full, skip (Drop oldest pattern or just skip cycle).
+		// Strict boundedness: if queue full, skip to avoid backing up.
+
+		// Check Auth Backoff
+		if resetTime, ok := m.backoffCache.Load(n.ID); ok {
+			if now.Before(resetTime.(time.Time)) {
+				continue // In backoff
 			}
+			m.backoffCache.Delete(n.ID)
+		}
+
+		select {
+		case m.nvrQueue <- n:
+			m.lastNVRCheck[n.ID] = now
+		default:
+			// Left due; retried next tick.
+			metrics.NVRChecksTotal.WithLabelValues("fail", "queue_full").Inc()
+		}
+	}
+
+	// Forget NVRs that were deleted.
+	for id := range m.lastNVRCheck {
+		if !seen[id] {
+			delete(m.lastNVRCheck, id)
 		}
 	}
 }
@@ -115,18 +226,8 @@ func (m *NVRMonitor) checkNVR(ctx context.Context, nvr *data.NVR) {
 	// Small random delay 0-500ms
 	time.Sleep(time.Duration(rand.Intn(500)) * time.Millisecond)
 
-	// Construct System Context for RLS
-	// We simulate a system user or simply use the TenantID to allow RLS to pass if we use repo methods that check it.
-	// However, UpsertNVRHealth likely bypasses RLS or we need to ensure it works.
-	// Repo implementation for Upsert might not enforce RLS if it's "System" operation?
-	// But `UpsertNVRHealth` takes `ctx`.
-	// Let's create a context with AuthContext.
-	ac := &middleware.AuthContext{
-		TenantID: nvr.TenantID.String(),
-		UserID:   "system-monitor",
-		Roles:    []string{"system"},
-	}
-	tenantCtx := middleware.WithAuthContext(ctx, ac)
+	// Tenant-scoped system context for RLS
+	tenantCtx := middleware.SystemContext(ctx, nvr.TenantID)
 
 	status := "online"
 	var errCode *string
@@ -216,52 +317,105 @@ func (m *NVRMonitor) runChannelScheduler(ctx context.Context) {
 
 			// Get NVRs
 			nvrs, _ := m.repo.ListAllNVRs(ctx)
+			m.scheduleChannels(ctx, nvrs)
+		}
+	}
+}
 
-			// We limit total scheduled channels per tick to avoid overload
-			enqueuedCount := 0
-			limit := 2000 // Tick Limit
+// scheduleChannels enqueues the enabled channels of online NVRs, up to the
+// per-tick limit. Probe state is seeded from the stored health rows for
+// channels the monitor has not tracked yet, and dropped for channels not
+// listed in this pass (deleted, disabled or behind an NVR that is down);
+// those are re-seeded when they are scheduled again.
+func (m *NVRMonitor) scheduleChannels(ctx context.Context, nvrs []*data.NVR) {
+	// We limit total scheduled channels per tick to avoid overload
+	enqueuedCount := 0
+	limit := 2000 // Tick Limit
+	seen := make(map[uuid.UUID]bool)
+
+	for _, n := range nvrs {
+		if enqueuedCount >= limit {
+			break
+		}
+		if m.Maintenance.Active(n.TenantID) {
+			continue
+		}
 
-			for _, n := range nvrs {
-				if enqueuedCount >= limit {
-					break
-				}
+		// Check NVR Cache Status
+		st, ok := m.nvrStatusCache.Load(n.ID)
+		if !ok || st.(string) != "online" {
+			continue // Logic: Skip channels if NVR down
+		}
+		m.probeModeCache.Store(n.ID, n.HealthProbeMode)
 
-				// Check NVR Cache Status
-				st, ok := m.nvrStatusCache.Load(n.ID)
-				if !ok || st.(string) != "online" {
-					continue // Logic: Skip channels if NVR down
-				}
+		// Fetch Channels for NVR, never more than the per-NVR cap
+		channels, _, err := m.repo.ListChannels(ctx, n.ID, data.NVRChannelFilter{IsEnabled: boolPtr(true)}, m.service.maxChannelsPerNVR(), 0)
+		if err != nil {
+			continue
+		}
+		for _, ch := range channels {
+			seen[ch.ID] = true
+		}
+		m.seedChannelState(ctx, n.ID, channels)
 
-				// Fetch Channels for NVR
-				// Warning: ListAllChannels for NVR might be 256 items.
-				channels, _, err := m.repo.ListChannels(ctx, n.ID, data.NVRChannelFilter{IsEnabled: boolPtr(true)}, 500, 0)
-				if err != nil {
+		for _, ch := range channels {
+			if enqueuedCount >= limit {
+				break
+			}
+
+			// Auth Backoff Check
+			if resetTime, ok := m.backoffCache.Load(ch.ID); ok {
+				if time.Now().Before(resetTime.(time.Time)) {
 					continue
 				}
+				m.backoffCache.Delete(ch.ID)
+			}
 
-				for _, ch := range channels {
-					if enqueuedCount >= limit {
-						break
-					}
-
-					// Auth Backoff Check
-					if resetTime, ok := m.backoffCache.Load(ch.ID); ok {
-						if time.Now().Before(resetTime.(time.Time)) {
-							continue
-						}
-						m.backoffCache.Delete(ch.ID)
-					}
-
-					select {
-					case m.chanQueue <- ch:
-						enqueuedCount++
-					default:
-						metrics.ChannelChecksTotal.WithLabelValues("fail", "queue_full").Inc()
-					}
-				}
+			select {
+			case m.chanQueue <- ch:
+				enqueuedCount++
+			default:
+				metrics.ChannelChecksTotal.WithLabelValues("fail", "queue_full").Inc()
 			}
 		}
 	}
+
+	m.chanMu.Lock()
+	for id := range m.chanState {
+		if !seen[id] {
+			delete(m.chanState, id)
+		}
+	}
+	m.chanMu.Unlock()
+}
+
+// seedChannelState loads the stored failure streak and status of the given
+// channels that have no probe state yet, so the offline debounce carries
+// over a restart.
+func (m *NVRMonitor) seedChannelState(ctx context.Context, nvrID uuid.UUID, channels []*data.NVRChannel) {
+	m.chanMu.Lock()
+	missing := make(map[uuid.UUID]bool)
+	for _, ch := range channels {
+		if _, ok := m.chanState[ch.ID]; !ok {
+			missing[ch.ID] = true
+		}
+	}
+	m.chanMu.Unlock()
+	if len(missing) == 0 {
+		return
+	}
+
+	stored, err := m.repo.ListChannelHealth(ctx, nvrID, m.service.maxChannelsPerNVR(), 0)
+	if err != nil {
+		return
+	}
+	m.chanMu.Lock()
+	defer m.chanMu.Unlock()
+	for _, h := range stored {
+		if _, ok := m.chanState[h.ChannelID]; missing[h.ChannelID] && !ok {
+			m.chanState[h.ChannelID] = channelProbeState{failures: h.ConsecutiveFailures, status: h.Status}
+		}
+	}
 }
 
 func (m *NVRMonitor) channelWorker(ctx context.Context) {
@@ -287,7 +441,7 @@ func (m *NVRMonitor) checkChannel(ctx context.Context, ch *data.NVRChannel) {
 	status := "online"
 	var errCode *string
 
-	// Probe RTSP
+	// Probe the channel (RTSP or snapshot, per the NVR health_probe_mode)
 	// We use `adapters.SanitizedURL`? No, we need REAL URL.
 	// The `ch` struct has `RTSPMain` which is SANITIZED in `NVRChannel` struct definition?
 	// Wait, `NVRChannel` struct in models says `RTSPMain string json:"rtsp_main_url_sanitized"`.
@@ -307,49 +461,95 @@ func (m *NVRMonitor) checkChannel(ctx context.Context, ch *data.NVRChannel) {
 	// We can inject it back.
 
 	// 1. Get NVR Creds
-	_, _, cred, err := m.service.getAdapterClient(ctx, ch.NVRID)
+	adapter, target, cred, err := m.service.getAdapterClient(ctx, ch.NVRID)
 	if err != nil {
 		status = "unknown" // Cannot fetch creds
-	} else {
-		// 2. Re-inject credentials into sanitized RTSP URL
-		// Helper function needed.
-		realURL := injectCredentials(ch.RTSPMain, cred.Username, cred.Password)
-
-		// 3. Probe
-		// Use a lightweight RTSP client or just net.Dial?
-		// "Channel check method: RTSP OPTIONS".
-		// We need an RTSP Prober helper.
-		if err := adapters.ProbeRTSP(ctx, realURL); err != nil {
-			if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "403") {
-				status = "auth_failed"
-				m.backoffCache.Store(ch.ID, time.Now().Add(10*time.Minute))
-			} else {
-				status = "offline"
-				// or "stream_error" if connect ok but protocol bad
-			}
-			e := err.Error()
-			errCode = &e
+	} else if err := m.probeChannel(ctx, m.probeMode(ch.NVRID), adapter, target, cred, ch); err != nil {
+		switch {
+		case errors.Is(err, errNoSnapshotSource):
+			status = "unknown"
+		case strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "403"):
+			status = "auth_failed"
+			m.backoffCache.Store(ch.ID, time.Now().Add(10*time.Minute))
+		default:
+			status = "offline"
+			// or "stream_error" if connect ok but protocol bad
 		}
+		e := err.Error()
+		errCode = &e
 	}
 
-	h := &data.NVRChannelHealth{
-		TenantID:      ch.TenantID,
-		NVRID:         ch.NVRID,
-		ChannelID:     ch.ID,
-		Status:        status,
-		LastCheckedAt: time.Now(),
-		LastErrorCode: errCode,
+	h := m.recordChannelProbe(ch, status, errCode, time.Now())
+	m.repo.UpsertChannelHealth(context.Background(), h)
+	metrics.ChannelChecksTotal.WithLabelValues("success", h.Status).Inc()
+}
+
+// errNoSnapshotSource means snapshot mode was selected but neither the adapter
+// nor the channel metadata offers a way to fetch a still image.
+var errNoSnapshotSource = errors.New("snapshot_unavailable")
+
+func (m *NVRMonitor) probeMode(nvrID uuid.UUID) string {
+	if mode, ok := m.probeModeCache.Load(nvrID); ok && mode.(string) != "" {
+		return mode.(string)
 	}
-	if status == "online" {
-		t := time.Now()
-		h.LastSuccessAt = &t
+	return HealthProbeRTSP
+}
+
+// probeChannel checks channel liveness using the NVR's probe mode. RTSP mode
+// does an OPTIONS handshake against the main stream, with the NVR credentials
+// re-injected into the sanitized URL stored in the DB. Snapshot mode uses the
+// adapter's native snapshot endpoint when it has one, otherwise the ONVIF
+// snapshot URI recorded in the channel metadata ("snapshot_uri").
+func (m *NVRMonitor) probeChannel(ctx context.Context, mode string, adapter adapters.Adapter, target adapters.NvrTarget, cred adapters.NvrCredential, ch *data.NVRChannel) error {
+	if mode != HealthProbeSnapshot {
+		return m.probeRTSP(ctx, injectCredentials(ch.RTSPMain, cred.Username, cred.Password))
+	}
+	if p, ok := adapter.(adapters.SnapshotProber); ok {
+		return p.ProbeSnapshot(ctx, target, cred, ch.ChannelRef)
 	}
+	if uri, _ := ch.Metadata["snapshot_uri"].(string); uri != "" {
+		return m.probeSnapshotURL(ctx, uri, cred)
+	}
+	return errNoSnapshotSource
+}
+
+// recordChannelProbe turns a probe result into the health row to persist.
+// An "offline" probe only flips a channel that was last reported online once
+// ChannelOfflineAfterFailures probes in a row have failed; until then the
+// previous status is kept. last_checked_at and the failure streak are always
+// updated, and a single success resets the streak.
+func (m *NVRMonitor) recordChannelProbe(ch *data.NVRChannel, status string, errCode *string, now time.Time) *data.NVRChannelHealth {
+	threshold := m.ChannelOfflineAfterFailures
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	m.chanMu.Lock()
+	prev, known := m.chanState[ch.ID]
+	next := channelProbeState{status: status}
 	if status != "online" {
-		h.ConsecutiveFailures = 1
+		next.failures = prev.failures + 1
+	}
+	if status == "offline" && known && prev.status == "online" && next.failures < threshold {
+		next.status = prev.status
 	}
+	m.chanState[ch.ID] = next
+	m.chanMu.Unlock()
 
-	m.repo.UpsertChannelHealth(context.Background(), h)
-	metrics.ChannelChecksTotal.WithLabelValues("success", status).Inc()
+	h := &data.NVRChannelHealth{
+		TenantID:            ch.TenantID,
+		NVRID:               ch.NVRID,
+		ChannelID:           ch.ID,
+		Status:              next.status,
+		LastCheckedAt:       now,
+		ConsecutiveFailures: next.failures,
+		LastErrorCode:       errCode,
+	}
+	if status == "online" {
+		t := now
+		h.LastSuccessAt = &t
+	}
+	return h
 }
 
 func injectCredentials(sanitizedURL, user, pass string) string {
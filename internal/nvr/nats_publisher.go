@@ -3,36 +3,68 @@ package nvr
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/nats-io/nats.go"
+	"github.com/technosupport/ts-vms/internal/metrics"
 )
 
+// DefaultPublishBufferSize bounds messages held while NATS is disconnected.
+const DefaultPublishBufferSize = 1000
+
+// NATSConn is the subset of *nats.Conn used by the publisher.
+type NATSConn interface {
+	Publish(subject string, data []byte) error
+	IsConnected() bool
+}
+
 type NATSPublisher struct {
-	conn       *nats.Conn
+	conn       NATSConn
 	subject    string
 	maxRetries int
+
+	mu         sync.Mutex
+	buffer     [][]byte
+	bufferSize int
 }
 
-func NewNATSPublisher(conn *nats.Conn, subject string, maxRetries int) *NATSPublisher {
+func NewNATSPublisher(conn NATSConn, subject string, maxRetries, bufferSize int) *NATSPublisher {
+	if bufferSize <= 0 {
+		bufferSize = DefaultPublishBufferSize
+	}
 	return &NATSPublisher{
 		conn:       conn,
 		subject:    subject,
 		maxRetries: maxRetries,
+		bufferSize: bufferSize,
 	}
 }
 
+// Publish sends the event, or buffers it while the connection is down.
+// A buffered event is not an error; it is flushed on reconnect.
 func (p *NATSPublisher) Publish(event *VmsEvent) error {
 	data, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("marshal error: %w", err)
 	}
 
+	if !p.conn.IsConnected() {
+		p.enqueue(data)
+		return nil
+	}
+
+	// Preserve ordering: drain anything held from the last outage first
+	p.Flush()
+
 	for i := 0; i <= p.maxRetries; i++ {
 		err = p.conn.Publish(p.subject, data)
 		if err == nil {
 			return nil
 		}
+		if !p.conn.IsConnected() {
+			p.enqueue(data)
+			return nil
+		}
 
 		// Backoff
 		time.Sleep(time.Duration(i*100) * time.Millisecond)
@@ -40,3 +72,46 @@ func (p *NATSPublisher) Publish(event *VmsEvent) error {
 
 	return fmt.Errorf("publish failed after %d retries: %w", p.maxRetries, err)
 }
+
+// Flush publishes buffered messages in order. Call from the NATS reconnect handler.
+// Stops at the first failure and keeps the remainder buffered.
+func (p *NATSPublisher) Flush() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sent := 0
+	for len(p.buffer) > 0 {
+		if !p.conn.IsConnected() {
+			break
+		}
+		if err := p.conn.Publish(p.subject, p.buffer[0]); err != nil {
+			break
+		}
+		p.buffer[0] = nil
+		p.buffer = p.buffer[1:]
+		sent++
+	}
+	metrics.NATSPublishBuffered.Set(float64(len(p.buffer)))
+	return sent
+}
+
+// Buffered returns the number of messages waiting for reconnect.
+func (p *NATSPublisher) Buffered() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.buffer)
+}
+
+// enqueue appends to the bounded buffer, dropping the oldest message when full.
+func (p *NATSPublisher) enqueue(data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buffer) >= p.bufferSize {
+		p.buffer[0] = nil
+		p.buffer = p.buffer[1:]
+		metrics.NATSPublishDroppedTotal.Inc()
+	}
+	p.buffer = append(p.buffer, data)
+	metrics.NATSPublishBuffered.Set(float64(len(p.buffer)))
+}
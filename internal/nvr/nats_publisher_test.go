@@ -0,0 +1,123 @@
+package nvr
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// mockConn records publishes and simulates connection state
+type mockConn struct {
+	mu        sync.Mutex
+	connected bool
+	published [][]byte
+}
+
+func (m *mockConn) Publish(subject string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.connected {
+		return errors.New("nats: connection closed")
+	}
+	m.published = append(m.published, data)
+	return nil
+}
+
+func (m *mockConn) IsConnected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connected
+}
+
+func (m *mockConn) setConnected(v bool) {
+	m.mu.Lock()
+	m.connected = v
+	m.mu.Unlock()
+}
+
+func eventTypes(t *testing.T, msgs [][]byte) []string {
+	var out []string
+	for _, raw := range msgs {
+		var evt VmsEvent
+		if err := json.Unmarshal(raw, &evt); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, evt.EventType)
+	}
+	return out
+}
+
+func TestNATSPublisher_FlushesBufferOnReconnect(t *testing.T) {
+	conn := &mockConn{connected: true}
+	pub := NewNATSPublisher(conn, "events.nvr", 0, 10)
+
+	if err := pub.Publish(&VmsEvent{EventType: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.setConnected(false)
+	for _, et := range []string{"b", "c"} {
+		if err := pub.Publish(&VmsEvent{EventType: et}); err != nil {
+			t.Fatalf("Publish during disconnect should buffer, got %v", err)
+		}
+	}
+	if pub.Buffered() != 2 {
+		t.Fatalf("Expected 2 buffered, got %d", pub.Buffered())
+	}
+
+	conn.setConnected(true)
+	if n := pub.Flush(); n != 2 {
+		t.Errorf("Expected 2 flushed, got %d", n)
+	}
+	if pub.Buffered() != 0 {
+		t.Errorf("Expected empty buffer after flush, got %d", pub.Buffered())
+	}
+
+	got := eventTypes(t, conn.published)
+	want := []string{"a", "b", "c"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Order mismatch: expected %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestNATSPublisher_BufferIsBounded(t *testing.T) {
+	conn := &mockConn{connected: false}
+	pub := NewNATSPublisher(conn, "events.nvr", 0, 3)
+
+	for _, et := range []string{"1", "2", "3", "4", "5"} {
+		pub.Publish(&VmsEvent{EventType: et})
+	}
+	if pub.Buffered() != 3 {
+		t.Fatalf("Expected buffer capped at 3, got %d", pub.Buffered())
+	}
+
+	conn.setConnected(true)
+	pub.Flush()
+
+	got := eventTypes(t, conn.published)
+	want := []string{"3", "4", "5"} // Oldest dropped
+	if len(got) != len(want) || got[0] != want[0] || got[2] != want[2] {
+		t.Errorf("Expected newest %v retained, got %v", want, got)
+	}
+}
+
+func TestNATSPublisher_PublishDrainsBufferFirst(t *testing.T) {
+	conn := &mockConn{connected: false}
+	pub := NewNATSPublisher(conn, "events.nvr", 0, 10)
+
+	pub.Publish(&VmsEvent{EventType: "held"})
+	conn.setConnected(true)
+	pub.Publish(&VmsEvent{EventType: "live"})
+
+	got := eventTypes(t, conn.published)
+	if len(got) != 2 || got[0] != "held" || got[1] != "live" {
+		t.Errorf("Expected buffered event before live event, got %v", got)
+	}
+}
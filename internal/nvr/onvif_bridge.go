@@ -0,0 +1,356 @@
+package nvr
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/discovery"
+)
+
+// ONVIFEventClient is the subset of *discovery.OnvifClient used by the bridge.
+type ONVIFEventClient interface {
+	GetEventsXAddr(ctx context.Context) (string, error)
+	CreatePullPointSubscription(ctx context.Context, eventsURI string, termination time.Duration) (string, error)
+	PullMessages(ctx context.Context, subscriptionURI string, timeout time.Duration, limit int) ([]discovery.NotificationMessage, error)
+}
+
+// ONVIFEventSourceLister lists the cameras opted in to ONVIF events;
+// data.CameraModel satisfies it.
+type ONVIFEventSourceLister interface {
+	ListONVIFEventSources(ctx context.Context) ([]data.ONVIFEventSource, error)
+}
+
+// ONVIFCredentialFunc returns the camera's ONVIF login; empty when none is stored.
+type ONVIFCredentialFunc func(ctx context.Context, tenantID, cameraID uuid.UUID) (username, password string, err error)
+
+type ONVIFBridgeConfig struct {
+	Enabled      bool
+	PullInterval time.Duration
+	// PullTimeout is how long the device may hold a PullMessages call open
+	// waiting for an event; keep it under the ONVIF client's call timeout.
+	PullTimeout     time.Duration
+	MaxMessages     int
+	SubscriptionTTL time.Duration
+	MaxInflight     int
+}
+
+const (
+	// onvifSourceRefresh is how often the opted-in camera list is reloaded.
+	onvifSourceRefresh = 30 * time.Second
+)
+
+type onvifSubscription struct {
+	client    ONVIFEventClient
+	address   string
+	expiresAt time.Time
+	// resubscribed is set when the camera had a subscription before; its
+	// "Initialized" state messages repeat what was already published.
+	resubscribed bool
+}
+
+// ONVIFEventBridge pulls motion/analytics events from cameras connected
+// directly (no NVR) over ONVIF PullPoint subscriptions and publishes them
+// through the same dedup and NATS path as the NVR poller.
+type ONVIFEventBridge struct {
+	sources ONVIFEventSourceLister
+	creds   ONVIFCredentialFunc
+	pub     *NATSPublisher
+	dedup   *EventDedup
+	cfg     ONVIFBridgeConfig
+
+	// NewClient builds the ONVIF client for a camera's device service.
+	NewClient func(xaddr, username, password string) (ONVIFEventClient, error)
+
+	mu         sync.Mutex
+	subs       map[uuid.UUID]*onvifSubscription
+	subscribed map[uuid.UUID]bool // Cameras subscribed at least once
+	inflight   map[uuid.UUID]bool
+
+	sem      chan struct{}
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewONVIFEventBridge(sources ONVIFEventSourceLister, creds ONVIFCredentialFunc, pub *NATSPublisher, dedup *EventDedup, cfg ONVIFBridgeConfig) *ONVIFEventBridge {
+	if cfg.PullInterval <= 0 {
+		cfg.PullInterval = time.Second
+	}
+	if cfg.PullTimeout <= 0 {
+		cfg.PullTimeout = time.Second
+	}
+	if cfg.MaxMessages <= 0 {
+		cfg.MaxMessages = 100
+	}
+	if cfg.SubscriptionTTL <= 0 {
+		cfg.SubscriptionTTL = 5 * time.Minute
+	}
+	if cfg.MaxInflight <= 0 {
+		cfg.MaxInflight = 10
+	}
+	return &ONVIFEventBridge{
+		sources: sources,
+		creds:   creds,
+		pub:     pub,
+		dedup:   dedup,
+		cfg:     cfg,
+		NewClient: func(xaddr, username, password string) (ONVIFEventClient, error) {
+			return discovery.NewOnvifClient(xaddr, username, password)
+		},
+		subs:       make(map[uuid.UUID]*onvifSubscription),
+		subscribed: make(map[uuid.UUID]bool),
+		inflight:   make(map[uuid.UUID]bool),
+		sem:        make(chan struct{}, cfg.MaxInflight),
+		stopChan:   make(chan struct{}),
+	}
+}
+
+func (b *ONVIFEventBridge) Start() {
+	if !b.cfg.Enabled {
+		return
+	}
+	b.wg.Add(1)
+	go b.runLoop()
+}
+
+func (b *ONVIFEventBridge) Stop() {
+	if !b.cfg.Enabled {
+		return
+	}
+	close(b.stopChan)
+	b.wg.Wait()
+}
+
+func (b *ONVIFEventBridge) runLoop() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.cfg.PullInterval)
+	defer ticker.Stop()
+
+	ctx := context.Background()
+	var sources []data.ONVIFEventSource
+	var listedAt time.Time
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case now := <-ticker.C:
+			if sources == nil || now.Sub(listedAt) >= onvifSourceRefresh {
+				list, err := b.sources.ListONVIFEventSources(ctx)
+				if err != nil {
+					log.Printf("[ERROR] ONVIF Events: Error listing cameras: %v", err)
+					continue
+				}
+				sources, listedAt = list, now
+				b.forgetRemoved(sources)
+			}
+			for _, src := range sources {
+				b.dispatch(ctx, src)
+			}
+		}
+	}
+}
+
+// dispatch pulls src in the background unless a pull for it is already
+// running or MaxInflight pulls are.
+func (b *ONVIFEventBridge) dispatch(ctx context.Context, src data.ONVIFEventSource) {
+	b.mu.Lock()
+	if b.inflight[src.CameraID] {
+		b.mu.Unlock()
+		return
+	}
+	select {
+	case b.sem <- struct{}{}:
+	default:
+		b.mu.Unlock()
+		return
+	}
+	b.inflight[src.CameraID] = true
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer func() {
+			b.mu.Lock()
+			delete(b.inflight, src.CameraID)
+			b.mu.Unlock()
+			<-b.sem
+		}()
+		if _, err := b.PullCamera(ctx, src); err != nil {
+			log.Printf("[WARN] ONVIF Events (%s): %v", src.CameraID, err)
+		}
+	}()
+}
+
+// forgetRemoved drops subscriptions of cameras no longer opted in; the
+// device expires them at their termination time.
+func (b *ONVIFEventBridge) forgetRemoved(sources []data.ONVIFEventSource) {
+	keep := make(map[uuid.UUID]bool, len(sources))
+	for _, s := range sources {
+		keep[s.CameraID] = true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id := range b.subs {
+		if !keep[id] {
+			delete(b.subs, id)
+		}
+	}
+	for id := range b.subscribed {
+		if !keep[id] {
+			delete(b.subscribed, id)
+		}
+	}
+}
+
+// PullCamera pulls pending events for one camera, (re)subscribing first when
+// needed, and publishes the mapped ones. Returns the number published.
+func (b *ONVIFEventBridge) PullCamera(ctx context.Context, src data.ONVIFEventSource) (int, error) {
+	sub, err := b.subscription(ctx, src)
+	if err != nil {
+		return 0, fmt.Errorf("subscribe: %w", err)
+	}
+
+	pullCtx, cancel := context.WithTimeout(ctx, b.cfg.PullTimeout+2*time.Second)
+	defer cancel()
+	msgs, err := sub.client.PullMessages(pullCtx, sub.address, b.cfg.PullTimeout, b.cfg.MaxMessages)
+	if err != nil {
+		// The subscription may have been dropped by the device; start over next time
+		b.mu.Lock()
+		delete(b.subs, src.CameraID)
+		b.mu.Unlock()
+		return 0, fmt.Errorf("pull: %w", err)
+	}
+
+	published := 0
+	for _, msg := range msgs {
+		if sub.resubscribed && strings.EqualFold(msg.PropertyOperation, "Initialized") {
+			continue
+		}
+		evt, ok := ConvertONVIFEvent(src, msg)
+		if !ok {
+			continue
+		}
+		if b.dedup.IsDuplicate(evt.DedupKey) {
+			continue
+		}
+		if err := b.pub.Publish(evt); err != nil {
+			return published, fmt.Errorf("publish_fail: %w", err)
+		}
+		published++
+	}
+	return published, nil
+}
+
+// subscription returns the camera's live PullPoint subscription, creating
+// one when there is none or it is about to expire.
+func (b *ONVIFEventBridge) subscription(ctx context.Context, src data.ONVIFEventSource) (*onvifSubscription, error) {
+	b.mu.Lock()
+	sub := b.subs[src.CameraID]
+	b.mu.Unlock()
+	if sub != nil && time.Now().Add(b.cfg.PullInterval+b.cfg.PullTimeout).Before(sub.expiresAt) {
+		return sub, nil
+	}
+
+	user, pass, err := b.creds(ctx, src.TenantID, src.CameraID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := b.NewClient(fmt.Sprintf("http://%s/onvif/device_service", src.IPAddress.String()), user, pass)
+	if err != nil {
+		return nil, err
+	}
+	eventsURI, err := client.GetEventsXAddr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := client.CreatePullPointSubscription(ctx, eventsURI, b.cfg.SubscriptionTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	sub = &onvifSubscription{client: client, address: addr, expiresAt: time.Now().Add(b.cfg.SubscriptionTTL)}
+	b.mu.Lock()
+	sub.resubscribed = b.subscribed[src.CameraID]
+	b.subscribed[src.CameraID] = true
+	b.subs[src.CameraID] = sub
+	b.mu.Unlock()
+	return sub, nil
+}
+
+// onvifStateItems are the Data items ONVIF uses for a rule's on/off state.
+var onvifStateItems = []string{"IsMotion", "State", "IsTamper", "IsInside", "IsGlobalSceneChange"}
+
+// onvifEventActive reports whether msg signals the start (or a pulse) of an
+// event. Messages carrying a state item are active only when it is "true";
+// stateless ones (e.g. LineDetector/Crossed) are always active.
+func onvifEventActive(msg discovery.NotificationMessage) bool {
+	for _, k := range onvifStateItems {
+		if v, ok := msg.Data[k]; ok {
+			return strings.EqualFold(v, "true")
+		}
+	}
+	return true
+}
+
+// onvifChannelRef picks the video source the event refers to.
+func onvifChannelRef(msg discovery.NotificationMessage) string {
+	for _, k := range []string{"VideoSourceConfigurationToken", "VideoSourceToken", "Source"} {
+		if v := msg.Source[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ConvertONVIFEvent maps an ONVIF notification from a directly connected
+// camera to a VmsEvent. It returns false for messages that do not start an
+// event (a state going false) and for topics with no VMS event type.
+func ConvertONVIFEvent(src data.ONVIFEventSource, msg discovery.NotificationMessage) (*VmsEvent, bool) {
+	if !onvifEventActive(msg) {
+		return nil, false
+	}
+	vType, vSev := MapVendorEvent("onvif", msg.Topic)
+	if vType == "unknown" {
+		return nil, false
+	}
+
+	occurred := msg.UtcTime
+	if occurred.IsZero() {
+		occurred = time.Now().UTC()
+	}
+	cameraID := src.CameraID
+	raw := map[string]interface{}{"topic": msg.Topic}
+	if msg.PropertyOperation != "" {
+		raw["property_operation"] = msg.PropertyOperation
+	}
+	for k, v := range msg.Source {
+		raw["source."+k] = v
+	}
+	for k, v := range msg.Data {
+		raw["data."+k] = v
+	}
+
+	evt := &VmsEvent{
+		EventID:    uuid.New(),
+		Source:     "camera",
+		Vendor:     "onvif",
+		TenantID:   src.TenantID,
+		SiteID:     src.SiteID,
+		CameraID:   &cameraID,
+		ChannelRef: onvifChannelRef(msg),
+		EventType:  vType,
+		Severity:   vSev,
+		OccurredAt: occurred,
+		ReceivedAt: time.Now(),
+		Raw:        raw,
+	}
+	// No NVR: the camera takes its place in the dedup key
+	evt.DedupKey = BuildDedupKey(src.TenantID.String(), src.CameraID.String(), evt.ChannelRef, vType, occurred)
+	return evt, true
+}
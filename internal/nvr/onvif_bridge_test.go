@@ -0,0 +1,181 @@
+package nvr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/discovery"
+)
+
+// fakeONVIFClient serves one PullPoint subscription with queued messages
+type fakeONVIFClient struct {
+	subscribes int
+	pulls      int
+	msgs       []discovery.NotificationMessage
+	pullErr    error
+}
+
+func (f *fakeONVIFClient) GetEventsXAddr(ctx context.Context) (string, error) {
+	return "http://10.0.0.5/onvif/event_service", nil
+}
+func (f *fakeONVIFClient) CreatePullPointSubscription(ctx context.Context, eventsURI string, termination time.Duration) (string, error) {
+	f.subscribes++
+	return "http://10.0.0.5/onvif/subscription/1", nil
+}
+func (f *fakeONVIFClient) PullMessages(ctx context.Context, subscriptionURI string, timeout time.Duration, limit int) ([]discovery.NotificationMessage, error) {
+	f.pulls++
+	if f.pullErr != nil {
+		return nil, f.pullErr
+	}
+	out := f.msgs
+	f.msgs = nil
+	return out, nil
+}
+
+func motionMessage(at time.Time, moving bool) discovery.NotificationMessage {
+	v := "false"
+	if moving {
+		v = "true"
+	}
+	return discovery.NotificationMessage{
+		Topic:             "RuleEngine/CellMotionDetector/Motion",
+		UtcTime:           at,
+		PropertyOperation: "Changed",
+		Source:            map[string]string{"VideoSourceConfigurationToken": "VideoSourceToken"},
+		Data:              map[string]string{"IsMotion": v},
+	}
+}
+
+func TestConvertONVIFEvent_Motion(t *testing.T) {
+	src := data.ONVIFEventSource{CameraID: uuid.New(), TenantID: uuid.New(), SiteID: uuid.New(), IPAddress: net.ParseIP("10.0.0.5")}
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	evt, ok := ConvertONVIFEvent(src, motionMessage(at, true))
+	if !ok {
+		t.Fatal("Expected motion start to map to an event")
+	}
+	if evt.Source != "camera" || evt.Vendor != "onvif" {
+		t.Errorf("Source/Vendor = %s/%s; want camera/onvif", evt.Source, evt.Vendor)
+	}
+	if evt.EventType != "motion" || evt.Severity != "info" {
+		t.Errorf("EventType/Severity = %s/%s; want motion/info", evt.EventType, evt.Severity)
+	}
+	if evt.CameraID == nil || *evt.CameraID != src.CameraID {
+		t.Errorf("CameraID = %v; want %s", evt.CameraID, src.CameraID)
+	}
+	if evt.TenantID != src.TenantID || evt.SiteID != src.SiteID || evt.NVRID != uuid.Nil {
+		t.Errorf("Unexpected scope: tenant %s site %s nvr %s", evt.TenantID, evt.SiteID, evt.NVRID)
+	}
+	if evt.ChannelRef != "VideoSourceToken" || !evt.OccurredAt.Equal(at) {
+		t.Errorf("ChannelRef/OccurredAt = %s/%v", evt.ChannelRef, evt.OccurredAt)
+	}
+	if want := BuildDedupKey(src.TenantID.String(), src.CameraID.String(), "VideoSourceToken", "motion", at); evt.DedupKey != want {
+		t.Errorf("DedupKey = %s; want %s", evt.DedupKey, want)
+	}
+	if evt.Raw["topic"] != "RuleEngine/CellMotionDetector/Motion" {
+		t.Errorf("Raw topic = %v", evt.Raw["topic"])
+	}
+
+	if _, ok := ConvertONVIFEvent(src, motionMessage(at, false)); ok {
+		t.Error("Motion stop should not produce an event")
+	}
+	unknown := motionMessage(at, true)
+	unknown.Topic = "Device/Trigger/Relay"
+	if _, ok := ConvertONVIFEvent(src, unknown); ok {
+		t.Error("Unmapped topic should not produce an event")
+	}
+}
+
+func TestONVIFEventBridge_PullCameraPublishes(t *testing.T) {
+	src := data.ONVIFEventSource{CameraID: uuid.New(), TenantID: uuid.New(), SiteID: uuid.New(), IPAddress: net.ParseIP("10.0.0.5")}
+	at := time.Now().UTC().Truncate(time.Second)
+	client := &fakeONVIFClient{msgs: []discovery.NotificationMessage{
+		motionMessage(at, true),
+		motionMessage(at, true), // Same second: deduplicated
+		motionMessage(at.Add(time.Second), false),
+	}}
+
+	conn := &mockConn{connected: true}
+	var gotUser, gotXAddr string
+	b := NewONVIFEventBridge(nil, func(ctx context.Context, tenantID, cameraID uuid.UUID) (string, string, error) {
+		return "admin", "secret", nil
+	}, NewNATSPublisher(conn, "events.nvr", 0, 10), NewEventDedup(100, 60), ONVIFBridgeConfig{Enabled: true})
+	b.NewClient = func(xaddr, username, password string) (ONVIFEventClient, error) {
+		gotXAddr, gotUser = xaddr, username
+		return client, nil
+	}
+
+	n, err := b.PullCamera(context.Background(), src)
+	if err != nil {
+		t.Fatalf("PullCamera failed: %v", err)
+	}
+	if n != 1 || len(conn.published) != 1 {
+		t.Fatalf("Expected 1 event published, got %d (%d on the wire)", n, len(conn.published))
+	}
+	if gotXAddr != "http://10.0.0.5/onvif/device_service" || gotUser != "admin" {
+		t.Errorf("Client built for %s as %q", gotXAddr, gotUser)
+	}
+
+	var evt VmsEvent
+	if err := json.Unmarshal(conn.published[0], &evt); err != nil {
+		t.Fatalf("Published payload is not a VmsEvent: %v", err)
+	}
+	if evt.Source != "camera" || evt.EventType != "motion" || evt.CameraID == nil || *evt.CameraID != src.CameraID {
+		t.Errorf("Unexpected published event: %+v", evt)
+	}
+
+	// The subscription is reused until it fails, then re-created
+	b.PullCamera(context.Background(), src)
+	if client.subscribes != 1 {
+		t.Errorf("Expected subscription reuse, got %d subscribes", client.subscribes)
+	}
+	client.pullErr = errors.New("subscription gone")
+	if _, err := b.PullCamera(context.Background(), src); err == nil {
+		t.Error("Expected pull error")
+	}
+	client.pullErr = nil
+	b.PullCamera(context.Background(), src)
+	if client.subscribes != 2 {
+		t.Errorf("Expected resubscribe after pull failure, got %d subscribes", client.subscribes)
+	}
+}
+
+func TestONVIFEventBridge_InitializedOnlyOnFirstSubscribe(t *testing.T) {
+	src := data.ONVIFEventSource{CameraID: uuid.New(), TenantID: uuid.New(), SiteID: uuid.New(), IPAddress: net.ParseIP("10.0.0.5")}
+	initialized := func(at time.Time) discovery.NotificationMessage {
+		msg := motionMessage(at, true)
+		msg.PropertyOperation = "Initialized"
+		return msg
+	}
+	at := time.Now().UTC().Truncate(time.Second)
+	client := &fakeONVIFClient{msgs: []discovery.NotificationMessage{initialized(at)}}
+
+	conn := &mockConn{connected: true}
+	b := NewONVIFEventBridge(nil, func(ctx context.Context, tenantID, cameraID uuid.UUID) (string, string, error) {
+		return "", "", nil
+	}, NewNATSPublisher(conn, "events.nvr", 0, 10), NewEventDedup(100, 60), ONVIFBridgeConfig{Enabled: true})
+	b.NewClient = func(xaddr, username, password string) (ONVIFEventClient, error) { return client, nil }
+
+	if n, _ := b.PullCamera(context.Background(), src); n != 1 {
+		t.Fatalf("Expected the first subscription's initial state published, got %d", n)
+	}
+
+	// The device drops the subscription; the new one replays the current state
+	client.pullErr = errors.New("subscription gone")
+	b.PullCamera(context.Background(), src)
+	client.pullErr = nil
+	client.msgs = []discovery.NotificationMessage{initialized(at.Add(time.Minute)), motionMessage(at.Add(2*time.Minute), true)}
+	n, err := b.PullCamera(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.subscribes != 2 || n != 1 {
+		t.Errorf("Expected only the Changed event after resubscribing, got %d published (%d subscribes)", n, client.subscribes)
+	}
+}
@@ -2,14 +2,17 @@ package nvr
 
 import (
 	"context"
+	"log"
 	"math/rand"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/middleware"
 )
 
 // StartDailySync starts a background ticker to run discovery sync.
 func (s *Service) StartDailySync(ctx context.Context) {
+	ctx = middleware.SystemContext(ctx, uuid.Nil)
 	ticker := time.NewTicker(24 * time.Hour)
 	go func() {
 		// Jitter startup to avoid immediate load on restart
@@ -29,14 +32,52 @@ func (s *Service) StartDailySync(ctx context.Context) {
 	}()
 }
 
+// StartOrphanChannelReconciler periodically frees channels whose provisioned
+// camera was deleted (see ReconcileOrphanedChannels).
+func (s *Service) StartOrphanChannelReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.ReconcileOrphanedChannels(ctx)
+			}
+		}
+	}()
+}
+
+// ReconcileOrphanedChannels resets provision_state to 'not_created' for
+// channels left 'created' after their camera was deleted.
+// Audit: nvr.channel.orphan_release (one per tenant with released channels)
+func (s *Service) ReconcileOrphanedChannels(ctx context.Context) (int, error) {
+	released, err := s.repo.ReleaseOrphanedChannels(middleware.SystemContext(ctx, uuid.Nil))
+	if err != nil {
+		log.Printf("[NVR] Orphaned channel reconcile failed: %v", err)
+		return 0, err
+	}
+	total := 0
+	for tenantID, n := range released {
+		total += n
+		s.audit(middleware.SystemContext(ctx, tenantID), "nvr.channel.orphan_release", tenantID, "system", "success", map[string]any{"count": n})
+	}
+	if total > 0 {
+		log.Printf("[NVR] Released %d orphaned channel(s) for re-provisioning", total)
+	}
+	return total, nil
+}
+
 // RunDiscoverySync orchestrates the daily sync
 // Audit: nvr.channel.daily_sync
 func (s *Service) RunDiscoverySync(ctx context.Context) {
+	ctx = middleware.SystemContext(ctx, uuid.Nil)
 	// Bounds:
 	// Cap NVRs per cycle: 200
 	// Skip if last_synced < 24h
 
-	// Use Nil UUID for system action
+	// Nil tenant: the sync spans all tenants
 	s.audit(ctx, "nvr.channel.daily_sync", uuid.Nil, "system", "success", nil)
 
 	// Placeholder logic
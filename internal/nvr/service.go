@@ -12,14 +12,35 @@ import (
 	"github.com/technosupport/ts-vms/internal/audit"
 	"github.com/technosupport/ts-vms/internal/crypto"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/middleware"
 	"github.com/technosupport/ts-vms/internal/nvr/adapters"
 )
 
 var (
-	ErrNVRNotFound = errors.New("nvr not found")
-	ErrInvalidOp   = errors.New("invalid operation")
+	ErrNVRNotFound          = errors.New("nvr not found")
+	ErrInvalidOp            = errors.New("invalid operation")
+	ErrInvalidRecordingMode = errors.New("invalid recording mode")
+	ErrInvalidNVRName       = errors.New("invalid name")
+	ErrInvalidNVRIP         = errors.New("invalid ip address")
+	ErrInvalidVendor        = errors.New("invalid vendor")
 )
 
+// Recording modes for camera_nvr_links.
+const (
+	RecordingModeVMS    = "vms"
+	RecordingModeNVR    = "nvr"
+	RecordingModeHybrid = "hybrid" // Both VMS and NVR record
+)
+
+// ValidRecordingMode reports whether mode is an allowed link recording mode.
+func ValidRecordingMode(mode string) bool {
+	switch mode {
+	case RecordingModeVMS, RecordingModeNVR, RecordingModeHybrid:
+		return true
+	}
+	return false
+}
+
 type KeyManager interface {
 	WrapDEK(dek []byte, aad []byte) (string, []byte, []byte, []byte, error)
 	UnwrapDEK(kid string, nonce, ciphertext, tag, aad []byte) ([]byte, error)
@@ -31,15 +52,44 @@ type Auditor interface {
 
 type CameraCreator interface {
 	CreateCamera(ctx context.Context, c *data.Camera) error
+	FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error)
 	EnableCamera(ctx context.Context, id, tenantID uuid.UUID) error
 	DisableCamera(ctx context.Context, id, tenantID uuid.UUID) error
 }
 
+// DefaultMaxChannelsPerNVR bounds how many channels discovery stores (and
+// the monitor probes) for one NVR.
+const DefaultMaxChannelsPerNVR = 512
+
 type Service struct {
 	repo    data.NVRRepository
 	keyring KeyManager
 	auditor Auditor
 	cameras CameraCreator
+
+	// VendorDetectTimeout bounds the fingerprint probe for vendor "auto".
+	// Zero or less means DefaultVendorDetectTimeout.
+	VendorDetectTimeout time.Duration
+
+	// MaxChannelsPerNVR caps the channels kept per NVR; a device reporting
+	// more is truncated. Zero or less means DefaultMaxChannelsPerNVR.
+	MaxChannelsPerNVR int
+
+	// HealthSummaryCache, when set, serves GetNVRHealthSummary from Redis.
+	HealthSummaryCache *HealthSummaryCache
+
+	// CredentialAADVersion is the AAD format new credentials are bound to and
+	// MigrateCredentialAAD re-binds to. Zero means DefaultCredentialAADVersion.
+	CredentialAADVersion int
+
+	// AdapterClients, when set, gives adapters a keep-alive HTTP client per
+	// NVR reused across probes. Nil means a fresh client per adapter call.
+	AdapterClients *adapters.ClientPool
+
+	// DescribeProbe makes channel validation OPTIONS + DESCRIBE the main
+	// stream and compare it with the channel's stored profile. Off, only the
+	// URL is checked.
+	DescribeProbe bool
 }
 
 func NewService(repo data.NVRRepository, keyring KeyManager, auditor Auditor, cameras CameraCreator) *Service {
@@ -48,7 +98,16 @@ func NewService(repo data.NVRRepository, keyring KeyManager, auditor Auditor, ca
 		keyring: keyring,
 		auditor: auditor,
 		cameras: cameras,
+
+		MaxChannelsPerNVR: DefaultMaxChannelsPerNVR,
+	}
+}
+
+func (s *Service) maxChannelsPerNVR() int {
+	if s.MaxChannelsPerNVR <= 0 {
+		return DefaultMaxChannelsPerNVR
 	}
+	return s.MaxChannelsPerNVR
 }
 
 func (s *Service) GetRepo() data.NVRRepository {
@@ -57,21 +116,50 @@ func (s *Service) GetRepo() data.NVRRepository {
 
 // --- CRUD ---
 
-func (s *Service) CreateNVR(ctx context.Context, nvr *data.NVR) error {
-	// Validation
+// validateNVRIdentity checks the operator-supplied name, address and vendor.
+func validateNVRIdentity(nvr *data.NVR) error {
 	if nvr.Name == "" || len(nvr.Name) > 120 {
-		return errors.New("invalid name")
+		return ErrInvalidNVRName
 	}
 	if ip := net.ParseIP(nvr.IPAddress); ip == nil {
-		return errors.New("invalid ip address")
+		return ErrInvalidNVRIP
 	}
-	// Vendor allowed: "hikvision" | "dahua" | "onvif" | "generic" | "unknown"
+	// Vendor allowed: "hikvision" | "dahua" | "onvif" | "generic" | "unknown",
+	// or "auto" to fingerprint the device
 	switch nvr.Vendor {
-	case "hikvision", "dahua", "onvif", "generic", "unknown":
-		// ok
-	default:
-		return errors.New("invalid vendor")
+	case "hikvision", "dahua", "onvif", "generic", VendorUnknown, VendorAuto:
+		return nil
 	}
+	return ErrInvalidVendor
+}
+
+func (s *Service) CreateNVR(ctx context.Context, nvr *data.NVR) error {
+	// Validation
+	if err := validateNVRIdentity(nvr); err != nil {
+		return err
+	}
+	var detected *VendorDetection
+	if nvr.Vendor == VendorAuto {
+		d := s.detectVendorFor(ctx, nvr)
+		detected = &d
+	} else {
+		nvr.VendorConfidence = nil
+	}
+
+	if nvr.HealthCheckIntervalSeconds == 0 {
+		nvr.HealthCheckIntervalSeconds = DefaultHealthCheckIntervalSeconds
+	}
+	if err := validateHealthCheckInterval(nvr.HealthCheckIntervalSeconds); err != nil {
+		return err
+	}
+	if err := validateEventPollInterval(nvr.EventPollIntervalMs); err != nil {
+		return err
+	}
+	mode, err := normalizeHealthProbeMode(nvr.HealthProbeMode)
+	if err != nil {
+		return err
+	}
+	nvr.HealthProbeMode = mode
 
 	nvr.Status = "unknown" // Initial status
 
@@ -79,10 +167,15 @@ func (s *Service) CreateNVR(ctx context.Context, nvr *data.NVR) error {
 		return err
 	}
 
-	s.audit(ctx, "nvr.create", nvr.TenantID, nvr.ID.String(), "success", map[string]any{
+	meta := map[string]any{
 		"name": nvr.Name,
 		"ip":   nvr.IPAddress,
-	})
+	}
+	if detected != nil {
+		meta["vendor_detected"] = detected.Vendor
+		meta["vendor_confidence"] = detected.Confidence
+	}
+	s.audit(ctx, "nvr.create", nvr.TenantID, nvr.ID.String(), "success", meta)
 	return nil
 }
 
@@ -95,10 +188,31 @@ func (s *Service) GetNVR(ctx context.Context, id uuid.UUID) (*data.NVR, error) {
 }
 
 func (s *Service) UpdateNVR(ctx context.Context, nvr *data.NVR) error {
+	if err := validateNVRIdentity(nvr); err != nil {
+		return err
+	}
+	if err := validateHealthCheckInterval(nvr.HealthCheckIntervalSeconds); err != nil {
+		return err
+	}
+	if err := validateEventPollInterval(nvr.EventPollIntervalMs); err != nil {
+		return err
+	}
+	mode, err := normalizeHealthProbeMode(nvr.HealthProbeMode)
+	if err != nil {
+		return err
+	}
+	nvr.HealthProbeMode = mode
+
+	var meta map[string]any
+	if nvr.Vendor == VendorAuto {
+		d := s.detectVendorFor(ctx, nvr)
+		meta = map[string]any{"vendor_detected": d.Vendor, "vendor_confidence": d.Confidence}
+	}
 	if err := s.repo.Update(ctx, nvr); err != nil {
 		return err
 	}
-	s.audit(ctx, "nvr.update", nvr.TenantID, nvr.ID.String(), "success", nil)
+	s.evictAdapterClient(nvr.ID)
+	s.audit(ctx, "nvr.update", nvr.TenantID, nvr.ID.String(), "success", meta)
 	return nil
 }
 
@@ -106,27 +220,38 @@ func (s *Service) DeleteNVR(ctx context.Context, id, tenantID uuid.UUID) error {
 	if err := s.repo.Delete(ctx, id); err != nil {
 		return err
 	}
+	s.evictAdapterClient(id)
 	s.audit(ctx, "nvr.delete", tenantID, id.String(), "success", nil)
 	return nil
 }
 
 // --- Linking ---
 
-func (s *Service) UpsertLink(ctx context.Context, link *data.NVRLink) error {
+// UpsertLink creates or replaces the camera's link and reports which one
+// happened. Linking a channel already used by another camera returns
+// data.ErrChannelLinked.
+func (s *Service) UpsertLink(ctx context.Context, link *data.NVRLink) (created bool, err error) {
 	// Validation
-	if link.RecordingMode != "vms" && link.RecordingMode != "nvr" {
-		return errors.New("invalid recording mode")
+	if !ValidRecordingMode(link.RecordingMode) {
+		return false, ErrInvalidRecordingMode
 	}
 
-	if err := s.repo.UpsertLink(ctx, link); err != nil {
-		return err
+	created, err = s.repo.UpsertLink(ctx, link)
+	if err != nil {
+		return false, err
 	}
 
+	action := "updated"
+	if created {
+		action = "created"
+	}
 	s.audit(ctx, "nvr.link.upsert", link.TenantID, link.NVRID.String(), "success", map[string]any{
-		"camera_id":      link.CameraID,
-		"recording_mode": link.RecordingMode,
+		"camera_id":       link.CameraID,
+		"nvr_channel_ref": link.NVRChannelRef,
+		"recording_mode":  link.RecordingMode,
+		"action":          action,
 	})
-	return nil
+	return created, nil
 }
 
 func (s *Service) UnlinkCamera(ctx context.Context, tenantID, cameraID uuid.UUID) error {
@@ -143,10 +268,36 @@ func (s *Service) UnlinkCamera(ctx context.Context, tenantID, cameraID uuid.UUID
 	return s.repo.UnlinkCamera(ctx, cameraID)
 }
 
-func (s *Service) ListLinks(ctx context.Context, nvrID uuid.UUID, limit, offset int) ([]*data.NVRLink, error) {
+// ListLinks lists the links of one of tenantID's NVRs; another tenant's NVR
+// is reported as not found.
+func (s *Service) ListLinks(ctx context.Context, nvrID, tenantID uuid.UUID, limit, offset int) ([]*data.NVRLink, error) {
+	if err := s.checkNVRTenant(ctx, nvrID, tenantID); err != nil {
+		return nil, err
+	}
 	return s.repo.ListLinks(ctx, nvrID, limit, offset)
 }
 
+// ListLinksWithCameras lists links with the linked camera's name, IP and
+// enabled state, plus the total; links to deleted cameras are left out.
+func (s *Service) ListLinksWithCameras(ctx context.Context, nvrID, tenantID uuid.UUID, order string, limit, offset int) ([]*data.NVRLinkWithCamera, int, error) {
+	if err := s.checkNVRTenant(ctx, nvrID, tenantID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListLinksWithCameras(ctx, nvrID, order, limit, offset)
+}
+
+// checkNVRTenant returns data.ErrRecordNotFound unless nvrID belongs to tenantID.
+func (s *Service) checkNVRTenant(ctx context.Context, nvrID, tenantID uuid.UUID) error {
+	n, err := s.repo.GetByID(ctx, nvrID)
+	if err != nil {
+		return err
+	}
+	if n.TenantID != tenantID {
+		return data.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (s *Service) ListChannels(ctx context.Context, nvrID, tenantID uuid.UUID, filter data.NVRChannelFilter, limit, offset int) ([]*data.NVRChannel, int, error) {
 	// Verify Access (GetNVR checks RLS via repo usually, but repo method ListChannels takes nvrID)
 	// We should ensure NVR belongs to tenant.
@@ -167,6 +318,34 @@ func (s *Service) ListChannels(ctx context.Context, nvrID, tenantID uuid.UUID, f
 
 // --- Credentials ---
 
+// Credential AAD formats. Stored credentials record theirs, so raising the
+// write version never strands older rows.
+const (
+	CredentialAADv1 = 1 // "{tenant}:{nvr}:nvr_credential_v1"
+	CredentialAADv2 = 2 // "ts-vms/nvr_credential/v2/{tenant}/{nvr}"
+
+	DefaultCredentialAADVersion = CredentialAADv1
+)
+
+// credentialAAD builds the AAD binding an NVR credential to its tenant and NVR
+// in the given format; 0 is the pre-versioning v1.
+func credentialAAD(version int, tenantID, nvrID uuid.UUID) ([]byte, error) {
+	switch version {
+	case 0, CredentialAADv1:
+		return []byte(fmt.Sprintf("%s:%s:nvr_credential_v1", tenantID.String(), nvrID.String())), nil
+	case CredentialAADv2:
+		return []byte(fmt.Sprintf("ts-vms/nvr_credential/v2/%s/%s", tenantID.String(), nvrID.String())), nil
+	}
+	return nil, fmt.Errorf("unknown nvr credential aad version %d", version)
+}
+
+func (s *Service) credentialAADVersion() int {
+	if s.CredentialAADVersion > 0 {
+		return s.CredentialAADVersion
+	}
+	return DefaultCredentialAADVersion
+}
+
 func (s *Service) SetCredentials(ctx context.Context, nvrID, tenantID uuid.UUID, username, password string) error {
 	// 1. Prepare Payload
 	payload := map[string]string{
@@ -175,29 +354,47 @@ func (s *Service) SetCredentials(ctx context.Context, nvrID, tenantID uuid.UUID,
 	}
 	payloadBytes, _ := json.Marshal(payload)
 
-	// 2. Generate DEK
-	dek, err := crypto.GenerateDEK()
+	cred, err := s.sealCredential(tenantID, nvrID, payloadBytes, s.credentialAADVersion())
 	if err != nil {
 		return err
 	}
 
-	// 3. AAD Binding
-	aad := []byte(fmt.Sprintf("%s:%s:nvr_credential_v1", tenantID.String(), nvrID.String()))
+	if err := s.repo.UpsertCredential(ctx, cred); err != nil {
+		return err
+	}
 
-	// 4. Encrypt Data with DEK
+	s.audit(ctx, "nvr.credential.write", tenantID, nvrID.String(), "success", nil)
+	return nil
+}
+
+// sealCredential encrypts payloadBytes under a fresh DEK bound to the AAD of
+// the given version.
+func (s *Service) sealCredential(tenantID, nvrID uuid.UUID, payloadBytes []byte, version int) (*data.NVRCredential, error) {
+	// 1. Generate DEK
+	dek, err := crypto.GenerateDEK()
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. AAD Binding
+	aad, err := credentialAAD(version, tenantID, nvrID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Encrypt Data with DEK
 	dataNonce, dataCipher, dataTag, err := crypto.EncryptGCM(dek, payloadBytes, aad)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 5. Wrap DEK with Master Key
+	// 4. Wrap DEK with Master Key
 	kid, dekNonce, dekCipher, dekTag, err := s.keyring.WrapDEK(dek, aad)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 6. Store
-	cred := &data.NVRCredential{
+	return &data.NVRCredential{
 		TenantID:       tenantID,
 		NVRID:          nvrID,
 		MasterKID:      kid,
@@ -207,14 +404,28 @@ func (s *Service) SetCredentials(ctx context.Context, nvrID, tenantID uuid.UUID,
 		DataNonce:      dataNonce,
 		DataCiphertext: dataCipher,
 		DataTag:        dataTag,
+		AADVersion:     version,
+	}, nil
+}
+
+// openCredential decrypts a stored credential using the AAD of its recorded
+// version.
+func (s *Service) openCredential(cred *data.NVRCredential) ([]byte, error) {
+	// 1. AAD Reconstruct
+	aad, err := credentialAAD(cred.AADVersion, cred.TenantID, cred.NVRID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.UpsertCredential(ctx, cred); err != nil {
-		return err
+	// 2. Unwrap DEK
+	dek, err := s.keyring.UnwrapDEK(cred.MasterKID, cred.DekNonce, cred.DekCiphertext, cred.DekTag, aad)
+	if err != nil {
+		return nil, err
 	}
+	defer clear(dek)
 
-	s.audit(ctx, "nvr.credential.write", tenantID, nvrID.String(), "success", nil)
-	return nil
+	// 3. Decrypt Data
+	return crypto.DecryptGCM(dek, cred.DataNonce, cred.DataCiphertext, cred.DataTag, aad)
 }
 
 // GetCredentials returns Decrypted credentials! Caller must ensure permission.
@@ -228,18 +439,7 @@ func (s *Service) GetCredentials(ctx context.Context, nvrID, tenantID uuid.UUID)
 		return "", "", errors.New("access denied")
 	}
 
-	// 1. AAD Reconstruct
-	aad := []byte(fmt.Sprintf("%s:%s:nvr_credential_v1", tenantID.String(), nvrID.String()))
-
-	// 2. Unwrap DEK
-	dek, err := s.keyring.UnwrapDEK(cred.MasterKID, cred.DekNonce, cred.DekCiphertext, cred.DekTag, aad)
-	if err != nil {
-		s.audit(ctx, "nvr.credential.read", tenantID, nvrID.String(), "fail", nil)
-		return "", "", errors.New("decryption failed")
-	}
-
-	// 3. Decrypt Data
-	payloadBytes, err := crypto.DecryptGCM(dek, cred.DataNonce, cred.DataCiphertext, cred.DataTag, aad)
+	payloadBytes, err := s.openCredential(cred)
 	if err != nil {
 		s.audit(ctx, "nvr.credential.read", tenantID, nvrID.String(), "failure", nil)
 		return "", "", errors.New("decryption failed")
@@ -255,6 +455,71 @@ func (s *Service) GetCredentials(ctx context.Context, nvrID, tenantID uuid.UUID)
 	return payload["username"], payload["password"], nil
 }
 
+// CredentialAADMigration summarises a MigrateCredentialAAD run.
+type CredentialAADMigration struct {
+	TargetVersion int `json:"target_version"`
+	Migrated      int `json:"migrated"`
+	Current       int `json:"current"` // Already at the target version
+	Failed        int `json:"failed"`  // Undecryptable; left as stored
+}
+
+// MigrateCredentialAAD re-encrypts the tenant's NVR credentials bound to any
+// other AAD version under CredentialAADVersion, with a fresh DEK. A credential
+// rewritten concurrently is left to that writer.
+func (s *Service) MigrateCredentialAAD(ctx context.Context, tenantID uuid.UUID) (*CredentialAADMigration, error) {
+	keys, err := s.repo.ListCredentialKeys(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CredentialAADMigration{TargetVersion: s.credentialAADVersion()}
+	for _, k := range keys {
+		if !k.HasCredential {
+			continue
+		}
+		if k.AADVersion == res.TargetVersion {
+			res.Current++
+			continue
+		}
+		cred, err := s.repo.GetCredential(ctx, k.NVRID)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				continue // Deleted meanwhile
+			}
+			return nil, err
+		}
+		if cred.TenantID != tenantID {
+			continue
+		}
+		payloadBytes, err := s.openCredential(cred)
+		if err != nil {
+			res.Failed++
+			continue
+		}
+		rebound, err := s.sealCredential(tenantID, k.NVRID, payloadBytes, res.TargetVersion)
+		clear(payloadBytes)
+		if err != nil {
+			return nil, err
+		}
+		from := cred.AADVersion
+		if from == 0 {
+			from = CredentialAADv1
+		}
+		if err := s.repo.RebindCredential(ctx, rebound, from); err != nil {
+			if errors.Is(err, data.ErrOptimisticLock) {
+				continue
+			}
+			return nil, err
+		}
+		res.Migrated++
+	}
+
+	s.audit(ctx, "nvr.credential.aad_migrate", tenantID, "", "success", map[string]any{
+		"target_version": res.TargetVersion, "migrated": res.Migrated, "failed": res.Failed,
+	})
+	return res, nil
+}
+
 func (s *Service) DeleteCredentials(ctx context.Context, nvrID, tenantID uuid.UUID) error {
 	if err := s.repo.DeleteCredential(ctx, nvrID); err != nil {
 		return err
@@ -263,6 +528,56 @@ func (s *Service) DeleteCredentials(ctx context.Context, nvrID, tenantID uuid.UU
 	return nil
 }
 
+// Credential health states.
+const (
+	CredentialOK            = "ok"
+	CredentialUndecryptable = "undecryptable" // DEK no longer unwraps (e.g. retired master key)
+	CredentialMissing       = "missing"
+)
+
+type CredentialHealth struct {
+	NVRID     uuid.UUID `json:"nvr_id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	MasterKID string    `json:"master_kid,omitempty"`
+}
+
+// CredentialHealth reports, per NVR of the tenant, whether its credential DEK
+// still unwraps. The DEK is discarded and the payload is never decrypted.
+func (s *Service) CredentialHealth(ctx context.Context, tenantID uuid.UUID) ([]CredentialHealth, error) {
+	keys, err := s.repo.ListCredentialKeys(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]CredentialHealth, 0, len(keys))
+	bad := 0
+	for _, k := range keys {
+		h := CredentialHealth{NVRID: k.NVRID, Name: k.Name, Status: CredentialMissing}
+		if k.HasCredential {
+			h.MasterKID = k.MasterKID
+			aad, err := credentialAAD(k.AADVersion, k.TenantID, k.NVRID)
+			var dek []byte
+			if err == nil {
+				dek, err = s.keyring.UnwrapDEK(k.MasterKID, k.DekNonce, k.DekCiphertext, k.DekTag, aad)
+			}
+			if err != nil {
+				h.Status = CredentialUndecryptable
+				bad++
+			} else {
+				clear(dek)
+				h.Status = CredentialOK
+			}
+		}
+		out = append(out, h)
+	}
+
+	s.audit(ctx, "nvr.credential.health", tenantID, "", "success", map[string]any{
+		"nvrs": len(keys), "undecryptable": bad,
+	})
+	return out, nil
+}
+
 // --- Helpers ---
 
 func (s *Service) audit(ctx context.Context, action string, tenantID uuid.UUID, targetID string, result string, meta map[string]any) {
@@ -277,17 +592,32 @@ func (s *Service) audit(ctx context.Context, action string, tenantID uuid.UUID,
 	}
 
 	s.auditor.WriteEvent(ctx, audit.AuditEvent{
-		EventID:    uuid.New(),
-		TenantID:   tenantID,
-		Action:     action,
-		TargetType: "nvr",
-		TargetID:   targetID,
-		Result:     result,
-		Metadata:   metaBytes,
-		CreatedAt:  time.Now(),
+		EventID:     uuid.New(),
+		TenantID:    tenantID,
+		ActorUserID: actorFromContext(ctx),
+		Action:      action,
+		TargetType:  "nvr",
+		TargetID:    targetID,
+		Result:      result,
+		Metadata:    metaBytes,
+		CreatedAt:   time.Now(),
 	})
 }
 
+// actorFromContext returns the requesting user, or the system actor for
+// background jobs running under middleware.SystemContext.
+func actorFromContext(ctx context.Context) *uuid.UUID {
+	ac, ok := middleware.GetAuthContext(ctx)
+	if !ok {
+		return nil
+	}
+	id, err := uuid.Parse(ac.UserID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
 // --- Adapter Integration (Phase 2.7) ---
 
 func (s *Service) getAdapterClient(ctx context.Context, nvrID uuid.UUID) (adapters.Adapter, adapters.NvrTarget, adapters.NvrCredential, error) {
@@ -322,6 +652,9 @@ func (s *Service) getAdapterClient(ctx context.Context, nvrID uuid.UUID) (adapte
 		Port:     nvr.Port,
 		Vendor:   nvr.Vendor,
 	}
+	if s.AdapterClients != nil {
+		target.HTTPClient = s.AdapterClients.Get(nvr.ID)
+	}
 	cred := adapters.NvrCredential{
 		Username: user,
 		Password: pass,
@@ -335,6 +668,53 @@ func (s *Service) getAdapterClient(ctx context.Context, nvrID uuid.UUID) (adapte
 	return adapter, target, cred, nil
 }
 
+// evictAdapterClient drops the pooled client of an NVR whose address or
+// vendor may have changed.
+func (s *Service) evictAdapterClient(nvrID uuid.UUID) {
+	if s.AdapterClients != nil {
+		s.AdapterClients.Evict(nvrID)
+	}
+}
+
+// ChannelSnapshot returns a still for a camera streamed through an NVR
+// channel (link recording_mode "nvr"): the adapter's native channel snapshot,
+// else the ONVIF snapshot URI in the channel metadata. routed is false, with
+// no error, when the camera should be captured from its own RTSP stream:
+// it is not NVR-routed, its NVR's adapter cannot be built, or the NVR offers
+// neither snapshot source.
+func (s *Service) ChannelSnapshot(ctx context.Context, cameraID uuid.UUID) (frame []byte, routed bool, err error) {
+	link, err := s.repo.GetLinkByCameraID(ctx, cameraID)
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if link.RecordingMode != "nvr" || !link.IsEnabled || link.NVRChannelRef == nil {
+		return nil, false, nil
+	}
+
+	adapter, target, cred, err := s.getAdapterClient(ctx, link.NVRID)
+	if err != nil {
+		return nil, false, nil
+	}
+	if f, ok := adapter.(adapters.SnapshotFetcher); ok {
+		frame, err = f.FetchSnapshot(ctx, target, cred, *link.NVRChannelRef)
+		return frame, true, err
+	}
+	ch, err := s.repo.GetChannelByRef(ctx, link.NVRID, *link.NVRChannelRef)
+	if err != nil {
+		return nil, false, err
+	}
+	if ch != nil {
+		if uri, _ := ch.Metadata["snapshot_uri"].(string); uri != "" {
+			frame, err = adapters.FetchSnapshotURL(ctx, uri, cred)
+			return frame, true, err
+		}
+	}
+	return nil, false, nil
+}
+
 func (s *Service) GetAdapterDeviceInfo(ctx context.Context, nvrID, tenantID uuid.UUID) (adapters.NvrDeviceInfo, error) {
 	adapter, target, cred, err := s.getAdapterClient(ctx, nvrID)
 	if err != nil {
@@ -1,11 +1,28 @@
 package nvr
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/audit"
+	"github.com/technosupport/ts-vms/internal/crypto"
 	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/maintenance"
+	"github.com/technosupport/ts-vms/internal/middleware"
+	"github.com/technosupport/ts-vms/internal/nvr/adapters"
+	_ "github.com/technosupport/ts-vms/internal/nvr/adapters/hikvision"
 )
 
 // Mock Repo
@@ -14,6 +31,12 @@ type mockRepo struct {
 	links    map[uuid.UUID]*data.NVRLink
 	creds    map[uuid.UUID]*data.NVRCredential
 	channels map[uuid.UUID]*data.NVRChannel
+
+	defaultRecordingMode string // tenant default; "" behaves like an unset tenant
+	channelHealth        []*data.NVRChannelHealth
+
+	mu             sync.Mutex
+	deletedCameras map[uuid.UUID]bool // soft-deleted camera IDs
 }
 
 func (m *mockRepo) Create(ctx context.Context, nvr *data.NVR) error { m.nvrs[nvr.ID] = nvr; return nil }
@@ -29,9 +52,17 @@ func (m *mockRepo) List(ctx context.Context, tid uuid.UUID, f data.NVRFilter, l,
 	return nil, 0, nil
 }
 func (m *mockRepo) ListAllNVRs(ctx context.Context) ([]*data.NVR, error) { return nil, nil }
-func (m *mockRepo) UpsertLink(ctx context.Context, l *data.NVRLink) error {
+func (m *mockRepo) UpsertLink(ctx context.Context, l *data.NVRLink) (bool, error) {
+	if l.NVRChannelRef != nil {
+		for cid, other := range m.links {
+			if cid != l.CameraID && other.NVRID == l.NVRID && other.NVRChannelRef != nil && *other.NVRChannelRef == *l.NVRChannelRef {
+				return false, data.ErrChannelLinked
+			}
+		}
+	}
+	_, existed := m.links[l.CameraID]
 	m.links[l.CameraID] = l
-	return nil
+	return !existed, nil
 }
 func (m *mockRepo) GetLinkByCameraID(ctx context.Context, cid uuid.UUID) (*data.NVRLink, error) {
 	if l, ok := m.links[cid]; ok {
@@ -42,10 +73,20 @@ func (m *mockRepo) GetLinkByCameraID(ctx context.Context, cid uuid.UUID) (*data.
 func (m *mockRepo) ListLinks(ctx context.Context, nid uuid.UUID, l, o int) ([]*data.NVRLink, error) {
 	return nil, nil
 }
+func (m *mockRepo) ListLinksWithCameras(ctx context.Context, nid uuid.UUID, order string, l, o int) ([]*data.NVRLinkWithCamera, int, error) {
+	return nil, 0, nil
+}
 func (m *mockRepo) UnlinkCamera(ctx context.Context, cid uuid.UUID) error {
 	delete(m.links, cid)
 	return nil
 }
+func (m *mockRepo) GetDefaultRecordingMode(ctx context.Context, tid uuid.UUID) (string, error) {
+	return m.defaultRecordingMode, nil
+}
+func (m *mockRepo) SetDefaultRecordingMode(ctx context.Context, tid uuid.UUID, mode string) error {
+	m.defaultRecordingMode = mode
+	return nil
+}
 func (m *mockRepo) UpsertCredential(ctx context.Context, c *data.NVRCredential) error {
 	m.creds[c.NVRID] = c
 	return nil
@@ -60,6 +101,30 @@ func (m *mockRepo) DeleteCredential(ctx context.Context, nid uuid.UUID) error {
 	delete(m.creds, nid)
 	return nil
 }
+func (m *mockRepo) RebindCredential(ctx context.Context, c *data.NVRCredential, fromVersion int) error {
+	cur, ok := m.creds[c.NVRID]
+	if !ok || max(cur.AADVersion, 1) != fromVersion {
+		return data.ErrOptimisticLock
+	}
+	m.creds[c.NVRID] = c
+	return nil
+}
+func (m *mockRepo) ListCredentialKeys(ctx context.Context, tid uuid.UUID) ([]*data.NVRCredentialKey, error) {
+	var keys []*data.NVRCredentialKey
+	for _, n := range m.nvrs {
+		if n.TenantID != tid {
+			continue
+		}
+		k := &data.NVRCredentialKey{NVRID: n.ID, TenantID: n.TenantID, Name: n.Name}
+		if c, ok := m.creds[n.ID]; ok {
+			k.HasCredential = true
+			k.AADVersion = max(c.AADVersion, 1)
+			k.MasterKID, k.DekNonce, k.DekCiphertext, k.DekTag = c.MasterKID, c.DekNonce, c.DekCiphertext, c.DekTag
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
 
 // Discovery Stubs
 func (m *mockRepo) UpsertChannel(ctx context.Context, ch *data.NVRChannel) error {
@@ -88,6 +153,11 @@ func (m *mockRepo) GetChannelByRef(ctx context.Context, nvrID uuid.UUID, ref str
 	return nil, nil
 }
 func (m *mockRepo) UpdateChannelStatus(ctx context.Context, id uuid.UUID, validationStatus string, errCode *string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.channels[id]; ok {
+		c.ValidationStatus, c.LastErrorCode = validationStatus, errCode
+	}
 	return nil
 }
 
@@ -95,6 +165,17 @@ func (m *mockRepo) UpdateChannelStatus(ctx context.Context, id uuid.UUID, valida
 func (m *mockRepo) BulkEnableChannels(ctx context.Context, ids []uuid.UUID, enable bool) error {
 	return nil
 }
+func (m *mockRepo) BulkRenameChannels(ctx context.Context, nvrID uuid.UUID, names map[uuid.UUID]string) error {
+	for id := range names {
+		if c, ok := m.channels[id]; !ok || c.NVRID != nvrID {
+			return data.ErrRecordNotFound
+		}
+	}
+	for id, name := range names {
+		m.channels[id].Name = name
+	}
+	return nil
+}
 
 // Event Polling Mock
 func (m *mockRepo) UpsertEventPollState(ctx context.Context, state *data.NVREventPollState) error {
@@ -113,7 +194,13 @@ func (m *mockRepo) GetNVRHealthSummary(ctx context.Context, tid uuid.UUID, s []u
 	return nil, nil
 }
 func (m *mockRepo) ListChannelHealth(ctx context.Context, nid uuid.UUID, l, o int) ([]*data.NVRChannelHealth, error) {
-	return nil, nil
+	var res []*data.NVRChannelHealth
+	for _, h := range m.channelHealth {
+		if h.NVRID == nid {
+			res = append(res, h)
+		}
+	}
+	return res, nil
 }
 
 // Mock Keyring (Real implementation is fine if isolated, but here we mock to verify AAD passed)
@@ -172,16 +259,24 @@ func TestValidation(t *testing.T) {
 	err := svc.CreateNVR(context.Background(), &data.NVR{
 		Name: "test", IPAddress: "1.2.3.4", Vendor: "bad",
 	})
-	if err == nil {
-		t.Error("Expected error for invalid vendor")
+	if !errors.Is(err, ErrInvalidVendor) {
+		t.Errorf("Expected ErrInvalidVendor, got %v", err)
 	}
 
 	// Invalid IP
 	err = svc.CreateNVR(context.Background(), &data.NVR{
 		Name: "test", IPAddress: "bad-ip", Vendor: "hikvision",
 	})
-	if err == nil {
-		t.Error("Expected error for invalid IP")
+	if !errors.Is(err, ErrInvalidNVRIP) {
+		t.Errorf("Expected ErrInvalidNVRIP, got %v", err)
+	}
+
+	// Updates are held to the same rules
+	err = svc.UpdateNVR(context.Background(), &data.NVR{
+		Name: "", IPAddress: "1.2.3.4", Vendor: "hikvision", HealthCheckIntervalSeconds: DefaultHealthCheckIntervalSeconds,
+	})
+	if !errors.Is(err, ErrInvalidNVRName) {
+		t.Errorf("Expected ErrInvalidNVRName, got %v", err)
 	}
 }
 
@@ -226,12 +321,12 @@ func TestDiscoveryLogic(t *testing.T) {
 	}
 
 	// Test Provision Cameras
-	count, err := svc.ProvisionCameras(context.Background(), nid, tid, []uuid.UUID{chID})
+	res, err := svc.ProvisionCameras(context.Background(), nid, tid, []uuid.UUID{chID}, "", false)
 	if err != nil {
 		t.Fatalf("ProvisionCameras failed: %v", err)
 	}
-	if count != 1 {
-		t.Errorf("Expected 1 camera provisioned, got %d", count)
+	if res.Created != 1 {
+		t.Errorf("Expected 1 camera provisioned, got %d", res.Created)
 	}
 
 	// Verify ProvisionState updated
@@ -248,8 +343,1136 @@ func (m *mockRepo) UpdateChannelProvisionState(ctx context.Context, id uuid.UUID
 	return nil
 }
 
-type mockCamCreator struct{}
+func (m *mockRepo) ReleaseOrphanedChannels(ctx context.Context) (map[uuid.UUID]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	released := make(map[uuid.UUID]int)
+	for _, ch := range m.channels {
+		if ch.ProvisionState != "created" {
+			continue
+		}
+		live := false
+		for camID, l := range m.links {
+			if l.NVRID == ch.NVRID && l.NVRChannelRef != nil && *l.NVRChannelRef == ch.ChannelRef && !m.deletedCameras[camID] {
+				live = true
+				break
+			}
+		}
+		if !live {
+			ch.ProvisionState = "not_created"
+			released[ch.TenantID]++
+		}
+	}
+	return released, nil
+}
+
+func (m *mockRepo) channelState(id uuid.UUID) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.channels[id].ProvisionState
+}
 
-func (m *mockCamCreator) CreateCamera(ctx context.Context, c *data.Camera) error          { return nil }
+func TestOrphanReconciler_FreesChannelAfterCameraDelete(t *testing.T) {
+	repo := &mockRepo{
+		nvrs:           make(map[uuid.UUID]*data.NVR),
+		links:          make(map[uuid.UUID]*data.NVRLink),
+		creds:          make(map[uuid.UUID]*data.NVRCredential),
+		channels:       make(map[uuid.UUID]*data.NVRChannel),
+		deletedCameras: make(map[uuid.UUID]bool),
+	}
+	svc := NewService(repo, &mockKeyring{}, nil, &mockCamCreator{})
+
+	tid, nid, chID := uuid.New(), uuid.New(), uuid.New()
+	repo.nvrs[nid] = &data.NVR{ID: nid, TenantID: tid, Name: "TestNVR", IPAddress: "1.2.3.4", Vendor: "hikvision"}
+	repo.channels[chID] = &data.NVRChannel{ID: chID, TenantID: tid, NVRID: nid, ChannelRef: "ch1", ProvisionState: "not_created"}
+
+	if res, err := svc.ProvisionCameras(context.Background(), nid, tid, []uuid.UUID{chID}, "", false); err != nil || res.Created != 1 {
+		t.Fatalf("ProvisionCameras: res=%+v err=%v", res, err)
+	}
+
+	// Camera still live: nothing to release
+	if n, _ := svc.ReconcileOrphanedChannels(context.Background()); n != 0 {
+		t.Fatalf("Expected no release while camera exists, got %d", n)
+	}
+
+	// Soft-delete the provisioned camera
+	repo.mu.Lock()
+	for camID := range repo.links {
+		repo.deletedCameras[camID] = true
+	}
+	repo.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.StartOrphanChannelReconciler(ctx, 10*time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for repo.channelState(chID) != "not_created" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Channel not released, state=%s", repo.channelState(chID))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type mockCamCreator struct {
+	created  int
+	existing []*data.Camera // Matched by FindByIP
+}
+
+func (m *mockCamCreator) CreateCamera(ctx context.Context, c *data.Camera) error {
+	m.created++
+	return nil
+}
+func (m *mockCamCreator) FindByIP(ctx context.Context, tenantID uuid.UUID, ip net.IP) (uuid.UUID, error) {
+	for _, c := range m.existing {
+		if c.TenantID == tenantID && c.IPAddress.Equal(ip) {
+			return c.ID, nil
+		}
+	}
+	return uuid.Nil, data.ErrRecordNotFound
+}
 func (m *mockCamCreator) EnableCamera(ctx context.Context, id, tenantID uuid.UUID) error  { return nil }
 func (m *mockCamCreator) DisableCamera(ctx context.Context, id, tenantID uuid.UUID) error { return nil }
+
+var stubAdapterOnce sync.Once
+
+// newValidationFixture seeds an NVR with n channels; the first has no RTSP URL.
+func newValidationFixture(t *testing.T, n int) (*Service, uuid.UUID, uuid.UUID, []uuid.UUID) {
+	t.Helper()
+	// Channel validation only needs credentials from the adapter lookup
+	stubAdapterOnce.Do(func() {
+		adapters.Register("rtsp_fallback", func(adapters.NvrTarget, adapters.NvrCredential) (adapters.Adapter, error) {
+			return nil, nil
+		})
+	})
+
+	repo := &mockRepo{
+		nvrs:     make(map[uuid.UUID]*data.NVR),
+		links:    make(map[uuid.UUID]*data.NVRLink),
+		creds:    make(map[uuid.UUID]*data.NVRCredential),
+		channels: make(map[uuid.UUID]*data.NVRChannel),
+	}
+	tenantID, nvrID := uuid.New(), uuid.New()
+	repo.nvrs[nvrID] = &data.NVR{ID: nvrID, TenantID: tenantID, Vendor: "generic", IPAddress: "10.0.0.1"}
+
+	var ids []uuid.UUID
+	for i := 0; i < n; i++ {
+		ch := &data.NVRChannel{ID: uuid.New(), NVRID: nvrID, TenantID: tenantID, RTSPMain: "rtsp://10.0.0.1/ch"}
+		if i == 0 {
+			ch.RTSPMain = ""
+		}
+		repo.channels[ch.ID] = ch
+		ids = append(ids, ch.ID)
+	}
+	return NewService(repo, nil, nil, nil), tenantID, nvrID, ids
+}
+
+func TestRunChannelValidation_SyncReturnsResults(t *testing.T) {
+	svc, tenantID, nvrID, ids := newValidationFixture(t, 3)
+
+	out, err := svc.RunChannelValidation(context.Background(), nvrID, tenantID, ids, true)
+	if err != nil {
+		t.Fatalf("RunChannelValidation failed: %v", err)
+	}
+	if out.Mode != ValidationModeSync {
+		t.Fatalf("Expected sync mode, got %s", out.Mode)
+	}
+	if len(out.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(out.Results))
+	}
+	if out.Results[ids[0]] != "error" {
+		t.Errorf("Expected error for channel without RTSP, got %q", out.Results[ids[0]])
+	}
+	if out.Results[ids[1]] != "ok" {
+		t.Errorf("Expected ok, got %q", out.Results[ids[1]])
+	}
+
+	// Existing clients read results as {channel_id: status}
+	body, _ := json.Marshal(out)
+	var decoded struct {
+		Results map[string]string `json:"results"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded.Results[ids[1].String()] != "ok" {
+		t.Errorf("Expected results keyed by channel id, got %s", body)
+	}
+}
+
+// rtspDescribeServer answers OPTIONS and DESCRIBE (with sdp) on one connection.
+func rtspDescribeServer(t *testing.T, sdp string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			reqLine, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cseq := ""
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line = strings.TrimSpace(line); line == "" {
+					break
+				}
+				if v, ok := strings.CutPrefix(line, "CSeq: "); ok {
+					cseq = v
+				}
+			}
+			if strings.HasPrefix(reqLine, "DESCRIBE") {
+				fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nContent-Type: application/sdp\r\nContent-Length: %d\r\n\r\n%s", cseq, len(sdp), sdp)
+				continue
+			}
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\n\r\n", cseq)
+		}
+	}()
+	return "rtsp://" + ln.Addr().String() + "/Streaming/Channels/101"
+}
+
+func TestRunChannelValidation_DescribeProbeComparesStoredProfile(t *testing.T) {
+	svc, tenantID, nvrID, ids := newValidationFixture(t, 3)
+	svc.DescribeProbe = true
+	repo := svc.repo.(*mockRepo)
+	profile := map[string]any{"codec": "H264", "width": "1920", "height": "1080"}
+
+	match, mismatch := repo.channels[ids[1]], repo.channels[ids[2]]
+	match.Metadata, mismatch.Metadata = profile, profile
+	match.RTSPMain = rtspDescribeServer(t, "v=0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=framesize:96 1920-1080\r\n")
+	mismatch.RTSPMain = rtspDescribeServer(t, "v=0\r\nm=video 0 RTP/AVP 98\r\na=rtpmap:98 H265/90000\r\na=framesize:98 1920-1080\r\n")
+
+	out, err := svc.RunChannelValidation(context.Background(), nvrID, tenantID, ids[1:], true)
+	if err != nil {
+		t.Fatalf("RunChannelValidation failed: %v", err)
+	}
+	if out.Results[ids[1]] != "ok" {
+		t.Errorf("Expected ok for matching stream, got %q", out.Results[ids[1]])
+	}
+	if out.Results[ids[2]] != "error" || mismatch.LastErrorCode == nil || *mismatch.LastErrorCode != "profile_mismatch: codec" {
+		t.Errorf("Expected codec mismatch, got %q (%v)", out.Results[ids[2]], mismatch.LastErrorCode)
+	}
+}
+
+func TestRunChannelValidation_DiscoveredHikvisionProfile(t *testing.T) {
+	isapi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ISAPI/ContentMgmt/InputProxy/channels":
+			fmt.Fprint(w, `<IPChannelList><IPChannel><id>1</id><channelName>Gate</channelName><enabled>true</enabled></IPChannel><IPChannel><id>2</id><channelName>Yard</channelName><enabled>true</enabled></IPChannel></IPChannelList>`)
+		case "/ISAPI/Streaming/channels":
+			fmt.Fprint(w, `<StreamingChannelList>
+				<StreamingChannel><id>101</id><Video><videoCodecType>H.264</videoCodecType><videoResolutionWidth>1920</videoResolutionWidth><videoResolutionHeight>1080</videoResolutionHeight></Video></StreamingChannel>
+				<StreamingChannel><id>102</id><Video><videoCodecType>H.264</videoCodecType><videoResolutionWidth>640</videoResolutionWidth><videoResolutionHeight>360</videoResolutionHeight></Video></StreamingChannel>
+				<StreamingChannel><id>201</id><Video><videoCodecType>H.265</videoCodecType><videoResolutionWidth>2560</videoResolutionWidth><videoResolutionHeight>1440</videoResolutionHeight></Video></StreamingChannel>
+			</StreamingChannelList>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer isapi.Close()
+	host, portStr, _ := net.SplitHostPort(strings.TrimPrefix(isapi.URL, "http://"))
+	port, _ := strconv.Atoi(portStr)
+
+	tid, nid := uuid.New(), uuid.New()
+	repo := &mockRepo{
+		nvrs:     map[uuid.UUID]*data.NVR{nid: {ID: nid, TenantID: tid, Vendor: "hikvision", IPAddress: host, Port: port}},
+		channels: make(map[uuid.UUID]*data.NVRChannel),
+		creds:    map[uuid.UUID]*data.NVRCredential{},
+	}
+	svc := NewService(repo, &mockKeyring{}, nil, nil)
+	svc.DescribeProbe = true
+
+	if res, err := svc.DiscoverChannels(context.Background(), nid, tid); err != nil || res.Count != 2 {
+		t.Fatalf("DiscoverChannels: res=%+v err=%v", res, err)
+	}
+	byRef := map[string]*data.NVRChannel{}
+	for _, ch := range repo.channels {
+		byRef[ch.ChannelRef] = ch
+	}
+	if byRef["1"].Metadata["codec"] != "H.264" || byRef["1"].Metadata["width"] != "1920" {
+		t.Fatalf("Expected main stream profile in metadata, got %v", byRef["1"].Metadata)
+	}
+
+	// Both channels actually stream 1080p H.264; the NVR's RTSP port is
+	// stood in for by DESCRIBE servers.
+	sdp := "v=0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=framesize:96 1920-1080\r\n"
+	byRef["1"].RTSPMain = rtspDescribeServer(t, sdp)
+	byRef["2"].RTSPMain = rtspDescribeServer(t, sdp)
+
+	out, err := svc.RunChannelValidation(context.Background(), nid, tid, []uuid.UUID{byRef["1"].ID, byRef["2"].ID}, true)
+	if err != nil {
+		t.Fatalf("RunChannelValidation failed: %v", err)
+	}
+	if out.Results[byRef["1"].ID] != "ok" {
+		t.Errorf("Expected ok for channel matching its ISAPI profile, got %q (%v)", out.Results[byRef["1"].ID], byRef["1"].LastErrorCode)
+	}
+	if code := byRef["2"].LastErrorCode; out.Results[byRef["2"].ID] != "error" || code == nil || *code != "profile_mismatch: codec,resolution" {
+		t.Errorf("Expected codec and resolution mismatch, got %q (%v)", out.Results[byRef["2"].ID], code)
+	}
+}
+
+func TestRunChannelValidation_AllChannelsWhenEmpty(t *testing.T) {
+	svc, tenantID, nvrID, _ := newValidationFixture(t, 4)
+
+	out, err := svc.RunChannelValidation(context.Background(), nvrID, tenantID, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Count != 4 || len(out.Results) != 4 {
+		t.Errorf("Expected all 4 channels validated, got count=%d results=%d", out.Count, len(out.Results))
+	}
+}
+
+// waitValidated blocks until the background run has persisted a validation
+// status for every channel.
+func waitValidated(t *testing.T, svc *Service, ids []uuid.UUID) {
+	t.Helper()
+	repo := svc.repo.(*mockRepo)
+	deadline := time.Now().Add(2 * time.Second)
+	for _, id := range ids {
+		for {
+			repo.mu.Lock()
+			status := repo.channels[id].ValidationStatus
+			repo.mu.Unlock()
+			if status != "" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Channel %s never validated", id)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+func TestRunChannelValidation_AsyncByDefault(t *testing.T) {
+	svc, tenantID, nvrID, ids := newValidationFixture(t, 2)
+
+	out, err := svc.RunChannelValidation(context.Background(), nvrID, tenantID, ids, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Mode != ValidationModeAsync || out.Reason != "" {
+		t.Errorf("Expected async without reason, got mode=%s reason=%s", out.Mode, out.Reason)
+	}
+	if out.Results != nil {
+		t.Error("Async run must not return inline results")
+	}
+	waitValidated(t, svc, ids)
+}
+
+func TestRunChannelValidation_CapForcesAsync(t *testing.T) {
+	svc, tenantID, nvrID, ids := newValidationFixture(t, SyncValidateMaxChannels+1)
+
+	out, err := svc.RunChannelValidation(context.Background(), nvrID, tenantID, ids, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Mode != ValidationModeAsync || out.Reason != ValidationReasonSyncCap {
+		t.Errorf("Expected async with sync_cap_exceeded, got mode=%s reason=%s", out.Mode, out.Reason)
+	}
+	if out.Results != nil {
+		t.Error("Async run must not return inline results")
+	}
+	if out.Count != SyncValidateMaxChannels+1 {
+		t.Errorf("Expected count %d, got %d", SyncValidateMaxChannels+1, out.Count)
+	}
+	waitValidated(t, svc, ids)
+}
+
+func TestRunChannelValidation_ForeignTenant(t *testing.T) {
+	svc, _, nvrID, ids := newValidationFixture(t, 1)
+
+	if _, err := svc.RunChannelValidation(context.Background(), nvrID, uuid.New(), ids, true); err == nil {
+		t.Error("Expected access denied for foreign tenant")
+	}
+}
+
+func TestScheduleNVRs_HonorsPerNVRInterval(t *testing.T) {
+	m := NewMonitor(nil, nil)
+	fast := &data.NVR{ID: uuid.New(), HealthCheckIntervalSeconds: 30}
+	slow := &data.NVR{ID: uuid.New(), HealthCheckIntervalSeconds: 60}
+	nvrs := []*data.NVR{fast, slow}
+
+	counts := map[uuid.UUID]int{}
+	start := time.Now()
+	for tick := 0; tick < 60; tick++ { // 10 simulated minutes
+		m.scheduleNVRs(nvrs, start.Add(time.Duration(tick)*nvrSchedulerTick))
+		for len(m.nvrQueue) > 0 {
+			counts[(<-m.nvrQueue).ID]++
+		}
+	}
+
+	if counts[fast.ID] != 20 || counts[slow.ID] != 10 {
+		t.Errorf("Expected 20 checks at 30s and 10 at 60s, got %d and %d", counts[fast.ID], counts[slow.ID])
+	}
+}
+
+func TestScheduleNVRs_PausedDuringMaintenance(t *testing.T) {
+	m := NewMonitor(nil, nil)
+	m.Maintenance = maintenance.New()
+	n := &data.NVR{ID: uuid.New(), TenantID: uuid.New(), HealthCheckIntervalSeconds: 30}
+	start := time.Now()
+
+	m.Maintenance.SetGlobal(true)
+	for tick := 0; tick < 6; tick++ {
+		m.scheduleNVRs([]*data.NVR{n}, start.Add(time.Duration(tick)*nvrSchedulerTick))
+	}
+	if len(m.nvrQueue) != 0 {
+		t.Fatalf("Expected no checks during maintenance, got %d", len(m.nvrQueue))
+	}
+
+	m.Maintenance.SetGlobal(false)
+	m.scheduleNVRs([]*data.NVR{n}, start.Add(6*nvrSchedulerTick))
+	if len(m.nvrQueue) != 1 {
+		t.Errorf("Expected the NVR checked on the first tick after maintenance, got %d", len(m.nvrQueue))
+	}
+}
+
+func TestCreateNVR_HealthCheckInterval(t *testing.T) {
+	svc := NewService(&mockRepo{nvrs: make(map[uuid.UUID]*data.NVR)}, nil, nil, nil)
+
+	n := &data.NVR{Name: "nvr", IPAddress: "10.0.0.1", Vendor: "hikvision"}
+	if err := svc.CreateNVR(context.Background(), n); err != nil {
+		t.Fatalf("CreateNVR failed: %v", err)
+	}
+	if n.HealthCheckIntervalSeconds != DefaultHealthCheckIntervalSeconds {
+		t.Errorf("Expected default interval %d, got %d", DefaultHealthCheckIntervalSeconds, n.HealthCheckIntervalSeconds)
+	}
+
+	err := svc.CreateNVR(context.Background(), &data.NVR{Name: "nvr", IPAddress: "10.0.0.2", Vendor: "hikvision", HealthCheckIntervalSeconds: 5})
+	if !errors.Is(err, ErrInvalidHealthCheckInterval) {
+		t.Errorf("Expected ErrInvalidHealthCheckInterval for interval below minimum, got %v", err)
+	}
+}
+
+func renameFixture() (*Service, *data.NVR, *data.NVRChannel, *data.NVRChannel) {
+	tenantID := uuid.New()
+	n := &data.NVR{ID: uuid.New(), TenantID: tenantID}
+	ch1 := &data.NVRChannel{ID: uuid.New(), NVRID: n.ID, Name: "Camera 01"}
+	ch2 := &data.NVRChannel{ID: uuid.New(), NVRID: n.ID, Name: "Camera 02"}
+	repo := &mockRepo{
+		nvrs:     map[uuid.UUID]*data.NVR{n.ID: n},
+		channels: map[uuid.UUID]*data.NVRChannel{ch1.ID: ch1, ch2.ID: ch2},
+	}
+	return NewService(repo, nil, nil, nil), n, ch1, ch2
+}
+
+func TestBulkRenameChannels_Map(t *testing.T) {
+	svc, n, ch1, ch2 := renameFixture()
+
+	err := svc.BulkRenameChannels(context.Background(), n.ID, n.TenantID, map[uuid.UUID]string{
+		ch1.ID: "  Lobby East ",
+		ch2.ID: "Lobby West",
+	})
+	if err != nil {
+		t.Fatalf("BulkRenameChannels failed: %v", err)
+	}
+	if ch1.Name != "Lobby East" || ch2.Name != "Lobby West" {
+		t.Errorf("Unexpected names %q, %q", ch1.Name, ch2.Name)
+	}
+
+	// Foreign tenant cannot rename
+	err = svc.BulkRenameChannels(context.Background(), n.ID, uuid.New(), map[uuid.UUID]string{ch1.ID: "x"})
+	if !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for foreign tenant, got %v", err)
+	}
+}
+
+func TestBulkRenameChannels_RejectsOverLongName(t *testing.T) {
+	svc, n, ch1, ch2 := renameFixture()
+
+	err := svc.BulkRenameChannels(context.Background(), n.ID, n.TenantID, map[uuid.UUID]string{
+		ch1.ID: "Valid",
+		ch2.ID: strings.Repeat("x", MaxChannelNameLen+1),
+	})
+	if !errors.Is(err, ErrInvalidChannelName) {
+		t.Fatalf("Expected ErrInvalidChannelName, got %v", err)
+	}
+	if ch1.Name != "Camera 01" || ch2.Name != "Camera 02" {
+		t.Error("No channel should be renamed when one name is invalid")
+	}
+
+	if err := svc.BulkRenameChannels(context.Background(), n.ID, n.TenantID, map[uuid.UUID]string{ch1.ID: "   "}); !errors.Is(err, ErrInvalidChannelName) {
+		t.Errorf("Expected blank name rejected, got %v", err)
+	}
+}
+
+func TestPrefixChannelNames_Idempotent(t *testing.T) {
+	svc, n, ch1, ch2 := renameFixture()
+	ids := []uuid.UUID{ch1.ID, ch2.ID}
+
+	for i := 0; i < 2; i++ {
+		if err := svc.PrefixChannelNames(context.Background(), n.ID, n.TenantID, ids, "Bldg A - "); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ch1.Name != "Bldg A - Camera 01" || ch2.Name != "Bldg A - Camera 02" {
+		t.Errorf("Unexpected names %q, %q", ch1.Name, ch2.Name)
+	}
+}
+
+func TestUpsertLink_CreateThenUpdate(t *testing.T) {
+	svc := NewService(&mockRepo{links: make(map[uuid.UUID]*data.NVRLink)}, nil, nil, nil)
+	tenantID, nvrID, camID := uuid.New(), uuid.New(), uuid.New()
+	ch1, ch2 := "1", "2"
+
+	created, err := svc.UpsertLink(context.Background(), &data.NVRLink{
+		TenantID: tenantID, NVRID: nvrID, CameraID: camID, NVRChannelRef: &ch1, RecordingMode: "vms",
+	})
+	if err != nil || !created {
+		t.Fatalf("Expected create, got created=%v err=%v", created, err)
+	}
+
+	created, err = svc.UpsertLink(context.Background(), &data.NVRLink{
+		TenantID: tenantID, NVRID: nvrID, CameraID: camID, NVRChannelRef: &ch2, RecordingMode: "nvr",
+	})
+	if err != nil || created {
+		t.Fatalf("Expected update, got created=%v err=%v", created, err)
+	}
+}
+
+func TestUpsertLink_ChannelLinkedToOtherCamera(t *testing.T) {
+	repo := &mockRepo{links: make(map[uuid.UUID]*data.NVRLink)}
+	svc := NewService(repo, nil, nil, nil)
+	tenantID, nvrID := uuid.New(), uuid.New()
+	first, second := uuid.New(), uuid.New()
+	ch := "101"
+
+	if _, err := svc.UpsertLink(context.Background(), &data.NVRLink{
+		TenantID: tenantID, NVRID: nvrID, CameraID: first, NVRChannelRef: &ch, RecordingMode: "vms",
+	}); err != nil {
+		t.Fatalf("First link failed: %v", err)
+	}
+
+	_, err := svc.UpsertLink(context.Background(), &data.NVRLink{
+		TenantID: tenantID, NVRID: nvrID, CameraID: second, NVRChannelRef: &ch, RecordingMode: "vms",
+	})
+	if !errors.Is(err, data.ErrChannelLinked) {
+		t.Fatalf("Expected ErrChannelLinked, got %v", err)
+	}
+	if _, ok := repo.links[second]; ok {
+		t.Error("Conflicting link must not be stored")
+	}
+	if got := repo.links[first]; got == nil || *got.NVRChannelRef != ch {
+		t.Errorf("Original link should be untouched, got %+v", got)
+	}
+}
+
+func TestRecordChannelProbe_DebouncesOffline(t *testing.T) {
+	m := NewMonitor(nil, &mockRepo{})
+	ch := &data.NVRChannel{ID: uuid.New(), NVRID: uuid.New(), TenantID: uuid.New()}
+	errCode := "timeout"
+	now := time.Now()
+
+	if h := m.recordChannelProbe(ch, "online", nil, now); h.Status != "online" || h.LastSuccessAt == nil {
+		t.Fatalf("Expected online with success time, got %+v", h)
+	}
+
+	// One failure: still online, but the probe is recorded.
+	now = now.Add(time.Minute)
+	h := m.recordChannelProbe(ch, "offline", &errCode, now)
+	if h.Status != "online" {
+		t.Errorf("Single failure should not flip status, got %s", h.Status)
+	}
+	if !h.LastCheckedAt.Equal(now) || h.ConsecutiveFailures != 1 || h.LastSuccessAt != nil {
+		t.Errorf("Failed probe not recorded: %+v", h)
+	}
+
+	// A success resets the streak.
+	m.recordChannelProbe(ch, "online", nil, now.Add(time.Minute))
+	if h := m.recordChannelProbe(ch, "offline", &errCode, now.Add(2*time.Minute)); h.Status != "online" {
+		t.Errorf("Streak should restart after success, got %s", h.Status)
+	}
+
+	// Second consecutive failure reaches the threshold.
+	h = m.recordChannelProbe(ch, "offline", &errCode, now.Add(3*time.Minute))
+	if h.Status != "offline" || h.ConsecutiveFailures != 2 {
+		t.Errorf("Expected offline after %d failures, got %+v", DefaultChannelOfflineAfterFailures, h)
+	}
+}
+
+func TestRecordChannelProbe_ConfigurableThreshold(t *testing.T) {
+	m := NewMonitor(nil, &mockRepo{})
+	m.ChannelOfflineAfterFailures = 3
+	ch := &data.NVRChannel{ID: uuid.New()}
+	m.recordChannelProbe(ch, "online", nil, time.Now())
+
+	var statuses []string
+	for i := 0; i < 3; i++ {
+		statuses = append(statuses, m.recordChannelProbe(ch, "offline", nil, time.Now()).Status)
+	}
+	if statuses[0] != "online" || statuses[1] != "online" || statuses[2] != "offline" {
+		t.Errorf("Expected online, online, offline; got %v", statuses)
+	}
+}
+
+func TestScheduleChannels_SeedsAndPrunesProbeState(t *testing.T) {
+	n := &data.NVR{ID: uuid.New(), TenantID: uuid.New()}
+	failing := &data.NVRChannel{ID: uuid.New(), NVRID: n.ID, TenantID: n.TenantID}
+	repo := &mockRepo{
+		channels: map[uuid.UUID]*data.NVRChannel{failing.ID: failing},
+		channelHealth: []*data.NVRChannelHealth{
+			{NVRID: n.ID, ChannelID: failing.ID, Status: "online", ConsecutiveFailures: 1},
+		},
+	}
+	m := NewMonitor(&Service{}, repo)
+	m.nvrStatusCache.Store(n.ID, "online")
+
+	// After a restart the stored streak still counts toward the threshold.
+	m.scheduleChannels(context.Background(), []*data.NVR{n})
+	if h := m.recordChannelProbe(failing, "offline", nil, time.Now()); h.Status != "offline" || h.ConsecutiveFailures != 2 {
+		t.Errorf("Expected the stored failure to carry over, got %+v", h)
+	}
+
+	// A channel no longer listed is forgotten.
+	delete(repo.channels, failing.ID)
+	m.scheduleChannels(context.Background(), []*data.NVR{n})
+	if _, ok := m.chanState[failing.ID]; ok {
+		t.Error("Expected state for the removed channel to be pruned")
+	}
+}
+
+func newProvisionFixture() (*Service, *mockRepo, *mockCamCreator, uuid.UUID, uuid.UUID, uuid.UUID) {
+	repo := &mockRepo{
+		nvrs:     make(map[uuid.UUID]*data.NVR),
+		links:    make(map[uuid.UUID]*data.NVRLink),
+		creds:    make(map[uuid.UUID]*data.NVRCredential),
+		channels: make(map[uuid.UUID]*data.NVRChannel),
+	}
+	cams := &mockCamCreator{}
+	svc := NewService(repo, &mockKeyring{}, nil, cams)
+	tid, nid, chID := uuid.New(), uuid.New(), uuid.New()
+	repo.nvrs[nid] = &data.NVR{ID: nid, TenantID: tid, Name: "TestNVR", IPAddress: "1.2.3.4", Vendor: "hikvision"}
+	repo.channels[chID] = &data.NVRChannel{ID: chID, TenantID: tid, NVRID: nid, ChannelRef: "ch1", ProvisionState: "not_created"}
+	return svc, repo, cams, tid, nid, chID
+}
+
+func TestProvisionCameras_RecordingMode(t *testing.T) {
+	cases := []struct {
+		name, requested, tenantDefault, want string
+	}{
+		{"explicit hybrid", RecordingModeHybrid, RecordingModeVMS, RecordingModeHybrid},
+		{"tenant default", "", RecordingModeNVR, RecordingModeNVR},
+		{"unset tenant falls back to vms", "", "", RecordingModeVMS},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, repo, _, tid, nid, chID := newProvisionFixture()
+			repo.defaultRecordingMode = tc.tenantDefault
+
+			if res, err := svc.ProvisionCameras(context.Background(), nid, tid, []uuid.UUID{chID}, tc.requested, false); err != nil || res.Created != 1 {
+				t.Fatalf("ProvisionCameras: res=%+v err=%v", res, err)
+			}
+			for _, l := range repo.links {
+				if l.RecordingMode != tc.want {
+					t.Errorf("Expected link recording mode %q, got %q", tc.want, l.RecordingMode)
+				}
+			}
+		})
+	}
+}
+
+func TestProvisionCameras_InvalidRecordingModeCreatesNothing(t *testing.T) {
+	svc, repo, cams, tid, nid, chID := newProvisionFixture()
+
+	_, err := svc.ProvisionCameras(context.Background(), nid, tid, []uuid.UUID{chID}, "cloud", false)
+	if !errors.Is(err, ErrInvalidRecordingMode) {
+		t.Fatalf("Expected ErrInvalidRecordingMode, got %v", err)
+	}
+	if cams.created != 0 || len(repo.links) != 0 {
+		t.Errorf("No camera or link may be created: cameras=%d links=%d", cams.created, len(repo.links))
+	}
+	if repo.channels[chID].ProvisionState != "not_created" {
+		t.Error("Channel provision state must be untouched")
+	}
+}
+
+func TestProvisionCameras_AdoptsExistingCameraByIP(t *testing.T) {
+	svc, repo, cams, tid, nid, chID := newProvisionFixture()
+	repo.channels[chID].Metadata = map[string]any{"raw_name": "Gate", "ip": "10.0.0.21"}
+	other := uuid.New()
+	repo.channels[other] = &data.NVRChannel{ID: other, TenantID: tid, NVRID: nid, ChannelRef: "ch2", ProvisionState: "not_created",
+		Metadata: map[string]any{"ip": "10.0.0.99"}}
+
+	existing := &data.Camera{ID: uuid.New(), TenantID: tid, IPAddress: net.ParseIP("10.0.0.21")}
+	cams.existing = []*data.Camera{existing}
+
+	res, err := svc.ProvisionCameras(context.Background(), nid, tid, []uuid.UUID{chID, other}, "", true)
+	if err != nil {
+		t.Fatalf("ProvisionCameras: %v", err)
+	}
+	if res.Adopted != 1 || res.Created != 1 {
+		t.Fatalf("Expected 1 adopted and 1 created, got %+v", res)
+	}
+	if cams.created != 1 {
+		t.Errorf("Only the unmatched channel may create a camera, created=%d", cams.created)
+	}
+	l, ok := repo.links[existing.ID]
+	if !ok || *l.NVRChannelRef != "ch1" {
+		t.Fatalf("Existing camera must be linked to ch1, link=%+v", l)
+	}
+	if repo.channels[chID].ProvisionState != "created" {
+		t.Error("Adopted channel must be marked provisioned")
+	}
+}
+
+func TestProvisionCameras_AdoptSkipsCameraLinkedElsewhere(t *testing.T) {
+	svc, repo, cams, tid, nid, chID := newProvisionFixture()
+	repo.channels[chID].Metadata = map[string]any{"ip": "10.0.0.21"}
+
+	existing := &data.Camera{ID: uuid.New(), TenantID: tid, IPAddress: net.ParseIP("10.0.0.21")}
+	cams.existing = []*data.Camera{existing}
+	repo.links[existing.ID] = &data.NVRLink{TenantID: tid, CameraID: existing.ID, NVRID: uuid.New()}
+
+	res, err := svc.ProvisionCameras(context.Background(), nid, tid, []uuid.UUID{chID}, "", true)
+	if err != nil {
+		t.Fatalf("ProvisionCameras: %v", err)
+	}
+	if res.Adopted != 0 || res.Created != 1 {
+		t.Errorf("Camera owned by another NVR must not be adopted, got %+v", res)
+	}
+}
+
+// retiredKeyring fails to unwrap DEKs wrapped under a retired master key.
+type retiredKeyring struct {
+	mockKeyring
+	retired string
+}
+
+func (k *retiredKeyring) UnwrapDEK(kid string, nonce, ciphertext, tag, aad []byte) ([]byte, error) {
+	if kid == k.retired {
+		return nil, errors.New("unknown master key")
+	}
+	return k.mockKeyring.UnwrapDEK(kid, nonce, ciphertext, tag, aad)
+}
+
+func TestCredentialHealth_FlagsUndecryptable(t *testing.T) {
+	tenantID := uuid.New()
+	healthy, broken, bare := uuid.New(), uuid.New(), uuid.New()
+	repo := &mockRepo{
+		nvrs: map[uuid.UUID]*data.NVR{
+			healthy:    {ID: healthy, TenantID: tenantID, Name: "healthy"},
+			broken:     {ID: broken, TenantID: tenantID, Name: "broken"},
+			bare:       {ID: bare, TenantID: tenantID, Name: "bare"},
+			uuid.New(): {ID: uuid.New(), TenantID: uuid.New(), Name: "other tenant"},
+		},
+		creds: map[uuid.UUID]*data.NVRCredential{
+			healthy: {NVRID: healthy, TenantID: tenantID, MasterKID: "master-2"},
+			broken:  {NVRID: broken, TenantID: tenantID, MasterKID: "master-1"},
+		},
+	}
+	keyring := &retiredKeyring{retired: "master-1"}
+	svc := NewService(repo, keyring, nil, nil)
+
+	results, err := svc.CredentialHealth(context.Background(), tenantID)
+	if err != nil {
+		t.Fatalf("CredentialHealth failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 tenant NVRs, got %d", len(results))
+	}
+	want := map[uuid.UUID]string{healthy: CredentialOK, broken: CredentialUndecryptable, bare: CredentialMissing}
+	for _, r := range results {
+		if r.Status != want[r.NVRID] {
+			t.Errorf("%s: expected %s, got %s", r.Name, want[r.NVRID], r.Status)
+		}
+	}
+
+	expectedAAD := tenantID.String() + ":" + healthy.String() + ":nvr_credential_v1"
+	if string(keyring.lastAAD) != expectedAAD {
+		t.Errorf("Expected AAD %s, got %s", expectedAAD, keyring.lastAAD)
+	}
+}
+
+// probeAdapter is an adapter with no native snapshot support; only the type
+// matters to probeChannel.
+type probeAdapter struct{ adapters.Adapter }
+
+type snapshotAdapter struct {
+	probeAdapter
+	refs []string
+}
+
+func (a *snapshotAdapter) ProbeSnapshot(_ context.Context, _ adapters.NvrTarget, _ adapters.NvrCredential, channelRef string) error {
+	a.refs = append(a.refs, channelRef)
+	return nil
+}
+
+// probeRecorder swaps the monitor's probe transports for recorders.
+func probeRecorder(m *NVRMonitor) (rtsp, snapshots *[]string) {
+	rtsp, snapshots = &[]string{}, &[]string{}
+	m.probeRTSP = func(_ context.Context, u string) error {
+		*rtsp = append(*rtsp, u)
+		return nil
+	}
+	m.probeSnapshotURL = func(_ context.Context, u string, _ adapters.NvrCredential) error {
+		*snapshots = append(*snapshots, u)
+		return nil
+	}
+	return rtsp, snapshots
+}
+
+func TestProbeChannel_RTSPMode(t *testing.T) {
+	m := NewMonitor(nil, &mockRepo{})
+	rtsp, snapshots := probeRecorder(m)
+	adapter := &snapshotAdapter{}
+	cred := adapters.NvrCredential{Username: "admin", Password: "pw"}
+	ch := &data.NVRChannel{ChannelRef: "1", RTSPMain: "rtsp://10.0.0.1:554/ch1",
+		Metadata: map[string]any{"snapshot_uri": "http://10.0.0.1/snap.jpg"}}
+
+	if err := m.probeChannel(context.Background(), HealthProbeRTSP, adapter, adapters.NvrTarget{}, cred, ch); err != nil {
+		t.Fatal(err)
+	}
+	if len(*rtsp) != 1 || (*rtsp)[0] != "rtsp://admin:pw@10.0.0.1:554/ch1" {
+		t.Errorf("Expected one RTSP probe with credentials, got %v", *rtsp)
+	}
+	if len(adapter.refs) != 0 || len(*snapshots) != 0 {
+		t.Error("RTSP mode must not fetch snapshots")
+	}
+}
+
+func TestProbeChannel_SnapshotMode(t *testing.T) {
+	m := NewMonitor(nil, &mockRepo{})
+	rtsp, snapshots := probeRecorder(m)
+	ch := &data.NVRChannel{ChannelRef: "3", RTSPMain: "rtsp://10.0.0.1:554/ch3",
+		Metadata: map[string]any{"snapshot_uri": "http://10.0.0.1/onvif/snapshot?ch=3"}}
+
+	// Adapter with a native snapshot endpoint wins.
+	native := &snapshotAdapter{}
+	if err := m.probeChannel(context.Background(), HealthProbeSnapshot, native, adapters.NvrTarget{}, adapters.NvrCredential{}, ch); err != nil {
+		t.Fatal(err)
+	}
+	if len(native.refs) != 1 || native.refs[0] != "3" {
+		t.Errorf("Expected adapter snapshot for channel 3, got %v", native.refs)
+	}
+
+	// Otherwise the ONVIF snapshot URI from the channel metadata.
+	if err := m.probeChannel(context.Background(), HealthProbeSnapshot, probeAdapter{}, adapters.NvrTarget{}, adapters.NvrCredential{}, ch); err != nil {
+		t.Fatal(err)
+	}
+	if len(*snapshots) != 1 || (*snapshots)[0] != "http://10.0.0.1/onvif/snapshot?ch=3" {
+		t.Errorf("Expected metadata snapshot URI probe, got %v", *snapshots)
+	}
+	if len(*rtsp) != 0 {
+		t.Errorf("Snapshot mode must not probe RTSP, got %v", *rtsp)
+	}
+
+	// Neither available.
+	ch.Metadata = nil
+	if err := m.probeChannel(context.Background(), HealthProbeSnapshot, probeAdapter{}, adapters.NvrTarget{}, adapters.NvrCredential{}, ch); !errors.Is(err, errNoSnapshotSource) {
+		t.Errorf("Expected errNoSnapshotSource, got %v", err)
+	}
+}
+
+func TestProbeMode_DefaultsToRTSP(t *testing.T) {
+	m := NewMonitor(nil, &mockRepo{})
+	known, legacy := uuid.New(), uuid.New()
+	m.probeModeCache.Store(known, HealthProbeSnapshot)
+	m.probeModeCache.Store(legacy, "")
+
+	if got := m.probeMode(known); got != HealthProbeSnapshot {
+		t.Errorf("Expected snapshot, got %s", got)
+	}
+	if got := m.probeMode(legacy); got != HealthProbeRTSP {
+		t.Errorf("Empty mode should fall back to rtsp, got %s", got)
+	}
+	if got := m.probeMode(uuid.New()); got != HealthProbeRTSP {
+		t.Errorf("Unknown NVR should fall back to rtsp, got %s", got)
+	}
+
+	for mode, ok := range map[string]bool{"": true, "rtsp": true, "snapshot": true, "http": false} {
+		if _, err := normalizeHealthProbeMode(mode); (err == nil) != ok || (!ok && !errors.Is(err, ErrInvalidHealthProbeMode)) {
+			t.Errorf("normalizeHealthProbeMode(%q) error = %v", mode, err)
+		}
+	}
+}
+
+// channelListAdapter reports a fixed channel list.
+type channelListAdapter struct {
+	probeAdapter
+	channels []adapters.NvrChannel
+}
+
+func (a *channelListAdapter) ListChannels(context.Context, adapters.NvrTarget, adapters.NvrCredential) ([]adapters.NvrChannel, error) {
+	return a.channels, nil
+}
+
+func TestDiscoverChannels_TruncatesAtCap(t *testing.T) {
+	adapter := &channelListAdapter{}
+	for i := 0; i < 10; i++ {
+		adapter.channels = append(adapter.channels, adapters.NvrChannel{ChannelRef: strconv.Itoa(i + 1), Name: "Camera " + strconv.Itoa(i+1)})
+	}
+	adapters.Register("test_many_channels", func(adapters.NvrTarget, adapters.NvrCredential) (adapters.Adapter, error) {
+		return adapter, nil
+	})
+
+	repo := &mockRepo{
+		nvrs:     make(map[uuid.UUID]*data.NVR),
+		creds:    make(map[uuid.UUID]*data.NVRCredential),
+		channels: make(map[uuid.UUID]*data.NVRChannel),
+	}
+	tenantID, nvrID := uuid.New(), uuid.New()
+	repo.nvrs[nvrID] = &data.NVR{ID: nvrID, TenantID: tenantID, Vendor: "test_many_channels", IPAddress: "10.0.0.1"}
+
+	svc := NewService(repo, nil, nil, nil)
+	svc.MaxChannelsPerNVR = 4
+
+	res, err := svc.DiscoverChannels(context.Background(), nvrID, tenantID)
+	if err != nil {
+		t.Fatalf("DiscoverChannels: %v", err)
+	}
+	if !res.Truncated || res.Reported != 10 || res.Count != 4 {
+		t.Errorf("Expected 4 of 10 channels stored and truncated, got %+v", res)
+	}
+	if len(repo.channels) != 4 {
+		t.Fatalf("Expected 4 channels stored, got %d", len(repo.channels))
+	}
+	for _, ch := range repo.channels {
+		if n, _ := strconv.Atoi(ch.ChannelRef); n < 1 || n > 4 {
+			t.Errorf("Expected only the first 4 channels, stored %s", ch.ChannelRef)
+		}
+	}
+
+	// Within the cap nothing is flagged
+	svc.MaxChannelsPerNVR = 10
+	if res, err = svc.DiscoverChannels(context.Background(), nvrID, tenantID); err != nil || res.Truncated || res.Count != 10 {
+		t.Errorf("Expected all 10 channels untruncated, got %+v (err %v)", res, err)
+	}
+}
+
+type recordingAuditor struct {
+	mu     sync.Mutex
+	events []audit.AuditEvent
+}
+
+func (a *recordingAuditor) WriteEvent(_ context.Context, evt audit.AuditEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, evt)
+	return nil
+}
+
+func TestBackgroundAudits_CarrySystemActor(t *testing.T) {
+	repo := &mockRepo{
+		nvrs:     make(map[uuid.UUID]*data.NVR),
+		channels: make(map[uuid.UUID]*data.NVRChannel),
+	}
+	nid, tenantA, tenantB := uuid.New(), uuid.New(), uuid.New()
+	repo.channels[uuid.New()] = &data.NVRChannel{TenantID: tenantA, NVRID: nid, ChannelRef: "ch1", ProvisionState: "created"}
+	repo.channels[uuid.New()] = &data.NVRChannel{TenantID: tenantA, NVRID: nid, ChannelRef: "ch2", ProvisionState: "created"}
+	repo.channels[uuid.New()] = &data.NVRChannel{TenantID: tenantB, NVRID: uuid.New(), ChannelRef: "ch1", ProvisionState: "created"}
+
+	aud := &recordingAuditor{}
+	svc := NewService(repo, nil, aud, nil)
+	svc.RunDiscoverySync(context.Background())
+	if n, err := svc.ReconcileOrphanedChannels(context.Background()); err != nil || n != 3 {
+		t.Fatalf("ReconcileOrphanedChannels: n=%d err=%v", n, err)
+	}
+
+	if len(aud.events) != 3 {
+		t.Fatalf("Expected 3 audit events, got %d", len(aud.events))
+	}
+	for _, evt := range aud.events {
+		if evt.ActorUserID == nil || *evt.ActorUserID != audit.DefaultSystemActorID {
+			t.Errorf("%s: expected system actor %s, got %v", evt.Action, audit.DefaultSystemActorID, evt.ActorUserID)
+		}
+	}
+
+	// Orphan releases are attributed to the owning tenant, never the nil tenant
+	counts := map[uuid.UUID]string{}
+	for _, evt := range aud.events[1:] {
+		if evt.Action != "nvr.channel.orphan_release" {
+			t.Fatalf("Unexpected action %s", evt.Action)
+		}
+		counts[evt.TenantID] = string(evt.Metadata)
+	}
+	if counts[tenantA] != `{"count":2}` || counts[tenantB] != `{"count":1}` {
+		t.Errorf("Expected per-tenant release events, got %v", counts)
+	}
+
+	// User-initiated actions keep the user as actor
+	userID := uuid.New()
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{UserID: userID.String()})
+	svc.audit(ctx, "nvr.update", uuid.New(), nid.String(), "success", nil)
+	if got := aud.events[3].ActorUserID; got == nil || *got != userID {
+		t.Errorf("Expected user actor %s, got %v", userID, got)
+	}
+}
+
+// gcmKeyring wraps DEKs with a real AES-GCM master key so AAD mismatches fail.
+type gcmKeyring struct{ key []byte }
+
+func (k gcmKeyring) WrapDEK(dek, aad []byte) (string, []byte, []byte, []byte, error) {
+	nonce, cipher, tag, err := crypto.EncryptGCM(k.key, dek, aad)
+	return "master-1", nonce, cipher, tag, err
+}
+func (k gcmKeyring) UnwrapDEK(kid string, nonce, ciphertext, tag, aad []byte) ([]byte, error) {
+	return crypto.DecryptGCM(k.key, nonce, ciphertext, tag, aad)
+}
+
+func TestCredentialAAD_V1DecryptsAfterV2(t *testing.T) {
+	tenantID, nvrID := uuid.New(), uuid.New()
+	repo := &mockRepo{
+		nvrs:  map[uuid.UUID]*data.NVR{nvrID: {ID: nvrID, TenantID: tenantID, Name: "nvr"}},
+		creds: make(map[uuid.UUID]*data.NVRCredential),
+	}
+	keyring := gcmKeyring{key: make([]byte, 32)}
+	svc := NewService(repo, keyring, nil, nil)
+
+	// Written before versioning: v1
+	if err := svc.SetCredentials(context.Background(), nvrID, tenantID, "admin", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if v := repo.creds[nvrID].AADVersion; v != CredentialAADv1 {
+		t.Fatalf("Expected v1 credential by default, got v%d", v)
+	}
+	repo.creds[nvrID].AADVersion = 0 // As read from a row predating the column default
+
+	svc.CredentialAADVersion = CredentialAADv2
+	user, pass, err := svc.GetCredentials(context.Background(), nvrID, tenantID)
+	if err != nil || user != "admin" || pass != "s3cret" {
+		t.Fatalf("v1 credential must still decrypt, got %q/%q (%v)", user, pass, err)
+	}
+	results, _ := svc.CredentialHealth(context.Background(), tenantID)
+	if len(results) != 1 || results[0].Status != CredentialOK {
+		t.Errorf("Expected v1 credential to be healthy, got %+v", results)
+	}
+}
+
+func TestMigrateCredentialAAD_RebindsToV2(t *testing.T) {
+	tenantID, nvrV1, nvrV2, bare := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	repo := &mockRepo{
+		nvrs: map[uuid.UUID]*data.NVR{
+			nvrV1: {ID: nvrV1, TenantID: tenantID, Name: "old"},
+			nvrV2: {ID: nvrV2, TenantID: tenantID, Name: "new"},
+			bare:  {ID: bare, TenantID: tenantID, Name: "bare"},
+		},
+		creds: make(map[uuid.UUID]*data.NVRCredential),
+	}
+	keyring := gcmKeyring{key: make([]byte, 32)}
+	svc := NewService(repo, keyring, nil, nil)
+	ctx := context.Background()
+
+	if err := svc.SetCredentials(ctx, nvrV1, tenantID, "old-user", "old-pass"); err != nil {
+		t.Fatal(err)
+	}
+	svc.CredentialAADVersion = CredentialAADv2
+	if err := svc.SetCredentials(ctx, nvrV2, tenantID, "new-user", "new-pass"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := svc.MigrateCredentialAAD(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("MigrateCredentialAAD: %v", err)
+	}
+	if *res != (CredentialAADMigration{TargetVersion: CredentialAADv2, Migrated: 1, Current: 1}) {
+		t.Errorf("Unexpected migration result %+v", *res)
+	}
+
+	after := repo.creds[nvrV1]
+	if after.AADVersion != CredentialAADv2 {
+		t.Fatalf("Expected credential re-bound to v2, got v%d", after.AADVersion)
+	}
+	v2AAD, _ := credentialAAD(CredentialAADv2, tenantID, nvrV1)
+	if _, err := keyring.UnwrapDEK(after.MasterKID, after.DekNonce, after.DekCiphertext, after.DekTag, v2AAD); err != nil {
+		t.Errorf("Migrated DEK must unwrap under the v2 AAD: %v", err)
+	}
+	v1AAD, _ := credentialAAD(CredentialAADv1, tenantID, nvrV1)
+	if _, err := keyring.UnwrapDEK(after.MasterKID, after.DekNonce, after.DekCiphertext, after.DekTag, v1AAD); err == nil {
+		t.Error("Migrated DEK must no longer unwrap under the v1 AAD")
+	}
+	if user, pass, err := svc.GetCredentials(ctx, nvrV1, tenantID); err != nil || user != "old-user" || pass != "old-pass" {
+		t.Errorf("Migrated credential must decrypt unchanged, got %q/%q (%v)", user, pass, err)
+	}
+
+	// Re-running is a no-op once everything is current
+	if res, _ := svc.MigrateCredentialAAD(ctx, tenantID); res.Migrated != 0 || res.Current != 2 {
+		t.Errorf("Expected idempotent re-run, got %+v", *res)
+	}
+}
+
+// fetchAdapter serves channel snapshots natively.
+type fetchAdapter struct {
+	probeAdapter
+	refs []string
+}
+
+func (a *fetchAdapter) FetchSnapshot(_ context.Context, _ adapters.NvrTarget, _ adapters.NvrCredential, channelRef string) ([]byte, error) {
+	a.refs = append(a.refs, channelRef)
+	return []byte("jpeg-" + channelRef), nil
+}
+
+func TestChannelSnapshot_RoutesNVRLinkedCameras(t *testing.T) {
+	fetcher := &fetchAdapter{}
+	adapters.Register("snapshot-test", func(adapters.NvrTarget, adapters.NvrCredential) (adapters.Adapter, error) {
+		return fetcher, nil
+	})
+	defer adapters.Unregister("snapshot-test")
+
+	tid, nid := uuid.New(), uuid.New()
+	nvrCam, vmsCam, direct := uuid.New(), uuid.New(), uuid.New()
+	ref := "4"
+	repo := &mockRepo{
+		nvrs: map[uuid.UUID]*data.NVR{nid: {ID: nid, TenantID: tid, Vendor: "snapshot-test"}},
+		links: map[uuid.UUID]*data.NVRLink{
+			nvrCam: {TenantID: tid, CameraID: nvrCam, NVRID: nid, NVRChannelRef: &ref, RecordingMode: "nvr", IsEnabled: true},
+			vmsCam: {TenantID: tid, CameraID: vmsCam, NVRID: nid, NVRChannelRef: &ref, RecordingMode: "vms", IsEnabled: true},
+		},
+		creds: map[uuid.UUID]*data.NVRCredential{},
+	}
+	svc := NewService(repo, &mockKeyring{}, nil, nil)
+
+	frame, routed, err := svc.ChannelSnapshot(context.Background(), nvrCam)
+	if err != nil || !routed || string(frame) != "jpeg-4" {
+		t.Fatalf("Expected adapter snapshot of channel 4, got %q routed=%v (%v)", frame, routed, err)
+	}
+	for _, cam := range []uuid.UUID{vmsCam, direct} {
+		if _, routed, err := svc.ChannelSnapshot(context.Background(), cam); routed || err != nil {
+			t.Errorf("Camera %s must use RTSP, got routed=%v (%v)", cam, routed, err)
+		}
+	}
+	if len(fetcher.refs) != 1 {
+		t.Errorf("Expected one adapter fetch, got %v", fetcher.refs)
+	}
+}
+
+func TestChannelSnapshot_FallsBackWithoutNativeSnapshot(t *testing.T) {
+	adapters.Register("nosnapshot-test", func(adapters.NvrTarget, adapters.NvrCredential) (adapters.Adapter, error) {
+		return probeAdapter{}, nil
+	})
+	defer adapters.Unregister("nosnapshot-test")
+
+	tid := uuid.New()
+	plain, unknown := uuid.New(), uuid.New()
+	plainCam, unknownCam := uuid.New(), uuid.New()
+	ref := "2"
+	repo := &mockRepo{
+		nvrs: map[uuid.UUID]*data.NVR{
+			plain:   {ID: plain, TenantID: tid, Vendor: "nosnapshot-test"},
+			unknown: {ID: unknown, TenantID: tid, Vendor: "no-such-vendor"},
+		},
+		links: map[uuid.UUID]*data.NVRLink{
+			plainCam:   {TenantID: tid, CameraID: plainCam, NVRID: plain, NVRChannelRef: &ref, RecordingMode: "nvr", IsEnabled: true},
+			unknownCam: {TenantID: tid, CameraID: unknownCam, NVRID: unknown, NVRChannelRef: &ref, RecordingMode: "nvr", IsEnabled: true},
+		},
+		creds: map[uuid.UUID]*data.NVRCredential{},
+	}
+	svc := NewService(repo, &mockKeyring{}, nil, nil)
+
+	for name, cam := range map[string]uuid.UUID{"no native snapshot": plainCam, "adapter lookup fails": unknownCam} {
+		if _, routed, err := svc.ChannelSnapshot(context.Background(), cam); routed || err != nil {
+			t.Errorf("%s: expected RTSP fallback, got routed=%v (%v)", name, routed, err)
+		}
+	}
+}
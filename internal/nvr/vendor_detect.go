@@ -0,0 +1,180 @@
+package nvr
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/technosupport/ts-vms/internal/data"
+	"github.com/technosupport/ts-vms/internal/discovery"
+)
+
+// Vendor values with special meaning on create.
+const (
+	VendorAuto    = "auto"    // Fingerprint the device to pick the vendor
+	VendorUnknown = "unknown" // Detection was inconclusive
+)
+
+// DefaultVendorDetectTimeout bounds the whole fingerprint probe.
+const DefaultVendorDetectTimeout = 5 * time.Second
+
+// VendorDetection is the outcome of an unauthenticated fingerprint probe.
+// Confidence is 0-1; an inconclusive probe reports VendorUnknown with 0.
+type VendorDetection struct {
+	Vendor     string  `json:"vendor"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source,omitempty"` // http_banner, http_body, onvif
+}
+
+// bannerSignature matches a lowercase fragment of an HTTP response.
+type bannerSignature struct {
+	vendor     string
+	fragment   string
+	confidence float64
+}
+
+// Server / WWW-Authenticate / Location header fragments are strong evidence;
+// page body fragments are weaker since login pages are often rebranded.
+var (
+	headerSignatures = []bannerSignature{
+		{"hikvision", "hikvision", 0.9},
+		{"hikvision", "app-webs", 0.9},
+		{"hikvision", "dnvrs-webs", 0.9},
+		{"hikvision", "doc/page/login.asp", 0.8}, // Redirect to the web UI
+		{"dahua", "dahua", 0.9},
+	}
+	bodySignatures = []bannerSignature{
+		{"hikvision", "hikvision", 0.7},
+		{"hikvision", "doc/page/login.asp", 0.7},
+		{"dahua", "dahua", 0.7},
+		{"dahua", "/rpc2_login", 0.7},
+	}
+)
+
+// Generic ONVIF answers rank below any vendor banner: a vendor adapter is
+// preferred whenever the device also identifies itself.
+const (
+	onvifKnownManufacturerConfidence = 0.95
+	onvifDeviceInfoConfidence        = 0.6
+	onvifFaultConfidence             = 0.4
+)
+
+// DetectVendor fingerprints the device at ip:port without credentials: the
+// HTTP banner of "/" (response headers, then the page body) and an ONVIF GetDeviceInformation on /onvif/device_service. The most
+// confident match wins; nothing conclusive yields VendorUnknown.
+func DetectVendor(ctx context.Context, ip string, port int) VendorDetection {
+	if port <= 0 {
+		port = 80
+	}
+	base := "http://" + net.JoinHostPort(ip, strconv.Itoa(port))
+
+	best := VendorDetection{Vendor: VendorUnknown}
+	for _, d := range []VendorDetection{probeHTTPBanner(ctx, base), probeONVIFDeviceInfo(ctx, base)} {
+		if d.Confidence > best.Confidence {
+			best = d
+		}
+	}
+	return best
+}
+
+func probeHTTPBanner(ctx context.Context, base string) VendorDetection {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/", nil)
+	if err != nil {
+		return VendorDetection{}
+	}
+	client := &http.Client{
+		Timeout: probeTimeout(ctx),
+		// Redirect targets on the device carry the same banner; stay on the first answer.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return VendorDetection{}
+	}
+	defer resp.Body.Close()
+
+	headers := strings.ToLower(resp.Header.Get("Server") + " " + resp.Header.Get("WWW-Authenticate") + " " + resp.Header.Get("Location"))
+	for _, sig := range headerSignatures {
+		if strings.Contains(headers, sig.fragment) {
+			return VendorDetection{Vendor: sig.vendor, Confidence: sig.confidence, Source: "http_banner"}
+		}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 16<<10))
+	page := strings.ToLower(string(body))
+	for _, sig := range bodySignatures {
+		if strings.Contains(page, sig.fragment) {
+			return VendorDetection{Vendor: sig.vendor, Confidence: sig.confidence, Source: "http_body"}
+		}
+	}
+	return VendorDetection{}
+}
+
+func probeONVIFDeviceInfo(ctx context.Context, base string) VendorDetection {
+	cli, err := discovery.NewOnvifClient(base+"/onvif/device_service", "", "")
+	if err != nil {
+		return VendorDetection{}
+	}
+	cli.HTTP.Timeout = probeTimeout(ctx)
+
+	info, err := cli.GetDeviceInformation(ctx)
+	if err != nil {
+		// A SOAP fault (typically "not authorized") still proves an ONVIF device
+		if strings.HasPrefix(err.Error(), "onvif error") && strings.Contains(err.Error(), "Envelope") {
+			return VendorDetection{Vendor: "onvif", Confidence: onvifFaultConfidence, Source: "onvif"}
+		}
+		return VendorDetection{}
+	}
+
+	if v := vendorFromManufacturer(info.Manufacturer); v != "" {
+		return VendorDetection{Vendor: v, Confidence: onvifKnownManufacturerConfidence, Source: "onvif"}
+	}
+	return VendorDetection{Vendor: "onvif", Confidence: onvifDeviceInfoConfidence, Source: "onvif"}
+}
+
+// probeTimeout is the per-request timeout for a probe: whatever is left of
+// ctx's deadline (the configured vendor-detect timeout when called from the
+// service), or DefaultVendorDetectTimeout when ctx has none.
+func probeTimeout(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); left > 0 {
+			return left
+		}
+		return time.Millisecond // Already expired; ctx fails the request
+	}
+	return DefaultVendorDetectTimeout
+}
+
+// vendorFromManufacturer maps an ONVIF manufacturer string to a vendor with a
+// dedicated adapter, or "" for anything else.
+func vendorFromManufacturer(m string) string {
+	m = strings.ToLower(m)
+	switch {
+	case strings.Contains(m, "hikvision"):
+		return "hikvision"
+	case strings.Contains(m, "dahua"):
+		return "dahua"
+	}
+	return ""
+}
+
+// detectVendorFor runs detection for nvr under the service timeout and
+// records the result on it.
+func (s *Service) detectVendorFor(ctx context.Context, nvr *data.NVR) VendorDetection {
+	timeout := s.VendorDetectTimeout
+	if timeout <= 0 {
+		timeout = DefaultVendorDetectTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	d := DetectVendor(ctx, nvr.IPAddress, nvr.Port)
+	nvr.Vendor = d.Vendor
+	confidence := d.Confidence
+	nvr.VendorConfidence = &confidence
+	return d
+}
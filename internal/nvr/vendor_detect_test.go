@@ -0,0 +1,130 @@
+package nvr
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/technosupport/ts-vms/internal/data"
+)
+
+// fingerprintServer starts a device stand-in and returns its ip and port.
+func fingerprintServer(t *testing.T, h http.HandlerFunc) (string, int) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	host, portStr, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+func TestDetectVendor(t *testing.T) {
+	cases := map[string]struct {
+		handler    http.HandlerFunc
+		wantVendor string
+	}{
+		"hikvision server banner": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Server", "App-webs/")
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			wantVendor: "hikvision",
+		},
+		"hikvision login redirect": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/" {
+					http.Redirect(w, r, "/doc/page/login.asp?_1700000000", http.StatusFound)
+					return
+				}
+				http.NotFound(w, r)
+			},
+			wantVendor: "hikvision",
+		},
+		"dahua via onvif manufacturer": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/onvif/device_service" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` +
+					`<tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"><tds:Manufacturer>Dahua</tds:Manufacturer></tds:GetDeviceInformationResponse>` +
+					`</s:Body></s:Envelope>`))
+			},
+			wantVendor: "dahua",
+		},
+		"unknown device": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte("<html><body>It works!</body></html>"))
+			},
+			wantVendor: VendorUnknown,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ip, port := fingerprintServer(t, tc.handler)
+			d := DetectVendor(context.Background(), ip, port)
+			if d.Vendor != tc.wantVendor {
+				t.Errorf("Expected %s, got %+v", tc.wantVendor, d)
+			}
+			if tc.wantVendor == VendorUnknown && d.Confidence != 0 {
+				t.Errorf("Expected zero confidence for unknown, got %v", d.Confidence)
+			}
+			if tc.wantVendor != VendorUnknown && (d.Confidence <= 0 || d.Confidence > 1) {
+				t.Errorf("Expected confidence in (0,1], got %v", d.Confidence)
+			}
+		})
+	}
+}
+
+func TestCreateNVR_AutoVendor(t *testing.T) {
+	ip, port := fingerprintServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "DNVRS-Webs")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	repo := &mockRepo{nvrs: make(map[uuid.UUID]*data.NVR)}
+	svc := NewService(repo, nil, nil, nil)
+
+	n := &data.NVR{ID: uuid.New(), TenantID: uuid.New(), Name: "Lobby NVR", Vendor: VendorAuto, IPAddress: ip, Port: port}
+	if err := svc.CreateNVR(context.Background(), n); err != nil {
+		t.Fatalf("CreateNVR: %v", err)
+	}
+	stored := repo.nvrs[n.ID]
+	if stored.Vendor != "hikvision" {
+		t.Errorf("Expected hikvision, got %s", stored.Vendor)
+	}
+	if stored.VendorConfidence == nil || *stored.VendorConfidence <= 0 {
+		t.Errorf("Expected a stored detection confidence, got %v", stored.VendorConfidence)
+	}
+
+	// An explicit vendor carries no confidence
+	manual := &data.NVR{ID: uuid.New(), TenantID: n.TenantID, Name: "Yard NVR", Vendor: "dahua", IPAddress: ip, Port: port}
+	if err := svc.CreateNVR(context.Background(), manual); err != nil {
+		t.Fatalf("CreateNVR: %v", err)
+	}
+	if manual.VendorConfidence != nil {
+		t.Errorf("Expected no confidence for an operator-chosen vendor, got %v", *manual.VendorConfidence)
+	}
+}
+
+func TestProbeTimeout_FollowsContextDeadline(t *testing.T) {
+	if got := probeTimeout(context.Background()); got != DefaultVendorDetectTimeout {
+		t.Errorf("Expected the default without a deadline, got %s", got)
+	}
+
+	// A configured timeout longer than the default must not be cut short
+	ctx, cancel := context.WithTimeout(context.Background(), 3*DefaultVendorDetectTimeout)
+	defer cancel()
+	if got := probeTimeout(ctx); got <= DefaultVendorDetectTimeout {
+		t.Errorf("Expected the context's %s budget, got %s", 3*DefaultVendorDetectTimeout, got)
+	}
+}
@@ -3,6 +3,7 @@ package tokens
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -25,8 +26,32 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// DefaultIssuer is the iss claim minted by the control plane.
+const DefaultIssuer = "ts-vms"
+
+// Audiences of the services that accept access tokens. A token is only
+// honoured by the service named in its aud claim.
+const (
+	AudienceControlPlane = "ts-vms-api"
+	AudienceHLS          = "ts-vms-hlsd"
+)
+
+// DefaultLegacyClaimsGrace covers the 7-day refresh lifetime of sessions
+// opened before iss/aud were minted.
+const DefaultLegacyClaimsGrace = 7 * 24 * time.Hour
+
+// Manager mints and verifies HS256 tokens. Issuer and Audience are stamped
+// on minted tokens and, when set, required on verified ones; a manager with
+// an empty Audience accepts any aud (internal service tokens).
 type Manager struct {
 	signingKey []byte
+	Issuer     string
+	Audience   string
+
+	// LegacyClaimsUntil is the end of the upgrade transition: before it, a
+	// token carrying no iss (or no aud) passes that check. A wrong, non-empty
+	// claim is always rejected.
+	LegacyClaimsUntil time.Time
 }
 
 func NewManager(signingKey string) *Manager {
@@ -37,11 +62,21 @@ func (m *Manager) GenerateAccessToken(userID, tenantID string) (string, error) {
 	return m.generateToken(userID, tenantID, Access, 15*time.Minute)
 }
 
+// GenerateAccessTokenFor mints an access token for another service, e.g.
+// AudienceHLS for hlsd.
+func (m *Manager) GenerateAccessTokenFor(userID, tenantID, audience string) (string, error) {
+	return m.mint(userID, tenantID, Access, 15*time.Minute, audience)
+}
+
 func (m *Manager) GenerateRefreshToken(userID, tenantID string) (string, error) {
 	return m.generateToken(userID, tenantID, Refresh, 7*24*time.Hour)
 }
 
 func (m *Manager) generateToken(userID, tenantID string, tokenType TokenType, duration time.Duration) (string, error) {
+	return m.mint(userID, tenantID, tokenType, duration, m.Audience)
+}
+
+func (m *Manager) mint(userID, tenantID string, tokenType TokenType, duration time.Duration, audience string) (string, error) {
 	now := time.Now().UTC()
 	claims := Claims{
 		TenantID:  tenantID,
@@ -53,8 +88,12 @@ func (m *Manager) generateToken(userID, tenantID string, tokenType TokenType, du
 			NotBefore: jwt.NewNumericDate(now),
 			ID:        uuid.New().String(), // jti
 			Subject:   userID,
+			Issuer:    m.Issuer,
 		},
 	}
+	if audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	// Add Kid for future key rotation support, even if using single key now
 	token.Header["kid"] = "v1"
@@ -62,6 +101,9 @@ func (m *Manager) generateToken(userID, tenantID string, tokenType TokenType, du
 	return token.SignedString(m.signingKey)
 }
 
+// ValidateToken verifies the signature and time claims, plus iss and aud
+// when the manager has them configured. A wrong audience surfaces as
+// jwt.ErrTokenInvalidAudience.
 func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -75,9 +117,17 @@ func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
-	return nil, errors.New("invalid token")
+	legacy := time.Now().Before(m.LegacyClaimsUntil)
+	if m.Issuer != "" && claims.Issuer != m.Issuer && !(legacy && claims.Issuer == "") {
+		return nil, jwt.ErrTokenInvalidIssuer
+	}
+	if m.Audience != "" && !slices.Contains(claims.Audience, m.Audience) && !(legacy && len(claims.Audience) == 0) {
+		return nil, jwt.ErrTokenInvalidAudience
+	}
+	return claims, nil
 }
@@ -1,8 +1,11 @@
 package tokens_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/technosupport/ts-vms/internal/tokens"
 )
 
@@ -42,3 +45,76 @@ func TestInvalidSignature(t *testing.T) {
 		t.Error("Expected validation error for wrong signature")
 	}
 }
+
+func TestAudienceAndIssuer(t *testing.T) {
+	api := tokens.NewManager("secret")
+	api.Issuer, api.Audience = tokens.DefaultIssuer, tokens.AudienceControlPlane
+	hls := tokens.NewManager("secret")
+	hls.Issuer, hls.Audience = tokens.DefaultIssuer, tokens.AudienceHLS
+
+	token, _ := api.GenerateAccessToken("u1", "t1")
+	claims, err := api.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Expected matching audience to pass: %v", err)
+	}
+	if claims.Issuer != tokens.DefaultIssuer || len(claims.Audience) != 1 || claims.Audience[0] != tokens.AudienceControlPlane {
+		t.Errorf("Expected iss/aud to be minted, got %q %v", claims.Issuer, claims.Audience)
+	}
+
+	if _, err := hls.ValidateToken(token); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Errorf("Expected audience mismatch, got %v", err)
+	}
+
+	other := tokens.NewManager("secret")
+	other.Issuer, other.Audience = "someone-else", tokens.AudienceControlPlane
+	if _, err := other.ValidateToken(token); !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Errorf("Expected issuer mismatch, got %v", err)
+	}
+
+	// Tokens without aud are rejected once an audience is expected
+	legacy, _ := tokens.NewManager("secret").GenerateAccessToken("u1", "t1")
+	if _, err := api.ValidateToken(legacy); err == nil {
+		t.Error("Expected a token without aud to be rejected")
+	}
+}
+
+func TestLegacyClaimsGrace(t *testing.T) {
+	api := tokens.NewManager("secret")
+	api.Issuer, api.Audience = tokens.DefaultIssuer, tokens.AudienceControlPlane
+	api.LegacyClaimsUntil = time.Now().Add(time.Hour)
+
+	legacy, _ := tokens.NewManager("secret").GenerateAccessToken("u1", "t1")
+	if _, err := api.ValidateToken(legacy); err != nil {
+		t.Errorf("Expected a token without iss/aud to pass during the transition: %v", err)
+	}
+
+	// The window never excuses a wrong audience
+	hlsToken, _ := api.GenerateAccessTokenFor("u1", "t1", tokens.AudienceHLS)
+	if _, err := api.ValidateToken(hlsToken); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Errorf("Expected audience mismatch, got %v", err)
+	}
+
+	api.LegacyClaimsUntil = time.Now().Add(-time.Second)
+	if _, err := api.ValidateToken(legacy); err == nil {
+		t.Error("Expected a token without aud to be rejected after the transition")
+	}
+}
+
+func TestGenerateAccessTokenFor(t *testing.T) {
+	api := tokens.NewManager("secret")
+	api.Issuer, api.Audience = tokens.DefaultIssuer, tokens.AudienceControlPlane
+	hls := tokens.NewManager("secret")
+	hls.Issuer, hls.Audience = tokens.DefaultIssuer, tokens.AudienceHLS
+
+	token, err := api.GenerateAccessTokenFor("u1", "t1", tokens.AudienceHLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := hls.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Expected hlsd to accept the minted token: %v", err)
+	}
+	if claims.TokenType != tokens.Access || claims.UserID != "u1" {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/technosupport/ts-vms/internal/api"
@@ -213,6 +215,63 @@ func TestDAO_GetByEmail_DeletedReuse(t *testing.T) {
 	}
 }
 
+func TestDAO_ListFiltered_EmailAndDisabled(t *testing.T) {
+	db := getTestDB(t)
+	repo := data.UserModel{DB: db}
+	tid := uuid.New()
+	tag := uuid.NewString()[:8]
+	active := &data.User{TenantID: tid, Email: "ops-" + tag + "@a.com"}
+	disabled := &data.User{TenantID: tid, Email: "ops-" + tag + "@b.com", IsDisabled: true}
+	deleted := &data.User{TenantID: tid, Email: "ops-" + tag + "@c.com"}
+	for _, u := range []*data.User{active, disabled, deleted} {
+		if err := repo.Create(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo.SoftDelete(context.Background(), deleted.ID)
+
+	list, total, err := repo.ListFiltered(context.Background(), tid, data.UserFilter{Email: "OPS-" + tag}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(list) != 2 {
+		t.Fatalf("Expected 2 non-deleted matches, got total=%d len=%d", total, len(list))
+	}
+	for _, u := range list {
+		if u.ID == deleted.ID {
+			t.Error("Soft-deleted user must be excluded")
+		}
+	}
+
+	off := false
+	list, total, _ = repo.ListFiltered(context.Background(), tid, data.UserFilter{Email: tag, IsDisabled: &off}, 10, 0)
+	if total != 1 || list[0].ID != active.ID {
+		t.Errorf("Expected only the active user, got total=%d", total)
+	}
+}
+
+func TestDAO_ListFiltered_Role(t *testing.T) {
+	db := getTestDB(t)
+	repo := data.UserModel{DB: db}
+	tid := uuid.New()
+	withRole := &data.User{TenantID: tid, Email: uuid.NewString() + "@r.com"}
+	without := &data.User{TenantID: tid, Email: uuid.NewString() + "@r.com"}
+	repo.Create(context.Background(), withRole)
+	repo.Create(context.Background(), without)
+	rid := uuid.New()
+	if err := repo.AssignRole(context.Background(), withRole.ID, rid, tid, "tenant"); err != nil {
+		t.Skipf("Role fixture unavailable: %v", err)
+	}
+
+	list, total, err := repo.ListFiltered(context.Background(), tid, data.UserFilter{RoleID: &rid}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || list[0].ID != withRole.ID {
+		t.Errorf("Expected only the role holder, got total=%d", total)
+	}
+}
+
 // --- Service Tests ---
 
 func TestService_CreateUser_HashesPassword(t *testing.T) {
@@ -268,6 +327,58 @@ func TestHandler_DisableUser_SelfProtection(t *testing.T) {
 	}
 }
 
+func TestHandler_ListUsers_Filters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tid, rid := uuid.New(), uuid.New()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM users u WHERE u.tenant_id = \$1 AND u.deleted_at IS NULL AND u.email ILIKE .* AND u.is_disabled = \$3 AND EXISTS \(SELECT 1 FROM user_roles`).
+		WithArgs(tid, "ops", true, rid).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	mock.ExpectQuery(`SELECT u.id, u.tenant_id, u.email`).
+		WithArgs(tid, "ops", true, rid, 2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "email", "display_name", "is_disabled", "created_at", "updated_at"}).
+			AddRow(uuid.New(), tid, "ops@test.com", "Ops", true, time.Now(), time.Now()))
+
+	handler := &api.UserHandler{Service: users.NewService(&data.UserModel{DB: db}, nil, nil, nil)}
+	req := httptest.NewRequest("GET", "/api/v1/users?email=ops&is_disabled=true&role_id="+rid.String()+"&limit=2&offset=4", nil)
+	req = req.WithContext(middleware.WithAuthContext(req.Context(), &middleware.AuthContext{UserID: uuid.NewString(), TenantID: tid.String()}))
+	rr := httptest.NewRecorder()
+	handler.ListUsers(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	var resp struct {
+		Data []api.UserListItem `json:"data"`
+		Meta map[string]int     `json:"meta"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Meta["total"] != 7 || len(resp.Data) != 1 || resp.Data[0].Email != "ops@test.com" {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+	if bytes.Contains(rr.Body.Bytes(), []byte("password")) {
+		t.Error("List response must not include password fields")
+	}
+}
+
+func TestHandler_ListUsers_InvalidFilter(t *testing.T) {
+	handler := &api.UserHandler{}
+	for _, q := range []string{"is_disabled=maybe", "role_id=admin"} {
+		rr := httptest.NewRecorder()
+		handler.ListUsers(rr, withMockAuth(httptest.NewRequest("GET", "/api/v1/users?"+q, nil)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rr.Code)
+		}
+	}
+}
+
 func TestHandler_CreateUser_Validation(t *testing.T) {
 	handler := &api.UserHandler{}
 	rr := httptest.NewRecorder()
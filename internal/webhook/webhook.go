@@ -0,0 +1,235 @@
+// Package webhook delivers signed JSON events to an external HTTP endpoint
+// with retries. Deliveries are queued and sent by a background worker so
+// callers never block on the receiver.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Headers set on every delivery.
+const (
+	HeaderEvent     = "X-VMS-Event"
+	HeaderDelivery  = "X-VMS-Delivery"
+	HeaderTimestamp = "X-VMS-Timestamp"
+	HeaderSignature = "X-VMS-Signature"
+)
+
+const (
+	DefaultTimeout        = 10 * time.Second
+	DefaultMaxAttempts    = 5
+	DefaultInitialBackoff = 2 * time.Second
+	DefaultQueueSize      = 1000
+
+	// maxBackoff caps the doubling delay between attempts.
+	maxBackoff = 5 * time.Minute
+)
+
+type Config struct {
+	URL            string
+	Secret         string
+	Timeout        time.Duration
+	MaxAttempts    int
+	InitialBackoff time.Duration // Doubles after each failed attempt
+	QueueSize      int
+}
+
+// Event is the JSON body of a delivery.
+type Event struct {
+	ID         uuid.UUID `json:"id"`
+	Type       string    `json:"type"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// permanentError marks a response that retrying will not fix (4xx other
+// than 408/429).
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+// Sign returns the X-VMS-Signature value for body sent at timestamp ts:
+// "sha256=" + hex(HMAC-SHA256(secret, ts + "." + body)). Receivers recompute
+// it and compare with hmac.Equal.
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type Dispatcher struct {
+	cfg    Config
+	client *http.Client
+
+	// sleep waits between attempts; tests replace it.
+	sleep func(ctx context.Context, d time.Duration) error
+
+	queue    chan Event
+	stopOnce sync.Once
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewDispatcher(cfg Config) *Dispatcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = DefaultInitialBackoff
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	return &Dispatcher{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		sleep:    sleepCtx,
+		queue:    make(chan Event, cfg.QueueSize),
+		stopChan: make(chan struct{}),
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Start runs the delivery worker.
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go d.run()
+}
+
+// Stop abandons pending retries and waits for the worker to exit.
+// Events still queued are dropped.
+func (d *Dispatcher) Stop() {
+	d.stopOnce.Do(func() { close(d.stopChan) })
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-d.stopChan
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-d.stopChan:
+			return
+		case evt := <-d.queue:
+			if err := d.Deliver(ctx, evt); err != nil {
+				log.Printf("[ERROR] Webhook: %s %s not delivered: %v", evt.Type, evt.ID, err)
+			}
+		}
+	}
+}
+
+// Enqueue queues evt for delivery, filling in ID and OccurredAt when unset.
+// It never blocks: when the queue is full the event is dropped and false
+// is returned.
+func (d *Dispatcher) Enqueue(evt Event) bool {
+	if evt.ID == uuid.Nil {
+		evt.ID = uuid.New()
+	}
+	if evt.OccurredAt.IsZero() {
+		evt.OccurredAt = time.Now().UTC()
+	}
+	select {
+	case d.queue <- evt:
+		return true
+	default:
+		log.Printf("[WARN] Webhook: queue full, dropped %s %s", evt.Type, evt.ID)
+		return false
+	}
+}
+
+// Deliver POSTs evt, retrying network errors, 408, 429 and 5xx responses up
+// to MaxAttempts with exponential backoff. The delivery ID and body are the
+// same on every attempt so receivers can de-duplicate.
+func (d *Dispatcher) Deliver(ctx context.Context, evt Event) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	backoff := d.cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err = d.post(ctx, evt, body)
+		var perm *permanentError
+		if err == nil || errors.As(err, &perm) || attempt >= d.cfg.MaxAttempts {
+			if err != nil {
+				return fmt.Errorf("attempt %d: %w", attempt, err)
+			}
+			return nil
+		}
+		if serr := d.sleep(ctx, backoff); serr != nil {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, evt Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, evt.Type)
+	req.Header.Set(HeaderDelivery, evt.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	if d.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(d.cfg.Secret, ts, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return &permanentError{fmt.Errorf("webhook rejected with status %d", resp.StatusCode)}
+	}
+}
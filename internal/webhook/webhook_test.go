@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDeliver_RetriesUntilSuccess(t *testing.T) {
+	var attempts int32
+	var deliveries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries = append(deliveries, r.Header.Get(HeaderDelivery))
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(Config{URL: srv.URL, Secret: "s3cret", MaxAttempts: 5, InitialBackoff: time.Second})
+	var waits []time.Duration
+	d.sleep = func(ctx context.Context, wait time.Duration) error {
+		waits = append(waits, wait)
+		return nil
+	}
+
+	evt := Event{ID: uuid.New(), Type: "camera.created", TenantID: uuid.New(), OccurredAt: time.Now()}
+	if err := d.Deliver(context.Background(), evt); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+		t.Errorf("Expected backoff [1s 2s], got %v", waits)
+	}
+	for _, id := range deliveries {
+		if id != evt.ID.String() {
+			t.Errorf("Expected the same delivery id on every attempt, got %s", id)
+		}
+	}
+}
+
+func TestDeliver_GivesUp(t *testing.T) {
+	cases := map[string]struct {
+		status       int
+		wantAttempts int32
+	}{
+		"server error retried to the limit": {http.StatusInternalServerError, 3},
+		"client error not retried":          {http.StatusBadRequest, 1},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			d := NewDispatcher(Config{URL: srv.URL, MaxAttempts: 3})
+			d.sleep = func(context.Context, time.Duration) error { return nil }
+			if err := d.Deliver(context.Background(), Event{ID: uuid.New(), Type: "camera.updated"}); err == nil {
+				t.Fatal("Expected an error")
+			}
+			if attempts != tc.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tc.wantAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestDeliver_Signed(t *testing.T) {
+	got := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		got <- r
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(Config{URL: srv.URL, Secret: "s3cret"})
+	if err := d.Deliver(context.Background(), Event{ID: uuid.New(), Type: "camera.deleted"}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	r := <-got
+	ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("Bad timestamp header: %v", err)
+	}
+	if want := Sign("s3cret", ts, body); r.Header.Get(HeaderSignature) != want {
+		t.Errorf("Signature mismatch: got %s want %s", r.Header.Get(HeaderSignature), want)
+	}
+	if r.Header.Get(HeaderEvent) != "camera.deleted" {
+		t.Errorf("Expected event header camera.deleted, got %q", r.Header.Get(HeaderEvent))
+	}
+}
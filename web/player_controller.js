@@ -73,6 +73,14 @@ class PlayerController {
         this._cleanupWebRTC();
         this._cleanupHLS();
         this._sendTelemetry(EVENTS.SESSION_END);
+        if (this.session) {
+            // Frees the live slot and records camera.live.stop where views are audited
+            fetch(`/api/v1/live/${this.session.viewer_session_id}/stop`, {
+                method: 'POST',
+                headers: { 'Authorization': `Bearer ${this.config.token}` },
+                keepalive: true
+            }).catch(() => {});
+        }
         this.session = null;
     }
 
@@ -269,7 +277,10 @@ class PlayerController {
         }
         // HLS.js (MSE) Check
         else if (this.config.hlsCtor && this.config.hlsCtor.isSupported()) {
-            this.hls = new this.config.hlsCtor();
+            // Hold back as many segments as the tenant's target latency allows
+            this.hls = new this.config.hlsCtor({
+                liveSyncDurationCount: this.session.hls.live_sync_segments || 3,
+            });
             this.hls.loadSource(url);
             this.hls.attachMedia(this.video);
             this.hls.on(this.config.hlsCtor.Events.MANIFEST_PARSED, () => {